@@ -0,0 +1,83 @@
+package admission
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/admission"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	"github.com/spf13/cobra"
+)
+
+var admissionCmdExamples = fmt.Sprintf(`
+  admission command evaluates the Kubescape controls, Rego and CEL alike, on the objects sent to the Kubernetes API server.
+
+  # Serve a validating webhook for the NSA framework, denying privileged containers and warning about the rest
+  %[1]s admission serve --frameworks nsa --tls-cert-file tls.crt --tls-key-file tls.key --enforce C-0057
+
+  # Replay saved AdmissionReviews and print the responses
+  %[1]s admission replay review.json --frameworks nsa --enforce C-0057
+`, cautils.ExecName())
+
+// admissionFlags holds the flags shared by the admission subcommands.
+type admissionFlags struct {
+	frameworks    string
+	admissionInfo metav1.AdmissionInfo
+	scanInfo      cautils.ScanInfo
+}
+
+func GetAdmissionCmd(ks meta.IKubescape) *cobra.Command {
+	var flags admissionFlags
+
+	admissionCmd := &cobra.Command{
+		Use:     "admission",
+		Short:   "Evaluate Kubescape controls at admission time",
+		Long:    `Run the selected frameworks' Rego and CEL controls on every object the API server sends to a validating webhook. The related objects the controls need are read from informer caches, SecurityException objects in the cluster are honored, and each failed control denies, warns about or audits the request according to its mode.`,
+		Example: admissionCmdExamples,
+	}
+
+	admissionCmd.PersistentFlags().StringVar(&flags.frameworks, "frameworks", "", "Frameworks to evaluate, e.g: --frameworks nsa,mitre. Defaults to all frameworks")
+	admissionCmd.PersistentFlags().StringVar(&flags.admissionInfo.DefaultMode, "default-mode", string(admission.ModeWarn), `Mode of the controls not given to --enforce, --warn or --audit: "enforce", "warn" or "audit"`)
+	admissionCmd.PersistentFlags().StringSliceVar(&flags.admissionInfo.Enforce, "enforce", nil, "Controls whose failure denies the request, e.g: --enforce C-0057,C-0016")
+	admissionCmd.PersistentFlags().StringSliceVar(&flags.admissionInfo.Warn, "warn", nil, "Controls whose failure admits the request with a warning")
+	admissionCmd.PersistentFlags().StringSliceVar(&flags.admissionInfo.Audit, "audit", nil, "Controls whose failure is only recorded in the audit log")
+	admissionCmd.PersistentFlags().BoolVar(&flags.admissionInfo.FailClosed, "fail-closed", false, "Deny the requests that cannot be evaluated instead of admitting them with a warning")
+	admissionCmd.PersistentFlags().StringVar(&flags.scanInfo.ControlsInputs, "controls-config", "", "Path to an controls-config obj. If not set will download controls-config from ARMO management portal")
+	admissionCmd.PersistentFlags().StringVar(&flags.scanInfo.UseExceptions, "exceptions", "", "Path to an exceptions obj. If not set will download exceptions from ARMO management portal")
+	admissionCmd.PersistentFlags().StringVar(&flags.scanInfo.UseArtifactsFrom, "use-artifacts-from", "", "Load artifacts from local directory. If not used will download them")
+
+	admissionCmd.AddCommand(getServeCmd(ks, &flags))
+	admissionCmd.AddCommand(getReplayCmd(ks, &flags))
+
+	return admissionCmd
+}
+
+// policyIdentifiers validates the shared flags and returns the frameworks to
+// evaluate, setting up flags.scanInfo for them.
+func (flags *admissionFlags) policyIdentifiers() ([]cautils.PolicyIdentifier, error) {
+	if _, err := admission.NewModes(flags.admissionInfo.DefaultMode, nil, nil, nil); err != nil {
+		return nil, err
+	}
+
+	flags.scanInfo.FrameworkScan = true
+	var frameworks []string
+	if flags.frameworks == "" {
+		flags.scanInfo.ScanAll = true
+	} else {
+		frameworks = strings.Split(flags.frameworks, ",")
+		if slices.Contains(frameworks, "") {
+			return nil, fmt.Errorf("usage: --frameworks <framework-0>,<framework-1>")
+		}
+		if slices.Contains(frameworks, "all") {
+			flags.scanInfo.ScanAll = true
+			frameworks = getter.NativeFrameworks
+		}
+	}
+	flags.scanInfo.SetScanType(cautils.ScanTypeFramework)
+	return cautils.BuildPolicyIdentifiers(frameworks, apisv1.KindFramework), nil
+}
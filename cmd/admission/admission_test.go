@@ -0,0 +1,85 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKubescape records the arguments ServeAdmission is called with.
+type stubKubescape struct {
+	mocks.MockIKubescape
+	admissionInfo     *metav1.AdmissionInfo
+	scanInfo          *cautils.ScanInfo
+	policyIdentifiers []cautils.PolicyIdentifier
+}
+
+func (s *stubKubescape) ServeAdmission(_ context.Context, admissionInfo *metav1.AdmissionInfo, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) error {
+	s.admissionInfo = admissionInfo
+	s.scanInfo = scanInfo
+	s.policyIdentifiers = policyIdentifiers
+	return nil
+}
+
+func TestServeCmd(t *testing.T) {
+	ks := &stubKubescape{}
+	admissionCmd := GetAdmissionCmd(ks)
+	admissionCmd.SetArgs([]string{"serve", "--frameworks", "nsa,mitre", "--tls-cert-file", "tls.crt", "--tls-key-file", "tls.key", "--enforce", "C-0057,C-0016", "--audit", "C-0017", "--fail-closed"})
+
+	require.NoError(t, admissionCmd.Execute())
+	assert.Equal(t, &metav1.AdmissionInfo{
+		Addr:        ":8443",
+		CertFile:    "tls.crt",
+		KeyFile:     "tls.key",
+		DefaultMode: "warn",
+		Enforce:     []string{"C-0057", "C-0016"},
+		Audit:       []string{"C-0017"},
+		FailClosed:  true,
+	}, ks.admissionInfo)
+	assert.True(t, ks.scanInfo.FrameworkScan)
+	assert.False(t, ks.scanInfo.ScanAll)
+	require.Len(t, ks.policyIdentifiers, 2)
+	assert.Equal(t, "nsa", ks.policyIdentifiers[0].Identifier)
+	assert.Equal(t, "mitre", ks.policyIdentifiers[1].Identifier)
+}
+
+func TestReplayCmd(t *testing.T) {
+	ks := &stubKubescape{}
+	admissionCmd := GetAdmissionCmd(ks)
+	admissionCmd.SetArgs([]string{"replay", "a.json", "b.json", "--default-mode", "audit"})
+
+	require.NoError(t, admissionCmd.Execute())
+	assert.Equal(t, []string{"a.json", "b.json"}, ks.admissionInfo.ReplayFiles)
+	assert.Equal(t, "audit", ks.admissionInfo.DefaultMode)
+	assert.True(t, ks.scanInfo.ScanAll, "all frameworks are evaluated by default")
+}
+
+func TestAdmissionCmd_RejectsBadArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "unknown mode", args: []string{"replay", "a.json", "--default-mode", "deny"}, wantError: `invalid admission mode "deny"`},
+		{name: "empty framework", args: []string{"replay", "a.json", "--frameworks", "nsa,"}, wantError: "usage: --frameworks <framework-0>,<framework-1>"},
+		{name: "no review", args: []string{"replay"}, wantError: "requires at least 1 arg(s)"},
+		{name: "no certificate", args: []string{"serve"}, wantError: `required flag(s) "tls-cert-file", "tls-key-file" not set`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := &stubKubescape{}
+			admissionCmd := GetAdmissionCmd(ks)
+			admissionCmd.SetArgs(tt.args)
+			admissionCmd.SilenceUsage = true
+			admissionCmd.SilenceErrors = true
+
+			require.ErrorContains(t, admissionCmd.Execute(), tt.wantError)
+			assert.Nil(t, ks.admissionInfo)
+		})
+	}
+}
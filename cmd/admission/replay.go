@@ -0,0 +1,30 @@
+package admission
+
+import (
+	"fmt"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	"github.com/spf13/cobra"
+)
+
+func getReplayCmd(ks meta.IKubescape, flags *admissionFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay <review.json>...",
+		Short: "Answer saved AdmissionReviews and print the responses",
+		Long:  "Evaluate the AdmissionReview JSON files the way the webhook would, against the current cluster, and print each review with its response as one JSON line. Use it to try modes and frameworks on recorded requests before serving them.",
+		Example: fmt.Sprintf(`
+  # Check which saved requests the webhook would deny
+  %[1]s admission replay reviews/*.json --enforce C-0057,C-0016
+`, cautils.ExecName()),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policyIdentifiers, err := flags.policyIdentifiers()
+			if err != nil {
+				return err
+			}
+			flags.admissionInfo.ReplayFiles = args
+			return ks.ServeAdmission(ks.Context(), &flags.admissionInfo, &flags.scanInfo, policyIdentifiers)
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package admission
+
+import (
+	"fmt"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	"github.com/kubescape/kubescape/v4/core/pkg/admission"
+	"github.com/spf13/cobra"
+)
+
+func getServeCmd(ks meta.IKubescape, flags *admissionFlags) *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a validating admission webhook",
+		Long:  fmt.Sprintf("Serve AdmissionReviews over TLS at %s, with a /healthz probe. Point a ValidatingWebhookConfiguration at the service exposing it.", admission.ValidatePath),
+		Example: fmt.Sprintf(`
+  # Serve on port 8443, denying privileged containers and warning about the other failed controls
+  %[1]s admission serve --tls-cert-file /certs/tls.crt --tls-key-file /certs/tls.key --enforce C-0057
+`, cautils.ExecName()),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policyIdentifiers, err := flags.policyIdentifiers()
+			if err != nil {
+				return err
+			}
+			return ks.ServeAdmission(ks.Context(), &flags.admissionInfo, &flags.scanInfo, policyIdentifiers)
+		},
+	}
+
+	serveCmd.Flags().StringVar(&flags.admissionInfo.Addr, "addr", ":8443", "Address to listen on")
+	serveCmd.Flags().StringVar(&flags.admissionInfo.CertFile, "tls-cert-file", "", "TLS certificate served to the API server")
+	serveCmd.Flags().StringVar(&flags.admissionInfo.KeyFile, "tls-key-file", "", "TLS private key of --tls-cert-file")
+	_ = serveCmd.MarkFlagRequired("tls-cert-file")
+	_ = serveCmd.MarkFlagRequired("tls-key-file")
+
+	return serveCmd
+}
@@ -4,16 +4,33 @@ import (
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/pkg/imagecache"
+	"github.com/kubescape/kubescape/v4/core/pkg/rulecache"
 	"github.com/kubescape/kubescape/v4/core/pkg/scancache"
 	"github.com/spf13/cobra"
 )
 
 func getDeleteCacheCmd() *cobra.Command {
-	return &cobra.Command{
+	var images, rules bool
+	cmd := &cobra.Command{
 		Use:   "cache",
 		Short: "Delete the incremental scan cache",
-		Long:  "Deletes the cache file used by 'kubescape scan --incremental'. The next --incremental scan will re-evaluate every resource.",
+		Long:  "Deletes the cache file used by 'kubescape scan --incremental'. The next --incremental scan will re-evaluate every resource. With --images, deletes the image scan results cached by 'kubescape scan --image-cache' instead, so the next scan pulls and matches every image again. With --rules, deletes the compiled rules cached by scans and 'kubescape download --warm-cache' instead, so the next scan compiles every rule again.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if images {
+				if err := imagecache.Delete(getter.DefaultLocalStore); err != nil {
+					return err
+				}
+				logger.L().Info("Image scan cache deleted", helpers.String("path", getter.DefaultLocalStore))
+				return nil
+			}
+			if rules {
+				if err := rulecache.Delete(getter.DefaultLocalStore); err != nil {
+					return err
+				}
+				logger.L().Info("Compiled rule cache deleted", helpers.String("path", getter.DefaultLocalStore))
+				return nil
+			}
 			if err := scancache.Delete(getter.DefaultLocalStore); err != nil {
 				return err
 			}
@@ -21,4 +38,8 @@ func getDeleteCacheCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&images, "images", false, "Delete the image scan result cache instead of the incremental scan cache")
+	cmd.Flags().BoolVar(&rules, "rules", false, "Delete the compiled rule cache instead of the incremental scan cache")
+	cmd.MarkFlagsMutuallyExclusive("images", "rules")
+	return cmd
 }
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	"github.com/kubescape/kubescape/v4/core/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetDeleteCmd(t *testing.T) {
@@ -19,3 +23,43 @@ func TestGetDeleteCmd(t *testing.T) {
 	assert.Equal(t, "Delete cached configurations", configCmd.Short)
 	assert.Equal(t, "", configCmd.Long)
 }
+
+func TestGetDeleteCacheCmdImages(t *testing.T) {
+	cacheDir := t.TempDir()
+	previous := getter.DefaultLocalStore
+	getter.DefaultLocalStore = cacheDir
+	t.Cleanup(func() { getter.DefaultLocalStore = previous })
+
+	imageCacheDir := filepath.Join(cacheDir, "image-scan-cache")
+	require.NoError(t, os.MkdirAll(filepath.Join(imageCacheDir, "generation"), 0o700))
+	incrementalCache := filepath.Join(cacheDir, "incremental-scan-cache.json")
+	require.NoError(t, os.WriteFile(incrementalCache, []byte("{}"), 0o600))
+
+	cmd := getDeleteCacheCmd()
+	cmd.SetArgs([]string{"--images"})
+	require.NoError(t, cmd.Execute())
+
+	_, err := os.Stat(imageCacheDir)
+	assert.True(t, os.IsNotExist(err))
+	assert.FileExists(t, incrementalCache)
+}
+
+func TestGetDeleteCacheCmdRules(t *testing.T) {
+	cacheDir := t.TempDir()
+	previous := getter.DefaultLocalStore
+	getter.DefaultLocalStore = cacheDir
+	t.Cleanup(func() { getter.DefaultLocalStore = previous })
+
+	ruleCacheDir := filepath.Join(cacheDir, "rule-cache")
+	require.NoError(t, os.MkdirAll(filepath.Join(ruleCacheDir, "generation"), 0o700))
+	incrementalCache := filepath.Join(cacheDir, "incremental-scan-cache.json")
+	require.NoError(t, os.WriteFile(incrementalCache, []byte("{}"), 0o600))
+
+	cmd := getDeleteCacheCmd()
+	cmd.SetArgs([]string{"--rules"})
+	require.NoError(t, cmd.Execute())
+
+	_, err := os.Stat(ruleCacheDir)
+	assert.True(t, os.IsNotExist(err))
+	assert.FileExists(t, incrementalCache)
+}
@@ -21,6 +21,12 @@ var (
   # Download all artifacts and save them in /tmp path
   %[1]s download artifacts --output /tmp
   
+  # Download all artifacts and publish them as a policy bundle to an OCI registry
+  %[1]s download artifacts --push oci://registry.example.com/kubescape/policies:v1
+
+  # Download all artifacts and compile their rules, so the first scan does not compile them
+  %[1]s download artifacts --warm-cache
+
   # Download the NSA framework. Run '%[1]s list frameworks' for all frameworks names
   %[1]s download framework nsa
 
@@ -83,6 +89,8 @@ func GetDownloadCmd(ks meta.IKubescape) *cobra.Command {
 
 	downloadCmd.PersistentFlags().StringVarP(&downloadInfo.AccountID, "account", "", "", "Kubescape SaaS account ID. Default will load account ID from cache")
 	downloadCmd.PersistentFlags().StringVarP(&downloadInfo.AccessKey, "access-key", "", "", "Kubescape SaaS access key. Default will load access key from cache")
+	downloadCmd.Flags().StringVar(&downloadInfo.Push, "push", "", "Push the downloaded artifacts to an OCI registry as a policy bundle, e.g: --push oci://registry.example.com/kubescape/policies:v1. Only supported for artifacts")
+	downloadCmd.Flags().BoolVar(&downloadInfo.WarmCache, "warm-cache", false, "Compile the rules of the downloaded controls into the rule cache, so the next scan loads them instead of compiling them. Only supported for artifacts, framework and control")
 	downloadCmd.Flags().StringVarP(&downloadInfo.Path, "output", "o", "", "Output file. If not specified, will save in `~/.kubescape/<policy name>.json`")
 
 	return downloadCmd
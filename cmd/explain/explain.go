@@ -0,0 +1,103 @@
+package explain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/cmd/shared"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
+	"github.com/kubescape/opa-utils/objectsenvelopes"
+	"github.com/spf13/cobra"
+)
+
+var explainCmdExamples = fmt.Sprintf(`
+  Explain command runs a single control on a single resource and its related objects, and prints how the control reached its verdict.
+
+  # Explain why a deployment in the cluster fails C-0017
+  %[1]s explain C-0017 Deployment/default/nginx
+
+  # Explain the verdict on a resource of local manifests
+  %[1]s explain C-0017 Deployment.v1.apps/default/nginx ./manifests
+
+  # Print the full Rego evaluation trace as JSON
+  %[1]s explain C-0017 Pod/web --file-path pod.yaml --trace full --format json
+`, cautils.ExecName())
+
+var ErrUsage = errors.New("usage: <control-id> <kind>[.<version>[.<group>]]/[<namespace>/]<name> [<input path>...]")
+
+func GetExplainCmd(ks meta.IKubescape) *cobra.Command {
+	var explainInfo metav1.ExplainInfo
+	var scanInfo cautils.ScanInfo
+
+	explainCmd := &cobra.Command{
+		Use:     "explain <control-id> <kind>[.<version>[.<group>]]/[<namespace>/]<name> [<input path>...]",
+		Short:   "Explain why a resource passed or failed a control",
+		Long:    `Run a single control on a single resource, from the cluster or from files, and print how each of its rules reached its verdict: the rule's input document and Rego evaluation trace, or the values of its CEL sub-expressions, the control inputs it read, the failed and fix paths, and the exceptions that matched the resource or nearly did.`,
+		Example: explainCmdExamples,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return ErrUsage
+			}
+			if scanInfo.FilePath != "" && len(args) > 2 {
+				return fmt.Errorf("usage: use either --file-path or positional input paths, not both")
+			}
+			_, _, _, _, err := parseResourceIdentifier(args[1])
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, kind, name, apiVersion, err := parseResourceIdentifier(args[1])
+			if err != nil {
+				return err
+			}
+			explainInfo.ControlID = args[0]
+
+			scanInfo.ScanObject = &objectsenvelopes.ScanObject{}
+			scanInfo.ScanObject.SetNamespace(namespace)
+			if apiVersion != "" {
+				scanInfo.ScanObject.SetApiVersion(apiVersion)
+			}
+			scanInfo.ScanObject.SetKind(kind)
+			scanInfo.ScanObject.SetName(name)
+			scanInfo.Namespace = namespace
+
+			scanInfo.InputPatterns = slices.Clone(args[2:])
+			if scanInfo.FilePath != "" {
+				scanInfo.InputPatterns = []string{scanInfo.FilePath}
+			}
+
+			return ks.Explain(ks.Context(), &explainInfo, &scanInfo)
+		},
+	}
+
+	explainCmd.Flags().StringVar(&explainInfo.Trace, "trace", cautils.ExplainTraceFails, fmt.Sprintf("Rego evaluation trace to print: %s", strings.Join(cautils.ExplainTraceModes(), ", ")))
+	explainCmd.Flags().StringVarP(&explainInfo.Format, "format", "f", printer.PrettyFormat, fmt.Sprintf("Output format: %s or %s", printer.PrettyFormat, printer.JsonFormat))
+	explainCmd.Flags().StringVarP(&explainInfo.Output, "output", "o", "", "File to write the explanation to; defaults to stdout")
+	explainCmd.Flags().StringVar(&scanInfo.FilePath, "file-path", "", "Path to the file holding the resource")
+	explainCmd.Flags().StringVar(&scanInfo.ControlsInputs, "controls-config", "", "Path to an controls-config obj. If not set will download controls-config from ARMO management portal")
+	explainCmd.Flags().StringVar(&scanInfo.UseExceptions, "exceptions", "", "Path to an exceptions obj. If not set will download exceptions from ARMO management portal")
+	explainCmd.Flags().StringSliceVar(&scanInfo.UseFrom, "use-from", nil, "Load local policy object from specified path. If not used will download latest")
+	explainCmd.Flags().StringVar(&scanInfo.UseArtifactsFrom, "use-artifacts-from", "", "Load artifacts from local directory. If not used will download them")
+
+	return explainCmd
+}
+
+// parseResourceIdentifier parses <kind>[.<version>[.<group>]]/[<namespace>/]<name>.
+func parseResourceIdentifier(identifier string) (namespace, kind, name, apiVersion string, err error) {
+	parts := strings.Split(identifier, "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return "", "", "", "", fmt.Errorf("invalid resource %q, expected <kind>[.<version>[.<group>]]/[<namespace>/]<name>", identifier)
+	}
+	kind, apiVersion, err = shared.ParseKindAndAPIVersion(parts[0])
+	if err != nil {
+		return "", "", "", "", err
+	}
+	if len(parts) == 3 {
+		namespace = parts[1]
+	}
+	return namespace, kind, parts[len(parts)-1], apiVersion, nil
+}
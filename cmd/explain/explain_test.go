@@ -0,0 +1,75 @@
+package explain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKubescape records the arguments Explain is called with.
+type stubKubescape struct {
+	mocks.MockIKubescape
+	explainInfo *metav1.ExplainInfo
+	scanInfo    *cautils.ScanInfo
+}
+
+func (s *stubKubescape) Explain(_ context.Context, explainInfo *metav1.ExplainInfo, scanInfo *cautils.ScanInfo) error {
+	s.explainInfo = explainInfo
+	s.scanInfo = scanInfo
+	return nil
+}
+
+func TestGetExplainCmd(t *testing.T) {
+	ks := &stubKubescape{}
+	explainCmd := GetExplainCmd(ks)
+	explainCmd.SetArgs([]string{"C-0017", "Deployment.v1.apps/default/nginx", "./manifests", "--trace", "full", "--format", "json"})
+
+	require.NoError(t, explainCmd.Execute())
+	assert.Equal(t, &metav1.ExplainInfo{ControlID: "C-0017", Trace: "full", Format: "json"}, ks.explainInfo)
+	require.NotNil(t, ks.scanInfo.ScanObject)
+	assert.Equal(t, "Deployment", ks.scanInfo.ScanObject.GetKind())
+	assert.Equal(t, "apps/v1", ks.scanInfo.ScanObject.GetApiVersion())
+	assert.Equal(t, "default", ks.scanInfo.ScanObject.GetNamespace())
+	assert.Equal(t, "nginx", ks.scanInfo.ScanObject.GetName())
+	assert.Equal(t, []string{"./manifests"}, ks.scanInfo.InputPatterns)
+}
+
+func TestGetExplainCmd_Defaults(t *testing.T) {
+	ks := &stubKubescape{}
+	explainCmd := GetExplainCmd(ks)
+	explainCmd.SetArgs([]string{"C-0017", "Pod/web", "--file-path", "pod.yaml"})
+
+	require.NoError(t, explainCmd.Execute())
+	assert.Equal(t, cautils.ExplainTraceFails, ks.explainInfo.Trace)
+	assert.Equal(t, "pretty-printer", ks.explainInfo.Format)
+	assert.Equal(t, "", ks.scanInfo.ScanObject.GetNamespace())
+	assert.Equal(t, []string{"pod.yaml"}, ks.scanInfo.InputPatterns)
+}
+
+func TestGetExplainCmd_RejectsBadArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "missing resource", args: []string{"C-0017"}, wantError: ErrUsage.Error()},
+		{name: "missing name", args: []string{"C-0017", "Pod/"}, wantError: `invalid resource "Pod/"`},
+		{name: "too many segments", args: []string{"C-0017", "Pod/a/b/c"}, wantError: `invalid resource "Pod/a/b/c"`},
+		{name: "bad version", args: []string{"C-0017", "Deployment.apps/nginx"}, wantError: "invalid workload identifier"},
+		{name: "file path and input paths", args: []string{"C-0017", "Pod/web", "./manifests", "--file-path", "pod.yaml"}, wantError: "use either --file-path or positional input paths"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explainCmd := GetExplainCmd(&stubKubescape{})
+			explainCmd.SetArgs(tt.args)
+			explainCmd.SilenceUsage = true
+			explainCmd.SilenceErrors = true
+			assert.ErrorContains(t, explainCmd.Execute(), tt.wantError)
+		})
+	}
+}
@@ -24,6 +24,10 @@ var fixCmdExamples = fmt.Sprintf(`
   directory you control:
   3) %[1]s fix output.json --base-path .
 
+  # Apply the fixes and open a GitHub pull request / GitLab merge request with them
+  # (the API token is read from KS_FORGE_TOKEN, or GITHUB_TOKEN / GITLAB_TOKEN)
+  %[1]s fix output.json --no-confirm --open-pr
+
 `, cautils.ExecName())
 
 func GetFixCmd(ks meta.IKubescape) *cobra.Command {
@@ -39,6 +43,9 @@ func GetFixCmd(ks meta.IKubescape) *cobra.Command {
 				return errors.New("report output file is required")
 			}
 			fixInfo.ReportFile = args[0]
+			if fixInfo.OpenPR && fixInfo.DryRun {
+				return errors.New("--open-pr cannot be used with --dry-run")
+			}
 
 			return ks.Fix(&fixInfo)
 		},
@@ -50,5 +57,11 @@ func GetFixCmd(ks meta.IKubescape) *cobra.Command {
 	fixCmd.PersistentFlags().StringVar(&fixInfo.BasePath, "base-path", "", "Restrict fixes to this directory: the report's own recorded scan location must resolve inside it. Use this when the report file comes from a source you don't fully trust (e.g. a shared CI artifact); without it, the report's recorded location is trusted as-is")
 	fixCmd.PersistentFlags().StringVar(&fixInfo.ContainerProfilePath, "container-profile", "", "Path to a JSON file containing a ContainerProfile to use for drift detection")
 
+	fixCmd.PersistentFlags().BoolVar(&fixInfo.OpenPR, "open-pr", false, "After applying the fixes, commit them on a new branch of the scanned git repository, push it and open a GitHub pull request or GitLab merge request. The API token is read from $KS_FORGE_TOKEN, $GITHUB_TOKEN or $GITLAB_TOKEN")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.PRBranch, "pr-branch", "", "Branch to create for --open-pr (default \"kubescape/fix-<timestamp>\")")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.PRBaseBranch, "pr-base", "", "Branch the --open-pr pull request targets (default: the branch currently checked out)")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.PRRemote, "pr-remote", "origin", "Git remote to push the --open-pr branch to")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.ForgeAPIURL, "forge-api-url", "", "GitHub or GitLab API base URL for --open-pr, for self-hosted instances (default https://api.github.com or https://gitlab.com/api/v4)")
+
 	return fixCmd
 }
@@ -28,3 +28,12 @@ func TestGetFixCmd(t *testing.T) {
 	err = fixCmd.RunE(&cobra.Command{}, []string{"random-file.json"})
 	assert.Nil(t, err)
 }
+
+func TestGetFixCmd_OpenPRRejectsDryRun(t *testing.T) {
+	fixCmd := GetFixCmd(&mocks.MockIKubescape{})
+	assert.NoError(t, fixCmd.PersistentFlags().Set("open-pr", "true"))
+	assert.NoError(t, fixCmd.PersistentFlags().Set("dry-run", "true"))
+
+	err := fixCmd.RunE(&cobra.Command{}, []string{"report.json"})
+	assert.EqualError(t, err, "--open-pr cannot be used with --dry-run")
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Audit decisions and outcomes.
+const (
+	DecisionAllowed = "allowed"
+	DecisionDenied  = "denied"
+
+	OutcomeSuccess   = "success"
+	OutcomeToolError = "tool_error"
+	OutcomeError     = "error"
+)
+
+// AuditEntry is one line of the audit log: a single tools/call, resources/read
+// or prompts/get, whether or not it was allowed to run. It names the tool, the
+// resource or the prompt.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Caller     *Caller   `json:"caller"`
+	Tool       string    `json:"tool,omitempty"`
+	Resource   string    `json:"resource,omitempty"`
+	Prompt     string    `json:"prompt,omitempty"`
+	Arguments  any       `json:"arguments,omitempty"`
+	Decision   string    `json:"decision"`
+	Outcome    string    `json:"outcome,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"durationMs"`
+	Session    string    `json:"session,omitempty"`
+}
+
+// AuditLog writes AuditEntry values as JSON lines. It is safe for concurrent
+// use; the MCP server dispatches every request on its own goroutine.
+type AuditLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewAuditLog returns an AuditLog writing to w.
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w}
+}
+
+// OpenAuditLog appends to the file at filePath, or writes to stderr for "-".
+func OpenAuditLog(filePath string) (*AuditLog, error) {
+	if filePath == "-" {
+		return NewAuditLog(os.Stderr), nil
+	}
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", filePath, err)
+	}
+	return &AuditLog{w: f, closer: f}, nil
+}
+
+// Record writes entry. A nil AuditLog records nothing.
+func (l *AuditLog) Record(entry AuditEntry) error {
+	if l == nil {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying file, if OpenAuditLog opened one.
+func (l *AuditLog) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// ToolMiddleware enforces authz's per-tool authorization and records every
+// call, allowed or denied, in audit. Either may be nil: a nil authz allows
+// every call and a nil audit records nothing.
+func ToolMiddleware(authz *Authenticator, audit *AuditLog, onAuditError func(error)) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			call := startCall(ctx, audit, onAuditError)
+			call.entry.Tool = request.Params.Name
+			call.entry.Arguments = request.Params.Arguments
+
+			if !authz.Allowed(call.entry.Caller, request.Params.Name) {
+				call.denied()
+				return mcp.NewToolResultError(fmt.Sprintf("forbidden: %s is not allowed to call %s", call.entry.Caller.Subject, request.Params.Name)), nil
+			}
+
+			result, err := next(ctx, request)
+			call.done(err, result != nil && result.IsError)
+			return result, err
+		}
+	}
+}
+
+// ResourceMiddleware enforces authz's per-resource authorization and records
+// every read in audit, as ToolMiddleware does for tool calls.
+func ResourceMiddleware(authz *Authenticator, audit *AuditLog, onAuditError func(error)) server.ResourceHandlerMiddleware {
+	return func(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			call := startCall(ctx, audit, onAuditError)
+			call.entry.Resource = request.Params.URI
+
+			if !authz.AllowedResource(call.entry.Caller, request.Params.URI) {
+				call.denied()
+				return nil, fmt.Errorf("forbidden: %s is not allowed to read %s", call.entry.Caller.Subject, request.Params.URI)
+			}
+
+			contents, err := next(ctx, request)
+			call.done(err, false)
+			return contents, err
+		}
+	}
+}
+
+// PromptMiddleware enforces authz's per-prompt authorization and records every
+// get in audit, as ToolMiddleware does for tool calls.
+func PromptMiddleware(authz *Authenticator, audit *AuditLog, onAuditError func(error)) server.PromptHandlerMiddleware {
+	return func(next server.PromptHandlerFunc) server.PromptHandlerFunc {
+		return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			call := startCall(ctx, audit, onAuditError)
+			call.entry.Prompt = request.Params.Name
+			if len(request.Params.Arguments) > 0 {
+				call.entry.Arguments = request.Params.Arguments
+			}
+
+			if !authz.AllowedPrompt(call.entry.Caller, request.Params.Name) {
+				call.denied()
+				return nil, fmt.Errorf("forbidden: %s is not allowed to get %s", call.entry.Caller.Subject, request.Params.Name)
+			}
+
+			result, err := next(ctx, request)
+			call.done(err, false)
+			return result, err
+		}
+	}
+}
+
+// auditedCall is a request being served, recorded in the audit log once it is
+// denied or done.
+type auditedCall struct {
+	start        time.Time
+	entry        AuditEntry
+	audit        *AuditLog
+	onAuditError func(error)
+}
+
+func startCall(ctx context.Context, audit *AuditLog, onAuditError func(error)) *auditedCall {
+	start := time.Now()
+	caller := CallerFromContext(ctx)
+	if caller == nil {
+		caller = &Caller{Subject: "anonymous", Method: MethodNone}
+	}
+	return &auditedCall{
+		start:        start,
+		entry:        AuditEntry{Time: start.UTC(), Caller: caller, Session: sessionID(ctx)},
+		audit:        audit,
+		onAuditError: onAuditError,
+	}
+}
+
+func (c *auditedCall) denied() {
+	c.entry.Decision = DecisionDenied
+	c.record()
+}
+
+// done records an allowed call that returned err, or a tool result that is an
+// error when toolError is set.
+func (c *auditedCall) done(err error, toolError bool) {
+	c.entry.Decision = DecisionAllowed
+	switch {
+	case err != nil:
+		c.entry.Outcome = OutcomeError
+		c.entry.Error = err.Error()
+	case toolError:
+		c.entry.Outcome = OutcomeToolError
+	default:
+		c.entry.Outcome = OutcomeSuccess
+	}
+	c.record()
+}
+
+func (c *auditedCall) record() {
+	c.entry.DurationMS = time.Since(c.start).Milliseconds()
+	if err := c.audit.Record(c.entry); err != nil && c.onAuditError != nil {
+		c.onAuditError(err)
+	}
+}
+
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func callTool(name string, args map[string]any) mcp.CallToolRequest {
+	var req mcp.CallToolRequest
+	req.Params.Name = name
+	req.Params.Arguments = args
+	return req
+}
+
+func auditEntries(t *testing.T, buf *bytes.Buffer) []AuditEntry {
+	t.Helper()
+	var entries []AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e AuditEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestToolMiddleware(t *testing.T) {
+	a, err := NewAuthenticator(context.Background(), &Config{Tokens: []StaticToken{{Subject: "x", SHA256: sha("x"), Roles: []string{RoleReadOnly}}}})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	audit := NewAuditLog(&buf)
+
+	calls := 0
+	handler := ToolMiddleware(a, audit, nil)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		if request.Params.Name == "get_container_profile" {
+			return mcp.NewToolResultError("not found"), nil
+		}
+		if request.Params.Name == "list_frameworks" {
+			return nil, errors.New("boom")
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	ctx := WithCaller(context.Background(), &Caller{Subject: "dev@example.com", Method: MethodOIDC, Roles: []string{RoleReadOnly}})
+
+	res, err := handler(ctx, callTool("dry_run_remediation", map[string]any{"resource_kind": "pods"}))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Equal(t, 0, calls, "a denied call never reaches the tool")
+
+	_, err = handler(ctx, callTool("list_controls", nil))
+	require.NoError(t, err)
+	_, _ = handler(ctx, callTool("get_container_profile", map[string]any{"namespace": "default"}))
+	_, err = handler(ctx, callTool("list_frameworks", nil))
+	assert.Error(t, err)
+
+	entries := auditEntries(t, &buf)
+	require.Len(t, entries, 4)
+	assert.Equal(t, "dry_run_remediation", entries[0].Tool)
+	assert.Equal(t, DecisionDenied, entries[0].Decision)
+	assert.Equal(t, "dev@example.com", entries[0].Caller.Subject)
+	assert.Equal(t, map[string]any{"resource_kind": "pods"}, entries[0].Arguments)
+
+	assert.Equal(t, DecisionAllowed, entries[1].Decision)
+	assert.Equal(t, OutcomeSuccess, entries[1].Outcome)
+	assert.Equal(t, OutcomeToolError, entries[2].Outcome)
+	assert.Equal(t, OutcomeError, entries[3].Outcome)
+	assert.Equal(t, "boom", entries[3].Error)
+}
+
+func TestToolMiddlewareWithoutAuth(t *testing.T) {
+	var buf bytes.Buffer
+	handler := ToolMiddleware(nil, NewAuditLog(&buf), nil)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	res, err := handler(context.Background(), callTool("dry_run_remediation", nil))
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+
+	entries := auditEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, MethodNone, entries[0].Caller.Method)
+	assert.Equal(t, DecisionAllowed, entries[0].Decision)
+}
+
+func TestResourceAndPromptMiddleware(t *testing.T) {
+	a, err := NewAuthenticator(context.Background(), &Config{
+		Tokens: []StaticToken{{Subject: "x", SHA256: sha("x"), Roles: []string{"triage"}}},
+		Roles:  map[string]Role{"triage": {Resources: []string{"kubescape://frameworks/*"}, Prompts: []string{"triage_*"}}},
+	})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	audit := NewAuditLog(&buf)
+	ctx := WithCaller(context.Background(), &Caller{Subject: "dev@example.com", Method: MethodOIDC, Roles: []string{"triage"}})
+
+	reads := 0
+	readResource := ResourceMiddleware(a, audit, nil)(func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		reads++
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, Text: "{}"}}, nil
+	})
+	var read mcp.ReadResourceRequest
+	read.Params.URI = "kubescape://scans/latest"
+	_, err = readResource(ctx, read)
+	assert.ErrorContains(t, err, "forbidden")
+	assert.Zero(t, reads, "a denied read never reaches the resource")
+	read.Params.URI = "kubescape://frameworks/nsa"
+	contents, err := readResource(ctx, read)
+	require.NoError(t, err)
+	assert.Len(t, contents, 1)
+
+	getPrompt := PromptMiddleware(a, audit, nil)(func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return nil, errors.New("boom")
+	})
+	var get mcp.GetPromptRequest
+	get.Params.Name = "triage_cluster"
+	get.Params.Arguments = map[string]string{"namespace": "default"}
+	_, err = getPrompt(ctx, get)
+	assert.Error(t, err)
+
+	entries := auditEntries(t, &buf)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "kubescape://scans/latest", entries[0].Resource)
+	assert.Equal(t, DecisionDenied, entries[0].Decision)
+	assert.Equal(t, "dev@example.com", entries[0].Caller.Subject)
+	assert.Empty(t, entries[0].Tool)
+
+	assert.Equal(t, "kubescape://frameworks/nsa", entries[1].Resource)
+	assert.Equal(t, DecisionAllowed, entries[1].Decision)
+	assert.Equal(t, OutcomeSuccess, entries[1].Outcome)
+
+	assert.Equal(t, "triage_cluster", entries[2].Prompt)
+	assert.Equal(t, map[string]any{"namespace": "default"}, entries[2].Arguments)
+	assert.Equal(t, OutcomeError, entries[2].Outcome)
+}
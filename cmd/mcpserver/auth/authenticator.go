@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// Authentication methods recorded on a Caller.
+const (
+	MethodToken = "token"
+	MethodOIDC  = "oidc"
+	// MethodNone marks callers of a transport without authentication (stdio,
+	// or HTTP without --auth-config on a loopback address).
+	MethodNone = "none"
+)
+
+var errUnauthenticated = errors.New("missing or invalid bearer token")
+
+// Caller is the authenticated identity behind a request.
+type Caller struct {
+	Subject    string   `json:"subject"`
+	Method     string   `json:"method"`
+	Roles      []string `json:"roles,omitempty"`
+	RemoteAddr string   `json:"remoteAddr,omitempty"`
+}
+
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx carrying caller.
+func WithCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller stored by WithCaller, or nil.
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerKey{}).(*Caller)
+	return caller
+}
+
+// HTTPContextFunc carries the caller the Middleware put on the HTTP request
+// over to the context the MCP server runs tool handlers with. It fits both
+// server.WithHTTPContextFunc and server.WithSSEContextFunc.
+func HTTPContextFunc(ctx context.Context, r *http.Request) context.Context {
+	if caller := CallerFromContext(r.Context()); caller != nil {
+		return WithCaller(ctx, caller)
+	}
+	return ctx
+}
+
+// Authenticator verifies bearer tokens against the static tokens and the OIDC
+// issuer of a Config, and decides which tools, resources and prompts the
+// resulting caller may use.
+type Authenticator struct {
+	tokens   []staticToken
+	oidc     *OIDCConfig
+	verifier *oidc.IDTokenVerifier
+	roles    map[string]Role
+}
+
+type staticToken struct {
+	hash    []byte
+	subject string
+	roles   []string
+}
+
+// NewAuthenticator builds an Authenticator from a validated Config. With OIDC
+// configured it fetches the issuer's discovery document, so the issuer must be
+// reachable at startup.
+func NewAuthenticator(ctx context.Context, cfg *Config) (*Authenticator, error) {
+	a := &Authenticator{roles: cfg.roles(), oidc: cfg.OIDC}
+	for _, t := range cfg.Tokens {
+		hash, err := hex.DecodeString(strings.ToLower(t.SHA256))
+		if err != nil {
+			return nil, fmt.Errorf("token %s: sha256 is not hex: %w", t.Subject, err)
+		}
+		a.tokens = append(a.tokens, staticToken{hash: hash, subject: t.Subject, roles: t.Roles})
+	}
+	if cfg.OIDC != nil {
+		provider, err := oidc.NewProvider(ctx, cfg.OIDC.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover OIDC issuer %s: %w", cfg.OIDC.IssuerURL, err)
+		}
+		a.verifier = provider.Verifier(&oidc.Config{ClientID: cfg.OIDC.Audience})
+	}
+	return a, nil
+}
+
+// Authenticate resolves the bearer token of r to a Caller.
+func (a *Authenticator) Authenticate(r *http.Request) (*Caller, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, errUnauthenticated
+	}
+	token = strings.TrimSpace(token)
+
+	sum := sha256.Sum256([]byte(token))
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(sum[:], t.hash) == 1 {
+			return &Caller{Subject: t.subject, Method: MethodToken, Roles: t.roles, RemoteAddr: r.RemoteAddr}, nil
+		}
+	}
+
+	if a.verifier == nil || strings.Count(token, ".") != 2 {
+		return nil, errUnauthenticated
+	}
+	idToken, err := a.verifier.Verify(r.Context(), token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnauthenticated, err)
+	}
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnauthenticated, err)
+	}
+
+	subjectClaim := a.oidc.SubjectClaim
+	if subjectClaim == "" {
+		subjectClaim = "sub"
+	}
+	subject, _ := claims[subjectClaim].(string)
+	if subject == "" {
+		subject = idToken.Subject
+	}
+	return &Caller{Subject: subject, Method: MethodOIDC, Roles: a.oidcRoles(claims), RemoteAddr: r.RemoteAddr}, nil
+}
+
+// oidcRoles maps the values of the configured roles claim to roles.
+func (a *Authenticator) oidcRoles(claims map[string]any) []string {
+	rolesClaim := a.oidc.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "groups"
+	}
+	var values []string
+	switch v := claims[rolesClaim].(type) {
+	case string:
+		values = strings.Fields(v)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	granted := map[string]bool{}
+	for _, value := range values {
+		for _, role := range a.oidc.RoleBindings[value] {
+			granted[role] = true
+		}
+		if _, ok := a.roles[value]; ok {
+			granted[value] = true
+		}
+	}
+	roles := make([]string, 0, len(granted))
+	for role := range granted {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Allowed reports whether caller may call tool. A nil Authenticator means the
+// transport is unauthenticated and every tool is allowed, as before auth existed.
+func (a *Authenticator) Allowed(caller *Caller, tool string) bool {
+	return a.allowed(caller, tool, func(role Role) []string { return role.Tools })
+}
+
+// AllowedResource reports whether caller may read the resource at uri.
+func (a *Authenticator) AllowedResource(caller *Caller, uri string) bool {
+	return a.allowed(caller, uri, func(role Role) []string { return role.Resources })
+}
+
+// AllowedPrompt reports whether caller may get prompt.
+func (a *Authenticator) AllowedPrompt(caller *Caller, prompt string) bool {
+	return a.allowed(caller, prompt, func(role Role) []string { return role.Prompts })
+}
+
+func (a *Authenticator) allowed(caller *Caller, name string, patterns func(Role) []string) bool {
+	if a == nil {
+		return true
+	}
+	if caller == nil {
+		return false
+	}
+	for _, role := range caller.Roles {
+		if matchesAny(patterns(a.roles[role]), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware rejects requests without a valid bearer token with 401 and
+// stores the authenticated Caller on the request context.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, err := a.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kubescape-mcp"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithCaller(r.Context(), caller)))
+	})
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func requestWithToken(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+// fakeIssuer serves an OIDC discovery document and JWKS for a fresh RSA key,
+// and returns a function that signs claims with it.
+func fakeIssuer(t *testing.T) (string, func(claims map[string]any) string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                srv.URL,
+			"jwks_uri":                              srv.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "k1", Algorithm: "RS256", Use: "sig"}}})
+	})
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", "k1"))
+	require.NoError(t, err)
+	sign := func(claims map[string]any) string {
+		token, err := jwt.Signed(signer).Claims(claims).Serialize()
+		require.NoError(t, err)
+		return token
+	}
+	return srv.URL, sign
+}
+
+func TestAuthenticateStaticToken(t *testing.T) {
+	cfg := &Config{Tokens: []StaticToken{
+		{Subject: "ci-bot", SHA256: sha("s3cret"), Roles: []string{RoleReadOnly}},
+		{Subject: "ops", SHA256: sha("0ps"), Roles: []string{RoleAdmin}},
+	}}
+	require.NoError(t, cfg.Validate())
+	a, err := NewAuthenticator(context.Background(), cfg)
+	require.NoError(t, err)
+
+	caller, err := a.Authenticate(requestWithToken("s3cret"))
+	require.NoError(t, err)
+	assert.Equal(t, "ci-bot", caller.Subject)
+	assert.Equal(t, MethodToken, caller.Method)
+
+	for _, token := range []string{"", "wrong", "s3cret2"} {
+		_, err := a.Authenticate(requestWithToken(token))
+		assert.Error(t, err, "token %q", token)
+	}
+}
+
+func TestAuthenticateOIDC(t *testing.T) {
+	issuer, sign := fakeIssuer(t)
+	cfg := &Config{OIDC: &OIDCConfig{
+		IssuerURL:    issuer,
+		Audience:     "kubescape-mcp",
+		SubjectClaim: "email",
+		RoleBindings: map[string][]string{"platform-admins": {RoleAdmin}},
+	}}
+	require.NoError(t, cfg.Validate())
+	a, err := NewAuthenticator(context.Background(), cfg)
+	require.NoError(t, err)
+
+	now := time.Now()
+	valid := map[string]any{
+		"iss": issuer, "aud": "kubescape-mcp", "sub": "u-1", "email": "dev@example.com",
+		"groups": []string{"developers", "read-only"},
+		"iat":    now.Unix(), "exp": now.Add(time.Hour).Unix(),
+	}
+	caller, err := a.Authenticate(requestWithToken(sign(valid)))
+	require.NoError(t, err)
+	assert.Equal(t, "dev@example.com", caller.Subject)
+	assert.Equal(t, MethodOIDC, caller.Method)
+	assert.Equal(t, []string{RoleReadOnly}, caller.Roles, "a group named like a role grants it")
+
+	admin := map[string]any{}
+	for k, v := range valid {
+		admin[k] = v
+	}
+	admin["groups"] = []string{"platform-admins"}
+	caller, err = a.Authenticate(requestWithToken(sign(admin)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleAdmin}, caller.Roles)
+
+	wrongAudience := map[string]any{}
+	for k, v := range valid {
+		wrongAudience[k] = v
+	}
+	wrongAudience["aud"] = "someone-else"
+	_, err = a.Authenticate(requestWithToken(sign(wrongAudience)))
+	assert.Error(t, err)
+
+	expired := map[string]any{}
+	for k, v := range valid {
+		expired[k] = v
+	}
+	expired["exp"] = now.Add(-time.Hour).Unix()
+	_, err = a.Authenticate(requestWithToken(sign(expired)))
+	assert.Error(t, err)
+}
+
+func TestAllowed(t *testing.T) {
+	a, err := NewAuthenticator(context.Background(), &Config{
+		Tokens: []StaticToken{{Subject: "x", SHA256: sha("x"), Roles: []string{RoleReadOnly}}},
+		Roles:  map[string]Role{"triage": {Tools: []string{"list_*"}}},
+	})
+	require.NoError(t, err)
+
+	readOnly := &Caller{Subject: "x", Roles: []string{RoleReadOnly}}
+	assert.True(t, a.Allowed(readOnly, "list_vulnerability_manifests"))
+	assert.True(t, a.Allowed(readOnly, "run_framework_security_scan"))
+	assert.False(t, a.Allowed(readOnly, "dry_run_remediation"))
+	assert.False(t, a.Allowed(readOnly, "scan_local_iac"))
+
+	assert.True(t, a.Allowed(&Caller{Roles: []string{RoleAdmin}}, "dry_run_remediation"))
+	assert.True(t, a.Allowed(&Caller{Roles: []string{"triage"}}, "list_controls"))
+	assert.False(t, a.Allowed(&Caller{Roles: []string{"triage"}}, "get_container_profile"))
+	assert.False(t, a.Allowed(&Caller{}, "list_controls"))
+	assert.False(t, a.Allowed(nil, "list_controls"))
+
+	var unauthenticated *Authenticator
+	assert.True(t, unauthenticated.Allowed(nil, "dry_run_remediation"))
+}
+
+func TestAllowedResourceAndPrompt(t *testing.T) {
+	a, err := NewAuthenticator(context.Background(), &Config{
+		Tokens: []StaticToken{{Subject: "x", SHA256: sha("x"), Roles: []string{RoleReadOnly}}},
+		Roles:  map[string]Role{"triage": {Tools: []string{"list_*"}, Resources: []string{"kubescape://controls/*"}}},
+	})
+	require.NoError(t, err)
+
+	readOnly := &Caller{Subject: "x", Roles: []string{RoleReadOnly}}
+	assert.True(t, a.AllowedResource(readOnly, "kubescape://frameworks"))
+	assert.True(t, a.AllowedResource(readOnly, "kubescape://frameworks/nsa"))
+	assert.True(t, a.AllowedResource(readOnly, "kubescape://workloads/default/Deployment/nginx/configuration-scan"))
+	assert.True(t, a.AllowedResource(readOnly, "kubescape://vulnerability-manifests/default/nginx/cve_details/CVE-2024-3094"))
+	assert.True(t, a.AllowedResource(readOnly, "kubescape://scans/latest"))
+	assert.True(t, a.AllowedPrompt(readOnly, "triage_cluster"))
+
+	triage := &Caller{Roles: []string{"triage"}}
+	assert.True(t, a.AllowedResource(triage, "kubescape://controls/C-0016"))
+	assert.False(t, a.AllowedResource(triage, "kubescape://frameworks/nsa"))
+	assert.False(t, a.AllowedPrompt(triage, "triage_cluster"), "a role without prompts gets none")
+	assert.False(t, a.AllowedResource(nil, "kubescape://frameworks"))
+
+	var unauthenticated *Authenticator
+	assert.True(t, unauthenticated.AllowedResource(nil, "kubescape://scans/latest"))
+	assert.True(t, unauthenticated.AllowedPrompt(nil, "triage_cluster"))
+
+	bad := &Config{Tokens: []StaticToken{{Subject: "x", SHA256: sha("x")}}, Roles: map[string]Role{"broken": {Resources: []string{"kubescape://["}}}}
+	assert.ErrorContains(t, bad.Validate(), "bad resource pattern")
+}
+
+func TestMiddleware(t *testing.T) {
+	a, err := NewAuthenticator(context.Background(), &Config{Tokens: []StaticToken{{Subject: "ci-bot", SHA256: sha("s3cret"), Roles: []string{RoleReadOnly}}}})
+	require.NoError(t, err)
+
+	var seen *Caller
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := HTTPContextFunc(context.Background(), r)
+		seen = CallerFromContext(ctx)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, requestWithToken("nope"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+	assert.Nil(t, seen)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, requestWithToken("s3cret"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, seen)
+	assert.Equal(t, "ci-bot", seen.Subject)
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+		return p
+	}
+
+	cfg, err := LoadConfig(write("ok.yaml", `
+tokens:
+  - subject: ci-bot
+    sha256: `+sha("s3cret")+`
+    roles: [read-only, triage]
+roles:
+  triage:
+    tools: ["list_*"]
+`))
+	require.NoError(t, err)
+	assert.Len(t, cfg.Tokens, 1)
+
+	tests := map[string]string{
+		"empty":        "roles: {}\n",
+		"unknown role": "tokens:\n  - subject: a\n    sha256: " + sha("a") + "\n    roles: [root]\n",
+		"short hash":   "tokens:\n  - subject: a\n    sha256: abc\n    roles: [admin]\n",
+		"oidc no aud":  "oidc:\n  issuerURL: https://issuer.example\n",
+		"bad pattern":  "tokens:\n  - subject: a\n    sha256: " + sha("a") + "\n    roles: [admin]\nroles:\n  x:\n    tools: ['[']\n",
+		"unknown key":  "tokenz: []\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadConfig(write(name+".yaml", content))
+			assert.Error(t, err)
+		})
+	}
+}
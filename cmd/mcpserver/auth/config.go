@@ -0,0 +1,195 @@
+// Package auth authenticates and authorizes callers of the Kubescape MCP server
+// when it runs as a shared HTTP service, and writes the audit log of their tool calls.
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RoleAdmin may call every tool.
+	RoleAdmin = "admin"
+	// RoleReadOnly may call every tool that neither proposes changes to the
+	// cluster nor reads the server's own filesystem.
+	RoleReadOnly = "read-only"
+)
+
+// builtinRoles are always defined; a config file may override them by name.
+var builtinRoles = map[string]Role{
+	RoleAdmin: {Tools: []string{"*"}, Resources: []string{"*"}, Prompts: []string{"*"}},
+	RoleReadOnly: {
+		Tools: []string{
+			"list_*",
+			"get_*",
+			"run_*_scan",
+			"scan_controls",
+			"scan_resource_slice",
+			"evaluate_cel_rule",
+		},
+		Resources: []string{"*"},
+		Prompts:   []string{"*"},
+	},
+}
+
+// Config is the --auth-config file.
+//
+//	tokens:
+//	  - subject: ci-bot
+//	    sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	    roles: [read-only]
+//	oidc:
+//	  issuerURL: https://login.example.com
+//	  audience: kubescape-mcp
+//	  rolesClaim: groups
+//	  roleBindings:
+//	    platform-admins: [admin]
+//	    developers: [read-only]
+//	roles:
+//	  triage:
+//	    tools: ["list_*", "get_configuration_*"]
+//	    resources: ["kubescape://controls/*"]
+//	    prompts: ["*"]
+type Config struct {
+	// Tokens are static bearer tokens, stored as the hex SHA-256 of the token
+	// so the file itself is not a credential.
+	Tokens []StaticToken `json:"tokens,omitempty"`
+	// OIDC, when set, accepts JWTs issued by IssuerURL for Audience.
+	OIDC *OIDCConfig `json:"oidc,omitempty"`
+	// Roles adds to (or overrides) the built-in "admin" and "read-only" roles.
+	Roles map[string]Role `json:"roles,omitempty"`
+}
+
+type StaticToken struct {
+	Subject string   `json:"subject"`
+	SHA256  string   `json:"sha256"`
+	Roles   []string `json:"roles"`
+}
+
+type OIDCConfig struct {
+	IssuerURL string `json:"issuerURL"`
+	Audience  string `json:"audience"`
+	// SubjectClaim names the caller in the audit log; defaults to "sub".
+	SubjectClaim string `json:"subjectClaim,omitempty"`
+	// RolesClaim is the claim holding the caller's groups or roles; defaults to "groups".
+	RolesClaim string `json:"rolesClaim,omitempty"`
+	// RoleBindings maps a RolesClaim value to the roles it grants. A claim
+	// value that names a role directly grants that role too.
+	RoleBindings map[string][]string `json:"roleBindings,omitempty"`
+}
+
+// Role is a set of tool-name patterns (path.Match syntax) a caller may invoke,
+// and of the resource URIs and prompt names it may read and get. A "*" pattern
+// matches every name, including URIs, which path.Match would split at "/".
+type Role struct {
+	Tools     []string `json:"tools"`
+	Resources []string `json:"resources,omitempty"`
+	Prompts   []string `json:"prompts,omitempty"`
+}
+
+// matchesAny reports whether name matches one of patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadConfig reads and validates an --auth-config file.
+func LoadConfig(filePath string) (*Config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config %s: %w", filePath, err)
+	}
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse auth config %s: %w", filePath, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid auth config %s: %w", filePath, err)
+	}
+	return &cfg, nil
+}
+
+// Validate rejects configs that would silently authenticate nobody or grant
+// roles that do not exist.
+func (c *Config) Validate() error {
+	if len(c.Tokens) == 0 && c.OIDC == nil {
+		return fmt.Errorf("at least one of tokens or oidc must be configured")
+	}
+	roles := c.roles()
+	for name, role := range roles {
+		for _, patterns := range []struct {
+			kind string
+			list []string
+		}{{"tool", role.Tools}, {"resource", role.Resources}, {"prompt", role.Prompts}} {
+			for _, pattern := range patterns.list {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("role %q: bad %s pattern %q: %w", name, patterns.kind, pattern, err)
+				}
+			}
+		}
+	}
+	for i, t := range c.Tokens {
+		if t.Subject == "" {
+			return fmt.Errorf("tokens[%d]: subject is required", i)
+		}
+		if len(t.SHA256) != 64 {
+			return fmt.Errorf("tokens[%d] (%s): sha256 must be the 64-character hex SHA-256 of the token", i, t.Subject)
+		}
+		if err := checkRoles(roles, t.Roles); err != nil {
+			return fmt.Errorf("tokens[%d] (%s): %w", i, t.Subject, err)
+		}
+	}
+	if c.OIDC != nil {
+		if c.OIDC.IssuerURL == "" || c.OIDC.Audience == "" {
+			return fmt.Errorf("oidc: issuerURL and audience are required")
+		}
+		for claim, granted := range c.OIDC.RoleBindings {
+			if err := checkRoles(roles, granted); err != nil {
+				return fmt.Errorf("oidc.roleBindings[%s]: %w", claim, err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkRoles(roles map[string]Role, names []string) error {
+	for _, name := range names {
+		if _, ok := roles[name]; !ok {
+			return fmt.Errorf("unknown role %q (defined: %s)", name, strings.Join(sortedKeys(roles), ", "))
+		}
+	}
+	return nil
+}
+
+// roles returns the built-in roles merged with the configured ones.
+func (c *Config) roles() map[string]Role {
+	out := make(map[string]Role, len(builtinRoles)+len(c.Roles))
+	for name, role := range builtinRoles {
+		out[name] = role
+	}
+	for name, role := range c.Roles {
+		out[name] = role
+	}
+	return out
+}
+
+func sortedKeys(roles map[string]Role) []string {
+	keys := make([]string, 0, len(roles))
+	for k := range roles {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
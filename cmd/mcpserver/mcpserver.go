@@ -20,6 +20,7 @@ import (
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/kubescape/kubescape/v4/cmd/mcpserver/auth"
 	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	"github.com/kubescape/kubescape/v4/core/pkg/fixhandler"
 	"golang.org/x/sync/semaphore"
@@ -96,6 +97,12 @@ type KubescapeMcpserver struct {
 	scanGroup      singleflight.Group
 	scanCtxMu      sync.Mutex
 	scanCtxs       map[string]*scanCtxState
+	// latestScans backs the kubescape://scans/latest resource, one scan per
+	// caller (see scanOwner); subscriptions decides who hears about it
+	// changing.
+	latestScanMu  sync.Mutex
+	latestScans   map[string]*scanSummary
+	subscriptions *resourceSubscriptions
 }
 
 // getPolicyGetter lazily constructs policyGetter on first use, guarded so
@@ -1273,20 +1280,48 @@ func (ksServer *KubescapeMcpserver) CallTool(ctx context.Context, name string, a
 	}
 }
 
-func mcpServerEntrypoint(transport string, port int) error {
+func mcpServerEntrypoint(opts serverOptions) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
 	logger.L().Info("Starting MCP server...")
 
+	authn, audit, err := newAccessControl(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	subscriptions := newResourceSubscriptions()
+	serverOpts := []server.ServerOption{
+		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(true, false),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(subscriptions.hooks()),
+		server.WithRecovery(),
+	}
+	if authn != nil || audit != nil {
+		onAuditError := func(err error) {
+			logger.L().Warning("failed to write MCP audit log entry", helpers.Error(err))
+		}
+		serverOpts = append(serverOpts,
+			server.WithToolHandlerMiddleware(auth.ToolMiddleware(authn, audit, onAuditError)),
+			server.WithResourceHandlerMiddleware(auth.ResourceMiddleware(authn, audit, onAuditError)),
+			server.WithPromptHandlerMiddleware(auth.PromptMiddleware(authn, audit, onAuditError)),
+		)
+	}
+
 	// Create a new MCP server
 	s := server.NewMCPServer(
 		"Kubescape MCP Server",
 		"0.0.1",
-		server.WithToolCapabilities(false),
-		server.WithRecovery(),
+		serverOpts...,
 	)
 
 	ksServer := &KubescapeMcpserver{
-		s:            s,
-		policyGetter: getter.NewDownloadReleasedPolicy(),
+		s:             s,
+		policyGetter:  getter.NewDownloadReleasedPolicy(),
+		subscriptions: subscriptions,
 	}
 
 	// Initialize the policy getter to load the local ~/.kubescape cache.
@@ -1309,24 +1344,18 @@ func mcpServerEntrypoint(transport string, port int) error {
 	createControlScanningTools(ksServer)
 	createPolicyListingTools(ksServer)
 	createAdvancedTools(ksServer)
+	createPolicyResources(ksServer)
+	createScanResources(ksServer)
+	createPrompts(ksServer)
 
 	// Start the server
-	if transport == "sse" {
-		sseServer := server.NewSSEServer(s)
-		addr := fmt.Sprintf("127.0.0.1:%d", port)
-		logger.L().Info("Starting SSE server", helpers.String("addr", addr))
-		if err := sseServer.Start(addr); err != nil {
-			return fmt.Errorf("sse server error: %w", err)
-		}
-		return nil
-	} else if transport == "stdio" {
-		if err := server.ServeStdio(s); err != nil {
-			return fmt.Errorf("server error: %w", err)
-		}
-		return nil
-	} else {
-		return fmt.Errorf("unsupported transport '%s': must be 'sse' or 'stdio'", transport)
+	if opts.isHTTP() {
+		return serveHTTP(s, opts, authn)
+	}
+	if err := server.ServeStdio(s); err != nil {
+		return fmt.Errorf("server error: %w", err)
 	}
+	return nil
 }
 
 func createRBACScanningTools(ksServer *KubescapeMcpserver) {
@@ -1424,17 +1453,22 @@ func createPolicyListingTools(ksServer *KubescapeMcpserver) {
 }
 
 func GetMCPServerCmd() *cobra.Command {
-	var transport string
-	var port int
+	var opts serverOptions
 	cmd := &cobra.Command{
 		Use:   "mcpserver",
 		Short: "Start the Kubescape MCP server",
 		Long:  `Start the Kubescape MCP server`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return mcpServerEntrypoint(transport, port)
+			return mcpServerEntrypoint(opts)
 		},
 	}
-	cmd.Flags().StringVarP(&transport, "transport", "t", "stdio", "Transport protocol to use (stdio or sse)")
-	cmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to use for SSE transport")
+	cmd.Flags().StringVarP(&opts.transport, "transport", "t", transportStdio, "Transport protocol to use (stdio, sse or http for MCP streamable HTTP)")
+	cmd.Flags().IntVarP(&opts.port, "port", "p", 8080, "Port to use for the sse and http transports")
+	cmd.Flags().StringVar(&opts.listenAddress, "listen-address", "127.0.0.1", "Address the sse and http transports listen on. Non-loopback addresses require --auth-config")
+	cmd.Flags().StringVar(&opts.endpointPath, "endpoint-path", "/mcp", "URL path of the http transport endpoint")
+	cmd.Flags().StringVar(&opts.tlsCertFile, "tls-cert", "", "TLS certificate file for the sse and http transports")
+	cmd.Flags().StringVar(&opts.tlsKeyFile, "tls-key", "", "TLS private key file for the sse and http transports")
+	cmd.Flags().StringVar(&opts.authConfigPath, "auth-config", "", "YAML file with the bearer tokens, OIDC issuer and per-tool roles clients authenticate with (sse and http transports)")
+	cmd.Flags().StringVar(&opts.auditLogPath, "audit-log", "", "Append a JSON line per tool call (caller, tool, arguments, decision, outcome) to this file; '-' for stderr")
 	return cmd
 }
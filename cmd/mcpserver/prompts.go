@@ -0,0 +1,120 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	triagePromptName       = "triage_failing_controls"
+	cveBlastRadiusPrompt   = "explain_cve_blast_radius"
+	defaultTriageFramework = "nsa"
+	defaultTriageLimit     = 5
+	maxTriageLimit         = 20
+)
+
+// Prompts are workflows a user picks from the client's prompt menu. They only
+// produce instructions: the model still fetches everything through the tools
+// and resources, whose authorization applies unchanged. Getting a prompt is
+// itself authorized and audited (auth.PromptMiddleware).
+func createPrompts(ksServer *KubescapeMcpserver) {
+	triage := mcp.NewPrompt(triagePromptName,
+		mcp.WithPromptDescription("Triage the top failing controls in a namespace: scan it, rank the failed controls, explain each one and propose fixes."),
+		mcp.WithArgument("namespace",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Namespace to triage"),
+		),
+		mcp.WithArgument("framework",
+			mcp.ArgumentDescription("Framework to scan against (optional, defaults to nsa)"),
+		),
+		mcp.WithArgument("limit",
+			mcp.ArgumentDescription(fmt.Sprintf("How many failing controls to triage (optional, defaults to %d, at most %d)", defaultTriageLimit, maxTriageLimit)),
+		),
+	)
+	ksServer.s.AddPrompt(triage, ksServer.GetTriagePrompt)
+
+	blastRadius := mcp.NewPrompt(cveBlastRadiusPrompt,
+		mcp.WithPromptDescription("Explain a CVE's blast radius: which workloads ship the vulnerable package, whether it is loaded at runtime, and what an attacker could reach from them."),
+		mcp.WithArgument("cve_id",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("ID of the CVE (e.g. CVE-2024-3094)"),
+		),
+		mcp.WithArgument("namespace",
+			mcp.ArgumentDescription("Namespace to limit the analysis to (optional, defaults to all namespaces)"),
+		),
+	)
+	ksServer.s.AddPrompt(blastRadius, ksServer.GetCVEBlastRadiusPrompt)
+}
+
+func (ksServer *KubescapeMcpserver) GetTriagePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	namespace := strings.TrimSpace(request.Params.Arguments["namespace"])
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace argument is required")
+	}
+	framework := strings.TrimSpace(request.Params.Arguments["framework"])
+	if framework == "" {
+		framework = defaultTriageFramework
+	}
+	limit := defaultTriageLimit
+	if raw := strings.TrimSpace(request.Params.Arguments["limit"]); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxTriageLimit {
+			return nil, fmt.Errorf("limit must be a number between 1 and %d", maxTriageLimit)
+		}
+		limit = n
+	}
+
+	text := fmt.Sprintf(`Triage the top %[1]d failing Kubescape controls in namespace %[2]q.
+
+1. Call run_framework_security_scan with namespace %[2]q and framework_name %[3]q.
+2. Read %[4]s. Its failed_controls list is ordered by how many resources failed each control; take the first %[1]d.
+3. For each of them, read its uri (kubescape://controls/{control_id}) for the description, remediation and the Rego/CEL logic that failed.
+4. Using the failed resources the scan returned, explain for each control:
+   - what is wrong and why it matters in this namespace;
+   - which resources are affected;
+   - the concrete fix (a manifest change where possible), and anything that could break when it is applied.
+5. If the scan reports degraded results or controls that were not evaluated, say so: the ranking may be incomplete.
+
+Finish with the fixes ordered by risk reduced per unit of effort.`, limit, namespace, framework, latestScanURI)
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Triage the top %d failing controls in namespace %s", limit, namespace),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text))},
+	), nil
+}
+
+func (ksServer *KubescapeMcpserver) GetCVEBlastRadiusPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	cveID := strings.TrimSpace(request.Params.Arguments["cve_id"])
+	if cveID == "" {
+		return nil, fmt.Errorf("cve_id argument is required")
+	}
+	namespace := strings.TrimSpace(request.Params.Arguments["namespace"])
+	scope := "across all namespaces"
+	namespaceArg := "without a namespace"
+	if namespace != "" {
+		scope = fmt.Sprintf("in namespace %q", namespace)
+		namespaceArg = fmt.Sprintf("with namespace %q", namespace)
+	}
+
+	text := fmt.Sprintf(`Explain the blast radius of %[1]s %[2]s.
+
+1. Call list_vulnerability_manifests %[3]s to find the workloads and images Kubescape has scanned.
+2. For each manifest, call list_vulnerability_matches_for_cve with cve_id %[1]q. Keep the ones that match, and note the package, installed version and fixed version.
+3. Relevant (workload-level) manifests tell you whether the vulnerable package is actually loaded at runtime; prefer them over image-level ones, and say which case applies.
+4. For each affected workload, read kubescape://workloads/{namespace}/{kind}/{name}/configuration-scan and call get_container_profile to see how it runs: privileges, host access, mounted secrets, service account, and the network and syscalls it really uses.
+5. Call run_rbac_security_scan and run_network_security_scan %[3]s to see what the affected workloads' service accounts can do and whether their traffic is restricted.
+
+Summarize:
+- which workloads are exposed, and which ones only ship the package without loading it;
+- what an attacker who exploits %[1]s in each could reach next (cluster API, secrets, other workloads, the node);
+- the fix (upgrade target) and the configuration changes that shrink the blast radius until it is rolled out.`, cveID, scope, namespaceArg)
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Blast radius of %s %s", cveID, scope),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text))},
+	), nil
+}
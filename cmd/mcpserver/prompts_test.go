@@ -0,0 +1,75 @@
+package mcpserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getPromptRequest(args map[string]string) mcp.GetPromptRequest {
+	var req mcp.GetPromptRequest
+	req.Params.Arguments = args
+	return req
+}
+
+func promptText(t *testing.T, result *mcp.GetPromptResult) string {
+	t.Helper()
+	require.NotNil(t, result)
+	require.Len(t, result.Messages, 1)
+	assert.Equal(t, mcp.RoleUser, result.Messages[0].Role)
+	text, ok := result.Messages[0].Content.(mcp.TextContent)
+	require.True(t, ok)
+	return text.Text
+}
+
+func TestGetTriagePrompt(t *testing.T) {
+	ksServer := &KubescapeMcpserver{}
+
+	result, err := ksServer.GetTriagePrompt(context.Background(), getPromptRequest(map[string]string{"namespace": "payments"}))
+	require.NoError(t, err)
+	text := promptText(t, result)
+	assert.Contains(t, text, `namespace "payments"`)
+	assert.Contains(t, text, `framework_name "nsa"`)
+	assert.Contains(t, text, "top 5")
+	assert.Contains(t, text, latestScanURI)
+
+	result, err = ksServer.GetTriagePrompt(context.Background(), getPromptRequest(map[string]string{"namespace": "payments", "framework": "mitre", "limit": "3"}))
+	require.NoError(t, err)
+	text = promptText(t, result)
+	assert.Contains(t, text, `framework_name "mitre"`)
+	assert.Contains(t, text, "top 3")
+
+	for name, args := range map[string]map[string]string{
+		"missing namespace":  {},
+		"blank namespace":    {"namespace": "  "},
+		"limit not a number": {"namespace": "payments", "limit": "many"},
+		"limit too large":    {"namespace": "payments", "limit": "500"},
+		"limit zero":         {"namespace": "payments", "limit": "0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ksServer.GetTriagePrompt(context.Background(), getPromptRequest(args))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetCVEBlastRadiusPrompt(t *testing.T) {
+	ksServer := &KubescapeMcpserver{}
+
+	result, err := ksServer.GetCVEBlastRadiusPrompt(context.Background(), getPromptRequest(map[string]string{"cve_id": "CVE-2024-3094"}))
+	require.NoError(t, err)
+	text := promptText(t, result)
+	assert.Contains(t, text, "CVE-2024-3094 across all namespaces")
+	assert.Contains(t, text, "list_vulnerability_matches_for_cve")
+	assert.Contains(t, text, "kubescape://workloads/{namespace}/{kind}/{name}/configuration-scan")
+
+	result, err = ksServer.GetCVEBlastRadiusPrompt(context.Background(), getPromptRequest(map[string]string{"cve_id": "CVE-2024-3094", "namespace": "web"}))
+	require.NoError(t, err)
+	assert.Contains(t, promptText(t, result), `with namespace "web"`)
+
+	_, err = ksServer.GetCVEBlastRadiusPrompt(context.Background(), getPromptRequest(nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,511 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/cmd/mcpserver/auth"
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor/cel"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	frameworksURI         = "kubescape://frameworks"
+	frameworkURIPrefix    = "kubescape://frameworks/"
+	controlURIPrefix      = "kubescape://controls/"
+	latestScanURI         = "kubescape://scans/latest"
+	workloadURIPrefix     = "kubescape://workloads/"
+	workloadScanURISuffix = "/configuration-scan"
+	resourceUpdatedMethod = "notifications/resources/updated"
+	workloadKindLabel     = "kubescape.io/workload-kind"
+	workloadNameLabel     = "kubescape.io/workload-name"
+	mimeTypeJSON          = "application/json"
+)
+
+// frameworkEntry is one framework in the kubescape://frameworks listing.
+type frameworkEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Controls    int    `json:"controls"`
+	URI         string `json:"uri"`
+}
+
+// frameworkControlEntry is one control inside a framework resource; the
+// control's own resource carries the full detail.
+type frameworkControlEntry struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Severity string `json:"severity"`
+	URI      string `json:"uri"`
+}
+
+type frameworkResource struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	Controls    []frameworkControlEntry `json:"controls"`
+}
+
+type controlRuleSource struct {
+	Name               string `json:"name"`
+	Language           string `json:"language"`
+	Description        string `json:"description,omitempty"`
+	Rego               string `json:"rego,omitempty"`
+	ResourceEnumerator string `json:"resource_enumerator,omitempty"`
+}
+
+// controlCELSource is the control's ValidatingAdmissionPolicy from the embedded
+// CEL library, the same expressions the CEL engine evaluates for it.
+type controlCELSource struct {
+	PolicyName      string               `json:"policy_name"`
+	MatchConditions []cel.MatchCondition `json:"match_conditions,omitempty"`
+	Variables       []cel.Variable       `json:"variables,omitempty"`
+	Validations     []cel.Validation     `json:"validations"`
+}
+
+type controlResource struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Severity    string              `json:"severity"`
+	ScoreFactor float32             `json:"score_factor"`
+	Description string              `json:"description,omitempty"`
+	Remediation string              `json:"remediation,omitempty"`
+	Rules       []controlRuleSource `json:"rules"`
+	CEL         *controlCELSource   `json:"cel,omitempty"`
+}
+
+// failedControlSummary counts the resources one control failed on in a scan.
+type failedControlSummary struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	FailedResources int    `json:"failed_resources"`
+	URI             string `json:"uri"`
+}
+
+// scanSummary is what kubescape://scans/latest serves: the headline numbers of
+// the most recent scan this server ran, without the failed resources
+// themselves (the tool result that produced it already returned those).
+type scanSummary struct {
+	Scan                 string                 `json:"scan"`
+	Namespace            string                 `json:"namespace,omitempty"`
+	Inputs               []string               `json:"inputs,omitempty"`
+	CompletedAt          time.Time              `json:"completed_at"`
+	ComplianceScore      *float32               `json:"compliance_score,omitempty"`
+	FrameworkName        string                 `json:"framework_name,omitempty"`
+	Degraded             bool                   `json:"degraded"`
+	NotEvaluatedControls int                    `json:"not_evaluated_controls"`
+	TotalControls        int                    `json:"total_controls"`
+	TotalFailed          int                    `json:"total_failed"`
+	FailedControls       []failedControlSummary `json:"failed_controls"`
+}
+
+// summarizeScan builds the kubescape://scans/latest payload from a finished
+// scan. Failed controls are ordered by how many resources they failed on, most
+// first, so the top of the list is where triage should start.
+func summarizeScan(label, namespace string, inputs []string, response scanResponse, results map[string]resourcesresults.Result) scanSummary {
+	byID := map[string]*failedControlSummary{}
+	for _, result := range results {
+		for i := range result.AssociatedControls {
+			ac := &result.AssociatedControls[i]
+			if !ac.GetStatus(nil).IsFailed() {
+				continue
+			}
+			entry, ok := byID[ac.GetID()]
+			if !ok {
+				entry = &failedControlSummary{ID: ac.GetID(), Name: ac.GetName(), URI: controlURIPrefix + url.PathEscape(ac.GetID())}
+				byID[ac.GetID()] = entry
+			}
+			entry.FailedResources++
+		}
+	}
+	failed := make([]failedControlSummary, 0, len(byID))
+	for _, entry := range byID {
+		failed = append(failed, *entry)
+	}
+	sort.Slice(failed, func(i, j int) bool {
+		if failed[i].FailedResources != failed[j].FailedResources {
+			return failed[i].FailedResources > failed[j].FailedResources
+		}
+		return failed[i].ID < failed[j].ID
+	})
+
+	return scanSummary{
+		Scan:                 label,
+		Namespace:            namespace,
+		Inputs:               inputs,
+		CompletedAt:          time.Now().UTC(),
+		ComplianceScore:      response.ComplianceScore,
+		FrameworkName:        response.FrameworkName,
+		Degraded:             response.Degraded,
+		NotEvaluatedControls: response.NotEvaluatedControls,
+		TotalControls:        response.TotalControls,
+		TotalFailed:          response.TotalFailed,
+		FailedControls:       failed,
+	}
+}
+
+// scanOwner identifies the caller of the request ctx carries, whose latest
+// scan kubescape://scans/latest is. The callers of a transport without
+// authentication are one and the same.
+func scanOwner(ctx context.Context) string {
+	caller := auth.CallerFromContext(ctx)
+	if caller == nil {
+		return ""
+	}
+	return caller.Method + ":" + caller.Subject
+}
+
+// recordScan makes summary the latest scan of the caller of ctx and tells the
+// caller's sessions subscribed to kubescape://scans/latest that it changed.
+func (ksServer *KubescapeMcpserver) recordScan(ctx context.Context, summary scanSummary) {
+	owner := scanOwner(ctx)
+	ksServer.latestScanMu.Lock()
+	if ksServer.latestScans == nil {
+		ksServer.latestScans = make(map[string]*scanSummary)
+	}
+	ksServer.latestScans[owner] = &summary
+	ksServer.latestScanMu.Unlock()
+	ksServer.notifyResourceUpdated(latestScanURI, owner)
+}
+
+// getLatestScan returns the latest scan of the caller of ctx.
+func (ksServer *KubescapeMcpserver) getLatestScan(ctx context.Context) *scanSummary {
+	ksServer.latestScanMu.Lock()
+	defer ksServer.latestScanMu.Unlock()
+	return ksServer.latestScans[scanOwner(ctx)]
+}
+
+// notifyResourceUpdated sends notifications/resources/updated for uri to every
+// session owner subscribed to it with. Sessions that went away in the
+// meantime are skipped; a client that misses an update can always re-read the
+// resource.
+func (ksServer *KubescapeMcpserver) notifyResourceUpdated(uri, owner string) {
+	if ksServer.s == nil {
+		return
+	}
+	for _, sessionID := range ksServer.subscriptions.subscribers(uri, owner) {
+		if err := ksServer.s.SendNotificationToSpecificClient(sessionID, resourceUpdatedMethod, map[string]any{"uri": uri}); err != nil {
+			logger.L().Debug("failed to notify MCP session of resource update",
+				helpers.String("session", sessionID),
+				helpers.String("uri", uri),
+				helpers.Error(err))
+		}
+	}
+}
+
+// resourceSubscriptions tracks which sessions subscribed to which resource
+// URIs, and as which caller (see scanOwner). mcp-go acknowledges
+// resources/subscribe but leaves tracking to the server, so this hooks into
+// subscribe, unsubscribe and session teardown. A nil *resourceSubscriptions
+// has no subscribers.
+type resourceSubscriptions struct {
+	mu        sync.Mutex
+	bySession map[string]map[string]struct{}
+	owners    map[string]string
+}
+
+func newResourceSubscriptions() *resourceSubscriptions {
+	return &resourceSubscriptions{bySession: map[string]map[string]struct{}{}, owners: map[string]string{}}
+}
+
+// hooks returns the server hooks that keep r up to date.
+func (r *resourceSubscriptions) hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterSubscribe(func(ctx context.Context, _ any, message *mcp.SubscribeRequest, _ *mcp.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			r.subscribe(session.SessionID(), scanOwner(ctx), message.Params.URI)
+		}
+	})
+	hooks.AddAfterUnsubscribe(func(ctx context.Context, _ any, message *mcp.UnsubscribeRequest, _ *mcp.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			r.unsubscribe(session.SessionID(), message.Params.URI)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		r.drop(session.SessionID())
+	})
+	return hooks
+}
+
+func (r *resourceSubscriptions) subscribe(sessionID, owner, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.bySession[sessionID] == nil {
+		r.bySession[sessionID] = map[string]struct{}{}
+	}
+	r.bySession[sessionID][uri] = struct{}{}
+	r.owners[sessionID] = owner
+}
+
+func (r *resourceSubscriptions) unsubscribe(sessionID, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.bySession[sessionID], uri)
+}
+
+func (r *resourceSubscriptions) drop(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.bySession, sessionID)
+	delete(r.owners, sessionID)
+}
+
+// subscribers returns the IDs of the sessions owner subscribed to uri with,
+// sorted.
+func (r *resourceSubscriptions) subscribers(uri, owner string) []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for sessionID, uris := range r.bySession {
+		if _, ok := uris[uri]; ok && r.owners[sessionID] == owner {
+			ids = append(ids, sessionID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func createPolicyResources(ksServer *KubescapeMcpserver) {
+	frameworks := mcp.NewResource(
+		frameworksURI,
+		"Frameworks",
+		mcp.WithResourceDescription("All security frameworks available for scanning, with their descriptions and control counts. Each entry links to its kubescape://frameworks/{framework_name} resource."),
+		mcp.WithMIMEType(mimeTypeJSON),
+	)
+	ksServer.s.AddResource(frameworks, ksServer.ReadFrameworksResource)
+
+	frameworkTemplate := mcp.NewResourceTemplate(
+		frameworkURIPrefix+"{framework_name}",
+		"Framework",
+		mcp.WithTemplateDescription("A security framework (e.g. nsa, mitre) and the controls it is made of, with their severities."),
+		mcp.WithTemplateMIMEType(mimeTypeJSON),
+	)
+	ksServer.s.AddResourceTemplate(frameworkTemplate, ksServer.ReadFrameworkResource)
+
+	controlTemplate := mcp.NewResourceTemplate(
+		controlURIPrefix+"{control_id}",
+		"Control",
+		mcp.WithTemplateDescription("A security control (e.g. C-0016): description, remediation, and the Rego and CEL source it is evaluated with."),
+		mcp.WithTemplateMIMEType(mimeTypeJSON),
+	)
+	ksServer.s.AddResourceTemplate(controlTemplate, ksServer.ReadControlResource)
+}
+
+func createScanResources(ksServer *KubescapeMcpserver) {
+	latestScan := mcp.NewResource(
+		latestScanURI,
+		"Latest Scan Summary",
+		mcp.WithResourceDescription("Summary of the most recent scan you ran on this server: compliance score, totals, and the failed controls ordered by how many resources failed them. Subscribe to be notified when a new scan lands."),
+		mcp.WithMIMEType(mimeTypeJSON),
+	)
+	ksServer.s.AddResource(latestScan, ksServer.ReadLatestScanResource)
+
+	workloadScanTemplate := mcp.NewResourceTemplate(
+		workloadURIPrefix+"{namespace}/{kind}/{name}"+workloadScanURISuffix,
+		"Workload Configuration Scan",
+		mcp.WithTemplateDescription("The in-cluster configuration scan of one workload (e.g. kubescape://workloads/default/Deployment/nginx/configuration-scan), looked up by the workload rather than by manifest name."),
+		mcp.WithTemplateMIMEType(mimeTypeJSON),
+	)
+	ksServer.s.AddResourceTemplate(workloadScanTemplate, ksServer.ReadWorkloadConfigurationResource)
+}
+
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", uri, err)
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: mimeTypeJSON,
+		Text:     string(data),
+	}}, nil
+}
+
+// uriParam returns the single path segment of uri after prefix, unescaped.
+func uriParam(uri, prefix string) (string, error) {
+	if !strings.HasPrefix(uri, prefix) {
+		return "", fmt.Errorf("invalid URI: %s", uri)
+	}
+	raw := uri[len(prefix):]
+	if raw == "" || strings.Contains(raw, "/") {
+		return "", fmt.Errorf("invalid URI: %s", uri)
+	}
+	value, err := url.PathUnescape(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URI: %s", uri)
+	}
+	return value, nil
+}
+
+func (ksServer *KubescapeMcpserver) ReadFrameworksResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	frameworks, err := ksServer.getPolicyGetter().GetFrameworks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get frameworks: %w", err)
+	}
+	entries := make([]frameworkEntry, 0, len(frameworks))
+	for i := range frameworks {
+		entries = append(entries, frameworkEntry{
+			Name:        frameworks[i].Name,
+			Description: frameworks[i].Description,
+			Controls:    len(frameworks[i].Controls),
+			URI:         frameworkURIPrefix + url.PathEscape(frameworks[i].Name),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return jsonResource(request.Params.URI, entries)
+}
+
+func (ksServer *KubescapeMcpserver) ReadFrameworkResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name, err := uriParam(request.Params.URI, frameworkURIPrefix)
+	if err != nil {
+		return nil, err
+	}
+	framework, err := ksServer.getPolicyGetter().GetFramework(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get framework %s: %w", name, err)
+	}
+	return jsonResource(request.Params.URI, newFrameworkResource(framework))
+}
+
+func newFrameworkResource(framework *reporthandling.Framework) frameworkResource {
+	res := frameworkResource{
+		Name:        framework.Name,
+		Description: framework.Description,
+		Controls:    make([]frameworkControlEntry, 0, len(framework.Controls)),
+	}
+	for i := range framework.Controls {
+		control := &framework.Controls[i]
+		res.Controls = append(res.Controls, frameworkControlEntry{
+			ID:       control.ControlID,
+			Name:     control.Name,
+			Severity: apis.ControlSeverityToString(control.BaseScore),
+			URI:      controlURIPrefix + url.PathEscape(control.ControlID),
+		})
+	}
+	sort.Slice(res.Controls, func(i, j int) bool { return res.Controls[i].ID < res.Controls[j].ID })
+	return res
+}
+
+func (ksServer *KubescapeMcpserver) ReadControlResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id, err := uriParam(request.Params.URI, controlURIPrefix)
+	if err != nil {
+		return nil, err
+	}
+	control, err := ksServer.getPolicyGetter().GetControl(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get control %s: %w", id, err)
+	}
+	return jsonResource(request.Params.URI, newControlResource(control))
+}
+
+// newControlResource renders a control with the source of its rules. Controls
+// in the embedded CEL library also carry their ValidatingAdmissionPolicy
+// expressions: a CEL rule's own text is not what gets evaluated (the engine
+// loads the policy by control ID), so without them the logic would be missing.
+func newControlResource(control *reporthandling.Control) controlResource {
+	res := controlResource{
+		ID:          control.ControlID,
+		Name:        control.Name,
+		Severity:    apis.ControlSeverityToString(control.BaseScore),
+		ScoreFactor: control.BaseScore,
+		Description: control.Description,
+		Remediation: control.Remediation,
+		Rules:       make([]controlRuleSource, 0, len(control.Rules)),
+	}
+	for i := range control.Rules {
+		rule := &control.Rules[i]
+		source := controlRuleSource{
+			Name:        rule.Name,
+			Language:    string(rule.RuleLanguage),
+			Description: rule.Description,
+		}
+		if rule.RuleLanguage != reporthandling.CELLanguage {
+			source.Rego = rule.Rule
+			source.ResourceEnumerator = rule.ResourceEnumerator
+		}
+		res.Rules = append(res.Rules, source)
+	}
+	if exprs, err := cel.ExpressionsForControl(control.ControlID); err == nil {
+		res.CEL = &controlCELSource{
+			PolicyName:      exprs.PolicyName,
+			MatchConditions: exprs.MatchConditions,
+			Variables:       exprs.Variables,
+			Validations:     exprs.Validations,
+		}
+	}
+	return res
+}
+
+func (ksServer *KubescapeMcpserver) ReadLatestScanResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	latest := ksServer.getLatestScan(ctx)
+	if latest == nil {
+		return nil, fmt.Errorf("no scan has completed yet: run a scan tool (e.g. run_framework_security_scan) first")
+	}
+	return jsonResource(request.Params.URI, latest)
+}
+
+// workloadScanURI holds the parsed components of a
+// kubescape://workloads/{namespace}/{kind}/{name}/configuration-scan URI.
+type workloadScanURI struct {
+	namespace string
+	kind      string
+	name      string
+}
+
+func parseWorkloadScanURI(uri string) (*workloadScanURI, error) {
+	if !strings.HasPrefix(uri, workloadURIPrefix) || !strings.HasSuffix(uri, workloadScanURISuffix) {
+		return nil, fmt.Errorf("invalid URI: %s", uri)
+	}
+	parts := strings.Split(strings.TrimSuffix(uri[len(workloadURIPrefix):], workloadScanURISuffix), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid URI: %s", uri)
+	}
+	return &workloadScanURI{namespace: parts[0], kind: parts[1], name: parts[2]}, nil
+}
+
+// ReadWorkloadConfigurationResource finds a workload's configuration scan by
+// the workload labels the node-agent stamps on it, so a client that knows the
+// Deployment it cares about does not have to discover the manifest name first.
+func (ksServer *KubescapeMcpserver) ReadWorkloadConfigurationResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	parsed, err := parseWorkloadScanURI(request.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	client, ksErr := ksServer.getKsClient()
+	if ksErr != nil {
+		return nil, fmt.Errorf("failed to connect to Kubernetes cluster: %w", ksErr)
+	}
+	scans, err := client.WorkloadConfigurationScans(parsed.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: workloadNameLabel + "=" + parsed.name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configuration manifests: %w", err)
+	}
+	for i := range scans.Items {
+		if !strings.EqualFold(scans.Items[i].Labels[workloadKindLabel], parsed.kind) {
+			continue
+		}
+		// List may return metadata only; Get returns the full scan.
+		manifest, err := client.WorkloadConfigurationScans(parsed.namespace).Get(ctx, scans.Items[i].Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get configuration manifest: %w", err)
+		}
+		return jsonResource(request.Params.URI, manifest)
+	}
+	return nil, fmt.Errorf("no configuration scan found for %s %s/%s", parsed.kind, parsed.namespace, parsed.name)
+}
@@ -0,0 +1,287 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v4/cmd/mcpserver/auth"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	storagev1beta1 "github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	storagefake "github.com/kubescape/storage/pkg/generated/clientset/versioned/fake"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func readResourceRequest(uri string) mcp.ReadResourceRequest {
+	var req mcp.ReadResourceRequest
+	req.Params.URI = uri
+	return req
+}
+
+func resourceText(t *testing.T, contents []mcp.ResourceContents) string {
+	t.Helper()
+	require.Len(t, contents, 1)
+	text, ok := contents[0].(mcp.TextResourceContents)
+	require.True(t, ok)
+	return text.Text
+}
+
+func associatedControl(id, name string, status apis.ScanningStatus) resourcesresults.ResourceAssociatedControl {
+	return resourcesresults.ResourceAssociatedControl{
+		ControlID: id,
+		Name:      name,
+		Status:    apis.StatusInfo{InnerStatus: status},
+	}
+}
+
+func TestSummarizeScan(t *testing.T) {
+	results := map[string]resourcesresults.Result{
+		"a": {ResourceID: "a", AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+			associatedControl("C-0016", "Allow privilege escalation", apis.StatusFailed),
+			associatedControl("C-0017", "Immutable container filesystem", apis.StatusFailed),
+		}},
+		"b": {ResourceID: "b", AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+			associatedControl("C-0017", "Immutable container filesystem", apis.StatusFailed),
+			associatedControl("C-0016", "Allow privilege escalation", apis.StatusPassed),
+		}},
+		"c": {ResourceID: "c", AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+			associatedControl("C-0030", "Ingress and Egress blocked", apis.StatusFailed),
+		}},
+	}
+	score := float32(71.5)
+	response := buildScanResponse(results, &score, "nsa", false, 1, 3)
+
+	summary := summarizeScan("Framework", "prod", nil, response, results)
+	assert.Equal(t, "Framework", summary.Scan)
+	assert.Equal(t, "prod", summary.Namespace)
+	assert.Equal(t, "nsa", summary.FrameworkName)
+	require.NotNil(t, summary.ComplianceScore)
+	assert.Equal(t, score, *summary.ComplianceScore)
+	assert.Equal(t, 3, summary.TotalFailed)
+	assert.Equal(t, 1, summary.NotEvaluatedControls)
+	assert.WithinDuration(t, time.Now(), summary.CompletedAt, time.Minute)
+
+	require.Len(t, summary.FailedControls, 3)
+	assert.Equal(t, failedControlSummary{ID: "C-0017", Name: "Immutable container filesystem", FailedResources: 2, URI: "kubescape://controls/C-0017"}, summary.FailedControls[0])
+	assert.Equal(t, "C-0016", summary.FailedControls[1].ID, "ties are broken by control ID")
+	assert.Equal(t, 1, summary.FailedControls[1].FailedResources)
+	assert.Equal(t, "C-0030", summary.FailedControls[2].ID)
+}
+
+func TestNewControlResource(t *testing.T) {
+	control := &reporthandling.Control{
+		ControlID:   "C-0016",
+		Description: "Attackers may escalate privileges",
+		Remediation: "Set allowPrivilegeEscalation to false",
+		BaseScore:   7,
+		Rules: []reporthandling.PolicyRule{{
+			Rule:               "package armo_builtins\ndeny[msga] { false }",
+			RuleLanguage:       reporthandling.RegoLanguage,
+			ResourceEnumerator: "package armo_builtins\ndeny[msga] { true }",
+		}},
+	}
+	control.Name = "Allow privilege escalation"
+	control.Rules[0].Name = "rule-allow-privilege-escalation"
+
+	res := newControlResource(control)
+	assert.Equal(t, "C-0016", res.ID)
+	assert.Equal(t, "Allow privilege escalation", res.Name)
+	assert.Equal(t, apis.ControlSeverityToString(7), res.Severity)
+	assert.Equal(t, "Set allowPrivilegeEscalation to false", res.Remediation)
+	require.Len(t, res.Rules, 1)
+	assert.Equal(t, "rule-allow-privilege-escalation", res.Rules[0].Name)
+	assert.Contains(t, res.Rules[0].Rego, "package armo_builtins")
+	assert.NotEmpty(t, res.Rules[0].ResourceEnumerator)
+
+	require.NotNil(t, res.CEL, "C-0016 ships in the embedded CEL library")
+	assert.Equal(t, "kubescape-c-0016-allow-privilege-escalation", res.CEL.PolicyName)
+	assert.NotEmpty(t, res.CEL.Validations)
+
+	custom := &reporthandling.Control{ControlID: "C-9999"}
+	assert.Nil(t, newControlResource(custom).CEL, "a control outside the CEL library has no CEL source")
+	assert.NotNil(t, newControlResource(custom).Rules, "rules serialize as [] rather than null")
+}
+
+func TestNewFrameworkResource(t *testing.T) {
+	framework := &reporthandling.Framework{
+		Description: "NSA guidance",
+		Controls: []reporthandling.Control{
+			{ControlID: "C-0030", BaseScore: 6},
+			{ControlID: "C-0016", BaseScore: 7},
+		},
+	}
+	framework.Name = "nsa"
+
+	res := newFrameworkResource(framework)
+	assert.Equal(t, "nsa", res.Name)
+	assert.Equal(t, "NSA guidance", res.Description)
+	require.Len(t, res.Controls, 2)
+	assert.Equal(t, "C-0016", res.Controls[0].ID)
+	assert.Equal(t, "kubescape://controls/C-0016", res.Controls[0].URI)
+	assert.Equal(t, apis.ControlSeverityToString(7), res.Controls[0].Severity)
+}
+
+func TestURIParam(t *testing.T) {
+	value, err := uriParam("kubescape://frameworks/cis-v1.23-t1.0.1", frameworkURIPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, "cis-v1.23-t1.0.1", value)
+
+	value, err = uriParam("kubescape://frameworks/my%20framework", frameworkURIPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, "my framework", value)
+
+	for _, uri := range []string{
+		"kubescape://frameworks/",
+		"kubescape://frameworks/nsa/extra",
+		"kubescape://controls/C-0016",
+		"kubescape://frameworks/%zz",
+	} {
+		_, err := uriParam(uri, frameworkURIPrefix)
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestParseWorkloadScanURI(t *testing.T) {
+	parsed, err := parseWorkloadScanURI("kubescape://workloads/default/Deployment/nginx/configuration-scan")
+	require.NoError(t, err)
+	assert.Equal(t, &workloadScanURI{namespace: "default", kind: "Deployment", name: "nginx"}, parsed)
+
+	for _, uri := range []string{
+		"kubescape://workloads/default/Deployment/nginx",
+		"kubescape://workloads/default/nginx/configuration-scan",
+		"kubescape://workloads//Deployment/nginx/configuration-scan",
+		"kubescape://workloads/default/Deployment/nginx/extra/configuration-scan",
+		"kubescape://configuration-manifests/default/Deployment/nginx/configuration-scan",
+	} {
+		_, err := parseWorkloadScanURI(uri)
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestReadWorkloadConfigurationResource(t *testing.T) {
+	scan := func(name, kind, workload string) *storagev1beta1.WorkloadConfigurationScan {
+		return &storagev1beta1.WorkloadConfigurationScan{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{workloadKindLabel: kind, workloadNameLabel: workload},
+		}}
+	}
+	client := storagefake.NewClientset(
+		scan("deployment-nginx", "Deployment", "nginx"),
+		scan("statefulset-nginx", "StatefulSet", "nginx"),
+		scan("deployment-redis", "Deployment", "redis"),
+	)
+	ksServer := &KubescapeMcpserver{ksClient: client.SpdxV1beta1()}
+
+	contents, err := ksServer.ReadWorkloadConfigurationResource(context.Background(), readResourceRequest("kubescape://workloads/default/statefulset/nginx/configuration-scan"))
+	require.NoError(t, err)
+	var got storagev1beta1.WorkloadConfigurationScan
+	require.NoError(t, json.Unmarshal([]byte(resourceText(t, contents)), &got))
+	assert.Equal(t, "statefulset-nginx", got.Name, "the kind matches case-insensitively")
+
+	_, err = ksServer.ReadWorkloadConfigurationResource(context.Background(), readResourceRequest("kubescape://workloads/default/DaemonSet/nginx/configuration-scan"))
+	assert.ErrorContains(t, err, "no configuration scan found")
+}
+
+func TestReadLatestScanResource(t *testing.T) {
+	ksServer := &KubescapeMcpserver{}
+	_, err := ksServer.ReadLatestScanResource(context.Background(), readResourceRequest(latestScanURI))
+	assert.ErrorContains(t, err, "no scan has completed yet")
+
+	ksServer.recordScan(context.Background(), scanSummary{Scan: "RBAC", Namespace: "prod", TotalFailed: 4})
+	contents, err := ksServer.ReadLatestScanResource(context.Background(), readResourceRequest(latestScanURI))
+	require.NoError(t, err)
+	var got scanSummary
+	require.NoError(t, json.Unmarshal([]byte(resourceText(t, contents)), &got))
+	assert.Equal(t, "RBAC", got.Scan)
+	assert.Equal(t, 4, got.TotalFailed)
+}
+
+func TestReadLatestScanResource_PerCaller(t *testing.T) {
+	ksServer := &KubescapeMcpserver{}
+	alice := auth.WithCaller(context.Background(), &auth.Caller{Subject: "alice", Method: auth.MethodOIDC})
+	bob := auth.WithCaller(context.Background(), &auth.Caller{Subject: "bob", Method: auth.MethodOIDC})
+
+	ksServer.recordScan(alice, scanSummary{Scan: "RBAC", Namespace: "payments"})
+	_, err := ksServer.ReadLatestScanResource(bob, readResourceRequest(latestScanURI))
+	assert.ErrorContains(t, err, "no scan has completed yet", "a caller does not read another caller's scan")
+
+	ksServer.recordScan(bob, scanSummary{Scan: "Framework", Namespace: "default"})
+	contents, err := ksServer.ReadLatestScanResource(alice, readResourceRequest(latestScanURI))
+	require.NoError(t, err)
+	var got scanSummary
+	require.NoError(t, json.Unmarshal([]byte(resourceText(t, contents)), &got))
+	assert.Equal(t, "payments", got.Namespace, "another caller's scan does not replace a caller's own")
+}
+
+// testSession is an in-process client session that captures notifications.
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// TestLatestScanSubscription checks that a session subscribed to
+// kubescape://scans/latest is notified when a scan is recorded, and stops
+// being notified once it unsubscribes.
+func TestLatestScanSubscription(t *testing.T) {
+	subscriptions := newResourceSubscriptions()
+	s := server.NewMCPServer("test", "0.0.1",
+		server.WithResourceCapabilities(true, false),
+		server.WithHooks(subscriptions.hooks()),
+	)
+	ksServer := &KubescapeMcpserver{s: s, subscriptions: subscriptions}
+
+	subscriber := &testSession{id: "subscriber", notifications: make(chan mcp.JSONRPCNotification, 4)}
+	bystander := &testSession{id: "bystander", notifications: make(chan mcp.JSONRPCNotification, 4)}
+	require.NoError(t, s.RegisterSession(context.Background(), subscriber))
+	require.NoError(t, s.RegisterSession(context.Background(), bystander))
+
+	sendAs := func(ctx context.Context, session server.ClientSession, method string) {
+		msg, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": map[string]any{"uri": latestScanURI}})
+		require.NoError(t, err)
+		resp := s.HandleMessage(s.WithContext(ctx, session), msg)
+		_, isError := resp.(mcp.JSONRPCError)
+		require.False(t, isError, "%s failed: %v", method, resp)
+	}
+	send := func(session server.ClientSession, method string) {
+		sendAs(context.Background(), session, method)
+	}
+	send(subscriber, "resources/subscribe")
+	assert.Equal(t, []string{"subscriber"}, subscriptions.subscribers(latestScanURI, ""))
+	// The bystander subscribes as another caller, whose latest scan is not
+	// the one recorded below.
+	sendAs(auth.WithCaller(context.Background(), &auth.Caller{Subject: "bob", Method: auth.MethodToken}), bystander, "resources/subscribe")
+
+	ksServer.recordScan(context.Background(), scanSummary{Scan: "Framework"})
+	select {
+	case n := <-subscriber.notifications:
+		assert.Equal(t, resourceUpdatedMethod, n.Method)
+		assert.Equal(t, latestScanURI, n.Params.AdditionalFields["uri"])
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not notified")
+	}
+	assert.Empty(t, bystander.notifications)
+
+	send(subscriber, "resources/unsubscribe")
+	assert.Empty(t, subscriptions.subscribers(latestScanURI, ""))
+	ksServer.recordScan(context.Background(), scanSummary{Scan: "Framework"})
+	assert.Empty(t, subscriber.notifications)
+
+	send(subscriber, "resources/subscribe")
+	s.UnregisterSession(context.Background(), subscriber.id)
+	assert.Empty(t, subscriptions.subscribers(latestScanURI, ""), "a closed session's subscriptions are dropped")
+}
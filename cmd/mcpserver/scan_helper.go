@@ -119,6 +119,7 @@ func runScan(ctx context.Context, ksServer *KubescapeMcpserver, namespace string
 	}
 
 	response := buildScanResponse(scanData.ResourcesResult, complianceScore, frameworkName, degraded, notEvaluated, totalControls)
+	ksServer.recordScan(ctx, summarizeScan(label, namespace, inputPatterns, response, scanData.ResourcesResult))
 
 	logger.L().Ctx(ctx).Info(fmt.Sprintf("Completed on-demand MCP %s security scan", label),
 		helpers.Int("failed_resources", response.TotalFailed),
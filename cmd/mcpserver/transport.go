@@ -0,0 +1,151 @@
+package mcpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/cmd/mcpserver/auth"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	transportStdio          = "stdio"
+	transportSSE            = "sse"
+	transportStreamableHTTP = "http"
+)
+
+// serverOptions are the mcpserver command's flags.
+type serverOptions struct {
+	transport     string
+	port          int
+	listenAddress string
+	endpointPath  string
+	tlsCertFile   string
+	tlsKeyFile    string
+	// authConfigPath is an auth.Config file; without it HTTP transports are
+	// unauthenticated and may only listen on a loopback address.
+	authConfigPath string
+	// auditLogPath receives one JSON line per tool call; "-" is stderr.
+	auditLogPath string
+}
+
+func (o *serverOptions) isHTTP() bool {
+	return o.transport == transportSSE || o.transport == transportStreamableHTTP
+}
+
+func (o *serverOptions) validate() error {
+	switch o.transport {
+	case transportStdio, transportSSE, transportStreamableHTTP:
+	case "streamable-http":
+		o.transport = transportStreamableHTTP
+	default:
+		return fmt.Errorf("unsupported transport '%s': must be 'stdio', 'sse' or 'http'", o.transport)
+	}
+
+	if !o.isHTTP() {
+		if o.tlsCertFile != "" || o.tlsKeyFile != "" || o.authConfigPath != "" {
+			return fmt.Errorf("--tls-cert, --tls-key and --auth-config only apply to the 'sse' and 'http' transports")
+		}
+		return nil
+	}
+	if (o.tlsCertFile == "") != (o.tlsKeyFile == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	// An unauthenticated MCP server hands out cluster read access (and
+	// remediation dry-runs) to anyone who can reach it, so only allow that
+	// where the only callers are local processes, as before these flags existed.
+	if o.authConfigPath == "" && !isLoopback(o.listenAddress) {
+		return fmt.Errorf("refusing to listen on %s without authentication: pass --auth-config, or listen on a loopback address", o.listenAddress)
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newAccessControl loads the authenticator and audit log the options ask for.
+// Either may be nil.
+func newAccessControl(ctx context.Context, o serverOptions) (*auth.Authenticator, *auth.AuditLog, error) {
+	var authn *auth.Authenticator
+	if o.authConfigPath != "" {
+		cfg, err := auth.LoadConfig(o.authConfigPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if authn, err = auth.NewAuthenticator(ctx, cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	var audit *auth.AuditLog
+	if o.auditLogPath != "" {
+		var err error
+		if audit, err = auth.OpenAuditLog(o.auditLogPath); err != nil {
+			return nil, nil, err
+		}
+	}
+	return authn, audit, nil
+}
+
+// newHTTPHandler wraps the MCP server in the HTTP transport o selects, behind
+// authn when it is set.
+func newHTTPHandler(s *server.MCPServer, o serverOptions, authn *auth.Authenticator) http.Handler {
+	var handler http.Handler
+	mux := http.NewServeMux()
+	switch o.transport {
+	case transportSSE:
+		handler = server.NewSSEServer(s, server.WithSSEContextFunc(auth.HTTPContextFunc))
+		if authn != nil {
+			handler = authn.Middleware(handler)
+		}
+		mux.Handle("/", handler)
+	default:
+		handler = server.NewStreamableHTTPServer(s,
+			server.WithEndpointPath(o.endpointPath),
+			server.WithHTTPContextFunc(auth.HTTPContextFunc),
+		)
+		if authn != nil {
+			handler = authn.Middleware(handler)
+		}
+		mux.Handle(o.endpointPath, handler)
+	}
+	return mux
+}
+
+func serveHTTP(s *server.MCPServer, o serverOptions, authn *auth.Authenticator) error {
+	addr := net.JoinHostPort(o.listenAddress, strconv.Itoa(o.port))
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           newHTTPHandler(s, o, authn),
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	logger.L().Info("Starting MCP HTTP server",
+		helpers.String("transport", o.transport),
+		helpers.String("addr", addr),
+		helpers.String("tls", strconv.FormatBool(o.tlsCertFile != "")),
+		helpers.String("auth", strconv.FormatBool(authn != nil)))
+
+	var err error
+	if o.tlsCertFile != "" {
+		err = srv.ListenAndServeTLS(o.tlsCertFile, o.tlsKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("%s server error: %w", o.transport, err)
+	}
+	return nil
+}
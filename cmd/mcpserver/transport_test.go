@@ -0,0 +1,128 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/cmd/mcpserver/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    serverOptions
+		wantErr string
+	}{
+		{name: "stdio", opts: serverOptions{transport: "stdio"}},
+		{name: "sse on loopback", opts: serverOptions{transport: "sse", listenAddress: "127.0.0.1"}},
+		{name: "http alias", opts: serverOptions{transport: "streamable-http", listenAddress: "localhost"}},
+		{name: "http on all interfaces with auth", opts: serverOptions{transport: "http", listenAddress: "0.0.0.0", authConfigPath: "auth.yaml"}},
+		{name: "unknown transport", opts: serverOptions{transport: "websocket"}, wantErr: "unsupported transport"},
+		{name: "tls on stdio", opts: serverOptions{transport: "stdio", tlsCertFile: "c", tlsKeyFile: "k"}, wantErr: "only apply"},
+		{name: "cert without key", opts: serverOptions{transport: "http", listenAddress: "127.0.0.1", tlsCertFile: "c"}, wantErr: "together"},
+		{name: "unauthenticated non-loopback", opts: serverOptions{transport: "http", listenAddress: "0.0.0.0"}, wantErr: "without authentication"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestStreamableHTTPAuthorization drives the http transport end to end: the
+// bearer token is checked before the MCP layer, and the caller it resolves to
+// reaches the tool middleware, which denies and audits per tool.
+func TestStreamableHTTPAuthorization(t *testing.T) {
+	sum := sha256.Sum256([]byte("reader-token"))
+	authn, err := auth.NewAuthenticator(context.Background(), &auth.Config{Tokens: []auth.StaticToken{
+		{Subject: "reader", SHA256: hex.EncodeToString(sum[:]), Roles: []string{auth.RoleReadOnly}},
+	}})
+	require.NoError(t, err)
+	var auditBuf bytes.Buffer
+	audit := auth.NewAuditLog(&auditBuf)
+
+	s := server.NewMCPServer("test", "0.0.1",
+		server.WithToolCapabilities(false),
+		server.WithToolHandlerMiddleware(auth.ToolMiddleware(authn, audit, nil)),
+	)
+	for _, name := range []string{"list_frameworks", "dry_run_remediation"} {
+		s.AddTool(mcp.NewTool(name), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("ran " + request.Params.Name), nil
+		})
+	}
+
+	srv := httptest.NewServer(newHTTPHandler(s, serverOptions{transport: transportStreamableHTTP, endpointPath: "/mcp"}, authn))
+	defer srv.Close()
+
+	post := func(token, sessionID string, body map[string]any) *http.Response {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/mcp", bytes.NewReader(b))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	initialize := map[string]any{
+		"jsonrpc": "2.0", "id": 1, "method": "initialize",
+		"params": map[string]any{"protocolVersion": "2025-03-26", "capabilities": map[string]any{}, "clientInfo": map[string]any{"name": "test", "version": "1"}},
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("", "", initialize).StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, post("wrong", "", initialize).StatusCode)
+
+	resp := post("reader-token", "", initialize)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+
+	callTool := func(name string) string {
+		resp := post("reader-token", sessionID, map[string]any{
+			"jsonrpc": "2.0", "id": 2, "method": "tools/call",
+			"params": map[string]any{"name": name, "arguments": map[string]any{"namespace": "prod"}},
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		return buf.String()
+	}
+
+	assert.Contains(t, callTool("list_frameworks"), "ran list_frameworks")
+	denied := callTool("dry_run_remediation")
+	assert.Contains(t, denied, "forbidden")
+	assert.NotContains(t, denied, "ran dry_run_remediation")
+
+	lines := strings.Split(strings.TrimSpace(auditBuf.String()), "\n")
+	require.Len(t, lines, 2)
+	var entry auth.AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "reader", entry.Caller.Subject)
+	assert.Equal(t, "dry_run_remediation", entry.Tool)
+	assert.Equal(t, auth.DecisionDenied, entry.Decision)
+	assert.Equal(t, map[string]any{"namespace": "prod"}, entry.Arguments)
+}
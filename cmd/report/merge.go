@@ -0,0 +1,56 @@
+package report
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/core"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
+	"github.com/spf13/cobra"
+)
+
+var mergeExample = fmt.Sprintf(`
+  Merge command combines the JSON reports of several clusters, scanned with
+  the same frameworks at the same versions, into one fleet report.
+
+  # Scan every cluster to its own JSON report, then merge them
+  1) %[1]s scan framework nsa --kube-context prod --format json --output prod.json
+  2) %[1]s scan framework nsa --kube-context staging --format json --output staging.json
+  3) %[1]s report merge prod.json staging.json
+
+  # Write the fleet report as HTML and PDF, with a control-by-cluster heatmap
+  %[1]s report merge reports/*.json --format html,pdf --output fleet
+
+  # Write the fleet report as JSON
+  %[1]s report merge prod.json staging.json --format json --output fleet.json
+`, cautils.ExecName())
+
+func getMergeCmd(ks meta.IKubescape) *cobra.Command {
+	var mergeInfo metav1.MergeReportsInfo
+
+	mergeCmd := &cobra.Command{
+		Use:     "merge <report.json>...",
+		Short:   "Merge per-cluster JSON scan reports into a fleet report",
+		Long:    `Merge saved JSON scan reports, one per cluster, into a fleet report with a score per cluster and per namespace and the controls failing in the most clusters. Compliance scores are recomputed from the resource results of each report, so reports written by different Kubescape versions are scored alike. The reports must have been scanned with the same frameworks at the same versions.`,
+		Example: mergeExample,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mergeInfo.Files = args
+			if err := core.ValidateFleetFormats(mergeInfo.Formats(), mergeInfo.Output); err != nil {
+				return err
+			}
+			_, err := ks.MergeReports(cmd.Context(), &mergeInfo)
+			return err
+		},
+	}
+
+	mergeCmd.Flags().StringVarP(&mergeInfo.Format, "format", "f", printer.PrettyFormat, fmt.Sprintf(`Output formats, comma-separated: "%s"`, strings.Join(slices.Sorted(maps.Keys(core.FleetFormats)), `", "`)))
+	mergeCmd.Flags().StringVarP(&mergeInfo.Output, "output", "o", "", "Output file; defaults to stdout. Each format is written with its own extension")
+
+	return mergeCmd
+}
@@ -0,0 +1,60 @@
+package report
+
+import (
+	"context"
+	"testing"
+
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/mocks"
+	"github.com/kubescape/kubescape/v4/core/pkg/fleet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKubescape records what MergeReports was called with.
+type stubKubescape struct {
+	mocks.MockIKubescape
+	received *metav1.MergeReportsInfo
+}
+
+func (s *stubKubescape) MergeReports(_ context.Context, info *metav1.MergeReportsInfo) (*fleet.Report, error) {
+	copy := *info
+	s.received = &copy
+	return &fleet.Report{}, nil
+}
+
+func TestMergeCmd(t *testing.T) {
+	ks := &stubKubescape{}
+	cmd := GetReportCmd(ks)
+	cmd.SetArgs([]string{"merge", "prod.json", "staging.json", "--format", "html, pdf", "--output", "fleet"})
+
+	require.NoError(t, cmd.Execute())
+	require.NotNil(t, ks.received)
+	assert.Equal(t, []string{"prod.json", "staging.json"}, ks.received.Files)
+	assert.Equal(t, []string{"html", "pdf"}, ks.received.Formats())
+	assert.Equal(t, "fleet", ks.received.Output)
+}
+
+func TestMergeCmd_Validation(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "needs a report", args: []string{"merge"}, wantError: "requires at least 1 arg"},
+		{name: "single-cluster format", args: []string{"merge", "prod.json", "--format", "sarif"}, wantError: `format "sarif" is not supported`},
+		{name: "pdf needs an output file", args: []string{"merge", "prod.json", "--format", "pdf"}, wantError: "needs an output file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := &stubKubescape{}
+			cmd := GetReportCmd(ks)
+			cmd.SilenceUsage = true
+			cmd.SetArgs(tt.args)
+
+			require.ErrorContains(t, cmd.Execute(), tt.wantError)
+			assert.Nil(t, ks.received)
+		})
+	}
+}
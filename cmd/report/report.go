@@ -0,0 +1,28 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	"github.com/spf13/cobra"
+)
+
+var reportExample = fmt.Sprintf(`
+  # Merge the JSON reports of several clusters into one fleet report
+  %[1]s report merge prod.json staging.json
+`, cautils.ExecName())
+
+func GetReportCmd(ks meta.IKubescape) *cobra.Command {
+
+	// reportCmd represents the report command
+	reportCmd := &cobra.Command{
+		Use:     "report",
+		Short:   "Work with saved Kubescape scan reports",
+		Example: reportExample,
+	}
+
+	reportCmd.AddCommand(getMergeCmd(ks))
+
+	return reportCmd
+}
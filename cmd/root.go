@@ -8,21 +8,27 @@ import (
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/kubescape/v4/cmd/admission"
 	"github.com/kubescape/kubescape/v4/cmd/completion"
 	"github.com/kubescape/kubescape/v4/cmd/config"
 	"github.com/kubescape/kubescape/v4/cmd/decrypt"
 	"github.com/kubescape/kubescape/v4/cmd/diff"
 	"github.com/kubescape/kubescape/v4/cmd/download"
+	"github.com/kubescape/kubescape/v4/cmd/explain"
 	"github.com/kubescape/kubescape/v4/cmd/fix"
 	"github.com/kubescape/kubescape/v4/cmd/list"
 	"github.com/kubescape/kubescape/v4/cmd/mcpserver"
 	"github.com/kubescape/kubescape/v4/cmd/operator"
 	"github.com/kubescape/kubescape/v4/cmd/patch"
 	"github.com/kubescape/kubescape/v4/cmd/prerequisites"
+	"github.com/kubescape/kubescape/v4/cmd/report"
+	"github.com/kubescape/kubescape/v4/cmd/rules"
 	"github.com/kubescape/kubescape/v4/cmd/scan"
 	"github.com/kubescape/kubescape/v4/cmd/update"
 	"github.com/kubescape/kubescape/v4/cmd/vap"
 	"github.com/kubescape/kubescape/v4/cmd/version"
+	"github.com/kubescape/kubescape/v4/cmd/watch"
+	"github.com/kubescape/kubescape/v4/cmd/worker"
 	"github.com/kubescape/kubescape/v4/core/cautils"
 	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	"github.com/kubescape/kubescape/v4/core/core"
@@ -117,8 +123,14 @@ func getRootCmd(ks meta.IKubescape, ksVersion, ksCommit, ksDate string) *cobra.C
 	rootCmd.AddCommand(update.GetUpdateCmd(ks))
 	rootCmd.AddCommand(fix.GetFixCmd(ks))
 	rootCmd.AddCommand(diff.GetDiffCmd(ks))
+	rootCmd.AddCommand(watch.GetWatchCmd(ks))
+	rootCmd.AddCommand(report.GetReportCmd(ks))
 	rootCmd.AddCommand(patch.GetPatchCmd(ks))
 	rootCmd.AddCommand(vap.GetVapHelperCmd())
+	rootCmd.AddCommand(admission.GetAdmissionCmd(ks))
+	rootCmd.AddCommand(explain.GetExplainCmd(ks))
+	rootCmd.AddCommand(rules.GetRulesCmd(ks))
+	rootCmd.AddCommand(worker.GetWorkerCmd(ks))
 	rootCmd.AddCommand(operator.GetOperatorCmd(ks))
 	rootCmd.AddCommand(prerequisites.GetPreReqCmd(ks))
 	rootCmd.AddCommand(mcpserver.GetMCPServerCmd())
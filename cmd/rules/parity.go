@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
+	"github.com/spf13/cobra"
+)
+
+var parityExample = fmt.Sprintf(`
+  Parity command runs the Rego rules and the CEL admission policy of every
+  control implemented both ways on a corpus of manifests, and reports the
+  resources they disagree on. It exits with 1 when they disagree.
+
+  # Compare every control of the CEL bundle on local manifests
+  %[1]s rules parity ./manifests
+
+  # Compare two controls on the test fixtures of a regolibrary checkout,
+  # with the Rego controls of the same checkout
+  %[1]s rules parity ../regolibrary --controls C-0016,C-0017 --use-from ../regolibrary/release/controls.json
+
+  # Compare verdicts only, and write the report as JSON
+  %[1]s rules parity ./manifests --ignore-paths --format json --output parity.json
+`, cautils.ExecName())
+
+func getParityCmd(ks meta.IKubescape) *cobra.Command {
+	var parityInfo metav1.RulesParityInfo
+
+	parityCmd := &cobra.Command{
+		Use:     "parity <path>...",
+		Short:   "Compare the Rego and CEL implementations of controls on manifests",
+		Long:    `Run the Rego rules and the CEL admission policy of every control implemented both ways on manifests, and report per control the resources they disagree on: the two verdicts, the failed and fix paths only one side reported, and the resource minimized to the fields the disagreement depends on. A path holding a regolibrary checkout is split into the test inputs of its rules, each compared on the controls using the rule.`,
+		Example: parityExample,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parityInfo.InputPaths = args
+			if parityInfo.Format != printer.PrettyFormat && parityInfo.Format != printer.JsonFormat {
+				return fmt.Errorf("invalid format %q, expected %s or %s", parityInfo.Format, printer.PrettyFormat, printer.JsonFormat)
+			}
+
+			divergences, err := ks.RulesParity(cmd.Context(), &parityInfo)
+			if err != nil {
+				return err
+			}
+			if divergences > 0 {
+				return fmt.Errorf("found %d disagreement(s) between the Rego and CEL implementations", divergences)
+			}
+			return nil
+		},
+	}
+
+	parityCmd.Flags().StringSliceVar(&parityInfo.ControlIDs, "controls", nil, "Controls to compare; defaults to every control of the embedded CEL bundle")
+	parityCmd.Flags().StringSliceVar(&parityInfo.UseFrom, "use-from", nil, "Load the Rego controls from the specified policy files. If not used will download latest")
+	parityCmd.Flags().StringVar(&parityInfo.ControlsInputs, "controls-config", "", "Path to a controls-config obj. If not set will download controls-config from ARMO management portal")
+	parityCmd.Flags().BoolVar(&parityInfo.IgnorePaths, "ignore-paths", false, "Compare verdicts only, not the failed and fix paths")
+	parityCmd.Flags().StringVarP(&parityInfo.Format, "format", "f", printer.PrettyFormat, fmt.Sprintf("Output format: %s or %s", printer.PrettyFormat, printer.JsonFormat))
+	parityCmd.Flags().StringVarP(&parityInfo.Output, "output", "o", "", "File to write the report to; defaults to stdout")
+
+	return parityCmd
+}
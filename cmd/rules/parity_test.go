@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKubescape records what RulesParity was called with and reports
+// divergences disagreements.
+type stubKubescape struct {
+	mocks.MockIKubescape
+	received    *metav1.RulesParityInfo
+	divergences int
+}
+
+func (s *stubKubescape) RulesParity(_ context.Context, info *metav1.RulesParityInfo) (int, error) {
+	copy := *info
+	s.received = &copy
+	return s.divergences, nil
+}
+
+func TestParityCmd(t *testing.T) {
+	ks := &stubKubescape{}
+	cmd := GetRulesCmd(ks)
+	cmd.SetArgs([]string{"parity", "./manifests", "../regolibrary", "--controls", "C-0016,C-0017", "--use-from", "controls.json", "--ignore-paths", "--format", "json", "--output", "parity.json"})
+
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, &metav1.RulesParityInfo{
+		InputPaths:  []string{"./manifests", "../regolibrary"},
+		ControlIDs:  []string{"C-0016", "C-0017"},
+		UseFrom:     []string{"controls.json"},
+		IgnorePaths: true,
+		Format:      "json",
+		Output:      "parity.json",
+	}, ks.received)
+}
+
+func TestParityCmd_FailsOnDisagreement(t *testing.T) {
+	ks := &stubKubescape{divergences: 2}
+	cmd := GetRulesCmd(ks)
+	cmd.SetArgs([]string{"parity", "./manifests"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), "found 2 disagreement(s)")
+	assert.Equal(t, "pretty-printer", ks.received.Format)
+}
+
+func TestParityCmd_Validation(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "no paths", args: []string{"parity"}, wantError: "requires at least 1 arg"},
+		{name: "bad format", args: []string{"parity", ".", "--format", "sarif"}, wantError: `invalid format "sarif"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := &stubKubescape{}
+			cmd := GetRulesCmd(ks)
+			cmd.SetArgs(tt.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			assert.ErrorContains(t, cmd.Execute(), tt.wantError)
+			assert.Nil(t, ks.received)
+		})
+	}
+}
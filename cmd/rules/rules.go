@@ -0,0 +1,28 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	"github.com/spf13/cobra"
+)
+
+var rulesExample = fmt.Sprintf(`
+  # Compare the Rego and CEL implementations of every control on local manifests
+  %[1]s rules parity ./manifests
+`, cautils.ExecName())
+
+func GetRulesCmd(ks meta.IKubescape) *cobra.Command {
+
+	// rulesCmd represents the rules command
+	rulesCmd := &cobra.Command{
+		Use:     "rules",
+		Short:   "Work with the rules implementing Kubescape controls",
+		Example: rulesExample,
+	}
+
+	rulesCmd.AddCommand(getParityCmd(ks))
+
+	return rulesCmd
+}
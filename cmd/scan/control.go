@@ -91,6 +91,10 @@ func getControlCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Comman
 				return err
 			}
 
+			if scanInfo.IsFleetScan() {
+				return runFleetScan(ctx, cmd, ks, scanInfo, policyIdentifiers)
+			}
+
 			results, err := ks.ScanContext(ctx, scanInfo, policyIdentifiers)
 			if err != nil {
 				return err
@@ -0,0 +1,104 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/core"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	"github.com/kubescape/kubescape/v4/core/pkg/fleet"
+	"github.com/spf13/cobra"
+)
+
+// validateFleetScanFlags rejects flag combinations a fleet scan (--contexts or
+// --all-contexts) cannot honor. Options whose result is tied to a single
+// cluster's report, such as baselines and image scanning, are not supported.
+func validateFleetScanFlags(cmd *cobra.Command, scanInfo *cautils.ScanInfo) error {
+	if !scanInfo.IsFleetScan() {
+		return nil
+	}
+	if scanInfo.AllContexts && len(scanInfo.FleetContexts) > 0 {
+		return errors.New("--contexts and --all-contexts cannot be used together")
+	}
+	if commandStringFlag(cmd, "kube-context") != "" {
+		return errors.New("--kube-context cannot be used with --contexts or --all-contexts")
+	}
+	if len(scanInfo.InputPatterns) > 0 || scanInfo.ChartPath != "" || scanInfo.FilePath != "" {
+		return errors.New("--contexts and --all-contexts scan live clusters and do not accept input files")
+	}
+	if scanInfo.FleetParallelism < 1 {
+		return fmt.Errorf("invalid --fleet-parallelism %d: must be at least 1", scanInfo.FleetParallelism)
+	}
+	for _, unsupported := range []struct {
+		flag string
+		set  bool
+	}{
+		{"--scan-images", scanInfo.ScanImages},
+		{"--baseline", scanInfo.Baseline != ""},
+		{"--dry-run", scanInfo.DryRun},
+		{"--min-severity", scanInfo.MinSeverity != ""},
+		{"--max-severity", scanInfo.MaxSeverity != ""},
+	} {
+		if unsupported.set {
+			return fmt.Errorf("%s is not supported with --contexts or --all-contexts", unsupported.flag)
+		}
+	}
+	return core.ValidateFleetFormats(scanInfo.Formats(), scanInfo.Output)
+}
+
+// runFleetScan scans every selected context, prints the fleet report and
+// applies --compliance-threshold and --severity-threshold to each cluster.
+func runFleetScan(ctx context.Context, cmd *cobra.Command, ks meta.IKubescape, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) error {
+	if err := validateFleetScanFlags(cmd, scanInfo); err != nil {
+		return err
+	}
+	report, err := ks.ScanFleet(ctx, scanInfo, policyIdentifiers)
+	if err != nil {
+		return err
+	}
+	if err := core.PrintFleetReport(ctx, report, scanInfo.Formats(), scanInfo.Output); err != nil {
+		return err
+	}
+	return enforceFleetThresholds(report, scanInfo)
+}
+
+// enforceFleetThresholds fails the fleet scan when a cluster could not be
+// scanned or any scanned cluster breaks a threshold.
+func enforceFleetThresholds(report *fleet.Report, scanInfo *cautils.ScanInfo) error {
+	if failed := report.Failed(); len(failed) > 0 {
+		names := make([]string, 0, len(failed))
+		for _, cluster := range failed {
+			names = append(names, cluster.Name)
+		}
+		return fmt.Errorf("failed to scan %d of %d clusters: %s", len(failed), len(report.Clusters), strings.Join(names, ", "))
+	}
+
+	var belowThreshold, overSeverity []string
+	threshold := fleet.SeverityRank(scanInfo.FailThresholdSeverity)
+	for _, cluster := range report.Clusters {
+		if cluster.ComplianceScore < scanInfo.ComplianceThreshold {
+			belowThreshold = append(belowThreshold, fmt.Sprintf("%s (%.2f)", cluster.Name, cluster.ComplianceScore))
+		}
+		if scanInfo.FailThresholdSeverity == "" {
+			continue
+		}
+		for _, control := range cluster.Controls {
+			// Unknown severities rank 0 and fail closed, as in single-cluster scans.
+			rank := fleet.SeverityRank(control.Severity)
+			if control.Status == fleet.StatusFailed && (rank >= threshold || rank == 0) {
+				overSeverity = append(overSeverity, cluster.Name)
+				break
+			}
+		}
+	}
+	if len(belowThreshold) > 0 {
+		return fmt.Errorf("scan compliance-score is below permitted threshold in %s (compliance-threshold: %.2f)", strings.Join(belowThreshold, ", "), scanInfo.ComplianceThreshold)
+	}
+	if len(overSeverity) > 0 {
+		return fmt.Errorf("compliance result exceeds severity threshold %s in %s", scanInfo.FailThresholdSeverity, strings.Join(overSeverity, ", "))
+	}
+	return nil
+}
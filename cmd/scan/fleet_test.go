@@ -0,0 +1,132 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/mocks"
+	"github.com/kubescape/kubescape/v4/core/pkg/fleet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fleetRecordingKubescape struct {
+	mocks.MockIKubescape
+	clusters          []fleet.ClusterResult
+	scanInfo          *cautils.ScanInfo
+	policyIdentifiers []cautils.PolicyIdentifier
+}
+
+func (m *fleetRecordingKubescape) ScanFleet(_ context.Context, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) (*fleet.Report, error) {
+	m.scanInfo = scanInfo
+	m.policyIdentifiers = policyIdentifiers
+	return fleet.NewReport([]string{"nsa"}, m.clusters), nil
+}
+
+func fleetTestClusters() []fleet.ClusterResult {
+	return []fleet.ClusterResult{
+		{Name: "prod", ComplianceScore: 60, Controls: []fleet.ControlResult{
+			{ID: "C-0017", Severity: "Low", Status: fleet.StatusFailed, FailedResources: 2},
+			{ID: "C-0002", Severity: "High", Status: fleet.StatusPassed},
+		}},
+		{Name: "staging", ComplianceScore: 90, Controls: []fleet.ControlResult{
+			{ID: "C-0002", Severity: "High", Status: fleet.StatusPassed},
+		}},
+	}
+}
+
+func TestFleetScan_WritesReport(t *testing.T) {
+	ks := &fleetRecordingKubescape{clusters: fleetTestClusters()}
+	output := filepath.Join(t.TempDir(), "fleet")
+	cmd := GetScanCommand(ks)
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{"framework", "nsa", "--contexts", "prod,staging", "--format", "json", "--output", output})
+
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, []string{"prod", "staging"}, ks.scanInfo.FleetContexts)
+	assert.Equal(t, 4, ks.scanInfo.FleetParallelism)
+	require.Len(t, ks.policyIdentifiers, 1)
+	assert.Equal(t, "nsa", ks.policyIdentifiers[0].Identifier)
+
+	data, err := os.ReadFile(output + ".json")
+	require.NoError(t, err)
+	var report fleet.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Len(t, report.Clusters, 2)
+	assert.InDelta(t, 75, report.ComplianceScore, 0.001)
+}
+
+func TestFleetScan_Thresholds(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		clusters  []fleet.ClusterResult
+		wantError string
+	}{
+		{name: "passes without thresholds", clusters: fleetTestClusters()},
+		{name: "compliance threshold is applied per cluster", args: []string{"--compliance-threshold", "70"}, clusters: fleetTestClusters(), wantError: "below permitted threshold in prod (60.00)"},
+		{name: "severity threshold at or below a failed control", args: []string{"--severity-threshold", "low"}, clusters: fleetTestClusters(), wantError: "exceeds severity threshold low in prod"},
+		{name: "severity threshold above every failed control", args: []string{"--severity-threshold", "high"}, clusters: fleetTestClusters()},
+		{name: "unreachable cluster fails the scan", clusters: append(fleetTestClusters(), fleet.ClusterResult{Name: "edge", Error: "failed connecting to Kubernetes cluster"}), wantError: "failed to scan 1 of 3 clusters: edge"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := &fleetRecordingKubescape{clusters: tt.clusters}
+			cmd := GetScanCommand(ks)
+			cmd.SilenceUsage = true
+			args := []string{"framework", "nsa", "--all-contexts", "--format", "json", "--output", filepath.Join(t.TempDir(), "fleet.json")}
+			cmd.SetArgs(append(args, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantError)
+		})
+	}
+}
+
+func TestFleetScan_RejectsUnsupportedFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "contexts and all-contexts", args: []string{"--contexts", "prod", "--all-contexts"}, wantError: "cannot be used together"},
+		{name: "image scanning", args: []string{"--contexts", "prod", "--scan-images"}, wantError: "--scan-images is not supported"},
+		{name: "baseline", args: []string{"--contexts", "prod", "--baseline", "base.json"}, wantError: "--baseline is not supported"},
+		{name: "output-only severity filter", args: []string{"--contexts", "prod", "--min-severity", "high"}, wantError: "--min-severity is not supported"},
+		{name: "single-cluster format", args: []string{"--contexts", "prod", "--format", "sarif"}, wantError: `format "sarif" is not supported`},
+		{name: "parallelism", args: []string{"--contexts", "prod", "--fleet-parallelism", "0"}, wantError: "invalid --fleet-parallelism 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := &fleetRecordingKubescape{}
+			cmd := GetScanCommand(ks)
+			cmd.SilenceUsage = true
+			cmd.SetArgs(append([]string{"framework", "nsa"}, tt.args...))
+
+			require.ErrorContains(t, cmd.Execute(), tt.wantError)
+			assert.Nil(t, ks.scanInfo, "the fleet is not scanned")
+		})
+	}
+}
+
+func TestFleetScan_RejectsInputFiles(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "deployment.yaml")
+	require.NoError(t, os.WriteFile(manifest, []byte("apiVersion: apps/v1\nkind: Deployment\n"), 0o600))
+	ks := &fleetRecordingKubescape{}
+	cmd := GetScanCommand(ks)
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{"framework", "nsa", manifest, "--contexts", "prod"})
+
+	require.ErrorContains(t, cmd.Execute(), "do not accept input files")
+	assert.Nil(t, ks.scanInfo)
+}
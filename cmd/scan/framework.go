@@ -37,6 +37,9 @@ var (
   # Scan kubernetes YAML manifest files (single file or glob)
   %[1]s scan framework nsa .
 
+  # Scan a framework definition composing controls of other frameworks and custom rules
+  %[1]s scan framework ./our-baseline.yaml
+
   Run '%[1]s list frameworks' for the list of supported frameworks
 `, cautils.ExecName())
 
@@ -111,6 +114,10 @@ func getFrameworkCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Comm
 
 			policyIdentifiers := cautils.BuildPolicyIdentifiers(frameworks, apisv1.KindFramework)
 
+			if scanInfo.IsFleetScan() {
+				return runFleetScan(ctx, cmd, ks, scanInfo, policyIdentifiers)
+			}
+
 			results, err := ks.ScanContext(ctx, scanInfo, policyIdentifiers)
 			if err != nil {
 				return err
@@ -29,27 +29,39 @@ var (
   # Scan the linux/amd64 variant from a multi-architecture image index
   %[1]s scan image "nginx" --platform linux/amd64
 
+  # Match a pre-generated CycloneDX or SPDX SBOM instead of pulling the image
+  %[1]s scan image "registry.example.com/app:1.2.3" --sbom app.cdx.json
+  %[1]s scan image --sbom app.spdx.json
+
 `, cautils.ExecName())
 )
 
 // getImageCmd returns the scan image command
 func getImageCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Command {
+	var sbomPath string
+	checkArgs := func(args []string) error {
+		if sbomPath != "" && len(args) <= 1 {
+			return nil
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("the command takes exactly one image name as an argument")
+		}
+		return nil
+	}
+
 	cmd := &cobra.Command{
 		Use:     "image <image>:<tag> [flags]",
 		Short:   "Scan an image for vulnerabilities",
 		Example: imageExample,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) != 1 {
-				return fmt.Errorf("the command takes exactly one image name as an argument")
-			}
-			return nil
+			return checkArgs(args)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, cancel := deriveTimeoutContext(scanInfo, ks)
 			defer cancel()
 
-			if len(args) != 1 {
-				return fmt.Errorf("the command takes exactly one image name as an argument")
+			if err := checkArgs(args); err != nil {
+				return err
 			}
 
 			if err := shared.ValidateCommonScanFlags(cmd, scanInfo, shared.ImageScanFormats); err != nil {
@@ -71,7 +83,19 @@ func getImageCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Command
 				return err
 			}
 
-			imageName := args[0]
+			if sbomPath != "" {
+				if scanInfo.ImagePlatform != "" {
+					return fmt.Errorf("--platform cannot be used with --sbom: the SBOM already describes a single platform")
+				}
+				if _, err := os.Stat(sbomPath); err != nil {
+					return fmt.Errorf("cannot read SBOM: %w", err)
+				}
+			}
+
+			var imageName string
+			if len(args) == 1 {
+				imageName = args[0]
+			}
 			if strings.HasSuffix(imageName, ".tar") && !strings.HasPrefix(imageName, "docker-archive:") && !strings.HasPrefix(imageName, "oci-archive:") {
 				if _, err := os.Stat(imageName); err == nil {
 					imageName = "docker-archive:" + imageName
@@ -81,6 +105,7 @@ func getImageCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Command
 			imgScanInfo := &metav1.ImageScanInfo{
 				Authority:          credentials.Authority,
 				Image:              imageName,
+				SBOM:               sbomPath,
 				Platform:           scanInfo.ImagePlatform,
 				Username:           credentials.Username,
 				Password:           credentials.Password,
@@ -105,6 +130,7 @@ func getImageCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Command
 	cmd.PersistentFlags().StringVarP(&scanInfo.RegistryUsername, "username", "u", "", "Username for registry login")
 	cmd.PersistentFlags().StringVarP(&scanInfo.RegistryPassword, "password", "p", "", "Password for registry login")
 	cmd.PersistentFlags().StringVar(&scanInfo.ImagePlatform, "platform", "", "OCI platform to scan, for example linux/amd64 or linux/arm64/v8")
+	cmd.PersistentFlags().StringVar(&sbomPath, "sbom", "", "Match this pre-generated SBOM (syft JSON, CycloneDX or SPDX, or a cosign attestation of one) instead of pulling the image. The image argument is optional and defaults to the image named in the SBOM")
 
 	return cmd
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -390,3 +391,40 @@ func TestGetImageCmd_RunE_ForwardsLocalTarball(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "docker-archive:"+tmpFile.Name(), mockKubescape.imgScanInfo.Image)
 }
+
+func TestGetImageCmd_RunE_SBOM(t *testing.T) {
+	sbomPath := filepath.Join(t.TempDir(), "app.cdx.json")
+	require.NoError(t, os.WriteFile(sbomPath, []byte(`{"bomFormat":"CycloneDX","specVersion":"1.5"}`), 0o600))
+
+	newCmd := func() (*cobra.Command, *imageScanCaptureKubescape) {
+		mockKubescape := &imageScanCaptureKubescape{}
+		scanInfo := cautils.ScanInfo{}
+		cmd := getImageCmd(mockKubescape, &scanInfo)
+		parentCmd := &cobra.Command{Use: "scan"}
+		parentCmd.PersistentFlags().StringVarP(&scanInfo.Format, "format", "f", "pretty-printer", "")
+		parentCmd.AddCommand(cmd)
+		return cmd, mockKubescape
+	}
+
+	cmd, mockKubescape := newCmd()
+	require.NoError(t, cmd.PersistentFlags().Set("sbom", sbomPath))
+	require.NoError(t, cmd.Args(cmd, nil))
+	require.NoError(t, cmd.RunE(cmd, nil))
+	assert.Equal(t, sbomPath, mockKubescape.imgScanInfo.SBOM)
+	assert.Empty(t, mockKubescape.imgScanInfo.Image)
+
+	cmd, mockKubescape = newCmd()
+	require.NoError(t, cmd.PersistentFlags().Set("sbom", sbomPath))
+	require.NoError(t, cmd.RunE(cmd, []string{"registry.example.com/app:1.2.3"}))
+	assert.Equal(t, "registry.example.com/app:1.2.3", mockKubescape.imgScanInfo.Image)
+
+	cmd, _ = newCmd()
+	require.NoError(t, cmd.PersistentFlags().Set("sbom", sbomPath))
+	require.NoError(t, cmd.PersistentFlags().Set("platform", "linux/amd64"))
+	assert.ErrorContains(t, cmd.RunE(cmd, nil), "--platform cannot be used with --sbom")
+
+	cmd, _ = newCmd()
+	require.NoError(t, cmd.PersistentFlags().Set("sbom", filepath.Join(t.TempDir(), "missing.json")))
+	assert.ErrorContains(t, cmd.RunE(cmd, nil), "cannot read SBOM")
+	assert.Error(t, cmd.Args(cmd, []string{"a", "b"}))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/kubescape/kubescape/v4/core/meta"
 	"github.com/kubescape/kubescape/v4/core/pkg/reportcrypto"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling"
+	"github.com/kubescape/kubescape/v4/core/pkg/shardworker"
 	"github.com/kubescape/kubescape/v4/pkg/imagescan"
 	v1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
 	"github.com/spf13/cobra"
@@ -82,12 +84,21 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 			if scanInfo.ControlTimeout < 0 {
 				return fmt.Errorf("invalid --control-timeout %s: must be zero or positive", scanInfo.ControlTimeout)
 			}
+			if scanInfo.EvalConcurrency < 0 {
+				return fmt.Errorf("invalid --eval-concurrency %d: must be zero or positive", scanInfo.EvalConcurrency)
+			}
 			if strings.Contains(scanInfo.ControlsVersion, "/") {
 				return fmt.Errorf(
 					"invalid --controls-version %q: must be a regolibrary release tag and cannot contain '/'",
 					scanInfo.ControlsVersion,
 				)
 			}
+			if scanInfo.PolicyBundle != "" && (scanInfo.ControlsVersion != "" || scanInfo.UseArtifactsFrom != "") {
+				return fmt.Errorf("--policy-bundle cannot be combined with --controls-version or --use-artifacts-from")
+			}
+			if err := validateWorkers(&scanInfo); err != nil {
+				return err
+			}
 			if scanInfo.Baseline != "" && scanInfo.BaselineSeverityThreshold != "" {
 				if err := shared.ValidateSeverity(scanInfo.BaselineSeverityThreshold); err != nil {
 					return err
@@ -123,6 +134,11 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 			if scanInfo.View == string(cautils.SecurityViewType) {
 				policyIdentifiers := setSecurityViewScanInfo(args, &scanInfo)
 
+				if scanInfo.IsFleetScan() {
+					ctx, cancel := deriveTimeoutContext(&scanInfo, ks)
+					defer cancel()
+					return runFleetScan(ctx, cmd, ks, &scanInfo, policyIdentifiers)
+				}
 				if err := securityScan(scanInfo, ks, policyIdentifiers); err != nil {
 					return err
 				}
@@ -163,6 +179,11 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 	scanCmd.PersistentFlags().StringVar(&scanInfo.MinSeverity, "min-severity", "", "Only include controls at or above this severity (low, medium, high, critical) in the output. Does not affect exit codes — --compliance-threshold, --severity-threshold, --fail-coverage-below and --fail-on-degraded-config are always computed on the full unfiltered report")
 	scanCmd.PersistentFlags().StringVar(&scanInfo.MaxSeverity, "max-severity", "", "Only include controls at or below this severity (low, medium, high, critical) in the output. Does not affect exit codes — thresholds are always computed on the full unfiltered report")
 
+	scanCmd.PersistentFlags().StringVar(&scanInfo.ScoringProfile, "scoring-profile", "", "Path to a YAML or JSON scoring profile defining severity weights, control overrides, namespace multipliers and how exceptions and action-required results count. Applies to every compliance score and to --compliance-threshold")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.Ownership, "ownership", "", "Path to a YAML or JSON ownership file mapping namespaces, label selectors, Helm releases and file paths to owners. Tags every result with its owner")
+	scanCmd.PersistentFlags().StringSliceVar(&scanInfo.ReportAs, "report-as", nil, fmt.Sprintf("Also report the results per clause of these standards, through a crosswalk mapping controls onto their clauses (%s, or one added with --crosswalk). Shown in the pretty-printer, JSON, HTML and PDF outputs", strings.Join(cautils.CrosswalkStandards(), ", ")))
+	scanCmd.PersistentFlags().StringSliceVar(&scanInfo.Crosswalks, "crosswalk", nil, "Path to a YAML or JSON crosswalk mapping controls onto the clauses of a standard, replacing the shipped crosswalk of that standard or adding one. Can be repeated")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.SplitByOwner, "split-by-owner", "", "Directory to write one report per owner to, in every format given with --format. Requires --ownership")
 	scanCmd.PersistentFlags().Float32VarP(&scanInfo.ComplianceThreshold, "compliance-threshold", "", 0, "Compliance threshold is the percent below which the command fails and returns exit code 1. Applies to 'scan framework', 'scan control', and '--view resource|control'")
 	scanCmd.PersistentFlags().Float32Var(&scanInfo.FailCoverageThreshold, "fail-coverage-below", 0, "Fail (exit code 1) when the scan coverage score drops below this percentage (0 to disable). The score is the ratio of evaluated controls discounted by 3 points per silent failed GVR pull (a resource type that failed to collect entirely but whose dependent controls still evaluated via other resource types), 2 points per partial GVR pull, and 5 points per degraded policy input, so a scan with every control evaluated can still fail on partial resource collection or fallback policy inputs")
 	scanCmd.PersistentFlags().BoolVar(&scanInfo.FailOnDegradedConfig, "fail-on-degraded-config", false, "Fail the scan (exit code 1) if control configurations or exceptions could not be loaded from their configured source and bundled defaults were used instead")
@@ -170,6 +191,8 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 	scanCmd.PersistentFlags().StringVar(&scanInfo.FailThresholdSeverity, "severity-threshold", "", "Severity threshold is the severity of failed controls at which the command fails and returns exit code 1. Failed controls whose severity is unknown (missing base score) are treated as exceeding any threshold")
 	scanCmd.PersistentFlags().BoolVar(&scanInfo.OnlyFixable, "only-fixable", false, "When used with --severity-threshold on image scans, only count CVEs that have an available fix toward the pass/fail decision")
 	scanCmd.PersistentFlags().StringVar(&scanInfo.ControlsVersion, "controls-version", "", "Pin the regolibrary release tag used to download controls (see https://github.com/kubescape/regolibrary/releases). If not used will download the latest release. Has no effect when --account is set (cloud backend is used instead)")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.PolicyBundle, "policy-bundle", "", "Load the frameworks, controls-config, exceptions and attack tracks from a signed OCI policy bundle, e.g: --policy-bundle oci://registry.example.com/kubescape/policies:v1. Requires --policy-bundle-key")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.PolicyBundleKey, "policy-bundle-key", "", "Cosign public key (file, URL or KMS reference) the policy bundle signature is verified with")
 
 	// --fail-threshold was removed as a functioning flag, but its registration must stay so
 	// pflag/cobra keep accepting it instead of erroring with "unknown flag" for callers who
@@ -204,7 +227,7 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 	scanCmd.PersistentFlags().BoolVarP(&scanInfo.VerboseMode, "verbose", "v", false, "Display all of the input resources and not only failed resources")
 	scanCmd.PersistentFlags().BoolVarP(&scanInfo.ShowEvidence, "show-evidence", "E", false, "Show evidence paths with current field values for each failed control (pretty-printer only)")
 	scanCmd.PersistentFlags().BoolVar(&scanInfo.ShowSecrets, "show-secrets", false, "Show secret field values in evidence output. By default secret values are redacted. Only effective with --show-evidence")
-	scanCmd.PersistentFlags().StringVar(&scanInfo.View, "view", string(cautils.SecurityViewType), fmt.Sprintf("View results based on the %s/%s/%s. default is --view=%s", cautils.ResourceViewType, cautils.ControlViewType, cautils.SecurityViewType, cautils.SecurityViewType))
+	scanCmd.PersistentFlags().StringVar(&scanInfo.View, "view", string(cautils.SecurityViewType), fmt.Sprintf("View results based on the %s/%s/%s/%s. default is --view=%s", cautils.ResourceViewType, cautils.ControlViewType, cautils.SecurityViewType, cautils.OwnerViewType, cautils.SecurityViewType))
 	scanCmd.PersistentFlags().BoolVar(&scanInfo.UseDefault, "use-default", false, "Load local policy object from default path. If not used will download latest")
 	scanCmd.PersistentFlags().StringSliceVar(&scanInfo.UseFrom, "use-from", nil, "Load local policy object from specified path. If not used will download latest")
 	scanCmd.PersistentFlags().StringVar(&scanInfo.FormatVersion, "format-version", "v2", "Output object can be different between versions, this is for maintaining backward and forward compatibility. Supported:'v1'/'v2'")
@@ -233,9 +256,15 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 	scanCmd.PersistentFlags().BoolVar(&scanInfo.SkipDBUpdate, "skip-db-update", false, "Do not update the vulnerability database before scanning images. Uses the locally cached database; fails if none is cached.")
 	scanCmd.PersistentFlags().DurationVar(&scanInfo.ScanTimeout, "scan-timeout", 0, "Maximum duration for the scan (e.g. 5m, 30s, 1h). 0 means no timeout. When the timeout is reached the scan exits with a non-zero code.")
 	scanCmd.PersistentFlags().DurationVar(&scanInfo.ControlTimeout, "control-timeout", 0, "Maximum duration for evaluating a single control (e.g. 30s, 1m). 0 means no timeout. Controls that exceed this are marked as not evaluated and the scan continues. Must be lower than --scan-timeout when both are set.")
+	scanCmd.PersistentFlags().IntVar(&scanInfo.EvalConcurrency, "eval-concurrency", 0, "Number of controls evaluated concurrently. 0 means one per CPU. Results are the same at any concurrency. With --enable-streaming, fewer controls run at once on namespaces too large to evaluate concurrently within the streaming memory bound.")
 	scanCmd.PersistentFlags().BoolVar(&scanInfo.EnableStreaming, "enable-streaming", false, "Enable resource streaming for large clusters to reduce memory usage. Resources are processed in batches instead of loading all at once. Automatically enabled for clusters with >2500 resources.")
 	scanCmd.PersistentFlags().BoolVar(&scanInfo.DryRun, "dry-run", false, "Check whether the current credentials can list every resource type the requested policies need, without collecting resources or evaluating controls. Cluster scans only.")
 	scanCmd.PersistentFlags().BoolVar(&scanInfo.Incremental, "incremental", false, "Cache the verdict for each resource, keyed by a hash of its spec/metadata plus the controls-config version, and skip re-evaluating unchanged resources on the next scan. Opt-in; scan output is unaffected. Cache automatically invalidates when the controls-config version changes; clear it manually with 'kubescape config delete cache'.")
+	scanCmd.PersistentFlags().StringSliceVar(&scanInfo.Workers, "workers", nil, "Base URLs of 'kubescape worker' processes to distribute the control evaluation to, e.g. --workers http://127.0.0.1:9091,http://127.0.0.1:9092. The scan collects the resources and merges the results; the workers evaluate the controls by namespace. The bearer token of KS_API_TOKEN is presented when set. Cannot be combined with --enable-streaming or --incremental")
+	scanCmd.PersistentFlags().DurationVar(&scanInfo.WorkerTimeout, "worker-timeout", shardworker.DefaultShardTimeout, "Maximum duration for a worker to evaluate a shard of controls with --workers. A shard a worker does not answer in time is sent to the next worker")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.WorkersLocalFallback, "workers-local-fallback", false, "Evaluate locally the shards of controls no worker could evaluate with --workers, instead of failing the scan")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.SBOMDir, "sbom-dir", "", "Directory of pre-generated SBOMs (syft JSON, CycloneDX or SPDX, or cosign attestations of them) for --scan-images. An image with an SBOM there that names it by tag or digest is matched from the SBOM instead of being pulled; other images are pulled as usual")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.ImageCache, "image-cache", false, "Cache image scan results, keyed by image manifest digest, platform, vulnerability DB build and matcher config, and reuse them instead of matching unchanged images again. Digest references skip pulling on a hit. Opt-in; the cache automatically invalidates when the vulnerability DB is updated; clear it manually with 'kubescape config delete cache --images'.")
 
 	// Helm value override flags. Mirror `helm install` so users can pass overrides through verbatim
 	// when scanning a Helm chart directory. Note: -f is already taken by --format, so --values is long-only.
@@ -252,12 +281,22 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 	scanCmd.PersistentFlags().StringArrayVar(&scanInfo.HelmSetFileValues, "set-file", nil, "Set Helm values from respective files specified via the command line (can specify multiple)")
 	scanCmd.PersistentFlags().StringVar(&scanInfo.HelmReleaseName, "release-name", "", "Helm release name made available as .Release.Name when rendering the chart")
 	scanCmd.PersistentFlags().StringVar(&scanInfo.HelmReleaseNamespace, "release-namespace", "", "Helm release namespace made available as .Release.Namespace when rendering the chart")
+	scanCmd.PersistentFlags().StringArrayVar(&scanInfo.JsonnetJPaths, "jsonnet-jpath", nil, "Directory to search for Jsonnet libraries when evaluating .jsonnet files, after the vendor/ and lib/ directories of their jsonnet-bundler project. May be repeated")
+	scanCmd.PersistentFlags().StringArrayVar(&scanInfo.JsonnetExtStrs, "jsonnet-ext-str", nil, "Jsonnet external variable, as <key>=<value>, for .jsonnet files and Tanka environments. May be repeated")
+	scanCmd.PersistentFlags().StringArrayVar(&scanInfo.JsonnetTLAStrs, "jsonnet-tla-str", nil, "Jsonnet top-level argument, as <key>=<value>, for .jsonnet files and Tanka environments. May be repeated")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.GitOps, "gitops", false, "Render Argo CD Applications and ApplicationSets and Flux HelmReleases and Kustomizations found in the scanned files into the resources they deploy, and scan those too. File scans only")
+	scanCmd.PersistentFlags().StringArrayVar(&scanInfo.GitOpsSources, "gitops-source", nil, "Local stand-in for a source GitOps objects reference, as <source>=<path>. <source> is a Git, Helm or OCI repository URL or a Flux source <Kind>/<namespace>/<name>; <path> is a checkout, a chart directory or a chart archive. May be repeated")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.HelmReleases, "helm-releases", false, "Read the Helm v3 releases stored in the cluster and attribute the resources they deployed to their release, chart, template and values. Needs permission to list Secrets. Cluster scans only")
 
 	// hidden flags
 	_ = scanCmd.PersistentFlags().MarkHidden("omit-raw-resources") // #nosec G104 -- flag defined on this command; MarkHidden only errors for an unknown flag
 	_ = scanCmd.PersistentFlags().MarkHidden("print-attack-tree")  // #nosec G104 -- flag defined on this command; MarkHidden only errors for an unknown flag
 	_ = scanCmd.PersistentFlags().MarkHidden("format-version")     // #nosec G104 -- flag defined on this command; MarkHidden only errors for an unknown flag
 
+	scanCmd.PersistentFlags().StringSliceVar(&scanInfo.FleetContexts, "contexts", nil, "Scan each of these kubeconfig contexts with the same policies and print one fleet report comparing them, e.g: --contexts prod-eu,prod-us,staging")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.AllContexts, "all-contexts", false, "Scan every context in the kubeconfig with the same policies and print one fleet report comparing them")
+	scanCmd.PersistentFlags().IntVar(&scanInfo.FleetParallelism, "fleet-parallelism", core.DefaultFleetParallelism, "Number of clusters evaluated concurrently with --contexts or --all-contexts. Connecting to a cluster and collecting its resources is always done one cluster at a time")
+
 	// Retrieve --kubeconfig flag from https://github.com/kubernetes/kubectl/blob/master/pkg/cmd/cmd.go
 	scanCmd.PersistentFlags().AddGoFlag(flag.Lookup("kubeconfig"))
 
@@ -275,10 +314,46 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 	return scanCmd
 }
 
+// validateWorkers checks the --workers URLs. A distributed scan collects every
+// resource before the evaluation starts, so it does not stream, and the
+// workers have no incremental cache to consult.
+func validateWorkers(scanInfo *cautils.ScanInfo) error {
+	if len(scanInfo.Workers) == 0 {
+		return nil
+	}
+	if scanInfo.EnableStreaming {
+		return fmt.Errorf("--workers cannot be combined with --enable-streaming")
+	}
+	if scanInfo.Incremental {
+		return fmt.Errorf("--workers cannot be combined with --incremental")
+	}
+	if scanInfo.WorkerTimeout <= 0 {
+		return fmt.Errorf("--worker-timeout must be positive")
+	}
+	for _, worker := range scanInfo.Workers {
+		u, err := url.Parse(worker)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid --workers URL %q: expected http(s)://<host>:<port>", worker)
+		}
+	}
+	return nil
+}
+
 func validateCombinedImageScanFlags(scanInfo *cautils.ScanInfo) error {
-	if scanInfo == nil || !scanInfo.ScanImages {
+	if scanInfo == nil {
 		return nil
 	}
+	if scanInfo.SBOMDir != "" && !scanInfo.ScanImages {
+		return fmt.Errorf("--sbom-dir requires --scan-images")
+	}
+	if !scanInfo.ScanImages {
+		return nil
+	}
+	if scanInfo.SBOMDir != "" {
+		if info, err := os.Stat(scanInfo.SBOMDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("--sbom-dir %q is not a readable directory", scanInfo.SBOMDir)
+		}
+	}
 	if err := shared.ValidateImageScanInfo(scanInfo); err != nil {
 		return err
 	}
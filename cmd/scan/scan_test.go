@@ -3,6 +3,7 @@ package scan
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -495,11 +496,17 @@ func TestGetScanCommand_PersistentFlagValidation(t *testing.T) {
 		{name: "rejects empty format version", args: []string{"--format-version="}, wantError: "invalid --format-version"},
 		{name: "rejects negative scan timeout", args: []string{"--scan-timeout=-1s"}, wantError: "invalid --scan-timeout"},
 		{name: "rejects negative control timeout", args: []string{"--control-timeout=-1s"}, wantError: "invalid --control-timeout"},
+		{name: "rejects negative eval concurrency", args: []string{"--eval-concurrency=-1"}, wantError: "invalid --eval-concurrency"},
 		{name: "accepts defaults", wantCalls: 1, wantError: "scan reached"},
 		{name: "accepts format version v1", args: []string{"--format-version=v1"}, wantCalls: 1, wantError: "scan reached"},
 		{name: "accepts format version v2", args: []string{"--format-version=v2"}, wantCalls: 1, wantError: "scan reached"},
 		{name: "accepts zero timeouts", args: []string{"--scan-timeout=0", "--control-timeout=0"}, wantCalls: 1, wantError: "scan reached"},
 		{name: "accepts positive timeouts", args: []string{"--scan-timeout=2s", "--control-timeout=1s"}, wantCalls: 1, wantError: "scan reached"},
+		{name: "accepts eval concurrency", args: []string{"--eval-concurrency=4"}, wantCalls: 1, wantError: "scan reached"},
+		{name: "rejects workers without a scheme", args: []string{"--workers=127.0.0.1:9091"}, wantError: "invalid --workers URL"},
+		{name: "rejects workers with streaming", args: []string{"--workers=http://127.0.0.1:9091", "--enable-streaming"}, wantError: "--enable-streaming"},
+		{name: "rejects workers with incremental", args: []string{"--workers=http://127.0.0.1:9091", "--incremental"}, wantError: "--incremental"},
+		{name: "accepts workers", args: []string{"--workers=http://127.0.0.1:9091,https://worker-1.kubescape:9090"}, wantCalls: 1, wantError: "scan reached"},
 	}
 
 	for _, tt := range tests {
@@ -1178,6 +1185,20 @@ func TestValidateCombinedImageScanFlags(t *testing.T) {
 			},
 			wantErr: shared.ErrRegistryUsernamePassword.Error(),
 		},
+		{
+			name:     "sbom dir requires image scanning",
+			scanInfo: &cautils.ScanInfo{SBOMDir: t.TempDir()},
+			wantErr:  "--sbom-dir requires --scan-images",
+		},
+		{
+			name:     "sbom dir must exist",
+			scanInfo: &cautils.ScanInfo{ScanImages: true, SBOMDir: filepath.Join(t.TempDir(), "missing")},
+			wantErr:  "not a readable directory",
+		},
+		{
+			name:     "sbom dir with image scanning",
+			scanInfo: &cautils.ScanInfo{ScanImages: true, SBOMDir: t.TempDir()},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1231,3 +1252,29 @@ func TestGetScanCommand_SkipDBUpdateDefaultsToFalse(t *testing.T) {
 	require.NotNil(t, mockKubescape.scanInfo)
 	assert.False(t, mockKubescape.scanInfo.SkipDBUpdate)
 }
+
+func TestValidateWorkers(t *testing.T) {
+	workers := []string{"http://127.0.0.1:9091"}
+	tests := []struct {
+		name     string
+		scanInfo *cautils.ScanInfo
+		wantErr  string
+	}{
+		{name: "no workers", scanInfo: &cautils.ScanInfo{}},
+		{name: "workers", scanInfo: &cautils.ScanInfo{Workers: workers, WorkerTimeout: time.Minute}},
+		{name: "streaming", scanInfo: &cautils.ScanInfo{Workers: workers, WorkerTimeout: time.Minute, EnableStreaming: true}, wantErr: "--enable-streaming"},
+		{name: "incremental", scanInfo: &cautils.ScanInfo{Workers: workers, WorkerTimeout: time.Minute, Incremental: true}, wantErr: "--incremental"},
+		{name: "no worker timeout", scanInfo: &cautils.ScanInfo{Workers: workers}, wantErr: "--worker-timeout must be positive"},
+		{name: "bad URL", scanInfo: &cautils.ScanInfo{Workers: []string{"127.0.0.1:9091"}, WorkerTimeout: time.Minute}, wantErr: "invalid --workers URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWorkers(tt.scanInfo)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
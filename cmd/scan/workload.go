@@ -1,10 +1,8 @@
 package scan
 
 import (
-	"errors"
 	"fmt"
 	"io"
-	"regexp"
 	"strings"
 
 	"github.com/kubescape/kubescape/v4/cmd/shared"
@@ -42,7 +40,7 @@ var (
 
 `, cautils.ExecName())
 
-	ErrInvalidWorkloadIdentifier = errors.New("invalid workload identifier, expected <kind>[.<version>[.<group>]]/<name>")
+	ErrInvalidWorkloadIdentifier = shared.ErrInvalidWorkloadIdentifier
 )
 
 // controlCmd represents the control command
@@ -196,7 +194,7 @@ func parseWorkloadIdentifierString(workloadIdentifier string) (namespace, kind,
 		if x[0] == "" || x[1] == "" {
 			return "", "", "", "", ErrInvalidWorkloadIdentifier
 		}
-		parsedKind, parsedApiVersion, err := parseKindAndApiVersion(x[0])
+		parsedKind, parsedApiVersion, err := shared.ParseKindAndAPIVersion(x[0])
 		if err != nil {
 			return "", "", "", "", err
 		}
@@ -206,7 +204,7 @@ func parseWorkloadIdentifierString(workloadIdentifier string) (namespace, kind,
 		if x[0] == "" || x[1] == "" || x[2] == "" {
 			return "", "", "", "", ErrInvalidWorkloadIdentifier
 		}
-		parsedKind, parsedApiVersion, err := parseKindAndApiVersion(x[1])
+		parsedKind, parsedApiVersion, err := shared.ParseKindAndAPIVersion(x[1])
 		if err != nil {
 			return "", "", "", "", err
 		}
@@ -215,29 +213,3 @@ func parseWorkloadIdentifierString(workloadIdentifier string) (namespace, kind,
 
 	return "", "", "", "", ErrInvalidWorkloadIdentifier
 }
-
-var apiVersionPattern = regexp.MustCompile(`^v\d+((alpha|beta)\d+)?$`)
-
-func parseKindAndApiVersion(kindStr string) (kind, apiVersion string, err error) {
-	parts := strings.Split(kindStr, ".")
-	if len(parts) == 1 {
-		return kindStr, "", nil
-	}
-
-	// Reject empty components
-	for _, part := range parts {
-		if part == "" {
-			return "", "", fmt.Errorf("%w: empty component in %q", ErrInvalidWorkloadIdentifier, kindStr)
-		}
-	}
-
-	if !apiVersionPattern.MatchString(parts[1]) {
-		return "", "", fmt.Errorf("%w: %q is not a valid API version in %q", ErrInvalidWorkloadIdentifier, parts[1], kindStr)
-	}
-
-	if len(parts) >= 3 {
-		group := strings.Join(parts[2:], ".")
-		return parts[0], group + "/" + parts[1], nil // kind.version.group -> group/version
-	}
-	return parts[0], parts[1], nil // kind.version -> version
-}
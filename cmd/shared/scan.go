@@ -26,8 +26,11 @@ var ErrUnknownSeverity = fmt.Errorf("unknown severity. Supported severities are:
 var ErrBadThreshold = fmt.Errorf("bad argument: out of range threshold")
 
 var (
-	ErrKeepLocalOrSubmit        = fmt.Errorf("you can use `keep-local` or `submit`, but not both")
-	ErrOmitRawResourcesOrSubmit = fmt.Errorf("you can use `omit-raw-resources` or `submit`, but not both")
+	ErrKeepLocalOrSubmit            = fmt.Errorf("you can use `keep-local` or `submit`, but not both")
+	ErrOmitRawResourcesOrSubmit     = fmt.Errorf("you can use `omit-raw-resources` or `submit`, but not both")
+	ErrGitOpsSourceWithoutGitOps    = fmt.Errorf("--gitops-source requires --gitops")
+	ErrOwnerViewWithoutOwnership    = fmt.Errorf("--view=owner requires --ownership")
+	ErrSplitByOwnerWithoutOwnership = fmt.Errorf("--split-by-owner requires --ownership")
 )
 
 // ValidateThresholds validates that FailThreshold, ComplianceThreshold and
@@ -115,6 +118,18 @@ func ValidateCommonScanFlags(cmd *cobra.Command, scanInfo *cautils.ScanInfo, sup
 		return ErrOmitRawResourcesOrSubmit
 	}
 
+	if len(scanInfo.GitOpsSources) > 0 && !scanInfo.GitOps {
+		return ErrGitOpsSourceWithoutGitOps
+	}
+	if scanInfo.Ownership == "" {
+		if scanInfo.View == string(cautils.OwnerViewType) {
+			return ErrOwnerViewWithoutOwnership
+		}
+		if scanInfo.SplitByOwner != "" {
+			return ErrSplitByOwnerWithoutOwnership
+		}
+	}
+
 	if scanInfo.FailThresholdSeverity != "" {
 		if err := ValidateSeverity(scanInfo.FailThresholdSeverity); err != nil {
 			return err
@@ -0,0 +1,38 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidWorkloadIdentifier = errors.New("invalid workload identifier, expected <kind>[.<version>[.<group>]]/<name>")
+
+var apiVersionPattern = regexp.MustCompile(`^v\d+((alpha|beta)\d+)?$`)
+
+// ParseKindAndAPIVersion splits the <kind>[.<version>[.<group>]] part of a
+// workload identifier into the kind and the API version.
+func ParseKindAndAPIVersion(kindStr string) (kind, apiVersion string, err error) {
+	parts := strings.Split(kindStr, ".")
+	if len(parts) == 1 {
+		return kindStr, "", nil
+	}
+
+	// Reject empty components
+	for _, part := range parts {
+		if part == "" {
+			return "", "", fmt.Errorf("%w: empty component in %q", ErrInvalidWorkloadIdentifier, kindStr)
+		}
+	}
+
+	if !apiVersionPattern.MatchString(parts[1]) {
+		return "", "", fmt.Errorf("%w: %q is not a valid API version in %q", ErrInvalidWorkloadIdentifier, parts[1], kindStr)
+	}
+
+	if len(parts) >= 3 {
+		group := strings.Join(parts[2:], ".")
+		return parts[0], group + "/" + parts[1], nil // kind.version.group -> group/version
+	}
+	return parts[0], parts[1], nil // kind.version -> version
+}
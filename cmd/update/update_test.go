@@ -11,6 +11,7 @@ import (
 	"github.com/kubescape/kubescape/v4/core/cautils"
 	"github.com/kubescape/kubescape/v4/core/meta"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/fleet"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -32,6 +33,24 @@ func (s *stubKubescape) Scan(*cautils.ScanInfo, []cautils.PolicyIdentifier) (*re
 func (s *stubKubescape) ScanContext(context.Context, *cautils.ScanInfo, []cautils.PolicyIdentifier) (*resultshandling.ResultsHandler, error) {
 	return nil, nil
 }
+func (s *stubKubescape) ScanFleet(context.Context, *cautils.ScanInfo, []cautils.PolicyIdentifier) (*fleet.Report, error) {
+	return nil, nil
+}
+func (s *stubKubescape) Watch(context.Context, *metav1.WatchInfo, *cautils.ScanInfo, []cautils.PolicyIdentifier) error {
+	return nil
+}
+func (s *stubKubescape) ServeAdmission(context.Context, *metav1.AdmissionInfo, *cautils.ScanInfo, []cautils.PolicyIdentifier) error {
+	return nil
+}
+func (s *stubKubescape) ServeShardWorker(context.Context, *metav1.ShardWorkerInfo) error {
+	return nil
+}
+func (s *stubKubescape) Explain(context.Context, *metav1.ExplainInfo, *cautils.ScanInfo) error {
+	return nil
+}
+func (s *stubKubescape) RulesParity(context.Context, *metav1.RulesParityInfo) (int, error) {
+	return 0, nil
+}
 func (s *stubKubescape) List(*metav1.ListPolicies) (*metav1.ListResult, error) {
 	return nil, nil
 }
@@ -47,6 +66,9 @@ func (s *stubKubescape) Fix(*metav1.FixInfo) error { return nil }
 func (s *stubKubescape) Diff(*metav1.DiffInfo) (int, error) {
 	return 0, nil
 }
+func (s *stubKubescape) MergeReports(context.Context, *metav1.MergeReportsInfo) (*fleet.Report, error) {
+	return nil, nil
+}
 func (s *stubKubescape) Patch(*metav1.PatchInfo, *cautils.ScanInfo) (bool, error) {
 	return false, nil
 }
@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	corewatch "github.com/kubescape/kubescape/v4/core/pkg/watch"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	"github.com/spf13/cobra"
+)
+
+var watchCmdExamples = fmt.Sprintf(`
+  Watch command scans the cluster, then re-evaluates the resources that change and prints how the results changed, one JSON object per line.
+
+  # Watch the cluster against all frameworks
+  %[1]s watch
+
+  # Watch the cluster against the NSA and MITRE frameworks
+  %[1]s watch nsa,mitre
+
+  # Append the changes to a file and record them as Kubernetes Events
+  %[1]s watch --output changes.jsonl --events
+
+  # Rescan at most once a minute
+  %[1]s watch --debounce 1m
+`, cautils.ExecName())
+
+var ErrBadDebounce = errors.New("bad argument: --debounce must be positive")
+
+func GetWatchCmd(ks meta.IKubescape) *cobra.Command {
+	var watchInfo metav1.WatchInfo
+	var scanInfo cautils.ScanInfo
+
+	watchCmd := &cobra.Command{
+		Use:     "watch [<framework names list>]",
+		Short:   "Continuously scan the cluster and report how the results change",
+		Long:    `Scan the cluster, then watch the resource types the controls evaluate and rescan after every batch of changes. Rescans list from the watch caches and re-evaluate only the changed resources and the controls relating them to others. Each control that starts failing, is resolved, or goes away with its resource is printed as a JSON line, and can be recorded as a Kubernetes Event on the resource.`,
+		Example: watchCmdExamples,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if watchInfo.Debounce <= 0 {
+				return ErrBadDebounce
+			}
+
+			scanInfo.FrameworkScan = true
+			var frameworks []string
+			if len(args) == 0 {
+				scanInfo.ScanAll = true
+			} else {
+				frameworks = strings.Split(args[0], ",")
+				if slices.Contains(frameworks, "") {
+					return fmt.Errorf("usage: <framework-0>,<framework-1>")
+				}
+				if slices.Contains(frameworks, "all") {
+					scanInfo.ScanAll = true
+					frameworks = getter.NativeFrameworks
+				}
+			}
+			scanInfo.SetScanType(cautils.ScanTypeFramework)
+			scanInfo.Silent = true
+
+			return ks.Watch(ks.Context(), &watchInfo, &scanInfo, cautils.BuildPolicyIdentifiers(frameworks, apisv1.KindFramework))
+		},
+	}
+
+	watchCmd.Flags().StringVarP(&watchInfo.Output, "output", "o", "", "File to append the result changes to as JSON lines; defaults to stdout")
+	watchCmd.Flags().BoolVar(&watchInfo.Events, "events", false, "Record the result changes as Kubernetes Events on the changed resources")
+	watchCmd.Flags().DurationVar(&watchInfo.Debounce, "debounce", corewatch.DefaultDebounce, "How long to collect changes before rescanning")
+	watchCmd.Flags().StringVarP(&scanInfo.ExcludedNamespaces, "exclude-namespaces", "e", "", "Namespaces to exclude from watching. e.g: --exclude-namespaces ns-a,ns-b")
+	watchCmd.Flags().StringVar(&scanInfo.IncludeNamespaces, "include-namespaces", "", "Watch specific namespaces. e.g: --include-namespaces ns-a,ns-b")
+	watchCmd.Flags().StringVar(&scanInfo.ControlsInputs, "controls-config", "", "Path to an controls-config obj. If not set will download controls-config from ARMO management portal")
+	watchCmd.Flags().StringVar(&scanInfo.UseExceptions, "exceptions", "", "Path to an exceptions obj. If not set will download exceptions from ARMO management portal")
+	watchCmd.Flags().StringVar(&scanInfo.UseArtifactsFrom, "use-artifacts-from", "", "Load artifacts from local directory. If not used will download them")
+
+	return watchCmd
+}
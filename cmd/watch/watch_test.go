@@ -0,0 +1,68 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKubescape records the arguments Watch is called with.
+type stubKubescape struct {
+	mocks.MockIKubescape
+	watchInfo         *metav1.WatchInfo
+	scanInfo          *cautils.ScanInfo
+	policyIdentifiers []cautils.PolicyIdentifier
+}
+
+func (s *stubKubescape) Watch(_ context.Context, watchInfo *metav1.WatchInfo, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) error {
+	s.watchInfo = watchInfo
+	s.scanInfo = scanInfo
+	s.policyIdentifiers = policyIdentifiers
+	return nil
+}
+
+func TestGetWatchCmd(t *testing.T) {
+	ks := &stubKubescape{}
+	watchCmd := GetWatchCmd(ks)
+	watchCmd.SetArgs([]string{"nsa,mitre", "--output", "changes.jsonl", "--events", "--debounce", "1m"})
+
+	require.NoError(t, watchCmd.Execute())
+	assert.Equal(t, &metav1.WatchInfo{Output: "changes.jsonl", Events: true, Debounce: time.Minute}, ks.watchInfo)
+	assert.False(t, ks.scanInfo.ScanAll)
+	require.Len(t, ks.policyIdentifiers, 2)
+	assert.Equal(t, "nsa", ks.policyIdentifiers[0].Identifier)
+	assert.Equal(t, "mitre", ks.policyIdentifiers[1].Identifier)
+}
+
+func TestGetWatchCmd_AllFrameworksByDefault(t *testing.T) {
+	ks := &stubKubescape{}
+	watchCmd := GetWatchCmd(ks)
+	watchCmd.SetArgs([]string{})
+
+	require.NoError(t, watchCmd.Execute())
+	assert.True(t, ks.scanInfo.ScanAll)
+}
+
+func TestGetWatchCmd_RejectsBadArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "zero debounce", args: []string{"--debounce", "0s"}, wantError: ErrBadDebounce.Error()},
+		{name: "empty framework", args: []string{"nsa,"}, wantError: "usage: <framework-0>,<framework-1>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watchCmd := GetWatchCmd(&stubKubescape{})
+			watchCmd.SetArgs(tt.args)
+			require.ErrorContains(t, watchCmd.Execute(), tt.wantError)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/meta"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/shardworker"
+	"github.com/spf13/cobra"
+)
+
+var workerCmdExamples = fmt.Sprintf(`
+  # Run two workers on this machine and distribute the control evaluation of a scan to them
+  export KS_API_TOKEN=secret
+  %[1]s worker --addr 127.0.0.1:9091 &
+  %[1]s worker --addr 127.0.0.1:9092 &
+  %[1]s scan --workers http://127.0.0.1:9091,http://127.0.0.1:9092
+
+  # Run a worker without authentication, on a host only trusted clients can reach
+  %[1]s worker --addr 127.0.0.1:9091 --insecure
+`, cautils.ExecName())
+
+func GetWorkerCmd(ks meta.IKubescape) *cobra.Command {
+	var workerInfo metav1.ShardWorkerInfo
+
+	workerCmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Evaluate the controls of distributed scans",
+		Long: fmt.Sprintf(`Serve the evaluation of the shards a scan run with --workers sends, at %s, with a /healthz probe. `+
+			`The scan collects the resources, cuts the control evaluation into shards of controls by namespace and merges the results the workers return. `+
+			`Workers keep no state, so any number of them can serve the same scans. Requests must present %[2]s as a bearer token, and scans present it; `+
+			`the worker refuses to start without %[2]s unless --insecure is set.`, shardworker.EvaluatePath, shardworker.TokenEnv),
+		Example: workerCmdExamples,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (workerInfo.CertFile == "") != (workerInfo.KeyFile == "") {
+				return fmt.Errorf("--tls-cert-file and --tls-key-file must be set together")
+			}
+			if shardworker.Token() == "" && !workerInfo.Insecure {
+				return fmt.Errorf("%s is not set: set it, or serve without authentication with --insecure", shardworker.TokenEnv)
+			}
+			return ks.ServeShardWorker(ks.Context(), &workerInfo)
+		},
+	}
+
+	workerCmd.Flags().StringVar(&workerInfo.Addr, "addr", ":9090", "Address to listen on")
+	workerCmd.Flags().StringVar(&workerInfo.CertFile, "tls-cert-file", "", "TLS certificate to serve with. Plain HTTP when not set")
+	workerCmd.Flags().StringVar(&workerInfo.KeyFile, "tls-key-file", "", "TLS private key of --tls-cert-file")
+	workerCmd.Flags().Int64Var(&workerInfo.MaxShardBytes, "max-shard-bytes", shardworker.DefaultMaxShardBytes, "Largest shard to accept, as posted or once decompressed. Scans split larger shards")
+	workerCmd.Flags().BoolVar(&workerInfo.Insecure, "insecure", false, "Serve without "+shardworker.TokenEnv+", evaluating the shards of anyone who can reach the worker")
+
+	return workerCmd
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/mocks"
+	"github.com/kubescape/kubescape/v4/core/pkg/shardworker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKubescape records what ServeShardWorker was called with.
+type stubKubescape struct {
+	mocks.MockIKubescape
+	workerInfo *metav1.ShardWorkerInfo
+}
+
+func (s *stubKubescape) ServeShardWorker(_ context.Context, workerInfo *metav1.ShardWorkerInfo) error {
+	s.workerInfo = workerInfo
+	return nil
+}
+
+func TestWorkerCmd(t *testing.T) {
+	t.Setenv(shardworker.TokenEnv, "secret")
+	ks := &stubKubescape{}
+	cmd := GetWorkerCmd(ks)
+	cmd.SetArgs([]string{"--addr", "127.0.0.1:9091", "--max-shard-bytes", "1048576"})
+
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, &metav1.ShardWorkerInfo{Addr: "127.0.0.1:9091", MaxShardBytes: 1 << 20}, ks.workerInfo)
+}
+
+func TestWorkerCmd_Insecure(t *testing.T) {
+	t.Setenv(shardworker.TokenEnv, "")
+	ks := &stubKubescape{}
+	cmd := GetWorkerCmd(ks)
+	cmd.SetArgs([]string{"--insecure"})
+
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, &metav1.ShardWorkerInfo{Addr: ":9090", Insecure: true, MaxShardBytes: shardworker.DefaultMaxShardBytes}, ks.workerInfo)
+}
+
+func TestWorkerCmd_RejectsBadArguments(t *testing.T) {
+	t.Setenv(shardworker.TokenEnv, "")
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "certificate without key", args: []string{"--tls-cert-file", "tls.crt", "--insecure"}, wantError: "must be set together"},
+		{name: "no token", args: []string{}, wantError: "KS_API_TOKEN is not set"},
+		{name: "arguments", args: []string{"extra"}, wantError: "unknown command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := &stubKubescape{}
+			cmd := GetWorkerCmd(ks)
+			cmd.SetArgs(tt.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			assert.ErrorContains(t, cmd.Execute(), tt.wantError)
+			assert.Nil(t, ks.workerInfo)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package cautils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinCrosswalkSource is the Source of the crosswalks shipped with
+// Kubescape.
+const builtinCrosswalkSource = "builtin"
+
+// builtinCrosswalks holds the crosswalks shipped with Kubescape, one file per
+// standard named after it.
+//
+//go:embed crosswalks/*.yaml
+var builtinCrosswalks embed.FS
+
+// Crosswalk maps Kubescape controls onto the clauses of an external standard,
+// so --report-as can report a scan per clause. Crosswalks for iso27001,
+// pci-dss, soc2 and nist-800-53 ship with Kubescape, and --crosswalk files,
+// in YAML or JSON, replace them or add other standards:
+//
+//	standard: iso27001
+//	name: ISO/IEC 27001:2022 Annex A
+//	clauses:
+//	  - id: A.8.2
+//	    name: Privileged access rights
+//	    controls: [C-0035, C-0057, C-0185]
+//
+// A control may support several clauses.
+type Crosswalk struct {
+	Standard string            `json:"standard" yaml:"standard"`
+	Name     string            `json:"name,omitempty" yaml:"name"`
+	Clauses  []CrosswalkClause `json:"clauses" yaml:"clauses"`
+
+	// Source and Digest identify the file the crosswalk was loaded from,
+	// "builtin" for the shipped ones, so a report records exactly which
+	// mapping its clauses were aggregated with.
+	Source string `json:"source,omitempty" yaml:"-"`
+	Digest string `json:"digest,omitempty" yaml:"-"`
+}
+
+// CrosswalkClause is a clause of a standard and the controls supporting it.
+type CrosswalkClause struct {
+	ID       string   `json:"id" yaml:"id"`
+	Name     string   `json:"name,omitempty" yaml:"name"`
+	Controls []string `json:"controls" yaml:"controls"`
+}
+
+// StandardReport is a scan's results aggregated onto the clauses of a
+// standard through its crosswalk.
+type StandardReport struct {
+	Standard string `json:"standard"`
+	Name     string `json:"name,omitempty"`
+	// ComplianceScore averages the compliance scores of the clauses any
+	// supporting control of which was scanned.
+	ComplianceScore float32        `json:"complianceScore"`
+	Clauses         []ClauseReport `json:"clauses"`
+}
+
+// ClauseReport is the status of one clause of a standard.
+type ClauseReport struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Status is failed when a supporting control failed, passed when the
+	// others passed, and skipped when none was scanned or all were skipped.
+	Status apis.ScanningStatus `json:"status"`
+	// ComplianceScore averages the compliance scores of the supporting
+	// controls, weighted as the framework scores weigh them.
+	ComplianceScore float32 `json:"complianceScore"`
+	// Controls lists the supporting controls that were scanned, and
+	// FailedControls those of them that failed.
+	Controls       []string `json:"controls"`
+	FailedControls []string `json:"failedControls,omitempty"`
+	// FailedResources lists the IDs of the resources failing a supporting
+	// control.
+	FailedResources []string `json:"failedResources,omitempty"`
+}
+
+// LoadCrosswalks returns the crosswalks of the standards, in order, from the
+// files, which take precedence, or else from the shipped ones. It returns nil
+// when no standard is given.
+func LoadCrosswalks(standards, files []string) ([]*Crosswalk, error) {
+	if len(standards) == 0 {
+		return nil, nil
+	}
+	available, err := loadBuiltinCrosswalks()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read crosswalk: %w", err)
+		}
+		crosswalk, err := parseCrosswalk(data, file)
+		if err != nil {
+			return nil, err
+		}
+		available[crosswalk.Standard] = crosswalk
+	}
+
+	crosswalks := make([]*Crosswalk, 0, len(standards))
+	for _, standard := range standards {
+		crosswalk, ok := available[strings.ToLower(standard)]
+		if !ok {
+			return nil, fmt.Errorf("no crosswalk for standard %q, expected one of %s", standard, strings.Join(crosswalkStandards(available), ", "))
+		}
+		if !slices.Contains(crosswalks, crosswalk) {
+			crosswalks = append(crosswalks, crosswalk)
+		}
+	}
+	return crosswalks, nil
+}
+
+// CrosswalkStandards lists the standards of the shipped crosswalks.
+func CrosswalkStandards() []string {
+	available, err := loadBuiltinCrosswalks()
+	if err != nil {
+		return nil
+	}
+	return crosswalkStandards(available)
+}
+
+func crosswalkStandards(crosswalks map[string]*Crosswalk) []string {
+	standards := make([]string, 0, len(crosswalks))
+	for standard := range crosswalks {
+		standards = append(standards, standard)
+	}
+	slices.Sort(standards)
+	return standards
+}
+
+func loadBuiltinCrosswalks() (map[string]*Crosswalk, error) {
+	entries, err := builtinCrosswalks.ReadDir("crosswalks")
+	if err != nil {
+		return nil, err
+	}
+	crosswalks := make(map[string]*Crosswalk, len(entries))
+	for _, entry := range entries {
+		data, err := builtinCrosswalks.ReadFile(path.Join("crosswalks", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		crosswalk, err := parseCrosswalk(data, builtinCrosswalkSource)
+		if err != nil {
+			return nil, err
+		}
+		crosswalks[crosswalk.Standard] = crosswalk
+	}
+	return crosswalks, nil
+}
+
+func parseCrosswalk(data []byte, source string) (*Crosswalk, error) {
+	crosswalk := &Crosswalk{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(crosswalk); err != nil {
+		return nil, fmt.Errorf("invalid crosswalk %s: %w", source, err)
+	}
+	if err := crosswalk.validate(); err != nil {
+		return nil, fmt.Errorf("invalid crosswalk %s: %w", source, err)
+	}
+	digest := sha256.Sum256(data)
+	crosswalk.Source = source
+	crosswalk.Digest = "sha256:" + hex.EncodeToString(digest[:])
+	return crosswalk, nil
+}
+
+// validate checks the crosswalk. Standards are matched case-insensitively.
+func (c *Crosswalk) validate() error {
+	if c.Standard == "" {
+		return errors.New("standard is required")
+	}
+	c.Standard = strings.ToLower(c.Standard)
+	if len(c.Clauses) == 0 {
+		return errors.New("at least one clause is required")
+	}
+	clauses := make(map[string]bool, len(c.Clauses))
+	for _, clause := range c.Clauses {
+		if clause.ID == "" {
+			return errors.New("clause id is required")
+		}
+		if clauses[clause.ID] {
+			return fmt.Errorf("duplicate clause %q", clause.ID)
+		}
+		clauses[clause.ID] = true
+		if len(clause.Controls) == 0 {
+			return fmt.Errorf("clause %q has no controls", clause.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cautils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCrosswalk(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "crosswalk.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadCrosswalks(t *testing.T) {
+	t.Run("loads the shipped crosswalks", func(t *testing.T) {
+		assert.Equal(t, []string{"iso27001", "nist-800-53", "pci-dss", "soc2"}, CrosswalkStandards())
+
+		crosswalks, err := LoadCrosswalks([]string{"ISO27001", "pci-dss", "iso27001"}, nil)
+		require.NoError(t, err)
+		require.Len(t, crosswalks, 2)
+		assert.Equal(t, "iso27001", crosswalks[0].Standard)
+		assert.Equal(t, "pci-dss", crosswalks[1].Standard)
+		assert.Equal(t, builtinCrosswalkSource, crosswalks[0].Source)
+		assert.NotEmpty(t, crosswalks[0].Digest)
+	})
+
+	t.Run("files replace the shipped crosswalks", func(t *testing.T) {
+		file := writeCrosswalk(t, `
+standard: soc2
+clauses:
+  - {id: CC6.1, controls: [C-0035]}
+`)
+		crosswalks, err := LoadCrosswalks([]string{"soc2"}, []string{file})
+		require.NoError(t, err)
+		require.Len(t, crosswalks, 1)
+		assert.Equal(t, file, crosswalks[0].Source)
+		assert.Equal(t, []CrosswalkClause{{ID: "CC6.1", Controls: []string{"C-0035"}}}, crosswalks[0].Clauses)
+	})
+
+	t.Run("files add standards", func(t *testing.T) {
+		file := writeCrosswalk(t, `{"standard": "internal", "clauses": [{"id": "SEC-1", "controls": ["C-0017"]}]}`)
+		crosswalks, err := LoadCrosswalks([]string{"internal"}, []string{file})
+		require.NoError(t, err)
+		assert.Equal(t, "internal", crosswalks[0].Standard)
+	})
+
+	t.Run("nil without standards", func(t *testing.T) {
+		crosswalks, err := LoadCrosswalks(nil, []string{"missing.yaml"})
+		require.NoError(t, err)
+		assert.Nil(t, crosswalks)
+	})
+
+	t.Run("rejects unknown standards", func(t *testing.T) {
+		_, err := LoadCrosswalks([]string{"hipaa"}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `no crosswalk for standard "hipaa"`)
+	})
+}
+
+func TestLoadCrosswalks_Rejects(t *testing.T) {
+	for name, tc := range map[string]struct {
+		crosswalk string
+		err       string
+	}{
+		"no standard":      {crosswalk: "clauses: [{id: a, controls: [C-0017]}]", err: "standard is required"},
+		"no clauses":       {crosswalk: "standard: x", err: "at least one clause"},
+		"no clause id":     {crosswalk: "standard: x\nclauses: [{controls: [C-0017]}]", err: "clause id is required"},
+		"duplicate clause": {crosswalk: "standard: x\nclauses: [{id: a, controls: [C-0017]}, {id: a, controls: [C-0016]}]", err: `duplicate clause "a"`},
+		"no controls":      {crosswalk: "standard: x\nclauses: [{id: a}]", err: `clause "a" has no controls`},
+		"unknown field":    {crosswalk: "standard: x\nclauses: [{id: a, controls: [C-0017], weight: 2}]", err: "field weight not found"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadCrosswalks([]string{"x"}, []string{writeCrosswalk(t, tc.crosswalk)})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package cautils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+)
+
+// IsCueFile reports whether path is a CUE file.
+func IsCueFile(path string) bool {
+	return filepath.Ext(path) == ".cue"
+}
+
+// isCuePackageDirectory reports whether dir holds the files of a CUE package
+// to evaluate. The cue.mod directory of a CUE module holds its dependencies
+// and schemas, and is never evaluated.
+func isCuePackageDirectory(dir string) bool {
+	if slices.Contains(strings.Split(filepath.ToSlash(dir), "/"), "cue.mod") {
+		return false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && IsCueFile(entry.Name()) && !strings.HasSuffix(entry.Name(), "_tool.cue") {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateCue exports the CUE package in dir, or the CUE file file when it is
+// set, as JSON, and returns the Kubernetes objects the output holds.
+func evaluateCue(ctx context.Context, dir, file string) ([]workloadinterface.IMetadata, error) {
+	target, source := ".", dir
+	if file != "" {
+		target, source = filepath.Base(file), file
+	}
+	out, err := runEvaluator(ctx, dir, "cue", "export", "--out", "json", target)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(out, &value); err != nil {
+		return nil, fmt.Errorf("invalid cue output: %w", err)
+	}
+	return evaluatedWorkloads(source, kubernetesObjects(value))
+}
+
+// LoadResourcesFromCue loads the Kubernetes resources the CUE packages under
+// basePath evaluate to. A package spans the files of its directory, so its
+// resources are attributed to the directory; an explicit .cue file is
+// evaluated on its own. An explicit file or package that fails to evaluate is
+// an error; in a directory scan, such packages are reported as skipped, as a
+// missing cue CLI is.
+func LoadResourcesFromCue(ctx context.Context, basePath string) (map[string][]workloadinterface.IMetadata, []SkippedManifest, error) {
+	return LoadResourcesFromCueFiltered(ctx, basePath, nil)
+}
+
+// LoadResourcesFromCueFiltered behaves like LoadResourcesFromCue, leaving out
+// the files and directories filter excludes.
+func LoadResourcesFromCueFiltered(ctx context.Context, basePath string, filter *PathFilter) (map[string][]workloadinterface.IMetadata, []SkippedManifest, error) {
+	if isFile(basePath) {
+		if !IsCueFile(basePath) || filter.Excluded(basePath, false) {
+			return nil, nil, nil
+		}
+		wls, err := evaluateCue(ctx, filepath.Dir(basePath), basePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to evaluate %q: %w", basePath, err)
+		}
+		return map[string][]workloadinterface.IMetadata{basePath: wls}, nil, nil
+	}
+
+	directories, errs := listDirs(basePath, filter)
+	for _, err := range errs {
+		logger.L().Ctx(ctx).Warning("Skipping path while discovering CUE packages", helpers.Error(err))
+	}
+
+	absBasePath, _ := filepath.Abs(basePath)
+	sourceToWorkloads := map[string][]workloadinterface.IMetadata{}
+	var skips []SkippedManifest
+	for _, dir := range directories {
+		if !isCuePackageDirectory(dir) {
+			continue
+		}
+		wls, err := evaluateCue(ctx, dir, "")
+		if err != nil {
+			if dir == absBasePath {
+				return sourceToWorkloads, skips, fmt.Errorf("failed to evaluate CUE package %q: %w", dir, err)
+			}
+			skips = append(skips, SkippedManifest{Path: dir, Reason: "evaluation error: " + err.Error()})
+			continue
+		}
+		if len(wls) > 0 {
+			sourceToWorkloads[dir] = wls
+		}
+	}
+	return sourceToWorkloads, skips, nil
+}
@@ -0,0 +1,74 @@
+package cautils
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadResourcesFromCue(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"cue.mod/module.cue":         `module: "example.com/deploy"`,
+		"cue.mod/pkg/k8s/schema.cue": `package k8s`,
+		"apps/web/deployment.cue":    `package web`,
+		"apps/web/service.cue":       `package web`,
+		"apps/web/deploy_tool.cue":   `package web`,
+		"tools/only_tool.cue":        `package tools`,
+		"apps/web/README.md":         ``,
+	})
+	calls := stubEvaluator(t, func(string, string, []string) ([]byte, error) {
+		return []byte(fmt.Sprintf(`{"objects": {"deployment": %s}}`, fmt.Sprintf(jsonnetDeployment, "web"))), nil
+	})
+
+	sourceToWorkloads, skips, err := LoadResourcesFromCue(context.Background(), root)
+	require.NoError(t, err)
+	assert.Empty(t, skips)
+
+	web := filepath.Join(root, "apps", "web")
+	require.Len(t, sourceToWorkloads, 1, "cue.mod and tool-only directories are not evaluated")
+	require.Len(t, sourceToWorkloads[web], 1)
+	assert.Equal(t, "web", sourceToWorkloads[web][0].GetName())
+	assert.Equal(t, []evaluatorCall{{dir: web, name: "cue", args: []string{"export", "--out", "json", "."}}}, *calls)
+}
+
+func TestLoadResourcesFromCueFile(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"web.cue": `package web`})
+	calls := stubEvaluator(t, func(string, string, []string) ([]byte, error) {
+		return []byte(fmt.Sprintf(jsonnetDeployment, "web")), nil
+	})
+
+	file := filepath.Join(root, "web.cue")
+	sourceToWorkloads, _, err := LoadResourcesFromCue(context.Background(), file)
+	require.NoError(t, err)
+	require.Len(t, sourceToWorkloads[file], 1)
+	assert.Equal(t, []evaluatorCall{{dir: root, name: "cue", args: []string{"export", "--out", "json", "web.cue"}}}, *calls)
+}
+
+func TestLoadResourcesFromCueReportsFailures(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"web.cue":         `package web`,
+		"schemas/api.cue": `package schemas`,
+	})
+	stubEvaluator(t, func(dir, _ string, _ []string) ([]byte, error) {
+		if dir == filepath.Join(root, "schemas") {
+			return nil, fmt.Errorf("cue failed: incomplete value")
+		}
+		return []byte(fmt.Sprintf(jsonnetDeployment, "web")), nil
+	})
+
+	sourceToWorkloads, skips, err := LoadResourcesFromCue(context.Background(), root)
+	require.NoError(t, err)
+	assert.Contains(t, sourceToWorkloads, root)
+	require.Len(t, skips, 1, "a package that cannot be evaluated is skipped in a directory scan")
+	assert.Equal(t, filepath.Join(root, "schemas"), skips[0].Path)
+
+	_, _, err = LoadResourcesFromCue(context.Background(), filepath.Join(root, "schemas"))
+	assert.ErrorContains(t, err, "incomplete value", "an explicit package that cannot be evaluated fails the scan")
+}
@@ -3,6 +3,7 @@ package cautils
 import (
 	"context"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/anchore/grype/grype/match"
@@ -13,6 +14,8 @@ import (
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/cautils/helmrelease"
 	"github.com/kubescape/opa-utils/reporthandling"
 	apis "github.com/kubescape/opa-utils/reporthandling/apis"
 	"github.com/kubescape/opa-utils/reporthandling/attacktrack/v1alpha1"
@@ -100,6 +103,8 @@ type OPASessionObj struct {
 	AttackTracks          map[string]v1alpha1.IAttackTrack
 	Report                *reporthandlingv2.PostureReport // scan results v2 - Remove
 	RegoInputData         RegoInputData                   // input passed to rego for scanning. map[<control name>][<input arguments>]
+	ControlInputsScopes   []getter.ControlInputsScope     // control inputs overridden for the resources of some scopes
+	ResourceInputsScopes  map[string]string               // scope whose control inputs each resource was evaluated with, map[<resource ID>]<scope name>
 	Metadata              *reporthandlingv2.Metadata
 	InfoMap               map[string]apis.StatusInfo         // Map errors of resources to StatusInfo
 	ResourceToControlsMap map[string][]string                // map[<apigroup/apiversion/resource>] = [<control_IDs>]
@@ -111,8 +116,20 @@ type OPASessionObj struct {
 	Policies              []reporthandling.Framework         // list of frameworks to scan
 	Exceptions            []armotypes.PostureExceptionPolicy // list of exceptions to apply on scan results
 	ExceptionAudit        *ExceptionAudit                    // optional exception usage audit
+	ScoringProfile        *ScoringProfile                    // optional weighted scoring model replacing the default compliance scores
+	RuntimeScore          *RuntimeScore                      // optional compliance score adjusted by runtime telemetry
+	FrameworkDefinitions  []*getter.FrameworkDefinition      // framework definitions composing the scanned frameworks
+	FrameworkSections     []FrameworkSectionScore            // compliance scores of the sections of the framework definitions
+	Crosswalks            []*Crosswalk                       // crosswalks of the standards the results are reported as
+	StandardReports       []StandardReport                   // results aggregated onto the clauses of the crosswalks' standards
+	GitOpsObjects         map[string]GitOpsObjectRef         // GitOps object each rendered resource came from, map[<resource ID>]<object>
+	HelmReleases          []helmrelease.Summary              // Helm releases deployed in the scanned cluster
+	Ownership             *Ownership                         // optional mapping of resources to the teams owning them
+	ResourceOwners        map[string]string                  // owner of each owned resource, map[<resource ID>]<owner name>
 	AuditExceptions       bool                               // include exception usage audit in supported outputs
 	HonorInlineExceptions bool                               // honor kubescape.io/skip-* annotations as inline exception policies
+	ExplainTrace          string                             // trace mode of the explanations of the single resource's verdicts, empty when not explaining
+	Explanations          []ControlExplanation               // verdicts of the controls on the single resource, explained
 	OmitRawResources      bool                               // omit raw resources from output
 	SingleResourceScan    workloadinterface.IWorkload        // single resource scan
 	TopWorkloadsByScore   []reporthandling.IResource
@@ -147,6 +164,7 @@ func NewOPASessionObj(ctx context.Context, frameworks []reporthandling.Framework
 		OmitRawResources:      scanInfo.OmitRawResources,
 		AuditExceptions:       scanInfo.AuditExceptions,
 		HonorInlineExceptions: scanInfo.HonorInlineExceptions.GetBool(),
+		ExplainTrace:          scanInfo.ExplainTrace,
 		TriggeredByCLI:        scanInfo.TriggeredByCLI,
 		LabelsToCopy:          scanInfo.LabelsToCopy,
 		SkipControls:          scanInfo.SkipControls,
@@ -260,6 +278,28 @@ func (sessionObj *OPASessionObj) SetNumberOfWorkerNodes(n int) {
 	sessionObj.Metadata.ContextMetadata.ClusterContextMetadata.NumberOfWorkerNodes = n
 }
 
+// HelmReleasesWithResources returns the session's Helm releases, each with
+// the IDs of the scanned resources attributed to it, so that reports can group
+// results by release.
+func (sessionObj *OPASessionObj) HelmReleasesWithResources() []helmrelease.Summary {
+	if len(sessionObj.HelmReleases) == 0 {
+		return nil
+	}
+	resources := make(map[string][]string)
+	for resourceID, source := range sessionObj.ResourceSource {
+		if strings.HasPrefix(source.HelmPath, helmrelease.PathPrefix) {
+			resources[source.HelmPath] = append(resources[source.HelmPath], resourceID)
+		}
+	}
+	releases := make([]helmrelease.Summary, 0, len(sessionObj.HelmReleases))
+	for _, release := range sessionObj.HelmReleases {
+		release.Resources = resources[release.Path()]
+		sort.Strings(release.Resources)
+		releases = append(releases, release)
+	}
+	return releases
+}
+
 func NewOPASessionObjMock() *OPASessionObj {
 	return &OPASessionObj{
 		Policies:             nil,
@@ -0,0 +1,84 @@
+package cautils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+// Explain trace modes, as for `opa eval --explain`: the notes the rules
+// leave with trace(), the expressions that failed, or the full evaluation.
+const (
+	ExplainTraceNotes = "notes"
+	ExplainTraceFails = "fails"
+	ExplainTraceFull  = "full"
+)
+
+// ExplainTraceModes lists the valid explain trace modes.
+func ExplainTraceModes() []string {
+	return []string{ExplainTraceNotes, ExplainTraceFails, ExplainTraceFull}
+}
+
+// ValidateExplainTrace checks mode is a valid explain trace mode.
+func ValidateExplainTrace(mode string) error {
+	if !slices.Contains(ExplainTraceModes(), mode) {
+		return fmt.Errorf("invalid trace mode %q, expected one of %s", mode, strings.Join(ExplainTraceModes(), ", "))
+	}
+	return nil
+}
+
+// ControlExplanation explains the verdict of a control on a single resource.
+// Status is the control's final status, once exceptions are applied, and each
+// rule explains the status it reached before them.
+type ControlExplanation struct {
+	ControlID  string                 `json:"controlID"`
+	Name       string                 `json:"name"`
+	ResourceID string                 `json:"resourceID"`
+	Status     apis.ScanningStatus    `json:"status"`
+	Rules      []RuleExplanation      `json:"rules"`
+	Exceptions []ExceptionExplanation `json:"exceptions,omitempty"`
+}
+
+// RuleExplanation explains the verdict of one rule of a control on the
+// explained resource.
+type RuleExplanation struct {
+	Name     string                   `json:"name"`
+	Language string                   `json:"language"`
+	Status   apis.ScanningStatus      `json:"status"`
+	Reason   string                   `json:"reason,omitempty"` // why the rule did not evaluate the resource, or failed to
+	Input    []map[string]any         `json:"input,omitempty"`  // input document of a Rego rule: the resource and its related objects
+	Inputs   map[string][]string      `json:"controlInputs,omitempty"`
+	Scope    string                   `json:"controlInputsScope,omitempty"` // control inputs scope the inputs come from
+	Trace    []string                 `json:"trace,omitempty"`              // Rego evaluation trace
+	CEL      []ExpressionTrace        `json:"cel,omitempty"`                // CEL variables and validations
+	Paths    []armotypes.PosturePaths `json:"paths,omitempty"`              // failed, fix, review and delete paths
+}
+
+// ExpressionTrace is a CEL variable or validation with the values its
+// sub-expressions evaluated to, outermost first.
+type ExpressionTrace struct {
+	Variable   string               `json:"variable,omitempty"`
+	Expression string               `json:"expression"`
+	Passed     *bool                `json:"passed,omitempty"` // verdict of a validation
+	Message    string               `json:"message,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	Values     []SubExpressionValue `json:"values,omitempty"`
+}
+
+// SubExpressionValue is the value a CEL sub-expression evaluated to.
+type SubExpressionValue struct {
+	Expression string `json:"expression"`
+	Value      any    `json:"value,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ExceptionExplanation is an exception that matched the explained resource
+// and control, or nearly did: Reasons tell what kept it from matching.
+type ExceptionExplanation struct {
+	Name    string   `json:"name"`
+	Matched bool     `json:"matched"`
+	Reasons []string `json:"reasons,omitempty"`
+}
@@ -304,6 +304,25 @@ func excludeHelmChartMetadataFiles(files []string) []string {
 	return remaining
 }
 
+// excludeJsonnetMetadataFiles drops the jsonnet-bundler files and the
+// spec.json of Tanka environments, which describe Jsonnet projects rather
+// than Kubernetes resources.
+func excludeJsonnetMetadataFiles(files []string) []string {
+	remaining := make([]string, 0, len(files))
+	for _, file := range files {
+		switch filepath.Base(file) {
+		case "jsonnetfile.json", "jsonnetfile.lock.json":
+			continue
+		case "spec.json":
+			if isTankaEnvironment(filepath.Dir(file)) {
+				continue
+			}
+		}
+		remaining = append(remaining, file)
+	}
+	return remaining
+}
+
 // IsUnderAnyDir reports whether path is inside one of dirs after normalizing
 // relative paths and resolving symlinks where the path already exists.
 func IsUnderAnyDir(path string, dirs []string) bool {
@@ -724,6 +743,10 @@ func LoadResourcesFromFilesFiltered(ctx context.Context, input, rootPath string,
 	// flagged as skipped manifests.
 	files = excludeHelmChartMetadataFiles(files)
 
+	// jsonnet-bundler and Tanka metadata are JSON files too, and a Tanka
+	// spec.json even carries an apiVersion and a kind.
+	files = excludeJsonnetMetadataFiles(files)
+
 	workloads, skips, errs := loadFiles(rootPath, files)
 	if len(errs) > 0 {
 		loadErr := fmt.Errorf("failed to load one or more manifests from %q: %w", input, errors.Join(errs...))
@@ -0,0 +1,15 @@
+package cautils
+
+// FrameworkSectionScore is the compliance score of one section of a framework
+// definition (see getter.FrameworkDefinition).
+type FrameworkSectionScore struct {
+	// Framework is the name of the definition the section belongs to.
+	Framework string `json:"framework"`
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	// Controls lists the IDs of the section's controls that were scanned.
+	Controls []string `json:"controls"`
+	// ComplianceScore averages the compliance scores of the section's
+	// controls, weighted as the framework's score weighs them.
+	ComplianceScore float32 `json:"complianceScore"`
+}
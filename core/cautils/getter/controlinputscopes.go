@@ -0,0 +1,107 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ControlInputsScopesKey is the key of a controls-config file listing its
+// scoped control inputs, next to the global ones.
+const ControlInputsScopesKey = "scopes"
+
+// ControlInputsScope overrides control inputs for the resources it selects:
+//
+//	{
+//	  "imageRepositoryAllowList": ["quay.io/"],
+//	  "scopes": [
+//	    {
+//	      "name": "payments",
+//	      "namespaces": ["payments", "payments-*"],
+//	      "inputs": {"imageRepositoryAllowList": ["registry.payments.example.com/"]}
+//	    },
+//	    {
+//	      "name": "legacy-batch",
+//	      "namespaceSelector": "tier=batch",
+//	      "labelSelector": "app.kubernetes.io/part-of=legacy",
+//	      "inputs": {"allowedHostPaths": ["/var/spool"]}
+//	    }
+//	  ]
+//	}
+//
+// A scope selects the resources matching all of its criteria: a namespace
+// glob, a label selector on the labels of the resource's namespace, and a
+// label selector on the labels of the resource itself. A resource is
+// evaluated with the inputs of the first scope selecting it, replacing the
+// global values of the same inputs.
+type ControlInputsScope struct {
+	Name              string              `json:"name"`
+	Namespaces        []string            `json:"namespaces,omitempty"`
+	NamespaceSelector string              `json:"namespaceSelector,omitempty"`
+	LabelSelector     string              `json:"labelSelector,omitempty"`
+	Inputs            map[string][]string `json:"inputs"`
+}
+
+// Validate checks the scope selects resources and overrides inputs.
+func (s *ControlInputsScope) Validate() error {
+	if s.Name == "" {
+		return errors.New("scope name is required")
+	}
+	if len(s.Namespaces) == 0 && s.NamespaceSelector == "" && s.LabelSelector == "" {
+		return fmt.Errorf("scope %q selects no resources, set namespaces, namespaceSelector or labelSelector", s.Name)
+	}
+	for _, pattern := range s.Namespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q in scope %q: %w", pattern, s.Name, err)
+		}
+	}
+	if _, err := labels.Parse(s.NamespaceSelector); err != nil {
+		return fmt.Errorf("invalid namespaceSelector in scope %q: %w", s.Name, err)
+	}
+	if _, err := labels.Parse(s.LabelSelector); err != nil {
+		return fmt.Errorf("invalid labelSelector in scope %q: %w", s.Name, err)
+	}
+	if len(s.Inputs) == 0 {
+		return fmt.Errorf("scope %q overrides no inputs", s.Name)
+	}
+	return nil
+}
+
+// GetScopedControlsInputs retrieves the scoped control inputs of the
+// controls-config file, nil when it has none. A missing file has none: it is
+// reported by GetControlsInputs.
+func (lp *LoadPolicy) GetScopedControlsInputs(_ context.Context, _ /* clusterName */ string) ([]ControlInputsScope, error) {
+	filePath := lp.filePath()
+	buf, err := os.ReadFile(filepath.Clean(filePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Scopes []ControlInputsScope `json:"scopes"`
+	}
+	if err := json.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("error reading scopes of %s: %w", filepath.Base(filePath), err)
+	}
+	names := make(map[string]bool, len(config.Scopes))
+	for i := range config.Scopes {
+		scope := &config.Scopes[i]
+		if err := scope.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid scope in %s: %w", filepath.Base(filePath), err)
+		}
+		if names[scope.Name] {
+			return nil, fmt.Errorf("invalid scope in %s: duplicate scope %q", filepath.Base(filePath), scope.Name)
+		}
+		names[scope.Name] = true
+	}
+	return config.Scopes, nil
+}
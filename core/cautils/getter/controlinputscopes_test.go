@@ -0,0 +1,92 @@
+package getter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeControlsConfig(t *testing.T, content string) *LoadPolicy {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "controls-inputs.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return NewLoadPolicy([]string{path})
+}
+
+func TestGetScopedControlsInputs(t *testing.T) {
+	t.Parallel()
+
+	p := writeControlsConfig(t, `{
+  "imageRepositoryAllowList": ["quay.io/"],
+  "scopes": [
+    {"name": "payments", "namespaces": ["payments", "payments-*"], "inputs": {"imageRepositoryAllowList": ["registry.payments.example.com/"]}},
+    {"name": "legacy", "namespaceSelector": "tier=batch", "labelSelector": "app=legacy", "inputs": {"allowedHostPaths": ["/var/spool"]}}
+  ]
+}`)
+
+	t.Run("loads the scopes", func(t *testing.T) {
+		t.Parallel()
+
+		scopes, err := p.GetScopedControlsInputs(context.Background(), "")
+		require.NoError(t, err)
+		assert.Equal(t, []ControlInputsScope{
+			{
+				Name:       "payments",
+				Namespaces: []string{"payments", "payments-*"},
+				Inputs:     map[string][]string{"imageRepositoryAllowList": {"registry.payments.example.com/"}},
+			},
+			{
+				Name:              "legacy",
+				NamespaceSelector: "tier=batch",
+				LabelSelector:     "app=legacy",
+				Inputs:            map[string][]string{"allowedHostPaths": {"/var/spool"}},
+			},
+		}, scopes)
+	})
+
+	t.Run("global inputs ignore the scopes", func(t *testing.T) {
+		t.Parallel()
+
+		inputs, err := p.GetControlsInputs(context.Background(), "")
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{"imageRepositoryAllowList": {"quay.io/"}}, inputs)
+	})
+
+	t.Run("none without a file", func(t *testing.T) {
+		t.Parallel()
+
+		scopes, err := NewLoadPolicy([]string{filepath.Join(t.TempDir(), "missing.json")}).GetScopedControlsInputs(context.Background(), "")
+		require.NoError(t, err)
+		assert.Nil(t, scopes)
+	})
+}
+
+func TestGetScopedControlsInputs_Rejects(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		scopes string
+		err    string
+	}{
+		"no name":          {scopes: `[{"namespaces": ["a"], "inputs": {"x": []}}]`, err: "scope name is required"},
+		"no criteria":      {scopes: `[{"name": "a", "inputs": {"x": []}}]`, err: `scope "a" selects no resources`},
+		"bad glob":         {scopes: `[{"name": "a", "namespaces": ["["], "inputs": {"x": []}}]`, err: `invalid namespace pattern "["`},
+		"bad selector":     {scopes: `[{"name": "a", "labelSelector": "a in (", "inputs": {"x": []}}]`, err: `invalid labelSelector in scope "a"`},
+		"no inputs":        {scopes: `[{"name": "a", "namespaces": ["a"]}]`, err: `scope "a" overrides no inputs`},
+		"duplicate":        {scopes: `[{"name": "a", "namespaces": ["a"], "inputs": {"x": []}}, {"name": "a", "namespaces": ["b"], "inputs": {"x": []}}]`, err: `duplicate scope "a"`},
+		"malformed scopes": {scopes: `{"name": "a"}`, err: "error reading scopes"},
+		"bad ns selector":  {scopes: `[{"name": "a", "namespaceSelector": "a in (", "inputs": {"x": []}}]`, err: `invalid namespaceSelector in scope "a"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := writeControlsConfig(t, `{"scopes": `+tc.scopes+`}`).GetScopedControlsInputs(context.Background(), "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
@@ -0,0 +1,454 @@
+package getter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"gopkg.in/yaml.v3"
+)
+
+// RuleAttributeInlinePolicy marks a CEL rule whose ValidatingAdmissionPolicy
+// is carried in the rule itself rather than looked up by control ID in the
+// embedded bundle, as is the case for the custom CEL rules of a framework
+// definition.
+const RuleAttributeInlinePolicy = "inlineValidatingAdmissionPolicy"
+
+// frameworkDefinitionsDir is where definitions are looked up by name, under
+// the local dot files for kubescape.
+const frameworkDefinitionsDir = "framework-definitions"
+
+// definitionSeverityScores are the base scores a severity override sets, the
+// lowest score opa-utils maps to that severity.
+var definitionSeverityScores = map[string]float32{
+	"critical": 9,
+	"high":     7,
+	"medium":   4,
+	"low":      1,
+}
+
+// FrameworkDefinition composes a framework out of controls taken from other
+// frameworks and of custom Rego or CEL rules. It is loaded from a .yaml or
+// .yml file:
+//
+//	name: golden-baseline
+//	description: Cherry-picked NSA and CIS controls plus our own checks
+//	sections:
+//	  - id: "1"
+//	    name: Workload hardening
+//	    controls:
+//	      - {id: C-0017, framework: NSA, severity: high}
+//	      - id: C-0044
+//	        inputs: {insecureCapabilities: [SYS_ADMIN, NET_ADMIN]}
+//	  - id: "2"
+//	    name: Our checks
+//	    controls:
+//	      - {id: custom-no-latest, name: Images are pinned, rule: rules/no-latest.rego}
+//	      - {id: custom-read-only-root, rule: rules/read-only-root.yaml}
+//
+// A control naming a framework is taken from it, any other control that is
+// not custom is looked up by ID. A custom control's rule is a .rego file, or a
+// .yaml/.yml file holding a ValidatingAdmissionPolicy evaluated as CEL, and is
+// resolved relative to the definition file. Each section is scored on its own.
+type FrameworkDefinition struct {
+	Name        string             `json:"name" yaml:"name"`
+	Description string             `json:"description,omitempty" yaml:"description"`
+	Sections    []FrameworkSection `json:"sections" yaml:"sections"`
+
+	// Source and Digest identify the file the definition was loaded from, so a
+	// report records exactly which definition it was scanned against.
+	Source string `json:"source,omitempty" yaml:"-"`
+	Digest string `json:"digest,omitempty" yaml:"-"`
+}
+
+// FrameworkSection groups controls of a framework definition.
+type FrameworkSection struct {
+	ID       string              `json:"id" yaml:"id"`
+	Name     string              `json:"name,omitempty" yaml:"name"`
+	Controls []DefinitionControl `json:"controls" yaml:"controls"`
+}
+
+// DefinitionControl is one control of a framework definition.
+type DefinitionControl struct {
+	ID string `json:"id" yaml:"id"`
+	// Framework is the framework the control is taken from. Without it the
+	// control is looked up by ID.
+	Framework string `json:"framework,omitempty" yaml:"framework"`
+	// Rule makes the control a custom one, evaluating the rule at that path.
+	Rule string `json:"rule,omitempty" yaml:"rule"`
+	// Match lists the resources a custom rule is evaluated against, every
+	// resource by default.
+	Match       []DefinitionRuleMatch `json:"match,omitempty" yaml:"match"`
+	Name        string                `json:"name,omitempty" yaml:"name"`
+	Description string                `json:"description,omitempty" yaml:"description"`
+	Remediation string                `json:"remediation,omitempty" yaml:"remediation"`
+	// Severity replaces the control's severity.
+	Severity string `json:"severity,omitempty" yaml:"severity"`
+	// Inputs sets control configuration inputs for this control only, over
+	// the ones the scan loads.
+	Inputs map[string][]string `json:"inputs,omitempty" yaml:"inputs"`
+
+	ruleText string
+}
+
+// DefinitionRuleMatch selects the resources a custom rule is evaluated against.
+type DefinitionRuleMatch struct {
+	APIGroups   []string `json:"apiGroups" yaml:"apiGroups"`
+	APIVersions []string `json:"apiVersions" yaml:"apiVersions"`
+	Resources   []string `json:"resources" yaml:"resources"`
+}
+
+// IsCustom reports whether the control evaluates a custom rule.
+func (c *DefinitionControl) IsCustom() bool {
+	return c.Rule != ""
+}
+
+// IsFrameworkDefinitionFile reports whether a framework identifier names a
+// framework definition file rather than a framework.
+func IsFrameworkDefinitionFile(identifier string) bool {
+	switch strings.ToLower(filepath.Ext(identifier)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// FrameworkDefinitionsDir is the directory framework definitions are looked up
+// in by name, and listed from.
+func FrameworkDefinitionsDir() string {
+	return GetDefaultPath(frameworkDefinitionsDir)
+}
+
+// LoadFrameworkDefinition reads and validates the framework definition at
+// file, along with the rules of its custom controls.
+func LoadFrameworkDefinition(file string) (*FrameworkDefinition, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read framework definition: %w", err)
+	}
+	definition := &FrameworkDefinition{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(definition); err != nil {
+		return nil, fmt.Errorf("invalid framework definition %s: %w", file, err)
+	}
+	if err := definition.validate(); err != nil {
+		return nil, fmt.Errorf("invalid framework definition %s: %w", file, err)
+	}
+	if err := definition.readRules(filepath.Dir(file)); err != nil {
+		return nil, fmt.Errorf("invalid framework definition %s: %w", file, err)
+	}
+	digest := sha256.Sum256(data)
+	definition.Source = file
+	definition.Digest = "sha256:" + hex.EncodeToString(digest[:])
+	return definition, nil
+}
+
+// ResolveFrameworkDefinition loads the framework definition a framework
+// identifier refers to: the definition file it names, or the definition saved
+// as <identifier>.yaml or .yml in dir. It returns nil when the identifier
+// refers to no definition.
+func ResolveFrameworkDefinition(identifier, dir string) (*FrameworkDefinition, error) {
+	if IsFrameworkDefinitionFile(identifier) {
+		return LoadFrameworkDefinition(identifier)
+	}
+	if dir == "" || strings.ContainsAny(identifier, `/\`) {
+		return nil, nil
+	}
+	for _, ext := range []string{".yaml", ".yml"} {
+		file := filepath.Join(dir, identifier+ext)
+		if _, err := os.Stat(file); err == nil {
+			return LoadFrameworkDefinition(file)
+		}
+	}
+	return nil, nil
+}
+
+// ListFrameworkDefinitions loads the framework definitions saved in dir. A
+// missing directory holds no definitions. Definitions that fail to load are
+// skipped and reported in the returned error.
+func ListFrameworkDefinitions(dir string) ([]*FrameworkDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read framework definitions directory %q: %w", dir, err)
+	}
+	var definitions []*FrameworkDefinition
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !IsFrameworkDefinitionFile(entry.Name()) {
+			continue
+		}
+		definition, err := LoadFrameworkDefinition(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions, errors.Join(errs...)
+}
+
+// validate checks the definition. Control IDs must be unique across sections,
+// so that every control scores in exactly one section.
+func (d *FrameworkDefinition) validate() error {
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(d.Sections) == 0 {
+		return errors.New("at least one section is required")
+	}
+	sections := map[string]bool{}
+	controls := map[string]bool{}
+	for _, section := range d.Sections {
+		if section.ID == "" {
+			return errors.New("section id is required")
+		}
+		if sections[section.ID] {
+			return fmt.Errorf("duplicate section %q", section.ID)
+		}
+		sections[section.ID] = true
+		if len(section.Controls) == 0 {
+			return fmt.Errorf("section %q has no controls", section.ID)
+		}
+		for _, control := range section.Controls {
+			if control.ID == "" {
+				return fmt.Errorf("control id is required in section %q", section.ID)
+			}
+			id := strings.ToLower(control.ID)
+			if controls[id] {
+				return fmt.Errorf("duplicate control %q", control.ID)
+			}
+			controls[id] = true
+			if control.Severity != "" {
+				if _, ok := definitionSeverityScores[strings.ToLower(control.Severity)]; !ok {
+					return fmt.Errorf("unknown severity %q for control %s, expected one of critical, high, medium, low", control.Severity, control.ID)
+				}
+			}
+			if !control.IsCustom() {
+				if len(control.Match) > 0 {
+					return fmt.Errorf("control %s sets match without a custom rule", control.ID)
+				}
+				continue
+			}
+			if control.Framework != "" {
+				return fmt.Errorf("control %s sets both a custom rule and a framework", control.ID)
+			}
+			if ext := strings.ToLower(filepath.Ext(control.Rule)); ext != ".rego" && !IsFrameworkDefinitionFile(control.Rule) {
+				return fmt.Errorf("rule %q of control %s is not a .rego, .yaml or .yml file", control.Rule, control.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// readRules reads the rules of the custom controls, relative to dir.
+func (d *FrameworkDefinition) readRules(dir string) error {
+	for i := range d.Sections {
+		for j := range d.Sections[i].Controls {
+			control := &d.Sections[i].Controls[j]
+			if !control.IsCustom() {
+				continue
+			}
+			file := control.Rule
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			raw, err := os.ReadFile(filepath.Clean(file))
+			if err != nil {
+				return fmt.Errorf("read rule of control %s: %w", control.ID, err)
+			}
+			control.ruleText = string(raw)
+		}
+	}
+	return nil
+}
+
+// ControlSections maps the ID of every control of the definition to the ID of
+// its section.
+func (d *FrameworkDefinition) ControlSections() map[string]string {
+	sections := map[string]string{}
+	for _, section := range d.Sections {
+		for _, control := range section.Controls {
+			sections[control.ID] = section.ID
+		}
+	}
+	return sections
+}
+
+// Compose builds the framework the definition describes, taking the controls
+// that are not custom from policyGetter.
+func (d *FrameworkDefinition) Compose(policyGetter IPolicyGetter) (*reporthandling.Framework, error) {
+	frameworks := map[string]*reporthandling.Framework{}
+	var controls []reporthandling.Control
+	for _, section := range d.Sections {
+		for i := range section.Controls {
+			definition := &section.Controls[i]
+			var control *reporthandling.Control
+			var err error
+			switch {
+			case definition.IsCustom():
+				control = definition.customControl()
+			case definition.Framework != "":
+				control, err = frameworkControl(policyGetter, frameworks, definition.Framework, definition.ID)
+			default:
+				control, err = policyGetter.GetControl(definition.ID)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("framework definition %s: control %s: %w", d.Name, definition.ID, err)
+			}
+			definition.override(control)
+			controls = append(controls, *control)
+		}
+	}
+	return &reporthandling.Framework{
+		PortalBase: armotypes.PortalBase{
+			Name: d.Name,
+		},
+		Description: d.Description,
+		TypeTags:    []string{"custom"},
+		Controls:    controls,
+	}, nil
+}
+
+// frameworkControl takes a control out of a framework, fetching each
+// framework once.
+func frameworkControl(policyGetter IPolicyGetter, frameworks map[string]*reporthandling.Framework, frameworkName, controlID string) (*reporthandling.Control, error) {
+	key := strings.ToLower(frameworkName)
+	framework, ok := frameworks[key]
+	if !ok {
+		var err error
+		if framework, err = policyGetter.GetFramework(frameworkName); err != nil {
+			return nil, err
+		}
+		if framework == nil {
+			return nil, fmt.Errorf("framework %s: %w", frameworkName, ErrFrameworkNotMatching)
+		}
+		frameworks[key] = framework
+	}
+	for i := range framework.Controls {
+		if strings.EqualFold(framework.Controls[i].ControlID, controlID) {
+			control := framework.Controls[i]
+			return &control, nil
+		}
+	}
+	return nil, fmt.Errorf("not in framework %s: %w", frameworkName, ErrControlNotMatching)
+}
+
+// customControl wraps the control's rule as a control of its own, like
+// LoadCustomRules does.
+func (c *DefinitionControl) customControl() *reporthandling.Control {
+	match := []reporthandling.RuleMatchObjects{{
+		APIGroups:   []string{"*"},
+		APIVersions: []string{"*"},
+		Resources:   []string{"*"},
+	}}
+	if len(c.Match) > 0 {
+		match = make([]reporthandling.RuleMatchObjects, 0, len(c.Match))
+		for _, m := range c.Match {
+			match = append(match, reporthandling.RuleMatchObjects{APIGroups: m.APIGroups, APIVersions: m.APIVersions, Resources: m.Resources})
+		}
+	}
+	rule := reporthandling.PolicyRule{
+		Rule:         c.ruleText,
+		Match:        match,
+		RuleLanguage: reporthandling.RegoLanguage,
+		Description:  fmt.Sprintf("User-authored custom rule from %s", c.Rule),
+		PortalBase: armotypes.PortalBase{
+			Name: strings.TrimSuffix(filepath.Base(c.Rule), filepath.Ext(c.Rule)),
+		},
+	}
+	if IsFrameworkDefinitionFile(c.Rule) {
+		rule.RuleLanguage = reporthandling.CELLanguage
+		rule.Attributes = map[string]interface{}{RuleAttributeInlinePolicy: true}
+	}
+	return &reporthandling.Control{
+		ControlID:   c.ID,
+		Description: rule.Description,
+		Rules:       []reporthandling.PolicyRule{rule},
+		PortalBase: armotypes.PortalBase{
+			Name: c.ID,
+		},
+	}
+}
+
+// override applies the definition's overrides to control.
+func (c *DefinitionControl) override(control *reporthandling.Control) {
+	if c.Name != "" {
+		control.Name = c.Name
+	}
+	if c.Description != "" {
+		control.Description = c.Description
+	}
+	if c.Remediation != "" {
+		control.Remediation = c.Remediation
+	}
+	if c.Severity != "" {
+		control.BaseScore = definitionSeverityScores[strings.ToLower(c.Severity)]
+	}
+	if len(c.Inputs) > 0 {
+		inputs := make(map[string][]string, len(control.FixedInput)+len(c.Inputs))
+		for key, values := range control.FixedInput {
+			inputs[key] = slices.Clone(values)
+		}
+		for key, values := range c.Inputs {
+			inputs[key] = slices.Clone(values)
+		}
+		control.FixedInput = inputs
+	}
+}
+
+// FrameworkDefinitionGetter serves the frameworks of framework definitions,
+// composed from the frameworks and controls of the policy getter it wraps,
+// and delegates everything else to it.
+type FrameworkDefinitionGetter struct {
+	IPolicyGetter
+	definitions map[string]*FrameworkDefinition
+}
+
+var _ IPolicyGetter = &FrameworkDefinitionGetter{}
+
+// NewFrameworkDefinitionGetter wraps policyGetter to serve definitions, keyed
+// by the framework identifiers they were resolved from.
+func NewFrameworkDefinitionGetter(policyGetter IPolicyGetter, definitions map[string]*FrameworkDefinition) *FrameworkDefinitionGetter {
+	return &FrameworkDefinitionGetter{
+		IPolicyGetter: policyGetter,
+		definitions:   definitions,
+	}
+}
+
+// Defines reports whether identifier refers to a framework definition.
+func (g *FrameworkDefinitionGetter) Defines(identifier string) bool {
+	_, ok := g.definitions[identifier]
+	return ok
+}
+
+// GetFramework composes the framework of the definition identifier refers
+// to, if any, and otherwise gets it from the wrapped policy getter.
+func (g *FrameworkDefinitionGetter) GetFramework(identifier string) (*reporthandling.Framework, error) {
+	if definition, ok := g.definitions[identifier]; ok {
+		return definition.Compose(g.IPolicyGetter)
+	}
+	return g.IPolicyGetter.GetFramework(identifier)
+}
+
+// ShouldPersistPolicyArtifacts follows the wrapped policy getter.
+func (g *FrameworkDefinitionGetter) ShouldPersistPolicyArtifacts() bool {
+	if persistence, ok := g.IPolicyGetter.(interface{ ShouldPersistPolicyArtifacts() bool }); ok {
+		return persistence.ShouldPersistPolicyArtifacts()
+	}
+	return true
+}
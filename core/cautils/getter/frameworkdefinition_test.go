@@ -0,0 +1,167 @@
+package getter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/internal/testutils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFrameworkDefinitionFile(name string) string {
+	return filepath.Join(testutils.CurrentDir(), "testdata", "framework-definitions", name+".yaml")
+}
+
+func TestLoadFrameworkDefinition(t *testing.T) {
+	definition, err := LoadFrameworkDefinition(testFrameworkDefinitionFile("golden-baseline"))
+	require.NoError(t, err)
+	assert.Equal(t, "golden-baseline", definition.Name)
+	assert.Len(t, definition.Sections, 3)
+	assert.Contains(t, definition.Digest, "sha256:")
+	assert.Equal(t, map[string]string{
+		"C-0017":                "1",
+		"C-0044":                "1",
+		"C-0053":                "2",
+		"C-0012":                "2",
+		"custom-no-latest":      "3",
+		"custom-read-only-root": "3",
+	}, definition.ControlSections())
+}
+
+func TestLoadFrameworkDefinition_Rejects(t *testing.T) {
+	for name, tc := range map[string]struct {
+		definition string
+		err        string
+	}{
+		"no name":            {definition: "sections: [{id: a, controls: [{id: C-0017}]}]", err: "name is required"},
+		"no sections":        {definition: "name: x", err: "at least one section"},
+		"empty section":      {definition: "name: x\nsections: [{id: a}]", err: `section "a" has no controls`},
+		"duplicate section":  {definition: "name: x\nsections: [{id: a, controls: [{id: C-0017}]}, {id: a, controls: [{id: C-0016}]}]", err: `duplicate section "a"`},
+		"duplicate control":  {definition: "name: x\nsections: [{id: a, controls: [{id: C-0017}]}, {id: b, controls: [{id: c-0017}]}]", err: `duplicate control "c-0017"`},
+		"unknown severity":   {definition: "name: x\nsections: [{id: a, controls: [{id: C-0017, severity: urgent}]}]", err: `unknown severity "urgent"`},
+		"unknown field":      {definition: "name: x\nsections: [{id: a, controls: [{id: C-0017, weight: 2}]}]", err: "field weight not found"},
+		"rule and framework": {definition: "name: x\nsections: [{id: a, controls: [{id: custom-a, rule: a.rego, framework: NSA}]}]", err: "both a custom rule and a framework"},
+		"unknown rule type":  {definition: "name: x\nsections: [{id: a, controls: [{id: custom-a, rule: a.py}]}]", err: "not a .rego, .yaml or .yml file"},
+		"missing rule":       {definition: "name: x\nsections: [{id: a, controls: [{id: custom-a, rule: missing.rego}]}]", err: "read rule of control custom-a"},
+		"match without rule": {definition: "name: x\nsections: [{id: a, controls: [{id: C-0017, match: [{resources: [Pod]}]}]}]", err: "sets match without a custom rule"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "definition.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tc.definition), 0o600))
+			_, err := LoadFrameworkDefinition(file)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestFrameworkDefinitionCompose(t *testing.T) {
+	definition, err := LoadFrameworkDefinition(testFrameworkDefinitionFile("golden-baseline"))
+	require.NoError(t, err)
+	policyGetter := NewLoadPolicy([]string{testFrameworkFile("NSA"), testFrameworkFile("MITRE")})
+
+	framework, err := definition.Compose(policyGetter)
+	require.NoError(t, err)
+	assert.Equal(t, "golden-baseline", framework.Name)
+
+	controls := map[string]reporthandling.Control{}
+	var ids []string
+	for _, control := range framework.Controls {
+		controls[control.ControlID] = control
+		ids = append(ids, control.ControlID)
+	}
+	assert.Equal(t, []string{"C-0017", "C-0044", "C-0053", "C-0012", "custom-no-latest", "custom-read-only-root"}, ids)
+
+	t.Run("overrides severities", func(t *testing.T) {
+		assert.Equal(t, float32(9), controls["C-0017"].BaseScore)
+		assert.Equal(t, float32(4), controls["custom-no-latest"].BaseScore)
+	})
+
+	t.Run("sets per-control inputs", func(t *testing.T) {
+		assert.Equal(t, map[string][]string{"insecureCapabilities": {"SYS_ADMIN"}}, controls["C-0044"].FixedInput)
+	})
+
+	t.Run("wraps rego rules", func(t *testing.T) {
+		control := controls["custom-no-latest"]
+		assert.Equal(t, "Images are pinned", control.Name)
+		require.Len(t, control.Rules, 1)
+		assert.Equal(t, reporthandling.RegoLanguage, control.Rules[0].RuleLanguage)
+		assert.Contains(t, control.Rules[0].Rule, "package armo_builtins")
+		assert.Equal(t, []string{"Pod"}, control.Rules[0].Match[0].Resources)
+	})
+
+	t.Run("wraps CEL rules", func(t *testing.T) {
+		control := controls["custom-read-only-root"]
+		require.Len(t, control.Rules, 1)
+		assert.Equal(t, reporthandling.CELLanguage, control.Rules[0].RuleLanguage)
+		assert.Contains(t, control.Rules[0].Rule, "kind: ValidatingAdmissionPolicy")
+		assert.Equal(t, true, control.Rules[0].Attributes[RuleAttributeInlinePolicy])
+		assert.Equal(t, []string{"*"}, control.Rules[0].Match[0].Resources)
+	})
+
+	t.Run("fails on controls missing from their framework", func(t *testing.T) {
+		definition := &FrameworkDefinition{Name: "x", Sections: []FrameworkSection{{ID: "a", Controls: []DefinitionControl{{ID: "C-0053", Framework: "NSA"}}}}}
+		_, err := definition.Compose(policyGetter)
+		require.ErrorIs(t, err, ErrControlNotMatching)
+	})
+}
+
+func TestFrameworkDefinitionGetter(t *testing.T) {
+	definition, err := LoadFrameworkDefinition(testFrameworkDefinitionFile("golden-baseline"))
+	require.NoError(t, err)
+	policyGetter := NewFrameworkDefinitionGetter(
+		NewLoadPolicy([]string{testFrameworkFile("NSA"), testFrameworkFile("MITRE")}),
+		map[string]*FrameworkDefinition{"./golden-baseline.yaml": definition},
+	)
+
+	assert.True(t, policyGetter.Defines("./golden-baseline.yaml"))
+	assert.False(t, policyGetter.Defines("NSA"))
+	assert.False(t, policyGetter.ShouldPersistPolicyArtifacts())
+
+	framework, err := policyGetter.GetFramework("./golden-baseline.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "golden-baseline", framework.Name)
+
+	framework, err = policyGetter.GetFramework("NSA")
+	require.NoError(t, err)
+	assert.Equal(t, "NSA", framework.Name)
+}
+
+func TestResolveFrameworkDefinition(t *testing.T) {
+	dir := filepath.Dir(testFrameworkDefinitionFile("golden-baseline"))
+
+	definition, err := ResolveFrameworkDefinition(testFrameworkDefinitionFile("golden-baseline"), "")
+	require.NoError(t, err)
+	require.NotNil(t, definition)
+
+	definition, err = ResolveFrameworkDefinition("golden-baseline", dir)
+	require.NoError(t, err)
+	require.NotNil(t, definition)
+	assert.Equal(t, "golden-baseline", definition.Name)
+
+	definition, err = ResolveFrameworkDefinition("nsa", dir)
+	require.NoError(t, err)
+	assert.Nil(t, definition)
+
+	_, err = ResolveFrameworkDefinition("./missing.yaml", dir)
+	require.Error(t, err)
+}
+
+func TestListFrameworkDefinitions(t *testing.T) {
+	definitions, err := ListFrameworkDefinitions(filepath.Dir(testFrameworkDefinitionFile("golden-baseline")))
+	require.NoError(t, err)
+	require.Len(t, definitions, 1)
+	assert.Equal(t, "golden-baseline", definitions[0].Name)
+
+	definitions, err = ListFrameworkDefinitions(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, definitions)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("name: broken"), 0o600))
+	_, err = ListFrameworkDefinitions(dir)
+	require.Error(t, err)
+}
@@ -30,6 +30,12 @@ type (
 		GetControlsInputs(ctx context.Context, clusterName string) (map[string][]string, error)
 	}
 
+	// IScopedControlsInputsGetter knows how to retrieve the control inputs
+	// applying to the resources of some scopes only.
+	IScopedControlsInputsGetter interface {
+		GetScopedControlsInputs(ctx context.Context, clusterName string) ([]ControlInputsScope, error)
+	}
+
 	// IAttackTracksGetter knows how to retrieve attack tracks.
 	IAttackTracksGetter interface {
 		GetAttackTracks() ([]v1alpha1.AttackTrack, error)
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/armosec/armoapi-go/armotypes"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/opa-utils/reporthandling"
@@ -31,10 +32,11 @@ var (
 )
 
 var (
-	_ IPolicyGetter         = &LoadPolicy{}
-	_ IExceptionsGetter     = &LoadPolicy{}
-	_ IAttackTracksGetter   = &LoadPolicy{}
-	_ IControlsInputsGetter = &LoadPolicy{}
+	_ IPolicyGetter               = &LoadPolicy{}
+	_ IExceptionsGetter           = &LoadPolicy{}
+	_ IAttackTracksGetter         = &LoadPolicy{}
+	_ IControlsInputsGetter       = &LoadPolicy{}
+	_ IScopedControlsInputsGetter = &LoadPolicy{}
 )
 
 func getCacheDir() string {
@@ -292,8 +294,8 @@ func (lp *LoadPolicy) GetControlsInputs(_ context.Context, _ /* clusterName */ s
 		return nil, formattedError
 	}
 
-	controlInputs := make(map[string][]string, 100) // from armotypes.Settings.PostureControlInputs
-	if err = json.Unmarshal(buf, &controlInputs); err != nil {
+	rawInputs := make(map[string]jsoniter.RawMessage, 100) // from armotypes.Settings.PostureControlInputs
+	if err = json.Unmarshal(buf, &rawInputs); err != nil {
 		formattedError := fmt.Errorf(
 			`error reading %s file, %v, "controls-config" will be downloaded from ARMO management portal`,
 			fileName, err,
@@ -301,6 +303,21 @@ func (lp *LoadPolicy) GetControlsInputs(_ context.Context, _ /* clusterName */ s
 
 		return nil, formattedError
 	}
+	delete(rawInputs, ControlInputsScopesKey) // see GetScopedControlsInputs
+
+	controlInputs := make(map[string][]string, len(rawInputs))
+	for key, raw := range rawInputs {
+		var values []string
+		if err = json.Unmarshal(raw, &values); err != nil {
+			formattedError := fmt.Errorf(
+				`error reading %s file, input %q: %v, "controls-config" will be downloaded from ARMO management portal`,
+				fileName, key, err,
+			)
+
+			return nil, formattedError
+		}
+		controlInputs[key] = values
+	}
 
 	return controlInputs, nil
 }
@@ -0,0 +1,341 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/attacktrack/v1alpha1"
+	"github.com/sigstore/cosign/v3/pkg/cosign"
+	ociremote "github.com/sigstore/cosign/v3/pkg/oci/remote"
+	sigs "github.com/sigstore/cosign/v3/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// =======================================================================================================================
+// ============================================== OCIPolicyBundle ========================================================
+// =======================================================================================================================
+
+const (
+	// PolicyBundleConfigMediaType identifies an OCI artifact as a Kubescape policy bundle.
+	PolicyBundleConfigMediaType types.MediaType = "application/vnd.kubescape.policy-bundle.config.v1+json"
+	// PolicyBundleLayerMediaType is the media type of each policy file in a bundle.
+	PolicyBundleLayerMediaType types.MediaType = "application/vnd.kubescape.policy.v1+json"
+
+	policyBundleScheme   = "oci://"
+	policyBundlesDir     = "policy-bundles"
+	policyFileAnnotation = "org.opencontainers.image.title"
+	// maxPolicyFileBytes bounds each file pulled from a bundle. The allcontrols
+	// framework, the largest released file, is a few MiB.
+	maxPolicyFileBytes = 64 << 20
+
+	// the files download artifacts writes next to the frameworks
+	bundleControlsInputsFile = "controls-inputs.json"
+	bundleExceptionsFile     = "exceptions.json"
+	bundleAttackTracksFile   = "attack-tracks.json"
+)
+
+var (
+	_ IPolicyGetter               = &OCIPolicyBundle{}
+	_ IExceptionsGetter           = &OCIPolicyBundle{}
+	_ IAttackTracksGetter         = &OCIPolicyBundle{}
+	_ IControlsInputsGetter       = &OCIPolicyBundle{}
+	_ IScopedControlsInputsGetter = &OCIPolicyBundle{}
+
+	ErrPolicyBundleKeyRequired = errors.New("a cosign public key is required to verify the policy bundle")
+	ErrNotPolicyBundle         = errors.New("the artifact is not a Kubescape policy bundle")
+)
+
+// OCIPolicyBundle serves the frameworks, control inputs, exceptions and attack
+// tracks of a policy bundle pulled from an OCI registry. Bundles are verified
+// with cosign before use and cached under the local store by digest.
+type OCIPolicyBundle struct {
+	dir      string
+	digest   string
+	policies *LoadPolicy
+}
+
+// PullOCIPolicyBundle resolves reference (e.g. oci://registry.example.com/kubescape/policies:v2.0.301),
+// verifies the signature of the resolved digest with the cosign public key
+// keyRef, and returns the bundle, downloading it unless it is already cached.
+func PullOCIPolicyBundle(ctx context.Context, reference, keyRef string) (*OCIPolicyBundle, error) {
+	if keyRef == "" {
+		return nil, ErrPolicyBundleKeyRequired
+	}
+	verifier, err := sigs.LoadPublicKey(ctx, keyRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the policy bundle key %s: %w", keyRef, err)
+	}
+	return pullOCIPolicyBundle(ctx, reference, verifier, GetDefaultPath(policyBundlesDir), registryOptions(ctx)...)
+}
+
+// PushOCIPolicyBundle publishes files, the output of download artifacts, as a
+// policy bundle at reference and returns the pushed digest. The bundle is not
+// signed; sign the digest with cosign before scanning with it.
+func PushOCIPolicyBundle(ctx context.Context, reference string, files []string) (string, error) {
+	return pushOCIPolicyBundle(reference, files, registryOptions(ctx)...)
+}
+
+// ParsePolicyBundleReference parses an OCI reference, with or without the oci:// scheme.
+func ParsePolicyBundleReference(reference string) (name.Reference, error) {
+	ref, err := name.ParseReference(strings.TrimPrefix(reference, policyBundleScheme))
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy bundle reference %q: %w", reference, err)
+	}
+	return ref, nil
+}
+
+// Digest returns the digest the bundle was pulled as.
+func (b *OCIPolicyBundle) Digest() string {
+	return b.digest
+}
+
+func (b *OCIPolicyBundle) GetFramework(name string) (*reporthandling.Framework, error) {
+	return b.policies.GetFramework(name)
+}
+
+func (b *OCIPolicyBundle) GetFrameworks() ([]reporthandling.Framework, error) {
+	return b.policies.GetFrameworks()
+}
+
+func (b *OCIPolicyBundle) GetControl(ID string) (*reporthandling.Control, error) {
+	return b.policies.GetControl(ID)
+}
+
+func (b *OCIPolicyBundle) ListFrameworks() ([]string, error) {
+	return b.policies.ListFrameworks()
+}
+
+func (b *OCIPolicyBundle) ListControls() ([]string, error) {
+	return b.policies.ListControls()
+}
+
+func (b *OCIPolicyBundle) GetControlsInputs(ctx context.Context, clusterName string) (map[string][]string, error) {
+	file, err := b.file(bundleControlsInputsFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoadPolicy([]string{file}).GetControlsInputs(ctx, clusterName)
+}
+
+// GetScopedControlsInputs retrieves the scoped control inputs of the bundle's
+// controls-config.
+func (b *OCIPolicyBundle) GetScopedControlsInputs(ctx context.Context, clusterName string) ([]ControlInputsScope, error) {
+	file, err := b.file(bundleControlsInputsFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoadPolicy([]string{file}).GetScopedControlsInputs(ctx, clusterName)
+}
+
+func (b *OCIPolicyBundle) GetExceptions(ctx context.Context, clusterName string) ([]armotypes.PostureExceptionPolicy, error) {
+	file, err := b.file(bundleExceptionsFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoadPolicy([]string{file}).GetExceptions(ctx, clusterName)
+}
+
+func (b *OCIPolicyBundle) GetAttackTracks() ([]v1alpha1.AttackTrack, error) {
+	file, err := b.file(bundleAttackTracksFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoadPolicy([]string{file}).GetAttackTracks()
+}
+
+func (b *OCIPolicyBundle) file(fileName string) (string, error) {
+	file := filepath.Join(b.dir, fileName)
+	if _, err := os.Stat(file); err != nil {
+		return "", fmt.Errorf("policy bundle %s has no %s", b.digest, fileName)
+	}
+	return file, nil
+}
+
+func registryOptions(ctx context.Context) []remote.Option {
+	return []remote.Option{remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+}
+
+// pullOCIPolicyBundle pulls the bundle into cacheDir. A nil verifier skips the
+// signature check and is only used by tests.
+func pullOCIPolicyBundle(ctx context.Context, reference string, verifier signature.Verifier, cacheDir string, opts ...remote.Option) (*OCIPolicyBundle, error) {
+	ref, err := ParsePolicyBundleReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := remote.Get(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve policy bundle %s: %w", ref, err)
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy bundle %s: %w", ref, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy bundle %s: %w", ref, err)
+	}
+	if manifest.Config.MediaType != PolicyBundleConfigMediaType {
+		return nil, fmt.Errorf("%s: %w", ref, ErrNotPolicyBundle)
+	}
+
+	digestRef := ref.Context().Digest(desc.Digest.String())
+	if verifier != nil {
+		if err := verifyPolicyBundle(ctx, digestRef, verifier, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// the cache is content addressed, so a cached digest never needs refreshing
+	dir := filepath.Join(cacheDir, strings.ReplaceAll(desc.Digest.String(), ":", "-"))
+	if _, err := os.Stat(dir); err != nil {
+		if err := extractPolicyBundle(img, manifest.Layers, cacheDir, dir); err != nil {
+			return nil, fmt.Errorf("failed to download policy bundle %s: %w", digestRef, err)
+		}
+	}
+	bundle, err := newOCIPolicyBundle(dir, desc.Digest.String())
+	if err != nil {
+		return nil, err
+	}
+	logger.L().Info("Loaded policy bundle", helpers.String("reference", ref.String()), helpers.String("digest", bundle.digest))
+	return bundle, nil
+}
+
+func verifyPolicyBundle(ctx context.Context, ref name.Digest, verifier signature.Verifier, opts []remote.Option) error {
+	co := &cosign.CheckOpts{
+		SigVerifier:   verifier,
+		ClaimVerifier: cosign.SimpleClaimVerifier,
+		// the bundle is trusted through the key alone: air-gapped sites mirror
+		// the registry, signatures included, but not a transparency log
+		IgnoreTlog:         true,
+		ExperimentalOCI11:  true,
+		RegistryClientOpts: []ociremote.Option{ociremote.WithRemoteOptions(opts...)},
+	}
+	if _, _, err := cosign.VerifyImageSignatures(ctx, ref, co); err != nil {
+		return fmt.Errorf("failed to verify the signature of policy bundle %s: %w", ref, err)
+	}
+	return nil
+}
+
+// extractPolicyBundle writes the policy files of a bundle to dir, through a
+// temporary directory so an interrupted pull never leaves a partial cache.
+func extractPolicyBundle(img v1.Image, layers []v1.Descriptor, cacheDir, dir string) error {
+	if err := os.MkdirAll(cacheDir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(cacheDir, ".pull-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, desc := range layers {
+		fileName := desc.Annotations[policyFileAnnotation]
+		if fileName == "" || filepath.Base(fileName) != fileName || filepath.Ext(fileName) != ".json" {
+			return fmt.Errorf("invalid policy file name %q", fileName)
+		}
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return err
+		}
+		if err := writePolicyFile(layer, filepath.Join(tmp, fileName)); err != nil {
+			return fmt.Errorf("%s: %w", fileName, err)
+		}
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		// another scan cached the same digest first
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func writePolicyFile(layer v1.Layer, file string) error {
+	// policy files are stored as is, so the blob is the file
+	rc, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxPolicyFileBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxPolicyFileBytes {
+		return fmt.Errorf("larger than %d bytes", maxPolicyFileBytes)
+	}
+	return os.WriteFile(file, data, 0o600)
+}
+
+// newOCIPolicyBundle loads the bundle cached in dir. Every file other than the
+// control inputs, exceptions and attack tracks is a framework.
+func newOCIPolicyBundle(dir, digest string) (*OCIPolicyBundle, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var frameworks []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if slices.Contains([]string{bundleControlsInputsFile, bundleExceptionsFile, bundleAttackTracksFile}, entry.Name()) {
+			continue
+		}
+		frameworks = append(frameworks, filepath.Join(dir, entry.Name()))
+	}
+	return &OCIPolicyBundle{dir: dir, digest: digest, policies: NewLoadPolicy(frameworks)}, nil
+}
+
+func pushOCIPolicyBundle(reference string, files []string, opts ...remote.Option) (string, error) {
+	ref, err := ParsePolicyBundleReference(reference)
+	if err != nil {
+		return "", err
+	}
+	files = slices.Clone(files)
+	slices.Sort(files)
+
+	img := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), PolicyBundleConfigMediaType)
+	addenda := make([]mutate.Addendum, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return "", err
+		}
+		addenda = append(addenda, mutate.Addendum{
+			Layer:       static.NewLayer(data, PolicyBundleLayerMediaType),
+			Annotations: map[string]string{policyFileAnnotation: filepath.Base(file)},
+		})
+	}
+	img, err = mutate.Append(img, addenda...)
+	if err != nil {
+		return "", err
+	}
+	if err := remote.Write(ref, img, opts...); err != nil {
+		return "", fmt.Errorf("failed to push policy bundle %s: %w", ref, err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return "", err
+	}
+	return digest.String(), nil
+}
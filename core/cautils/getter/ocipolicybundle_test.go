@@ -0,0 +1,103 @@
+package getter
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry() *httptest.Server {
+	return httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+}
+
+func testPolicyBundleFiles() []string {
+	files := []string{testFrameworkFile("NSA"), testFrameworkFile("MITRE")}
+	for _, artifact := range []string{"controls-inputs", "exceptions", "attack-tracks"} {
+		files = append(files, testFrameworkFile(artifact))
+	}
+	return files
+}
+
+func TestOCIPolicyBundleRoundTrip(t *testing.T) {
+	server := newTestRegistry()
+	defer server.Close()
+	reference := "oci://" + strings.TrimPrefix(server.URL, "http://") + "/kubescape/policies:v1"
+	ctx := context.Background()
+
+	digest, err := pushOCIPolicyBundle(reference, testPolicyBundleFiles())
+	require.NoError(t, err)
+
+	cacheDir := t.TempDir()
+	bundle, err := pullOCIPolicyBundle(ctx, reference, nil, cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, digest, bundle.Digest())
+	assert.DirExists(t, filepath.Join(cacheDir, strings.ReplaceAll(digest, ":", "-")))
+
+	frameworks, err := bundle.ListFrameworks()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"NSA", "MITRE"}, frameworks)
+	framework, err := bundle.GetFramework("nsa")
+	require.NoError(t, err)
+	assert.NotEmpty(t, framework.Controls)
+
+	inputs, err := bundle.GetControlsInputs(ctx, "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, inputs)
+	exceptions, err := bundle.GetExceptions(ctx, "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, exceptions)
+	attackTracks, err := bundle.GetAttackTracks()
+	require.NoError(t, err)
+	assert.NotEmpty(t, attackTracks)
+
+	t.Run("pulls a cached digest from the cache", func(t *testing.T) {
+		dir := filepath.Join(cacheDir, strings.ReplaceAll(digest, ":", "-"))
+		require.NoError(t, os.Remove(filepath.Join(dir, "MITRE.json")))
+
+		cached, err := pullOCIPolicyBundle(ctx, reference, nil, cacheDir)
+		require.NoError(t, err)
+		frameworks, err := cached.ListFrameworks()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"NSA"}, frameworks)
+	})
+}
+
+func TestPullOCIPolicyBundle_Rejects(t *testing.T) {
+	server := newTestRegistry()
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	ctx := context.Background()
+
+	t.Run("bundles without a key", func(t *testing.T) {
+		_, err := PullOCIPolicyBundle(ctx, "oci://"+host+"/kubescape/policies:v1", "")
+		require.ErrorIs(t, err, ErrPolicyBundleKeyRequired)
+	})
+
+	t.Run("artifacts that are not policy bundles", func(t *testing.T) {
+		img, err := random.Image(64, 1)
+		require.NoError(t, err)
+		ref, err := name.ParseReference(host + "/kubescape/image:v1")
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, img))
+
+		_, err = pullOCIPolicyBundle(ctx, "oci://"+host+"/kubescape/image:v1", nil, t.TempDir())
+		require.ErrorIs(t, err, ErrNotPolicyBundle)
+	})
+
+	t.Run("invalid references", func(t *testing.T) {
+		_, err := ParsePolicyBundleReference("oci://Not A Reference")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,15 @@
+package armo_builtins
+
+deny[msga] {
+	pod := input[_]
+	pod.kind == "Pod"
+	container := pod.spec.containers[_]
+	endswith(container.image, ":latest")
+	msga := {
+		"alertMessage": sprintf("container %v uses the latest tag", [container.name]),
+		"alertScore": 5,
+		"failedPaths": [],
+		"fixPaths": [],
+		"alertObject": {"k8sApiObjects": [pod]},
+	}
+}
@@ -0,0 +1,672 @@
+package cautils
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/strvals"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SourceTypeGitOps is the source file type of resources rendered from a
+// GitOps object. The source's path is the file declaring the object.
+const SourceTypeGitOps = "GitOps"
+
+// How a GitOps object was rendered.
+const (
+	GitOpsRendererHelm      = "helm"
+	GitOpsRendererKustomize = "kustomize"
+	GitOpsRendererManifests = "manifests"
+)
+
+// gitOpsKinds maps the GitOps object kinds rendered by a --gitops scan to
+// their API group.
+var gitOpsKinds = map[string]string{
+	"Application":    "argoproj.io",
+	"ApplicationSet": "argoproj.io",
+	"HelmRelease":    "helm.toolkit.fluxcd.io",
+	"Kustomization":  "kustomize.toolkit.fluxcd.io",
+}
+
+// gitOpsPlaceholder matches an ApplicationSet template parameter left
+// without a value.
+var gitOpsPlaceholder = regexp.MustCompile(`{{[^}]*}}`)
+
+// GitOpsObjectRef identifies the GitOps object a rendered resource came from.
+type GitOpsObjectRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Application is the name of the Application an ApplicationSet generated.
+	Application string `json:"application,omitempty"`
+	// Path is the file declaring the object, relative to the scanned
+	// repository root.
+	Path string `json:"path"`
+	// Renderer is GitOpsRendererHelm, GitOpsRendererKustomize or
+	// GitOpsRendererManifests, and Source the chart or directory it rendered,
+	// relative to the scanned repository root when it is inside it.
+	Renderer string `json:"renderer"`
+	Source   string `json:"source"`
+}
+
+// GitOpsRendering is the resources one source of a GitOps object rendered.
+type GitOpsRendering struct {
+	Ref       GitOpsObjectRef
+	Workloads []workloadinterface.IMetadata
+}
+
+// GitOpsSources maps the sources GitOps objects reference to local stand-ins,
+// so that they render without network access. Keys are Git, Helm or OCI
+// repository URLs, or Flux source objects named <Kind>/<namespace>/<name>;
+// values are local Git checkouts, directories holding Helm charts or chart
+// archives, or chart archives.
+type GitOpsSources map[string]string
+
+// ParseGitOpsSources parses --gitops-source values of the form
+// <source>=<path>.
+func ParseGitOpsSources(values []string) (GitOpsSources, error) {
+	sources := make(GitOpsSources, len(values))
+	for _, value := range values {
+		i := strings.LastIndex(value, "=")
+		if i <= 0 || i == len(value)-1 {
+			return nil, fmt.Errorf("invalid --gitops-source %q, expected <source>=<path>", value)
+		}
+		path, err := filepath.Abs(value[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid --gitops-source %q: %w", value, err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("invalid --gitops-source %q: %w", value, err)
+		}
+		sources[normalizeGitOpsSource(value[:i])] = path
+	}
+	return sources, nil
+}
+
+// normalizeGitOpsSource lets the forms of a repository URL found in GitOps
+// objects match each other: "git@host:org/repo.git" and
+// "https://host/org/repo/" both become "https://host/org/repo".
+func normalizeGitOpsSource(source string) string {
+	source = strings.TrimSpace(source)
+	if rest, ok := strings.CutPrefix(source, "git@"); ok {
+		source = "https://" + strings.Replace(rest, ":", "/", 1)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(source, "/"), ".git")
+}
+
+// IsGitOpsObject reports whether wl is an Argo CD Application or
+// ApplicationSet, or a Flux HelmRelease or Kustomization.
+func IsGitOpsObject(wl workloadinterface.IMetadata) bool {
+	group, ok := gitOpsKinds[wl.GetKind()]
+	if !ok {
+		return false
+	}
+	apiGroup, _, _ := strings.Cut(wl.GetApiVersion(), "/")
+	return apiGroup == group
+}
+
+// GitOpsRenderer renders GitOps objects locally, with the Helm and Kustomize
+// loaders used for charts and Kustomize directories.
+type GitOpsRenderer struct {
+	sources  GitOpsSources
+	repoRoot string
+	repoURL  string
+	// manifests are the scanned manifests, where Flux sources and the
+	// ConfigMaps and Secrets HelmReleases take values from are looked up.
+	manifests []workloadinterface.IMetadata
+}
+
+// NewGitOpsRenderer creates a renderer resolving sources through sources. A
+// source matching repoURL, the remote of the scanned repository, resolves to
+// repoRoot.
+func NewGitOpsRenderer(sources GitOpsSources, repoRoot, repoURL string, manifests []workloadinterface.IMetadata) *GitOpsRenderer {
+	return &GitOpsRenderer{
+		sources:   sources,
+		repoRoot:  repoRoot,
+		repoURL:   repoURL,
+		manifests: manifests,
+	}
+}
+
+// Render renders a GitOps object into the resources it deploys, one rendering
+// per source: an Application with several sources, or an ApplicationSet
+// generating several Applications, has several.
+func (r *GitOpsRenderer) Render(ctx context.Context, wl workloadinterface.IMetadata) ([]GitOpsRendering, error) {
+	ref := GitOpsObjectRef{Kind: wl.GetKind(), Namespace: wl.GetNamespace(), Name: wl.GetName()}
+	switch wl.GetKind() {
+	case "Application":
+		return r.renderApplication(ctx, wl.GetObject(), ref)
+	case "ApplicationSet":
+		return r.renderApplicationSet(ctx, wl.GetObject(), ref)
+	case "HelmRelease":
+		return r.renderHelmRelease(wl, ref)
+	case "Kustomization":
+		return r.renderKustomization(ctx, wl, ref)
+	default:
+		return nil, fmt.Errorf("%s is not a GitOps object", wl.GetKind())
+	}
+}
+
+// renderApplication renders the sources of an Argo CD Application. Sources
+// with a ref only provide value files to the others, as $<ref>/<path>.
+func (r *GitOpsRenderer) renderApplication(ctx context.Context, app map[string]any, ref GitOpsObjectRef) ([]GitOpsRendering, error) {
+	var sources []map[string]any
+	if source := gitOpsMap(app, "spec", "source"); source != nil {
+		sources = append(sources, source)
+	}
+	for _, source := range gitOpsSlice(app, "spec", "sources") {
+		if source, ok := source.(map[string]any); ok {
+			sources = append(sources, source)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("spec.source is missing")
+	}
+
+	refs := map[string]string{}
+	for _, source := range sources {
+		if name := gitOpsString(source, "ref"); name != "" {
+			dir, err := r.resolve(gitOpsString(source, "repoURL"))
+			if err != nil {
+				return nil, err
+			}
+			refs[name] = dir
+		}
+	}
+
+	namespace := gitOpsString(app, "spec", "destination", "namespace")
+	var renderings []GitOpsRendering
+	for _, source := range sources {
+		if gitOpsString(source, "ref") != "" && gitOpsString(source, "path") == "" && gitOpsString(source, "chart") == "" {
+			continue
+		}
+		rendering, err := r.renderArgoSource(ctx, source, refs, namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+		renderings = append(renderings, rendering)
+	}
+	return renderings, nil
+}
+
+// renderArgoSource renders one source of an Application: a chart from a Helm
+// repository, or a path of a Git repository holding a chart, a Kustomize
+// directory or plain manifests.
+func (r *GitOpsRenderer) renderArgoSource(ctx context.Context, source map[string]any, refs map[string]string, namespace string, ref GitOpsObjectRef) (GitOpsRendering, error) {
+	rendering := GitOpsRendering{Ref: ref}
+	root, err := r.resolve(gitOpsString(source, "repoURL"))
+	if err != nil {
+		return rendering, err
+	}
+
+	var chartPath string
+	if chart := gitOpsString(source, "chart"); chart != "" {
+		if chartPath, err = findChart(root, chart, gitOpsString(source, "targetRevision")); err != nil {
+			return rendering, err
+		}
+	} else {
+		dir := filepath.Join(root, gitOpsString(source, "path"))
+		if ok, _ := IsHelmDirectory(dir); !ok {
+			return r.renderDirectory(ctx, dir, rendering)
+		}
+		chartPath = dir
+	}
+
+	helm := gitOpsMap(source, "helm")
+	values, err := argoHelmValues(helm, chartPath, refs)
+	if err != nil {
+		return rendering, err
+	}
+	release := helmchartutil.ReleaseOptions{
+		Name:      cmp.Or(gitOpsString(helm, "releaseName"), ref.Application, ref.Name),
+		Namespace: namespace,
+	}
+	return r.renderChart(chartPath, values, release, rendering)
+}
+
+// argoHelmValues merges the Helm values of an Application source the way Argo
+// CD does: value files, then values, then valuesObject, then parameters.
+func argoHelmValues(helm map[string]any, chartPath string, refs map[string]string) (map[string]any, error) {
+	var valueFiles []string
+	for _, file := range gitOpsStrings(helm, "valueFiles") {
+		if rest, ok := strings.CutPrefix(file, "$"); ok {
+			name, path, _ := strings.Cut(rest, "/")
+			dir, ok := refs[name]
+			if !ok {
+				return nil, fmt.Errorf("value file %s references unknown source $%s", file, name)
+			}
+			file = filepath.Join(dir, path)
+		} else if !filepath.IsAbs(file) {
+			file = filepath.Join(chartPath, file)
+		}
+		if gitOpsBool(helm, "ignoreMissingValueFiles") && !isFile(file) {
+			continue
+		}
+		valueFiles = append(valueFiles, file)
+	}
+	values, err := HelmValueOptions{ValueFiles: valueFiles}.MergeValues()
+	if err != nil {
+		return nil, err
+	}
+
+	if inline := gitOpsString(helm, "values"); inline != "" {
+		inlineValues, err := helmchartutil.ReadValues([]byte(inline))
+		if err != nil {
+			return nil, fmt.Errorf("invalid helm.values: %w", err)
+		}
+		values = mergeMaps(values, inlineValues)
+	}
+	if valuesObject := gitOpsMap(helm, "valuesObject"); valuesObject != nil {
+		values = mergeMaps(values, valuesObject)
+	}
+
+	var parameters HelmValueOptions
+	for _, parameter := range gitOpsSlice(helm, "parameters") {
+		parameter, ok := parameter.(map[string]any)
+		if !ok {
+			continue
+		}
+		value := gitOpsString(parameter, "name") + "=" + gitOpsString(parameter, "value")
+		if gitOpsBool(parameter, "forceString") {
+			parameters.StringValues = append(parameters.StringValues, value)
+		} else {
+			parameters.Values = append(parameters.Values, value)
+		}
+	}
+	parameterValues, err := parameters.MergeValues()
+	if err != nil {
+		return nil, fmt.Errorf("invalid helm.parameters: %w", err)
+	}
+	return mergeMaps(values, parameterValues), nil
+}
+
+// renderApplicationSet renders the Applications an ApplicationSet generates.
+// Only list generators are rendered: the others need a cluster or network
+// access.
+func (r *GitOpsRenderer) renderApplicationSet(ctx context.Context, appSet map[string]any, ref GitOpsObjectRef) ([]GitOpsRendering, error) {
+	template := gitOpsMap(appSet, "spec", "template")
+	if template == nil {
+		return nil, errors.New("spec.template is missing")
+	}
+
+	var renderings []GitOpsRendering
+	for _, generator := range gitOpsSlice(appSet, "spec", "generators") {
+		generator, ok := generator.(map[string]any)
+		if !ok {
+			continue
+		}
+		if gitOpsMap(generator, "list") == nil {
+			return nil, fmt.Errorf("%s generators are not supported offline, only list generators are rendered", strings.Join(slices.Sorted(maps.Keys(generator)), ", "))
+		}
+		for _, element := range gitOpsSlice(generator, "list", "elements") {
+			element, ok := element.(map[string]any)
+			if !ok {
+				continue
+			}
+			params := map[string]string{}
+			flattenGitOpsParams("", element, params)
+			app, err := applyGitOpsParams(template, params)
+			if err != nil {
+				return nil, err
+			}
+			appRef := ref
+			appRef.Application = gitOpsString(app, "metadata", "name")
+			appRenderings, err := r.renderApplication(ctx, app, appRef)
+			if err != nil {
+				return nil, fmt.Errorf("application %s: %w", appRef.Application, err)
+			}
+			renderings = append(renderings, appRenderings...)
+		}
+	}
+	return renderings, nil
+}
+
+func flattenGitOpsParams(prefix string, element map[string]any, params map[string]string) {
+	for key, value := range element {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok {
+			flattenGitOpsParams(key, nested, params)
+			continue
+		}
+		params[key] = fmt.Sprint(value)
+	}
+}
+
+// applyGitOpsParams substitutes the {{param}} and {{.param}} placeholders of an
+// ApplicationSet template.
+func applyGitOpsParams(template map[string]any, params map[string]string) (map[string]any, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return nil, err
+	}
+	text := string(data)
+	for key, value := range params {
+		escaped, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		for _, placeholder := range []string{"{{" + key + "}}", "{{ " + key + " }}", "{{." + key + "}}", "{{ ." + key + " }}"} {
+			text = strings.ReplaceAll(text, placeholder, string(escaped[1:len(escaped)-1]))
+		}
+	}
+	if placeholder := gitOpsPlaceholder.FindString(text); placeholder != "" {
+		return nil, fmt.Errorf("template parameter %s has no value", placeholder)
+	}
+	var app map[string]any
+	if err := json.Unmarshal([]byte(text), &app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// renderHelmRelease renders the chart of a Flux HelmRelease with its values:
+// those of valuesFrom in order, then spec.values.
+func (r *GitOpsRenderer) renderHelmRelease(hr workloadinterface.IMetadata, ref GitOpsObjectRef) ([]GitOpsRendering, error) {
+	obj := hr.GetObject()
+	chartPath, err := r.helmReleaseChart(obj, hr.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	values, err := r.helmReleaseValues(obj, hr.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	targetNamespace := gitOpsString(obj, "spec", "targetNamespace")
+	releaseName := hr.GetName()
+	if targetNamespace != "" {
+		releaseName = targetNamespace + "-" + releaseName
+	}
+	release := helmchartutil.ReleaseOptions{
+		Name:      cmp.Or(gitOpsString(obj, "spec", "releaseName"), releaseName),
+		Namespace: cmp.Or(targetNamespace, hr.GetNamespace()),
+	}
+	rendering, err := r.renderChart(chartPath, values, release, GitOpsRendering{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	return []GitOpsRendering{rendering}, nil
+}
+
+// helmReleaseChart finds the chart of a HelmRelease, from spec.chart or from
+// spec.chartRef.
+func (r *GitOpsRenderer) helmReleaseChart(obj map[string]any, namespace string) (string, error) {
+	if chartRef := gitOpsMap(obj, "spec", "chartRef"); chartRef != nil {
+		kind, name := gitOpsString(chartRef, "kind"), gitOpsString(chartRef, "name")
+		refNamespace := cmp.Or(gitOpsString(chartRef, "namespace"), namespace)
+		switch kind {
+		case "OCIRepository":
+			dir, err := r.fluxSource(kind, refNamespace, name)
+			if err != nil {
+				return "", err
+			}
+			return findChart(dir, "", "")
+		case "HelmChart":
+			helmChart := r.manifest(kind, refNamespace, name)
+			if helmChart == nil {
+				return "", fmt.Errorf("HelmChart %s/%s not found among the scanned manifests", refNamespace, name)
+			}
+			return r.fluxChart(gitOpsMap(helmChart.GetObject(), "spec"), refNamespace)
+		default:
+			return "", fmt.Errorf("unsupported spec.chartRef kind %q", kind)
+		}
+	}
+	spec := gitOpsMap(obj, "spec", "chart", "spec")
+	if spec == nil {
+		return "", errors.New("spec.chart and spec.chartRef are missing")
+	}
+	return r.fluxChart(spec, namespace)
+}
+
+// fluxChart finds a chart described by a HelmChart spec: a path of a Git
+// repository or bucket, or a chart of a Helm or OCI repository.
+func (r *GitOpsRenderer) fluxChart(spec map[string]any, namespace string) (string, error) {
+	kind := gitOpsString(spec, "sourceRef", "kind")
+	dir, err := r.fluxSource(kind, cmp.Or(gitOpsString(spec, "sourceRef", "namespace"), namespace), gitOpsString(spec, "sourceRef", "name"))
+	if err != nil {
+		return "", err
+	}
+	chart := gitOpsString(spec, "chart")
+	if kind == "GitRepository" || kind == "Bucket" {
+		return filepath.Join(dir, chart), nil
+	}
+	return findChart(dir, chart, gitOpsString(spec, "version"))
+}
+
+// helmReleaseValues merges the values of a HelmRelease the way Flux does.
+func (r *GitOpsRenderer) helmReleaseValues(obj map[string]any, namespace string) (map[string]any, error) {
+	values := map[string]any{}
+	for _, reference := range gitOpsSlice(obj, "spec", "valuesFrom") {
+		reference, ok := reference.(map[string]any)
+		if !ok {
+			continue
+		}
+		kind, name := gitOpsString(reference, "kind"), gitOpsString(reference, "name")
+		key := cmp.Or(gitOpsString(reference, "valuesKey"), "values.yaml")
+		data, found := r.configData(kind, namespace, name, key)
+		if !found {
+			if gitOpsBool(reference, "optional") {
+				continue
+			}
+			return nil, fmt.Errorf("valuesFrom %s %s/%s key %s not found among the scanned manifests", kind, namespace, name, key)
+		}
+		if targetPath := gitOpsString(reference, "targetPath"); targetPath != "" {
+			if err := strvals.ParseInto(targetPath+"="+data, values); err != nil {
+				return nil, fmt.Errorf("invalid valuesFrom targetPath %s: %w", targetPath, err)
+			}
+			continue
+		}
+		referencedValues, err := helmchartutil.ReadValues([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid values in %s %s/%s key %s: %w", kind, namespace, name, key, err)
+		}
+		values = mergeMaps(values, referencedValues)
+	}
+	if inline := gitOpsMap(obj, "spec", "values"); inline != nil {
+		values = mergeMaps(values, inline)
+	}
+	return values, nil
+}
+
+// configData reads key from a scanned ConfigMap or Secret.
+func (r *GitOpsRenderer) configData(kind, namespace, name, key string) (string, bool) {
+	wl := r.manifest(kind, namespace, name)
+	if wl == nil {
+		return "", false
+	}
+	obj := wl.GetObject()
+	if kind == "Secret" {
+		if value, ok := gitOpsField(obj, "stringData", key).(string); ok {
+			return value, true
+		}
+		if value, ok := gitOpsField(obj, "data", key).(string); ok {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			return string(decoded), err == nil
+		}
+		return "", false
+	}
+	value, ok := gitOpsField(obj, "data", key).(string)
+	return value, ok
+}
+
+// renderKustomization renders the path of a Flux Kustomization: its
+// kustomization file, or all its manifests when it has none, as Flux does.
+func (r *GitOpsRenderer) renderKustomization(ctx context.Context, ks workloadinterface.IMetadata, ref GitOpsObjectRef) ([]GitOpsRendering, error) {
+	obj := ks.GetObject()
+	dir, err := r.fluxSource(gitOpsString(obj, "spec", "sourceRef", "kind"),
+		cmp.Or(gitOpsString(obj, "spec", "sourceRef", "namespace"), ks.GetNamespace()),
+		gitOpsString(obj, "spec", "sourceRef", "name"))
+	if err != nil {
+		return nil, err
+	}
+	rendering, err := r.renderDirectory(ctx, filepath.Join(dir, gitOpsString(obj, "spec", "path")), GitOpsRendering{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	return []GitOpsRendering{rendering}, nil
+}
+
+// renderChart renders the chart at chartPath with values over its defaults.
+func (r *GitOpsRenderer) renderChart(chartPath string, values map[string]any, release helmchartutil.ReleaseOptions, rendering GitOpsRendering) (GitOpsRendering, error) {
+	chart, err := NewHelmChart(chartPath)
+	if err != nil {
+		return rendering, fmt.Errorf("failed to load Helm chart %s: %w", chartPath, err)
+	}
+	sourceToWorkloads, errs := chart.GetWorkloadsWithOptions(mergeMaps(chart.GetDefaultValues(), values), release)
+	if len(errs) > 0 {
+		return rendering, fmt.Errorf("failed to render Helm chart %s: %w", chartPath, errors.Join(errs...))
+	}
+	rendering.Ref.Renderer = GitOpsRendererHelm
+	rendering.Ref.Source = r.relativeSource(chartPath)
+	rendering.Workloads = flattenSourceToWorkloads(sourceToWorkloads)
+	return rendering, nil
+}
+
+// renderDirectory renders a Kustomize directory, or loads the plain manifests
+// of any other directory.
+func (r *GitOpsRenderer) renderDirectory(ctx context.Context, dir string, rendering GitOpsRendering) (GitOpsRendering, error) {
+	rendering.Ref.Source = r.relativeSource(dir)
+	if isKustomizeDirectory(dir) {
+		sourceToWorkloads, errs := NewKustomizeDirectory(dir).GetWorkloads(dir)
+		if len(errs) > 0 {
+			return rendering, fmt.Errorf("failed to render Kustomize directory %s: %w", dir, errors.Join(errs...))
+		}
+		rendering.Ref.Renderer = GitOpsRendererKustomize
+		rendering.Workloads = flattenSourceToWorkloads(sourceToWorkloads)
+		return rendering, nil
+	}
+
+	sourceToWorkloads, _, err := LoadResourcesFromFiles(ctx, dir, dir, nil)
+	if err != nil {
+		return rendering, fmt.Errorf("failed to load manifests from %s: %w", dir, err)
+	}
+	rendering.Ref.Renderer = GitOpsRendererManifests
+	rendering.Workloads = flattenSourceToWorkloads(sourceToWorkloads)
+	return rendering, nil
+}
+
+// resolve finds the local stand-in of a Git, Helm or OCI repository URL.
+func (r *GitOpsRenderer) resolve(url string) (string, error) {
+	key := normalizeGitOpsSource(url)
+	if path, ok := r.sources[key]; ok {
+		return path, nil
+	}
+	if key != "" && key == normalizeGitOpsSource(r.repoURL) {
+		return r.repoRoot, nil
+	}
+	return "", fmt.Errorf("no local stand-in for %q, map one with --gitops-source %s=<path>", url, url)
+}
+
+// fluxSource finds the local stand-in of a Flux source: mapped by name, or
+// through the URL of the source object among the scanned manifests.
+func (r *GitOpsRenderer) fluxSource(kind, namespace, name string) (string, error) {
+	key := kind + "/" + namespace + "/" + name
+	if path, ok := r.sources[key]; ok {
+		return path, nil
+	}
+	source := r.manifest(kind, namespace, name)
+	if source == nil {
+		return "", fmt.Errorf("%s %s/%s not found among the scanned manifests, map it with --gitops-source %s=<path>", kind, namespace, name, key)
+	}
+	return r.resolve(gitOpsString(source.GetObject(), "spec", "url"))
+}
+
+func (r *GitOpsRenderer) manifest(kind, namespace, name string) workloadinterface.IMetadata {
+	for _, wl := range r.manifests {
+		if wl.GetKind() == kind && wl.GetNamespace() == namespace && wl.GetName() == name {
+			return wl
+		}
+	}
+	return nil
+}
+
+func (r *GitOpsRenderer) relativeSource(path string) string {
+	if rel, err := filepath.Rel(r.repoRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// findChart finds a chart in the stand-in of a Helm or OCI repository: the
+// stand-in itself when it is a chart or a chart archive, its <chart>
+// subdirectory, or its <chart>-<version>.tgz archive. Without a version, the
+// last <chart>-*.tgz archive in lexical order is used.
+func findChart(dir, chart, version string) (string, error) {
+	if isFile(dir) && strings.HasSuffix(dir, ".tgz") {
+		return dir, nil
+	}
+	if ok, _ := IsHelmDirectory(dir); ok {
+		return dir, nil
+	}
+	if chart != "" {
+		if ok, _ := IsHelmDirectory(filepath.Join(dir, chart)); ok {
+			return filepath.Join(dir, chart), nil
+		}
+		if archive := filepath.Join(dir, chart+"-"+version+".tgz"); version != "" && isFile(archive) {
+			return archive, nil
+		}
+	}
+	archives, _ := filepath.Glob(filepath.Join(dir, cmp.Or(chart, "*")+"-*.tgz"))
+	if len(archives) == 0 {
+		return "", fmt.Errorf("chart %q not found in %s", chart, dir)
+	}
+	slices.Sort(archives)
+	return archives[len(archives)-1], nil
+}
+
+func flattenSourceToWorkloads(sourceToWorkloads map[string][]workloadinterface.IMetadata) []workloadinterface.IMetadata {
+	var workloads []workloadinterface.IMetadata
+	for _, source := range slices.Sorted(maps.Keys(sourceToWorkloads)) {
+		workloads = append(workloads, sourceToWorkloads[source]...)
+	}
+	return workloads
+}
+
+func gitOpsField(obj map[string]any, fields ...string) any {
+	value, _, _ := unstructured.NestedFieldNoCopy(obj, fields...)
+	return value
+}
+
+func gitOpsString(obj map[string]any, fields ...string) string {
+	value, _ := gitOpsField(obj, fields...).(string)
+	return value
+}
+
+func gitOpsBool(obj map[string]any, fields ...string) bool {
+	value, _ := gitOpsField(obj, fields...).(bool)
+	return value
+}
+
+func gitOpsMap(obj map[string]any, fields ...string) map[string]any {
+	value, _ := gitOpsField(obj, fields...).(map[string]any)
+	return value
+}
+
+func gitOpsSlice(obj map[string]any, fields ...string) []any {
+	value, _ := gitOpsField(obj, fields...).([]any)
+	return value
+}
+
+func gitOpsStrings(obj map[string]any, fields ...string) []string {
+	var values []string
+	for _, value := range gitOpsSlice(obj, fields...) {
+		if value, ok := value.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,227 @@
+package cautils
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gitOpsRepoURL = "https://github.com/example/deploy.git"
+
+func gitOpsTestdata(t *testing.T) string {
+	t.Helper()
+	root, err := filepath.Abs(filepath.Join("testdata", "gitops"))
+	require.NoError(t, err)
+	return root
+}
+
+func gitOpsObject(apiVersion, kind, namespace, name string, spec map[string]any) workloadinterface.IMetadata {
+	return workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]any{"name": name, "namespace": namespace},
+		"spec":       spec,
+	})
+}
+
+// renderedImages maps the name of every rendered Deployment to its image.
+func renderedImages(t *testing.T, workloads []workloadinterface.IMetadata) map[string]string {
+	t.Helper()
+	images := map[string]string{}
+	for _, wl := range workloads {
+		if wl.GetKind() != "Deployment" {
+			continue
+		}
+		containers := gitOpsSlice(wl.GetObject(), "spec", "template", "spec", "containers")
+		require.Len(t, containers, 1)
+		images[wl.GetNamespace()+"/"+wl.GetName()] = gitOpsString(containers[0].(map[string]any), "image")
+	}
+	return images
+}
+
+func TestParseGitOpsSources(t *testing.T) {
+	root := gitOpsTestdata(t)
+
+	sources, err := ParseGitOpsSources([]string{
+		"git@github.com:example/charts.git=" + filepath.Join(root, "charts"),
+		"https://github.com/example/values/=" + filepath.Join(root, "values"),
+		"GitRepository/flux-system/deploy=" + root,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, GitOpsSources{
+		"https://github.com/example/charts": filepath.Join(root, "charts"),
+		"https://github.com/example/values": filepath.Join(root, "values"),
+		"GitRepository/flux-system/deploy":  root,
+	}, sources)
+
+	_, err = ParseGitOpsSources([]string{"https://github.com/example/charts"})
+	assert.ErrorContains(t, err, "expected <source>=<path>")
+
+	_, err = ParseGitOpsSources([]string{"https://github.com/example/charts=" + filepath.Join(root, "missing")})
+	assert.Error(t, err)
+}
+
+func TestIsGitOpsObject(t *testing.T) {
+	assert.True(t, IsGitOpsObject(gitOpsObject("argoproj.io/v1alpha1", "Application", "argocd", "web", nil)))
+	assert.True(t, IsGitOpsObject(gitOpsObject("argoproj.io/v1alpha1", "ApplicationSet", "argocd", "web", nil)))
+	assert.True(t, IsGitOpsObject(gitOpsObject("helm.toolkit.fluxcd.io/v2", "HelmRelease", "prod", "web", nil)))
+	assert.True(t, IsGitOpsObject(gitOpsObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "flux-system", "web", nil)))
+	assert.False(t, IsGitOpsObject(gitOpsObject("kustomize.config.k8s.io/v1beta1", "Kustomization", "", "web", nil)))
+	assert.False(t, IsGitOpsObject(gitOpsObject("apps/v1", "Deployment", "prod", "web", nil)))
+}
+
+func TestGitOpsRendererApplication(t *testing.T) {
+	root := gitOpsTestdata(t)
+	renderer := NewGitOpsRenderer(GitOpsSources{"https://github.com/example/values": filepath.Join(root, "values")}, root, gitOpsRepoURL, nil)
+
+	app := gitOpsObject("argoproj.io/v1alpha1", "Application", "argocd", "web", map[string]any{
+		"destination": map[string]any{"namespace": "prod"},
+		"sources": []any{
+			map[string]any{
+				"repoURL": "git@github.com:example/deploy.git",
+				"path":    "charts/web",
+				"helm": map[string]any{
+					"valueFiles":   []any{"$values/prod.yaml"},
+					"valuesObject": map[string]any{"image": map[string]any{"repository": "registry.example.com/web"}},
+					"parameters":   []any{map[string]any{"name": "image.tag", "value": "v2"}},
+				},
+			},
+			map[string]any{"repoURL": "https://github.com/example/values", "ref": "values"},
+		},
+	})
+
+	renderings, err := renderer.Render(context.Background(), app)
+	require.NoError(t, err)
+	require.Len(t, renderings, 1, "a source with only a ref renders nothing")
+
+	ref := renderings[0].Ref
+	assert.Equal(t, "Application", ref.Kind)
+	assert.Equal(t, "web", ref.Name)
+	assert.Equal(t, GitOpsRendererHelm, ref.Renderer)
+	assert.Equal(t, filepath.Join("charts", "web"), ref.Source)
+	assert.Equal(t, map[string]string{"prod/web": "registry.example.com/web:v2"}, renderedImages(t, renderings[0].Workloads))
+}
+
+func TestGitOpsRendererApplicationDirectory(t *testing.T) {
+	root := gitOpsTestdata(t)
+	renderer := NewGitOpsRenderer(nil, root, gitOpsRepoURL, nil)
+
+	app := gitOpsObject("argoproj.io/v1alpha1", "Application", "argocd", "web", map[string]any{
+		"source": map[string]any{"repoURL": gitOpsRepoURL, "path": "manifests"},
+	})
+
+	renderings, err := renderer.Render(context.Background(), app)
+	require.NoError(t, err)
+	require.Len(t, renderings, 1)
+	assert.Equal(t, GitOpsRendererManifests, renderings[0].Ref.Renderer)
+	require.Len(t, renderings[0].Workloads, 1)
+	assert.Equal(t, "Service", renderings[0].Workloads[0].GetKind())
+}
+
+func TestGitOpsRendererApplicationSet(t *testing.T) {
+	root := gitOpsTestdata(t)
+	renderer := NewGitOpsRenderer(nil, root, gitOpsRepoURL, nil)
+
+	appSet := gitOpsObject("argoproj.io/v1alpha1", "ApplicationSet", "argocd", "web", map[string]any{
+		"generators": []any{map[string]any{"list": map[string]any{"elements": []any{
+			map[string]any{"env": "staging", "tag": "1.26"},
+			map[string]any{"env": "prod", "tag": "1.27"},
+		}}}},
+		"template": map[string]any{
+			"metadata": map[string]any{"name": "web-{{env}}"},
+			"spec": map[string]any{
+				"destination": map[string]any{"namespace": "{{ .env }}"},
+				"source": map[string]any{
+					"repoURL": gitOpsRepoURL,
+					"path":    "charts/web",
+					"helm":    map[string]any{"parameters": []any{map[string]any{"name": "image.tag", "value": "{{tag}}"}}},
+				},
+			},
+		},
+	})
+
+	renderings, err := renderer.Render(context.Background(), appSet)
+	require.NoError(t, err)
+	require.Len(t, renderings, 2)
+	assert.Equal(t, "web-staging", renderings[0].Ref.Application)
+	assert.Equal(t, "web-prod", renderings[1].Ref.Application)
+	assert.Equal(t, map[string]string{"staging/web-staging": "nginx:1.26"}, renderedImages(t, renderings[0].Workloads))
+	assert.Equal(t, map[string]string{"prod/web-prod": "nginx:1.27"}, renderedImages(t, renderings[1].Workloads))
+}
+
+func TestGitOpsRendererApplicationSetRejectsUnsupportedGenerators(t *testing.T) {
+	renderer := NewGitOpsRenderer(nil, gitOpsTestdata(t), gitOpsRepoURL, nil)
+
+	appSet := gitOpsObject("argoproj.io/v1alpha1", "ApplicationSet", "argocd", "web", map[string]any{
+		"generators": []any{map[string]any{"clusters": map[string]any{}}},
+		"template":   map[string]any{"metadata": map[string]any{"name": "web-{{name}}"}},
+	})
+
+	_, err := renderer.Render(context.Background(), appSet)
+	assert.ErrorContains(t, err, "clusters generators are not supported offline")
+}
+
+func TestGitOpsRendererHelmRelease(t *testing.T) {
+	root := gitOpsTestdata(t)
+	manifests := []workloadinterface.IMetadata{
+		gitOpsObject("source.toolkit.fluxcd.io/v1", "GitRepository", "prod", "deploy", map[string]any{"url": gitOpsRepoURL}),
+		workloadinterface.NewWorkloadObj(map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "web-values", "namespace": "prod"},
+			"data":       map[string]any{"values.yaml": "replicaCount: 2\nimage:\n  tag: \"1.26\"\n"},
+		}),
+	}
+	renderer := NewGitOpsRenderer(nil, root, gitOpsRepoURL, manifests)
+
+	release := gitOpsObject("helm.toolkit.fluxcd.io/v2", "HelmRelease", "prod", "web", map[string]any{
+		"chart": map[string]any{"spec": map[string]any{
+			"chart":     "charts/web",
+			"sourceRef": map[string]any{"kind": "GitRepository", "name": "deploy"},
+		}},
+		"valuesFrom": []any{
+			map[string]any{"kind": "ConfigMap", "name": "web-values"},
+			map[string]any{"kind": "Secret", "name": "missing", "optional": true},
+		},
+		"values": map[string]any{"image": map[string]any{"repository": "registry.example.com/web"}},
+	})
+
+	renderings, err := renderer.Render(context.Background(), release)
+	require.NoError(t, err)
+	require.Len(t, renderings, 1)
+	assert.Equal(t, GitOpsRendererHelm, renderings[0].Ref.Renderer)
+	assert.Equal(t, map[string]string{"prod/web": "registry.example.com/web:1.26"}, renderedImages(t, renderings[0].Workloads))
+}
+
+func TestGitOpsRendererKustomization(t *testing.T) {
+	root := gitOpsTestdata(t)
+	renderer := NewGitOpsRenderer(GitOpsSources{"GitRepository/flux-system/deploy": root}, root, "", nil)
+
+	kustomization := gitOpsObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "flux-system", "web", map[string]any{
+		"path":      "./manifests",
+		"sourceRef": map[string]any{"kind": "GitRepository", "name": "deploy"},
+	})
+
+	renderings, err := renderer.Render(context.Background(), kustomization)
+	require.NoError(t, err)
+	require.Len(t, renderings, 1)
+	assert.Equal(t, GitOpsRendererManifests, renderings[0].Ref.Renderer)
+	assert.Equal(t, "manifests", renderings[0].Ref.Source)
+	require.Len(t, renderings[0].Workloads, 1)
+	assert.Equal(t, "Service", renderings[0].Workloads[0].GetKind())
+}
+
+func TestGitOpsRendererRequiresLocalSources(t *testing.T) {
+	renderer := NewGitOpsRenderer(nil, gitOpsTestdata(t), gitOpsRepoURL, nil)
+
+	app := gitOpsObject("argoproj.io/v1alpha1", "Application", "argocd", "web", map[string]any{
+		"source": map[string]any{"repoURL": "https://charts.example.com", "chart": "web", "targetRevision": "1.0.0"},
+	})
+
+	_, err := renderer.Render(context.Background(), app)
+	assert.ErrorContains(t, err, "map one with --gitops-source https://charts.example.com=<path>")
+}
@@ -0,0 +1,303 @@
+// Package helmrelease reads the release records Helm v3 stores in the cluster
+// (Secrets of type helm.sh/release.v1, named sh.helm.release.v1.<name>.v<revision>)
+// and attributes the objects a release deployed back to its chart, the
+// template that rendered them and the values the release was installed with.
+//
+// The rendered manifest Helm records for a release is what was applied, so an
+// object is matched to a release by its identity (group, kind, namespace and
+// name), never by its content: a live object that drifted from its release is
+// still attributed to it.
+package helmrelease
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/core/cautils/helmprovenance"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const (
+	// SecretType is the type of the Secrets Helm v3 stores releases in.
+	SecretType = "helm.sh/release.v1"
+	// SecretLabelSelector selects the Secrets Helm v3 stores releases in.
+	SecretLabelSelector = "owner=helm"
+	// SecretReleaseKey is the Secret data key holding the encoded release.
+	SecretReleaseKey = "release"
+	// PathPrefix prefixes the path of deployed releases in a resource Source,
+	// so they are not mistaken for charts on disk: helm://<namespace>/<name>.
+	PathPrefix = "helm://"
+)
+
+var magicGzip = []byte{0x1f, 0x8b, 0x08}
+
+// Decode decodes a release the way Helm's Secret storage driver encodes it:
+// base64 over (usually gzipped) JSON.
+func Decode(data []byte) (*release.Release, error) {
+	b := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(b, bytes.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid release encoding: %w", err)
+	}
+	b = b[:n]
+
+	// Releases stored before Helm compressed them are plain JSON.
+	if bytes.HasPrefix(b, magicGzip) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("invalid release compression: %w", err)
+		}
+		defer r.Close()
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("invalid release compression: %w", err)
+		}
+	}
+
+	var rel release.Release
+	if err := json.Unmarshal(b, &rel); err != nil {
+		return nil, fmt.Errorf("invalid release: %w", err)
+	}
+	return &rel, nil
+}
+
+// Current keeps the revision of each release that is in the cluster: the
+// latest deployed revision, or the latest revision when none is deployed
+// (a failed first install, or a pending upgrade of one). Releases are
+// returned ordered by namespace and name.
+func Current(releases []*release.Release) []*release.Release {
+	current := map[string]*release.Release{}
+	for _, rel := range releases {
+		if rel == nil {
+			continue
+		}
+		key := rel.Namespace + "/" + rel.Name
+		if kept, ok := current[key]; !ok || newer(rel, kept) {
+			current[key] = rel
+		}
+	}
+	out := make([]*release.Release, 0, len(current))
+	for _, key := range slices.Sorted(maps.Keys(current)) {
+		out = append(out, current[key])
+	}
+	return out
+}
+
+// newer reports whether rel should replace kept as the current revision.
+func newer(rel, kept *release.Release) bool {
+	if deployed(rel) != deployed(kept) {
+		return deployed(rel)
+	}
+	return rel.Version > kept.Version
+}
+
+func deployed(rel *release.Release) bool {
+	return rel.Info != nil && rel.Info.Status == release.StatusDeployed
+}
+
+// Path is the Source path of a deployed release: helm://<namespace>/<name>.
+func Path(rel *release.Release) string {
+	return PathPrefix + rel.Namespace + "/" + rel.Name
+}
+
+// Summary describes a deployed release in scan reports.
+type Summary struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	Revision     int    `json:"revision"`
+	Status       string `json:"status,omitempty"`
+	Chart        string `json:"chart,omitempty"`
+	ChartVersion string `json:"chartVersion,omitempty"`
+	AppVersion   string `json:"appVersion,omitempty"`
+	// ValuesKeys are the dotted keys of the values the release was installed
+	// or upgraded with, on top of the chart's defaults. Only the keys are
+	// reported: release values routinely hold credentials.
+	ValuesKeys []string `json:"valuesKeys,omitempty"`
+	// Resources are the IDs of the scanned resources attributed to the
+	// release.
+	Resources []string `json:"resources,omitempty"`
+}
+
+// Path is the Source path of the release: helm://<namespace>/<name>.
+func (summary Summary) Path() string {
+	return PathPrefix + summary.Namespace + "/" + summary.Name
+}
+
+// Summarize describes rel for scan reports.
+func Summarize(rel *release.Release) Summary {
+	summary := Summary{
+		Name:       rel.Name,
+		Namespace:  rel.Namespace,
+		Revision:   rel.Version,
+		ValuesKeys: ValuesKeys(rel.Config),
+	}
+	if rel.Info != nil {
+		summary.Status = rel.Info.Status.String()
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		summary.Chart = rel.Chart.Metadata.Name
+		summary.ChartVersion = rel.Chart.Metadata.Version
+		summary.AppVersion = rel.Chart.Metadata.AppVersion
+	}
+	return summary
+}
+
+// ValuesKeys returns the dotted keys of the leaf values in values, sorted.
+func ValuesKeys(values map[string]any) []string {
+	var keys []string
+	var walk func(prefix string, value any)
+	walk = func(prefix string, value any) {
+		nested, ok := value.(map[string]any)
+		if !ok || len(nested) == 0 {
+			keys = append(keys, prefix)
+			return
+		}
+		for key, value := range nested {
+			walk(prefix+"."+key, value)
+		}
+	}
+	for key, value := range values {
+		walk(key, value)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Object is an object a release deployed, with the template that rendered it.
+type Object struct {
+	APIVersion string
+	Kind       string
+	// Namespace is the namespace the manifest sets, empty when the object
+	// went to the release namespace or is cluster-scoped.
+	Namespace string
+	Name      string
+	// TemplateFile is the chart-relative template that rendered the object,
+	// e.g. "templates/deployment.yaml".
+	TemplateFile string
+	// ValuesPaths are the dotted .Values keys the template reads; see
+	// helmprovenance.Provenance.
+	ValuesPaths  []string
+	TemplateLine int
+}
+
+// Helm separates the documents of a release manifest the way SplitManifests
+// in helm.sh/helm/v3/pkg/releaseutil expects them.
+var (
+	manifestSeparator = regexp.MustCompile(`(?:^|\s*\n)---\s*`)
+	sourceComment     = regexp.MustCompile(`(?m)^# Source: (.+)$`)
+)
+
+// Objects lists the objects rel deployed, hooks included, attributed to the
+// templates of its chart.
+func Objects(rel *release.Release) []Object {
+	provenance := helmprovenance.Extract(rel.Chart)
+	objects := manifestObjects(rel.Manifest, "", provenance)
+	for _, hook := range rel.Hooks {
+		if hook != nil {
+			objects = append(objects, manifestObjects(hook.Manifest, hook.Path, provenance)...)
+		}
+	}
+	return objects
+}
+
+// manifestObjects parses the documents of a rendered manifest. Each document
+// names its template in a "# Source: <chart>/templates/..." comment; source
+// is used for documents without one.
+func manifestObjects(manifest, source string, provenance map[string]helmprovenance.Provenance) []Object {
+	var objects []Object
+	for _, document := range manifestSeparator.Split(manifest, -1) {
+		if strings.TrimSpace(document) == "" {
+			continue
+		}
+		var head struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Metadata   struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(document), &head); err != nil || head.Kind == "" || head.Metadata.Name == "" {
+			continue
+		}
+
+		templatePath := source
+		if match := sourceComment.FindStringSubmatch(document); match != nil {
+			templatePath = strings.TrimSpace(match[1])
+		}
+		object := Object{
+			APIVersion: head.APIVersion,
+			Kind:       head.Kind,
+			Namespace:  head.Metadata.Namespace,
+			Name:       head.Metadata.Name,
+		}
+		if prov, ok := provenance[templatePath]; ok {
+			object.TemplateFile = prov.TemplateFile
+			object.ValuesPaths = prov.ValuesPaths
+			object.TemplateLine = prov.TemplateLine
+		} else if _, file, ok := strings.Cut(templatePath, "/"); ok {
+			object.TemplateFile = file
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// Attribution links a live object to the release that deployed it.
+type Attribution struct {
+	Release *release.Release
+	Object  Object
+}
+
+// Index attributes live objects to the releases that deployed them.
+type Index map[string]Attribution
+
+// NewIndex indexes the objects of releases. An object claimed by several
+// releases is attributed to the first, in the order given.
+func NewIndex(releases []*release.Release) Index {
+	index := Index{}
+	add := func(key string, attribution Attribution) {
+		if _, ok := index[key]; !ok {
+			index[key] = attribution
+		}
+	}
+	for _, rel := range releases {
+		for _, object := range Objects(rel) {
+			attribution := Attribution{Release: rel, Object: object}
+			if object.Namespace != "" {
+				add(key(object.APIVersion, object.Kind, object.Namespace, object.Name), attribution)
+				continue
+			}
+			// Helm installs namespaced objects without a namespace in the
+			// release namespace; cluster-scoped objects have none.
+			add(key(object.APIVersion, object.Kind, rel.Namespace, object.Name), attribution)
+			add(key(object.APIVersion, object.Kind, "", object.Name), attribution)
+		}
+	}
+	return index
+}
+
+// Lookup finds the release that deployed the live object with the given
+// identity. The API version is compared by group only, since the cluster may
+// serve the object at another version than the chart rendered.
+func (index Index) Lookup(apiVersion, kind, namespace, name string) (Attribution, bool) {
+	attribution, ok := index[key(apiVersion, kind, namespace, name)]
+	return attribution, ok
+}
+
+func key(apiVersion, kind, namespace, name string) string {
+	group, _, ok := strings.Cut(apiVersion, "/")
+	if !ok {
+		group = ""
+	}
+	return strings.Join([]string{group, kind, namespace, name}, "/")
+}
@@ -0,0 +1,161 @@
+package helmrelease
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const deploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  replicas: {{ .Values.replicaCount }}
+  template:
+    spec:
+      containers:
+        - image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+`
+
+const manifest = `---
+# Source: web/templates/serviceaccount.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+  namespace: shared
+---
+# Source: web/templates/clusterrole.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: web-reader
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+`
+
+func newRelease(name string, version int, status release.Status) *release.Release {
+	return &release.Release{
+		Name:      name,
+		Namespace: "prod",
+		Version:   version,
+		Info:      &release.Info{Status: status},
+		Chart: &chart.Chart{
+			Metadata:  &chart.Metadata{Name: "web", Version: "1.2.3", AppVersion: "2.0.0"},
+			Templates: []*chart.File{{Name: "templates/deployment.yaml", Data: []byte(deploymentTemplate)}},
+		},
+		Config:   map[string]any{"image": map[string]any{"tag": "2.0.0", "pullSecret": "s3cr3t"}, "replicaCount": 2},
+		Manifest: manifest,
+		Hooks: []*release.Hook{{
+			Path:     "web/templates/tests/test-connection.yaml",
+			Manifest: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web-test-connection\n",
+		}},
+	}
+}
+
+// encode stores rel the way Helm's Secret storage driver does.
+func encode(t *testing.T, rel *release.Release, compress bool) []byte {
+	t.Helper()
+	data, err := json.Marshal(rel)
+	require.NoError(t, err)
+	if compress {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		data = buf.Bytes()
+	}
+	return []byte(base64.StdEncoding.EncodeToString(data))
+}
+
+func TestDecode(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		rel, err := Decode(encode(t, newRelease("web", 3, release.StatusDeployed), compress))
+		require.NoError(t, err)
+		assert.Equal(t, "web", rel.Name)
+		assert.Equal(t, 3, rel.Version)
+		assert.Equal(t, "1.2.3", rel.Chart.Metadata.Version)
+		require.Len(t, rel.Chart.Templates, 1)
+		assert.Equal(t, deploymentTemplate, string(rel.Chart.Templates[0].Data))
+	}
+
+	_, err := Decode([]byte("not base64!"))
+	assert.Error(t, err)
+}
+
+func TestCurrent(t *testing.T) {
+	releases := Current([]*release.Release{
+		newRelease("web", 1, release.StatusSuperseded),
+		newRelease("web", 2, release.StatusDeployed),
+		newRelease("web", 3, release.StatusFailed),
+		newRelease("api", 1, release.StatusFailed),
+		newRelease("api", 2, release.StatusPendingInstall),
+	})
+	require.Len(t, releases, 2)
+	assert.Equal(t, "api", releases[0].Name)
+	assert.Equal(t, 2, releases[0].Version, "the latest revision stands in when none is deployed")
+	assert.Equal(t, "web", releases[1].Name)
+	assert.Equal(t, 2, releases[1].Version, "the deployed revision wins over a later failed upgrade")
+}
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize(newRelease("web", 2, release.StatusDeployed))
+	assert.Equal(t, Summary{
+		Name:         "web",
+		Namespace:    "prod",
+		Revision:     2,
+		Status:       "deployed",
+		Chart:        "web",
+		ChartVersion: "1.2.3",
+		AppVersion:   "2.0.0",
+		ValuesKeys:   []string{"image.pullSecret", "image.tag", "replicaCount"},
+	}, summary, "values are reported by key only")
+	assert.Equal(t, "helm://prod/web", Path(newRelease("web", 2, release.StatusDeployed)))
+	assert.Equal(t, "helm://prod/web", summary.Path())
+}
+
+func TestIndex(t *testing.T) {
+	index := NewIndex([]*release.Release{newRelease("web", 2, release.StatusDeployed)})
+
+	attribution, ok := index.Lookup("apps/v1", "Deployment", "prod", "web")
+	require.True(t, ok, "objects without a namespace are deployed to the release namespace")
+	assert.Equal(t, "web", attribution.Release.Name)
+	assert.Equal(t, "templates/deployment.yaml", attribution.Object.TemplateFile)
+	assert.Equal(t, []string{"image.repository", "image.tag", "replicaCount"}, attribution.Object.ValuesPaths)
+	assert.Equal(t, 1, attribution.Object.TemplateLine)
+
+	_, ok = index.Lookup("apps/v1beta2", "Deployment", "prod", "web")
+	assert.True(t, ok, "objects are matched by API group, not version")
+
+	attribution, ok = index.Lookup("v1", "ServiceAccount", "shared", "web")
+	require.True(t, ok)
+	assert.Equal(t, "templates/serviceaccount.yaml", attribution.Object.TemplateFile, "templates are named by the Source comment")
+	assert.Empty(t, attribution.Object.ValuesPaths)
+	_, ok = index.Lookup("v1", "ServiceAccount", "prod", "web")
+	assert.False(t, ok, "an explicit namespace is kept")
+
+	_, ok = index.Lookup("rbac.authorization.k8s.io/v1", "ClusterRole", "", "web-reader")
+	assert.True(t, ok, "cluster-scoped objects have no namespace")
+
+	attribution, ok = index.Lookup("v1", "Pod", "prod", "web-test-connection")
+	require.True(t, ok, "hooks are attributed to their template")
+	assert.Equal(t, "templates/tests/test-connection.yaml", attribution.Object.TemplateFile)
+
+	_, ok = index.Lookup("apps/v1", "Deployment", "prod", "api")
+	assert.False(t, ok)
+}
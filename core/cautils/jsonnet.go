@@ -0,0 +1,312 @@
+package cautils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/opa-utils/objectsenvelopes/localworkload"
+)
+
+// Source file types of resources evaluated from Jsonnet and CUE.
+const (
+	SourceTypeJsonnet = "Jsonnet"
+	SourceTypeCUE     = "CUE"
+)
+
+// JsonnetOptions are the options Jsonnet files are evaluated with, as given
+// to the jsonnet CLI.
+type JsonnetOptions struct {
+	JPaths  []string // --jsonnet-jpath: library search directories, after the project's vendor/ and lib/
+	ExtStrs []string // --jsonnet-ext-str: <key>=<value> external variables
+	TLAStrs []string // --jsonnet-tla-str: <key>=<value> top-level arguments
+}
+
+// ErrEvaluatorNotFound is returned when the CLI evaluating Jsonnet, Tanka or
+// CUE sources is not installed.
+var ErrEvaluatorNotFound = errors.New("evaluator not found in PATH")
+
+// lookPath is exec.LookPath, indirected so that the evaluators can be
+// stubbed in unit tests without depending on the test host's PATH.
+var lookPath = exec.LookPath
+
+// runEvaluator runs an evaluator CLI in dir and returns its standard output.
+// It is a variable so that unit tests can stub the evaluators.
+var runEvaluator = func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	bin, err := lookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, ErrEvaluatorNotFound)
+	}
+	cmd := exec.CommandContext(ctx, bin, args...) // #nosec G204 -- runs the jsonnet, tk or cue CLI on files selected for the scan
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// IsJsonnetFile reports whether path is a Jsonnet entrypoint. Libraries
+// (.libsonnet) are only evaluated through the files importing them.
+func IsJsonnetFile(path string) bool {
+	return filepath.Ext(path) == ".jsonnet"
+}
+
+// isTankaEnvironment reports whether dir is a Tanka environment: a spec.json
+// next to a main.jsonnet.
+func isTankaEnvironment(dir string) bool {
+	return isFile(filepath.Join(dir, "spec.json")) && isFile(filepath.Join(dir, "main.jsonnet"))
+}
+
+// jsonnetProjectRoot finds the jsonnet-bundler project path belongs to: the
+// closest directory holding a jsonnetfile.json.
+func jsonnetProjectRoot(path string) (string, bool) {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if isFile(filepath.Join(dir, "jsonnetfile.json")) {
+			return dir, true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return "", false
+		}
+	}
+}
+
+// isVendoredJsonnet reports whether path belongs to a library vendored by
+// jsonnet-bundler, whose entrypoints are examples rather than manifests.
+func isVendoredJsonnet(path string) bool {
+	root, ok := jsonnetProjectRoot(path)
+	if !ok {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && strings.HasPrefix(filepath.ToSlash(rel), "vendor/")
+}
+
+// listJsonnetEntrypoints lists the Jsonnet files under basePath to evaluate,
+// and the Tanka environments to render with tk. The main.jsonnet of a Tanka
+// environment is rendered through the environment only.
+func listJsonnetEntrypoints(basePath string, filter *PathFilter) ([]string, []string, []error) {
+	if isFile(basePath) {
+		if !IsJsonnetFile(basePath) || filter.Excluded(basePath, false) {
+			return nil, nil, nil
+		}
+		if dir := filepath.Dir(basePath); filepath.Base(basePath) == "main.jsonnet" && isTankaEnvironment(dir) {
+			return nil, []string{dir}, nil
+		}
+		return []string{basePath}, nil, nil
+	}
+
+	directories, errs := listDirs(basePath, filter)
+	var files, environments []string
+	for _, dir := range directories {
+		if isTankaEnvironment(dir) {
+			environments = append(environments, dir)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() || !IsJsonnetFile(path) || filter.Excluded(path, false) || isVendoredJsonnet(path) {
+				continue
+			}
+			if entry.Name() == "main.jsonnet" && isTankaEnvironment(dir) {
+				continue
+			}
+			files = append(files, path)
+		}
+	}
+	return files, environments, errs
+}
+
+// jsonnetArgs builds the jsonnet CLI arguments evaluating file: the vendor/
+// and lib/ directories of its jsonnet-bundler project, if any, come first on
+// the library search path, then the user's.
+func jsonnetArgs(file string, opts JsonnetOptions) []string {
+	var args []string
+	if root, ok := jsonnetProjectRoot(file); ok {
+		for _, lib := range []string{"vendor", "lib"} {
+			if isDir(filepath.Join(root, lib)) {
+				args = append(args, "--jpath", filepath.Join(root, lib))
+			}
+		}
+	}
+	for _, jpath := range opts.JPaths {
+		args = append(args, "--jpath", jpath)
+	}
+	for _, extStr := range opts.ExtStrs {
+		args = append(args, "--ext-str", extStr)
+	}
+	for _, tlaStr := range opts.TLAStrs {
+		args = append(args, "--tla-str", tlaStr)
+	}
+	return append(args, file)
+}
+
+// evaluateJsonnetFile evaluates a Jsonnet file into the Kubernetes objects
+// its output holds.
+func evaluateJsonnetFile(ctx context.Context, file string, opts JsonnetOptions) ([]workloadinterface.IMetadata, error) {
+	out, err := runEvaluator(ctx, "", "jsonnet", jsonnetArgs(file, opts)...)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(out, &value); err != nil {
+		return nil, fmt.Errorf("invalid jsonnet output: %w", err)
+	}
+	return evaluatedWorkloads(file, kubernetesObjects(value))
+}
+
+// renderTankaEnvironment renders a Tanka environment with tk, which resolves
+// its library paths and applies the environment's namespace and labels. The
+// objects are attributed to the environment's main.jsonnet.
+func renderTankaEnvironment(ctx context.Context, dir string, opts JsonnetOptions) ([]workloadinterface.IMetadata, error) {
+	args := []string{"show", "--dangerous-allow-redirect"}
+	for _, extStr := range opts.ExtStrs {
+		args = append(args, "--ext-str", extStr)
+	}
+	for _, tlaStr := range opts.TLAStrs {
+		args = append(args, "--tla-str", tlaStr)
+	}
+	out, err := runEvaluator(ctx, dir, "tk", append(args, ".")...)
+	if err != nil {
+		return nil, err
+	}
+	wls, err := ReadFile(out, YAML_FILE_FORMAT)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tk output: %w", err)
+	}
+	objects := make([]any, 0, len(wls))
+	for _, wl := range wls {
+		objects = append(objects, wl.GetObject())
+	}
+	return evaluatedWorkloads(filepath.Join(dir, "main.jsonnet"), objects)
+}
+
+// LoadResourcesFromJsonnet loads the Kubernetes resources Jsonnet files and
+// Tanka environments under basePath evaluate to. An explicit file that fails
+// to evaluate is an error; in a directory scan, such files are reported as
+// skipped, as a missing jsonnet or tk CLI is.
+func LoadResourcesFromJsonnet(ctx context.Context, basePath string, opts JsonnetOptions) (map[string][]workloadinterface.IMetadata, []SkippedManifest, error) {
+	return LoadResourcesFromJsonnetFiltered(ctx, basePath, opts, nil)
+}
+
+// LoadResourcesFromJsonnetFiltered behaves like LoadResourcesFromJsonnet,
+// leaving out the files and directories filter excludes.
+func LoadResourcesFromJsonnetFiltered(ctx context.Context, basePath string, opts JsonnetOptions, filter *PathFilter) (map[string][]workloadinterface.IMetadata, []SkippedManifest, error) {
+	// A file input, or a Tanka environment input, is an explicit request.
+	absBasePath, err := filepath.Abs(basePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, environments, errs := listJsonnetEntrypoints(absBasePath, filter)
+	for _, err := range errs {
+		logger.L().Ctx(ctx).Warning("Skipping path while discovering Jsonnet files", helpers.Error(err))
+	}
+
+	explicitSource := filepath.Join(absBasePath, "main.jsonnet")
+	if isFile(absBasePath) {
+		explicitSource = absBasePath
+	}
+
+	sourceToWorkloads := map[string][]workloadinterface.IMetadata{}
+	var skips []SkippedManifest
+	load := func(source string, evaluate func() ([]workloadinterface.IMetadata, error)) error {
+		wls, err := evaluate()
+		if err != nil {
+			if source == explicitSource {
+				return fmt.Errorf("failed to evaluate %q: %w", source, err)
+			}
+			skips = append(skips, SkippedManifest{Path: source, Reason: "evaluation error: " + err.Error()})
+			return nil
+		}
+		if len(wls) > 0 {
+			sourceToWorkloads[source] = wls
+		}
+		return nil
+	}
+
+	for _, dir := range environments {
+		if err := load(filepath.Join(dir, "main.jsonnet"), func() ([]workloadinterface.IMetadata, error) {
+			return renderTankaEnvironment(ctx, dir, opts)
+		}); err != nil {
+			return sourceToWorkloads, skips, err
+		}
+	}
+	for _, file := range files {
+		if err := load(file, func() ([]workloadinterface.IMetadata, error) {
+			return evaluateJsonnetFile(ctx, file, opts)
+		}); err != nil {
+			return sourceToWorkloads, skips, err
+		}
+	}
+	return sourceToWorkloads, skips, nil
+}
+
+// kubernetesObjects finds the Kubernetes objects in an evaluated value the
+// way Tanka does: an object with an apiVersion and a kind is a Kubernetes
+// object, or a list whose items are; other objects and arrays are searched,
+// objects in key order.
+func kubernetesObjects(value any) []any {
+	switch value := value.(type) {
+	case map[string]any:
+		apiVersion, _ := value["apiVersion"].(string)
+		kind, _ := value["kind"].(string)
+		if apiVersion != "" && kind != "" {
+			if items, ok := value["items"].([]any); ok && strings.HasSuffix(kind, "List") {
+				return kubernetesObjects(items)
+			}
+			return []any{value}
+		}
+		var objects []any
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			objects = append(objects, kubernetesObjects(value[key])...)
+		}
+		return objects
+	case []any:
+		var objects []any
+		for _, item := range value {
+			objects = append(objects, kubernetesObjects(item)...)
+		}
+		return objects
+	default:
+		return nil
+	}
+}
+
+// evaluatedWorkloads turns the objects evaluated from source into workloads
+// located at source, numbered like the documents of a YAML file.
+func evaluatedWorkloads(source string, objects []any) ([]workloadinterface.IMetadata, error) {
+	var workloads []workloadinterface.IMetadata
+	for i, object := range objects {
+		data, err := json.Marshal(object)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %w", i, err)
+		}
+		wls, err := ReadFile(data, JSON_FILE_FORMAT)
+		if err != nil {
+			return nil, fmt.Errorf("object %d is not a valid Kubernetes object: %w", i, err)
+		}
+		for _, wl := range wls {
+			lw := localworkload.NewLocalWorkload(wl.GetObject())
+			lw.SetPath(fmt.Sprintf("%s:%d", source, i))
+			workloads = append(workloads, lw)
+		}
+	}
+	return workloads, nil
+}
@@ -0,0 +1,159 @@
+package cautils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluatorCall records one run of a stubbed evaluator CLI.
+type evaluatorCall struct {
+	dir  string
+	name string
+	args []string
+}
+
+// stubEvaluator replaces the evaluator CLIs with evaluate for the duration of
+// the test, and returns the calls made.
+func stubEvaluator(t *testing.T, evaluate func(dir, name string, args []string) ([]byte, error)) *[]evaluatorCall {
+	t.Helper()
+	calls := &[]evaluatorCall{}
+	original := runEvaluator
+	runEvaluator = func(_ context.Context, dir, name string, args ...string) ([]byte, error) {
+		*calls = append(*calls, evaluatorCall{dir: dir, name: name, args: args})
+		return evaluate(dir, name, args)
+	}
+	t.Cleanup(func() { runEvaluator = original })
+	return calls
+}
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+const jsonnetDeployment = `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "%s", "namespace": "default"}, "spec": {"template": {"spec": {"containers": [{"name": "app", "image": "nginx"}]}}}}`
+
+func TestKubernetesObjects(t *testing.T) {
+	value := map[string]any{
+		"web": map[string]any{
+			"service":    map[string]any{"apiVersion": "v1", "kind": "Service", "metadata": map[string]any{"name": "web"}},
+			"deployment": map[string]any{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]any{"name": "web"}},
+		},
+		"list": map[string]any{"apiVersion": "v1", "kind": "List", "items": []any{
+			map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]any{"name": "config"}},
+		}},
+		"replicas": 3,
+	}
+
+	var kinds []string
+	for _, object := range kubernetesObjects(value) {
+		kinds = append(kinds, object.(map[string]any)["kind"].(string))
+	}
+	assert.Equal(t, []string{"ConfigMap", "Deployment", "Service"}, kinds, "objects are found in key order, and lists are unwrapped")
+}
+
+func TestLoadResourcesFromJsonnet(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"jsonnetfile.json":                      `{"version": 1}`,
+		"lib/k.libsonnet":                       `{}`,
+		"vendor/github.com/x/y/example.jsonnet": `{}`,
+		"apps/web.jsonnet":                      `{}`,
+		"environments/prod/spec.json":           `{"apiVersion": "tanka.dev/v1alpha1", "kind": "Environment"}`,
+		"environments/prod/main.jsonnet":        `{}`,
+	})
+	calls := stubEvaluator(t, func(dir, name string, args []string) ([]byte, error) {
+		if name == "tk" {
+			return []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: api\n  namespace: prod\n"), nil
+		}
+		return []byte(fmt.Sprintf(`{"web": %s, "worker": %s}`, fmt.Sprintf(jsonnetDeployment, "web"), fmt.Sprintf(jsonnetDeployment, "worker"))), nil
+	})
+
+	opts := JsonnetOptions{JPaths: []string{"/opt/jsonnet"}, ExtStrs: []string{"env=prod"}, TLAStrs: []string{"replicas=3"}}
+	sourceToWorkloads, skips, err := LoadResourcesFromJsonnet(context.Background(), root, opts)
+	require.NoError(t, err)
+	assert.Empty(t, skips)
+
+	web := filepath.Join(root, "apps", "web.jsonnet")
+	env := filepath.Join(root, "environments", "prod")
+	require.Len(t, sourceToWorkloads, 2, "vendored files and the environment's main.jsonnet are not evaluated on their own")
+	require.Len(t, sourceToWorkloads[web], 2)
+	assert.Equal(t, "web", sourceToWorkloads[web][0].GetName())
+	assert.Equal(t, "worker", sourceToWorkloads[web][1].GetName())
+	require.Len(t, sourceToWorkloads[filepath.Join(env, "main.jsonnet")], 1)
+	assert.Equal(t, "api", sourceToWorkloads[filepath.Join(env, "main.jsonnet")][0].GetName())
+
+	require.Len(t, *calls, 2)
+	assert.Equal(t, evaluatorCall{dir: env, name: "tk", args: []string{"show", "--dangerous-allow-redirect", "--ext-str", "env=prod", "--tla-str", "replicas=3", "."}}, (*calls)[0])
+	assert.Equal(t, evaluatorCall{name: "jsonnet", args: []string{
+		"--jpath", filepath.Join(root, "vendor"),
+		"--jpath", filepath.Join(root, "lib"),
+		"--jpath", "/opt/jsonnet",
+		"--ext-str", "env=prod",
+		"--tla-str", "replicas=3",
+		web,
+	}}, (*calls)[1])
+}
+
+func TestLoadResourcesFromJsonnetReportsFailures(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"web.jsonnet": `{}`})
+	stubEvaluator(t, func(string, string, []string) ([]byte, error) {
+		return nil, fmt.Errorf("jsonnet: %w", ErrEvaluatorNotFound)
+	})
+
+	sourceToWorkloads, skips, err := LoadResourcesFromJsonnet(context.Background(), root, JsonnetOptions{})
+	require.NoError(t, err, "a directory scan skips what cannot be evaluated")
+	assert.Empty(t, sourceToWorkloads)
+	require.Len(t, skips, 1)
+	assert.Equal(t, filepath.Join(root, "web.jsonnet"), skips[0].Path)
+	assert.Contains(t, skips[0].Reason, "evaluator not found")
+
+	_, _, err = LoadResourcesFromJsonnet(context.Background(), filepath.Join(root, "web.jsonnet"), JsonnetOptions{})
+	assert.ErrorIs(t, err, ErrEvaluatorNotFound, "an explicit file that cannot be evaluated fails the scan")
+}
+
+func TestLoadResourcesFromJsonnetHonorsPathFilter(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"web.jsonnet":          `{}`,
+		"examples/foo.jsonnet": `{}`,
+	})
+	calls := stubEvaluator(t, func(string, string, []string) ([]byte, error) {
+		return []byte(fmt.Sprintf(jsonnetDeployment, "web")), nil
+	})
+	filter, err := NewScanPathFilter(root, []string{"examples/"}, false)
+	require.NoError(t, err)
+
+	sourceToWorkloads, _, err := LoadResourcesFromJsonnetFiltered(context.Background(), root, JsonnetOptions{}, filter)
+	require.NoError(t, err)
+	assert.Contains(t, sourceToWorkloads, filepath.Join(root, "web.jsonnet"))
+	assert.Len(t, *calls, 1)
+}
+
+func TestExcludeJsonnetMetadataFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"environments/prod/spec.json":    `{}`,
+		"environments/prod/main.jsonnet": `{}`,
+		"config/spec.json":               `{}`,
+	})
+
+	files := []string{
+		filepath.Join(root, "jsonnetfile.json"),
+		filepath.Join(root, "jsonnetfile.lock.json"),
+		filepath.Join(root, "environments", "prod", "spec.json"),
+		filepath.Join(root, "config", "spec.json"),
+	}
+	assert.Equal(t, []string{filepath.Join(root, "config", "spec.json")}, excludeJsonnetMetadataFiles(files))
+}
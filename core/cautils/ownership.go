@@ -0,0 +1,216 @@
+package cautils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils/helmrelease"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Unowned names the group of resources no owner claims, in the owner view and
+// in per-owner reports. No owner may take this name.
+const Unowned = "unowned"
+
+// Ownership maps scanned resources to the teams owning them. It is loaded from
+// the --ownership file, in YAML or JSON:
+//
+//	owners:
+//	  - name: payments
+//	    contacts: [alice, bob]
+//	    slack: "#payments-oncall"
+//	    email: payments@example.com
+//	    namespaces: ["payments", "payments-*"]
+//	    labelSelector: team=payments
+//	    helmReleases: ["payments/*"]
+//	    paths: ["services/payments/"]
+//	defaultOwner: platform
+//
+// A resource belongs to the first owner, in file order, that one of its rules
+// matches: a namespace glob, a Kubernetes label selector, a
+// <namespace>/<release> glob of the Helm release that deployed the resource
+// (see --helm-releases), or a gitignore-style pattern of the file the resource
+// was loaded from, relative to the scanned repository. Resources no rule
+// matches belong to the default owner, when set.
+type Ownership struct {
+	Owners       []Owner `json:"owners" yaml:"owners"`
+	DefaultOwner string  `json:"defaultOwner,omitempty" yaml:"defaultOwner"`
+
+	// Source is the file the ownership was loaded from.
+	Source string `json:"source,omitempty" yaml:"-"`
+}
+
+// Owner is a team owning resources, with the rules claiming them.
+type Owner struct {
+	Name          string   `json:"name" yaml:"name"`
+	Contacts      []string `json:"contacts,omitempty" yaml:"contacts"`
+	Slack         string   `json:"slack,omitempty" yaml:"slack"`
+	Email         string   `json:"email,omitempty" yaml:"email"`
+	Namespaces    []string `json:"namespaces,omitempty" yaml:"namespaces"`
+	LabelSelector string   `json:"labelSelector,omitempty" yaml:"labelSelector"`
+	HelmReleases  []string `json:"helmReleases,omitempty" yaml:"helmReleases"`
+	Paths         []string `json:"paths,omitempty" yaml:"paths"`
+
+	selector labels.Selector
+	paths    *PathFilter
+}
+
+// LoadOwnership reads and validates the ownership file at file. It returns nil
+// when file is empty.
+func LoadOwnership(file string) (*Ownership, error) {
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ownership file: %w", err)
+	}
+	ownership := &Ownership{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(ownership); err != nil {
+		return nil, fmt.Errorf("invalid ownership file %s: %w", file, err)
+	}
+	if err := ownership.validate(); err != nil {
+		return nil, fmt.Errorf("invalid ownership file %s: %w", file, err)
+	}
+	ownership.Source = file
+	return ownership, nil
+}
+
+// validate checks the ownership and compiles its rules.
+func (o *Ownership) validate() error {
+	if len(o.Owners) == 0 {
+		return errors.New("at least one owner is required")
+	}
+	names := make(map[string]struct{}, len(o.Owners))
+	for i := range o.Owners {
+		owner := &o.Owners[i]
+		switch owner.Name {
+		case "":
+			return errors.New("owner name is required")
+		case Unowned:
+			return fmt.Errorf("owner name %q is reserved", Unowned)
+		}
+		if strings.ContainsAny(owner.Name, `/\`) {
+			return fmt.Errorf("owner name %q must not contain path separators", owner.Name)
+		}
+		if _, ok := names[owner.Name]; ok {
+			return fmt.Errorf("duplicate owner %q", owner.Name)
+		}
+		names[owner.Name] = struct{}{}
+
+		for _, pattern := range append(append([]string{}, owner.Namespaces...), owner.HelmReleases...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q for owner %s: %w", pattern, owner.Name, err)
+			}
+		}
+		if owner.LabelSelector != "" {
+			selector, err := labels.Parse(owner.LabelSelector)
+			if err != nil {
+				return fmt.Errorf("invalid label selector for owner %s: %w", owner.Name, err)
+			}
+			owner.selector = selector
+		}
+		// Resource paths are relative to the scanned repository, so the
+		// patterns are anchored at a root the relative paths resolve under.
+		paths, err := NewPathFilter(".", owner.Paths)
+		if err != nil {
+			return fmt.Errorf("invalid path pattern for owner %s: %w", owner.Name, err)
+		}
+		owner.paths = paths
+	}
+	if _, ok := names[o.DefaultOwner]; o.DefaultOwner != "" && !ok {
+		return fmt.Errorf("default owner %q is not an owner", o.DefaultOwner)
+	}
+	return nil
+}
+
+// Owner returns the owner named name.
+func (o *Ownership) Owner(name string) (Owner, bool) {
+	for _, owner := range o.Owners {
+		if owner.Name == name {
+			return owner, true
+		}
+	}
+	return Owner{}, false
+}
+
+// OwnerList returns the owners, or nil when no ownership file was given.
+func (o *Ownership) OwnerList() []Owner {
+	if o == nil {
+		return nil
+	}
+	return o.Owners
+}
+
+// OwnerOf returns the name of the owner of resource, loaded from source when
+// known, or "" when no owner claims it.
+func (o *Ownership) OwnerOf(resource workloadinterface.IMetadata, source *reporthandling.Source) string {
+	for i := range o.Owners {
+		if o.Owners[i].claims(resource, source) {
+			return o.Owners[i].Name
+		}
+	}
+	return o.DefaultOwner
+}
+
+// Assign returns the owner of every owned resource, keyed by resource ID.
+func (o *Ownership) Assign(resources map[string]workloadinterface.IMetadata, sources map[string]reporthandling.Source) map[string]string {
+	owners := make(map[string]string, len(resources))
+	for resourceID, resource := range resources {
+		var source *reporthandling.Source
+		if s, ok := sources[resourceID]; ok {
+			source = &s
+		}
+		if owner := o.OwnerOf(resource, source); owner != "" {
+			owners[resourceID] = owner
+		}
+	}
+	return owners
+}
+
+func (owner *Owner) claims(resource workloadinterface.IMetadata, source *reporthandling.Source) bool {
+	namespace := resource.GetNamespace()
+	if resource.GetKind() == "Namespace" {
+		namespace = resource.GetName()
+	}
+	if namespace != "" && matchesAny(owner.Namespaces, namespace) {
+		return true
+	}
+
+	if owner.selector != nil {
+		if workload, ok := resource.(workloadinterface.IBasicWorkload); ok && owner.selector.Matches(labels.Set(workload.GetLabels())) {
+			return true
+		}
+	}
+
+	if source == nil {
+		return false
+	}
+	if release, ok := strings.CutPrefix(source.HelmPath, helmrelease.PathPrefix); ok && matchesAny(owner.HelmReleases, release) {
+		return true
+	}
+	sourcePath := source.RelativePath
+	if sourcePath == "" {
+		sourcePath = source.Path
+	}
+	return sourcePath != "" && !filepath.IsAbs(sourcePath) && owner.paths.Excluded(sourcePath, false)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package cautils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOwnership(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "owners.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func ownedWorkload(kind, namespace, name string, labels map[string]any) workloadinterface.IMetadata {
+	return workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   map[string]any{"name": name, "namespace": namespace, "labels": labels},
+	})
+}
+
+func TestLoadOwnership(t *testing.T) {
+	path := writeOwnership(t, `
+owners:
+  - name: payments
+    contacts: [alice, bob]
+    slack: "#payments-oncall"
+    email: payments@example.com
+    namespaces: ["payments-*"]
+    helmReleases: ["billing/*"]
+  - name: data
+    labelSelector: team in (data, analytics)
+    paths: ["services/data/"]
+  - name: platform
+    namespaces: ["kube-system"]
+defaultOwner: platform
+`)
+
+	ownership, err := LoadOwnership(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, ownership.Source)
+	require.Len(t, ownership.OwnerList(), 3)
+	payments, ok := ownership.Owner("payments")
+	require.True(t, ok)
+	assert.Equal(t, []string{"alice", "bob"}, payments.Contacts)
+
+	tests := []struct {
+		name     string
+		resource workloadinterface.IMetadata
+		source   *reporthandling.Source
+		want     string
+	}{
+		{name: "namespace glob", resource: ownedWorkload("Pod", "payments-eu", "api", nil), want: "payments"},
+		{name: "namespace object", resource: ownedWorkload("Namespace", "", "payments-us", nil), want: "payments"},
+		{name: "label selector", resource: ownedWorkload("Pod", "shared", "etl", map[string]any{"team": "analytics"}), want: "data"},
+		{name: "first owner wins", resource: ownedWorkload("Pod", "payments-eu", "etl", map[string]any{"team": "data"}), want: "payments"},
+		{
+			name:     "helm release",
+			resource: ownedWorkload("Pod", "shared", "invoices", nil),
+			source:   &reporthandling.Source{HelmPath: "helm://billing/invoices"},
+			want:     "payments",
+		},
+		{
+			name:     "path pattern",
+			resource: ownedWorkload("Pod", "", "loader", nil),
+			source:   &reporthandling.Source{RelativePath: "services/data/loader.yaml"},
+			want:     "data",
+		},
+		{name: "default owner", resource: ownedWorkload("Pod", "shared", "web", nil), want: "platform"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ownership.OwnerOf(tt.resource, tt.source))
+		})
+	}
+}
+
+func TestOwnershipAssign(t *testing.T) {
+	ownership, err := LoadOwnership(writeOwnership(t, "owners: [{name: payments, namespaces: [payments]}]"))
+	require.NoError(t, err)
+
+	owned := ownedWorkload("Pod", "payments", "api", nil)
+	unowned := ownedWorkload("Pod", "shared", "web", nil)
+	assert.Equal(t, map[string]string{owned.GetID(): "payments"}, ownership.Assign(map[string]workloadinterface.IMetadata{
+		owned.GetID():   owned,
+		unowned.GetID(): unowned,
+	}, nil), "resources without a default owner are left unowned")
+}
+
+func TestLoadOwnershipWithoutPath(t *testing.T) {
+	ownership, err := LoadOwnership("")
+	require.NoError(t, err)
+	assert.Nil(t, ownership)
+	assert.Nil(t, ownership.OwnerList())
+}
+
+func TestLoadOwnershipRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantError string
+	}{
+		{name: "no owners", content: "defaultOwner: platform", wantError: "at least one owner is required"},
+		{name: "unknown field", content: "owners: [{name: a, team: b}]", wantError: "field team not found"},
+		{name: "missing name", content: "owners: [{namespaces: [a]}]", wantError: "owner name is required"},
+		{name: "reserved name", content: "owners: [{name: unowned}]", wantError: `owner name "unowned" is reserved`},
+		{name: "path separator", content: "owners: [{name: a/b}]", wantError: "must not contain path separators"},
+		{name: "duplicate", content: "owners: [{name: a}, {name: a}]", wantError: `duplicate owner "a"`},
+		{name: "bad glob", content: "owners: [{name: a, namespaces: [\"prod-[\"]}]", wantError: `invalid pattern "prod-["`},
+		{name: "bad selector", content: "owners: [{name: a, labelSelector: \"team in (\"}]", wantError: "invalid label selector for owner a"},
+		{name: "unknown default", content: "owners: [{name: a}]\ndefaultOwner: b", wantError: `default owner "b" is not an owner`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadOwnership(writeOwnership(t, tt.content))
+			require.ErrorContains(t, err, tt.wantError)
+		})
+	}
+}
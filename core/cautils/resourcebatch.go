@@ -5,6 +5,7 @@ import (
 
 	"github.com/kubescape/k8s-interface/k8sinterface"
 	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/opa-utils/reporthandling"
 )
 
 // ClusterScope is the scope of the resources every other scope depends on:
@@ -61,6 +62,9 @@ type ResourceBatch struct {
 	ExternalResources ExternalResources
 	// AllResources holds the batch's objects by resource ID.
 	AllResources map[string]workloadinterface.IMetadata
+	// ResourceSource holds the sources known for the batch's objects by
+	// resource ID, such as the Helm release that deployed them. It may be nil.
+	ResourceSource map[string]reporthandling.Source
 }
 
 // NewResourceBatch returns an empty batch for the given scope.
@@ -0,0 +1,48 @@
+package cautils
+
+// Runtime adjustments of the failed findings of a workload.
+const (
+	RuntimeBoosted = "boosted"
+	RuntimeLowered = "lowered"
+)
+
+// RuntimeScore is the compliance score adjusted by the behavior of the scanned
+// workloads at runtime, as observed through host sensor telemetry. It is
+// reported next to the static compliance score, which it does not replace.
+type RuntimeScore struct {
+	// StaticComplianceScore is the report's compliance score without runtime
+	// adjustment.
+	StaticComplianceScore float32 `json:"staticComplianceScore"`
+	// ComplianceScore is the runtime-adjusted compliance score of the report.
+	ComplianceScore float32 `json:"complianceScore"`
+	// Frameworks maps framework names to their runtime-adjusted scores.
+	Frameworks map[string]float32 `json:"frameworks,omitempty"`
+	// Events is the number of telemetry events received, and
+	// UnattributedEvents the number of those that could not be tied to a
+	// scanned workload.
+	Events             int `json:"events"`
+	UnattributedEvents int `json:"unattributedEvents,omitempty"`
+	// Workloads lists the workloads whose failed findings were adjusted, with
+	// the behavior that adjusted them.
+	Workloads []RuntimeEvidence `json:"workloads,omitempty"`
+}
+
+// RuntimeEvidence is the runtime behavior that adjusted the failed findings of
+// one workload. A workload is boosted when it ran a shell, connected outside
+// its pod or used a capability it adds, and lowered otherwise.
+type RuntimeEvidence struct {
+	ResourceID string `json:"resourceID"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Adjustment is RuntimeBoosted or RuntimeLowered, and Factor the multiplier
+	// it applied to the weight of the workload's failed findings.
+	Adjustment string  `json:"adjustment"`
+	Factor     float32 `json:"factor"`
+	// Shells lists the shells the workload executed.
+	Shells []string `json:"shells,omitempty"`
+	// OutboundConnections lists the addresses the workload connected to.
+	OutboundConnections []string `json:"outboundConnections,omitempty"`
+	// Capabilities lists the capabilities the workload adds and used.
+	Capabilities []string `json:"capabilities,omitempty"`
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -21,6 +23,7 @@ import (
 	"github.com/kubescape/opa-utils/objectsenvelopes"
 	"github.com/kubescape/opa-utils/reporthandling"
 	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -106,6 +109,11 @@ const (
 	// action-required resources are always listed, and passed resources are
 	// listed in verbose mode.
 	ControlViewType ViewTypes = "control"
+
+	// OwnerViewType groups failed resources by the owner the --ownership
+	// file assigns them, listing each owner's contacts and the controls its
+	// resources failed. Unowned resources come last.
+	OwnerViewType ViewTypes = "owner"
 )
 
 type PolicyIdentifier struct {
@@ -124,6 +132,8 @@ type ScanInfo struct {
 	UseDefault                bool        // Load framework from cached file (instead of download). Use when running offline
 	UseArtifactsFrom          string      // Load artifacts from local path. Use when running offline
 	ControlsVersion           string      // Pin the regolibrary release used to download policies (e.g. "v2.0.301"). Empty uses the latest release
+	PolicyBundle              string      // OCI reference of a signed policy bundle to load the policies from (e.g. "oci://registry.example.com/kubescape/policies:v1")
+	PolicyBundleKey           string      // Cosign public key the policy bundle is verified with
 	VerboseMode               bool        // Display all the input resources and not only failed resources
 	Hide                      bool        // Hide sensitive identifiers (names, namespaces, images) in results
 	EncryptionEnabled         bool
@@ -165,14 +175,20 @@ type ScanInfo struct {
 	EnableRegoPrint           bool                         // true if print rego
 	ScanObject                *objectsenvelopes.ScanObject // identifies a single resource (k8s object) to be scanned
 	IsDeletedScanObject       bool                         // indicates whether the ScanObject is a deleted K8S resource
+	AdmissionObject           map[string]any               // object under admission review, scanned as the single resource instead of the ScanObject
+	ExplainTrace              string                       // explain the verdicts on the single resource with this trace mode (see ExplainTraceModes)
 	TriggeredByCLI            bool                         // indicates whether the scan was triggered by the CLI
 	ScanType                  ScanTypes
 	ScanImages                bool
 	UseDefaultMatchers        bool
 	ScanTimeout               time.Duration // Maximum duration for the entire scan (0 = no timeout)
 	ControlTimeout            time.Duration // Maximum duration for evaluating a single control (0 = no timeout)
+	EvalConcurrency           int           // Number of controls evaluated concurrently (0 = one per CPU)
 	EnableStreaming           bool          // Enable resource streaming for large clusters to keep the evaluation input bounded
 	Incremental               bool          // Cache verdicts per resource, keyed by resource hash + controls-config version, and skip re-evaluating unchanged resources
+	Workers                   []string      // base URLs of the `kubescape worker` processes the control evaluation is distributed to, evaluated locally when empty
+	WorkerTimeout             time.Duration // Maximum duration of a shard's round trip to a worker before it is sent to the next one (0 = shardworker.DefaultShardTimeout)
+	WorkersLocalFallback      bool          // Evaluate locally the shards no worker could evaluate, instead of failing the scan
 	DryRun                    bool          // Check RBAC access for the resources the scan would need, without collecting or evaluating anything
 	ChartPath                 string
 	FilePath                  string
@@ -182,6 +198,12 @@ type ScanInfo struct {
 	HelmSetFileValues         []string // --set-file: Helm value overrides whose value is read from a file
 	HelmReleaseName           string   // --release-name: Helm release name made available as .Release.Name during render
 	HelmReleaseNamespace      string   // --release-namespace: Helm release namespace made available as .Release.Namespace
+	JsonnetJPaths             []string // --jsonnet-jpath: Jsonnet library search directories
+	JsonnetExtStrs            []string // --jsonnet-ext-str: <key>=<value> Jsonnet external variables
+	JsonnetTLAStrs            []string // --jsonnet-tla-str: <key>=<value> Jsonnet top-level arguments
+	GitOps                    bool     // --gitops: render Argo CD and Flux objects into the resources they deploy
+	GitOpsSources             []string // --gitops-source: <source>=<path> local stand-ins for the sources GitOps objects reference
+	HelmReleases              bool     // --helm-releases: attribute cluster resources to the Helm releases stored in the cluster
 	LabelsToCopy              []string // Labels to copy from workloads to scan reports
 	SkipControls              string   // Control IDs to skip, e.g. "C-0001,C-0020"
 	IncludeControls           string   // Control IDs to include (all others skipped), e.g. "C-0001,C-0002"
@@ -200,12 +222,23 @@ type ScanInfo struct {
 	RegistryToken             string            // Bearer token for workload image registry authentication
 	ImageScanConcurrency      int               // Number of concurrent workers for image scanning
 	ImagePlatform             string            // OCI platform used for image scanning (os/architecture[/variant])
+	ImageCache                bool              // Reuse image scan results cached by manifest digest, grype DB build and matcher config
+	SBOMDir                   string            // Directory of pre-generated SBOMs used instead of pulling the images they describe
 	MinSeverity               string            // Only include controls at or above this severity in the output
 	MaxSeverity               string            // Only include controls at or below this severity in the output
 	Baseline                  string            // Path to a saved JSON scan report; when set, the fresh scan is diffed against it
 	BaselineFailOnNew         bool              // Exit with code 1 when the baseline diff finds new or incomparable failures
 	BaselineSeverityThreshold string            // Only count new/incomparable baseline failures at or above this severity when enforcing BaselineFailOnNew
 	BaselineGranularity       string            // Comparison unit for the baseline diff: "evidence" (default) or "control"
+	FleetContexts             []string          // Kubeconfig contexts scanned into one fleet report (--contexts)
+	AllContexts               bool              // Scan every context in the kubeconfig into one fleet report (--all-contexts)
+	FleetParallelism          int               // Number of clusters scanned concurrently in a fleet scan
+	ScoringProfile            string            // Path to a scoring profile replacing the default compliance scoring (--scoring-profile)
+	Ownership                 string            // Path to an ownership file mapping resources to owners (--ownership)
+	SplitByOwner              string            // Directory receiving one report per owner, in every output format (--split-by-owner)
+	ReportAs                  []string          // Standards the results are aggregated onto per clause (--report-as)
+	Crosswalks                []string          // Paths to crosswalk files replacing or adding to the shipped ones (--crosswalk)
+	DynamicClient             dynamic.Interface // Client used to list cluster resources instead of the default one; set by watch mode to serve lists from its informer caches
 }
 
 type Getters struct {
@@ -520,6 +553,47 @@ func (scanInfo *ScanInfo) GetClusterContextName() string {
 	return k8sinterface.GetContextName()
 }
 
+// IsFleetScan reports whether the scan covers several kubeconfig contexts and
+// produces a fleet report rather than a single-cluster one.
+func (scanInfo *ScanInfo) IsFleetScan() bool {
+	return scanInfo.AllContexts || len(scanInfo.FleetContexts) > 0
+}
+
+// KubeconfigContexts lists, sorted by name, the contexts in the kubeconfig
+// selected by --kubeconfig or the default loading rules.
+func (scanInfo *ScanInfo) KubeconfigContexts() ([]string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if scanInfo.kubeconfigPath != "" {
+		loadingRules.ExplicitPath = scanInfo.kubeconfigPath
+	}
+	kubeconfig, err := loadingRules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return slices.Sorted(maps.Keys(kubeconfig.Contexts)), nil
+}
+
+// ForClusterContext returns a copy of the scan settings targeting
+// contextName, used to scan one member of a fleet. The copy gets its own scan
+// ID and cleanups, and resolves its context independently of scanInfo.
+func (scanInfo *ScanInfo) ForClusterContext(contextName string) *ScanInfo {
+	clone := *scanInfo
+	clone.UseFrom = slices.Clone(scanInfo.UseFrom)
+	clone.FleetContexts = nil
+	clone.AllContexts = false
+	clone.ScanID = ""
+	clone.scanningContext = nil
+	clone.cleanups = nil
+	clone.SetKubeconfigSelection(scanInfo.kubeconfigPath, contextName)
+	return &clone
+}
+
+// ForRescan returns a copy of the scan settings for scanning the same target
+// again, used by watch mode. The copy gets its own scan ID and cleanups.
+func (scanInfo *ScanInfo) ForRescan() *ScanInfo {
+	return scanInfo.ForClusterContext(scanInfo.kubeContextOverride)
+}
+
 // getScanningContext get scanning context from the input param
 // this function should be called only once. Call GetScanningContext() to get the scanning context
 func (scanInfo *ScanInfo) getScanningContext(input string) ScanningContext {
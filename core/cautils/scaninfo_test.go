@@ -160,6 +160,38 @@ func TestResolveClusterContextNameSkipsDefaultLoadingWithoutSelection(t *testing
 	assert.False(t, scanInfo.contextResolved)
 }
 
+func TestKubeconfigContexts(t *testing.T) {
+	scanInfo := &ScanInfo{}
+	scanInfo.SetKubeconfigSelection(writeScanInfoMultiContextKubeconfig(t), "")
+
+	contexts, err := scanInfo.KubeconfigContexts()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"context-current", "context-selected"}, contexts)
+}
+
+func TestForClusterContext(t *testing.T) {
+	path := writeScanInfoMultiContextKubeconfig(t)
+	scanInfo := &ScanInfo{
+		ScanID:        "fleet",
+		UseFrom:       []string{"nsa.json"},
+		FleetContexts: []string{"context-current", "context-selected"},
+		Format:        "json",
+	}
+	scanInfo.SetKubeconfigSelection(path, "")
+
+	member := scanInfo.ForClusterContext("context-selected")
+	member.UseFrom[0] = "mitre.json"
+
+	assert.False(t, member.IsFleetScan())
+	assert.True(t, scanInfo.IsFleetScan())
+	assert.Empty(t, member.ScanID)
+	assert.Equal(t, "json", member.Format)
+	assert.Equal(t, []string{"nsa.json"}, scanInfo.UseFrom, "members do not share UseFrom")
+	require.NoError(t, member.ResolveClusterContextName())
+	assert.Equal(t, "context-selected", member.GetClusterContextName())
+	assert.False(t, scanInfo.contextResolved)
+}
+
 func writeScanInfoKubeconfig(t *testing.T, contextName string) string {
 	t.Helper()
 	path := filepath.Join(t.TempDir(), "config")
@@ -0,0 +1,204 @@
+package cautils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Treatments a scoring profile can give to resources passing a control through
+// an exception, and to resources whose control result requires action.
+const (
+	ScoreAsPassed  = "passed"
+	ScoreAsFailed  = "failed"
+	ScoreAsIgnored = "ignored"
+)
+
+// scoringSeverities are the control severities a scoring profile can weigh.
+var scoringSeverities = []string{"critical", "high", "medium", "low", "unknown"}
+
+// ScoringProfile replaces the default compliance scoring, where every control
+// and every resource counts the same, with weights. It is loaded from the
+// --scoring-profile file, in YAML or JSON:
+//
+//	name: risk-committee
+//	severityWeights: {critical: 10, high: 5, medium: 2, low: 1}
+//	controls:
+//	  C-0017: {weight: 0}
+//	  C-0002: {severity: critical}
+//	namespaces:
+//	  - {pattern: "prod-*", multiplier: 3}
+//	exceptions: passed
+//	actionRequired: failed
+//
+// A control's score is the weighted share of its resources passing it, each
+// resource weighing the multiplier of the first namespace pattern it matches
+// (1 for none, and for cluster-scoped resources). Framework and report scores
+// are averages of their controls' scores, weighted by the control's weight
+// override or else the weight of its severity (1 for unlisted severities).
+type ScoringProfile struct {
+	Name            string                    `json:"name" yaml:"name"`
+	SeverityWeights map[string]float32        `json:"severityWeights,omitempty" yaml:"severityWeights"`
+	Controls        map[string]ControlScoring `json:"controls,omitempty" yaml:"controls"`
+	Namespaces      []NamespaceScoring        `json:"namespaces,omitempty" yaml:"namespaces"`
+	// Exceptions says how a resource passing a control through an exception
+	// counts: ScoreAsPassed (the default), ScoreAsFailed or ScoreAsIgnored.
+	Exceptions string `json:"exceptions" yaml:"exceptions"`
+	// ActionRequired says how a skipped control result that requires action
+	// counts: ScoreAsIgnored (the default), ScoreAsFailed or ScoreAsPassed.
+	ActionRequired string `json:"actionRequired" yaml:"actionRequired"`
+
+	// Source and Digest identify the file the profile was loaded from, so a
+	// report records exactly which profile scored it.
+	Source string `json:"source,omitempty" yaml:"-"`
+	Digest string `json:"digest,omitempty" yaml:"-"`
+}
+
+// ControlScoring overrides how one control is weighed.
+type ControlScoring struct {
+	// Weight replaces the control's severity weight. 0 leaves the control out
+	// of framework and report scores.
+	Weight *float32 `json:"weight,omitempty" yaml:"weight"`
+	// Severity replaces the control's severity when looking up its weight.
+	Severity string `json:"severity,omitempty" yaml:"severity"`
+}
+
+// NamespaceScoring multiplies the weight of the resources in the namespaces
+// matching Pattern, a glob such as "prod-*".
+type NamespaceScoring struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Multiplier defaults to 1 when omitted. 0 leaves the namespaces' resources
+	// out of their controls' scores.
+	Multiplier *float32 `json:"multiplier" yaml:"multiplier"`
+}
+
+// LoadScoringProfile reads and validates the scoring profile at file. It
+// returns nil when file is empty.
+func LoadScoringProfile(file string) (*ScoringProfile, error) {
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scoring profile: %w", err)
+	}
+	profile := &ScoringProfile{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(profile); err != nil {
+		return nil, fmt.Errorf("invalid scoring profile %s: %w", file, err)
+	}
+	if err := profile.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scoring profile %s: %w", file, err)
+	}
+	digest := sha256.Sum256(data)
+	profile.Source = file
+	profile.Digest = "sha256:" + hex.EncodeToString(digest[:])
+	return profile, nil
+}
+
+// validate checks the profile and fills in its defaults. Severities are
+// matched case-insensitively.
+func (p *ScoringProfile) validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	weights := make(map[string]float32, len(p.SeverityWeights))
+	for severity, weight := range p.SeverityWeights {
+		severity = strings.ToLower(severity)
+		if !slices.Contains(scoringSeverities, severity) {
+			return fmt.Errorf("unknown severity %q in severityWeights, expected one of %s", severity, strings.Join(scoringSeverities, ", "))
+		}
+		if weight < 0 {
+			return fmt.Errorf("negative weight %v for severity %s", weight, severity)
+		}
+		weights[severity] = weight
+	}
+	p.SeverityWeights = weights
+
+	for id, control := range p.Controls {
+		if control.Weight != nil && *control.Weight < 0 {
+			return fmt.Errorf("negative weight %v for control %s", *control.Weight, id)
+		}
+		if control.Severity != "" && !slices.Contains(scoringSeverities, strings.ToLower(control.Severity)) {
+			return fmt.Errorf("unknown severity %q for control %s, expected one of %s", control.Severity, id, strings.Join(scoringSeverities, ", "))
+		}
+	}
+
+	for i, ns := range p.Namespaces {
+		if ns.Pattern == "" {
+			return errors.New("namespace pattern is required")
+		}
+		if _, err := path.Match(ns.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q: %w", ns.Pattern, err)
+		}
+		if ns.Multiplier == nil {
+			one := float32(1)
+			p.Namespaces[i].Multiplier = &one
+		} else if *ns.Multiplier < 0 {
+			return fmt.Errorf("negative multiplier %v for namespace pattern %q", *ns.Multiplier, ns.Pattern)
+		}
+	}
+
+	var err error
+	if p.Exceptions, err = scoringTreatment("exceptions", p.Exceptions, ScoreAsPassed); err != nil {
+		return err
+	}
+	if p.ActionRequired, err = scoringTreatment("actionRequired", p.ActionRequired, ScoreAsIgnored); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scoringTreatment(field, value, defaultValue string) (string, error) {
+	switch value = strings.ToLower(value); value {
+	case "":
+		return defaultValue, nil
+	case ScoreAsPassed, ScoreAsFailed, ScoreAsIgnored:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q, expected %s, %s or %s", field, value, ScoreAsPassed, ScoreAsFailed, ScoreAsIgnored)
+	}
+}
+
+// ControlWeight is the weight of a control of the given severity in framework
+// and report scores.
+func (p *ScoringProfile) ControlWeight(controlID, severity string) float32 {
+	override := p.Controls[controlID]
+	if override.Weight != nil {
+		return *override.Weight
+	}
+	if override.Severity != "" {
+		severity = override.Severity
+	}
+	if weight, ok := p.SeverityWeights[strings.ToLower(severity)]; ok {
+		return weight
+	}
+	return 1
+}
+
+// NamespaceMultiplier is the weight of a resource in namespace in its
+// controls' scores. Cluster-scoped resources, in no namespace, weigh 1.
+func (p *ScoringProfile) NamespaceMultiplier(namespace string) float32 {
+	if namespace == "" {
+		return 1
+	}
+	for _, ns := range p.Namespaces {
+		if ok, _ := path.Match(ns.Pattern, namespace); ok {
+			if ns.Multiplier == nil {
+				return 1
+			}
+			return *ns.Multiplier
+		}
+	}
+	return 1
+}
@@ -0,0 +1,90 @@
+package cautils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeScoringProfile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profile.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadScoringProfile(t *testing.T) {
+	path := writeScoringProfile(t, `
+name: risk-committee
+severityWeights: {Critical: 10, high: 5, medium: 2}
+controls:
+  C-0017: {weight: 0}
+  C-0002: {severity: critical}
+namespaces:
+  - {pattern: "prod-*", multiplier: 3}
+  - {pattern: "*", multiplier: 0.5}
+actionRequired: failed
+`)
+
+	profile, err := LoadScoringProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "risk-committee", profile.Name)
+	assert.Equal(t, path, profile.Source)
+	assert.True(t, strings.HasPrefix(profile.Digest, "sha256:"))
+	assert.Equal(t, ScoreAsPassed, profile.Exceptions, "exceptions count as passed by default")
+	assert.Equal(t, ScoreAsFailed, profile.ActionRequired)
+
+	assert.Equal(t, float32(10), profile.ControlWeight("C-0001", "Critical"), "severities are matched case-insensitively")
+	assert.Equal(t, float32(0), profile.ControlWeight("C-0017", "Low"), "a control weight overrides its severity")
+	assert.Equal(t, float32(10), profile.ControlWeight("C-0002", "High"), "a control severity override picks the severity weight")
+	assert.Equal(t, float32(1), profile.ControlWeight("C-0003", "Low"), "unlisted severities weigh 1")
+
+	assert.Equal(t, float32(3), profile.NamespaceMultiplier("prod-eu"), "the first matching pattern wins")
+	assert.Equal(t, float32(0.5), profile.NamespaceMultiplier("dev"))
+	assert.Equal(t, float32(1), profile.NamespaceMultiplier(""), "cluster-scoped resources weigh 1")
+}
+
+func TestLoadScoringProfileDefaultsNamespaceMultiplier(t *testing.T) {
+	profile, err := LoadScoringProfile(writeScoringProfile(t, `
+name: p
+namespaces:
+  - {pattern: "prod-*"}
+  - {pattern: "sandbox-*", multiplier: 0}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, float32(1), profile.NamespaceMultiplier("prod-eu"), "an omitted multiplier weighs 1")
+	assert.Equal(t, float32(0), profile.NamespaceMultiplier("sandbox-1"), "an explicit 0 leaves the namespace out")
+}
+
+func TestLoadScoringProfileWithoutPath(t *testing.T) {
+	profile, err := LoadScoringProfile("")
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+}
+
+func TestLoadScoringProfileRejectsInvalidProfiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantError string
+	}{
+		{name: "missing name", content: "severityWeights: {high: 2}", wantError: "name is required"},
+		{name: "unknown field", content: "name: p\nweights: {high: 2}", wantError: "field weights not found"},
+		{name: "unknown severity", content: "name: p\nseverityWeights: {severe: 2}", wantError: `unknown severity "severe"`},
+		{name: "negative weight", content: "name: p\ncontrols: {C-0001: {weight: -1}}", wantError: "negative weight -1 for control C-0001"},
+		{name: "negative multiplier", content: "name: p\nnamespaces: [{pattern: \"prod-*\", multiplier: -2}]", wantError: "negative multiplier -2"},
+		{name: "bad pattern", content: "name: p\nnamespaces: [{pattern: \"prod-[\", multiplier: 2}]", wantError: "invalid namespace pattern"},
+		{name: "bad treatment", content: "name: p\nexceptions: sometimes", wantError: `invalid exceptions "sometimes"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadScoringProfile(writeScoringProfile(t, tt.content))
+			require.ErrorContains(t, err, tt.wantError)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/admission"
+	"github.com/kubescape/kubescape/v4/core/pkg/policyhandler"
+	"github.com/kubescape/kubescape/v4/core/pkg/watch"
+)
+
+// ServeAdmission serves a validating admission webhook. Each reviewed object
+// is scanned as a single resource by the OPA processor, with the related
+// objects its controls need listed from informer caches and the cluster's
+// SecurityException objects applied, and the failed controls deny, warn or
+// audit the request according to their mode. With replay files, the reviews
+// they hold are answered and printed instead.
+func (ks *Kubescape) ServeAdmission(ctx context.Context, admissionInfo *metav1.AdmissionInfo, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) error {
+	modes, err := admission.NewModes(admissionInfo.DefaultMode, admissionInfo.Enforce, admissionInfo.Warn, admissionInfo.Audit)
+	if err != nil {
+		return err
+	}
+	if err := resolveClusterContext(scanInfo); err != nil {
+		return err
+	}
+	k8s := getKubernetesApi()
+	if k8s == nil {
+		return ErrClusterConnection
+	}
+
+	// Reviews list the related objects from the informer caches, and share
+	// the policies downloaded by the first one.
+	scanInfo.DynamicClient = watch.NewClient(ctx, k8s.DynamicClient)
+	scanInfo.Submit.SetBool(false)
+	scanInfo.Local = true
+	scanInfo.HostSensorEnabled.SetBool(false)
+	scanInfo.ScanImages = false
+	scanInfo.EnableStreaming = false
+	sharedPolicies := policyhandler.NewSharedPolicies()
+
+	handler := &admission.Handler{
+		Modes:      modes,
+		FailClosed: admissionInfo.FailClosed,
+		Evaluate: func(ctx context.Context, object map[string]any) (*cautils.OPASessionObj, error) {
+			reviewScanInfo := scanInfo.ForRescan()
+			reviewScanInfo.AdmissionObject = object
+			results, err := ks.ScanContext(policyhandler.WithSharedPolicies(ctx, sharedPolicies), reviewScanInfo, slices.Clone(policyIdentifiers))
+			if err != nil {
+				return nil, err
+			}
+			return results.GetData(), nil
+		},
+	}
+
+	if len(admissionInfo.ReplayFiles) > 0 {
+		return replayAdmissionReviews(ctx, handler, admissionInfo.ReplayFiles)
+	}
+	return admission.Serve(ctx, admissionInfo.Addr, admissionInfo.CertFile, admissionInfo.KeyFile, handler)
+}
+
+// replayAdmissionReviews prints the review answered for each file, one JSON
+// document per line.
+func replayAdmissionReviews(ctx context.Context, handler *admission.Handler, files []string) error {
+	encoder := json.NewEncoder(os.Stdout)
+	var errs error
+	for _, file := range files {
+		review, err := handler.ReplayFile(ctx, file)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if err := encoder.Encode(review); err != nil {
+			return fmt.Errorf("failed to write the admission response: %w", err)
+		}
+	}
+	return errs
+}
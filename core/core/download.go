@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -14,6 +15,8 @@ import (
 	"github.com/kubescape/kubescape/v4/core/cautils"
 	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor"
+	"github.com/kubescape/opa-utils/reporthandling"
 )
 
 const (
@@ -65,6 +68,8 @@ var (
 	configInputsGetterFunc = getConfigInputsGetter
 	tenantConfigFunc       = cautils.GetTenantConfig
 	kubernetesAPIFunc      = getKubernetesApi
+	pushPolicyBundleFunc   = getter.PushOCIPolicyBundle
+	openRuleCacheFunc      = opaprocessor.OpenRuleCache
 )
 
 func DownloadSupportCommands() []string {
@@ -79,6 +84,12 @@ func DownloadSupportCommands() []string {
 }
 
 func (ks *Kubescape) Download(downloadInfo *metav1.DownloadInfo) (*metav1.DownloadResult, error) {
+	if downloadInfo.Push != "" && downloadInfo.Target != TargetArtifacts {
+		return nil, fmt.Errorf("only %s can be pushed as a policy bundle", TargetArtifacts)
+	}
+	if downloadInfo.WarmCache && downloadInfo.Target != TargetArtifacts && downloadInfo.Target != TargetFramework && downloadInfo.Target != TargetControl {
+		return nil, fmt.Errorf("only %s, %s and %s have rules to compile into the rule cache", TargetArtifacts, TargetFramework, TargetControl)
+	}
 	setPathAndFilename(downloadInfo)
 	if err := os.MkdirAll(downloadInfo.Path, downloadDirPerm); err != nil {
 		return nil, fmt.Errorf("failed to create download directory %q: %w", downloadInfo.Path, err)
@@ -87,7 +98,54 @@ func (ks *Kubescape) Download(downloadInfo *metav1.DownloadInfo) (*metav1.Downlo
 	if err != nil {
 		return nil, err
 	}
-	return &metav1.DownloadResult{Files: files}, nil
+	result := &metav1.DownloadResult{Files: files}
+	if downloadInfo.Push != "" {
+		// a partial download returned an error above, so only complete bundles are pushed
+		if result.Digest, err = pushPolicyBundleFunc(ks.Context(), downloadInfo.Push, files); err != nil {
+			return nil, err
+		}
+		logger.L().Success("Pushed policy bundle", helpers.String("reference", downloadInfo.Push), helpers.String("digest", result.Digest))
+	}
+	if downloadInfo.WarmCache {
+		if result.Warmed, err = warmRuleCache(ks.Context(), files); err != nil {
+			return nil, err
+		}
+		logger.L().Success("Compiled rules into the rule cache", helpers.Int("rules", result.Warmed))
+	}
+	return result, nil
+}
+
+// warmRuleCache compiles the rules of the controls and frameworks saved in
+// files into the rule cache, so that the next scan loads them instead of
+// compiling them. Files holding other artifacts are skipped. A rule that fails
+// to compile is only logged (the scan reports it as it would without a
+// cache), but a cache that cannot be written fails the download.
+func warmRuleCache(ctx context.Context, files []string) (int, error) {
+	var rules []reporthandling.PolicyRule
+	for _, file := range files {
+		buf, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return 0, err
+		}
+		// same detection as LoadPolicy.ListControls: a control has a ControlID
+		var control reporthandling.Control
+		if err := json.Unmarshal(buf, &control); err == nil && control.ControlID != "" {
+			rules = append(rules, control.Rules...)
+			continue
+		}
+		var framework reporthandling.Framework
+		if err := json.Unmarshal(buf, &framework); err == nil && framework.Name != "" {
+			for _, control := range framework.Controls {
+				rules = append(rules, control.Rules...)
+			}
+		}
+	}
+
+	ruleCache, err := openRuleCacheFunc(ctx, getter.DefaultLocalStore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open the rule cache: %w", err)
+	}
+	return opaprocessor.WarmRuleCache(ctx, ruleCache, rules)
 }
 
 func downloadArtifact(ctx context.Context, downloadInfo *metav1.DownloadInfo, downloadArtifactFunc map[string]func(context.Context, *metav1.DownloadInfo) ([]string, error)) ([]string, error) {
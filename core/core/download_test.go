@@ -14,6 +14,7 @@ import (
 	"github.com/kubescape/kubescape/v4/core/cautils"
 	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/rulecache"
 	"github.com/kubescape/opa-utils/reporthandling"
 	"github.com/kubescape/opa-utils/reporthandling/attacktrack/v1alpha1"
 	"github.com/stretchr/testify/assert"
@@ -396,6 +397,107 @@ func TestDownload_ArtifactsJSONOutputWritesToCurrentDirectory(t *testing.T) {
 	}
 }
 
+func TestDownload_PushesArtifactsAsPolicyBundle(t *testing.T) {
+	withTenantConfig(t, &fakeTenantConfig{})
+	withConfigInputsGetter(t, &fakeControlsInputsGetter{inputs: map[string][]string{"a": {"1"}}}, nil)
+	withExceptionsGetter(t, &fakeExceptionsGetter{exceptions: []armotypes.PostureExceptionPolicy{{}}}, nil)
+	withAttackTracksGetter(t, &fakeAttackTracksGetter{tracks: []v1alpha1.AttackTrack{{}}}, nil)
+	withPolicyGetter(t, &fakePolicyGetter{frameworks: []reporthandling.Framework{{PortalBase: armotypes.PortalBase{Name: "nsa"}}}}, nil)
+
+	var pushedRef string
+	var pushedFiles []string
+	origPush := pushPolicyBundleFunc
+	pushPolicyBundleFunc = func(_ context.Context, reference string, files []string) (string, error) {
+		pushedRef, pushedFiles = reference, files
+		return "sha256:abc", nil
+	}
+	t.Cleanup(func() { pushPolicyBundleFunc = origPush })
+
+	dir := t.TempDir()
+	ks := NewKubescape(context.Background())
+	res, err := ks.Download(&metav1.DownloadInfo{
+		Target: TargetArtifacts,
+		Path:   dir,
+		Push:   "oci://registry.example.com/kubescape/policies:v1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", res.Digest)
+	assert.Equal(t, "oci://registry.example.com/kubescape/policies:v1", pushedRef)
+	assert.ElementsMatch(t, res.Files, pushedFiles)
+	assert.Len(t, pushedFiles, 4)
+
+	t.Run("rejects pushing other targets", func(t *testing.T) {
+		_, err := ks.Download(&metav1.DownloadInfo{Target: TargetFramework, Path: dir, Push: pushedRef})
+		require.ErrorContains(t, err, "only artifacts can be pushed")
+	})
+
+	t.Run("does not push partial downloads", func(t *testing.T) {
+		pushedFiles = nil
+		withExceptionsGetter(t, &fakeExceptionsGetter{}, errors.New("unreachable"))
+		_, err := ks.Download(&metav1.DownloadInfo{Target: TargetArtifacts, Path: dir, Push: pushedRef})
+		require.Error(t, err)
+		assert.Nil(t, pushedFiles)
+	})
+}
+
+func TestDownload_WarmsRuleCache(t *testing.T) {
+	rule := reporthandling.PolicyRule{
+		PortalBase:   armotypes.PortalBase{Name: "host-network"},
+		RuleLanguage: reporthandling.RegoLanguage,
+		Rule: `package armo_builtins
+
+deny contains msga if {
+	some pod in input
+	pod.spec.hostNetwork
+	msga := {"alertMessage": "host network", "alertObject": {"k8sApiObjects": [pod]}}
+}
+`,
+	}
+	control := reporthandling.Control{ControlID: "C-0041", Rules: []reporthandling.PolicyRule{rule}}
+	withTenantConfig(t, &fakeTenantConfig{})
+	withPolicyGetter(t, &fakePolicyGetter{
+		framework: &reporthandling.Framework{PortalBase: armotypes.PortalBase{Name: "nsa"}, Controls: []reporthandling.Control{control}},
+		control:   &control,
+	}, nil)
+
+	cacheDir := t.TempDir()
+	origOpen := openRuleCacheFunc
+	openRuleCacheFunc = func(ctx context.Context, _ string) (*rulecache.Store, error) {
+		return origOpen(ctx, cacheDir)
+	}
+	t.Cleanup(func() { openRuleCacheFunc = origOpen })
+
+	ks := NewKubescape(context.Background())
+	for target, identifier := range map[string]string{TargetFramework: "nsa", TargetControl: "C-0041"} {
+		t.Run(target, func(t *testing.T) {
+			require.NoError(t, rulecache.Delete(cacheDir))
+			res, err := ks.Download(&metav1.DownloadInfo{Target: target, Identifier: identifier, Path: t.TempDir(), WarmCache: true})
+			require.NoError(t, err)
+			assert.Equal(t, 1, res.Warmed)
+			entries, err := filepath.Glob(filepath.Join(cacheDir, "rule-cache", "*", "*.json"))
+			require.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+
+	t.Run("rejects targets without rules", func(t *testing.T) {
+		_, err := ks.Download(&metav1.DownloadInfo{Target: TargetExceptions, Path: t.TempDir(), WarmCache: true})
+		require.ErrorContains(t, err, "have rules to compile")
+	})
+
+	t.Run("fails when the cache cannot be written", func(t *testing.T) {
+		unwritable := t.TempDir()
+		openRuleCacheFunc = func(ctx context.Context, _ string) (*rulecache.Store, error) {
+			store, err := origOpen(ctx, unwritable)
+			require.NoError(t, err)
+			require.NoError(t, os.RemoveAll(unwritable))
+			return store, nil
+		}
+		_, err := ks.Download(&metav1.DownloadInfo{Target: TargetControl, Identifier: "C-0041", Path: t.TempDir(), WarmCache: true})
+		require.ErrorContains(t, err, "failed to write the rule cache")
+	})
+}
+
 // ---------------------------------------------------------------------------
 // Fakes for the getter interfaces, used together with the policyGetterFunc /
 // exceptionsGetterFunc / attackTracksGetterFunc / configInputsGetterFunc /
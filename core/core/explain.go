@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+)
+
+// Explain scans the single resource of scanInfo with one control and writes
+// how the control's rules reached their verdicts on it: the rules' input
+// document and Rego trace or CEL sub-expression values, the control inputs
+// they read, the failed and fix paths, and the exceptions that matched or
+// nearly matched.
+func (ks *Kubescape) Explain(ctx context.Context, explainInfo *metav1.ExplainInfo, scanInfo *cautils.ScanInfo) error {
+	if err := cautils.ValidateExplainTrace(explainInfo.Trace); err != nil {
+		return err
+	}
+	if explainInfo.Format != printer.PrettyFormat && explainInfo.Format != printer.JsonFormat {
+		return fmt.Errorf("invalid format %q, expected %s or %s", explainInfo.Format, printer.PrettyFormat, printer.JsonFormat)
+	}
+	if scanInfo.ScanObject == nil {
+		return fmt.Errorf("no resource to explain")
+	}
+
+	scanInfo.ExplainTrace = explainInfo.Trace
+	scanInfo.SetScanType(cautils.ScanTypeControl)
+	scanInfo.Submit.SetBool(false)
+	scanInfo.Local = true
+	scanInfo.HostSensorEnabled.SetBool(false)
+	scanInfo.ScanImages = false
+	scanInfo.EnableStreaming = false
+	scanInfo.Silent = true
+
+	results, err := ks.ScanContext(ctx, scanInfo, cautils.BuildPolicyIdentifiers([]string{explainInfo.ControlID}, apisv1.KindControl))
+	if err != nil {
+		return err
+	}
+	explanations := results.GetData().Explanations
+	if len(explanations) == 0 {
+		return fmt.Errorf("%s %s was not found", scanInfo.ScanObject.GetKind(), scanInfo.ScanObject.GetName())
+	}
+
+	var out io.Writer = os.Stdout
+	if explainInfo.Output != "" {
+		f, err := os.Create(filepath.Clean(explainInfo.Output))
+		if err != nil {
+			return fmt.Errorf("failed to create the explanation output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if explainInfo.Format == printer.JsonFormat {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(explanations)
+	}
+	return writeExplanations(out, explanations)
+}
+
+// writeExplanations writes explanations for a reader: the verdict of each
+// control, then how each rule reached its own.
+func writeExplanations(out io.Writer, explanations []cautils.ControlExplanation) error {
+	var b strings.Builder
+	for i, control := range explanations {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s %s: %s\n", control.ControlID, control.Name, control.Status)
+		fmt.Fprintf(&b, "Resource: %s\n", control.ResourceID)
+
+		for _, rule := range control.Rules {
+			fmt.Fprintf(&b, "\nRule %s (%s): %s\n", rule.Name, rule.Language, rule.Status)
+			if rule.Reason != "" {
+				fmt.Fprintf(&b, "  %s\n", rule.Reason)
+			}
+			if len(rule.Inputs) > 0 {
+				inputs, err := json.Marshal(rule.Inputs)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(&b, "  Control inputs: %s", inputs)
+				if rule.Scope != "" {
+					fmt.Fprintf(&b, " (scope %s)", rule.Scope)
+				}
+				b.WriteString("\n")
+			}
+			writePaths(&b, rule)
+			if len(rule.Input) > 0 {
+				input, err := json.MarshalIndent(rule.Input, "    ", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(&b, "  Input:\n    %s\n", input)
+			}
+			if len(rule.Trace) > 0 {
+				b.WriteString("  Trace:\n")
+				for _, line := range rule.Trace {
+					fmt.Fprintf(&b, "    %s\n", line)
+				}
+			}
+			for _, trace := range rule.CEL {
+				writeExpressionTrace(&b, trace)
+			}
+		}
+
+		if len(control.Exceptions) > 0 {
+			b.WriteString("\nExceptions:\n")
+			for _, exception := range control.Exceptions {
+				if exception.Matched {
+					fmt.Fprintf(&b, "  %s: matched\n", exception.Name)
+					continue
+				}
+				fmt.Fprintf(&b, "  %s: did not match\n", exception.Name)
+				for _, reason := range exception.Reasons {
+					fmt.Fprintf(&b, "    - %s\n", reason)
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func writePaths(b *strings.Builder, rule cautils.RuleExplanation) {
+	for _, path := range rule.Paths {
+		switch {
+		case path.FailedPath != "":
+			fmt.Fprintf(b, "  Failed path: %s\n", path.FailedPath)
+		case path.FixPath.Path != "":
+			fmt.Fprintf(b, "  Fix path: %s=%s\n", path.FixPath.Path, path.FixPath.Value)
+		case path.ReviewPath != "":
+			fmt.Fprintf(b, "  Review path: %s\n", path.ReviewPath)
+		case path.DeletePath != "":
+			fmt.Fprintf(b, "  Delete path: %s\n", path.DeletePath)
+		}
+	}
+}
+
+func writeExpressionTrace(b *strings.Builder, trace cautils.ExpressionTrace) {
+	switch {
+	case trace.Variable != "":
+		fmt.Fprintf(b, "  Variable %s: %s\n", trace.Variable, trace.Expression)
+	case trace.Passed != nil && *trace.Passed:
+		fmt.Fprintf(b, "  Validation passed: %s\n", trace.Expression)
+	case trace.Passed != nil:
+		fmt.Fprintf(b, "  Validation failed: %s\n", trace.Expression)
+		if trace.Message != "" {
+			fmt.Fprintf(b, "    message: %s\n", trace.Message)
+		}
+	default:
+		fmt.Fprintf(b, "  Validation: %s\n", trace.Expression)
+	}
+	if trace.Error != "" {
+		fmt.Fprintf(b, "    error: %s\n", trace.Error)
+	}
+	for _, value := range trace.Values {
+		if value.Error != "" {
+			fmt.Fprintf(b, "    %s => error: %s\n", value.Expression, value.Error)
+			continue
+		}
+		data, err := json.Marshal(value.Value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value.Value))
+		}
+		fmt.Fprintf(b, "    %s => %s\n", value.Expression, data)
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteExplanations(t *testing.T) {
+	passed, failed := true, false
+	var out strings.Builder
+	require.NoError(t, writeExplanations(&out, []cautils.ControlExplanation{{
+		ControlID:  "C-0017",
+		Name:       "Immutable container filesystem",
+		ResourceID: "/v1/default/Pod/web",
+		Status:     apis.StatusFailed,
+		Rules: []cautils.RuleExplanation{
+			{
+				Name:     "immutable-container-filesystem",
+				Language: "Rego",
+				Status:   apis.StatusFailed,
+				Inputs:   map[string][]string{"allowedRegistries": {"quay.io/"}},
+				Scope:    "payments",
+				Paths: []armotypes.PosturePaths{
+					{FailedPath: "spec.containers[0].securityContext.readOnlyRootFilesystem"},
+					{FixPath: armotypes.FixPath{Path: "spec.containers[0].securityContext.readOnlyRootFilesystem", Value: "true"}},
+				},
+				Trace: []string{`query:1     | Note "container web is mutable"`},
+			},
+			{
+				Name:     "immutable-container-filesystem-cel",
+				Language: "CEL",
+				Status:   apis.StatusFailed,
+				CEL: []cautils.ExpressionTrace{
+					{Variable: "containers", Expression: "object.spec.containers"},
+					{Expression: "variables.containers.size() > 0", Passed: &passed},
+					{
+						Expression: "variables.containers.all(c, c.securityContext.readOnlyRootFilesystem)",
+						Passed:     &failed,
+						Message:    "containers must have an immutable filesystem",
+						Values:     []cautils.SubExpressionValue{{Expression: "variables.containers.all(c, c.securityContext.readOnlyRootFilesystem)", Value: false}},
+					},
+				},
+			},
+			{Name: "services-only", Language: "Rego", Status: apis.StatusSkipped, Reason: "the rule does not match Pod objects"},
+		},
+		Exceptions: []cautils.ExceptionExplanation{
+			{Name: "web", Matched: true},
+			{Name: "system", Reasons: []string{`its namespace is "kube-system", the resource's is "default"`}},
+		},
+	}}))
+
+	for _, line := range []string{
+		"C-0017 Immutable container filesystem: failed",
+		"Resource: /v1/default/Pod/web",
+		"Rule immutable-container-filesystem (Rego): failed",
+		`  Control inputs: {"allowedRegistries":["quay.io/"]} (scope payments)`,
+		"  Failed path: spec.containers[0].securityContext.readOnlyRootFilesystem",
+		"  Fix path: spec.containers[0].securityContext.readOnlyRootFilesystem=true",
+		`    query:1     | Note "container web is mutable"`,
+		"  Variable containers: object.spec.containers",
+		"  Validation passed: variables.containers.size() > 0",
+		"  Validation failed: variables.containers.all(c, c.securityContext.readOnlyRootFilesystem)",
+		"    message: containers must have an immutable filesystem",
+		"    variables.containers.all(c, c.securityContext.readOnlyRootFilesystem) => false",
+		"Rule services-only (Rego): skipped",
+		"  the rule does not match Pod objects",
+		"  web: matched",
+		"  system: did not match",
+		`    - its namespace is "kube-system", the resource's is "default"`,
+	} {
+		assert.Contains(t, out.String(), line+"\n")
+	}
+}
+
+func TestExplain_RejectsBadArguments(t *testing.T) {
+	ks := &Kubescape{}
+	assert.ErrorContains(t, ks.Explain(context.Background(), &metav1.ExplainInfo{ControlID: "C-0017", Trace: "all", Format: "pretty-printer"}, &cautils.ScanInfo{}), `invalid trace mode "all"`)
+	assert.ErrorContains(t, ks.Explain(context.Background(), &metav1.ExplainInfo{ControlID: "C-0017", Trace: "fails", Format: "sarif"}, &cautils.ScanInfo{}), `invalid format "sarif"`)
+}
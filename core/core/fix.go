@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
 	"github.com/kubescape/kubescape/v4/core/pkg/fixhandler"
+	"github.com/kubescape/kubescape/v4/core/pkg/fixhandler/pullrequest"
 	"github.com/mattn/go-isatty"
 )
 
@@ -110,9 +113,92 @@ func (ks *Kubescape) Fix(fixInfo *metav1.FixInfo) error {
 		return fmt.Errorf("failed to fix some resources, check the logs for more details")
 	}
 
+	if fixInfo.OpenPR {
+		return ks.openFixPullRequest(fixInfo, handler, plannedFiles)
+	}
+
 	return nil
 }
 
+// openFixPullRequest publishes the files Fix just wrote as a pull request on
+// the forge recorded in the report's repository context. It only runs after a
+// fully successful apply: a pull request carrying half the planned fixes would
+// read as if kubescape had nothing more to say about the other files.
+func (ks *Kubescape) openFixPullRequest(fixInfo *metav1.FixInfo, handler *fixhandler.FixHandler, files map[string]bool) error {
+	repo := handler.RepoContext()
+	if repo == nil || repo.Provider == "" || repo.Provider == "none" {
+		return fmt.Errorf("--open-pr requires a report from a scan of a git repository with a GitHub or GitLab remote; the fixes were applied locally")
+	}
+
+	token := pullrequest.TokenFromEnv(repo.Provider)
+	forge, err := pullrequest.NewForge(repo.Provider, fixInfo.ForgeAPIURL, repo.Owner, repo.Repo, token)
+	if err != nil {
+		return fmt.Errorf("%w; the fixes were applied locally", err)
+	}
+
+	paths := make([]string, 0, len(files))
+	for f := range files {
+		paths = append(paths, f)
+	}
+	sort.Strings(paths)
+
+	fixed := handler.FixedControls()
+	fixedControls := make([]pullrequest.Control, 0, len(fixed))
+	for _, c := range fixed {
+		fixedControls = append(fixedControls, pullrequest.Control{
+			ControlID:    c.ControlID,
+			ControlName:  c.ControlName,
+			ResourceKind: c.ResourceKind,
+			ResourceName: c.ResourceName,
+			FilePath:     relativeToBase(handler.LocalBasePath(), c.FilePath),
+		})
+	}
+	unfixed := handler.UnfixedControls()
+	unfixedControls := make([]pullrequest.Control, 0, len(unfixed))
+	for _, c := range unfixed {
+		unfixedControls = append(unfixedControls, pullrequest.Control{
+			ControlID:    c.ControlID,
+			ControlName:  c.ControlName,
+			ResourceKind: c.ResourceKind,
+			ResourceName: c.ResourceName,
+			FilePath:     relativeToBase(handler.LocalBasePath(), c.FilePath),
+			Reason:       c.Reason,
+		})
+	}
+
+	logger.L().Info("Opening a pull request with the applied fixes...")
+	result, err := pullrequest.Open(ks.Context(), forge, token, pullrequest.Options{
+		RepoPath: handler.LocalBasePath(),
+		Files:    paths,
+		Branch:   fixInfo.PRBranch,
+		Base:     fixInfo.PRBaseBranch,
+		Remote:   fixInfo.PRRemote,
+		Provider: repo.Provider,
+		Fixed:    fixedControls,
+		Unfixed:  unfixedControls,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open pull request: %w", err)
+	}
+
+	logger.L().Success("Opened pull request", helpers.String("url", result.URL))
+	return nil
+}
+
+// relativeToBase shortens an absolute file path to the repository-relative
+// form reviewers see in the pull request diff. Paths it cannot relativize
+// (already relative, or outside base) are returned unchanged.
+func relativeToBase(base, path string) string {
+	if base == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
 func userConfirmed() bool {
 	fd := os.Stdin.Fd()
 	if !isTerminal(fd) && !isCygwinTerminal(fd) {
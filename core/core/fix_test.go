@@ -5,12 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/armosec/armoapi-go/armotypes"
+	gitv5 "github.com/go-git/go-git/v5"
+	configv5 "github.com/go-git/go-git/v5/config"
+	plumbingv5 "github.com/go-git/go-git/v5/plumbing"
+	objectv5 "github.com/go-git/go-git/v5/plumbing/object"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
 	"github.com/kubescape/opa-utils/objectsenvelopes/localworkload"
 	"github.com/kubescape/opa-utils/reporthandling"
@@ -335,3 +342,77 @@ func TestFix_ReturnsErrorWhenApplyFails(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestFix_OpenPRRequiresGitRepoContext(t *testing.T) {
+	dir := t.TempDir()
+	reportPath := buildFixableReport(t, dir)
+
+	ks := &Kubescape{Ctx: context.Background()}
+	err := ks.Fix(&metav1.FixInfo{ReportFile: reportPath, NoConfirm: true, OpenPR: true})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a report from a scan of a git repository")
+	assert.Contains(t, manifestContent(t, dir), "privileged: false", "the local fix is still applied")
+}
+
+func TestFix_OpenPROpensPullRequest(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := filepath.Join(t.TempDir(), "remote.git")
+	_, err := gitv5.PlainInit(remotePath, true)
+	require.NoError(t, err)
+
+	buildFixableReport(t, dir)
+	require.NoError(t, os.Remove(filepath.Join(dir, "report.json")))
+	repo, err := gitv5.PlainInitWithOptions(dir, &gitv5.PlainInitOptions{
+		InitOptions: gitv5.InitOptions{DefaultBranch: plumbingv5.NewBranchReferenceName("main")},
+	})
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&configv5.RemoteConfig{Name: "origin", URLs: []string{remotePath}})
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("deploy.yaml")
+	require.NoError(t, err)
+	_, err = wt.Commit("initial", &gitv5.CommitOptions{Author: &objectv5.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()}})
+	require.NoError(t, err)
+
+	// Re-point the fixable report at the git repository.
+	raw, err := os.ReadFile(buildFixableReport(t, t.TempDir()))
+	require.NoError(t, err)
+	var report reporthandlingv2.PostureReport
+	require.NoError(t, json.Unmarshal(raw, &report))
+	report.Resources[0].Source.Path = dir
+	report.Metadata.ScanMetadata.ScanningTarget = reporthandlingv2.GitLocal
+	report.Metadata.ContextMetadata = reporthandlingv2.ContextMetadata{
+		RepoContextMetadata: &reporthandlingv2.RepoContextMetadata{
+			Provider:      "github",
+			Owner:         "acme",
+			Repo:          "manifests",
+			Branch:        "main",
+			LocalRootPath: dir,
+		},
+	}
+	reportPath := writeReportFile(t, t.TempDir(), &report)
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 3, "html_url": "https://github.example/acme/manifests/pull/3"}`))
+	}))
+	defer srv.Close()
+	t.Setenv("KS_FORGE_TOKEN", "tok")
+
+	ks := &Kubescape{Ctx: context.Background()}
+	err = ks.Fix(&metav1.FixInfo{ReportFile: reportPath, NoConfirm: true, OpenPR: true, PRBranch: "kubescape/fix-c0057", ForgeAPIURL: srv.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, "kubescape/fix-c0057", got["head"])
+	assert.Equal(t, "main", got["base"])
+	assert.Contains(t, got["body"], "| C-0057 Privileged container | Deployment/demo | `deploy.yaml` |")
+
+	remote, err := gitv5.PlainOpen(remotePath)
+	require.NoError(t, err)
+	_, err = remote.Reference(plumbingv5.NewBranchReferenceName("kubescape/fix-c0057"), true)
+	assert.NoError(t, err, "the fix branch is pushed to the remote")
+}
@@ -0,0 +1,302 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/fleet"
+	"github.com/kubescape/kubescape/v4/core/pkg/policyhandler"
+	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling"
+	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+// DefaultFleetParallelism is the number of clusters a fleet scan evaluates at
+// the same time when --fleet-parallelism is not set.
+const DefaultFleetParallelism = 4
+
+// FleetFormats maps the output formats a fleet report supports to the writer
+// producing them. Formats tied to a single cluster's findings (SARIF, SBOMs,
+// PolicyReports) have no fleet equivalent.
+var FleetFormats = map[string]func(io.Writer, *fleet.Report) error{
+	printer.PrettyFormat:      fleet.WritePretty,
+	printer.JsonFormat:        fleet.WriteJSON,
+	printer.YamlFormat:        fleet.WriteYAML,
+	printer.MarkdownFormat:    fleet.WriteMarkdown,
+	printer.HtmlFormat:        fleet.WriteHTML,
+	printer.CsvFormat:         fleet.WriteCSV,
+	printer.JunitResultFormat: fleet.WriteJUnit,
+	printer.PrometheusFormat:  fleet.WritePrometheus,
+	printer.PdfFormat:         fleet.WritePDF,
+}
+
+// ValidateFleetFormats checks that a fleet report can be written in every
+// format to output.
+func ValidateFleetFormats(formats []string, output string) error {
+	for _, format := range formats {
+		if _, ok := FleetFormats[format]; !ok {
+			supported := slices.Sorted(maps.Keys(FleetFormats))
+			return fmt.Errorf("format %q is not supported for fleet reports, supported formats: %s", format, strings.Join(supported, ", "))
+		}
+		if format == printer.PdfFormat && output == "" {
+			return errors.New("the pdf format needs an output file, set it with --output")
+		}
+	}
+	return nil
+}
+
+// clusterConnectionMu serializes the parts of fleet member scans that read
+// the process-wide k8s-interface context: connecting, collecting resources
+// and building the rego dependencies. Rule evaluation runs unlocked.
+var clusterConnectionMu sync.Mutex
+
+type fleetMemberKey struct{}
+
+// fleetMember is carried on the context of each cluster scan in a fleet scan.
+type fleetMember struct {
+	contextName    string
+	releasedPolicy *getter.DownloadReleasedPolicy
+}
+
+func fleetMemberFromContext(ctx context.Context) *fleetMember {
+	member, _ := ctx.Value(fleetMemberKey{}).(*fleetMember)
+	return member
+}
+
+// enterFleetCluster switches the process to the fleet member's kube context
+// and holds clusterConnectionMu until leave is called. It is a no-op outside
+// a fleet scan. leave may be called more than once.
+func enterFleetCluster(ctx context.Context) (leave func()) {
+	member := fleetMemberFromContext(ctx)
+	if member == nil {
+		return func() {}
+	}
+	clusterConnectionMu.Lock()
+	restore := cautils.EnterClusterContext(member.contextName)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			restore()
+			clusterConnectionMu.Unlock()
+		})
+	}
+}
+
+// releasedPolicyForScan returns the GitHub release downloader for a scan.
+// Fleet members share one, so the release is downloaded once per fleet scan.
+func releasedPolicyForScan(ctx context.Context, controlsVersion string) *getter.DownloadReleasedPolicy {
+	if member := fleetMemberFromContext(ctx); member != nil && member.releasedPolicy != nil {
+		return member.releasedPolicy
+	}
+	return getter.NewDownloadReleasedPolicyWithVersion(controlsVersion)
+}
+
+// ScanFleet scans every kubeconfig context selected by scanInfo (--contexts or
+// --all-contexts) with the same policies and returns one report comparing
+// them. A cluster that cannot be scanned is recorded in the report rather
+// than failing the others.
+func (ks *Kubescape) ScanFleet(ctx context.Context, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) (*fleet.Report, error) {
+	contexts := slices.Clone(scanInfo.FleetContexts)
+	if scanInfo.AllContexts {
+		var err error
+		if contexts, err = scanInfo.KubeconfigContexts(); err != nil {
+			return nil, err
+		}
+	}
+	contexts = dedupeContexts(contexts)
+	if len(contexts) == 0 {
+		return nil, errors.New("no kubeconfig contexts to scan")
+	}
+
+	parallelism := scanInfo.FleetParallelism
+	if parallelism <= 0 {
+		parallelism = DefaultFleetParallelism
+	}
+
+	ctx = policyhandler.WithSharedPolicies(ctx, policyhandler.NewSharedPolicies())
+	var releasedPolicy *getter.DownloadReleasedPolicy
+	if !isAirGappedMode(scanInfo) {
+		releasedPolicy = getter.NewDownloadReleasedPolicyWithVersion(scanInfo.ControlsVersion)
+	}
+
+	logger.L().Info("Scanning fleet", helpers.Int("clusters", len(contexts)), helpers.Int("parallelism", parallelism))
+
+	clusters := make([]fleet.ClusterResult, len(contexts))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, contextName := range contexts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			memberCtx := context.WithValue(ctx, fleetMemberKey{}, &fleetMember{contextName: contextName, releasedPolicy: releasedPolicy})
+			clusters[i] = ks.scanFleetMember(memberCtx, scanInfo.ForClusterContext(contextName), contextName, policyIdentifiers)
+		}()
+	}
+	wg.Wait()
+
+	var frameworks []string
+	for _, policy := range policyIdentifiers {
+		if policy.Kind == apisv1.KindFramework {
+			frameworks = append(frameworks, policy.Identifier)
+		}
+	}
+	return fleet.NewReport(frameworks, clusters), nil
+}
+
+// dedupeContexts drops blank and repeated context names, keeping the order
+// they were given in.
+func dedupeContexts(contexts []string) []string {
+	seen := make(map[string]bool, len(contexts))
+	deduped := make([]string, 0, len(contexts))
+	for _, name := range contexts {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		deduped = append(deduped, name)
+	}
+	return deduped
+}
+
+func (ks *Kubescape) scanFleetMember(ctx context.Context, scanInfo *cautils.ScanInfo, contextName string, policyIdentifiers []cautils.PolicyIdentifier) fleet.ClusterResult {
+	// Members never print: the fleet report replaces their output.
+	scanInfo.Format = printer.PrettyFormat
+	scanInfo.Output = ""
+
+	results, err := ks.ScanContext(ctx, scanInfo, slices.Clone(policyIdentifiers))
+	if err == nil && scanInfo.Submit.GetBool() && results.GetReporter() != nil {
+		err = results.GetReporter().Submit(ctx, results.GetData())
+	}
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("failed to scan cluster", helpers.String("context", contextName), helpers.Error(err))
+		return fleet.ClusterResult{Name: contextName, Error: err.Error()}
+	}
+	logger.L().Success("Scanned cluster", helpers.String("context", contextName))
+	return clusterResult(contextName, results)
+}
+
+// clusterResult reduces a cluster's scan results to the part the fleet
+// report compares across clusters.
+func clusterResult(contextName string, results *resultshandling.ResultsHandler) fleet.ClusterResult {
+	scanData := results.GetData()
+	summary := &scanData.Report.SummaryDetails
+
+	cluster := fleet.ClusterResult{
+		Name:            contextName,
+		ComplianceScore: summary.ComplianceScore,
+		FailedResources: summary.NumberOfResources().Failed(),
+		AllResources:    summary.NumberOfResources().All(),
+	}
+	for _, fw := range summary.ListFrameworks() {
+		cluster.Frameworks = append(cluster.Frameworks, fleet.FrameworkScore{Name: fw.GetName(), ComplianceScore: fw.GetComplianceScore()})
+	}
+	for _, control := range summary.ListControls() {
+		cluster.Controls = append(cluster.Controls, fleet.ControlResult{
+			ID:              control.GetID(),
+			Name:            control.GetName(),
+			Severity:        apis.ControlSeverityToString(control.GetScoreFactor()),
+			Status:          string(control.GetStatus().Status()),
+			ComplianceScore: control.GetComplianceScore(),
+			FailedResources: control.NumberOfResources().Failed(),
+			AllResources:    control.NumberOfResources().All(),
+		})
+	}
+
+	statuses := make([]fleet.ResourceStatus, 0, len(scanData.ResourcesResult))
+	for resourceID, result := range scanData.ResourcesResult {
+		status := fleet.ResourceStatus{ID: resourceID, Controls: map[string]string{}}
+		if obj, ok := scanData.AllResources[resourceID]; ok && obj != nil {
+			status.Kind = obj.GetKind()
+			status.Namespace = obj.GetNamespace()
+			status.Name = obj.GetName()
+		}
+		var failedControls []string
+		for _, control := range result.ListControls() {
+			controlStatus := control.GetStatus(nil)
+			status.Controls[control.GetID()] = string(controlStatus.Status())
+			if controlStatus.IsFailed() {
+				failedControls = append(failedControls, control.GetID())
+			}
+		}
+		statuses = append(statuses, status)
+		if len(failedControls) == 0 {
+			continue
+		}
+		cluster.Resources = append(cluster.Resources, fleet.ResourceResult{
+			ID:             resourceID,
+			Kind:           status.Kind,
+			Namespace:      status.Namespace,
+			Name:           status.Name,
+			FailedControls: failedControls,
+		})
+	}
+	cluster.Namespaces = fleet.NamespaceRollups(statuses)
+	return cluster
+}
+
+// PrintFleetReport writes report in every format. As for single-cluster scans,
+// each format goes to output with the format's extension, or to stdout when no
+// output file is set. PDF is never written to stdout.
+func PrintFleetReport(ctx context.Context, report *fleet.Report, formats []string, output string) error {
+	var errs error
+	for _, format := range formats {
+		write, ok := FleetFormats[format]
+		if !ok {
+			errs = errors.Join(errs, fmt.Errorf("format %q is not supported for fleet scans", format))
+			continue
+		}
+		outputFile, explicit := printer.ResolveOutputFile(format, output, "report")
+		if !explicit && format == printer.PdfFormat {
+			errs = errors.Join(errs, errors.New("the pdf format needs an output file, set it with --output"))
+			continue
+		}
+		if !explicit {
+			errs = errors.Join(errs, write(os.Stdout, report))
+			continue
+		}
+		f, err := printer.GetWriterNoFallback(outputFile)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		err = write(f, report)
+		errs = errors.Join(errs, err, f.Close())
+		if err == nil {
+			logger.L().Ctx(ctx).Success("Fleet report saved", helpers.String("path", outputFile))
+		}
+	}
+	return errs
+}
+
+// MergeReports combines saved JSON scan reports, one per cluster, into a fleet
+// report and writes it in every requested format.
+func (ks *Kubescape) MergeReports(ctx context.Context, mergeInfo *metav1.MergeReportsInfo) (*fleet.Report, error) {
+	reports := make([]*fleet.SavedReport, 0, len(mergeInfo.Files))
+	for _, path := range mergeInfo.Files {
+		saved, err := fleet.LoadReport(path)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, saved)
+	}
+	report, err := fleet.Merge(reports)
+	if err != nil {
+		return nil, err
+	}
+	return report, PrintFleetReport(ctx, report, mergeInfo.Formats(), mergeInfo.Output)
+}
@@ -17,7 +17,9 @@ import (
 	"github.com/distribution/reference"
 
 	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	ksmetav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling"
 	"github.com/kubescape/kubescape/v4/pkg/imagescan"
@@ -356,6 +358,51 @@ func scanWithRegistryMapping(
 	return nil, lastErr
 }
 
+// enableImageResultCache turns on the on-disk image scan result cache when
+// --image-cache is set. The scan still runs without it if the cache cannot be
+// opened.
+func enableImageResultCache(svc *imagescan.Service, scanInfo *cautils.ScanInfo) {
+	if scanInfo == nil || !scanInfo.ImageCache {
+		return
+	}
+	if err := svc.EnableResultCache(getter.DefaultLocalStore); err != nil {
+		logger.L().Warning("Image scan cache disabled", helpers.Error(err))
+	}
+}
+
+// sbomDirScanService matches the pre-generated SBOM --sbom-dir holds for an
+// image instead of pulling it, and scans images without one as usual.
+type sbomDirScanService struct {
+	svc   *imagescan.Service
+	index *imagescan.SBOMIndex
+}
+
+func (s *sbomDirScanService) Scan(ctx context.Context, img string, creds imagescan.RegistryCredentials, vulnExceptions, sevExceptions []string) (*cautils.ImageScanData, error) {
+	return s.ScanWithOptions(ctx, img, creds, vulnExceptions, sevExceptions, imagescan.ScanOptions{})
+}
+
+func (s *sbomDirScanService) ScanWithOptions(ctx context.Context, img string, creds imagescan.RegistryCredentials, vulnExceptions, sevExceptions []string, options imagescan.ScanOptions) (*cautils.ImageScanData, error) {
+	path, ok := s.index.Lookup(img, options.Platform)
+	if !ok {
+		return s.svc.ScanWithOptions(ctx, img, creds, vulnExceptions, sevExceptions, options)
+	}
+	logger.L().Debug("scanning image from SBOM", helpers.String("image", img), helpers.String("sbom", path))
+	return s.svc.ScanSBOM(ctx, path, img, vulnExceptions, sevExceptions)
+}
+
+// newImageScanService returns the service workload image scans go through:
+// the scanner itself, fronted by the --sbom-dir SBOMs when there are any.
+func newImageScanService(svc *imagescan.Service, scanInfo *cautils.ScanInfo) (imageScanService, error) {
+	if scanInfo == nil || scanInfo.SBOMDir == "" {
+		return svc, nil
+	}
+	index, err := imagescan.LoadSBOMIndex(scanInfo.SBOMDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SBOM directory %s: %w", scanInfo.SBOMDir, err)
+	}
+	return &sbomDirScanService{svc: svc, index: index}, nil
+}
+
 // ScanImage scans imgScanInfo.Image using ks.Context() as the operation's
 // context. It is a compatibility wrapper around ScanImageContext for callers
 // that have not migrated to passing their own context explicitly; see
@@ -374,7 +421,20 @@ func (ks *Kubescape) ScanImage(imgScanInfo *ksmetav1.ImageScanInfo, scanInfo *ca
 // safe if the instance is reused or another operation could run
 // concurrently against it.
 func (ks *Kubescape) ScanImageContext(ctx context.Context, imgScanInfo *ksmetav1.ImageScanInfo, scanInfo *cautils.ScanInfo) (bool, error) {
-	logger.L().Start(fmt.Sprintf("Scanning image %s...", imgScanInfo.Image))
+	if imgScanInfo.SBOM != "" && imgScanInfo.Image == "" {
+		// Exceptions target images by name, so the name recorded in the SBOM
+		// is needed before they are loaded.
+		subject, err := imagescan.ReadSBOMSubject(imgScanInfo.SBOM)
+		if err != nil {
+			return false, err
+		}
+		imgScanInfo.Image = subject.Image()
+	}
+	target := imgScanInfo.Image
+	if imgScanInfo.SBOM != "" {
+		target = fmt.Sprintf("%s (from SBOM %s)", imgScanInfo.Image, imgScanInfo.SBOM)
+	}
+	logger.L().Start(fmt.Sprintf("Scanning image %s...", target))
 
 	distCfg, installCfg, shouldUpdate, err := imagescan.NewDefaultDBConfig(scanInfo.ListingURL, scanInfo.SkipDBUpdate)
 	if err != nil {
@@ -387,6 +447,7 @@ func (ks *Kubescape) ScanImageContext(ctx context.Context, imgScanInfo *ksmetav1
 		return false, err
 	}
 	defer svc.Close()
+	enableImageResultCache(svc, scanInfo)
 
 	creds := imagescan.RegistryCredentials{
 		Authority: imgScanInfo.Authority,
@@ -407,16 +468,21 @@ func (ks *Kubescape) ScanImageContext(ctx context.Context, imgScanInfo *ksmetav1
 		vulnerabilityExceptions, severityExceptions = getUniqueVulnerabilitiesAndSeverities(exceptionPolicies, imgScanInfo.Image)
 	}
 
-	imageScanData, err := scanWithRegistryMapping(
-		ctx, svc, imgScanInfo.Image, []imagescan.RegistryCredentials{creds},
-		scanInfo.RegistryMapping, vulnerabilityExceptions, severityExceptions, imgScanInfo.Platform,
-	)
+	var imageScanData *cautils.ImageScanData
+	if imgScanInfo.SBOM != "" {
+		imageScanData, err = svc.ScanSBOM(ctx, imgScanInfo.SBOM, imgScanInfo.Image, vulnerabilityExceptions, severityExceptions)
+	} else {
+		imageScanData, err = scanWithRegistryMapping(
+			ctx, svc, imgScanInfo.Image, []imagescan.RegistryCredentials{creds},
+			scanInfo.RegistryMapping, vulnerabilityExceptions, severityExceptions, imgScanInfo.Platform,
+		)
+	}
 	if err != nil {
-		logger.L().StopError(fmt.Sprintf("Failed to scan image %s: %s", imgScanInfo.Image, err))
+		logger.L().StopError(fmt.Sprintf("Failed to scan image %s: %s", target, err))
 		return false, err
 	}
 
-	logger.L().StopSuccess(fmt.Sprintf("Successfully scanned image: %s", imgScanInfo.Image))
+	logger.L().StopSuccess(fmt.Sprintf("Successfully scanned image: %s", target))
 
 	scanInfo.SetScanType(cautils.ScanTypeImage)
 
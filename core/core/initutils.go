@@ -328,6 +328,57 @@ func getPolicyGetter(ctx context.Context, loadPoliciesFromFile []string, account
 
 }
 
+// getPolicyBundleGetters serves the policies, control inputs, exceptions and
+// attack tracks from the signed OCI policy bundle of --policy-bundle. The ones
+// given as local files still take precedence, and the cluster's
+// SecurityException objects are merged with the bundle's exceptions.
+func getPolicyBundleGetters(ctx context.Context, scanInfo *cautils.ScanInfo, target *k8sinterface.KubernetesApi) (cautils.Getters, error) {
+	bundle, err := getter.PullOCIPolicyBundle(ctx, scanInfo.PolicyBundle, scanInfo.PolicyBundleKey)
+	if err != nil {
+		return cautils.Getters{}, err
+	}
+	getters := cautils.Getters{
+		PolicyGetter:         bundle,
+		ControlsInputsGetter: bundle,
+		AttackTracksGetter:   bundle,
+	}
+	var exceptions getter.IExceptionsGetter = bundle
+	if len(scanInfo.UseFrom) > 0 {
+		getters.PolicyGetter = getter.NewLoadPolicy(scanInfo.UseFrom)
+	}
+	if scanInfo.ControlsInputs != "" {
+		getters.ControlsInputsGetter = getter.NewLoadPolicy([]string{scanInfo.ControlsInputs})
+	}
+	if scanInfo.AttackTracks != "" {
+		getters.AttackTracksGetter = getter.NewLoadPolicy([]string{scanInfo.AttackTracks})
+	}
+	if scanInfo.UseExceptions != "" {
+		exceptions = getter.NewLoadPolicy([]string{scanInfo.UseExceptions})
+	}
+	getters.ExceptionsGetter = getter.NewMergedExceptionsGetter(exceptions, newCRDExceptionsGetter(ctx, target))
+	return getters, nil
+}
+
+// resolveFrameworkDefinitions loads the framework definitions the framework
+// identifiers refer to, keyed by identifier: the definition files they name,
+// and the definitions saved under their name in getter.FrameworkDefinitionsDir.
+func resolveFrameworkDefinitions(policyIdentifiers []cautils.PolicyIdentifier) (map[string]*getter.FrameworkDefinition, error) {
+	definitions := map[string]*getter.FrameworkDefinition{}
+	for _, policy := range policyIdentifiers {
+		if policy.Kind != apisv1.KindFramework {
+			continue
+		}
+		definition, err := getter.ResolveFrameworkDefinition(policy.Identifier, getter.FrameworkDefinitionsDir())
+		if err != nil {
+			return nil, err
+		}
+		if definition != nil {
+			definitions[policy.Identifier] = definition
+		}
+	}
+	return definitions, nil
+}
+
 // setConfigInputsGetter sets the config input getter with the following precedence:
 //  1. Local file (--controls-config flag)
 //  2. Kubescape Cloud API (if accountID configured)
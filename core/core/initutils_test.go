@@ -3,6 +3,8 @@ package core
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -841,3 +843,30 @@ func TestPinnedVersionGettersReturnHardError(t *testing.T) {
 		require.Nil(t, g)
 	})
 }
+
+func TestResolveFrameworkDefinitions(t *testing.T) {
+	origStore := getter.DefaultLocalStore
+	getter.DefaultLocalStore = t.TempDir()
+	t.Cleanup(func() { getter.DefaultLocalStore = origStore })
+
+	const definition = "name: golden-baseline\nsections: [{id: a, controls: [{id: C-0017}]}]\n"
+	require.NoError(t, os.MkdirAll(getter.FrameworkDefinitionsDir(), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(getter.FrameworkDefinitionsDir(), "golden-baseline.yaml"), []byte(definition), 0o600))
+	file := filepath.Join(t.TempDir(), "our-baseline.yml")
+	require.NoError(t, os.WriteFile(file, []byte(definition), 0o600))
+
+	definitions, err := resolveFrameworkDefinitions([]cautils.PolicyIdentifier{
+		{Identifier: "nsa", Kind: apisv1.KindFramework},
+		{Identifier: "golden-baseline", Kind: apisv1.KindFramework},
+		{Identifier: file, Kind: apisv1.KindFramework},
+		{Identifier: "C-0017", Kind: apisv1.KindControl},
+	})
+	require.NoError(t, err)
+	assert.Len(t, definitions, 2)
+	assert.Contains(t, definitions, "golden-baseline")
+	assert.Contains(t, definitions, file)
+	assert.Equal(t, []string{"golden-baseline"}, listFrameworkDefinitions(context.Background()))
+
+	_, err = resolveFrameworkDefinitions([]cautils.PolicyIdentifier{{Identifier: "./missing.yaml", Kind: apisv1.KindFramework}})
+	require.Error(t, err)
+}
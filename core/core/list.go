@@ -6,12 +6,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v2/prettyprinter/tableprinter/utils"
@@ -117,7 +121,21 @@ func listFrameworks(ctx context.Context, listPolicies *metav1.ListPolicies) ([]s
 		return nil, err
 	}
 
-	return listFrameworksNames(policyGetter), nil
+	return append(listFrameworksNames(policyGetter), listFrameworkDefinitions(ctx)...), nil
+}
+
+// listFrameworkDefinitions lists the framework definitions saved in
+// getter.FrameworkDefinitionsDir, which scan by name like frameworks do.
+func listFrameworkDefinitions(ctx context.Context) []string {
+	definitions, err := getter.ListFrameworkDefinitions(getter.FrameworkDefinitionsDir())
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("failed to load framework definitions", helpers.Error(err))
+	}
+	names := make([]string, 0, len(definitions))
+	for _, definition := range definitions {
+		names = append(names, strings.TrimSuffix(filepath.Base(definition.Source), filepath.Ext(definition.Source)))
+	}
+	return names
 }
 
 func listControls(ctx context.Context, listPolicies *metav1.ListPolicies) ([]metav1.ControlListEntry, error) {
@@ -0,0 +1,269 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor"
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor/cel"
+	"github.com/kubescape/kubescape/v4/core/pkg/resourcehandler"
+	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/resources"
+)
+
+// controlParityReport is the parity of one control over the whole corpus.
+type controlParityReport struct {
+	opaprocessor.ControlParity
+	// Error is why the control could not be compared, on any case.
+	Error string `json:"error,omitempty"`
+}
+
+// parityCase is one set of manifests the controls are compared on. A case
+// cut from a regolibrary checkout is a rule's test input, and only the
+// controls using that rule are compared on it.
+type parityCase struct {
+	path string
+	rule string
+}
+
+// RulesParity runs the Rego rules and the CEL policy of every control
+// implemented both ways on the manifests of parityInfo, and writes the
+// resources they disagree on, minimized. It returns the number of
+// disagreements, counting a control that could not be compared as one.
+func (ks *Kubescape) RulesParity(ctx context.Context, parityInfo *metav1.RulesParityInfo) (int, error) {
+	if parityInfo.Format != printer.PrettyFormat && parityInfo.Format != printer.JsonFormat {
+		return 0, fmt.Errorf("invalid format %q, expected %s or %s", parityInfo.Format, printer.PrettyFormat, printer.JsonFormat)
+	}
+	if len(parityInfo.InputPaths) == 0 {
+		return 0, fmt.Errorf("no manifests to compare the controls on")
+	}
+
+	controlIDs := parityInfo.ControlIDs
+	if len(controlIDs) == 0 {
+		var err error
+		if controlIDs, err = cel.ControlIDs(); err != nil {
+			return 0, fmt.Errorf("failed to list the controls of the CEL bundle: %w", err)
+		}
+	}
+
+	policyGetter, err := getPolicyGetter(ctx, parityInfo.UseFrom, "", false, nil, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the Rego controls: %w", err)
+	}
+	inputsGetter, _, err := getConfigInputsGetter(ctx, parityInfo.ControlsInputs, "", nil, false, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the controls configuration: %w", err)
+	}
+	controlInputs, err := inputsGetter.GetControlsInputs(ctx, "")
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("failed to load the controls configuration, comparing with none", helpers.Error(err))
+		controlInputs = map[string][]string{}
+	}
+
+	reports := make([]controlParityReport, 0, len(controlIDs))
+	var controls []reporthandling.Control
+	for _, controlID := range controlIDs {
+		report := controlParityReport{ControlParity: opaprocessor.ControlParity{ControlID: controlID}}
+		control, err := policyGetter.GetControl(controlID)
+		switch {
+		case err != nil:
+			report.Error = fmt.Sprintf("failed to get the Rego control: %v", err)
+		case control == nil:
+			report.Error = "the control is not in the policy library"
+		default:
+			report.ControlID, report.Name = control.ControlID, control.Name
+			controls = append(controls, *control)
+		}
+		reports = append(reports, report)
+	}
+
+	cases, err := parityCases(parityInfo.InputPaths)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range cases {
+		caseControls := controls
+		if c.rule != "" {
+			caseControls = slices.DeleteFunc(slices.Clone(controls), func(control reporthandling.Control) bool {
+				return !slices.ContainsFunc(control.Rules, func(rule reporthandling.PolicyRule) bool { return rule.Name == c.rule })
+			})
+		}
+		if len(caseControls) == 0 {
+			continue
+		}
+		if err := checkCaseParity(ctx, c, caseControls, controlInputs, parityInfo.IgnorePaths, reports); err != nil {
+			return 0, err
+		}
+	}
+
+	divergences := 0
+	for _, report := range reports {
+		divergences += len(report.Divergences)
+		if report.Error != "" {
+			divergences++
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if parityInfo.Output != "" {
+		f, err := os.Create(filepath.Clean(parityInfo.Output))
+		if err != nil {
+			return divergences, fmt.Errorf("failed to create the parity report: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if parityInfo.Format == printer.JsonFormat {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return divergences, encoder.Encode(reports)
+	}
+	return divergences, writeParityReports(out, reports)
+}
+
+// parityCases lists the cases of the corpus. A path holding a regolibrary
+// checkout is split into the test inputs of its rules, so that each
+// resource is evaluated with the resources of its own test only; any other
+// path is a case of its own.
+func parityCases(paths []string) ([]parityCase, error) {
+	var cases []parityCase
+	for _, path := range paths {
+		inputs, err := filepath.Glob(filepath.Join(path, "rules", "*", "test", "*", "input"))
+		if err != nil {
+			return nil, err
+		}
+		if len(inputs) == 0 {
+			cases = append(cases, parityCase{path: path})
+			continue
+		}
+		for _, input := range inputs {
+			rel, err := filepath.Rel(filepath.Join(path, "rules"), input)
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, parityCase{path: input, rule: strings.Split(filepath.ToSlash(rel), "/")[0]})
+		}
+	}
+	return cases, nil
+}
+
+// checkCaseParity loads the manifests of c and compares the controls on
+// them, adding the outcome to the controls' reports.
+func checkCaseParity(ctx context.Context, c parityCase, controls []reporthandling.Control, controlInputs map[string][]string, ignorePaths bool, reports []controlParityReport) error {
+	scanInfo := &cautils.ScanInfo{InputPatterns: []string{c.path}}
+	sessionObj := cautils.NewOPASessionObj(ctx, []reporthandling.Framework{{Controls: controls}}, nil, scanInfo, nil)
+	sessionObj.RegoInputData.PostureControlInputs = controlInputs
+
+	k8sResources, allResources, externalResources, _, err := resourcehandler.NewFileResourceHandler().GetResources(ctx, sessionObj, scanInfo)
+	if err != nil {
+		return fmt.Errorf("failed to load the manifests of %s: %w", c.path, err)
+	}
+	if len(allResources) == 0 {
+		return nil
+	}
+	sessionObj.K8SResources = k8sResources
+	sessionObj.AllResources = allResources
+	sessionObj.ExternalResources = externalResources
+
+	opap := opaprocessor.NewOPAProcessor(sessionObj, resources.NewRegoDependenciesData(k8sinterface.GetK8sConfig(), ""), "", "", "", false, nil)
+	for i := range controls {
+		report := &reports[slices.IndexFunc(reports, func(r controlParityReport) bool { return r.ControlID == controls[i].ControlID })]
+		parity, err := opap.CheckParity(ctx, &controls[i], ignorePaths)
+		if parity.PolicyName != "" {
+			report.PolicyName = parity.PolicyName
+		}
+		if err != nil {
+			if report.Error == "" {
+				report.Error = err.Error()
+			}
+			continue
+		}
+		report.Resources += parity.Resources
+		for _, divergence := range parity.Divergences {
+			divergence.Source = c.path
+			if source, ok := sessionObj.ResourceSource[divergence.ResourceID]; ok && source.RelativePath != "" {
+				divergence.Source = filepath.Join(source.Path, source.RelativePath)
+			}
+			report.Divergences = append(report.Divergences, divergence)
+		}
+	}
+	return nil
+}
+
+// writeParityReports writes the reports for a reader: a line per control,
+// then each disagreement with the minimal resource reproducing it.
+func writeParityReports(out io.Writer, reports []controlParityReport) error {
+	var b strings.Builder
+	for _, report := range reports {
+		switch {
+		case report.Error != "" && report.Resources == 0:
+			fmt.Fprintf(&b, "%s: not compared: %s\n", parityTitle(report), report.Error)
+			continue
+		case len(report.Divergences) == 0:
+			fmt.Fprintf(&b, "%s: agree on %d resources\n", parityTitle(report), report.Resources)
+		default:
+			fmt.Fprintf(&b, "%s: disagree on %d of %d resources\n", parityTitle(report), len(report.Divergences), report.Resources)
+		}
+		if report.Error != "" {
+			fmt.Fprintf(&b, "  not compared on every input: %s\n", report.Error)
+		}
+		for _, divergence := range report.Divergences {
+			fmt.Fprintf(&b, "  %s", divergence.ResourceID)
+			if divergence.Source != "" {
+				fmt.Fprintf(&b, " (%s)", divergence.Source)
+			}
+			b.WriteString("\n")
+			fmt.Fprintf(&b, "    Rego: %s\n", parityStatus(divergence.Rego))
+			fmt.Fprintf(&b, "    CEL (%s): %s\n", report.PolicyName, parityStatus(divergence.CEL))
+			for _, path := range divergence.RegoOnlyPaths {
+				fmt.Fprintf(&b, "    only Rego reports: %s\n", path)
+			}
+			for _, path := range divergence.CELOnlyPaths {
+				fmt.Fprintf(&b, "    only CEL reports: %s\n", path)
+			}
+			if divergence.Resource == nil {
+				continue
+			}
+			resource, err := json.MarshalIndent(divergence.Resource, "      ", "  ")
+			if err != nil {
+				return err
+			}
+			label := "Resource"
+			if divergence.Minimized {
+				label = "Minimal resource"
+			}
+			fmt.Fprintf(&b, "    %s:\n      %s\n", label, resource)
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func parityTitle(report controlParityReport) string {
+	if report.Name == "" {
+		return report.ControlID
+	}
+	return report.ControlID + " " + report.Name
+}
+
+func parityStatus(verdict opaprocessor.ParityVerdict) string {
+	if verdict.Status == "" {
+		return "not evaluated"
+	}
+	if len(verdict.Paths) == 0 {
+		return string(verdict.Status)
+	}
+	return fmt.Sprintf("%s at %s", verdict.Status, strings.Join(verdict.Paths, ", "))
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParityCases(t *testing.T) {
+	library := t.TempDir()
+	for _, dir := range []string{
+		"rules/immutable-container-filesystem/test/pod/input",
+		"rules/immutable-container-filesystem/test/deployment/input",
+		"rules/non-root-containers/test/pod/input",
+		"rules/non-root-containers/raw.rego",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(library, dir), 0o755))
+	}
+	manifests := t.TempDir()
+
+	cases, err := parityCases([]string{library, manifests})
+	require.NoError(t, err)
+	assert.Equal(t, []parityCase{
+		{path: filepath.Join(library, "rules/immutable-container-filesystem/test/deployment/input"), rule: "immutable-container-filesystem"},
+		{path: filepath.Join(library, "rules/immutable-container-filesystem/test/pod/input"), rule: "immutable-container-filesystem"},
+		{path: filepath.Join(library, "rules/non-root-containers/test/pod/input"), rule: "non-root-containers"},
+		{path: manifests},
+	}, cases)
+}
+
+func TestWriteParityReports(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, writeParityReports(&out, []controlParityReport{
+		{ControlParity: opaprocessor.ControlParity{ControlID: "C-0016", Name: "Allow privilege escalation", Resources: 4}},
+		{
+			ControlParity: opaprocessor.ControlParity{
+				ControlID:  "C-0017",
+				Name:       "Immutable container filesystem",
+				PolicyName: "kubescape-c-0017-deny-resources-with-mutable-container-filesystem",
+				Resources:  3,
+				Divergences: []opaprocessor.ParityDivergence{{
+					ResourceID:    "/v1/default/Pod/mutable",
+					Source:        "manifests/pod.yaml",
+					Rego:          opaprocessor.ParityVerdict{Status: apis.StatusFailed, Paths: []string{"spec.containers[0].securityContext"}},
+					CEL:           opaprocessor.ParityVerdict{Status: apis.StatusFailed, Paths: []string{"spec.containers[0].securityContext.readOnlyRootFilesystem"}},
+					RegoOnlyPaths: []string{"spec.containers[0].securityContext"},
+					CELOnlyPaths:  []string{"spec.containers[0].securityContext.readOnlyRootFilesystem"},
+					Resource:      map[string]any{"kind": "Pod"},
+					Minimized:     true,
+				}},
+			},
+		},
+		{ControlParity: opaprocessor.ControlParity{ControlID: "C-0018"}, Error: "the control is not in the policy library"},
+		{ControlParity: opaprocessor.ControlParity{ControlID: "C-0013", Resources: 2, Divergences: []opaprocessor.ParityDivergence{{ResourceID: "/v1/default/Pod/web", CEL: opaprocessor.ParityVerdict{Status: apis.StatusPassed}}}}},
+	}))
+
+	for _, line := range []string{
+		"C-0016 Allow privilege escalation: agree on 4 resources",
+		"C-0017 Immutable container filesystem: disagree on 1 of 3 resources",
+		"  /v1/default/Pod/mutable (manifests/pod.yaml)",
+		"    Rego: failed at spec.containers[0].securityContext",
+		"    CEL (kubescape-c-0017-deny-resources-with-mutable-container-filesystem): failed at spec.containers[0].securityContext.readOnlyRootFilesystem",
+		"    only Rego reports: spec.containers[0].securityContext",
+		"    only CEL reports: spec.containers[0].securityContext.readOnlyRootFilesystem",
+		"    Minimal resource:",
+		`        "kind": "Pod"`,
+		"C-0018: not compared: the control is not in the policy library",
+		"    Rego: not evaluated",
+	} {
+		assert.Contains(t, out.String(), line+"\n")
+	}
+}
+
+func TestRulesParity_RejectsBadArguments(t *testing.T) {
+	ks := &Kubescape{}
+	_, err := ks.RulesParity(context.Background(), &metav1.RulesParityInfo{InputPaths: []string{"."}, Format: "sarif"})
+	assert.ErrorContains(t, err, `invalid format "sarif"`)
+	_, err = ks.RulesParity(context.Background(), &metav1.RulesParityInfo{Format: "json"})
+	assert.ErrorContains(t, err, "no manifests")
+}
@@ -28,7 +28,9 @@ import (
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/reporter"
+	"github.com/kubescape/kubescape/v4/core/pkg/rulecache"
 	"github.com/kubescape/kubescape/v4/core/pkg/scancache"
+	"github.com/kubescape/kubescape/v4/core/pkg/shardworker"
 	"github.com/kubescape/kubescape/v4/pkg/imagescan"
 	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
 	"github.com/kubescape/opa-utils/resources"
@@ -66,6 +68,9 @@ func getInterfaces(ctx context.Context, scanInfo *cautils.ScanInfo, policyIdenti
 			span.RecordError(ErrClusterConnection)
 			return componentInterfaces{}, ErrClusterConnection
 		}
+		if scanInfo.DynamicClient != nil {
+			k8s.DynamicClient = scanInfo.DynamicClient
+		}
 		k8sClient = k8s.KubernetesClient
 	}
 
@@ -237,6 +242,11 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 	ctxInit, spanInit := otel.Tracer("").Start(ctx, "initialization")
 	logger.L().Start("Kubescape scanner initializing...")
 
+	// A fleet member holds the cluster connection until its resources are
+	// collected; the leave below releases it before rule evaluation.
+	leaveCluster := enterFleetCluster(ctx)
+	defer leaveCluster()
+
 	// ===================== Initialization =====================
 	policyIdentifiers = resolveDefaultScanAllPolicies(scanInfo, policyIdentifiers) // resolve the ScanAll expansion while Init can still cache its paths
 	if err := scanInfo.Init(ctxInit, policyIdentifiers); err != nil {              // initialize scan info
@@ -244,6 +254,26 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 		return nil, err
 	}
 	defer scanInfo.Cleanup()
+	scoringProfile, err := cautils.LoadScoringProfile(scanInfo.ScoringProfile)
+	if err != nil {
+		spanInit.End()
+		return nil, err
+	}
+	ownership, err := cautils.LoadOwnership(scanInfo.Ownership)
+	if err != nil {
+		spanInit.End()
+		return nil, err
+	}
+	crosswalks, err := cautils.LoadCrosswalks(scanInfo.ReportAs, scanInfo.Crosswalks)
+	if err != nil {
+		spanInit.End()
+		return nil, err
+	}
+	frameworkDefinitions, err := resolveFrameworkDefinitions(policyIdentifiers)
+	if err != nil {
+		spanInit.End()
+		return nil, err
+	}
 	if err := resolveClusterContext(scanInfo); err != nil {
 		spanInit.End()
 		return nil, err
@@ -263,41 +293,52 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 		}
 	}()
 
-	// Only create DownloadReleasedPolicy if not in air-gapped mode
-	airGapped := isAirGappedMode(scanInfo)
-	var downloadReleasedPolicy *getter.DownloadReleasedPolicy
-	if airGapped {
-		// In air-gapped mode (--use-from is set — the user explicitly wants to load everything
-		// from local files with no network access), don't initialize the downloader to prevent
-		// network access
-		downloadReleasedPolicy = nil
+	var getters cautils.Getters
+	var controlInputsFromCache, exceptionsFromCache bool
+	if scanInfo.PolicyBundle != "" {
+		getters, err = getPolicyBundleGetters(ctxInit, scanInfo, interfaces.k8s)
+		if err != nil {
+			spanInit.End()
+			return nil, err
+		}
 	} else {
-		downloadReleasedPolicy = getter.NewDownloadReleasedPolicyWithVersion(scanInfo.ControlsVersion) // download config inputs from github release
-	}
+		// Only create DownloadReleasedPolicy if not in air-gapped mode
+		airGapped := isAirGappedMode(scanInfo)
+		var downloadReleasedPolicy *getter.DownloadReleasedPolicy
+		if airGapped {
+			// In air-gapped mode (--use-from is set — the user explicitly wants to load everything
+			// from local files with no network access), don't initialize the downloader to prevent
+			// network access
+			downloadReleasedPolicy = nil
+		} else {
+			downloadReleasedPolicy = releasedPolicyForScan(ctx, scanInfo.ControlsVersion) // download config inputs from github release
+		}
 
-	// set policy getter only after setting the customerGUID
-	var getters cautils.Getters
-	getters.PolicyGetter, err = getPolicyGetter(ctxInit, scanInfo.UseFrom, interfaces.tenantConfig.GetAccountID(), scanInfo.FrameworkScan, downloadReleasedPolicy, airGapped)
-	if err != nil {
-		spanInit.End()
-		return nil, err
-	}
-	var controlInputsFromCache bool
-	getters.ControlsInputsGetter, controlInputsFromCache, err = getConfigInputsGetterForTarget(ctxInit, scanInfo.ControlsInputs, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy, scanInfo.GetScanningContext() == cautils.ContextCluster, airGapped, interfaces.k8s)
-	if err != nil {
-		spanInit.End()
-		return nil, err
-	}
-	var exceptionsFromCache bool
-	getters.ExceptionsGetter, exceptionsFromCache, err = getExceptionsGetterForTarget(ctxInit, scanInfo.UseExceptions, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy, airGapped, interfaces.k8s)
-	if err != nil {
-		spanInit.End()
-		return nil, err
+		// set policy getter only after setting the customerGUID
+		getters.PolicyGetter, err = getPolicyGetter(ctxInit, scanInfo.UseFrom, interfaces.tenantConfig.GetAccountID(), scanInfo.FrameworkScan, downloadReleasedPolicy, airGapped)
+		if err != nil {
+			spanInit.End()
+			return nil, err
+		}
+		getters.ControlsInputsGetter, controlInputsFromCache, err = getConfigInputsGetterForTarget(ctxInit, scanInfo.ControlsInputs, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy, scanInfo.GetScanningContext() == cautils.ContextCluster, airGapped, interfaces.k8s)
+		if err != nil {
+			spanInit.End()
+			return nil, err
+		}
+		getters.ExceptionsGetter, exceptionsFromCache, err = getExceptionsGetterForTarget(ctxInit, scanInfo.UseExceptions, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy, airGapped, interfaces.k8s)
+		if err != nil {
+			spanInit.End()
+			return nil, err
+		}
+		getters.AttackTracksGetter, err = getAttackTracksGetter(ctxInit, scanInfo.AttackTracks, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy, airGapped)
+		if err != nil {
+			spanInit.End()
+			return nil, err
+		}
 	}
-	getters.AttackTracksGetter, err = getAttackTracksGetter(ctxInit, scanInfo.AttackTracks, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy, airGapped)
-	if err != nil {
-		spanInit.End()
-		return nil, err
+
+	if len(frameworkDefinitions) > 0 {
+		getters.PolicyGetter = getter.NewFrameworkDefinitionGetter(getters.PolicyGetter, frameworkDefinitions)
 	}
 
 	if scanInfo.ScanAll {
@@ -327,6 +368,14 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 		spanInit.End()
 		return resultsHandling, err
 	}
+	scanData.ScoringProfile = scoringProfile
+	scanData.Ownership = ownership
+	scanData.Crosswalks = crosswalks
+	for _, policy := range policyIdentifiers {
+		if definition, ok := frameworkDefinitions[policy.Identifier]; ok {
+			scanData.FrameworkDefinitions = append(scanData.FrameworkDefinitions, definition)
+		}
+	}
 	if controlInputsFromCache {
 		scanData.PolicyDegradations = append(scanData.PolicyDegradations, cautils.PolicyDegradation{Component: "controlInputs", Reason: "failed to fetch from GitHub, loaded from local cache"})
 	}
@@ -363,7 +412,9 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 	if scanInfo.GetScanningContext() == cautils.ContextCluster {
 		estimatedClusterSize = estimateClusterSize(interfaces.resourceHandler, ctxResources, scanInfo)
 	}
-	if !enableStreaming && scanInfo.GetScanningContext() == cautils.ContextCluster {
+	// A distributed scan collects every resource before handing the evaluation
+	// to its workers, so it never streams.
+	if !enableStreaming && len(scanInfo.Workers) == 0 && scanInfo.GetScanningContext() == cautils.ContextCluster {
 		// Auto-enable streaming for large clusters
 		enableStreaming = cautils.IsLargeCluster(estimatedClusterSize)
 		if enableStreaming {
@@ -377,7 +428,7 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 
 	if enableStreaming {
 		// Use streaming approach for large clusters
-		err = collectAndProcessResourcesWithStreaming(ctxResources, interfaces.resourceHandler, scanData, scanInfo, interfaces.tenantConfig.GetContextName(), scanInfo.ExcludedNamespaces, scanInfo.IncludeNamespaces, scanInfo.EnableRegoPrint, scanInfo.ControlTimeout, estimatedClusterSize)
+		err = collectAndProcessResourcesWithStreaming(ctxResources, interfaces.resourceHandler, interfaces.hostSensorHandler, scanData, scanInfo, interfaces.tenantConfig.GetContextName(), scanInfo.ExcludedNamespaces, scanInfo.IncludeNamespaces, scanInfo.EnableRegoPrint, scanInfo.ControlTimeout, estimatedClusterSize)
 	} else {
 		// Use traditional approach for small clusters
 		err = resourcehandler.CollectResources(ctxResources, interfaces.resourceHandler, scanData, scanInfo)
@@ -393,6 +444,12 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 		}
 		reportResults := opaprocessor.NewOPAProcessor(scanData, deps, interfaces.tenantConfig.GetContextName(), scanInfo.ExcludedNamespaces, scanInfo.IncludeNamespaces, scanInfo.EnableRegoPrint, exceptionRecorder)
 		reportResults.ControlTimeout = scanInfo.ControlTimeout
+		reportResults.SetEvalConcurrency(scanInfo.EvalConcurrency)
+		reportResults.SetRuleCache(openRuleCache(ctxOpa))
+		defer streamTelemetry(ctxOpa, interfaces.hostSensorHandler, reportResults)()
+		if len(scanInfo.Workers) > 0 {
+			reportResults.SetShardEvaluator(shardworker.NewClient(scanInfo.Workers, shardworker.Token(), scanInfo.WorkerTimeout), len(scanInfo.Workers), scanInfo.WorkersLocalFallback)
+		}
 		if cacheStore := loadIncrementalCacheIfEnabled(ctxOpa, scanInfo, scanData); cacheStore != nil {
 			reportResults.SetIncrementalCache(cacheStore)
 			defer func() {
@@ -401,6 +458,7 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 				}
 			}()
 		}
+		leaveCluster()
 		if err = reportResults.ProcessRulesListener(ctxOpa, cautils.NewProgressHandler("")); err != nil {
 			logger.L().Ctx(ctxOpa).Error("failed to process rules", helpers.Error(err))
 			// The eager listener finalizes its accumulated results before returning
@@ -435,6 +493,9 @@ func (ks *Kubescape) ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo
 		resultsHandling.SetScanError(scanImages(scanInfo.ScanType, scanData, ctx, resultsHandling, scanInfo, interfaces.k8s))
 	}
 	// ========================= results handling =====================
+	if scanData.Ownership != nil {
+		scanData.ResourceOwners = scanData.Ownership.Assign(scanData.AllResources, scanData.ResourceSource)
+	}
 	resultsHandling.SetData(scanData)
 
 	if scanInfo.EncryptionEnabled {
@@ -523,6 +584,12 @@ func scanImages(scanType cautils.ScanTypes, scanData *cautils.OPASessionObj, ctx
 		return errors.Join(append(containerErrors, fmt.Errorf("failed to initialize image scanner: %w", err))...)
 	}
 	defer svc.Close()
+	enableImageResultCache(svc, scanInfo)
+	imageSvc, err := newImageScanService(svc, scanInfo)
+	if err != nil {
+		logger.L().StopError(err.Error())
+		return errors.Join(append(containerErrors, err)...)
+	}
 	defaultCreds := registryCredentialsFromScanInfo(scanInfo)
 	var jobs []ImageScanJob
 	for target := range imagesToScan.Iter() {
@@ -568,7 +635,7 @@ func scanImages(scanType cautils.ScanTypes, scanData *cautils.OPASessionObj, ctx
 		concurrency = 1
 	}
 
-	return scanImageJobsWithDiscoveryErrors(ctx, svc, concurrency, jobs, resultsHandling, containerErrors)
+	return scanImageJobsWithDiscoveryErrors(ctx, imageSvc, concurrency, jobs, resultsHandling, containerErrors)
 }
 
 func scanImageJobsWithDiscoveryErrors(ctx context.Context, svc imageScanService, concurrency int, jobs []ImageScanJob, resultsHandling *resultshandling.ResultsHandler, discoveryErrors []error) error {
@@ -795,7 +862,37 @@ func loadIncrementalCacheIfEnabled(ctx context.Context, scanInfo *cautils.ScanIn
 	return cacheStore
 }
 
-func collectAndProcessResourcesWithStreaming(ctx context.Context, resourceHandler resourcehandler.IResourceHandler, scanData *cautils.OPASessionObj, scanInfo *cautils.ScanInfo, clusterName string, excludedNamespaces string, includeNamespaces string, enableRegoPrint bool, controlTimeout time.Duration, estimatedClusterSize int) error {
+// openRuleCache opens the on-disk cache of compiled rules, so that rules
+// compiled by an earlier scan (or by download --warm-cache) are loaded instead
+// of compiled. Returns nil when it cannot be opened; the scan then compiles
+// every rule as usual.
+func openRuleCache(ctx context.Context) *rulecache.Store {
+	ruleCache, err := opaprocessor.OpenRuleCache(ctx, getter.DefaultLocalStore)
+	if err != nil {
+		logger.L().Ctx(ctx).Debug("failed to open the compiled rule cache, proceeding without it", helpers.Error(err))
+		return nil
+	}
+	return ruleCache
+}
+
+// streamTelemetry streams the runtime telemetry of hostSensor while the
+// controls are evaluated, so that the report also gets a runtime-adjusted
+// score. The returned function ends the stream if the evaluation did not.
+func streamTelemetry(ctx context.Context, hostSensor hostsensorutils.IHostSensor, reportResults *opaprocessor.OPAProcessor) func() {
+	if hostSensor == nil {
+		return func() {}
+	}
+	telemetryCtx, stop := context.WithCancel(ctx)
+	events, err := hostSensor.StreamTelemetry(telemetryCtx)
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("failed to stream host sensor telemetry, the runtime-adjusted score is not computed", helpers.Error(err))
+		return stop
+	}
+	reportResults.SetTelemetry(events, stop)
+	return stop
+}
+
+func collectAndProcessResourcesWithStreaming(ctx context.Context, resourceHandler resourcehandler.IResourceHandler, hostSensor hostsensorutils.IHostSensor, scanData *cautils.OPASessionObj, scanInfo *cautils.ScanInfo, clusterName string, excludedNamespaces string, includeNamespaces string, enableRegoPrint bool, controlTimeout time.Duration, estimatedClusterSize int) error {
 	// The eager collector initializes this metadata before constructing the OPA
 	// processor. Do the same here because the cloud provider is a policy input,
 	// not only report metadata.
@@ -814,6 +911,9 @@ func collectAndProcessResourcesWithStreaming(ctx context.Context, resourceHandle
 	}
 	reportResults := opaprocessor.NewOPAProcessor(scanData, deps, clusterName, excludedNamespaces, includeNamespaces, enableRegoPrint, exceptionRecorder)
 	reportResults.ControlTimeout = controlTimeout
+	reportResults.SetEvalConcurrency(scanInfo.EvalConcurrency)
+	reportResults.SetRuleCache(openRuleCache(ctx))
+	defer streamTelemetry(ctx, hostSensor, reportResults)()
 	if cacheStore := loadIncrementalCacheIfEnabled(ctx, scanInfo, scanData); cacheStore != nil {
 		reportResults.SetIncrementalCache(cacheStore)
 		defer func() {
@@ -12,6 +12,7 @@ import (
 
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/pkg/hostsensorutils"
 	"github.com/kubescape/kubescape/v4/core/pkg/resourcehandler"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
@@ -696,7 +697,7 @@ func TestCollectAndProcessResourcesWithStreaming_InitializesProviderScope(t *tes
 		ContextName: "arn:aws:eks:us-east-1:123456789012:cluster/production",
 	}
 
-	err := collectAndProcessResourcesWithStreaming(context.Background(), mockHandler, sessionObj, &cautils.ScanInfo{}, "cluster", "", "", false, time.Second, 3000)
+	err := collectAndProcessResourcesWithStreaming(context.Background(), mockHandler, nil, sessionObj, &cautils.ScanInfo{}, "cluster", "", "", false, time.Second, 3000)
 
 	require.NoError(t, err)
 	assert.Same(t, apiServerInfo, sessionObj.Report.ClusterAPIServerInfo)
@@ -717,13 +718,51 @@ func TestCollectAndProcessResourcesWithStreaming_CancelsProducerContext(t *testi
 
 	parentCtx := context.Background()
 
-	_ = collectAndProcessResourcesWithStreaming(parentCtx, mockHandler, sessionObj, scanInfo, "cluster", "", "", false, time.Second, 10)
+	_ = collectAndProcessResourcesWithStreaming(parentCtx, mockHandler, nil, sessionObj, scanInfo, "cluster", "", "", false, time.Second, 10)
 
 	require.NotNil(t, mockHandler.passedCtx)
 	assert.NoError(t, parentCtx.Err(), "parent context must remain uncanceled")
 	assert.Equal(t, context.Canceled, mockHandler.passedCtx.Err(), "derived producer context must be canceled when function returns")
 }
 
+// telemetrySensorMock streams canned telemetry events until the scan ends it.
+type telemetrySensorMock struct {
+	hostsensorutils.HostSensorHandlerMock
+	events    []hostsensorutils.SyscallEvent
+	streamCtx context.Context
+}
+
+func (m *telemetrySensorMock) StreamTelemetry(ctx context.Context) (<-chan hostsensorutils.SyscallEvent, error) {
+	m.streamCtx = ctx
+	events := make(chan hostsensorutils.SyscallEvent, len(m.events))
+	for _, event := range m.events {
+		events <- event
+	}
+	go func() {
+		<-ctx.Done()
+		close(events)
+	}()
+	return events, nil
+}
+
+func TestCollectAndProcessResourcesWithStreaming_ScoresTelemetry(t *testing.T) {
+	sensor := &telemetrySensorMock{events: []hostsensorutils.SyscallEvent{
+		{PodUID: "uid-1", Type: hostsensorutils.SyscallEventExec, Path: "/bin/sh"},
+		{Type: hostsensorutils.SyscallEventConnect, RemoteAddr: "203.0.113.7:443"},
+	}}
+	sessionObj := cautils.NewOPASessionObjMock()
+
+	err := collectAndProcessResourcesWithStreaming(context.Background(), &streamingCancelMock{}, sensor, sessionObj, &cautils.ScanInfo{}, "cluster", "", "", false, time.Second, 10)
+
+	require.NoError(t, err)
+	require.NotNil(t, sessionObj.RuntimeScore, "the scan reports a runtime-adjusted score")
+	assert.Equal(t, 2, sessionObj.RuntimeScore.Events)
+	assert.Equal(t, 2, sessionObj.RuntimeScore.UnattributedEvents, "no scanned workload runs in the pods of the events")
+	assert.Equal(t, sessionObj.Report.SummaryDetails.ComplianceScore, sessionObj.RuntimeScore.StaticComplianceScore)
+	require.NotNil(t, sensor.streamCtx)
+	assert.Error(t, sensor.streamCtx.Err(), "the stream ends with the evaluation")
+}
+
 func TestGetAllWorkloadImages(t *testing.T) {
 	podData := map[string]interface{}{
 		"apiVersion": "v1",
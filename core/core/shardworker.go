@@ -0,0 +1,30 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/shardworker"
+)
+
+// ServeShardWorker serves the evaluation of the shards distributed scans
+// send to their workers. A worker is stateless: each shard carries the
+// controls, inputs and resources it is evaluated with, so any number of
+// workers can serve the same scans. Requests must present the bearer token of
+// KS_API_TOKEN, which must be set unless workerInfo.Insecure is.
+func (ks *Kubescape) ServeShardWorker(ctx context.Context, workerInfo *metav1.ShardWorkerInfo) error {
+	if (workerInfo.CertFile == "") != (workerInfo.KeyFile == "") {
+		return fmt.Errorf("both a TLS certificate and key are needed to serve TLS")
+	}
+	token := shardworker.Token()
+	if token == "" {
+		if !workerInfo.Insecure {
+			return fmt.Errorf("%s is not set: set it, or serve without authentication with --insecure", shardworker.TokenEnv)
+		}
+		logger.L().Warning("API token not configured — the worker evaluates the shards of anyone who can reach it", helpers.String("env", shardworker.TokenEnv))
+	}
+	return shardworker.Serve(ctx, workerInfo.Addr, workerInfo.CertFile, workerInfo.KeyFile, &shardworker.Handler{Token: token, MaxShardBytes: workerInfo.MaxShardBytes})
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/policyhandler"
+	"github.com/kubescape/kubescape/v4/core/pkg/watch"
+)
+
+// Watch scans the cluster, then watches the resource types the scan listed and
+// rescans after every batch of changes, emitting how the results changed as
+// JSON lines and, when requested, as Kubernetes Events. Rescans list from the
+// informer caches and re-evaluate only what the incremental scan cache cannot
+// answer, so they cost a fraction of the first scan.
+func (ks *Kubescape) Watch(ctx context.Context, watchInfo *metav1.WatchInfo, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) error {
+	if err := resolveClusterContext(scanInfo); err != nil {
+		return err
+	}
+	k8s := getKubernetesApi()
+	if k8s == nil {
+		return ErrClusterConnection
+	}
+
+	var out io.Writer = os.Stdout
+	if watchInfo.Output != "" {
+		f, err := os.OpenFile(filepath.Clean(watchInfo.Output), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open watch output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	sinks := []watch.Sink{watch.NewJSONLSink(out)}
+	if watchInfo.Events {
+		recorder, shutdown := newSecurityExceptionEventRecorderWithClient(k8s.KubernetesClient)
+		if recorder == nil {
+			return fmt.Errorf("failed to record Kubernetes events: %w", ErrClusterConnection)
+		}
+		defer shutdown()
+		sinks = append(sinks, watch.NewEventSink(recorder))
+	}
+	sinks = append(sinks, watchInfo.Sinks...)
+
+	client := watch.NewClient(ctx, k8s.DynamicClient)
+	// Every scan lists through the informer caches, keeps the per-resource
+	// verdicts of unchanged resources, and reuses the policies of the first.
+	scanInfo.DynamicClient = client
+	scanInfo.Incremental = true
+	scanInfo.EnableStreaming = false
+	scanInfo.Submit.SetBool(false)
+	scanInfo.Local = true
+	ctx = policyhandler.WithSharedPolicies(ctx, policyhandler.NewSharedPolicies())
+
+	w := &watch.Watcher{
+		Scan: func(ctx context.Context) (*cautils.OPASessionObj, error) {
+			results, err := ks.ScanContext(ctx, scanInfo.ForRescan(), slices.Clone(policyIdentifiers))
+			if err != nil {
+				return nil, err
+			}
+			return results.GetData(), nil
+		},
+		Changes:  client.Changes(),
+		Debounce: watchInfo.Debounce,
+		Sinks:    sinks,
+	}
+	return w.Run(ctx)
+}
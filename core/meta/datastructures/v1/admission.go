@@ -0,0 +1,13 @@
+package v1
+
+type AdmissionInfo struct {
+	Addr        string   // address the webhook server listens on
+	CertFile    string   // TLS certificate served to the API server
+	KeyFile     string   // TLS private key of CertFile
+	DefaultMode string   // mode of the controls not listed below: "enforce", "warn" or "audit"
+	Enforce     []string // control IDs whose failure denies the request
+	Warn        []string // control IDs whose failure returns a warning
+	Audit       []string // control IDs whose failure is only recorded in the audit log
+	FailClosed  bool     // deny the requests that cannot be evaluated instead of admitting them with a warning
+	ReplayFiles []string // AdmissionReview files to evaluate and print the responses of, instead of serving
+}
@@ -7,8 +7,12 @@ type DownloadInfo struct {
 	Identifier string // identifier of artifact to download
 	AccountID  string
 	AccessKey  string
+	Push       string // OCI reference to publish the downloaded artifacts to as a policy bundle, e.g. "oci://registry.example.com/kubescape/policies:v1"
+	WarmCache  bool   // compile the rules of the downloaded controls into the rule cache, so the next scan does not compile them
 }
 
 type DownloadResult struct {
-	Files []string // paths of the downloaded artifacts that were saved
+	Files  []string // paths of the downloaded artifacts that were saved
+	Digest string   // digest of the pushed policy bundle, when the artifacts were pushed
+	Warmed int      // compiled rules stored in the rule cache, when WarmCache is set
 }
@@ -0,0 +1,8 @@
+package v1
+
+type ExplainInfo struct {
+	ControlID string // control whose verdict on the resource is explained
+	Trace     string // Rego trace mode: "notes", "fails" or "full"
+	Format    string // "pretty-printer" or "json"
+	Output    string // file to write the explanation to; empty means stdout
+}
@@ -13,4 +13,13 @@ type FixInfo struct {
 	// as-is, as it always has been.
 	BasePath             string
 	ContainerProfilePath string // Path to an optional ContainerProfile JSON file
+
+	// OpenPR, once the fixes are written, commits them on a new branch of the
+	// report's local git repository, pushes it and opens a GitHub pull request
+	// or GitLab merge request. The API token is read from the environment.
+	OpenPR       bool
+	PRBranch     string // branch to create; empty means "kubescape/fix-<timestamp>"
+	PRBaseBranch string // branch the pull request targets; empty means the branch checked out
+	PRRemote     string // git remote to push to; empty means "origin"
+	ForgeAPIURL  string // GitHub/GitLab API base URL; empty means github.com / gitlab.com
 }
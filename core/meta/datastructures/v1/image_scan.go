@@ -6,6 +6,7 @@ type ImageScanInfo struct {
 	Password           string
 	Token              string
 	Image              string
+	SBOM               string // Pre-generated SBOM to match instead of pulling the image
 	Platform           string
 	Exceptions         string
 	UseDefaultMatchers bool
@@ -0,0 +1,20 @@
+package v1
+
+import "strings"
+
+type MergeReportsInfo struct {
+	Files  []string // paths to the JSON scan reports to merge, one per cluster
+	Format string   // comma-separated output formats, as for fleet scans
+	Output string   // output file path; empty means stdout
+}
+
+// Formats returns the requested output formats without blanks.
+func (m *MergeReportsInfo) Formats() []string {
+	var formats []string
+	for format := range strings.SplitSeq(m.Format, ",") {
+		if format = strings.TrimSpace(format); format != "" {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
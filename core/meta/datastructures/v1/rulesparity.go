@@ -0,0 +1,11 @@
+package v1
+
+type RulesParityInfo struct {
+	InputPaths     []string // manifests to compare on; a regolibrary checkout is split into its rules' test inputs
+	ControlIDs     []string // controls to compare; empty means every control of the embedded CEL bundle
+	UseFrom        []string // local policy files to read the Rego controls from; empty means download them
+	ControlsInputs string   // path to a controls-config file
+	IgnorePaths    bool     // compare verdicts only, not the failed and fix paths
+	Format         string   // "pretty-printer" or "json"
+	Output         string   // file to write the report to; empty means stdout
+}
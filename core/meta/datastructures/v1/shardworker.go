@@ -0,0 +1,9 @@
+package v1
+
+type ShardWorkerInfo struct {
+	Addr          string // address the worker listens on
+	CertFile      string // TLS certificate of the worker, plain HTTP when empty
+	KeyFile       string // TLS private key of CertFile
+	Insecure      bool   // serve without KS_API_TOKEN
+	MaxShardBytes int64  // largest shard accepted, shardworker.DefaultMaxShardBytes when zero
+}
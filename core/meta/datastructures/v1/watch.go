@@ -0,0 +1,14 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/kubescape/kubescape/v4/core/pkg/watch"
+)
+
+type WatchInfo struct {
+	Output   string        // JSONL file receiving the result changes; empty means stdout
+	Events   bool          // record the result changes as Kubernetes Events on the changed resources
+	Debounce time.Duration // how long to collect changes before rescanning
+	Sinks    []watch.Sink  // additional receivers of the result changes, such as the in-cluster storage
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/kubescape/kubescape/v4/core/cautils"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/fleet"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling"
 )
 
@@ -20,6 +21,33 @@ type IKubescape interface {
 	// operations, since Scan's context comes from mutable shared state.
 	ScanContext(ctx context.Context, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) (*resultshandling.ResultsHandler, error)
 
+	// ScanFleet scans every kubeconfig context selected by scanInfo with the same
+	// policies and returns one report comparing the clusters.
+	ScanFleet(ctx context.Context, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) (*fleet.Report, error)
+
+	// Watch scans the cluster, then rescans it whenever the resources the
+	// controls evaluate change, and emits how the results changed until ctx is
+	// done.
+	Watch(ctx context.Context, watchInfo *metav1.WatchInfo, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) error
+
+	// ServeAdmission serves a validating admission webhook evaluating the
+	// selected controls on every reviewed object until ctx is done, or replays
+	// the AdmissionReview files of admissionInfo.
+	ServeAdmission(ctx context.Context, admissionInfo *metav1.AdmissionInfo, scanInfo *cautils.ScanInfo, policyIdentifiers []cautils.PolicyIdentifier) error
+
+	// ServeShardWorker evaluates the shards distributed scans send it until
+	// ctx is done.
+	ServeShardWorker(ctx context.Context, workerInfo *metav1.ShardWorkerInfo) error
+
+	// Explain scans the single resource of scanInfo with one control and
+	// writes how the control's rules reached their verdicts on it.
+	Explain(ctx context.Context, explainInfo *metav1.ExplainInfo, scanInfo *cautils.ScanInfo) error
+
+	// RulesParity runs the Rego and CEL implementations of the selected
+	// controls on manifests, writes where they disagree and returns the
+	// number of disagreements.
+	RulesParity(ctx context.Context, parityInfo *metav1.RulesParityInfo) (int, error)
+
 	// policies
 	List(listPolicies *metav1.ListPolicies) (*metav1.ListResult, error)
 	Download(downloadInfo *metav1.DownloadInfo) (*metav1.DownloadResult, error)
@@ -35,6 +63,10 @@ type IKubescape interface {
 	// diff returns the number of new failures at or above the configured severity threshold.
 	Diff(diffInfo *metav1.DiffInfo) (int, error)
 
+	// MergeReports combines saved per-cluster JSON reports into a fleet report
+	// and writes it in the requested formats.
+	MergeReports(ctx context.Context, mergeInfo *metav1.MergeReportsInfo) (*fleet.Report, error)
+
 	// patch
 	Patch(patchInfo *metav1.PatchInfo, scanInfo *cautils.ScanInfo) (bool, error)
 
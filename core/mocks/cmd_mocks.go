@@ -5,6 +5,7 @@ import (
 
 	"github.com/kubescape/kubescape/v4/core/cautils"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/fleet"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling"
 )
 
@@ -24,6 +25,10 @@ func (m *MockIKubescape) ScanContext(_ context.Context, _ *cautils.ScanInfo, _ [
 	return nil, nil
 }
 
+func (m *MockIKubescape) ScanFleet(_ context.Context, _ *cautils.ScanInfo, _ []cautils.PolicyIdentifier) (*fleet.Report, error) {
+	return &fleet.Report{}, nil
+}
+
 func (m *MockIKubescape) List(_ *metav1.ListPolicies) (*metav1.ListResult, error) {
 	return &metav1.ListResult{}, nil
 }
@@ -52,6 +57,10 @@ func (m *MockIKubescape) Diff(_ *metav1.DiffInfo) (int, error) {
 	return 0, nil
 }
 
+func (m *MockIKubescape) MergeReports(_ context.Context, _ *metav1.MergeReportsInfo) (*fleet.Report, error) {
+	return &fleet.Report{}, nil
+}
+
 func (m *MockIKubescape) Patch(_ *metav1.PatchInfo, _ *cautils.ScanInfo) (bool, error) {
 	return false, nil
 }
@@ -63,3 +72,23 @@ func (m *MockIKubescape) ScanImage(_ *metav1.ImageScanInfo, _ *cautils.ScanInfo)
 func (m *MockIKubescape) ScanImageContext(_ context.Context, _ *metav1.ImageScanInfo, _ *cautils.ScanInfo) (bool, error) {
 	return false, nil
 }
+
+func (m *MockIKubescape) Watch(_ context.Context, _ *metav1.WatchInfo, _ *cautils.ScanInfo, _ []cautils.PolicyIdentifier) error {
+	return nil
+}
+
+func (m *MockIKubescape) ServeAdmission(_ context.Context, _ *metav1.AdmissionInfo, _ *cautils.ScanInfo, _ []cautils.PolicyIdentifier) error {
+	return nil
+}
+
+func (m *MockIKubescape) ServeShardWorker(_ context.Context, _ *metav1.ShardWorkerInfo) error {
+	return nil
+}
+
+func (m *MockIKubescape) Explain(_ context.Context, _ *metav1.ExplainInfo, _ *cautils.ScanInfo) error {
+	return nil
+}
+
+func (m *MockIKubescape) RulesParity(_ context.Context, _ *metav1.RulesParityInfo) (int, error) {
+	return 0, nil
+}
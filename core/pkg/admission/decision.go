@@ -0,0 +1,90 @@
+package admission
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// failedControl is a control the object under review fails.
+type failedControl struct {
+	id       string
+	name     string
+	severity string
+}
+
+func (c failedControl) String() string {
+	if c.severity == "" {
+		return c.id + " " + c.name
+	}
+	return fmt.Sprintf("%s %s (%s)", c.id, c.name, c.severity)
+}
+
+// Decide returns the response to request given the results of scanning its
+// object, whose resource ID is resourceID. Every failed control is recorded
+// as an audit annotation keyed by its lowercased ID; failed controls in warn
+// mode also return a warning, and failed controls in enforce mode deny the
+// request.
+func Decide(request *admissionv1.AdmissionRequest, resourceID string, sessionObj *cautils.OPASessionObj, modes Modes) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+
+	var denied []string
+	for _, control := range failedControls(sessionObj, resourceID) {
+		mode := modes.Of(control.id)
+		if response.AuditAnnotations == nil {
+			response.AuditAnnotations = map[string]string{}
+		}
+		response.AuditAnnotations[strings.ToLower(control.id)] = fmt.Sprintf("%s failed (%s)", control, mode)
+		switch mode {
+		case ModeEnforce:
+			denied = append(denied, control.String())
+		case ModeWarn:
+			response.Warnings = append(response.Warnings, "kubescape: "+control.String())
+		}
+	}
+
+	if len(denied) > 0 {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: "kubescape denied the request: " + strings.Join(denied, "; "),
+		}
+	}
+	return response
+}
+
+// failedControls returns the controls resourceID fails, sorted by ID.
+func failedControls(sessionObj *cautils.OPASessionObj, resourceID string) []failedControl {
+	if sessionObj == nil {
+		return nil
+	}
+	result, ok := sessionObj.ResourcesResult[resourceID]
+	if !ok {
+		return nil
+	}
+
+	var failed []failedControl
+	for _, control := range result.ListControls() {
+		if !control.GetStatus(nil).IsFailed() {
+			continue
+		}
+		c := failedControl{id: control.GetID(), name: control.GetName()}
+		if sessionObj.Report != nil {
+			if summary := sessionObj.Report.SummaryDetails.Controls.GetControl(reportsummary.EControlCriteriaID, c.id); summary != nil {
+				c.severity = apis.ControlSeverityToString(summary.GetScoreFactor())
+			}
+		}
+		failed = append(failed, c)
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].id < failed[j].id })
+	return failed
+}
@@ -0,0 +1,152 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxReviewBytes bounds the size of an AdmissionReview. The API server never
+// sends objects larger than its own 3MiB request limit.
+const maxReviewBytes = 4 << 20
+
+// EvaluateFunc scans object, the object under admission review, with the
+// related objects it needs from the cluster, and returns the results.
+type EvaluateFunc func(ctx context.Context, object map[string]any) (*cautils.OPASessionObj, error)
+
+// Handler answers the AdmissionReviews of a validating webhook.
+type Handler struct {
+	Evaluate EvaluateFunc
+	Modes    Modes
+	// FailClosed denies the requests whose object could not be evaluated,
+	// instead of admitting them with a warning.
+	FailClosed bool
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReviewBytes))
+	if err != nil {
+		http.Error(w, "failed to read the request", http.StatusBadRequest)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "the request is not an AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.Review(r.Context(), review)); err != nil {
+		logger.L().Ctx(r.Context()).Warning("failed to write the admission response", helpers.Error(err))
+	}
+}
+
+// Review returns review with the response to its request.
+func (h *Handler) Review(ctx context.Context, review *admissionv1.AdmissionReview) *admissionv1.AdmissionReview {
+	response := h.respond(ctx, review.Request)
+	response.UID = review.Request.UID
+	return &admissionv1.AdmissionReview{TypeMeta: review.TypeMeta, Response: response}
+}
+
+// ReplayFile replays the AdmissionReview saved in file and returns the review
+// with its response.
+func (h *Handler) ReplayFile(ctx context.Context, file string) (*admissionv1.AdmissionReview, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(data, review); err != nil {
+		return nil, fmt.Errorf("invalid AdmissionReview %s: %w", file, err)
+	}
+	if review.Request == nil {
+		return nil, fmt.Errorf("invalid AdmissionReview %s: no request", file)
+	}
+	return h.Review(ctx, review), nil
+}
+
+// unevaluatedSubResources are the subresources admitted without evaluation:
+// status leaves the spec unchanged, and scale, binding and eviction carry a
+// Scale, Binding or Eviction rather than the object.
+var unevaluatedSubResources = map[string]bool{
+	"status":   true,
+	"scale":    true,
+	"binding":  true,
+	"eviction": true,
+}
+
+func (h *Handler) respond(ctx context.Context, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	// Deletes, connects and some subresources carry no object the controls
+	// evaluate. Other subresources, such as pods/ephemeralcontainers, change
+	// the spec of the object they carry, which is evaluated.
+	if request.Operation == admissionv1.Delete || request.Operation == admissionv1.Connect || unevaluatedSubResources[request.SubResource] {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+	object, err := admissionObject(request)
+	if err != nil {
+		return h.failure(ctx, request, err)
+	}
+	sessionObj, err := h.Evaluate(ctx, object)
+	if err != nil {
+		return h.failure(ctx, request, err)
+	}
+	return Decide(request, workloadinterface.NewWorkloadObj(object).GetID(), sessionObj, h.Modes)
+}
+
+func (h *Handler) failure(ctx context.Context, request *admissionv1.AdmissionRequest, err error) *admissionv1.AdmissionResponse {
+	logger.L().Ctx(ctx).Warning("failed to evaluate admission request",
+		helpers.String("kind", request.Kind.Kind),
+		helpers.String("namespace", request.Namespace),
+		helpers.String("name", request.Name),
+		helpers.Error(err))
+	message := "kubescape could not evaluate the request: " + err.Error()
+	if h.FailClosed {
+		return &admissionv1.AdmissionResponse{Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusInternalServerError,
+			Reason:  metav1.StatusReasonInternalError,
+			Message: message,
+		}}
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true, Warnings: []string{message}}
+}
+
+// admissionObject decodes the object under review, completing the name and
+// namespace the API server only sets after admission.
+func admissionObject(request *admissionv1.AdmissionRequest) (map[string]any, error) {
+	if len(request.Object.Raw) == 0 {
+		return nil, errors.New("the request has no object")
+	}
+	object := &unstructured.Unstructured{}
+	if err := json.Unmarshal(request.Object.Raw, &object.Object); err != nil {
+		return nil, fmt.Errorf("failed to decode the object: %w", err)
+	}
+	if object.GetNamespace() == "" && request.Namespace != "" {
+		object.SetNamespace(request.Namespace)
+	}
+	if object.GetName() == "" {
+		name := request.Name
+		if name == "" {
+			name = object.GetGenerateName()
+		}
+		object.SetName(name)
+	}
+	return object.Object, nil
+}
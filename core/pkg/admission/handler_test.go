@@ -0,0 +1,186 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// stubEvaluate stands in for the OPA processor with two container controls,
+// so the fixtures replay without policies or a cluster. It records the
+// objects it evaluated.
+func stubEvaluate(evaluated *[]map[string]any) EvaluateFunc {
+	return func(_ context.Context, object map[string]any) (*cautils.OPASessionObj, error) {
+		*evaluated = append(*evaluated, object)
+
+		containers, _, _ := unstructured.NestedSlice(object, "spec", "containers")
+		if template, ok, _ := unstructured.NestedSlice(object, "spec", "template", "spec", "containers"); ok {
+			containers = template
+		}
+		ephemeral, _, _ := unstructured.NestedSlice(object, "spec", "ephemeralContainers")
+		containers = append(containers, ephemeral...)
+		privileged, escalation := apis.StatusPassed, apis.StatusPassed
+		for _, c := range containers {
+			container, _ := c.(map[string]any)
+			if p, _, _ := unstructured.NestedBool(container, "securityContext", "privileged"); p {
+				privileged = apis.StatusFailed
+			}
+			if allowed, ok, _ := unstructured.NestedBool(container, "securityContext", "allowPrivilegeEscalation"); !ok || allowed {
+				escalation = apis.StatusFailed
+			}
+		}
+
+		resourceID := workloadinterface.NewWorkloadObj(object).GetID()
+		return &cautils.OPASessionObj{ResourcesResult: map[string]resourcesresults.Result{
+			resourceID: {ResourceID: resourceID, AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+				{ControlID: "C-0057", Name: "Privileged container", Status: apis.StatusInfo{InnerStatus: privileged}},
+				{ControlID: "C-0016", Name: "Allow privilege escalation", Status: apis.StatusInfo{InnerStatus: escalation}},
+			}},
+		}}, nil
+	}
+}
+
+func TestReplayFixtures(t *testing.T) {
+	modes, err := NewModes("warn", []string{"C-0057"}, nil, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		fixture         string
+		wantAllowed     bool
+		wantMessage     string
+		wantWarnings    []string
+		wantAnnotations map[string]string
+		wantName        string
+	}{
+		{
+			fixture:      "create-privileged-pod.json",
+			wantAllowed:  false,
+			wantMessage:  "kubescape denied the request: C-0057 Privileged container",
+			wantWarnings: []string{"kubescape: C-0016 Allow privilege escalation"},
+			wantAnnotations: map[string]string{
+				"c-0016": "C-0016 Allow privilege escalation failed (warn)",
+				"c-0057": "C-0057 Privileged container failed (enforce)",
+			},
+			wantName: "api",
+		},
+		{
+			fixture:     "create-generate-name-pod.json",
+			wantAllowed: true,
+			wantName:    "api-7d9c5b-",
+		},
+		{
+			fixture:         "update-deployment.json",
+			wantAllowed:     true,
+			wantWarnings:    []string{"kubescape: C-0016 Allow privilege escalation"},
+			wantAnnotations: map[string]string{"c-0016": "C-0016 Allow privilege escalation failed (warn)"},
+			wantName:        "api",
+		},
+		{
+			fixture:     "delete-pod.json",
+			wantAllowed: true,
+		},
+		{
+			fixture:      "update-pod-ephemeralcontainers.json",
+			wantAllowed:  false,
+			wantMessage:  "kubescape denied the request: C-0057 Privileged container",
+			wantWarnings: []string{"kubescape: C-0016 Allow privilege escalation"},
+			wantAnnotations: map[string]string{
+				"c-0016": "C-0016 Allow privilege escalation failed (warn)",
+				"c-0057": "C-0057 Privileged container failed (enforce)",
+			},
+			wantName: "api",
+		},
+		{
+			fixture:     "update-pod-status.json",
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			var evaluated []map[string]any
+			h := &Handler{Evaluate: stubEvaluate(&evaluated), Modes: modes}
+
+			review, err := h.ReplayFile(context.Background(), filepath.Join("testdata", "reviews", tt.fixture))
+			require.NoError(t, err)
+			require.NotNil(t, review.Response)
+
+			response := review.Response
+			assert.Equal(t, "admission.k8s.io/v1", review.APIVersion)
+			assert.NotEmpty(t, response.UID)
+			assert.Equal(t, tt.wantAllowed, response.Allowed)
+			assert.Equal(t, tt.wantWarnings, response.Warnings)
+			assert.Equal(t, tt.wantAnnotations, response.AuditAnnotations)
+			if tt.wantMessage != "" {
+				require.NotNil(t, response.Result)
+				assert.Equal(t, tt.wantMessage, response.Result.Message)
+			}
+			if tt.wantName == "" {
+				assert.Empty(t, evaluated, "no object is evaluated")
+				return
+			}
+			require.Len(t, evaluated, 1)
+			object := &unstructured.Unstructured{Object: evaluated[0]}
+			assert.Equal(t, tt.wantName, object.GetName())
+			assert.Equal(t, "payments", object.GetNamespace())
+		})
+	}
+}
+
+func TestHandlerFailure(t *testing.T) {
+	failing := func(context.Context, map[string]any) (*cautils.OPASessionObj, error) {
+		return nil, errors.New("policies unavailable")
+	}
+	fixture := filepath.Join("testdata", "reviews", "create-privileged-pod.json")
+
+	open, err := (&Handler{Evaluate: failing}).ReplayFile(context.Background(), fixture)
+	require.NoError(t, err)
+	assert.True(t, open.Response.Allowed)
+	assert.Equal(t, []string{"kubescape could not evaluate the request: policies unavailable"}, open.Response.Warnings)
+
+	closed, err := (&Handler{Evaluate: failing, FailClosed: true}).ReplayFile(context.Background(), fixture)
+	require.NoError(t, err)
+	assert.False(t, closed.Response.Allowed)
+	assert.Equal(t, "kubescape could not evaluate the request: policies unavailable", closed.Response.Result.Message)
+}
+
+func TestServeHTTP(t *testing.T) {
+	var evaluated []map[string]any
+	modes, err := NewModes("audit", nil, nil, nil)
+	require.NoError(t, err)
+	h := &Handler{Evaluate: stubEvaluate(&evaluated), Modes: modes}
+
+	body, err := os.ReadFile(filepath.Join("testdata", "reviews", "create-privileged-pod.json"))
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(body)))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	review := &admissionv1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), review))
+	assert.True(t, review.Response.Allowed)
+	assert.Empty(t, review.Response.Warnings)
+	assert.Len(t, review.Response.AuditAnnotations, 2)
+
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader([]byte(`{"kind":"AdmissionReview"}`))))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ValidatePath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
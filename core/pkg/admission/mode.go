@@ -0,0 +1,68 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mode is what a failed control does to an admission request.
+type Mode string
+
+const (
+	// ModeEnforce denies the request.
+	ModeEnforce Mode = "enforce"
+	// ModeWarn admits the request with a warning returned to the client.
+	ModeWarn Mode = "warn"
+	// ModeAudit admits the request and records the failure in the audit log.
+	ModeAudit Mode = "audit"
+)
+
+// ParseMode returns the mode named s.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case ModeEnforce, ModeWarn, ModeAudit:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid admission mode %q, supported modes: enforce, warn, audit", s)
+}
+
+// Modes holds the mode of every control.
+type Modes struct {
+	Default  Mode
+	Controls map[string]Mode // keyed by control ID
+}
+
+// NewModes returns modes defaulting to defaultMode, with the controls of each
+// list set to its mode. A control given in two lists takes the strictest one.
+func NewModes(defaultMode string, enforce, warn, audit []string) (Modes, error) {
+	mode, err := ParseMode(defaultMode)
+	if err != nil {
+		return Modes{}, err
+	}
+	modes := Modes{Default: mode, Controls: map[string]Mode{}}
+	// Lists are applied from the most to the least strict, so the strictest
+	// mode of a control wins.
+	for _, list := range []struct {
+		mode     Mode
+		controls []string
+	}{{ModeEnforce, enforce}, {ModeWarn, warn}, {ModeAudit, audit}} {
+		for _, controlID := range list.controls {
+			controlID = strings.TrimSpace(controlID)
+			if controlID == "" {
+				continue
+			}
+			if _, ok := modes.Controls[controlID]; !ok {
+				modes.Controls[controlID] = list.mode
+			}
+		}
+	}
+	return modes, nil
+}
+
+// Of returns the mode of controlID.
+func (m Modes) Of(controlID string) Mode {
+	if mode, ok := m.Controls[controlID]; ok {
+		return mode
+	}
+	return m.Default
+}
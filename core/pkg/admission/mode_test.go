@@ -0,0 +1,26 @@
+package admission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewModes(t *testing.T) {
+	modes, err := NewModes("Audit", []string{"C-0057"}, []string{"C-0016", "C-0057"}, []string{" C-0017 ", ""})
+	require.NoError(t, err)
+
+	assert.Equal(t, ModeEnforce, modes.Of("C-0057"), "the strictest mode wins")
+	assert.Equal(t, ModeWarn, modes.Of("C-0016"))
+	assert.Equal(t, ModeAudit, modes.Of("C-0017"))
+	assert.Equal(t, ModeAudit, modes.Of("C-0001"), "other controls take the default mode")
+}
+
+func TestParseModeRejectsUnknownModes(t *testing.T) {
+	_, err := ParseMode("deny")
+	require.ErrorContains(t, err, `invalid admission mode "deny"`)
+
+	_, err = NewModes("block", nil, nil, nil)
+	require.Error(t, err)
+}
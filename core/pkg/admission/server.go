@@ -0,0 +1,48 @@
+package admission
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+)
+
+// ValidatePath is the path the webhook configuration sends AdmissionReviews to.
+const ValidatePath = "/validate"
+
+// shutdownTimeout bounds how long in-flight reviews may finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
+// Serve serves handler over TLS on addr at ValidatePath, with a /healthz
+// probe, until ctx is done.
+func Serve(ctx context.Context, addr, certFile, keyFile string, handler http.Handler) error {
+	mux := http.NewServeMux()
+	mux.Handle(ValidatePath, handler)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdownErr <- server.Shutdown(shutdownCtx)
+	}()
+
+	logger.L().Info("Serving admission reviews", helpers.String("address", addr), helpers.String("path", ValidatePath))
+	if err := server.ListenAndServeTLS(certFile, keyFile); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-shutdownErr
+}
@@ -130,6 +130,16 @@ func transformSession(session *cautils.OPASessionObj, _ *Mapping, transformer Tr
 	}
 	session.ResourceSource = newResourceSource
 
+	newResourceOwners := make(map[string]string, len(session.ResourceOwners))
+	for oldID, owner := range session.ResourceOwners {
+		newID, err := resolveMappedID(transformer, idMapping, oldID, "ref")
+		if err != nil {
+			return err
+		}
+		newResourceOwners[newID] = owner
+	}
+	session.ResourceOwners = newResourceOwners
+
 	newResourcesPrioritized := make(map[string]prioritization.PrioritizedResource, len(session.ResourcesPrioritized))
 	for oldID, prioritized := range session.ResourcesPrioritized {
 		newID, err := resolveMappedID(transformer, idMapping, oldID, "ref")
@@ -23,6 +23,9 @@ type FixHandler struct {
 	// fixedControlsCount is the number of failed (resource, control) tuples that
 	// produced at least one yaml expression to apply.
 	fixedControlsCount int
+	// fixedControls records the tuples counted in fixedControlsCount, for
+	// callers that report which controls were fixed (e.g. fix --open-pr).
+	fixedControls []FixedControl
 }
 
 // ResourceFixInfo is a struct that holds the information about the resource that needs to be fixed
@@ -42,7 +45,7 @@ type ResourceFixInfo struct {
 // values.yaml deliberately.
 type HelmFixSuggestion struct {
 	Resource     *reporthandling.Resource
-	ChartPath    string              // on-disk chart root (Source.HelmPath)
+	ChartPath    string              // on-disk chart root, or helm://<namespace>/<release> for a deployed release (Source.HelmPath)
 	ChartName    string              // Source.HelmChartName
 	TemplateFile string              // chart-relative, e.g. "templates/deployment.yaml"
 	ValuesPaths  []string            // candidate dotted .Values.* keys referenced by the template; may be empty
@@ -62,6 +65,16 @@ type UnfixedControl struct {
 	Reason string
 }
 
+// FixedControl describes a failed (resource, control) tuple that `kubescape fix`
+// produced an automatic remediation for.
+type FixedControl struct {
+	ControlID    string
+	ControlName  string
+	ResourceName string
+	ResourceKind string
+	FilePath     string
+}
+
 // NodeInfo holds extra information about the node
 type nodeInfo struct {
 	node   *yaml.Node
@@ -18,6 +18,7 @@ import (
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/helmrelease"
 	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
 	"github.com/kubescape/opa-utils/objectsenvelopes"
 	"github.com/kubescape/opa-utils/objectsenvelopes/localworkload"
@@ -278,6 +279,7 @@ func (h *FixHandler) PrepareResourcesToFix(ctx context.Context) []ResourceFixInf
 	resourcesToFix := make([]ResourceFixInfo, 0)
 	h.unfixedControls = h.unfixedControls[:0]
 	h.fixedControlsCount = 0
+	h.fixedControls = h.fixedControls[:0]
 
 	var containerProfile *storagev1beta1.ContainerProfile
 	if h.fixInfo != nil && h.fixInfo.ContainerProfilePath != "" {
@@ -327,6 +329,9 @@ func (h *FixHandler) PrepareResourcesToFix(ctx context.Context) []ResourceFixInf
 		skipReason := ""
 		if resourcePath == "" {
 			skipReason = "skipped: resource has no local file path"
+		} else if resourceObj.Source != nil && (resourceObj.Source.FileType == cautils.SourceTypeJsonnet || resourceObj.Source.FileType == cautils.SourceTypeCUE) {
+			// evaluated output cannot be patched in place; point at the file to edit instead
+			skipReason = fmt.Sprintf("skipped: generated by %s source %s", resourceObj.Source.FileType, resourceObj.Source.RelativePath)
 		} else if resourceObj.Source == nil || resourceObj.Source.FileType != reporthandling.SourceTypeYaml {
 			skipReason = "skipped: source is not a YAML file"
 		}
@@ -407,7 +412,7 @@ func (h *FixHandler) PrepareResourcesToFix(ctx context.Context) []ResourceFixInf
 
 			// Fully auto-remediated: every failed path produced an expression.
 			if added > 0 && len(skipped) == 0 {
-				h.fixedControlsCount++
+				h.recordFixedControl(ac, resourceObj, absolutePath)
 				continue
 			}
 
@@ -443,7 +448,7 @@ func (h *FixHandler) PrepareResourcesToFix(ctx context.Context) []ResourceFixInf
 		plannedPaths := plannedPathsFromExpressions(rfi.YamlExpressions)
 		for _, pu := range tentativeUnfixed {
 			if len(plannedPaths) > 0 && controlIsCoveredByPlannedPaths(pu.ac, plannedPaths) {
-				h.fixedControlsCount++
+				h.recordFixedControl(pu.ac, resourceObj, absolutePath)
 				continue
 			}
 			h.unfixedControls = append(h.unfixedControls, pu.entry)
@@ -571,7 +576,12 @@ func (h *FixHandler) PrintHelmSuggestions(suggestions []HelmFixSuggestion) {
 	var sb strings.Builder
 	sb.WriteString("\nHelm-rendered resources cannot be patched in place. Suggested values.yaml edits:\n\n")
 	for _, s := range suggestions {
-		fmt.Fprintf(&sb, "Chart: %s (%s)\n", s.ChartName, s.ChartPath)
+		if release, ok := strings.CutPrefix(s.ChartPath, helmrelease.PathPrefix); ok {
+			// Deployed releases are upgraded with new values, not edited on disk.
+			fmt.Fprintf(&sb, "Chart: %s (deployed as release %s; apply the edits with helm upgrade)\n", s.ChartName, release)
+		} else {
+			fmt.Fprintf(&sb, "Chart: %s (%s)\n", s.ChartName, s.ChartPath)
+		}
 		if s.TemplateFile != "" {
 			fmt.Fprintf(&sb, "Template: %s\n", s.TemplateFile)
 		}
@@ -609,6 +619,39 @@ func (h *FixHandler) FixedControlsCount() int {
 	return h.fixedControlsCount
 }
 
+// FixedControls returns the failed (resource, control) tuples counted by
+// FixedControlsCount during the most recent call to PrepareResourcesToFix.
+func (h *FixHandler) FixedControls() []FixedControl {
+	out := make([]FixedControl, len(h.fixedControls))
+	copy(out, h.fixedControls)
+	return out
+}
+
+func (h *FixHandler) recordFixedControl(ac *resourcesresults.ResourceAssociatedControl, resourceObj *reporthandling.Resource, filePath string) {
+	h.fixedControlsCount++
+	h.fixedControls = append(h.fixedControls, FixedControl{
+		ControlID:    ac.GetID(),
+		ControlName:  ac.GetName(),
+		ResourceName: resourceObj.GetName(),
+		ResourceKind: resourceObj.GetKind(),
+		FilePath:     sanitizeForLog(filePath),
+	})
+}
+
+// RepoContext returns the git repository context the report was scanned in,
+// or nil when the report records none.
+func (h *FixHandler) RepoContext() *reporthandlingv2.RepoContextMetadata {
+	if h.reportObj == nil {
+		return nil
+	}
+	return h.reportObj.Metadata.ContextMetadata.RepoContextMetadata
+}
+
+// LocalBasePath returns the local directory the report's resources resolve against.
+func (h *FixHandler) LocalBasePath() string {
+	return h.localBasePath
+}
+
 // Phase tells PrintUnfixedControls whether the fixer has already written the
 // planned changes to disk or is still in a planning state (dry-run, declined
 // confirm, partial apply). The summary line phrases the verb accordingly.
@@ -0,0 +1,188 @@
+package pullrequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+
+	defaultGitHubAPIURL = "https://api.github.com"
+	defaultGitLabAPIURL = "https://gitlab.com/api/v4"
+
+	// maxForgeResponseBytes caps how much of a forge API response we buffer.
+	// The bodies we care about are a few KB; anything larger is an error page
+	// or a misconfigured --forge-api-url and is not worth holding in memory.
+	maxForgeResponseBytes = 1 << 20
+)
+
+// Request is the forge-agnostic description of the pull (or merge) request to open.
+type Request struct {
+	Title string
+	Body  string
+	// Head is the branch carrying the fixes, Base the branch it targets.
+	Head string
+	Base string
+}
+
+// Result identifies the pull request the forge created.
+type Result struct {
+	Number int
+	URL    string
+}
+
+// Forge opens pull requests against a hosted repository.
+type Forge interface {
+	OpenPullRequest(ctx context.Context, req Request) (*Result, error)
+}
+
+// NewForge returns the Forge for provider, as recorded in the report's
+// RepoContextMetadata.Provider. apiURL may be empty to use the public
+// github.com / gitlab.com endpoints; self-hosted instances must set it.
+func NewForge(provider, apiURL, owner, repo, token string) (Forge, error) {
+	if owner == "" || repo == "" {
+		return nil, fmt.Errorf("cannot open a pull request: the report does not record the repository owner and name")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("cannot open a pull request on %s: no API token found (set %s)", provider, strings.Join(tokenEnvVars(provider), " or "))
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch strings.ToLower(provider) {
+	case ProviderGitHub:
+		if apiURL == "" {
+			apiURL = defaultGitHubAPIURL
+		}
+		return &gitHubForge{baseURL: strings.TrimRight(apiURL, "/"), owner: owner, repo: repo, token: token, httpClient: client}, nil
+	case ProviderGitLab:
+		if apiURL == "" {
+			apiURL = defaultGitLabAPIURL
+		}
+		return &gitLabForge{baseURL: strings.TrimRight(apiURL, "/"), project: owner + "/" + repo, token: token, httpClient: client}, nil
+	default:
+		return nil, fmt.Errorf("cannot open a pull request: unsupported git provider %q (supported: %s, %s)", provider, ProviderGitHub, ProviderGitLab)
+	}
+}
+
+// tokenEnvVars lists, in lookup order, the environment variables a token for
+// provider is read from.
+func tokenEnvVars(provider string) []string {
+	switch strings.ToLower(provider) {
+	case ProviderGitHub:
+		return []string{"KS_FORGE_TOKEN", "GITHUB_TOKEN"}
+	case ProviderGitLab:
+		return []string{"KS_FORGE_TOKEN", "GITLAB_TOKEN"}
+	default:
+		return []string{"KS_FORGE_TOKEN"}
+	}
+}
+
+type gitHubForge struct {
+	baseURL    string
+	owner      string
+	repo       string
+	token      string
+	httpClient *http.Client
+}
+
+func (f *gitHubForge) OpenPullRequest(ctx context.Context, req Request) (*Result, error) {
+	payload := map[string]any{
+		"title": req.Title,
+		"body":  req.Body,
+		"head":  req.Head,
+		"base":  req.Base,
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls", f.baseURL, url.PathEscape(f.owner), url.PathEscape(f.repo))
+	headers := map[string]string{
+		"Authorization":        "Bearer " + f.token,
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+
+	var resp struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := postJSON(ctx, f.httpClient, endpoint, headers, payload, &resp); err != nil {
+		return nil, fmt.Errorf("github: failed to open pull request: %w", err)
+	}
+	return &Result{Number: resp.Number, URL: resp.HTMLURL}, nil
+}
+
+type gitLabForge struct {
+	baseURL string
+	// project is the "group/subgroup/project" path; GitLab accepts it URL-encoded in place of the numeric ID.
+	project    string
+	token      string
+	httpClient *http.Client
+}
+
+func (f *gitLabForge) OpenPullRequest(ctx context.Context, req Request) (*Result, error) {
+	payload := map[string]any{
+		"title":                req.Title,
+		"description":          req.Body,
+		"source_branch":        req.Head,
+		"target_branch":        req.Base,
+		"remove_source_branch": true,
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests", f.baseURL, url.PathEscape(f.project))
+	headers := map[string]string{
+		"PRIVATE-TOKEN": f.token,
+		"Accept":        "application/json",
+	}
+
+	var resp struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	if err := postJSON(ctx, f.httpClient, endpoint, headers, payload, &resp); err != nil {
+		return nil, fmt.Errorf("gitlab: failed to open merge request: %w", err)
+	}
+	return &Result{Number: resp.IID, URL: resp.WebURL}, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxForgeResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet := string(respBody)
+		if len(snippet) > 200 {
+			snippet = snippet[:200] + "..."
+		}
+		return fmt.Errorf("api returned status %d: %s", resp.StatusCode, snippet)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
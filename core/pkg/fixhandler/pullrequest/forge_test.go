@@ -0,0 +1,98 @@
+package pullrequest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubForgeOpenPullRequest(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 42, "html_url": "https://github.example/acme/manifests/pull/42"}`))
+	}))
+	defer srv.Close()
+
+	forge, err := NewForge("github", srv.URL, "acme", "manifests", "tok")
+	require.NoError(t, err)
+
+	res, err := forge.OpenPullRequest(context.Background(), Request{Title: "t", Body: "b", Head: "kubescape/fix", Base: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, 42, res.Number)
+	assert.Equal(t, "https://github.example/acme/manifests/pull/42", res.URL)
+	assert.Equal(t, "/repos/acme/manifests/pulls", gotPath)
+	assert.Equal(t, "Bearer tok", gotAuth)
+	assert.Equal(t, map[string]any{"title": "t", "body": "b", "head": "kubescape/fix", "base": "main"}, gotBody)
+}
+
+func TestGitLabForgeOpenMergeRequest(t *testing.T) {
+	var gotPath, gotToken string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotToken = r.Header.Get("PRIVATE-TOKEN")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"iid": 7, "web_url": "https://gitlab.example/acme/infra/manifests/-/merge_requests/7"}`))
+	}))
+	defer srv.Close()
+
+	forge, err := NewForge("GitLab", srv.URL+"/api/v4/", "acme/infra", "manifests", "tok")
+	require.NoError(t, err)
+
+	res, err := forge.OpenPullRequest(context.Background(), Request{Title: "t", Body: "b", Head: "kubescape/fix", Base: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, 7, res.Number)
+	assert.Equal(t, "/api/v4/projects/acme%2Finfra%2Fmanifests/merge_requests", gotPath)
+	assert.Equal(t, "tok", gotToken)
+	assert.Equal(t, "kubescape/fix", gotBody["source_branch"])
+	assert.Equal(t, "main", gotBody["target_branch"])
+	assert.Equal(t, "b", gotBody["description"])
+}
+
+func TestForgeErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message": "A pull request already exists"}`))
+	}))
+	defer srv.Close()
+
+	forge, err := NewForge("github", srv.URL, "acme", "manifests", "tok")
+	require.NoError(t, err)
+
+	_, err = forge.OpenPullRequest(context.Background(), Request{Head: "h", Base: "main"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 422")
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestNewForgeValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		owner    string
+		token    string
+		wantErr  string
+	}{
+		{name: "unsupported provider", provider: "bitbucket", owner: "acme", token: "tok", wantErr: "unsupported git provider"},
+		{name: "missing token", provider: "github", owner: "acme", wantErr: "GITHUB_TOKEN"},
+		{name: "missing owner", provider: "gitlab", token: "tok", wantErr: "owner and name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewForge(tt.provider, "", tt.owner, "repo", tt.token)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
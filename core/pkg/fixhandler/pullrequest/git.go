@@ -0,0 +1,137 @@
+package pullrequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitv5 "github.com/go-git/go-git/v5"
+	configv5 "github.com/go-git/go-git/v5/config"
+	plumbingv5 "github.com/go-git/go-git/v5/plumbing"
+	objectv5 "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	transporthttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// fallbackSignature authors the fix commit when neither the repository nor
+// the user's git config names anyone; CI checkouts commonly have no identity.
+var fallbackSignature = objectv5.Signature{Name: "kubescape", Email: "kubescape@users.noreply.github.com"}
+
+// commitAndPush creates opts.Branch at HEAD, commits opts.Files on it and pushes
+// it to opts.Remote. It returns the branch HEAD pointed at before, which is the
+// natural base for the pull request ("" when HEAD was detached).
+func commitAndPush(ctx context.Context, opts Options, message, token string) (string, error) {
+	// EnableDotGitCommonDir for linked worktrees, as in cautils.NewLocalGitRepository.
+	repo, err := gitv5.PlainOpenWithOptions(opts.RepoPath, &gitv5.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to open git repository at %s: %w", opts.RepoPath, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	base := ""
+	if head.Name().IsBranch() {
+		base = head.Name().Short()
+	}
+
+	remote, err := repo.Remote(opts.Remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to find git remote %q: %w", opts.Remote, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to open worktree: %w", err)
+	}
+	root := worktree.Filesystem.Root()
+
+	// Resolve every path before touching the checkout, so a file outside the
+	// repository aborts the whole operation instead of leaving a half-made branch.
+	relPaths := make([]string, 0, len(opts.Files))
+	for _, file := range opts.Files {
+		rel, err := relativeToRoot(root, file)
+		if err != nil {
+			return "", err
+		}
+		relPaths = append(relPaths, rel)
+	}
+
+	branchRef := plumbingv5.NewBranchReferenceName(opts.Branch)
+	if _, err := repo.Reference(branchRef, false); err == nil {
+		return "", fmt.Errorf("branch %q already exists; pass a different --pr-branch", opts.Branch)
+	}
+	// Keep carries the fixes already written to the working tree over to the new branch.
+	if err := worktree.Checkout(&gitv5.CheckoutOptions{Branch: branchRef, Create: true, Keep: true}); err != nil {
+		return "", fmt.Errorf("failed to create branch %q: %w", opts.Branch, err)
+	}
+
+	for _, rel := range relPaths {
+		if _, err := worktree.Add(rel); err != nil {
+			return "", fmt.Errorf("failed to stage %s: %w", rel, err)
+		}
+	}
+
+	commitOpts := &gitv5.CommitOptions{}
+	if err := commitOpts.Validate(repo); errors.Is(err, gitv5.ErrMissingAuthor) {
+		sig := fallbackSignature
+		sig.When = time.Now()
+		commitOpts = &gitv5.CommitOptions{Author: &sig}
+	}
+	if _, err := worktree.Commit(message, commitOpts); err != nil {
+		return "", fmt.Errorf("failed to commit fixes: %w", err)
+	}
+
+	refSpec := configv5.RefSpec(fmt.Sprintf("%s:%s", branchRef, branchRef))
+	err = remote.PushContext(ctx, &gitv5.PushOptions{
+		RemoteName: opts.Remote,
+		RefSpecs:   []configv5.RefSpec{refSpec},
+		Auth:       pushAuth(remote.Config().URLs, opts.Provider, token),
+	})
+	if err != nil && !errors.Is(err, gitv5.NoErrAlreadyUpToDate) {
+		return "", fmt.Errorf("failed to push branch %q to %q: %w", opts.Branch, opts.Remote, err)
+	}
+
+	return base, nil
+}
+
+// pushAuth authenticates HTTP(S) pushes with the same token used for the forge
+// API. SSH remotes keep go-git's default (the SSH agent), since an API token is
+// no use there.
+func pushAuth(urls []string, provider, token string) transport.AuthMethod {
+	if token == "" || len(urls) == 0 {
+		return nil
+	}
+	if !strings.HasPrefix(urls[0], "https://") && !strings.HasPrefix(urls[0], "http://") {
+		return nil
+	}
+	username := "x-access-token"
+	if strings.EqualFold(provider, ProviderGitLab) {
+		username = "oauth2"
+	}
+	return &transporthttp.BasicAuth{Username: username, Password: token}
+}
+
+func relativeToRoot(root, file string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	// The worktree root is what go-git resolved, possibly through symlinks
+	// (e.g. /tmp on macOS); resolve the file the same way before comparing.
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if resolvedRoot, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolvedRoot
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the git repository at %s", file, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,210 @@
+// Package pullrequest publishes the edits `kubescape fix` made to a local git
+// checkout as a branch, a commit and a GitHub pull request / GitLab merge request.
+package pullrequest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Control is one (control, resource) finding, fixed or not, as shown in the
+// commit message and the pull request body.
+type Control struct {
+	ControlID    string
+	ControlName  string
+	ResourceKind string
+	ResourceName string
+	FilePath     string
+	// Reason is only set for unfixed controls.
+	Reason string
+}
+
+// Options configures Open.
+type Options struct {
+	// RepoPath is the root of the local git checkout the fixes were written to.
+	RepoPath string
+	// Files are the absolute paths of the files fix modified; only these are committed.
+	Files []string
+	// Branch is the branch to create; defaults to "kubescape/fix-<timestamp>".
+	Branch string
+	// Base is the branch the pull request targets; defaults to the branch checked out when fix ran.
+	Base string
+	// Remote is the git remote to push to; defaults to "origin".
+	Remote string
+	// Provider picks the username HTTP pushes authenticate with (see pushAuth).
+	Provider string
+
+	Fixed   []Control
+	Unfixed []Control
+}
+
+// Open commits opts.Files on a new branch, pushes it and opens a pull request
+// against it through forge. The checkout is left on the new branch, so the
+// user's own working copy reflects what was proposed.
+func Open(ctx context.Context, forge Forge, token string, opts Options) (*Result, error) {
+	if len(opts.Files) == 0 {
+		return nil, fmt.Errorf("cannot open a pull request: no files were changed")
+	}
+	if opts.Branch == "" {
+		opts.Branch = DefaultBranchName(time.Now())
+	}
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+
+	base, err := commitAndPush(ctx, opts, CommitMessage(opts.Fixed), token)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Base == "" {
+		opts.Base = base
+	}
+	if opts.Base == "" {
+		return nil, fmt.Errorf("cannot open a pull request: HEAD was detached and no base branch was given")
+	}
+
+	return forge.OpenPullRequest(ctx, Request{
+		Title: Title(opts.Fixed),
+		Body:  Body(opts.Fixed, opts.Unfixed),
+		Head:  opts.Branch,
+		Base:  opts.Base,
+	})
+}
+
+// TokenFromEnv returns the first non-empty API token configured for provider.
+func TokenFromEnv(provider string) string {
+	for _, name := range tokenEnvVars(provider) {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// DefaultBranchName is the branch used when the caller does not pick one.
+func DefaultBranchName(now time.Time) string {
+	return "kubescape/fix-" + now.UTC().Format("20060102-150405")
+}
+
+// controlIDs returns the distinct control IDs in controls, sorted, together
+// with the name recorded for each.
+func controlIDs(controls []Control) ([]string, map[string]string) {
+	names := make(map[string]string)
+	for _, c := range controls {
+		if _, ok := names[c.ControlID]; !ok || names[c.ControlID] == "" {
+			names[c.ControlID] = c.ControlName
+		}
+	}
+	ids := make([]string, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, names
+}
+
+// Title is the pull request title: the fixed control IDs, or a generic title
+// when there are too many to list.
+func Title(fixed []Control) string {
+	ids, _ := controlIDs(fixed)
+	switch {
+	case len(ids) == 0:
+		return "Kubescape: fix misconfigurations"
+	case len(ids) <= 3:
+		return "Kubescape: fix " + strings.Join(ids, ", ")
+	default:
+		return fmt.Sprintf("Kubescape: fix %d controls", len(ids))
+	}
+}
+
+// CommitMessage is the title followed by one line per fixed control with the
+// resources it was fixed on.
+func CommitMessage(fixed []Control) string {
+	ids, names := controlIDs(fixed)
+	resources := make(map[string][]string, len(ids))
+	for _, c := range fixed {
+		resources[c.ControlID] = appendUnique(resources[c.ControlID], c.ResourceKind+"/"+c.ResourceName)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(Title(fixed))
+	sb.WriteString("\n\n")
+	for _, id := range ids {
+		fmt.Fprintf(&sb, "- %s %s: %s\n", id, names[id], strings.Join(resources[id], ", "))
+	}
+	return sb.String()
+}
+
+// Body renders the pull request description as markdown: a table of the
+// fixed findings and, when there are any, the findings left for a human.
+func Body(fixed, unfixed []Control) string {
+	var sb strings.Builder
+	sb.WriteString("This pull request was opened by `kubescape fix --open-pr`.\n\n")
+
+	fixed, unfixed = sortedControls(fixed), sortedControls(unfixed)
+	fmt.Fprintf(&sb, "### Fixed (%d)\n\n", len(fixed))
+	if len(fixed) == 0 {
+		sb.WriteString("_No control was fully fixed; see the manual remediation list below._\n")
+	} else {
+		sb.WriteString("| Control | Resource | File |\n|---|---|---|\n")
+		for _, c := range fixed {
+			fmt.Fprintf(&sb, "| %s %s | %s/%s | `%s` |\n", c.ControlID, escapeCell(c.ControlName), c.ResourceKind, c.ResourceName, c.FilePath)
+		}
+	}
+
+	if len(unfixed) > 0 {
+		fmt.Fprintf(&sb, "\n### Requires manual remediation (%d)\n\n", len(unfixed))
+		sb.WriteString("| Control | Resource | File | Reason |\n|---|---|---|---|\n")
+		for _, c := range unfixed {
+			location := c.FilePath
+			if location == "" {
+				location = "<unknown>"
+			}
+			fmt.Fprintf(&sb, "| %s %s | %s/%s | `%s` | %s |\n", c.ControlID, escapeCell(c.ControlName), c.ResourceKind, c.ResourceName, location, escapeCell(c.Reason))
+		}
+	}
+	return sb.String()
+}
+
+// sortedControls returns controls deduplicated by control, resource and file,
+// in a stable order so re-running fix yields the same body.
+func sortedControls(controls []Control) []Control {
+	seen := make(map[string]bool, len(controls))
+	out := make([]Control, 0, len(controls))
+	for _, c := range controls {
+		key := c.ControlID + "|" + c.ResourceKind + "/" + c.ResourceName + "|" + c.FilePath
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].ControlID != out[j].ControlID {
+			return out[i].ControlID < out[j].ControlID
+		}
+		if out[i].FilePath != out[j].FilePath {
+			return out[i].FilePath < out[j].FilePath
+		}
+		return out[i].ResourceName < out[j].ResourceName
+	})
+	return out
+}
+
+// escapeCell keeps a free-text value from breaking out of its markdown table cell.
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "\r", " ").Replace(s)
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, existing := range list {
+		if existing == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
@@ -0,0 +1,164 @@
+package pullrequest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gitv5 "github.com/go-git/go-git/v5"
+	configv5 "github.com/go-git/go-git/v5/config"
+	plumbingv5 "github.com/go-git/go-git/v5/plumbing"
+	objectv5 "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	fixedControls = []Control{
+		{ControlID: "C-0017", ControlName: "Immutable container filesystem", ResourceKind: "Deployment", ResourceName: "web", FilePath: "deploy/web.yaml"},
+		{ControlID: "C-0016", ControlName: "Allow privilege escalation", ResourceKind: "Deployment", ResourceName: "web", FilePath: "deploy/web.yaml"},
+		{ControlID: "C-0016", ControlName: "Allow privilege escalation", ResourceKind: "Deployment", ResourceName: "api", FilePath: "deploy/api.yaml"},
+	}
+	unfixedControls = []Control{
+		{ControlID: "C-0270", ControlName: "Ensure CPU limits are set", ResourceKind: "Deployment", ResourceName: "web", FilePath: "deploy/web.yaml", Reason: "skipped: value | requires user input"},
+	}
+)
+
+// newRepoWithRemote creates a bare "remote" and a clone of it with one commit
+// on main, returning the clone's path.
+func newRepoWithRemote(t *testing.T) (string, *gitv5.Repository) {
+	t.Helper()
+	remotePath := filepath.Join(t.TempDir(), "remote.git")
+	remote, err := gitv5.PlainInit(remotePath, true)
+	require.NoError(t, err)
+
+	localPath := filepath.Join(t.TempDir(), "local")
+	local, err := gitv5.PlainInitWithOptions(localPath, &gitv5.PlainInitOptions{
+		InitOptions: gitv5.InitOptions{DefaultBranch: plumbingv5.NewBranchReferenceName("main")},
+	})
+	require.NoError(t, err)
+	_, err = local.CreateRemote(&configv5.RemoteConfig{Name: "origin", URLs: []string{remotePath}})
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(localPath, "deploy"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(localPath, "deploy", "web.yaml"), []byte("kind: Deployment\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(localPath, "deploy", "other.yaml"), []byte("kind: Service\n"), 0o600))
+	wt, err := local.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("deploy")
+	require.NoError(t, err)
+	_, err = wt.Commit("initial", &gitv5.CommitOptions{Author: &objectv5.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()}})
+	require.NoError(t, err)
+	require.NoError(t, local.Push(&gitv5.PushOptions{RemoteName: "origin", RefSpecs: []configv5.RefSpec{"refs/heads/main:refs/heads/main"}}))
+
+	return localPath, remote
+}
+
+func TestOpen(t *testing.T) {
+	localPath, remote := newRepoWithRemote(t)
+
+	// Simulate kubescape fix: one planned file edited, one unrelated local edit that must not be committed.
+	fixedFile := filepath.Join(localPath, "deploy", "web.yaml")
+	require.NoError(t, os.WriteFile(fixedFile, []byte("kind: Deployment\nspec: {}\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(localPath, "deploy", "other.yaml"), []byte("kind: Service\n# wip\n"), 0o600))
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 1, "html_url": "https://github.example/acme/manifests/pull/1"}`))
+	}))
+	defer srv.Close()
+
+	forge, err := NewForge(ProviderGitHub, srv.URL, "acme", "manifests", "tok")
+	require.NoError(t, err)
+
+	res, err := Open(context.Background(), forge, "tok", Options{
+		RepoPath: filepath.Join(localPath, "deploy"),
+		Files:    []string{fixedFile},
+		Branch:   "kubescape/fix-test",
+		Fixed:    fixedControls,
+		Unfixed:  unfixedControls,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.example/acme/manifests/pull/1", res.URL)
+	assert.Equal(t, "kubescape/fix-test", got["head"])
+	assert.Equal(t, "main", got["base"])
+	assert.Equal(t, "Kubescape: fix C-0016, C-0017", got["title"])
+
+	// The branch reached the remote with exactly the fixed file changed.
+	ref, err := remote.Reference(plumbingv5.NewBranchReferenceName("kubescape/fix-test"), true)
+	require.NoError(t, err)
+	commit, err := remote.CommitObject(ref.Hash())
+	require.NoError(t, err)
+	assert.Contains(t, commit.Message, "- C-0016 Allow privilege escalation: Deployment/web, Deployment/api")
+	assert.Contains(t, commit.Message, "- C-0017 Immutable container filesystem: Deployment/web")
+	parent, err := commit.Parent(0)
+	require.NoError(t, err)
+	patch, err := parent.Patch(commit)
+	require.NoError(t, err)
+	require.Len(t, patch.FilePatches(), 1)
+	_, to := patch.FilePatches()[0].Files()
+	assert.Equal(t, "deploy/web.yaml", to.Path())
+
+	// The unrelated edit is still in the working tree, uncommitted.
+	content, err := os.ReadFile(filepath.Join(localPath, "deploy", "other.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "# wip")
+}
+
+func TestOpenRejectsFilesOutsideRepo(t *testing.T) {
+	localPath, _ := newRepoWithRemote(t)
+	outside := filepath.Join(t.TempDir(), "x.yaml")
+	require.NoError(t, os.WriteFile(outside, []byte("kind: Pod\n"), 0o600))
+
+	forge, err := NewForge(ProviderGitHub, "http://127.0.0.1:0", "acme", "manifests", "tok")
+	require.NoError(t, err)
+	_, err = Open(context.Background(), forge, "tok", Options{RepoPath: localPath, Files: []string{outside}, Branch: "b"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside the git repository")
+
+	// Nothing was created.
+	repo, err := gitv5.PlainOpen(localPath)
+	require.NoError(t, err)
+	_, err = repo.Reference(plumbingv5.NewBranchReferenceName("b"), false)
+	assert.Error(t, err)
+}
+
+func TestOpenRejectsExistingBranch(t *testing.T) {
+	localPath, _ := newRepoWithRemote(t)
+	forge, err := NewForge(ProviderGitHub, "http://127.0.0.1:0", "acme", "manifests", "tok")
+	require.NoError(t, err)
+	_, err = Open(context.Background(), forge, "tok", Options{RepoPath: localPath, Files: []string{filepath.Join(localPath, "deploy", "web.yaml")}, Branch: "main"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestBody(t *testing.T) {
+	body := Body(fixedControls, unfixedControls)
+	assert.Contains(t, body, "### Fixed (3)")
+	assert.Contains(t, body, "| C-0016 Allow privilege escalation | Deployment/api | `deploy/api.yaml` |")
+	assert.Contains(t, body, "### Requires manual remediation (1)")
+	assert.Contains(t, body, `skipped: value \| requires user input`)
+
+	assert.NotContains(t, Body(fixedControls, nil), "manual remediation")
+	assert.Contains(t, Body(nil, unfixedControls), "No control was fully fixed")
+}
+
+func TestTitle(t *testing.T) {
+	assert.Equal(t, "Kubescape: fix misconfigurations", Title(nil))
+	assert.Equal(t, "Kubescape: fix C-0016, C-0017", Title(fixedControls))
+	many := append([]Control{{ControlID: "C-0001"}, {ControlID: "C-0002"}}, fixedControls...)
+	assert.Equal(t, "Kubescape: fix 4 controls", Title(many))
+}
+
+func TestPushAuth(t *testing.T) {
+	assert.Nil(t, pushAuth([]string{"git@github.com:acme/manifests.git"}, ProviderGitHub, "tok"))
+	assert.Nil(t, pushAuth([]string{"https://github.com/acme/manifests.git"}, ProviderGitHub, ""))
+	assert.NotNil(t, pushAuth([]string{"https://gitlab.com/acme/manifests.git"}, ProviderGitLab, "tok"))
+}
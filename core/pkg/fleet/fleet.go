@@ -0,0 +1,251 @@
+// Package fleet aggregates the posture scans of several clusters into one
+// report: a score table with a row per cluster and the controls that fail in
+// the most clusters. It only depends on the plain result types below, so the
+// same report can be built from live scans and from saved reports.
+package fleet
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// ControlResult is one control's outcome in one cluster.
+type ControlResult struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Severity        string  `json:"severity"`
+	Status          string  `json:"status"`
+	ComplianceScore float32 `json:"complianceScore"`
+	FailedResources int     `json:"failedResources"`
+	AllResources    int     `json:"allResources"`
+}
+
+// ResourceResult is a resource that failed at least one control.
+type ResourceResult struct {
+	ID             string   `json:"resourceID"`
+	Kind           string   `json:"kind,omitempty"`
+	Namespace      string   `json:"namespace,omitempty"`
+	Name           string   `json:"name,omitempty"`
+	FailedControls []string `json:"failedControls"`
+}
+
+// FrameworkScore is a framework's compliance score in one cluster.
+type FrameworkScore struct {
+	Name            string  `json:"name"`
+	ComplianceScore float32 `json:"complianceScore"`
+}
+
+// ClusterResult is the outcome of scanning one cluster. Error is set when the
+// scan did not complete (or its results could not be submitted); a cluster
+// that was never scanned has no controls.
+type ClusterResult struct {
+	Name string `json:"name"`
+	// Source is the saved report the cluster was read from, if any.
+	Source          string            `json:"source,omitempty"`
+	ComplianceScore float32           `json:"complianceScore"`
+	Frameworks      []FrameworkScore  `json:"frameworks,omitempty"`
+	FailedResources int               `json:"failedResources"`
+	AllResources    int               `json:"allResources"`
+	Controls        []ControlResult   `json:"controls,omitempty"`
+	Resources       []ResourceResult  `json:"resources,omitempty"`
+	Namespaces      []NamespaceResult `json:"namespaces,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// Scanned reports whether the cluster has results to aggregate.
+func (c ClusterResult) Scanned() bool {
+	return len(c.Controls) > 0
+}
+
+// FailedControls returns the number of controls that failed in the cluster.
+func (c ClusterResult) FailedControls() int {
+	failed := 0
+	for _, control := range c.Controls {
+		if control.Status == StatusFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// FleetControl is a control's outcome across the fleet.
+type FleetControl struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Severity string `json:"severity"`
+	// FailedClusters are the clusters in which the control failed.
+	FailedClusters []string `json:"failedClusters"`
+	// ScannedClusters is the number of clusters the control was evaluated in.
+	ScannedClusters int `json:"scannedClusters"`
+	FailedResources int `json:"failedResources"`
+	// ComplianceScore is the average of the control's score over the clusters
+	// it was evaluated in.
+	ComplianceScore float32 `json:"complianceScore"`
+}
+
+// Report is the aggregated result of a fleet scan.
+type Report struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Frameworks  []string  `json:"frameworks,omitempty"`
+	// FrameworkVersions maps each framework to the version the clusters were
+	// scanned with, when known.
+	FrameworkVersions map[string]string `json:"frameworkVersions,omitempty"`
+	// ComplianceScore is the average of the scanned clusters' scores.
+	ComplianceScore float32         `json:"complianceScore"`
+	Clusters        []ClusterResult `json:"clusters"`
+	// WorstControls are the controls that failed in at least one cluster,
+	// worst first: failing in more clusters, then more severe, then failing
+	// more resources.
+	WorstControls []FleetControl `json:"worstControls,omitempty"`
+}
+
+// NewReport aggregates the results of the given clusters. Clusters keep their
+// order; their controls and resources are sorted by ID.
+func NewReport(frameworks []string, clusters []ClusterResult) *Report {
+	report := &Report{
+		GeneratedAt: time.Now().UTC(),
+		Frameworks:  frameworks,
+		Clusters:    clusters,
+	}
+
+	byID := map[string]*FleetControl{}
+	scores := map[string]float32{}
+	var totalScore float32
+	scanned := 0
+	for i := range clusters {
+		cluster := &clusters[i]
+		sort.Slice(cluster.Controls, func(a, b int) bool { return cluster.Controls[a].ID < cluster.Controls[b].ID })
+		sort.Slice(cluster.Resources, func(a, b int) bool { return cluster.Resources[a].ID < cluster.Resources[b].ID })
+		if !cluster.Scanned() {
+			continue
+		}
+		scanned++
+		totalScore += cluster.ComplianceScore
+
+		for _, control := range cluster.Controls {
+			if control.Status == StatusSkipped {
+				continue
+			}
+			fc, ok := byID[control.ID]
+			if !ok {
+				fc = &FleetControl{ID: control.ID, Name: control.Name, Severity: control.Severity, FailedClusters: []string{}}
+				byID[control.ID] = fc
+			}
+			fc.ScannedClusters++
+			scores[control.ID] += control.ComplianceScore
+			if control.Status == StatusFailed {
+				fc.FailedClusters = append(fc.FailedClusters, cluster.Name)
+				fc.FailedResources += control.FailedResources
+			}
+		}
+	}
+	if scanned > 0 {
+		report.ComplianceScore = totalScore / float32(scanned)
+	}
+
+	for id, fc := range byID {
+		if len(fc.FailedClusters) == 0 {
+			continue
+		}
+		fc.ComplianceScore = scores[id] / float32(fc.ScannedClusters)
+		report.WorstControls = append(report.WorstControls, *fc)
+	}
+	sort.Slice(report.WorstControls, func(i, j int) bool {
+		a, b := report.WorstControls[i], report.WorstControls[j]
+		if len(a.FailedClusters) != len(b.FailedClusters) {
+			return len(a.FailedClusters) > len(b.FailedClusters)
+		}
+		if SeverityRank(a.Severity) != SeverityRank(b.Severity) {
+			return SeverityRank(a.Severity) > SeverityRank(b.Severity)
+		}
+		if a.FailedResources != b.FailedResources {
+			return a.FailedResources > b.FailedResources
+		}
+		return a.ID < b.ID
+	})
+	return report
+}
+
+// Failed returns the clusters whose scan reported an error.
+func (r *Report) Failed() []ClusterResult {
+	var failed []ClusterResult
+	for _, c := range r.Clusters {
+		if c.Error != "" {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// HeatmapCell is a control's outcome in one cluster of the heatmap. Status is
+// empty when the control was not part of the cluster's scan.
+type HeatmapCell struct {
+	Status          string
+	ComplianceScore float32
+	FailedResources int
+}
+
+// HeatmapRow is a control's outcome in every cluster, in cluster order.
+type HeatmapRow struct {
+	ID       string
+	Name     string
+	Severity string
+	Cells    []HeatmapCell
+}
+
+// Heatmap lays out every control against every cluster: the worst controls
+// first, in WorstControls order, then the controls that failed nowhere, by ID.
+func (r *Report) Heatmap() []HeatmapRow {
+	rows := map[string]*HeatmapRow{}
+	for i, cluster := range r.Clusters {
+		for _, control := range cluster.Controls {
+			row, ok := rows[control.ID]
+			if !ok {
+				row = &HeatmapRow{ID: control.ID, Name: control.Name, Severity: control.Severity, Cells: make([]HeatmapCell, len(r.Clusters))}
+				rows[control.ID] = row
+			}
+			row.Cells[i] = HeatmapCell{Status: control.Status, ComplianceScore: control.ComplianceScore, FailedResources: control.FailedResources}
+		}
+	}
+
+	heatmap := make([]HeatmapRow, 0, len(rows))
+	for _, fc := range r.WorstControls {
+		if row, ok := rows[fc.ID]; ok {
+			heatmap = append(heatmap, *row)
+			delete(rows, fc.ID)
+		}
+	}
+	rest := make([]string, 0, len(rows))
+	for id := range rows {
+		rest = append(rest, id)
+	}
+	sort.Strings(rest)
+	for _, id := range rest {
+		heatmap = append(heatmap, *rows[id])
+	}
+	return heatmap
+}
+
+// SeverityRank orders control severities, higher is more severe. Unknown
+// severities rank lowest.
+func SeverityRank(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return 4
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,140 @@
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testClusters() []ClusterResult {
+	return []ClusterResult{
+		{
+			Name:            "prod",
+			ComplianceScore: 60,
+			FailedResources: 3,
+			AllResources:    10,
+			Controls: []ControlResult{
+				{ID: "C-0017", Name: "Immutable container filesystem", Severity: "Low", Status: StatusFailed, ComplianceScore: 50, FailedResources: 2, AllResources: 4},
+				{ID: "C-0002", Name: "Prevent containers from allowing command execution", Severity: "High", Status: StatusFailed, ComplianceScore: 70, FailedResources: 1, AllResources: 4},
+				{ID: "C-0012", Name: "Applications credentials in configuration files", Severity: "High", Status: StatusPassed, ComplianceScore: 100, AllResources: 4},
+			},
+			Resources: []ResourceResult{
+				{ID: "apps/v1/default/Deployment/web", Kind: "Deployment", Namespace: "default", Name: "web", FailedControls: []string{"C-0017", "C-0002"}},
+			},
+		},
+		{
+			Name:            "staging",
+			ComplianceScore: 80,
+			FailedResources: 1,
+			AllResources:    5,
+			Controls: []ControlResult{
+				{ID: "C-0017", Name: "Immutable container filesystem", Severity: "Low", Status: StatusFailed, ComplianceScore: 60, FailedResources: 1, AllResources: 2},
+				{ID: "C-0002", Name: "Prevent containers from allowing command execution", Severity: "High", Status: StatusPassed, ComplianceScore: 100, AllResources: 2},
+				{ID: "C-0012", Name: "Applications credentials in configuration files", Severity: "High", Status: StatusSkipped, ComplianceScore: 0},
+			},
+		},
+		{Name: "edge", Error: "failed connecting to Kubernetes cluster"},
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	report := NewReport([]string{"nsa"}, testClusters())
+
+	assert.InDelta(t, 70, report.ComplianceScore, 0.001, "the average only counts scanned clusters")
+	require.Len(t, report.Failed(), 1)
+	assert.Equal(t, "edge", report.Failed()[0].Name)
+	assert.Equal(t, "C-0002", report.Clusters[0].Controls[0].ID, "controls are sorted by ID")
+	assert.Equal(t, 2, report.Clusters[0].FailedControls())
+
+	require.Len(t, report.WorstControls, 2, "controls passing everywhere are not listed")
+	worst := report.WorstControls[0]
+	assert.Equal(t, "C-0017", worst.ID, "failing in more clusters ranks before a higher severity")
+	assert.Equal(t, []string{"prod", "staging"}, worst.FailedClusters)
+	assert.Equal(t, 2, worst.ScannedClusters)
+	assert.Equal(t, 3, worst.FailedResources)
+	assert.InDelta(t, 55, worst.ComplianceScore, 0.001)
+
+	second := report.WorstControls[1]
+	assert.Equal(t, "C-0002", second.ID)
+	assert.Equal(t, []string{"prod"}, second.FailedClusters)
+	assert.InDelta(t, 85, second.ComplianceScore, 0.001)
+}
+
+func TestNewReportRanksBySeverityThenResources(t *testing.T) {
+	report := NewReport(nil, []ClusterResult{{
+		Name: "prod",
+		Controls: []ControlResult{
+			{ID: "C-3", Severity: "Medium", Status: StatusFailed, FailedResources: 9},
+			{ID: "C-2", Severity: "Critical", Status: StatusFailed, FailedResources: 1},
+			{ID: "C-1", Severity: "Medium", Status: StatusFailed, FailedResources: 10},
+		},
+	}})
+
+	var ids []string
+	for _, fc := range report.WorstControls {
+		ids = append(ids, fc.ID)
+	}
+	assert.Equal(t, []string{"C-2", "C-1", "C-3"}, ids)
+}
+
+func TestWriters(t *testing.T) {
+	report := NewReport([]string{"nsa"}, testClusters())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, report))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.WorstControls, decoded.WorstControls)
+	assert.Equal(t, "web", decoded.Clusters[0].Resources[0].Name)
+
+	buf.Reset()
+	require.NoError(t, WriteYAML(&buf, report))
+	assert.Contains(t, buf.String(), "complianceScore: 70")
+	assert.Contains(t, buf.String(), "worstControls:")
+
+	buf.Reset()
+	require.NoError(t, WritePretty(&buf, report))
+	assert.Contains(t, buf.String(), "Compliance score per cluster")
+	assert.Contains(t, buf.String(), "Worst controls across the fleet")
+	assert.Contains(t, buf.String(), "error: failed connecting to Kubernetes cluster")
+	assert.Contains(t, buf.String(), "2/2")
+
+	buf.Reset()
+	require.NoError(t, WriteMarkdown(&buf, report))
+	assert.Contains(t, buf.String(), "| prod | 60.00% | 2 | 3/10 | ok |")
+
+	buf.Reset()
+	require.NoError(t, WriteHTML(&buf, report))
+	assert.Contains(t, buf.String(), `<td title="prod, staging">2/2</td>`)
+
+	assert.Contains(t, buf.String(), `<td class="failed" title="1 failed resources">70.00%</td>`)
+	assert.Contains(t, buf.String(), `<td class="skipped">skipped</td>`)
+
+	buf.Reset()
+	require.NoError(t, WritePDF(&buf, report))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF")))
+
+	buf.Reset()
+	require.NoError(t, WriteCSV(&buf, report))
+	assert.Contains(t, buf.String(), "staging,C-0017,Immutable container filesystem,Low,failed,60.00,1,2,")
+	assert.Contains(t, buf.String(), "edge,,,,,,,,failed connecting to Kubernetes cluster")
+
+	buf.Reset()
+	require.NoError(t, WriteJUnit(&buf, report))
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Len(t, suites.Suites, 3)
+	assert.Equal(t, 3, suites.Failures)
+	assert.Equal(t, 1, suites.Errors)
+	assert.Equal(t, 1, suites.Suites[1].Skipped)
+
+	buf.Reset()
+	require.NoError(t, WritePrometheus(&buf, report))
+	assert.Contains(t, buf.String(), `kubescape_fleet_cluster_complianceScore{cluster="prod"} 60`)
+	assert.Contains(t, buf.String(), `kubescape_fleet_control_count_clusters_failed{controlID="C-0017",name="Immutable container filesystem",severity="Low"} 2`)
+	assert.Contains(t, buf.String(), "kubescape_fleet_count_clusters_failed{} 1")
+}
@@ -0,0 +1,363 @@
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The saved report types parse only the parts of a JSON scan report (a
+// PostureReport) the fleet report is built from. Scores are recomputed from
+// the per-resource results rather than read from the summary, so reports
+// produced by different Kubescape versions are scored the same way.
+type postureReport struct {
+	ClusterName    string           `json:"clusterName"`
+	Metadata       reportMetadata   `json:"metadata"`
+	Resources      []reportResource `json:"resources"`
+	Results        []reportResult   `json:"results"`
+	SummaryDetails reportSummary    `json:"summaryDetails"`
+}
+
+type reportMetadata struct {
+	TargetMetadata struct {
+		Cluster *struct {
+			ContextName string `json:"contextName"`
+		} `json:"clusterContextMetadata"`
+	} `json:"targetMetadata"`
+}
+
+type reportResource struct {
+	ResourceID string          `json:"resourceID"`
+	Object     json.RawMessage `json:"object"`
+}
+
+type reportResult struct {
+	ResourceID string          `json:"resourceID"`
+	Controls   []reportControl `json:"controls"`
+}
+
+type reportControl struct {
+	ControlID string       `json:"controlID"`
+	Name      string       `json:"name"`
+	Status    reportStatus `json:"status"`
+}
+
+type reportStatus struct {
+	Status string `json:"status"`
+}
+
+type reportSummary struct {
+	Frameworks []reportFramework               `json:"frameworks"`
+	Controls   map[string]reportControlSummary `json:"controls"`
+}
+
+type reportFramework struct {
+	Name     string                     `json:"name"`
+	Version  string                     `json:"version"`
+	Controls map[string]json.RawMessage `json:"controls"`
+}
+
+type reportControlSummary struct {
+	Name        string       `json:"name"`
+	Severity    string       `json:"severity"`
+	ScoreFactor float32      `json:"scoreFactor"`
+	StatusInfo  reportStatus `json:"statusInfo"`
+}
+
+// SavedReport is a JSON scan report loaded for a fleet report.
+type SavedReport struct {
+	Path    string
+	Cluster ClusterResult
+	// FrameworkVersions maps each framework the report was scanned with to
+	// its version.
+	FrameworkVersions map[string]string
+}
+
+// LoadReport reads the JSON scan report at path and scores the cluster it
+// describes from its per-resource results.
+func LoadReport(path string) (*SavedReport, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, fmt.Errorf("%s: invalid report: expected a JSON object", path)
+	}
+	var report postureReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%s: invalid JSON: %w", path, err)
+	}
+	if report.Results == nil || report.SummaryDetails.Controls == nil {
+		return nil, fmt.Errorf("%s: invalid report: missing results or summaryDetails; provide JSON output from kubescape scan", path)
+	}
+
+	saved := &SavedReport{
+		Path:              path,
+		Cluster:           clusterFromReport(&report),
+		FrameworkVersions: map[string]string{},
+	}
+	saved.Cluster.Name = reportClusterName(path, &report)
+	saved.Cluster.Source = path
+	for _, fw := range report.SummaryDetails.Frameworks {
+		saved.FrameworkVersions[fw.Name] = fw.Version
+	}
+	return saved, nil
+}
+
+func reportClusterName(path string, report *postureReport) string {
+	if cluster := report.Metadata.TargetMetadata.Cluster; cluster != nil && cluster.ContextName != "" {
+		return cluster.ContextName
+	}
+	if report.ClusterName != "" {
+		return report.ClusterName
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+func clusterFromReport(report *postureReport) ClusterResult {
+	identities := map[string]resourceIdentity{}
+	for _, resource := range report.Resources {
+		if identity, ok := identityFromObject(resource.Object); ok {
+			identities[resource.ResourceID] = identity
+		}
+	}
+
+	resources := make([]ResourceStatus, 0, len(report.Results))
+	names := map[string]string{}
+	for _, result := range report.Results {
+		identity, ok := identities[result.ResourceID]
+		if !ok {
+			identity = identityFromID(result.ResourceID)
+		}
+		resource := ResourceStatus{
+			ID:        result.ResourceID,
+			Kind:      identity.Kind,
+			Namespace: identity.Namespace,
+			Name:      identity.Name,
+			Controls:  make(map[string]string, len(result.Controls)),
+		}
+		for _, control := range result.Controls {
+			resource.Controls[control.ControlID] = normalizeStatus(control.Status.Status)
+			names[control.ControlID] = control.Name
+		}
+		resources = append(resources, resource)
+	}
+
+	counts := countControls(resources)
+	cluster := ClusterResult{
+		AllResources: len(resources),
+		Namespaces:   NamespaceRollups(resources),
+	}
+	for _, resource := range resources {
+		if !failedResource(resource) {
+			continue
+		}
+		cluster.FailedResources++
+		failed := ResourceResult{ID: resource.ID, Kind: resource.Kind, Namespace: resource.Namespace, Name: resource.Name}
+		for id, status := range resource.Controls {
+			if status == StatusFailed {
+				failed.FailedControls = append(failed.FailedControls, id)
+			}
+		}
+		sort.Strings(failed.FailedControls)
+		cluster.Resources = append(cluster.Resources, failed)
+	}
+
+	// scores holds the score of every control that was not skipped.
+	scores := map[string]float32{}
+	for id, summary := range report.SummaryDetails.Controls {
+		control := ControlResult{
+			ID:       id,
+			Name:     summary.Name,
+			Severity: summary.Severity,
+			Status:   StatusSkipped,
+		}
+		if control.Name == "" {
+			control.Name = names[id]
+		}
+		if control.Severity == "" {
+			control.Severity = severityFromScoreFactor(summary.ScoreFactor)
+		}
+		if c, ok := counts[id]; ok {
+			control.Status = StatusPassed
+			if c.failed > 0 {
+				control.Status = StatusFailed
+			}
+			control.ComplianceScore = c.score()
+			control.FailedResources = c.failed
+			control.AllResources = c.evaluated
+		} else if normalizeStatus(summary.StatusInfo.Status) == StatusPassed {
+			// A control with nothing to evaluate passes.
+			control.Status = StatusPassed
+			control.ComplianceScore = 100
+		}
+		if control.Status != StatusSkipped {
+			scores[id] = control.ComplianceScore
+		}
+		cluster.Controls = append(cluster.Controls, control)
+	}
+
+	cluster.ComplianceScore = meanScore(scores, nil)
+	for _, fw := range report.SummaryDetails.Frameworks {
+		cluster.Frameworks = append(cluster.Frameworks, FrameworkScore{Name: fw.Name, ComplianceScore: meanScore(scores, fw.Controls)})
+	}
+	return cluster
+}
+
+// meanScore averages scores, restricted to the controls in only when it is
+// not nil.
+func meanScore(scores map[string]float32, only map[string]json.RawMessage) float32 {
+	var total float32
+	n := 0
+	for id, score := range scores {
+		if only != nil {
+			if _, ok := only[id]; !ok {
+				continue
+			}
+		}
+		total += score
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / float32(n)
+}
+
+// normalizeStatus maps result statuses onto passed, failed and skipped.
+// Exceptions keep their passed status, and everything Kubescape could not
+// evaluate (skipped, irrelevant, unknown) is skipped.
+func normalizeStatus(status string) string {
+	switch strings.ToLower(status) {
+	case StatusPassed:
+		return StatusPassed
+	case StatusFailed:
+		return StatusFailed
+	default:
+		return StatusSkipped
+	}
+}
+
+// severityFromScoreFactor derives a control's severity from its score factor
+// the way Kubescape does for reports that do not carry the severity.
+func severityFromScoreFactor(scoreFactor float32) string {
+	switch {
+	case scoreFactor >= 9:
+		return "Critical"
+	case scoreFactor >= 7:
+		return "High"
+	case scoreFactor >= 4:
+		return "Medium"
+	case scoreFactor >= 1:
+		return "Low"
+	default:
+		return "Unknown"
+	}
+}
+
+type resourceIdentity struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func identityFromObject(raw json.RawMessage) (resourceIdentity, bool) {
+	if len(raw) == 0 {
+		return resourceIdentity{}, false
+	}
+	var object struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil || object.Kind == "" || object.Metadata.Name == "" {
+		return resourceIdentity{}, false
+	}
+	return resourceIdentity{Kind: object.Kind, Namespace: object.Metadata.Namespace, Name: object.Metadata.Name}, true
+}
+
+// identityFromID reads the identity out of a Kubernetes resource ID, which
+// ends in <namespace>/<kind>/<name> with an empty namespace for
+// cluster-scoped resources.
+func identityFromID(id string) resourceIdentity {
+	parts := strings.Split(id, "/")
+	if len(parts) < 3 {
+		return resourceIdentity{Name: id}
+	}
+	n := len(parts)
+	return resourceIdentity{Namespace: parts[n-3], Kind: parts[n-2], Name: parts[n-1]}
+}
+
+// Merge combines saved scan reports, one per cluster, into a fleet report.
+// The reports must have been scanned with the same frameworks at the same
+// versions, and describe different clusters.
+func Merge(reports []*SavedReport) (*Report, error) {
+	if len(reports) == 0 {
+		return nil, errors.New("no reports to merge")
+	}
+	if err := checkCompatible(reports); err != nil {
+		return nil, err
+	}
+
+	clusters := make([]ClusterResult, 0, len(reports))
+	seen := map[string]string{}
+	for _, saved := range reports {
+		if previous, ok := seen[saved.Cluster.Name]; ok {
+			return nil, fmt.Errorf("%s and %s are both reports for cluster %q", previous, saved.Path, saved.Cluster.Name)
+		}
+		seen[saved.Cluster.Name] = saved.Path
+		clusters = append(clusters, saved.Cluster)
+	}
+
+	versions := reports[0].FrameworkVersions
+	frameworks := make([]string, 0, len(versions))
+	for name := range versions {
+		frameworks = append(frameworks, name)
+	}
+	sort.Strings(frameworks)
+
+	report := NewReport(frameworks, clusters)
+	if len(versions) > 0 {
+		report.FrameworkVersions = versions
+	}
+	return report, nil
+}
+
+// checkCompatible returns an error unless every report was scanned with the
+// frameworks, and framework versions, of the first.
+func checkCompatible(reports []*SavedReport) error {
+	first := reports[0]
+	want := frameworkList(first.FrameworkVersions)
+	for _, saved := range reports[1:] {
+		if got := frameworkList(saved.FrameworkVersions); !slices.Equal(got, want) {
+			return fmt.Errorf("reports are not compatible: %s was scanned with %s, but %s with %s", first.Path, describeFrameworks(want), saved.Path, describeFrameworks(got))
+		}
+	}
+	return nil
+}
+
+// frameworkList lists the frameworks as sorted name@version pairs.
+func frameworkList(versions map[string]string) []string {
+	list := make([]string, 0, len(versions))
+	for name, version := range versions {
+		list = append(list, name+"@"+version)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func describeFrameworks(list []string) string {
+	if len(list) == 0 {
+		return "no framework"
+	}
+	return strings.Join(list, ", ")
+}
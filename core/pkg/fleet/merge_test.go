@@ -0,0 +1,94 @@
+package fleet
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadReports(t *testing.T, names ...string) []*SavedReport {
+	t.Helper()
+	var reports []*SavedReport
+	for _, name := range names {
+		saved, err := LoadReport(filepath.Join("testdata", name))
+		require.NoError(t, err)
+		reports = append(reports, saved)
+	}
+	return reports
+}
+
+func TestLoadReport(t *testing.T) {
+	saved := loadReports(t, "prod.json")[0]
+	cluster := saved.Cluster
+
+	assert.Equal(t, "prod", cluster.Name, "the kube context names the cluster")
+	assert.Equal(t, filepath.Join("testdata", "prod.json"), cluster.Source)
+	assert.Equal(t, map[string]string{"NSA": "v1.0.30"}, saved.FrameworkVersions)
+	assert.InDelta(t, 200.0/3, cluster.ComplianceScore, 0.001, "a passed control without resources scores 100")
+	require.Len(t, cluster.Frameworks, 1)
+	assert.InDelta(t, 200.0/3, cluster.Frameworks[0].ComplianceScore, 0.001)
+	assert.Equal(t, 2, cluster.FailedResources)
+	assert.Equal(t, 4, cluster.AllResources)
+
+	controls := map[string]ControlResult{}
+	for _, control := range cluster.Controls {
+		controls[control.ID] = control
+	}
+	assert.Equal(t, ControlResult{ID: "C-0017", Name: "Immutable container filesystem", Severity: "Low", Status: StatusFailed, ComplianceScore: 50, FailedResources: 2, AllResources: 4}, controls["C-0017"])
+	assert.Equal(t, "High", controls["C-0002"].Severity, "the severity falls back to the score factor")
+	assert.Equal(t, 2, controls["C-0002"].AllResources, "skipped results are not evaluations")
+	assert.Equal(t, StatusPassed, controls["C-0012"].Status)
+
+	assert.Equal(t, []NamespaceResult{
+		{Name: "", ComplianceScore: 100, AllResources: 1},
+		{Name: "default", ComplianceScore: 50, FailedControls: 2, FailedResources: 1, AllResources: 2},
+		{Name: "payments", ComplianceScore: 0, FailedControls: 1, FailedResources: 1, AllResources: 1},
+	}, cluster.Namespaces)
+}
+
+func TestLoadReportRejectsOtherJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"clusters": []}`), 0o600))
+
+	_, err := LoadReport(path)
+	require.ErrorContains(t, err, "missing results or summaryDetails")
+}
+
+func TestMerge(t *testing.T) {
+	report, err := Merge(loadReports(t, "prod.json", "staging.json"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"NSA"}, report.Frameworks)
+	assert.Equal(t, map[string]string{"NSA": "v1.0.30"}, report.FrameworkVersions)
+	require.Len(t, report.Clusters, 2)
+	assert.Equal(t, "staging", report.Clusters[1].Name, "the cluster name is read from the report")
+	assert.InDelta(t, 100, report.Clusters[1].ComplianceScore, 0.001)
+	assert.InDelta(t, (200.0/3+100)/2, report.ComplianceScore, 0.001)
+
+	require.Len(t, report.WorstControls, 2)
+	assert.Equal(t, "C-0002", report.WorstControls[0].ID)
+	assert.Equal(t, "C-0017", report.WorstControls[1].ID)
+
+	heatmap := report.Heatmap()
+	require.Len(t, heatmap, 3)
+	assert.Equal(t, []string{"C-0002", "C-0017", "C-0012"}, []string{heatmap[0].ID, heatmap[1].ID, heatmap[2].ID})
+	assert.Equal(t, []HeatmapCell{{Status: StatusPassed, ComplianceScore: 100}, {Status: StatusSkipped}}, heatmap[2].Cells)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMarkdown(&buf, report))
+	assert.Contains(t, buf.String(), "| prod | payments | 0.00% | 1 | 1/1 |\n| prod | default | 50.00% | 2 | 1/2 |", "ties on failed resources rank the lower score first")
+}
+
+func TestMergeRejectsIncompatibleReports(t *testing.T) {
+	_, err := Merge(loadReports(t, "prod.json", "newer-framework.json"))
+	require.ErrorContains(t, err, "reports are not compatible")
+	assert.ErrorContains(t, err, "NSA@v1.0.30")
+	assert.ErrorContains(t, err, "NSA@v1.0.31")
+
+	_, err = Merge(loadReports(t, "prod.json", "prod.json"))
+	require.ErrorContains(t, err, `both reports for cluster "prod"`)
+}
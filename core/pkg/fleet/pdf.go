@@ -0,0 +1,145 @@
+package fleet
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/line"
+	"github.com/johnfercher/maroto/v2/pkg/components/row"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/config"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontfamily"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/consts/orientation"
+	"github.com/johnfercher/maroto/v2/pkg/consts/pagesize"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+)
+
+const (
+	// pdfGridSize is the number of columns of the PDF page grid.
+	pdfGridSize = 24
+	// heatmapClustersPerTable bounds the cluster columns of one heatmap
+	// table; larger fleets get one table per group of clusters.
+	heatmapClustersPerTable = 9
+)
+
+var (
+	pdfHeaderText = props.Text{Size: 6, Family: fontfamily.Arial, Style: fontstyle.Bold}
+	pdfCellText   = props.Text{Size: 6, Family: fontfamily.Courier, Style: fontstyle.Normal}
+	pdfTitleText  = props.Text{Size: 10, Family: fontfamily.Arial, Style: fontstyle.Bold, Top: 3}
+
+	pdfStripe = &props.Color{Red: 224, Green: 224, Blue: 224}
+	pdfColors = map[string]*props.Color{
+		"passed":      {Red: 200, Green: 230, Blue: 201},
+		"failed":      {Red: 255, Green: 205, Blue: 210},
+		"failed-most": {Red: 229, Green: 115, Blue: 115},
+		"skipped":     {Red: 238, Green: 238, Blue: 238},
+	}
+)
+
+// WritePDF writes the per-cluster score table, the worst controls, a
+// control-by-cluster heatmap and the worst namespaces as a PDF document.
+func WritePDF(w io.Writer, r *Report) error {
+	m := maroto.New(config.NewBuilder().
+		WithPageSize(pagesize.A4).
+		WithOrientation(orientation.Horizontal).
+		WithMaxGridSize(pdfGridSize).
+		WithLeftMargin(10).
+		WithTopMargin(15).
+		WithRightMargin(10).
+		Build())
+
+	m.AddRow(10, text.NewCol(pdfGridSize, "Kubescape fleet scan", props.Text{Size: 14, Family: fontfamily.Arial, Style: fontstyle.Bold, Align: align.Center}))
+	m.AddRow(6, text.NewCol(pdfGridSize, fmt.Sprintf("Fleet compliance score: %s across %d clusters, generated %s",
+		formatScore(r.ComplianceScore), len(r.Clusters), r.GeneratedAt.Format("2006-01-02 15:04:05 MST")), pdfHeaderText))
+	m.AddAutoRow(line.NewCol(pdfGridSize, props.Line{Thickness: 0.3, SizePercent: 100}))
+
+	m.AddAutoRow(text.NewCol(pdfGridSize, "Compliance score per cluster", pdfTitleText))
+	m.AddRows(pdfTableRow(row.New(8), []int{6, 4, 4, 4, 6}, pdfHeaderText, "Cluster", "Compliance score", "Failed controls", "Failed resources", "Status"))
+	for i, c := range r.Clusters {
+		score, failedControls, failedResources := "-", "-", "-"
+		if c.Scanned() {
+			score = formatScore(c.ComplianceScore)
+			failedControls = strconv.Itoa(c.FailedControls())
+			failedResources = fmt.Sprintf("%d/%d", c.FailedResources, c.AllResources)
+		}
+		m.AddRows(pdfStriped(pdfTableRow(row.New(4), []int{6, 4, 4, 4, 6}, pdfCellText, c.Name, score, failedControls, failedResources, clusterStatus(c)), i))
+	}
+
+	if worst := worstControls(r); len(worst) > 0 {
+		m.AddAutoRow(text.NewCol(pdfGridSize, "Worst controls across the fleet", pdfTitleText))
+		m.AddRows(pdfTableRow(row.New(8), []int{3, 11, 3, 3, 4}, pdfHeaderText, "Control ID", "Control name", "Severity", "Failing clusters", "Failed resources"))
+		for i, fc := range worst {
+			m.AddRows(pdfStriped(pdfTableRow(row.New(4), []int{3, 11, 3, 3, 4}, pdfCellText, fc.ID, fc.Name, fc.Severity, failingClusters(fc), strconv.Itoa(fc.FailedResources)), i))
+		}
+	}
+
+	if heatmap := r.Heatmap(); len(heatmap) > 0 {
+		for start := 0; start < len(r.Clusters); start += heatmapClustersPerTable {
+			end := min(start+heatmapClustersPerTable, len(r.Clusters))
+			m.AddRows(pdfHeatmap(r, heatmap, start, end)...)
+		}
+	}
+
+	if namespaces := worstNamespaces(r); len(namespaces) > 0 {
+		m.AddAutoRow(text.NewCol(pdfGridSize, "Namespaces with the most failed resources", pdfTitleText))
+		m.AddRows(pdfTableRow(row.New(8), []int{6, 6, 4, 4, 4}, pdfHeaderText, "Cluster", "Namespace", "Compliance score", "Failed controls", "Failed resources"))
+		for i, ns := range namespaces {
+			m.AddRows(pdfStriped(pdfTableRow(row.New(4), []int{6, 6, 4, 4, 4}, pdfCellText, ns.Cluster, ns.DisplayName(), formatScore(ns.ComplianceScore), strconv.Itoa(ns.FailedControls), fmt.Sprintf("%d/%d", ns.FailedResources, ns.AllResources)), i))
+		}
+	}
+
+	doc, err := m.Generate()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(doc.GetBytes())
+	return err
+}
+
+// pdfHeatmap lays out the heatmap columns of clusters [start, end).
+func pdfHeatmap(r *Report, heatmap []HeatmapRow, start, end int) []core.Row {
+	title := "Controls by cluster"
+	if start > 0 || end < len(r.Clusters) {
+		title = fmt.Sprintf("Controls by cluster (clusters %d-%d of %d)", start+1, end, len(r.Clusters))
+	}
+	rows := []core.Row{text.NewAutoRow(title, pdfTitleText)}
+
+	header := row.New(8).Add(text.NewCol(3, "Control ID", pdfHeaderText), text.NewCol(2, "Severity", pdfHeaderText))
+	for _, c := range r.Clusters[start:end] {
+		header.Add(text.NewCol(2, c.Name, pdfHeaderText))
+	}
+	rows = append(rows, header)
+
+	for _, hr := range heatmap {
+		content := row.New(4).Add(text.NewCol(3, hr.ID, pdfCellText), text.NewCol(2, hr.Severity, pdfCellText))
+		for _, cell := range hr.Cells[start:end] {
+			c := col.New(2).Add(text.New(heatmapText(cell), pdfCellText))
+			if color, ok := pdfColors[heatmapClass(cell)]; ok {
+				c.WithStyle(&props.Cell{BackgroundColor: color})
+			}
+			content.Add(c)
+		}
+		rows = append(rows, content)
+	}
+	return rows
+}
+
+func pdfTableRow(r core.Row, sizes []int, style props.Text, values ...string) core.Row {
+	for i, value := range values {
+		r.Add(text.NewCol(sizes[i], value, style))
+	}
+	return r
+}
+
+func pdfStriped(r core.Row, i int) core.Row {
+	if i%2 == 0 {
+		r.WithStyle(&props.Cell{BackgroundColor: pdfStripe})
+	}
+	return r
+}
@@ -0,0 +1,123 @@
+package fleet
+
+import "sort"
+
+// ResourceStatus is one resource's control results in one cluster: the
+// status (StatusPassed, StatusFailed or StatusSkipped) of every control
+// evaluated against it.
+type ResourceStatus struct {
+	ID        string
+	Kind      string
+	Namespace string
+	Name      string
+	Controls  map[string]string
+}
+
+// NamespaceResult rolls up the resources of one namespace in one cluster.
+// Cluster-scoped resources are rolled up under an empty Name.
+type NamespaceResult struct {
+	Name string `json:"name"`
+	// ComplianceScore is the average, over the controls evaluated in the
+	// namespace, of the share of the namespace's resources passing them.
+	ComplianceScore float32 `json:"complianceScore"`
+	FailedControls  int     `json:"failedControls"`
+	FailedResources int     `json:"failedResources"`
+	AllResources    int     `json:"allResources"`
+}
+
+// DisplayName is the namespace name for the human-readable formats.
+func (n NamespaceResult) DisplayName() string {
+	if n.Name == "" {
+		return "(cluster-scoped)"
+	}
+	return n.Name
+}
+
+// controlCounts counts a control's evaluated and failed resources.
+type controlCounts struct {
+	failed, evaluated int
+}
+
+// score is the percentage of evaluated resources passing the control.
+func (c controlCounts) score() float32 {
+	if c.evaluated == 0 {
+		return 0
+	}
+	return 100 * float32(c.evaluated-c.failed) / float32(c.evaluated)
+}
+
+// countControls tallies every control over resources. Skipped results are not
+// evaluations and are left out.
+func countControls(resources []ResourceStatus) map[string]*controlCounts {
+	counts := map[string]*controlCounts{}
+	for _, resource := range resources {
+		for id, status := range resource.Controls {
+			if status != StatusPassed && status != StatusFailed {
+				continue
+			}
+			c, ok := counts[id]
+			if !ok {
+				c = &controlCounts{}
+				counts[id] = c
+			}
+			c.evaluated++
+			if status == StatusFailed {
+				c.failed++
+			}
+		}
+	}
+	return counts
+}
+
+// averageScore is the average score of the given controls, 0 without any.
+func averageScore(counts map[string]*controlCounts) float32 {
+	if len(counts) == 0 {
+		return 0
+	}
+	var total float32
+	for _, c := range counts {
+		total += c.score()
+	}
+	return total / float32(len(counts))
+}
+
+func failedResource(resource ResourceStatus) bool {
+	for _, status := range resource.Controls {
+		if status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceRollups groups resources by namespace and scores each namespace
+// from its resources' control results, sorted by namespace name.
+func NamespaceRollups(resources []ResourceStatus) []NamespaceResult {
+	byNamespace := map[string][]ResourceStatus{}
+	for _, resource := range resources {
+		byNamespace[resource.Namespace] = append(byNamespace[resource.Namespace], resource)
+	}
+
+	rollups := make([]NamespaceResult, 0, len(byNamespace))
+	for name, members := range byNamespace {
+		counts := countControls(members)
+		rollup := NamespaceResult{
+			Name:            name,
+			ComplianceScore: averageScore(counts),
+			AllResources:    len(members),
+		}
+		for _, c := range counts {
+			if c.failed > 0 {
+				rollup.FailedControls++
+			}
+		}
+		for _, member := range members {
+			if failedResource(member) {
+				rollup.FailedResources++
+			}
+		}
+		rollups = append(rollups, rollup)
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].Name < rollups[j].Name })
+	return rollups
+}
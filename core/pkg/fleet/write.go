@@ -0,0 +1,447 @@
+package fleet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"gopkg.in/yaml.v3"
+)
+
+// worstControlsToPrint bounds the worst-controls section of the human-readable
+// formats. The structured formats always carry the full list.
+const worstControlsToPrint = 10
+
+func formatScore(score float32) string {
+	return fmt.Sprintf("%.2f%%", score)
+}
+
+func clusterStatus(c ClusterResult) string {
+	if c.Error != "" {
+		return "error: " + c.Error
+	}
+	return "ok"
+}
+
+func failingClusters(fc FleetControl) string {
+	return fmt.Sprintf("%d/%d", len(fc.FailedClusters), fc.ScannedClusters)
+}
+
+func worstControls(r *Report) []FleetControl {
+	if len(r.WorstControls) > worstControlsToPrint {
+		return r.WorstControls[:worstControlsToPrint]
+	}
+	return r.WorstControls
+}
+
+// namespacesToPrint bounds the namespace section of the human-readable
+// formats.
+const namespacesToPrint = 10
+
+// clusterNamespace is a namespace rollup together with its cluster.
+type clusterNamespace struct {
+	Cluster string
+	NamespaceResult
+}
+
+// worstNamespaces lists the namespaces with the most failed resources across
+// the fleet, then the lowest scores.
+func worstNamespaces(r *Report) []clusterNamespace {
+	var namespaces []clusterNamespace
+	for _, c := range r.Clusters {
+		for _, ns := range c.Namespaces {
+			if ns.FailedResources > 0 {
+				namespaces = append(namespaces, clusterNamespace{Cluster: c.Name, NamespaceResult: ns})
+			}
+		}
+	}
+	sort.SliceStable(namespaces, func(i, j int) bool {
+		a, b := namespaces[i], namespaces[j]
+		if a.FailedResources != b.FailedResources {
+			return a.FailedResources > b.FailedResources
+		}
+		return a.ComplianceScore < b.ComplianceScore
+	})
+	if len(namespaces) > namespacesToPrint {
+		return namespaces[:namespacesToPrint]
+	}
+	return namespaces
+}
+
+// heatmapClass is the CSS class of a heatmap cell: its status, and for failed
+// controls how badly they failed.
+func heatmapClass(cell HeatmapCell) string {
+	switch cell.Status {
+	case StatusPassed:
+		return "passed"
+	case StatusFailed:
+		if cell.ComplianceScore < 50 {
+			return "failed-most"
+		}
+		return "failed"
+	case StatusSkipped:
+		return "skipped"
+	default:
+		return "missing"
+	}
+}
+
+// heatmapText is the text of a heatmap cell.
+func heatmapText(cell HeatmapCell) string {
+	switch cell.Status {
+	case StatusPassed, StatusFailed:
+		return formatScore(cell.ComplianceScore)
+	case StatusSkipped:
+		return StatusSkipped
+	default:
+		return "-"
+	}
+}
+
+func newTable(w io.Writer, title string) table.Writer {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.SetTitle(title)
+	t.Style().Options.SeparateHeader = true
+	t.Style().Format.HeaderAlign = text.AlignLeft
+	t.Style().Format.Header = text.FormatDefault
+	t.Style().Box = table.StyleBoxRounded
+	return t
+}
+
+// WritePretty writes the per-cluster score table and the worst controls as
+// terminal tables.
+func WritePretty(w io.Writer, r *Report) error {
+	clusters := newTable(w, "Compliance score per cluster")
+	clusters.AppendHeader(table.Row{"Cluster", "Compliance score", "Failed controls", "Failed resources", "Status"})
+	for _, c := range r.Clusters {
+		score, failedControls, failedResources := "-", "-", "-"
+		if c.Scanned() {
+			score = formatScore(c.ComplianceScore)
+			failedControls = strconv.Itoa(c.FailedControls())
+			failedResources = fmt.Sprintf("%d/%d", c.FailedResources, c.AllResources)
+		}
+		clusters.AppendRow(table.Row{c.Name, score, failedControls, failedResources, clusterStatus(c)})
+	}
+	clusters.AppendFooter(table.Row{"Fleet", formatScore(r.ComplianceScore)})
+	clusters.Render()
+
+	if len(r.WorstControls) == 0 {
+		_, err := fmt.Fprintln(w, "\nNo control failed in any cluster.")
+		return err
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	controls := newTable(w, "Worst controls across the fleet")
+	controls.AppendHeader(table.Row{"Control ID", "Control name", "Severity", "Failing clusters", "Failed resources"})
+	for _, fc := range worstControls(r) {
+		controls.AppendRow(table.Row{fc.ID, fc.Name, fc.Severity, failingClusters(fc), fc.FailedResources})
+	}
+	controls.Render()
+
+	if namespaces := worstNamespaces(r); len(namespaces) > 0 {
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+		t := newTable(w, "Namespaces with the most failed resources")
+		t.AppendHeader(table.Row{"Cluster", "Namespace", "Compliance score", "Failed controls", "Failed resources"})
+		for _, ns := range namespaces {
+			t.AppendRow(table.Row{ns.Cluster, ns.DisplayName(), formatScore(ns.ComplianceScore), ns.FailedControls, fmt.Sprintf("%d/%d", ns.FailedResources, ns.AllResources)})
+		}
+		t.Render()
+	}
+	return nil
+}
+
+// WriteJSON writes the full report as JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteYAML writes the full report as YAML, with the same field names as the
+// JSON report.
+func WriteYAML(w io.Writer, r *Report) error {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	// JSON is YAML, so decoding it into a node keeps the JSON field names and
+	// order. The node keeps JSON's flow style until it is reset.
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
+
+// WriteMarkdown writes the per-cluster score table and the worst controls as
+// Markdown tables.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b bytes.Buffer
+	b.WriteString("# Fleet scan\n\n")
+	fmt.Fprintf(&b, "Fleet compliance score: **%s** across %d clusters\n\n", formatScore(r.ComplianceScore), len(r.Clusters))
+	b.WriteString("## Compliance score per cluster\n\n")
+	b.WriteString("| Cluster | Compliance score | Failed controls | Failed resources | Status |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for _, c := range r.Clusters {
+		if c.Scanned() {
+			fmt.Fprintf(&b, "| %s | %s | %d | %d/%d | %s |\n", markdownCell(c.Name), formatScore(c.ComplianceScore), c.FailedControls(), c.FailedResources, c.AllResources, markdownCell(clusterStatus(c)))
+		} else {
+			fmt.Fprintf(&b, "| %s | - | - | - | %s |\n", markdownCell(c.Name), markdownCell(clusterStatus(c)))
+		}
+	}
+	if len(r.WorstControls) > 0 {
+		b.WriteString("\n## Worst controls across the fleet\n\n")
+		b.WriteString("| Control ID | Control name | Severity | Failing clusters | Failed resources |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, fc := range worstControls(r) {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %d |\n", markdownCell(fc.ID), markdownCell(fc.Name), fc.Severity, failingClusters(fc), fc.FailedResources)
+		}
+	}
+	if namespaces := worstNamespaces(r); len(namespaces) > 0 {
+		b.WriteString("\n## Namespaces with the most failed resources\n\n")
+		b.WriteString("| Cluster | Namespace | Compliance score | Failed controls | Failed resources |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, ns := range namespaces {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %d/%d |\n", markdownCell(ns.Cluster), markdownCell(ns.DisplayName()), formatScore(ns.ComplianceScore), ns.FailedControls, ns.FailedResources, ns.AllResources)
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+var htmlTemplate = template.Must(template.New("fleet").Funcs(template.FuncMap{
+	"score":    formatScore,
+	"status":   clusterStatus,
+	"failing":  failingClusters,
+	"worst":    worstControls,
+	"join":     strings.Join,
+	"nsWorst":  worstNamespaces,
+	"cell":     heatmapClass,
+	"cellText": heatmapText,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Kubescape fleet scan</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f0f0f0; }
+.error { color: #b00020; }
+td.passed { background: #c8e6c9; }
+td.failed { background: #ffcdd2; }
+td.failed-most { background: #e57373; }
+td.skipped { background: #eeeeee; color: #666; }
+td.missing { color: #999; }
+</style>
+</head>
+<body>
+<h1>Fleet scan</h1>
+<p>Fleet compliance score: <strong>{{ score .ComplianceScore }}</strong> across {{ len .Clusters }} clusters, generated {{ .GeneratedAt.Format "2006-01-02 15:04:05 MST" }}</p>
+<h2>Compliance score per cluster</h2>
+<table>
+<tr><th>Cluster</th><th>Compliance score</th><th>Failed controls</th><th>Failed resources</th><th>Status</th></tr>
+{{- range .Clusters }}
+<tr><td>{{ .Name }}</td>{{ if .Scanned }}<td>{{ score .ComplianceScore }}</td><td>{{ .FailedControls }}</td><td>{{ .FailedResources }}/{{ .AllResources }}</td>{{ else }}<td>-</td><td>-</td><td>-</td>{{ end }}<td{{ if .Error }} class="error"{{ end }}>{{ status . }}</td></tr>
+{{- end }}
+</table>
+{{- if .WorstControls }}
+<h2>Worst controls across the fleet</h2>
+<table>
+<tr><th>Control ID</th><th>Control name</th><th>Severity</th><th>Failing clusters</th><th>Failed resources</th></tr>
+{{- range worst . }}
+<tr><td>{{ .ID }}</td><td>{{ .Name }}</td><td>{{ .Severity }}</td><td title="{{ join .FailedClusters ", " }}">{{ failing . }}</td><td>{{ .FailedResources }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- with .Heatmap }}
+<h2>Controls by cluster</h2>
+<table>
+<tr><th>Control ID</th><th>Control name</th><th>Severity</th>{{ range $.Clusters }}<th>{{ .Name }}</th>{{ end }}</tr>
+{{- range . }}
+<tr><td>{{ .ID }}</td><td>{{ .Name }}</td><td>{{ .Severity }}</td>{{ range .Cells }}<td class="{{ cell . }}"{{ if .FailedResources }} title="{{ .FailedResources }} failed resources"{{ end }}>{{ cellText . }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+{{- end }}
+{{- with nsWorst . }}
+<h2>Namespaces with the most failed resources</h2>
+<table>
+<tr><th>Cluster</th><th>Namespace</th><th>Compliance score</th><th>Failed controls</th><th>Failed resources</th></tr>
+{{- range . }}
+<tr><td>{{ .Cluster }}</td><td>{{ .DisplayName }}</td><td>{{ score .ComplianceScore }}</td><td>{{ .FailedControls }}</td><td>{{ .FailedResources }}/{{ .AllResources }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
+
+// WriteHTML writes the per-cluster score table, the worst controls, a
+// control-by-cluster heatmap and the worst namespaces as an HTML page.
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+// WriteCSV writes one row per control per cluster.
+func WriteCSV(w io.Writer, r *Report) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"Cluster", "Control ID", "Control name", "Severity", "Status", "Compliance score", "Failed resources", "All resources", "Error"}); err != nil {
+		return err
+	}
+	for _, c := range r.Clusters {
+		if !c.Scanned() {
+			if err := out.Write([]string{c.Name, "", "", "", "", "", "", "", c.Error}); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, control := range c.Controls {
+			if err := out.Write([]string{
+				c.Name, control.ID, control.Name, control.Severity, control.Status,
+				strconv.FormatFloat(float64(control.ComplianceScore), 'f', 2, 32),
+				strconv.Itoa(control.FailedResources), strconv.Itoa(control.AllResources), c.Error,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes a test suite per cluster, with a test case per control.
+// A cluster whose scan failed is a suite with a single erroring test case.
+func WriteJUnit(w io.Writer, r *Report) error {
+	suites := junitTestSuites{Name: "Kubescape fleet scan"}
+	for _, c := range r.Clusters {
+		suite := junitTestSuite{
+			Name:       c.Name,
+			Properties: []junitProperty{{Name: "complianceScore", Value: strconv.FormatFloat(float64(c.ComplianceScore), 'f', 2, 32)}},
+		}
+		if c.Error != "" {
+			suite.Errors++
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: "scan", ClassName: c.Name, Error: &junitMessage{Message: c.Error}})
+		}
+		for _, control := range c.Controls {
+			tc := junitTestCase{Name: control.ID + " " + control.Name, ClassName: c.Name}
+			switch control.Status {
+			case StatusFailed:
+				suite.Failures++
+				tc.Failure = &junitMessage{Message: fmt.Sprintf("%d of %d resources failed (severity %s)", control.FailedResources, control.AllResources, control.Severity)}
+			case StatusSkipped:
+				suite.Skipped++
+				tc.Skipped = &junitMessage{Message: "control was not evaluated"}
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+		suite.Tests = len(suite.TestCases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Suites = append(suites.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the fleet, per-cluster and per-control metrics in the
+// Prometheus text format.
+func WritePrometheus(w io.Writer, r *Report) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "kubescape_fleet_complianceScore{} %d\n", int(r.ComplianceScore))
+	fmt.Fprintf(&b, "kubescape_fleet_count_clusters_failed{} %d\n", len(r.Failed()))
+	for _, c := range r.Clusters {
+		labels := fmt.Sprintf(`cluster="%s"`, prometheusLabelEscaper.Replace(c.Name))
+		if !c.Scanned() {
+			continue
+		}
+		fmt.Fprintf(&b, "kubescape_fleet_cluster_complianceScore{%s} %d\n", labels, int(c.ComplianceScore))
+		fmt.Fprintf(&b, "kubescape_fleet_cluster_count_controls_failed{%s} %d\n", labels, c.FailedControls())
+		fmt.Fprintf(&b, "kubescape_fleet_cluster_count_resources_failed{%s} %d\n", labels, c.FailedResources)
+	}
+	for _, fc := range r.WorstControls {
+		labels := fmt.Sprintf(`controlID="%s",name="%s",severity="%s"`,
+			prometheusLabelEscaper.Replace(fc.ID), prometheusLabelEscaper.Replace(fc.Name), prometheusLabelEscaper.Replace(fc.Severity))
+		fmt.Fprintf(&b, "kubescape_fleet_control_count_clusters_failed{%s} %d\n", labels, len(fc.FailedClusters))
+		fmt.Fprintf(&b, "kubescape_fleet_control_count_resources_failed{%s} %d\n", labels, fc.FailedResources)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
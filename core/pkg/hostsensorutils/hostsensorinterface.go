@@ -7,16 +7,34 @@ import (
 	"github.com/kubescape/opa-utils/reporthandling/apis"
 )
 
+// SyscallEventType classifies a SyscallEvent, so that consumers need not
+// interpret architecture-specific syscall numbers.
+type SyscallEventType string
+
+const (
+	SyscallEventExec       SyscallEventType = "exec"
+	SyscallEventConnect    SyscallEventType = "connect"
+	SyscallEventCapability SyscallEventType = "capability"
+)
+
 type SyscallEvent struct {
 	PID            int32
 	PodUID         string
 	SyscallID      int32
 	CapabilityName string
+	Type           SyscallEventType
+	// Path is the executable of an exec event.
+	Path string
+	// RemoteAddr is the destination of a connect event, as host:port or a
+	// unix socket path.
+	RemoteAddr string
 }
 
 type IHostSensor interface {
 	Init(ctx context.Context) error
 	TearDown() error
 	CollectResources(context.Context) ([]hostsensor.HostSensorDataEnvelope, map[string]apis.StatusInfo, error)
+	// StreamTelemetry sends the runtime events of the scanned pods until ctx
+	// is done, then closes the channel.
 	StreamTelemetry(ctx context.Context) (<-chan SyscallEvent, error)
 }
@@ -0,0 +1,293 @@
+// Package imagecache persists image vulnerability scan results across runs so
+// that an image whose manifest digest has not changed is not pulled, catalogued
+// and matched again until the vulnerability database or the matcher
+// configuration changes.
+//
+// Results live under <cacheDir>/image-scan-cache/<generation>/<key>/, where the
+// generation identifies everything that can change a result for the same image
+// (grype DB build, matcher configuration, grype version) and the key
+// identifies the image (manifest digest, platform, ignore rules). Opening a
+// generation removes every other one, so a DB update invalidates the cache
+// automatically and stale entries do not accumulate on disk.
+//
+// Each entry holds the image's SBOM in syft JSON and a compact description of
+// the grype matches. Vulnerabilities are not stored: they are looked up again
+// in the loaded DB on a hit, so they carry the provider handles grype needs to
+// resolve metadata later.
+package imagecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/anchore/grype/grype/match"
+	"github.com/anchore/grype/grype/pkg"
+	"github.com/anchore/grype/grype/search"
+	"github.com/anchore/grype/grype/vulnerability"
+	"github.com/anchore/syft/syft/format/syftjson"
+	"github.com/anchore/syft/syft/sbom"
+)
+
+const (
+	dirName     = "image-scan-cache"
+	sbomFile    = "sbom.json"
+	matchesFile = "matches.json"
+)
+
+// Store is one cache generation. It is safe for concurrent use: entries are
+// written to a temporary directory and renamed into place, so a reader sees
+// either a complete entry or none.
+type Store struct {
+	dir string
+}
+
+// Open returns the store for generation under cacheDir, creating it if needed
+// and removing the directories of every other generation.
+func Open(cacheDir, generation string) (*Store, error) {
+	if generation == "" {
+		return nil, fmt.Errorf("image scan cache generation is empty")
+	}
+	root := filepath.Join(cacheDir, dirName)
+	dir := filepath.Join(root, generation)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Name() != generation {
+			_ = os.RemoveAll(filepath.Join(root, e.Name())) // best effort: a leftover generation only costs disk space
+		}
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Delete removes every cached image scan result under cacheDir.
+func Delete(cacheDir string) error {
+	return os.RemoveAll(filepath.Join(cacheDir, dirName))
+}
+
+// Generation builds the generation identifier from the given parts (e.g. the DB
+// schema version and build timestamp, and the encoded matcher configuration).
+func Generation(parts ...[]byte) string {
+	return hashParts(parts...)
+}
+
+// Key identifies a scan of the image with the given manifest digest. An empty
+// platform is the host's default platform, which is what the image provider
+// resolves a multi-architecture index to in that case. Vulnerability
+// exceptions are part of the key because they decide which matches grype
+// reports as ignored.
+func Key(digest, platform string, vulnerabilityExceptions []string) string {
+	if platform == "" {
+		platform = runtime.GOOS + "/" + runtime.GOARCH
+	}
+	exceptions := slices.Clone(vulnerabilityExceptions)
+	slices.Sort(exceptions)
+	return hashParts([]byte(digest), []byte(platform), []byte(strings.Join(exceptions, "\n")))
+}
+
+func hashParts(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Result is a cached scan rehydrated for the current DB. Packages, Context and
+// SBOM are always set. Matches and IgnoredMatches are only set when Matched is
+// true; otherwise at least one cached match no longer resolves against the DB
+// and the caller must match Packages itself, which still skips cataloguing the
+// image.
+type Result struct {
+	Packages       []pkg.Package
+	Context        pkg.Context
+	SBOM           *sbom.SBOM
+	Matched        bool
+	Matches        match.Matches
+	IgnoredMatches []match.IgnoredMatch
+}
+
+// entry is the on-disk form of the matches. The package is referenced by its
+// syft ID, which the syft JSON SBOM preserves, and the vulnerability by the
+// fields that tell apart the DB records sharing an ID.
+type entry struct {
+	Matches        []cachedMatch `json:"matches"`
+	IgnoredMatches []cachedMatch `json:"ignoredMatches,omitempty"`
+}
+
+type cachedMatch struct {
+	VulnerabilityID string             `json:"vulnerabilityID"`
+	Namespace       string             `json:"namespace"`
+	PackageName     string             `json:"packageName"`
+	Constraint      string             `json:"constraint,omitempty"`
+	FixState        string             `json:"fixState,omitempty"`
+	FixVersions     []string           `json:"fixVersions,omitempty"`
+	PackageID       string             `json:"packageID"`
+	Details         match.Details      `json:"details,omitempty"`
+	IgnoreRules     []match.IgnoreRule `json:"ignoreRules,omitempty"`
+}
+
+// Get returns the cached result for key. providerConfig is used to turn the
+// cached SBOM back into grype packages, so it should match the configuration
+// the original scan used. A missing or unreadable entry is a miss.
+func (s *Store) Get(key string, vp vulnerability.Provider, providerConfig pkg.ProviderConfig) (*Result, bool) {
+	entryDir := filepath.Join(s.dir, key)
+	raw, err := os.ReadFile(filepath.Join(entryDir, matchesFile))
+	if err != nil {
+		return nil, false
+	}
+	var e entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, false
+	}
+
+	packages, pkgContext, sb, err := pkg.Provide("sbom:"+filepath.Join(entryDir, sbomFile), providerConfig)
+	if err != nil {
+		return nil, false
+	}
+	res := &Result{Packages: packages, Context: pkgContext, SBOM: sb}
+
+	r := restorer{vp: vp, packages: make(map[pkg.ID]pkg.Package, len(packages)), vulns: map[string][]vulnerability.Vulnerability{}}
+	for _, p := range packages {
+		r.packages[p.ID] = p
+	}
+	matches := match.NewMatches()
+	for _, c := range e.Matches {
+		m, ok := r.restore(c)
+		if !ok {
+			return res, true
+		}
+		matches.Add(m)
+	}
+	ignored := make([]match.IgnoredMatch, 0, len(e.IgnoredMatches))
+	for _, c := range e.IgnoredMatches {
+		m, ok := r.restore(c)
+		if !ok {
+			return res, true
+		}
+		ignored = append(ignored, match.IgnoredMatch{Match: m, AppliedIgnoreRules: c.IgnoreRules})
+	}
+
+	res.Matched = true
+	res.Matches = matches
+	res.IgnoredMatches = ignored
+	return res, true
+}
+
+// Put stores the SBOM and the matches grype produced for it under key.
+func (s *Store) Put(key string, sb *sbom.SBOM, matches match.Matches, ignoredMatches []match.IgnoredMatch) error {
+	if sb == nil {
+		return fmt.Errorf("no SBOM to cache")
+	}
+	var e entry
+	for _, m := range matches.Sorted() {
+		e.Matches = append(e.Matches, newCachedMatch(m, nil))
+	}
+	for _, m := range ignoredMatches {
+		e.IgnoredMatches = append(e.IgnoredMatches, newCachedMatch(m.Match, m.AppliedIgnoreRules))
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	f, err := os.OpenFile(filepath.Join(tmp, sbomFile), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := syftjson.NewFormatEncoder().Encode(f, *sb); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode SBOM: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, matchesFile), raw, 0o600); err != nil {
+		return err
+	}
+
+	entryDir := filepath.Join(s.dir, key)
+	if err := os.Rename(tmp, entryDir); err != nil {
+		if _, statErr := os.Stat(filepath.Join(entryDir, matchesFile)); statErr == nil {
+			return nil // another worker scanned the same digest first
+		}
+		_ = os.RemoveAll(entryDir) // incomplete entry from an interrupted run
+		return os.Rename(tmp, entryDir)
+	}
+	return nil
+}
+
+func newCachedMatch(m match.Match, rules []match.IgnoreRule) cachedMatch {
+	c := cachedMatch{
+		VulnerabilityID: m.Vulnerability.ID,
+		Namespace:       m.Vulnerability.Namespace,
+		PackageName:     m.Vulnerability.PackageName,
+		FixState:        string(m.Vulnerability.Fix.State),
+		FixVersions:     m.Vulnerability.Fix.Versions,
+		PackageID:       string(m.Package.ID),
+		Details:         m.Details,
+		IgnoreRules:     rules,
+	}
+	if m.Vulnerability.Constraint != nil {
+		c.Constraint = m.Vulnerability.Constraint.String()
+	}
+	return c
+}
+
+// restorer resolves cached matches against the loaded DB, querying each
+// vulnerability ID once per entry.
+type restorer struct {
+	vp       vulnerability.Provider
+	packages map[pkg.ID]pkg.Package
+	vulns    map[string][]vulnerability.Vulnerability
+}
+
+func (r *restorer) restore(c cachedMatch) (match.Match, bool) {
+	p, ok := r.packages[pkg.ID(c.PackageID)]
+	if !ok {
+		return match.Match{}, false
+	}
+	candidates, ok := r.vulns[c.VulnerabilityID]
+	if !ok {
+		var err error
+		candidates, err = r.vp.FindVulnerabilities(search.ByID(c.VulnerabilityID))
+		if err != nil {
+			return match.Match{}, false
+		}
+		r.vulns[c.VulnerabilityID] = candidates
+	}
+	for _, v := range candidates {
+		if v.Namespace != c.Namespace || v.PackageName != c.PackageName ||
+			string(v.Fix.State) != c.FixState || !slices.Equal(v.Fix.Versions, c.FixVersions) {
+			continue
+		}
+		constraint := ""
+		if v.Constraint != nil {
+			constraint = v.Constraint.String()
+		}
+		if constraint != c.Constraint {
+			continue
+		}
+		return match.Match{Vulnerability: v, Package: p, Details: c.Details}, true
+	}
+	return match.Match{}, false
+}
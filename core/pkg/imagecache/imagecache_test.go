@@ -0,0 +1,157 @@
+package imagecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anchore/grype/grype"
+	"github.com/anchore/grype/grype/match"
+	"github.com/anchore/grype/grype/matcher"
+	"github.com/anchore/grype/grype/pkg"
+	"github.com/anchore/grype/grype/version"
+	"github.com/anchore/grype/grype/vulnerability"
+	"github.com/anchore/grype/grype/vulnerability/mock"
+	syftPkg "github.com/anchore/syft/syft/pkg"
+	"github.com/anchore/syft/syft/sbom"
+	"github.com/anchore/syft/syft/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lodashVulnerability(id, constraint string) vulnerability.Vulnerability {
+	return vulnerability.Vulnerability{
+		Reference:   vulnerability.Reference{ID: id, Namespace: "github:language:javascript"},
+		PackageName: "lodash",
+		Constraint:  version.MustGetConstraint(constraint, version.SemanticFormat),
+		Fix:         vulnerability.Fix{State: vulnerability.FixStateFixed, Versions: []string{"4.17.21"}},
+	}
+}
+
+func testSBOM() *sbom.SBOM {
+	p := syftPkg.Package{
+		Name:     "lodash",
+		Version:  "4.17.0",
+		Type:     syftPkg.NpmPkg,
+		Language: syftPkg.JavaScript,
+		PURL:     "pkg:npm/lodash@4.17.0",
+	}
+	p.SetID()
+	return &sbom.SBOM{
+		Artifacts: sbom.Artifacts{Packages: syftPkg.NewCollection(p)},
+		Source:    source.Description{ID: "sha256:abc", Name: "registry.example/app", Version: "1.0"},
+	}
+}
+
+// scan mirrors the live path: packages come straight from the SBOM and the
+// matches from grype's matcher.
+func scan(t *testing.T, vp vulnerability.Provider, sb *sbom.SBOM, ignore []match.IgnoreRule) ([]pkg.Package, match.Matches, []match.IgnoredMatch) {
+	t.Helper()
+	packages := pkg.FromCollection(sb.Artifacts.Packages, pkg.SynthesisConfig{})
+	vm := grype.VulnerabilityMatcher{
+		VulnerabilityProvider: vp,
+		Matchers:              matcher.NewDefaultMatchers(matcher.Config{}),
+		IgnoreRules:           ignore,
+	}
+	remaining, ignored, err := vm.FindMatches(packages, pkg.Context{Source: &sb.Source})
+	require.NoError(t, err)
+	return packages, *remaining, ignored
+}
+
+func matchedIDs(matches match.Matches) []string {
+	var ids []string
+	for _, m := range matches.Sorted() {
+		ids = append(ids, m.Vulnerability.ID)
+	}
+	return ids
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	vp := mock.VulnerabilityProvider(
+		lodashVulnerability("GHSA-1111", "< 4.17.21"),
+		lodashVulnerability("GHSA-2222", "< 4.17.19"),
+	)
+	sb := testSBOM()
+	packages, matches, ignored := scan(t, vp, sb, []match.IgnoreRule{{Vulnerability: "GHSA-2222"}})
+	require.Equal(t, []string{"GHSA-1111"}, matchedIDs(matches))
+	require.Len(t, ignored, 1)
+
+	store, err := Open(t.TempDir(), Generation([]byte("db-1")))
+	require.NoError(t, err)
+	key := Key("sha256:digest", "linux/amd64", []string{"GHSA-2222"})
+
+	_, ok := store.Get(key, vp, pkg.ProviderConfig{})
+	assert.False(t, ok)
+
+	require.NoError(t, store.Put(key, sb, matches, ignored))
+	res, ok := store.Get(key, vp, pkg.ProviderConfig{})
+	require.True(t, ok)
+	require.True(t, res.Matched)
+
+	require.Len(t, res.Packages, 1)
+	assert.Equal(t, packages[0].ID, res.Packages[0].ID)
+	assert.Equal(t, "registry.example/app", res.Context.Source.Name)
+	require.NotNil(t, res.SBOM)
+
+	assert.Equal(t, []string{"GHSA-1111"}, matchedIDs(res.Matches))
+	restored := res.Matches.Sorted()[0]
+	original := matches.Sorted()[0]
+	assert.Equal(t, original.Package.ID, restored.Package.ID)
+	assert.Equal(t, original.Vulnerability.Constraint.String(), restored.Vulnerability.Constraint.String())
+	assert.Equal(t, original.Details.Types(), restored.Details.Types())
+
+	require.Len(t, res.IgnoredMatches, 1)
+	assert.Equal(t, "GHSA-2222", res.IgnoredMatches[0].Vulnerability.ID)
+	assert.Equal(t, ignored[0].AppliedIgnoreRules, res.IgnoredMatches[0].AppliedIgnoreRules)
+}
+
+func TestStoreGetUnresolvedMatch(t *testing.T) {
+	vp := mock.VulnerabilityProvider(lodashVulnerability("GHSA-1111", "< 4.17.21"))
+	sb := testSBOM()
+	_, matches, ignored := scan(t, vp, sb, nil)
+
+	store, err := Open(t.TempDir(), Generation([]byte("db-1")))
+	require.NoError(t, err)
+	key := Key("sha256:digest", "", nil)
+	require.NoError(t, store.Put(key, sb, matches, ignored))
+
+	// The record was amended in the DB without a generation change: the SBOM
+	// is still usable but the matches must be recomputed.
+	amended := mock.VulnerabilityProvider(lodashVulnerability("GHSA-1111", "< 4.17.20"))
+	res, ok := store.Get(key, amended, pkg.ProviderConfig{})
+	require.True(t, ok)
+	assert.False(t, res.Matched)
+	assert.Len(t, res.Packages, 1)
+}
+
+func TestOpenRemovesOtherGenerations(t *testing.T) {
+	cacheDir := t.TempDir()
+	vp := mock.VulnerabilityProvider(lodashVulnerability("GHSA-1111", "< 4.17.21"))
+	sb := testSBOM()
+	_, matches, ignored := scan(t, vp, sb, nil)
+	key := Key("sha256:digest", "", nil)
+
+	oldGen := Generation([]byte("db-1"))
+	old, err := Open(cacheDir, oldGen)
+	require.NoError(t, err)
+	require.NoError(t, old.Put(key, sb, matches, ignored))
+
+	current, err := Open(cacheDir, Generation([]byte("db-2")))
+	require.NoError(t, err)
+	_, ok := current.Get(key, vp, pkg.ProviderConfig{})
+	assert.False(t, ok)
+	_, err = os.Stat(filepath.Join(cacheDir, dirName, oldGen))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, Delete(cacheDir))
+	_, err = os.Stat(filepath.Join(cacheDir, dirName))
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, Delete(cacheDir))
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("sha256:a", "linux/amd64", []string{"CVE-1", "CVE-2"}), Key("sha256:a", "linux/amd64", []string{"CVE-2", "CVE-1"}))
+	assert.NotEqual(t, Key("sha256:a", "linux/amd64", nil), Key("sha256:b", "linux/amd64", nil))
+	assert.NotEqual(t, Key("sha256:a", "linux/amd64", nil), Key("sha256:a", "linux/arm64", nil))
+	assert.NotEqual(t, Key("sha256:a", "linux/amd64", nil), Key("sha256:a", "linux/amd64", []string{"CVE-1"}))
+}
@@ -2,6 +2,8 @@ package cel
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 )
@@ -17,6 +19,19 @@ import (
 // evaluates the gate itself. Refusing to answer metadata questions about it
 // would wrongly block that workflow.
 
+// ControlIDs returns the IDs of the controls the embedded bundle implements,
+// sorted. A control claimed by more than one policy is left out, since no
+// lookup answers for it.
+func ControlIDs() ([]string, error) {
+	catalog, err := getVAPCatalog()
+	if err != nil {
+		return nil, err
+	}
+	ids := slices.Collect(maps.Keys(catalog.byControl))
+	slices.Sort(ids)
+	return ids, nil
+}
+
 // PolicyNameForControl returns the metadata.name of the ValidatingAdmissionPolicy
 // implementing a control (e.g. C-0016 ->
 // kubescape-c-0016-allow-privilege-escalation), read from the embedded bundle.
@@ -104,3 +119,29 @@ func MatchConstraintsForPolicy(policyName string) (matchConstraints *admissionre
 	}
 	return vap.matchConstraints.DeepCopy(), true, nil
 }
+
+// ControlExpressions is the CEL source of a control's policy as the bundle
+// ships it, for callers that show a control's logic to a human (or a model)
+// rather than evaluate it.
+type ControlExpressions struct {
+	PolicyName      string
+	MatchConditions []MatchCondition
+	Variables       []Variable
+	Validations     []Validation
+}
+
+// ExpressionsForControl returns the matchConditions, variables and validations
+// of the control's policy, copied so the catalog's parsed policies stay
+// immutable. It errors when the control has no policy in the bundle.
+func ExpressionsForControl(controlID string) (ControlExpressions, error) {
+	vap, err := lookupVAP(controlID)
+	if err != nil {
+		return ControlExpressions{}, err
+	}
+	return ControlExpressions{
+		PolicyName:      vap.PolicyName,
+		MatchConditions: append([]MatchCondition(nil), vap.matchConditions...),
+		Variables:       append([]Variable(nil), vap.Variables...),
+		Validations:     append([]Validation(nil), vap.Validations...),
+	}, nil
+}
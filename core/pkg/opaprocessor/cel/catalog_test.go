@@ -7,6 +7,20 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// TestControlIDs checks the bundle's controls are listed sorted, and that
+// each resolves to its policy.
+func TestControlIDs(t *testing.T) {
+	ids, err := ControlIDs()
+	require.NoError(t, err)
+	assert.Contains(t, ids, "C-0016")
+	assert.Contains(t, ids, "C-0017")
+	assert.IsNonDecreasing(t, ids)
+	for _, id := range ids {
+		_, err := PolicyNameForControl(id)
+		assert.NoError(t, err, id)
+	}
+}
+
 // TestPolicyNameForControl checks the control -> policy-name lookup against the
 // real embedded bundle, so callers (cmd/vap) resolve exactly the names the
 // deployable YAML carries.
@@ -136,3 +150,23 @@ func TestMatchConstraintsForPolicyCopiesBundle(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, found)
 }
+
+// TestExpressionsForControl checks the CEL source is read off the embedded
+// policy and handed out as a copy.
+func TestExpressionsForControl(t *testing.T) {
+	exprs, err := ExpressionsForControl("C-0016")
+	require.NoError(t, err)
+	assert.Equal(t, "kubescape-c-0016-allow-privilege-escalation", exprs.PolicyName)
+	require.NotEmpty(t, exprs.Validations)
+	for _, v := range exprs.Validations {
+		assert.NotEmpty(t, v.Expression)
+	}
+
+	exprs.Validations[0].Expression = "false"
+	again, err := ExpressionsForControl("C-0016")
+	require.NoError(t, err)
+	assert.NotEqual(t, "false", again.Validations[0].Expression, "callers must not be able to mutate the catalog")
+
+	_, err = ExpressionsForControl("C-9999")
+	require.Error(t, err)
+}
@@ -41,6 +41,14 @@ func (e *Evaluator) EvaluateControl(ctx context.Context, controlID string, obj,
 	if err != nil {
 		return ControlEvaluation{}, err
 	}
+	return e.EvaluatePolicy(ctx, vap, obj, namespaceObject)
+}
+
+// EvaluatePolicy evaluates an already loaded policy against an object, with
+// the same scoping, params and matchConditions handling as EvaluateControl. It
+// is the entry point for policies that do not come from the embedded bundle,
+// such as the custom CEL rules of a framework definition (see ParsePolicy).
+func (e *Evaluator) EvaluatePolicy(ctx context.Context, vap *VAP, obj, namespaceObject map[string]any) (ControlEvaluation, error) {
 	if !vap.appliesTo(obj) {
 		return ControlEvaluation{Applicable: false}, nil
 	}
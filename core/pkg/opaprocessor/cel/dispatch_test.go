@@ -195,3 +195,61 @@ func TestEvaluateControlRefusesUnresolvableParams(t *testing.T) {
 	assert.Contains(t, err.Error(), "WorkerPool")
 	assert.Empty(t, eval.Results, "a refused control reports no verdict")
 }
+
+// TestEvaluatePolicyParsedPolicy proves a policy from outside the bundle
+// evaluates through the same path as a bundled control.
+func TestEvaluatePolicyParsedPolicy(t *testing.T) {
+	e, err := NewEvaluator()
+	require.NoError(t, err)
+
+	vap, err := ParsePolicy("custom-read-only-root", []byte(`
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: read-only-root
+spec:
+  matchConstraints:
+    resourceRules:
+      - apiGroups: [""]
+        apiVersions: ["v1"]
+        operations: ["CREATE", "UPDATE"]
+        resources: ["pods"]
+  validations:
+    - expression: "object.spec.containers.all(c, has(c.securityContext) && has(c.securityContext.readOnlyRootFilesystem) && c.securityContext.readOnlyRootFilesystem)"
+      message: "containers must use a read-only root filesystem"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "custom-read-only-root", vap.ControlID)
+
+	eval, err := e.EvaluatePolicy(context.Background(), vap, mutableFilesystemPod(), nil)
+	require.NoError(t, err)
+	require.True(t, eval.Applicable)
+	require.Len(t, eval.Results, 1)
+	assert.False(t, eval.Results[0].Passed)
+
+	eval, err = e.EvaluatePolicy(context.Background(), vap, readOnlyFilesystemPod(), nil)
+	require.NoError(t, err)
+	require.Len(t, eval.Results, 1)
+	assert.True(t, eval.Results[0].Passed)
+
+	eval, err = e.EvaluatePolicy(context.Background(), vap, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "cm", "namespace": "default"},
+	}, nil)
+	require.NoError(t, err)
+	assert.False(t, eval.Applicable)
+}
+
+func TestParsePolicyRejects(t *testing.T) {
+	for name, doc := range map[string]string{
+		"not a policy":   "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: cm}\n",
+		"no validations": "apiVersion: admissionregistration.k8s.io/v1\nkind: ValidatingAdmissionPolicy\nmetadata: {name: empty}\nspec: {}\n",
+		"unknown fields": "apiVersion: admissionregistration.k8s.io/v1\nkind: ValidatingAdmissionPolicy\nmetadata: {name: typo}\nspec: {validation: []}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePolicy("custom-x", []byte(doc))
+			require.Error(t, err)
+		})
+	}
+}
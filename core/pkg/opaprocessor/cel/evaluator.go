@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/google/cel-go/cel"
@@ -108,6 +109,11 @@ type Evaluator struct {
 	// the base env bakes in cel.CostLimit(PerCallLimit) and overriding it would
 	// mean scanning under a different ceiling than admission enforces.
 	costBudget int64
+	// rules reports whether env declares the variables of the CEL rules of a
+	// policy library (see NewRuleEvaluator), and bindings holds their values
+	// for the object being evaluated (see withBindings).
+	rules    bool
+	bindings map[string]any
 }
 
 // Option configures an Evaluator.
@@ -134,6 +140,10 @@ func NewEvaluator(opts ...Option) (*Evaluator, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newEvaluator(env, opts), nil
+}
+
+func newEvaluator(env *cel.Env, opts []Option) *Evaluator {
 	e := &Evaluator{env: env}
 	for _, opt := range opts {
 		opt(e)
@@ -142,7 +152,7 @@ func NewEvaluator(opts ...Option) (*Evaluator, error) {
 	// options are applied above, before anything can trigger a compile.
 	e.programs = newProgramCache(e.compileProgram)
 	e.plans = newPathPlanCache(e.buildPathPlan)
-	return e, nil
+	return e
 }
 
 // EvaluateOnObject evaluates one VAP's variables and validations against a
@@ -236,6 +246,7 @@ func (e *Evaluator) activationFor(ctx context.Context, obj, namespaceObject map[
 	activation["object"] = obj
 	activation["params"] = params
 	activation["variables"] = e.lazyVariables(ctx, variables, activation, budget)
+	maps.Copy(activation, e.bindings)
 	return activation
 }
 
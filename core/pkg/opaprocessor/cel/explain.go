@@ -0,0 +1,162 @@
+package cel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+)
+
+// SubExpressionValue is the value one sub-expression of a traced expression
+// evaluated to. Err is set instead of Value when it evaluated to an error.
+type SubExpressionValue struct {
+	Expression string
+	Value      any
+	Err        string
+}
+
+// ExpressionTrace is one expression of a policy with the values its
+// sub-expressions evaluated to, outermost first. Name is only set for a
+// variable.
+//
+// A sub-expression inside a comprehension's body is evaluated once per element,
+// so it is not listed: only the list the comprehension iterates and the
+// comprehension's own result are. The remediation paths of a failed validation
+// pin the element that failed.
+type ExpressionTrace struct {
+	Name       string
+	Expression string
+	Values     []SubExpressionValue
+	Err        error
+}
+
+// PolicyExplanation is the outcome of evaluating one policy against one object,
+// with the trace of every expression that decided it.
+type PolicyExplanation struct {
+	ControlEvaluation
+	Variables   []ExpressionTrace
+	Validations []ExpressionTrace
+}
+
+// ExplainControl is EvaluateControl with the trace of the policy's variables
+// and validations. See ExplainPolicy.
+func (e *Evaluator) ExplainControl(ctx context.Context, controlID string, obj, namespaceObject map[string]any) (PolicyExplanation, error) {
+	vap, err := loadVAP(controlID)
+	if err != nil {
+		return PolicyExplanation{}, err
+	}
+	return e.ExplainPolicy(ctx, vap, obj, namespaceObject)
+}
+
+// ExplainPolicy evaluates a policy against an object like EvaluatePolicy, then
+// evaluates each of its variables and validations again, tracking the value of
+// every sub-expression. The verdicts come from the first evaluation: the traces
+// are evaluated unmetered, so they explain a verdict without being able to
+// change it. An object the policy does not apply to, or whose match conditions
+// failed to evaluate, has no traces.
+func (e *Evaluator) ExplainPolicy(ctx context.Context, vap *VAP, obj, namespaceObject map[string]any) (PolicyExplanation, error) {
+	eval, err := e.EvaluatePolicy(ctx, vap, obj, namespaceObject)
+	if err != nil {
+		return PolicyExplanation{}, err
+	}
+	explanation := PolicyExplanation{ControlEvaluation: eval}
+	if !eval.Applicable || len(eval.Results) != len(vap.Validations) {
+		return explanation, nil // not matched, or a match condition errored
+	}
+	params, err := resolveParams(vap)
+	if err != nil {
+		return PolicyExplanation{}, err
+	}
+
+	if err := e.trace(ctx, &explanation, obj, namespaceObject, params, vap.Variables, vap.Validations); err != nil {
+		return PolicyExplanation{}, err
+	}
+	return explanation, nil
+}
+
+// trace adds the traces of variables and validations, evaluated unmetered
+// against obj, to explanation.
+func (e *Evaluator) trace(ctx context.Context, explanation *PolicyExplanation, obj, namespaceObject map[string]any, params any, variables []Variable, validations []Validation) error {
+	// Macro call tracking keeps the source of the macros (all, exists, ...) the
+	// parser expands, so the sub-expressions containing one print as written.
+	env, err := e.env.Extend(cel.EnableMacroCallTracking())
+	if err != nil {
+		return err
+	}
+	activation := e.activationFor(ctx, obj, namespaceObject, params, variables, nil)
+	for _, v := range variables {
+		trace := traceExpression(ctx, env, v.Expression, activation)
+		trace.Name = v.Name
+		explanation.Variables = append(explanation.Variables, trace)
+	}
+	for _, val := range validations {
+		explanation.Validations = append(explanation.Validations, traceExpression(ctx, env, val.Expression, activation))
+	}
+	return nil
+}
+
+// traceExpression evaluates an expression with state tracking and reads the
+// values of its sub-expressions off the evaluation state. The program is
+// compiled apart from the program cache, since state tracking slows every
+// evaluation down.
+func traceExpression(ctx context.Context, env *cel.Env, expr string, activation map[string]any) ExpressionTrace {
+	trace := ExpressionTrace{Expression: expr}
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		trace.Err = fmt.Errorf("compile: %w", issues.Err())
+		return trace
+	}
+	prog, err := env.Program(ast, cel.EvalOptions(cel.OptTrackState), cel.InterruptCheckFrequency(celconfig.CheckFrequency))
+	if err != nil {
+		trace.Err = fmt.Errorf("program: %w", err)
+		return trace
+	}
+	_, details, err := prog.ContextEval(ctx, activation)
+	if err != nil {
+		trace.Err = fmt.Errorf("eval: %w", err)
+	}
+	if details == nil {
+		return trace
+	}
+
+	native := ast.NativeRep()
+	state := details.State()
+	seen := make(map[string]bool)
+	var visit func(node celast.NavigableExpr)
+	visit = func(node celast.NavigableExpr) {
+		switch node.Kind() {
+		case celast.LiteralKind, celast.IdentKind:
+			return // literals are in the expression, identifiers bind whole objects
+		}
+		if val, ok := state.Value(node.ID()); ok && !types.IsUnknown(val) {
+			if text, err := cel.ExprToString(node, native.SourceInfo()); err == nil && !seen[text] {
+				seen[text] = true
+				value := SubExpressionValue{Expression: text}
+				if types.IsError(val) {
+					value.Err = fmt.Sprint(val.Value())
+				} else {
+					value.Value = val.Value()
+				}
+				trace.Values = append(trace.Values, value)
+			}
+		}
+		children := node.Children()
+		if node.Kind() == celast.ComprehensionKind {
+			iterRange := node.AsComprehension().IterRange().ID()
+			for _, child := range children {
+				if child.ID() == iterRange {
+					visit(child)
+				}
+			}
+			return
+		}
+		for _, child := range children {
+			visit(child)
+		}
+	}
+	visit(celast.NavigateAST(native))
+	return trace
+}
@@ -0,0 +1,93 @@
+package cel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func traceValue(t *testing.T, trace ExpressionTrace, expr string) SubExpressionValue {
+	t.Helper()
+	for _, v := range trace.Values {
+		if v.Expression == expr {
+			return v
+		}
+	}
+	require.Failf(t, "sub-expression not traced", "%q not in %+v", expr, trace.Values)
+	return SubExpressionValue{}
+}
+
+func TestExplainPolicy(t *testing.T) {
+	e, err := NewEvaluator()
+	require.NoError(t, err)
+
+	vap, err := ParsePolicy("custom-host-network", []byte(`
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: host-network
+spec:
+  matchConstraints:
+    resourceRules:
+      - apiGroups: [""]
+        apiVersions: ["v1"]
+        operations: ["CREATE", "UPDATE"]
+        resources: ["pods"]
+  variables:
+    - name: hostNetwork
+      expression: "has(object.spec.hostNetwork) && object.spec.hostNetwork"
+  validations:
+    - expression: "!variables.hostNetwork"
+      message: "pods must not use the host network"
+    - expression: "object.spec.containers.all(c, has(c.securityContext) && has(c.securityContext.readOnlyRootFilesystem) && c.securityContext.readOnlyRootFilesystem)"
+`))
+	require.NoError(t, err)
+
+	pod := mutableFilesystemPod()
+	pod["spec"].(map[string]any)["hostNetwork"] = true
+
+	explanation, err := e.ExplainPolicy(context.Background(), vap, pod, nil)
+	require.NoError(t, err)
+	require.True(t, explanation.Applicable)
+	require.Len(t, explanation.Results, 2)
+	assert.False(t, explanation.Results[0].Passed)
+	assert.False(t, explanation.Results[1].Passed)
+
+	t.Run("traces the variables", func(t *testing.T) {
+		require.Len(t, explanation.Variables, 1)
+		variable := explanation.Variables[0]
+		assert.Equal(t, "hostNetwork", variable.Name)
+		assert.NoError(t, variable.Err)
+		assert.Equal(t, true, traceValue(t, variable, "object.spec.hostNetwork").Value)
+		assert.Equal(t, true, traceValue(t, variable, "has(object.spec.hostNetwork)").Value)
+	})
+
+	t.Run("traces the validations", func(t *testing.T) {
+		require.Len(t, explanation.Validations, 2)
+		assert.Equal(t, true, traceValue(t, explanation.Validations[0], "variables.hostNetwork").Value)
+		assert.Equal(t, false, traceValue(t, explanation.Validations[0], "!variables.hostNetwork").Value)
+	})
+
+	t.Run("traces a comprehension's range and result, not its body", func(t *testing.T) {
+		validation := explanation.Validations[1]
+		assert.Equal(t, false, validation.Values[0].Value, "the outermost expression comes first")
+		traceValue(t, validation, "object.spec.containers")
+		for _, v := range validation.Values {
+			assert.NotContains(t, v.Expression, "@")
+			assert.NotEqual(t, "c.securityContext.readOnlyRootFilesystem", v.Expression)
+		}
+	})
+
+	t.Run("no traces for an object the policy does not apply to", func(t *testing.T) {
+		explanation, err := e.ExplainPolicy(context.Background(), vap, map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "cm", "namespace": "default"},
+		}, nil)
+		require.NoError(t, err)
+		assert.False(t, explanation.Applicable)
+		assert.Empty(t, explanation.Validations)
+	})
+}
@@ -258,6 +258,30 @@ func parseVAPBundle(data []byte) (*vapCatalog, error) {
 	return catalog, nil
 }
 
+// ParsePolicy parses a single ValidatingAdmissionPolicy authored outside the
+// embedded bundle, such as a custom CEL rule of a framework definition, and
+// refuses it when the offline engine cannot evaluate it with scan/admission
+// parity, exactly as loadVAP refuses a bundled one. controlID names the control
+// the policy is evaluated for, whatever its controlId label says.
+func ParsePolicy(controlID string, data []byte) (*VAP, error) {
+	var policy admissionregistrationv1.ValidatingAdmissionPolicy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("decode %s for control %q: %w", vapKind, controlID, err)
+	}
+	if policy.Kind != vapKind || policy.APIVersion != vapAPIVersion {
+		return nil, fmt.Errorf("control %q: expected a %s %s, got %s %s", controlID, vapAPIVersion, vapKind, policy.APIVersion, policy.Kind)
+	}
+	if len(policy.Spec.Validations) == 0 {
+		return nil, fmt.Errorf("control %q: %s %q has no validations", controlID, vapKind, policy.Name)
+	}
+	vap := newVAP(&policy)
+	vap.ControlID = controlID
+	if err := vap.requireSupported(); err != nil {
+		return nil, err
+	}
+	return vap, nil
+}
+
 // indexUnique adds one policy under one key, enforcing the duplicate-poisoning
 // scheme: the first occurrence indexes, a second drops the key from the index
 // and marks it duplicated, and further occurrences stay poisoned. An empty key
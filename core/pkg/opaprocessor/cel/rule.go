@@ -0,0 +1,158 @@
+package cel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"sigs.k8s.io/yaml"
+)
+
+// relatedObjectsVariable is the variable a library rule reads the scan's other
+// objects from: the objects a RegoResourcesAggregator grouped with the rule's
+// object, or every object of the input the object is not itself one of.
+const relatedObjectsVariable = "relatedObjects"
+
+// Rule is a regolibrary PolicyRule authored in CEL: the body of the rule's
+// `rule` field, evaluated against each object the rule matches like the
+// validations of a ValidatingAdmissionPolicy. Unlike a policy it has no
+// matchConstraints (the PolicyRule's match already scopes it) and no
+// failurePolicy: a validation that errors leaves the object's verdict unknown,
+// as a Rego rule that errors does.
+type Rule struct {
+	Name        string
+	Variables   []Variable
+	Validations []Validation
+}
+
+// ruleDocument is the YAML shape of a Rule.
+type ruleDocument struct {
+	Variables []struct {
+		Name       string `json:"name"`
+		Expression string `json:"expression"`
+	} `json:"variables,omitempty"`
+	Validations []struct {
+		Expression        string `json:"expression"`
+		Message           string `json:"message,omitempty"`
+		MessageExpression string `json:"messageExpression,omitempty"`
+	} `json:"validations"`
+}
+
+// ParseRule parses the body of a CEL PolicyRule:
+//
+//	variables:
+//	  - name: containers
+//	    expression: object.spec.containers
+//	validations:
+//	  - expression: variables.containers.all(c, c.securityContext.readOnlyRootFilesystem)
+//	    message: containers must have an immutable filesystem
+//
+// name is the PolicyRule's name, used in errors.
+func ParseRule(name string, data []byte) (*Rule, error) {
+	var doc ruleDocument
+	if err := yaml.UnmarshalStrict(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode CEL rule %q: %w", name, err)
+	}
+	if len(doc.Validations) == 0 {
+		return nil, fmt.Errorf("CEL rule %q has no validations", name)
+	}
+	rule := &Rule{Name: name}
+	for i, v := range doc.Variables {
+		if v.Name == "" || v.Expression == "" {
+			return nil, fmt.Errorf("CEL rule %q: variable %d needs a name and an expression", name, i)
+		}
+		rule.Variables = append(rule.Variables, Variable{Name: v.Name, Expression: v.Expression})
+	}
+	for i, v := range doc.Validations {
+		if v.Expression == "" {
+			return nil, fmt.Errorf("CEL rule %q: validation %d has no expression", name, i)
+		}
+		rule.Validations = append(rule.Validations, Validation{Expression: v.Expression, Message: v.Message, MessageExpression: v.MessageExpression})
+	}
+	return rule, nil
+}
+
+// NewRuleEvaluator builds an Evaluator for library rules: the VAP env of
+// NewEvaluator plus relatedObjects. It is kept apart from the VAP evaluator so
+// that a policy naming relatedObjects fails to compile, as it would at
+// admission.
+func NewRuleEvaluator(opts ...Option) (*Evaluator, error) {
+	env, err := newEnv()
+	if err != nil {
+		return nil, err
+	}
+	env, err = env.Extend(cel.Variable(relatedObjectsVariable, cel.ListType(cel.DynType)))
+	if err != nil {
+		return nil, err
+	}
+	e := newEvaluator(env, opts)
+	e.rules = true
+	return e, nil
+}
+
+// withBindings returns a copy of e that binds the library rule variables to
+// the given values. The copy shares e's program and path plan caches, so it is
+// cheap to make once per object.
+func (e *Evaluator) withBindings(relatedObjects []any) *Evaluator {
+	bound := *e
+	if relatedObjects == nil {
+		relatedObjects = []any{}
+	}
+	bound.bindings = map[string]any{relatedObjectsVariable: relatedObjects}
+	return &bound
+}
+
+// EvaluateRule evaluates a library rule against one object, with its
+// validations' verdicts, messages and remediation paths computed exactly as
+// for a policy (see EvaluateOnObject).
+//
+// params is bound to "params" as is; the scanner binds the control inputs
+// under params.settings, the way a policy reads its ControlConfiguration.
+// relatedObjects is bound to "relatedObjects". The result is always Applicable
+// and never FailOnError, see Rule.
+func (e *Evaluator) EvaluateRule(ctx context.Context, rule *Rule, obj, namespaceObject map[string]any, relatedObjects []any, params map[string]any) (ControlEvaluation, error) {
+	if !e.rules {
+		return ControlEvaluation{}, errors.New("CEL rules need an evaluator built by NewRuleEvaluator")
+	}
+	results, err := e.withBindings(relatedObjects).EvaluateOnObject(ctx, obj, namespaceObject, params, rule.Variables, rule.Validations)
+	if err != nil {
+		return ControlEvaluation{}, err
+	}
+	return ControlEvaluation{Applicable: true, Results: results}, nil
+}
+
+// Selects evaluates the CEL ResourceEnumerator of a library rule, a boolean
+// expression over object, namespaceObject, params and relatedObjects, and reports
+// whether the object is one the rule should evaluate.
+func (e *Evaluator) Selects(ctx context.Context, expr string, obj, namespaceObject map[string]any, relatedObjects []any, params map[string]any) (bool, error) {
+	if !e.rules {
+		return false, errors.New("CEL rules need an evaluator built by NewRuleEvaluator")
+	}
+	bound := e.withBindings(relatedObjects)
+	budget := newCostBudget(bound.budgetLimit())
+	val, err := bound.evalExpression(ctx, expr, bound.activationFor(ctx, obj, namespaceObject, params, nil, budget), budget)
+	if err != nil {
+		return false, err
+	}
+	selected, ok := val.(types.Bool)
+	if !ok {
+		return false, fmt.Errorf("resource enumerator %q returned %s, expected bool", expr, val.Type())
+	}
+	return bool(selected), nil
+}
+
+// ExplainRule is EvaluateRule with the trace of the rule's variables and
+// validations. See ExplainPolicy.
+func (e *Evaluator) ExplainRule(ctx context.Context, rule *Rule, obj, namespaceObject map[string]any, relatedObjects []any, params map[string]any) (PolicyExplanation, error) {
+	eval, err := e.EvaluateRule(ctx, rule, obj, namespaceObject, relatedObjects, params)
+	if err != nil {
+		return PolicyExplanation{}, err
+	}
+	explanation := PolicyExplanation{ControlEvaluation: eval}
+	if err := e.withBindings(relatedObjects).trace(ctx, &explanation, obj, namespaceObject, params, rule.Variables, rule.Validations); err != nil {
+		return PolicyExplanation{}, err
+	}
+	return explanation, nil
+}
@@ -0,0 +1,137 @@
+package cel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const immutableFilesystemRule = `
+variables:
+  - name: containers
+    expression: object.spec.containers
+validations:
+  - expression: "variables.containers.all(c, has(c.securityContext) && has(c.securityContext.readOnlyRootFilesystem) && c.securityContext.readOnlyRootFilesystem)"
+    message: containers must have an immutable filesystem
+`
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("immutable-container-filesystem", []byte(immutableFilesystemRule))
+	require.NoError(t, err)
+	assert.Equal(t, "immutable-container-filesystem", rule.Name)
+	assert.Equal(t, []Variable{{Name: "containers", Expression: "object.spec.containers"}}, rule.Variables)
+	require.Len(t, rule.Validations, 1)
+	assert.Equal(t, "containers must have an immutable filesystem", rule.Validations[0].Message)
+
+	tests := []struct {
+		name      string
+		data      string
+		wantError string
+	}{
+		{name: "no validations", data: "variables: []", wantError: "has no validations"},
+		{name: "unknown field", data: "validations: [{expression: 'true'}]\nmatchConstraints: {}", wantError: "decode CEL rule"},
+		{name: "unnamed variable", data: "variables: [{expression: 'true'}]\nvalidations: [{expression: 'true'}]", wantError: "variable 0 needs a name"},
+		{name: "empty validation", data: "validations: [{message: m}]", wantError: "validation 0 has no expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRule("r", []byte(tt.data))
+			assert.ErrorContains(t, err, tt.wantError)
+		})
+	}
+}
+
+func TestEvaluateRule(t *testing.T) {
+	e, err := NewRuleEvaluator()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("reports violations with paths", func(t *testing.T) {
+		rule, err := ParseRule("immutable-container-filesystem", []byte(immutableFilesystemRule))
+		require.NoError(t, err)
+
+		eval, err := e.EvaluateRule(ctx, rule, mutableFilesystemPod(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.True(t, eval.Applicable)
+		assert.False(t, eval.FailOnError)
+		require.Len(t, eval.Results, 1)
+		assert.False(t, eval.Results[0].Passed)
+		assert.Equal(t, "containers must have an immutable filesystem", eval.Results[0].Message)
+		assert.Equal(t, []PathHint{{Path: "spec.containers[0].securityContext.readOnlyRootFilesystem"}}, eval.Results[0].Paths, "paths are read through variables")
+
+		eval, err = e.EvaluateRule(ctx, rule, readOnlyFilesystemPod(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.True(t, eval.Results[0].Passed)
+	})
+
+	t.Run("binds related objects and params", func(t *testing.T) {
+		rule, err := ParseRule("exposed-pod", []byte(`
+validations:
+  - expression: "!relatedObjects.exists(s, s.kind == 'Service' && s.spec.type in params.settings.exposedServiceTypes)"
+    message: the pod is exposed
+`))
+		require.NoError(t, err)
+		service := map[string]any{"kind": "Service", "spec": map[string]any{"type": "NodePort"}}
+		params := map[string]any{"settings": map[string]any{"exposedServiceTypes": []any{"NodePort", "LoadBalancer"}}}
+
+		eval, err := e.EvaluateRule(ctx, rule, mutableFilesystemPod(), nil, []any{service}, params)
+		require.NoError(t, err)
+		assert.False(t, eval.Results[0].Passed)
+
+		eval, err = e.EvaluateRule(ctx, rule, mutableFilesystemPod(), nil, nil, params)
+		require.NoError(t, err)
+		assert.True(t, eval.Results[0].Passed, "no related objects binds an empty list")
+	})
+
+	t.Run("needs a rule evaluator", func(t *testing.T) {
+		vapEvaluator, err := NewEvaluator()
+		require.NoError(t, err)
+		_, err = vapEvaluator.EvaluateRule(ctx, &Rule{}, mutableFilesystemPod(), nil, nil, nil)
+		assert.ErrorContains(t, err, "NewRuleEvaluator")
+	})
+
+	t.Run("policies cannot read related objects", func(t *testing.T) {
+		vapEvaluator, err := NewEvaluator()
+		require.NoError(t, err)
+		results, err := vapEvaluator.EvaluateOnObject(ctx, mutableFilesystemPod(), nil, nil, nil, []Validation{{Expression: "relatedObjects.size() == 0"}})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Error(t, results[0].Err)
+	})
+}
+
+func TestSelects(t *testing.T) {
+	e, err := NewRuleEvaluator()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	selected, err := e.Selects(ctx, "object.metadata.namespace == 'default'", mutableFilesystemPod(), nil, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, selected)
+
+	selected, err = e.Selects(ctx, "relatedObjects.size() > 0", mutableFilesystemPod(), nil, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, selected)
+
+	_, err = e.Selects(ctx, "object.metadata.name", mutableFilesystemPod(), nil, nil, nil)
+	assert.ErrorContains(t, err, "expected bool")
+}
+
+func TestExplainRule(t *testing.T) {
+	e, err := NewRuleEvaluator()
+	require.NoError(t, err)
+	rule, err := ParseRule("immutable-container-filesystem", []byte(immutableFilesystemRule))
+	require.NoError(t, err)
+
+	explanation, err := e.ExplainRule(context.Background(), rule, mutableFilesystemPod(), nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, explanation.Results, 1)
+	assert.False(t, explanation.Results[0].Passed)
+	require.Len(t, explanation.Variables, 1)
+	assert.Equal(t, "containers", explanation.Variables[0].Name)
+	require.Len(t, explanation.Validations, 1)
+	assert.NoError(t, explanation.Validations[0].Err)
+	assert.NotEmpty(t, explanation.Validations[0].Values)
+}
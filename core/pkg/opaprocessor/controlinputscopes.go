@@ -0,0 +1,156 @@
+package opaprocessor
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"path"
+	"slices"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/kubescape/opa-utils/resources"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// inputsScope is a control inputs scope with its selectors parsed. A nil
+// selector is unset.
+type inputsScope struct {
+	*getter.ControlInputsScope
+	namespaceSelector labels.Selector
+	labelSelector     labels.Selector
+}
+
+// newInputsScopes parses the selectors of the session's control inputs scopes,
+// which were validated when loaded.
+func newInputsScopes(sessionObj *cautils.OPASessionObj) []inputsScope {
+	if sessionObj == nil {
+		return nil
+	}
+	scopes := make([]inputsScope, 0, len(sessionObj.ControlInputsScopes))
+	for i := range sessionObj.ControlInputsScopes {
+		scope := inputsScope{ControlInputsScope: &sessionObj.ControlInputsScopes[i]}
+		if scope.NamespaceSelector != "" {
+			scope.namespaceSelector, _ = labels.Parse(scope.NamespaceSelector)
+		}
+		if scope.LabelSelector != "" {
+			scope.labelSelector, _ = labels.Parse(scope.LabelSelector)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// selects reports whether the scope selects resource, whose namespace, if
+// any, is namespaceObject. A Namespace is in its own namespace.
+func (scope *inputsScope) selects(resource workloadinterface.IMetadata, namespaceObject map[string]any) bool {
+	namespace := resource.GetNamespace()
+	if resource.GetKind() == "Namespace" {
+		namespace = resource.GetName()
+		namespaceObject = resource.GetObject()
+	}
+	if len(scope.Namespaces) > 0 && (namespace == "" || !slices.ContainsFunc(scope.Namespaces, func(pattern string) bool {
+		matched, _ := path.Match(pattern, namespace)
+		return matched
+	})) {
+		return false
+	}
+	if scope.namespaceSelector != nil {
+		if namespaceObject == nil || !scope.namespaceSelector.Matches(labels.Set(workloadinterface.NewWorkloadObj(namespaceObject).GetLabels())) {
+			return false
+		}
+	}
+	if scope.labelSelector != nil {
+		workload, ok := resource.(workloadinterface.IBasicWorkload)
+		if !ok || !scope.labelSelector.Matches(labels.Set(workload.GetLabels())) {
+			return false
+		}
+	}
+	return true
+}
+
+// inputsScopeOf returns the first control inputs scope selecting resource,
+// nil for none.
+func (opap *OPAProcessor) inputsScopeOf(resource workloadinterface.IMetadata) *inputsScope {
+	namespaceObject := opap.celNamespaceIndex[resource.GetNamespace()]
+	for i := range opap.inputsScopes {
+		if opap.inputsScopes[i].selects(resource, namespaceObject) {
+			return &opap.inputsScopes[i]
+		}
+	}
+	return nil
+}
+
+// evaluateRuleOnInputsScopes evaluates rule again with the inputs of every
+// control inputs scope selecting one of the resources to scan and overriding
+// one of the rule's inputs, and replaces the results of the resources the
+// scope selects with these. Each evaluation sees all the resources to scan,
+// so rules relating resources of several scopes keep seeing all of them.
+// Rules declaring no control inputs are not evaluated again.
+func (opap *OPAProcessor) evaluateRuleOnInputsScopes(ctx context.Context, rule *reporthandling.PolicyRule, fixedControlInputs map[string][]string, resourceToScan []workloadinterface.IMetadata, deps resources.RegoDependenciesData, scope evaluationScope, controlID string, out map[string]*resourcesresults.ResourceAssociatedRule) error {
+	if len(opap.inputsScopes) == 0 || len(rule.ControlConfigInputs) == 0 {
+		return nil
+	}
+
+	scopeOf := make(map[string]*inputsScope)
+	var selecting []*inputsScope
+	for _, resource := range resourceToScan {
+		inputs := opap.inputsScopeOf(resource)
+		if inputs == nil {
+			continue
+		}
+		scopeOf[resource.GetID()] = inputs
+		if !slices.Contains(selecting, inputs) {
+			selecting = append(selecting, inputs)
+		}
+	}
+
+	var evalErrs []error
+	for _, inputs := range selecting {
+		scopedDeps := opap.makeScopedRegoDeps(rule.ControlConfigInputs, inputs, fixedControlInputs)
+		if maps.EqualFunc(scopedDeps.PostureControlInputs, deps.PostureControlInputs, slices.Equal) {
+			continue // the scope overrides none of the rule's inputs
+		}
+		scoped := make(map[string]*resourcesresults.ResourceAssociatedRule)
+		if err := opap.evaluateRuleOnScope(ctx, rule, resourceToScan, scopedDeps, scope, controlID, scoped); err != nil {
+			evalErrs = append(evalErrs, err)
+		}
+		for resourceID, result := range scoped {
+			if scopeOf[resourceID] != inputs {
+				continue
+			}
+			out[resourceID] = result
+			opap.recordInputsScope(resourceID, inputs.Name)
+		}
+	}
+	return errors.Join(evalErrs...)
+}
+
+// makeScopedRegoDeps builds the rego dependencies data like makeRegoDeps, with
+// the inputs of scope replacing the global ones. The fixed control inputs of
+// the control still take precedence.
+func (opap *OPAProcessor) makeScopedRegoDeps(configInputs []reporthandling.ControlConfigInputs, scope *inputsScope, fixedControlInputs map[string][]string) resources.RegoDependenciesData {
+	deps := opap.makeRegoDeps(configInputs, nil)
+	scopeInputs := (&resources.RegoDependenciesData{PostureControlInputs: scope.Inputs}).GetFilteredPostureControlConfigInputs(configInputs)
+	for k, v := range scopeInputs {
+		deps.PostureControlInputs[k] = slices.Clone(v)
+	}
+	for k, v := range fixedControlInputs {
+		deps.PostureControlInputs[k] = slices.Clone(v)
+	}
+	return deps
+}
+
+// recordInputsScope records that resourceID was evaluated with the inputs of
+// the named scope.
+func (opap *OPAProcessor) recordInputsScope(resourceID, name string) {
+	opap.mu.Lock()
+	defer opap.mu.Unlock()
+	if opap.ResourceInputsScopes == nil {
+		opap.ResourceInputsScopes = make(map[string]string)
+	}
+	opap.ResourceInputsScopes[resourceID] = name
+}
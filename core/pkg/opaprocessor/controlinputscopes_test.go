@@ -0,0 +1,124 @@
+package opaprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scopedPod(name, namespace, registry string, labels map[string]any) workloadinterface.IMetadata {
+	return workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": name, "namespace": namespace, "labels": labels},
+		"spec":       map[string]any{"registry": registry},
+	})
+}
+
+func TestInputsScopeSelects(t *testing.T) {
+	sess := cautils.NewOPASessionObjMock()
+	sess.ControlInputsScopes = []getter.ControlInputsScope{
+		{Name: "payments", Namespaces: []string{"payments-*"}, Inputs: map[string][]string{"a": {}}},
+		{Name: "batch", NamespaceSelector: "tier=batch", LabelSelector: "app=legacy", Inputs: map[string][]string{"a": {}}},
+	}
+	batch := workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]any{"name": "jobs", "labels": map[string]any{"tier": "batch"}},
+	})
+	sess.AllResources[batch.GetID()] = batch
+	opap := NewOPAProcessor(sess, resources.NewRegoDependenciesDataMock(), "test", "", "", false, nil)
+
+	scopeName := func(resource workloadinterface.IMetadata) string {
+		if scope := opap.inputsScopeOf(resource); scope != nil {
+			return scope.Name
+		}
+		return ""
+	}
+	assert.Equal(t, "payments", scopeName(scopedPod("web", "payments-eu", "", nil)))
+	assert.Equal(t, "", scopeName(scopedPod("web", "payments", "", nil)))
+	assert.Equal(t, "batch", scopeName(scopedPod("cron", "jobs", "", map[string]any{"app": "legacy"})))
+	assert.Equal(t, "", scopeName(scopedPod("cron", "jobs", "", map[string]any{"app": "modern"})))
+	assert.Equal(t, "", scopeName(scopedPod("cron", "other", "", map[string]any{"app": "legacy"})))
+	assert.Equal(t, "", scopeName(workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   map[string]any{"name": "admin"},
+	})), "cluster-scoped resources are in no namespace")
+}
+
+func TestProcessRule_ScopedControlInputs(t *testing.T) {
+	web := scopedPod("web", "default", "quay.io/web", nil)
+	payments := scopedPod("api", "payments", "registry.payments.example.com/api", nil)
+	paymentsQuay := scopedPod("worker", "payments", "quay.io/worker", nil)
+
+	sess := cautils.NewOPASessionObjMock()
+	sess.K8SResources = cautils.K8SResources{"/v1/pods": {web.GetID(), payments.GetID(), paymentsQuay.GetID()}}
+	for _, pod := range []workloadinterface.IMetadata{web, payments, paymentsQuay} {
+		sess.AllResources[pod.GetID()] = pod
+	}
+	sess.ControlInputsScopes = []getter.ControlInputsScope{{
+		Name:       "payments",
+		Namespaces: []string{"payments"},
+		Inputs:     map[string][]string{"allowedRegistries": {"registry.payments.example.com/"}},
+	}}
+
+	deps := resources.NewRegoDependenciesDataMock()
+	deps.PostureControlInputs = map[string][]string{"allowedRegistries": {"quay.io/"}}
+	opap := NewOPAProcessor(sess, deps, "test", "", "", false, nil)
+
+	rule := &reporthandling.PolicyRule{
+		Rule: `package armo_builtins
+import rego.v1
+
+deny contains msga if {
+    pod := input[_]
+    pod.kind == "Pod"
+    not allowed(pod.spec.registry)
+    msga := {
+        "alertMessage": "registry not allowed",
+        "packagename":  "armo_builtins",
+        "alertScore":   5,
+        "fixPaths":     [],
+        "failedPaths":  ["spec.registry"],
+        "alertObject":  {"k8sApiObjects": [pod]},
+    }
+}
+
+allowed(registry) if {
+    some prefix in data.postureControlInputs.allowedRegistries
+    startswith(registry, prefix)
+}
+`,
+		RuleLanguage: reporthandling.RegoLanguage,
+		Match: []reporthandling.RuleMatchObjects{{
+			APIGroups:   []string{""},
+			APIVersions: []string{"v1"},
+			Resources:   []string{"Pod"},
+		}},
+		ControlConfigInputs: []reporthandling.ControlConfigInputs{{Path: "settings.postureControlInputs.allowedRegistries"}},
+	}
+	rule.Name = "scoped-registries"
+
+	got, err := opap.processRule(context.Background(), rule, nil, evaluationScope{}, &reporthandling.Control{})
+	require.NoError(t, err)
+
+	require.Contains(t, got, web.GetID())
+	require.Contains(t, got, payments.GetID())
+	require.Contains(t, got, paymentsQuay.GetID())
+	assert.True(t, got[web.GetID()].GetStatus(nil).IsPassed(), "global inputs apply outside the scope")
+	assert.True(t, got[payments.GetID()].GetStatus(nil).IsPassed(), "scoped inputs apply in the scope")
+	assert.True(t, got[paymentsQuay.GetID()].GetStatus(nil).IsFailed(), "scoped inputs replace the global ones")
+
+	assert.Equal(t, map[string]string{
+		payments.GetID():     "payments",
+		paymentsQuay.GetID(): "payments",
+	}, sess.ResourceInputsScopes)
+}
@@ -0,0 +1,397 @@
+package opaprocessor
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/armosec/armoapi-go/identifiers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor/cel"
+	"github.com/kubescape/opa-utils/exceptions"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/kubescape/opa-utils/resources"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/topdown"
+	"github.com/open-policy-agent/opa/v1/topdown/lineage"
+)
+
+// explain explains how the rules of every control reached their verdicts on
+// the single resource of the scan. It runs once the controls are processed
+// and before updateResults strips the resources' data, so the rules are
+// evaluated again on the objects they saw. explainExceptions completes the
+// explanations once the exceptions are applied.
+func (opap *OPAProcessor) explain(ctx context.Context) {
+	if opap.SingleResourceScan == nil {
+		return
+	}
+	target, ok := opap.AllResources[opap.SingleResourceScan.GetID()]
+	if !ok {
+		target = opap.SingleResourceScan
+	}
+
+	candidates := opap.Exceptions
+	if opap.HonorInlineExceptions {
+		candidates = append(slices.Clone(candidates), opap.gatherInlineExceptions()...)
+	}
+	processor := exceptions.NewProcessor()
+
+	for _, key := range sortedControlIDs(opap.AllPolicies) {
+		control := opap.AllPolicies.Controls[key]
+		explanation := cautils.ControlExplanation{
+			ControlID:  control.ControlID,
+			Name:       control.Name,
+			ResourceID: target.GetID(),
+			Status:     apis.StatusSkipped,
+			Exceptions: nearlyMatchingExceptions(candidates, &control, target, opap.clusterName, processor),
+		}
+		for i := range control.Rules {
+			explanation.Rules = append(explanation.Rules, opap.explainRule(ctx, &control, &control.Rules[i], target))
+		}
+		opap.Explanations = append(opap.Explanations, explanation)
+	}
+}
+
+// explainRule evaluates one rule of control again on the objects it matches,
+// target among them, and traces the evaluation.
+func (opap *OPAProcessor) explainRule(ctx context.Context, control *reporthandling.Control, rule *reporthandling.PolicyRule, target workloadinterface.IMetadata) cautils.RuleExplanation {
+	explanation := cautils.RuleExplanation{
+		Name:     rule.Name,
+		Language: string(rule.RuleLanguage),
+		Status:   apis.StatusSkipped,
+	}
+
+	scope := opap.wholeClusterScope()
+	matched := scope.matchedObjects(rule)
+	if !slices.ContainsFunc(matched, func(resource workloadinterface.IMetadata) bool { return resource.GetID() == target.GetID() }) {
+		explanation.Reason = fmt.Sprintf("the rule does not match %s objects", target.GetKind())
+		return explanation
+	}
+
+	deps := opap.makeRegoDeps(rule.ControlConfigInputs, control.FixedInput)
+	if inputs := opap.inputsScopeOf(target); inputs != nil && len(rule.ControlConfigInputs) > 0 {
+		scopedDeps := opap.makeScopedRegoDeps(rule.ControlConfigInputs, inputs, control.FixedInput)
+		if !maps.EqualFunc(scopedDeps.PostureControlInputs, deps.PostureControlInputs, slices.Equal) {
+			deps = scopedDeps
+			explanation.Scope = inputs.Name
+		}
+	}
+	if len(deps.PostureControlInputs) > 0 {
+		explanation.Inputs = deps.PostureControlInputs
+	}
+
+	results := make(map[string]*resourcesresults.ResourceAssociatedRule)
+	evalErr := opap.evaluateRuleOnScope(ctx, rule, matched, deps, scope, control.ControlID, results)
+	if result, ok := results[target.GetID()]; ok {
+		explanation.Status = result.GetStatus(nil).Status()
+		explanation.Paths = result.Paths
+	}
+
+	var traceErr error
+	switch rule.RuleLanguage {
+	case reporthandling.CELLanguage:
+		explanation.CEL, traceErr = opap.explainCEL(ctx, rule, matched, deps, control.ControlID, target)
+	default:
+		explanation.Input, explanation.Trace, traceErr = opap.explainRego(ctx, rule, matched, deps, control.ControlID)
+	}
+
+	switch {
+	case evalErr != nil:
+		explanation.Reason = evalErr.Error()
+	case traceErr != nil:
+		explanation.Reason = fmt.Sprintf("failed to trace the rule: %v", traceErr)
+	case results[target.GetID()] == nil:
+		explanation.Reason = "the rule does not apply to the resource"
+	}
+	return explanation
+}
+
+// explainRego evaluates a Rego rule on the input document built from
+// resources, as evaluateRuleOnScope does, recording the evaluation trace
+// filtered by the explain trace mode. The input document is returned redacted
+// like the reports' resources.
+func (opap *OPAProcessor) explainRego(ctx context.Context, rule *reporthandling.PolicyRule, resources []workloadinterface.IMetadata, deps resources.RegoDependenciesData, controlID string) ([]map[string]any, []string, error) {
+	inputResources, err := reporthandling.RegoResourcesAggregator(rule, resources)
+	if err != nil {
+		return nil, nil, fmt.Errorf("aggregator failed: %w", err)
+	}
+	input := make([]map[string]any, 0, len(inputResources))
+	for _, resource := range inputResources {
+		input = append(input, resource.GetObject())
+	}
+	if input, err = opap.enumerateData(ctx, rule, input, controlID); err != nil {
+		return nil, nil, fmt.Errorf("enumerator failed: %w", err)
+	}
+
+	compiled, regoVersion, err := opap.getCompiledRule(ctx, rule.Name, ruleData(rule), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := deps.TOStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+	pq, err := rego.New(
+		rego.SetRegoVersion(regoVersion),
+		rego.Query("data.armo_builtins"),
+		rego.Compiler(compiled),
+		rego.Store(store),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to prepare query: %w", err)
+	}
+	tracer := topdown.NewBufferTracer()
+	if _, err := pq.Eval(ctx, rego.EvalInput(input), rego.EvalQueryTracer(tracer)); err != nil {
+		return nil, nil, fmt.Errorf("rego eval failed: %w", err)
+	}
+
+	var events []*topdown.Event
+	switch opap.ExplainTrace {
+	case cautils.ExplainTraceNotes:
+		events = lineage.Notes(*tracer)
+	case cautils.ExplainTraceFails:
+		events = lineage.Fails(*tracer)
+	default:
+		events = lineage.Full(*tracer)
+	}
+	var trace bytes.Buffer
+	topdown.PrettyTraceWithLocation(&trace, events)
+
+	redacted, err := redactInput(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return redacted, strings.FieldsFunc(trace.String(), func(r rune) bool { return r == '\n' }), nil
+}
+
+// redactInput copies an input document and removes the data reports do not
+// show from the copies: secret values, environment variables and statuses.
+func redactInput(input []map[string]any) ([]map[string]any, error) {
+	redacted := make([]map[string]any, 0, len(input))
+	for _, obj := range input {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy the input document: %w", err)
+		}
+		var clone map[string]any
+		if err := json.Unmarshal(data, &clone); err != nil {
+			return nil, fmt.Errorf("failed to copy the input document: %w", err)
+		}
+		removeData(workloadinterface.NewWorkloadObj(clone))
+		redacted = append(redacted, clone)
+	}
+	return redacted, nil
+}
+
+// explainCEL evaluates a CEL rule on target, tracing the values of its
+// variables and validations. A library CEL rule reads the other objects of
+// resources as relatedObjects and deps as params, as in runCELOnK8s.
+func (opap *OPAProcessor) explainCEL(ctx context.Context, rule *reporthandling.PolicyRule, resources []workloadinterface.IMetadata, deps resources.RegoDependenciesData, controlID string, target workloadinterface.IMetadata) ([]cautils.ExpressionTrace, error) {
+	obj := target.GetObject()
+	namespaceObject := opap.celNamespaceObjectFor(obj)
+
+	var explanation cel.PolicyExplanation
+	if isCELLibraryRule(rule) {
+		evaluator, err := opap.getCELRuleEvaluator()
+		if err != nil {
+			return nil, err
+		}
+		celRule, err := cel.ParseRule(rule.Name, []byte(rule.Rule))
+		if err != nil {
+			return nil, err
+		}
+		input := make([]map[string]any, 0, len(resources))
+		for _, resource := range resources {
+			input = append(input, resource.GetObject())
+		}
+		if explanation, err = evaluator.ExplainRule(ctx, celRule, obj, namespaceObject, celRelatedObjects(input)(obj), celRuleParams(deps)); err != nil {
+			return nil, err
+		}
+		return celExpressionTraces(explanation), nil
+	}
+
+	evaluator, err := opap.getCELEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	if inline, _ := rule.Attributes[getter.RuleAttributeInlinePolicy].(bool); inline {
+		vap, err := cel.ParsePolicy(controlID, []byte(rule.Rule))
+		if err != nil {
+			return nil, err
+		}
+		explanation, err = evaluator.ExplainPolicy(ctx, vap, obj, namespaceObject)
+		if err != nil {
+			return nil, err
+		}
+	} else if explanation, err = evaluator.ExplainControl(ctx, controlID, obj, namespaceObject); err != nil {
+		return nil, err
+	}
+	return celExpressionTraces(explanation), nil
+}
+
+// celExpressionTraces lists the traces of a policy's variables, then of its
+// validations with their verdicts.
+func celExpressionTraces(explanation cel.PolicyExplanation) []cautils.ExpressionTrace {
+	traces := make([]cautils.ExpressionTrace, 0, len(explanation.Variables)+len(explanation.Results))
+	for _, variable := range explanation.Variables {
+		trace := expressionTrace(variable)
+		trace.Variable = variable.Name
+		traces = append(traces, trace)
+	}
+	for i, result := range explanation.Results {
+		trace := cautils.ExpressionTrace{Expression: result.Expression}
+		if i < len(explanation.Validations) {
+			trace = expressionTrace(explanation.Validations[i])
+		}
+		trace.Error = ""
+		if result.Err != nil {
+			trace.Error = result.Err.Error()
+		} else {
+			trace.Passed = &result.Passed
+			trace.Message = result.Message
+		}
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+func expressionTrace(trace cel.ExpressionTrace) cautils.ExpressionTrace {
+	explained := cautils.ExpressionTrace{Expression: trace.Expression}
+	if trace.Err != nil {
+		explained.Error = trace.Err.Error()
+	}
+	for _, value := range trace.Values {
+		explained.Values = append(explained.Values, cautils.SubExpressionValue{
+			Expression: value.Expression,
+			Value:      value.Value,
+			Error:      value.Err,
+		})
+	}
+	return explained
+}
+
+// nearlyMatchingExceptions lists the exceptions targeting control or
+// selecting resource, with what keeps each from matching both. Whether they
+// matched is only known once they are applied (see explainExceptions): the
+// reasons approximate the exceptions processor's matching, to point at the
+// attribute to fix.
+func nearlyMatchingExceptions(candidates []armotypes.PostureExceptionPolicy, control *reporthandling.Control, resource workloadinterface.IMetadata, clusterName string, processor *exceptions.Processor) []cautils.ExceptionExplanation {
+	var nearly []cautils.ExceptionExplanation
+	for _, exception := range candidates {
+		var reasons []string
+		targetsControl := slices.ContainsFunc(exception.PosturePolicies, func(policy armotypes.PosturePolicy) bool {
+			if policy.ControlID != "" {
+				return processor.RegexCompareControlID(policy.ControlID, control.ControlID)
+			}
+			return policy.ControlName == "" || strings.EqualFold(policy.ControlName, control.Name)
+		})
+		if !targetsControl {
+			reasons = append(reasons, fmt.Sprintf("it targets %s, not %s", cmp.Or(strings.Join(exceptionControlIDs(exception), ", "), "other controls"), control.ControlID))
+		}
+		designatorReasons := designatorsMismatches(exception.Resources, resource, clusterName)
+		if !targetsControl && len(designatorReasons) > 0 {
+			continue // neither the control nor the resource
+		}
+		reasons = append(reasons, designatorReasons...)
+		if exceptionIsExpired(exception) {
+			reasons = append(reasons, fmt.Sprintf("it expired on %s", exception.ExpirationDate.Format("2006-01-02")))
+		}
+		nearly = append(nearly, cautils.ExceptionExplanation{Name: exceptionAuditName(exception), Reasons: reasons})
+	}
+	return nearly
+}
+
+// designatorsMismatches returns the mismatching attributes of the designator
+// closest to selecting resource, none when one selects it.
+func designatorsMismatches(designators []identifiers.PortalDesignator, resource workloadinterface.IMetadata, clusterName string) []string {
+	var closest []string
+	for i := range designators {
+		mismatches := designatorMismatches(&designators[i], resource, clusterName)
+		if len(mismatches) == 0 {
+			return nil
+		}
+		if closest == nil || len(mismatches) < len(closest) {
+			closest = mismatches
+		}
+	}
+	return closest
+}
+
+func designatorMismatches(designator *identifiers.PortalDesignator, resource workloadinterface.IMetadata, clusterName string) []string {
+	var resourceLabels map[string]string
+	if workload, ok := resource.(workloadinterface.IBasicWorkload); ok {
+		resourceLabels = workload.GetLabels()
+	}
+	attributes := map[string][2]string{
+		identifiers.AttributeCluster:   {designator.GetCluster(), clusterName},
+		identifiers.AttributeNamespace: {designator.GetNamespace(), resource.GetNamespace()},
+		identifiers.AttributeKind:      {designator.GetKind(), resource.GetKind()},
+		identifiers.AttributeName:      {designator.GetName(), resource.GetName()},
+	}
+	for key, pattern := range designator.GetLabels() {
+		attributes["label "+key] = [2]string{pattern, resourceLabels[key]}
+	}
+
+	var mismatches []string
+	for _, key := range slices.Sorted(maps.Keys(attributes)) {
+		pattern, actual := attributes[key][0], attributes[key][1]
+		if pattern == "" || attributeMatches(pattern, actual) {
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("its %s is %q, the resource's is %q", key, pattern, actual))
+	}
+	return mismatches
+}
+
+// attributeMatches compares a designator attribute, a regular expression, to
+// the resource's value, case-insensitively.
+func attributeMatches(pattern, value string) bool {
+	re, err := regexp.Compile("(?i)^(?:" + pattern + ")$")
+	if err != nil {
+		return strings.EqualFold(pattern, value)
+	}
+	return re.MatchString(value)
+}
+
+// explainExceptions completes the explanations once the exceptions are
+// applied: the controls' final statuses, and the exceptions that matched.
+func (opap *OPAProcessor) explainExceptions() {
+	for i := range opap.Explanations {
+		explanation := &opap.Explanations[i]
+		result, ok := opap.ResourcesResult[explanation.ResourceID]
+		if !ok {
+			continue
+		}
+		for _, control := range result.AssociatedControls {
+			if control.GetID() != explanation.ControlID {
+				continue
+			}
+			explanation.Status = control.GetStatus(nil).Status()
+			for _, rule := range control.ResourceAssociatedRules {
+				for _, exception := range rule.Exception {
+					explanation.Exceptions = markExceptionMatched(explanation.Exceptions, exceptionAuditName(exception))
+				}
+			}
+		}
+	}
+}
+
+func markExceptionMatched(explanations []cautils.ExceptionExplanation, name string) []cautils.ExceptionExplanation {
+	matched := cautils.ExceptionExplanation{Name: name, Matched: true}
+	if i := slices.IndexFunc(explanations, func(e cautils.ExceptionExplanation) bool { return e.Name == name }); i >= 0 {
+		explanations[i] = matched
+		return explanations
+	}
+	return append(explanations, matched)
+}
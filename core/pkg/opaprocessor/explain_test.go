@@ -0,0 +1,170 @@
+package opaprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/armosec/armoapi-go/identifiers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/exceptions"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	pod := workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "web", "namespace": "default"},
+		"spec": map[string]any{
+			"registry":   "docker.io/web",
+			"containers": []any{map[string]any{"name": "web", "env": []any{map[string]any{"name": "TOKEN", "value": "secret"}}}},
+		},
+	})
+	sess := cautils.NewOPASessionObjMock()
+	sess.K8SResources = cautils.K8SResources{"/v1/pods": {pod.GetID()}}
+	sess.AllResources[pod.GetID()] = pod
+	sess.SingleResourceScan = pod
+	sess.ExplainTrace = cautils.ExplainTraceNotes
+
+	deps := resources.NewRegoDependenciesDataMock()
+	deps.PostureControlInputs = map[string][]string{"allowedRegistries": {"quay.io/"}}
+	opap := NewOPAProcessor(sess, deps, "test", "", "", false, nil)
+
+	registryRule := reporthandling.PolicyRule{
+		Rule: `package armo_builtins
+import rego.v1
+
+deny contains msga if {
+    pod := input[_]
+    pod.kind == "Pod"
+    trace(sprintf("registry %s", [pod.spec.registry]))
+    not allowed(pod.spec.registry)
+    msga := {
+        "alertMessage": "registry not allowed",
+        "packagename":  "armo_builtins",
+        "alertScore":   5,
+        "fixPaths":     [],
+        "failedPaths":  ["spec.registry"],
+        "alertObject":  {"k8sApiObjects": [pod]},
+    }
+}
+
+allowed(registry) if {
+    some prefix in data.postureControlInputs.allowedRegistries
+    startswith(registry, prefix)
+}
+`,
+		RuleLanguage: reporthandling.RegoLanguage,
+		Match: []reporthandling.RuleMatchObjects{{
+			APIGroups:   []string{""},
+			APIVersions: []string{"v1"},
+			Resources:   []string{"Pod"},
+		}},
+		ControlConfigInputs: []reporthandling.ControlConfigInputs{{Path: "settings.postureControlInputs.allowedRegistries"}},
+	}
+	registryRule.Name = "allowed-registries"
+	serviceRule := reporthandling.PolicyRule{
+		Rule:         registryRule.Rule,
+		RuleLanguage: reporthandling.RegoLanguage,
+		Match: []reporthandling.RuleMatchObjects{{
+			APIGroups:   []string{""},
+			APIVersions: []string{"v1"},
+			Resources:   []string{"Service"},
+		}},
+	}
+	serviceRule.Name = "services-only"
+
+	opap.AllPolicies = &cautils.Policies{Controls: map[string]reporthandling.Control{
+		"C-TEST": {ControlID: "C-TEST", Rules: []reporthandling.PolicyRule{registryRule, serviceRule}},
+	}}
+	opap.explain(context.Background())
+
+	require.Len(t, sess.Explanations, 1)
+	explanation := sess.Explanations[0]
+	assert.Equal(t, "C-TEST", explanation.ControlID)
+	assert.Equal(t, pod.GetID(), explanation.ResourceID)
+	require.Len(t, explanation.Rules, 2)
+
+	t.Run("explains a rule's verdict", func(t *testing.T) {
+		rule := explanation.Rules[0]
+		assert.Equal(t, apis.StatusFailed, rule.Status)
+		assert.Empty(t, rule.Reason)
+		assert.Equal(t, map[string][]string{"allowedRegistries": {"quay.io/"}}, rule.Inputs)
+		require.NotEmpty(t, rule.Paths)
+		assert.True(t, slices.ContainsFunc(rule.Trace, func(line string) bool {
+			return strings.Contains(line, "registry docker.io/web")
+		}), "the trace holds the rule's notes: %v", rule.Trace)
+	})
+
+	t.Run("redacts the input document", func(t *testing.T) {
+		rule := explanation.Rules[0]
+		require.Len(t, rule.Input, 1)
+		input, err := json.Marshal(rule.Input)
+		require.NoError(t, err)
+		assert.NotContains(t, string(input), `"secret"`)
+		resource, err := json.Marshal(pod.GetObject())
+		require.NoError(t, err)
+		assert.Contains(t, string(resource), `"secret"`, "the resource keeps its data")
+	})
+
+	t.Run("tells why a rule did not evaluate the resource", func(t *testing.T) {
+		rule := explanation.Rules[1]
+		assert.Equal(t, apis.StatusSkipped, rule.Status)
+		assert.Equal(t, "the rule does not match Pod objects", rule.Reason)
+		assert.Empty(t, rule.Trace)
+	})
+}
+
+func TestNearlyMatchingExceptions(t *testing.T) {
+	pod := workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "web", "namespace": "default", "labels": map[string]any{"app": "web"}},
+	})
+	control := &reporthandling.Control{ControlID: "C-0017", Name: "Immutable container filesystem"}
+	designator := func(attributes map[string]string) []identifiers.PortalDesignator {
+		return []identifiers.PortalDesignator{{DesignatorType: identifiers.DesignatorAttributes, Attributes: attributes}}
+	}
+	exception := func(name, controlID string, attributes map[string]string) armotypes.PostureExceptionPolicy {
+		e := armotypes.PostureExceptionPolicy{
+			PosturePolicies: []armotypes.PosturePolicy{{ControlID: controlID}},
+			Resources:       designator(attributes),
+		}
+		e.Name = name
+		return e
+	}
+	expired := exception("expired", "C-0017", map[string]string{"kind": "Pod", "name": "web"})
+	yesterday := time.Now().Add(-24 * time.Hour)
+	expired.ExpirationDate = &yesterday
+
+	got := nearlyMatchingExceptions([]armotypes.PostureExceptionPolicy{
+		exception("matching", "C-0017", map[string]string{"kind": "Pod", "name": "web.*"}),
+		exception("other-namespace", "C-0017", map[string]string{"namespace": "kube-system", "name": "web"}),
+		exception("other-control", "C-0016", map[string]string{"kind": "pod", "name": "web"}),
+		exception("unrelated", "C-0016", map[string]string{"name": "db"}),
+		expired,
+	}, control, pod, "test", exceptions.NewProcessor())
+
+	byName := make(map[string][]string)
+	for _, e := range got {
+		assert.False(t, e.Matched, "matching is known once exceptions are applied")
+		byName[e.Name] = e.Reasons
+	}
+	assert.NotContains(t, byName, "unrelated")
+	assert.Contains(t, byName, "matching")
+	assert.Empty(t, byName["matching"])
+	assert.Equal(t, []string{`its namespace is "kube-system", the resource's is "default"`}, byName["other-namespace"])
+	assert.Equal(t, []string{"it targets C-0016, not C-0017"}, byName["other-control"])
+	require.Len(t, byName["expired"], 1)
+	assert.Contains(t, byName["expired"][0], "it expired on")
+}
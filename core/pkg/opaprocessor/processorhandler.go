@@ -1,8 +1,7 @@
-// Package opaprocessor evaluates Open Policy Agent (OPA) Rego rules and
-// dispatches CEL rules against scanned Kubernetes resources. CEL evaluation is
-// not yet implemented. It is the engine that consumes the rules defined in the
-// kubescape/regolibrary repository and produces Kubescape's misconfiguration
-// results.
+// Package opaprocessor evaluates Open Policy Agent (OPA) Rego rules and CEL
+// rules against scanned Kubernetes resources. It is the engine that consumes
+// the rules defined in the kubescape/regolibrary repository and produces
+// Kubescape's misconfiguration results.
 package opaprocessor
 
 import (
@@ -22,7 +21,10 @@ import (
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/pkg/hostsensorutils"
 	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor/cel"
+	"github.com/kubescape/kubescape/v4/core/pkg/rulecache"
 	"github.com/kubescape/kubescape/v4/core/pkg/scancache"
 	"github.com/kubescape/kubescape/v4/core/pkg/score"
 	"github.com/kubescape/opa-utils/objectsenvelopes"
@@ -36,6 +38,7 @@ import (
 	"github.com/open-policy-agent/opa/v1/rego"
 	opaprint "github.com/open-policy-agent/opa/v1/topdown/print"
 	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/singleflight"
 	"k8s.io/client-go/tools/record"
 )
 
@@ -93,6 +96,9 @@ type OPAProcessor struct {
 	compiledModules        map[string]compiledRule
 	compiledMu             sync.RWMutex
 	mu                     sync.Mutex
+	// compileGroup compiles each rule once when several controls evaluated at
+	// the same time need it, without holding compiledMu while compiling.
+	compileGroup singleflight.Group
 	// ControlTimeout, when non-zero, bounds the evaluation time of a single
 	// control. If exceeded, the control is recorded as not evaluated instead
 	// of stalling or aborting the whole scan.
@@ -107,6 +113,11 @@ type OPAProcessor struct {
 	celEvaluator     *cel.Evaluator
 	celEvaluatorOnce sync.Once
 	celEvaluatorErr  error
+	// celRuleEvaluator is the same for the CEL rules of the policy library,
+	// whose env also declares relatedObjects (see cel.NewRuleEvaluator).
+	celRuleEvaluator     *cel.Evaluator
+	celRuleEvaluatorOnce sync.Once
+	celRuleEvaluatorErr  error
 	// celNamespaceIndex maps namespace name -> the scan's Namespace object, so
 	// CEL evaluation can bind namespaceObject the way the apiserver does. In the
 	// non-streaming path it is built at construction (see indexNamespaces), where
@@ -116,6 +127,9 @@ type OPAProcessor struct {
 	// no such snapshot to take, so it extends the index batch by batch via
 	// indexNamespacesFrom, always before the batch is evaluated.
 	celNamespaceIndex map[string]map[string]any
+	// inputsScopes are the session's control inputs scopes, with their
+	// selectors parsed once for the scan (see evaluateRuleOnInputsScopes).
+	inputsScopes []inputsScope
 	// initialResourceCount is the size of AllResources snapshotted once at
 	// construction, so the large-cluster namespace-bucketing decision (see
 	// getNamespaceName) is made once per scan instead of drifting mid-scan as
@@ -129,6 +143,28 @@ type OPAProcessor struct {
 	// incrementalCache holds cached per-resource-per-control verdicts when
 	// --incremental is enabled. nil when the flag is off.
 	incrementalCache *scancache.Store
+	// ruleCache keeps compiled rules across runs (see rulecache.go). nil
+	// when the cache could not be opened. ruleCacheErr is the first failure
+	// to write to it, reported once at Warning by ruleCacheWarnOnce.
+	ruleCache         *rulecache.Store
+	ruleCacheWarnOnce sync.Once
+	ruleCacheErr      error
+	// shardEvaluator, when set, evaluates the shards of Process instead of
+	// this process, shardParallelism of them at a time (see shard.go).
+	// shardLocalFallback evaluates locally the shards no worker could.
+	shardEvaluator     ShardEvaluator
+	shardParallelism   int
+	shardLocalFallback bool
+	// evalConcurrency bounds how many controls of a scope are evaluated at
+	// once, one per CPU when zero (see evalWorkers).
+	evalConcurrency int
+	// streaming is set while ProcessWithStreaming runs, so that evalWorkers
+	// also bounds the objects evaluated at once.
+	streaming bool
+	// telemetry, when set, adds a runtime-adjusted score computed from its
+	// events to the report (see SetTelemetry).
+	telemetry     <-chan hostsensorutils.SyscallEvent
+	stopTelemetry func()
 }
 
 // NewOPAProcessor snapshots len(sessionObj.AllResources) at construction for
@@ -164,6 +200,7 @@ func NewOPAProcessor(sessionObj *cautils.OPASessionObj, regoDependenciesData *re
 		TimedOutControls:          make(map[string]string),
 		initialResourceCount:      initialResourceCount,
 		celNamespaceIndex:         indexNamespaces(sessionObj),
+		inputsScopes:              newInputsScopes(sessionObj),
 		largeClusterSizeThreshold: largeClusterSizeThreshold,
 	}
 }
@@ -224,6 +261,12 @@ func (opap *OPAProcessor) SetInitialResourceCount(count int) {
 	opap.initialResourceCount = count
 }
 
+// SetEvalConcurrency sets how many controls of a scope are evaluated at once.
+// Zero or less evaluates one control per CPU. Results do not depend on it.
+func (opap *OPAProcessor) SetEvalConcurrency(concurrency int) {
+	opap.evalConcurrency = concurrency
+}
+
 // SetIncrementalCache enables incremental-scan caching for this processor.
 // Rules and controls that could be affected by a resource other than the one
 // being verdicted (see ruleCacheEligible/controlCacheEligible) are never
@@ -232,6 +275,21 @@ func (opap *OPAProcessor) SetIncrementalCache(cache *scancache.Store) {
 	opap.incrementalCache = cache
 }
 
+// SetRuleCache makes the processor load the rules compiled on earlier runs
+// from cache, and store the rules it compiles there.
+func (opap *OPAProcessor) SetRuleCache(cache *rulecache.Store) {
+	opap.ruleCache = cache
+}
+
+// SetTelemetry scores the report with the runtime telemetry of events too
+// (see score.ScoreWrapper.CalculateWithTelemetry). Once the controls are
+// evaluated, stop ends the stream, and the events sent until events is closed
+// are scored. stop may be nil when events closes by itself.
+func (opap *OPAProcessor) SetTelemetry(events <-chan hostsensorutils.SyscallEvent, stop func()) {
+	opap.telemetry = events
+	opap.stopTelemetry = stop
+}
+
 func (opap *OPAProcessor) ProcessRulesListener(ctx context.Context, progressListener IJobProgressNotificationClient) error {
 	scanningScope := cautils.GetScanningScope(opap.Metadata.ContextMetadata)
 
@@ -256,22 +314,46 @@ func (opap *OPAProcessor) ProcessRulesListener(ctx context.Context, progressList
 	opap.ScanCoverage = cautils.BuildScanCoverage(opap.InfoMap, opap.ResourceToControlsMap, opap.TimedOutControls, opap.PartialGVRFailures, opap.PolicyDegradations)
 	opap.ScanCoverage.ComputeCoverageScore(len(opap.Report.SummaryDetails.Controls))
 
+	// explain the verdicts while the resources still hold their data
+	if opap.ExplainTrace != "" {
+		opap.explain(ctx)
+	}
+
 	// edit results
 	opap.updateResults(ctx)
+	if opap.ExplainTrace != "" {
+		opap.explainExceptions()
+	}
 
 	opap.markNotEvaluatedControlsSkipped()
 	opap.ScanCoverage.VacuousFrameworks = cautils.DetectVacuousFrameworks(opap.Report.SummaryDetails.Frameworks)
 
-	scorewrapper := score.NewScoreWrapper(opap.OPASessionObj)
-	if err := scorewrapper.Calculate(score.EPostureReportV2); err != nil {
-		logger.L().Ctx(ctx).Warning("failed to calculate score", helpers.Error(err))
-	}
+	opap.calculateScore(ctx)
 
 	opap.reweightComplianceScores()
 
 	return processErr
 }
 
+// calculateScore scores the report, with the runtime-adjusted score when
+// telemetry is set.
+func (opap *OPAProcessor) calculateScore(ctx context.Context) {
+	scorewrapper := score.NewScoreWrapper(opap.OPASessionObj)
+	scorewrapper.SetTimedOutControls(opap.TimedOutControls)
+	var err error
+	if opap.telemetry != nil {
+		if opap.stopTelemetry != nil {
+			opap.stopTelemetry()
+		}
+		err = scorewrapper.CalculateWithTelemetry(ctx, score.EPostureReportV2, opap.telemetry)
+	} else {
+		err = scorewrapper.Calculate(score.EPostureReportV2)
+	}
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("failed to calculate score", helpers.Error(err))
+	}
+}
+
 // ProcessWithStreaming processes OPA policies using streaming resource batches.
 // It processes batches incrementally, keeping the resident (cluster-scoped)
 // batch in memory throughout.
@@ -293,6 +375,8 @@ func (opap *OPAProcessor) ProcessWithStreaming(ctx context.Context, batchChan <-
 	defer span.End()
 	opap.loggerStartScanning()
 	defer opap.loggerDoneScanning()
+	opap.streaming = true
+	defer func() { opap.streaming = false }()
 
 	opap.AllPolicies = convertFrameworksToPolicies(opap.Policies, opap.ExcludedRules, cautils.GetScanningScope(opap.Metadata.ContextMetadata))
 	ConvertFrameworksToSummaryDetails(&opap.Report.SummaryDetails, opap.Policies, opap.AllPolicies)
@@ -362,6 +446,7 @@ haveResident:
 	for k, v := range residentBatch.AllResources {
 		opap.AllResources[k] = v
 	}
+	maps.Copy(opap.ResourceSource, residentBatch.ResourceSource)
 
 	// Index any Namespace objects the resident batch carries before evaluating,
 	// so CEL's namespaceObject binding is populated for this scope's objects.
@@ -408,6 +493,7 @@ haveResident:
 			for gvr, ids := range batch.K8SResources {
 				opap.K8SResources[gvr] = append(opap.K8SResources[gvr], ids...)
 			}
+			maps.Copy(opap.ResourceSource, batch.ResourceSource)
 
 		case err, ok := <-errChan:
 			if !ok {
@@ -452,10 +538,7 @@ done:
 	opap.markNotEvaluatedControlsSkipped()
 	opap.ScanCoverage.VacuousFrameworks = cautils.DetectVacuousFrameworks(opap.Report.SummaryDetails.Frameworks)
 
-	scorewrapper := score.NewScoreWrapper(opap.OPASessionObj)
-	if err := scorewrapper.Calculate(score.EPostureReportV2); err != nil {
-		logger.L().Ctx(ctx).Warning("failed to calculate score", helpers.Error(err))
-	}
+	opap.calculateScore(ctx)
 
 	opap.reweightComplianceScores()
 
@@ -466,27 +549,33 @@ done:
 // Each scope gets the full ControlTimeout budget — the timeout is not shared
 // across scopes, so a control that completes in earlier scopes keeps its
 // accumulated results even if a later scope exceeds the budget.
+//
+// Up to evalWorkers controls are evaluated at once. They finish in any order,
+// but their results are merged, and their errors joined, in the order of
+// controlIDs, so the results do not depend on the concurrency: a resource's
+// AssociatedControls come out as a sequential evaluation lays them out. A
+// control that finishes early waits in pending only until the controls before
+// it are merged.
 func (opap *OPAProcessor) processScope(ctx context.Context, policies *cautils.Policies, controlIDs []string, scope evaluationScope, progressListener IJobProgressNotificationClient) error {
-	var processErrs []error
-	var processErrsMu sync.Mutex
+	processErrs := make([]error, len(controlIDs))
 
-	numWorkers := runtime.GOMAXPROCS(0)
-	controlChan := make(chan string, len(controlIDs))
-	for _, id := range controlIDs {
-		controlChan <- id
+	numWorkers := opap.evalWorkers(scope, len(controlIDs))
+	controlChan := make(chan int, len(controlIDs))
+	for i := range controlIDs {
+		controlChan <- i
 	}
 	close(controlChan)
 
+	outcomes := make(chan controlOutcome, numWorkers)
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for controlID := range controlChan {
+			for index := range controlChan {
+				controlID := controlIDs[index]
 				if err := ctx.Err(); err != nil {
-					processErrsMu.Lock()
-					processErrs = append(processErrs, err)
-					processErrsMu.Unlock()
+					processErrs[index] = err
 					return // exit worker early on context cancellation
 				}
 
@@ -500,6 +589,7 @@ func (opap *OPAProcessor) processScope(ctx context.Context, policies *cautils.Po
 				_, timedOut := opap.TimedOutControls[controlID]
 				opap.mu.Unlock()
 				if timedOut {
+					outcomes <- controlOutcome{index: index}
 					continue
 				}
 
@@ -524,32 +614,82 @@ func (opap *OPAProcessor) processScope(ctx context.Context, policies *cautils.Po
 				}
 
 				if err != nil {
-					processErrsMu.Lock()
-					processErrs = append(processErrs, fmt.Errorf("control %q: %w", control.ControlID, err))
-					processErrsMu.Unlock()
-				}
-
-				// Update resources with latest results
-				if len(resourcesAssociatedControl) > 0 {
-					opap.mu.Lock()
-					for resourceID, controlResult := range resourcesAssociatedControl {
-						t, ok := opap.ResourcesResult[resourceID]
-						if !ok {
-							t = resourcesresults.Result{ResourceID: resourceID}
-						}
-						t.AssociatedControls = mergeAssociatedControls(t.AssociatedControls, controlResult, opap.AllPolicies)
-						opap.ResourcesResult[resourceID] = t
-					}
-					opap.mu.Unlock()
+					processErrs[index] = fmt.Errorf("control %q: %w", control.ControlID, err)
 				}
+				outcomes <- controlOutcome{index: index, results: resourcesAssociatedControl}
 			}
 		}()
 	}
-	wg.Wait()
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	pending := make(map[int]map[string]resourcesresults.ResourceAssociatedControl)
+	next := 0
+	for outcome := range outcomes {
+		pending[outcome.index] = outcome.results
+		for results, ok := pending[next]; ok; results, ok = pending[next] {
+			delete(pending, next)
+			opap.mergeControlResults(results)
+			next++
+		}
+	}
+	// A cancelled scan leaves gaps in the order; the controls that did finish
+	// still keep their results.
+	for _, index := range slices.Sorted(maps.Keys(pending)) {
+		opap.mergeControlResults(pending[index])
+	}
 
 	return errors.Join(processErrs...)
 }
 
+// controlOutcome is the result of evaluating controlIDs[index] of a scope.
+type controlOutcome struct {
+	index   int
+	results map[string]resourcesresults.ResourceAssociatedControl
+}
+
+// mergeControlResults merges the results of a control on a scope into the
+// results of the scan.
+func (opap *OPAProcessor) mergeControlResults(resourcesAssociatedControl map[string]resourcesresults.ResourceAssociatedControl) {
+	if len(resourcesAssociatedControl) == 0 {
+		return
+	}
+	opap.mu.Lock()
+	defer opap.mu.Unlock()
+	for resourceID, controlResult := range resourcesAssociatedControl {
+		t, ok := opap.ResourcesResult[resourceID]
+		if !ok {
+			t = resourcesresults.Result{ResourceID: resourceID}
+		}
+		t.AssociatedControls = mergeAssociatedControls(t.AssociatedControls, controlResult, opap.AllPolicies)
+		opap.ResourcesResult[resourceID] = t
+	}
+}
+
+// evalWorkers returns how many of a scope's controls are evaluated at once:
+// evalConcurrency, or one per CPU, and never more than there are controls.
+//
+// Every control in flight converts the scope's objects into its own Rego
+// input, so the memory evaluation takes grows with the concurrency. A
+// streaming scan streams to keep that input bounded, so there the controls in
+// flight are also bounded to hold no more objects together than the
+// large-cluster threshold: the input a scan just small enough not to stream
+// evaluates at once.
+func (opap *OPAProcessor) evalWorkers(scope evaluationScope, controls int) int {
+	workers := opap.evalConcurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if opap.streaming {
+		if objects := scope.objectCount(); objects > 0 {
+			workers = min(workers, max(1, opap.largeClusterSizeThreshold/objects))
+		}
+	}
+	return max(1, min(workers, controls))
+}
+
 // Process OPA policies (rules) on all configured controls.
 //
 // Resources are evaluated one scope at a time: first the resident scope
@@ -570,6 +710,10 @@ func (opap *OPAProcessor) Process(ctx context.Context, policies *cautils.Policie
 	opap.loggerStartScanning()
 	defer opap.loggerDoneScanning()
 
+	if opap.shardEvaluator != nil {
+		return opap.processSharded(ctx, policies, progressListener)
+	}
+
 	scopes := opap.evaluationScopes()
 	scopeControlIDs, wholeClusterControlIDs := splitWholeClusterControls(policies, sortedControlIDs(policies))
 
@@ -650,6 +794,18 @@ func newEvaluationScope(name string, batch *cautils.ResourceBatch, residentGroup
 	return scope
 }
 
+// objectCount returns the number of objects the scope's rules can match.
+func (scope evaluationScope) objectCount() int {
+	var count int
+	if scope.ownBatch {
+		count += scope.batchGroups.objectCount
+	}
+	if scope.residentGroups != nil {
+		count += scope.residentGroups.k8s.objectCount + scope.residentGroups.external.objectCount
+	}
+	return count
+}
+
 // matchedObjects returns the rule's input for this scope: the scope's own
 // matching objects, then the resident ones. The ordering matters because Rego
 // rules see the input as an array.
@@ -810,6 +966,8 @@ func splitWholeClusterControls(policies *cautils.Policies, controlIDs []string)
 // Rules reading a resource's status are excluded too, because ResourceHash
 // leaves status out of the cache key: a node upgrade changes only
 // status.nodeInfo, which would otherwise keep serving the pre-upgrade verdict.
+// CEL rules are excluded as well: their verdicts also read the resource's
+// Namespace object and, for library rules, its relatedObjects.
 func ruleCacheEligible(control *reporthandling.Control, rule *reporthandling.PolicyRule) bool {
 	if controlRequiresWholeClusterInput(control) {
 		return false
@@ -998,7 +1156,9 @@ func (opap *OPAProcessor) processControl(ctx context.Context, control *reporthan
 
 	if len(ruleErrs) == 0 && opap.incrementalCache != nil && controlCacheEligible(control) {
 		for resourceID, result := range resourcesAssociatedControl {
+			opap.mu.Lock()
 			resource, ok := opap.AllResources[resourceID]
+			opap.mu.Unlock()
 			if !ok {
 				continue
 			}
@@ -1073,17 +1233,27 @@ func (opap *OPAProcessor) processRuleOnScope(ctx context.Context, rule *reportha
 			return resources, nil
 		}
 	}
+
+	evalErr := opap.evaluateRuleOnScope(ctx, rule, resourceToScan, ruleRegoDependenciesData, scope, controlID, resources)
+	scopesErr := opap.evaluateRuleOnInputsScopes(ctx, rule, fixedControlInputs, resourceToScan, ruleRegoDependenciesData, scope, controlID, resources)
+	return resources, errors.Join(evalErr, scopesErr)
+}
+
+// evaluateRuleOnScope evaluates a single policy rule against the resources to
+// scan of a single scope, with the given rego dependencies data, and adds the
+// results to resources.
+func (opap *OPAProcessor) evaluateRuleOnScope(ctx context.Context, rule *reporthandling.PolicyRule, resourceToScan []workloadinterface.IMetadata, ruleRegoDependenciesData resources.RegoDependenciesData, scope evaluationScope, controlID string, resources map[string]*resourcesresults.ResourceAssociatedRule) error {
 	inputResources, err := reporthandling.RegoResourcesAggregator(
 		rule,
 		resourceToScan, // NOTE: this uses the initial snapshot of AllResources
 	)
 	if err != nil {
 		opap.markResourcesSkipped(resources, rule, ruleRegoDependenciesData, resourceToScan, err)
-		return resources, fmt.Errorf("aggregator failed for namespace %q: %w", scope.name, err)
+		return fmt.Errorf("aggregator failed for namespace %q: %w", scope.name, err)
 	}
 
 	if len(inputResources) == 0 {
-		return resources, nil // no resources found for testing
+		return nil // no resources found for testing
 	}
 
 	bufPtr := astEvalBufferPool.Get().(*[]map[string]any)
@@ -1103,7 +1273,7 @@ func (opap *OPAProcessor) processRuleOnScope(ctx context.Context, rule *reportha
 	enumeratedData, err := opap.enumerateData(ctx, rule, inputRawResources, controlID)
 	if err != nil {
 		opap.markResourcesSkipped(resources, rule, ruleRegoDependenciesData, inputResources, err)
-		return resources, fmt.Errorf("enumerator failed for namespace %q: %w", scope.name, err)
+		return fmt.Errorf("enumerator failed for namespace %q: %w", scope.name, err)
 	}
 
 	inputResources = objectsenvelopes.ListMapToMeta(enumeratedData)
@@ -1131,7 +1301,7 @@ func (opap *OPAProcessor) processRuleOnScope(ctx context.Context, rule *reportha
 	ruleResponses, celOut, err := opap.runOPAOnSingleRule(ctx, rule, inputRawResources, ruleData, ruleRegoDependenciesData, controlID)
 	if err != nil {
 		opap.markResourcesSkipped(resources, rule, ruleRegoDependenciesData, inputResources, err)
-		return resources, fmt.Errorf("rego eval failed for namespace %q: %w", scope.name, err)
+		return fmt.Errorf("rego eval failed for namespace %q: %w", scope.name, err)
 	}
 
 	// Record CEL resources with unknown verdicts as skipped before pass-inference.
@@ -1226,7 +1396,7 @@ func (opap *OPAProcessor) processRuleOnScope(ctx context.Context, rule *reportha
 			resources[failedResource.GetID()] = ruleResult
 		}
 	}
-	return resources, nil
+	return nil
 }
 
 // hasUnreachableDependency reports whether controlID depends (per
@@ -1242,6 +1412,10 @@ func (opap *OPAProcessor) processRuleOnScope(ctx context.Context, rule *reportha
 // O(len(ResourceToControlsMap)) cost is paid a small, bounded number of times
 // per scan rather than once per resource.
 func (opap *OPAProcessor) hasUnreachableDependency(controlID string) bool {
+	// Controls evaluated at the same time record their skips in InfoMap (see
+	// markResourcesSkipped).
+	opap.mu.Lock()
+	defer opap.mu.Unlock()
 	for gvr, controlIDs := range opap.ResourceToControlsMap {
 		if !slices.Contains(controlIDs, controlID) {
 			continue
@@ -1332,7 +1506,9 @@ func (opap *OPAProcessor) markControlsSkipped(controlIDs []string) {
 }
 
 func (opap *OPAProcessor) reweightComplianceScores() {
-	if len(opap.TimedOutControls) == 0 {
+	// A scoring profile already scores timed-out controls as failed, with
+	// the profile's weights.
+	if len(opap.TimedOutControls) == 0 || opap.ScoringProfile != nil {
 		return
 	}
 	var sum float32
@@ -1406,7 +1582,7 @@ func (opap *OPAProcessor) runOPAOnSingleRule(ctx context.Context, rule *reportha
 		responses, err := opap.runRegoOnK8s(ctx, rule, k8sObjects, getRuleData, ruleRegoDependenciesData, controlID)
 		return responses, celOutcome{}, err
 	case reporthandling.CELLanguage:
-		return opap.runCELOnK8s(ctx, rule, k8sObjects, getRuleData, controlID)
+		return opap.runCELOnK8s(ctx, rule, k8sObjects, getRuleData, ruleRegoDependenciesData, controlID)
 	default:
 		return nil, celOutcome{}, fmt.Errorf("rule: '%s', language '%v' not supported", rule.Name, rule.RuleLanguage)
 	}
@@ -1431,13 +1607,18 @@ type skippedCELResource struct {
 	err error
 }
 
-// runCELOnK8s evaluates a CEL-based PolicyRule against k8s objects by loading
-// the control's ValidatingAdmissionPolicy from the embedded bundle and running
-// its validations. controlID is threaded down from processControl (not read off
-// the rule) and selects which policy to load.
+// runCELOnK8s evaluates a CEL-based PolicyRule against k8s objects. A rule
+// comes in one of three forms:
+//   - with no rule text, it runs the control's ValidatingAdmissionPolicy from
+//     the embedded bundle; controlID is threaded down from processControl (not
+//     read off the rule) and selects which policy to load;
+//   - a custom CEL rule of a framework definition carries its own policy;
+//   - a CEL rule of the policy library carries a cel.Rule, evaluated with the
+//     rule's control inputs bound to params and the input's other objects to
+//     relatedObjects (see celRuleParams and celRelatedObjects).
 //
-// getRuleData is part of the shared dispatch signature but unused here: CEL
-// expressions come from the loaded VAP, not from the rule's Rego text.
+// getRuleData is part of the shared dispatch signature but unused here: a
+// ResourceEnumerator is resolved by enumerateData, never run as the rule.
 //
 // Verdicts map to the Rego path's shape (processRule infers the passing
 // resources as the input minus everything else), but per resource rather than
@@ -1452,8 +1633,8 @@ type skippedCELResource struct {
 // A control-wide failure (the evaluator or policy will not load) is the only
 // rule-level error: every object hits it identically, so the whole rule is
 // skipped, the same path a Rego eval error takes.
-func (opap *OPAProcessor) runCELOnK8s(ctx context.Context, rule *reporthandling.PolicyRule, k8sObjects []map[string]any, _ func(*reporthandling.PolicyRule) string, controlID string) ([]reporthandling.RuleResponse, celOutcome, error) {
-	evaluator, err := opap.getCELEvaluator()
+func (opap *OPAProcessor) runCELOnK8s(ctx context.Context, rule *reporthandling.PolicyRule, k8sObjects []map[string]any, _ func(*reporthandling.PolicyRule) string, ruleRegoDependenciesData resources.RegoDependenciesData, controlID string) ([]reporthandling.RuleResponse, celOutcome, error) {
+	evaluate, err := opap.celEvaluateFunc(ctx, rule, k8sObjects, ruleRegoDependenciesData, controlID)
 	if err != nil {
 		return nil, celOutcome{}, fmt.Errorf("rule: '%s', %w", rule.Name, err)
 	}
@@ -1470,7 +1651,7 @@ func (opap *OPAProcessor) runCELOnK8s(ctx context.Context, rule *reporthandling.
 		// policy reading namespaceObject.* sees an absent namespace (and a
 		// selection into it eval-errors and skips, never passes). File scans and
 		// scans whose frameworks never matched Namespaces stay on that safe path.
-		eval, err := evaluator.EvaluateControl(ctx, controlID, obj, opap.celNamespaceObjectFor(obj))
+		eval, err := evaluate(obj)
 		if err != nil {
 			return nil, celOutcome{}, fmt.Errorf("rule: '%s', %w", rule.Name, err)
 		}
@@ -1536,6 +1717,124 @@ func (opap *OPAProcessor) runCELOnK8s(ctx context.Context, rule *reporthandling.
 	return responses, outcome, nil
 }
 
+// celEvaluateFunc returns the function evaluating rule against one of
+// k8sObjects, for the form the rule comes in (see runCELOnK8s).
+func (opap *OPAProcessor) celEvaluateFunc(ctx context.Context, rule *reporthandling.PolicyRule, k8sObjects []map[string]any, deps resources.RegoDependenciesData, controlID string) (func(obj map[string]any) (cel.ControlEvaluation, error), error) {
+	if isCELLibraryRule(rule) {
+		evaluator, err := opap.getCELRuleEvaluator()
+		if err != nil {
+			return nil, err
+		}
+		celRule, err := cel.ParseRule(rule.Name, []byte(rule.Rule))
+		if err != nil {
+			return nil, err
+		}
+		params := celRuleParams(deps)
+		related := celRelatedObjects(k8sObjects)
+		return func(obj map[string]any) (cel.ControlEvaluation, error) {
+			return evaluator.EvaluateRule(ctx, celRule, obj, opap.celNamespaceObjectFor(obj), related(obj), params)
+		}, nil
+	}
+
+	evaluator, err := opap.getCELEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	// A custom CEL rule carries its own policy rather than one from the
+	// embedded bundle.
+	if inline, _ := rule.Attributes[getter.RuleAttributeInlinePolicy].(bool); inline {
+		vap, err := cel.ParsePolicy(controlID, []byte(rule.Rule))
+		if err != nil {
+			return nil, err
+		}
+		return func(obj map[string]any) (cel.ControlEvaluation, error) {
+			return evaluator.EvaluatePolicy(ctx, vap, obj, opap.celNamespaceObjectFor(obj))
+		}, nil
+	}
+	return func(obj map[string]any) (cel.ControlEvaluation, error) {
+		return evaluator.EvaluateControl(ctx, controlID, obj, opap.celNamespaceObjectFor(obj))
+	}, nil
+}
+
+// isCELLibraryRule reports whether rule is a CEL rule of the policy library,
+// one that carries a cel.Rule rather than a ValidatingAdmissionPolicy.
+func isCELLibraryRule(rule *reporthandling.PolicyRule) bool {
+	if rule.RuleLanguage != reporthandling.CELLanguage || rule.Rule == "" {
+		return false
+	}
+	inline, _ := rule.Attributes[getter.RuleAttributeInlinePolicy].(bool)
+	return !inline
+}
+
+// celRuleParams binds a library rule's control inputs the way a policy reads
+// its ControlConfiguration, under params.settings, and the data inputs a Rego
+// rule reads from data.dataControlInputs next to them (params.cloudProvider).
+func celRuleParams(deps resources.RegoDependenciesData) map[string]any {
+	settings := make(map[string]any, len(deps.PostureControlInputs))
+	for name, values := range deps.PostureControlInputs {
+		list := make([]any, 0, len(values))
+		for _, value := range values {
+			list = append(list, value)
+		}
+		settings[name] = list
+	}
+	params := map[string]any{"settings": settings}
+	for name, value := range deps.DataControlInputs {
+		params[name] = value
+	}
+	return params
+}
+
+// celRelatedObjects returns the function resolving the relatedObjects of one
+// of k8sObjects: the objects RegoResourcesAggregator grouped with it when it is
+// an aggregated object, and otherwise the objects of k8sObjects of other kinds
+// than its own, which is what a Rego rule finds next to it in its input.
+func celRelatedObjects(k8sObjects []map[string]any) func(obj map[string]any) []any {
+	// Kinds keep the order they first appear in, so relatedObjects lists the
+	// objects in input order whatever the object evaluated.
+	var kinds []string
+	byKind := make(map[string][]any)
+	for _, obj := range k8sObjects {
+		kind, _ := obj["kind"].(string)
+		if _, seen := byKind[kind]; !seen {
+			kinds = append(kinds, kind)
+		}
+		byKind[kind] = append(byKind[kind], obj)
+	}
+	// The objects related to each kind are built once and shared by all the
+	// objects of the kind: the CEL bindings only read them.
+	otherKinds := make(map[string][]any, len(kinds))
+	for _, kind := range kinds {
+		related := make([]any, 0, len(k8sObjects)-len(byKind[kind]))
+		for _, otherKind := range kinds {
+			if otherKind != kind {
+				related = append(related, byKind[otherKind]...)
+			}
+		}
+		otherKinds[kind] = related
+	}
+	all := make([]any, 0, len(k8sObjects))
+	for _, kind := range kinds {
+		all = append(all, byKind[kind]...)
+	}
+	return func(obj map[string]any) []any {
+		if objectsenvelopes.IsTypeRegoResponseVector(obj) {
+			relatedObjects := objectsenvelopes.NewRegoResponseVectorObject(obj).GetRelatedObjects()
+			related := make([]any, 0, len(relatedObjects))
+			for _, relatedObject := range relatedObjects {
+				related = append(related, relatedObject.GetObject())
+			}
+			return related
+		}
+		kind, _ := obj["kind"].(string)
+		if related, ok := otherKinds[kind]; ok {
+			return related
+		}
+		// An object of a kind k8sObjects lacks is related to all of them.
+		return all
+	}
+}
+
 // seedCELSkips records the CEL rule's unknown-verdict resources as StatusSkipped
 // (with their eval error in InfoMap) before pass-inference, so they are not
 // later mistaken for passes. It mirrors markResourcesSkipped but per resource,
@@ -1580,6 +1879,15 @@ func (opap *OPAProcessor) getCELEvaluator() (*cel.Evaluator, error) {
 	return opap.celEvaluator, opap.celEvaluatorErr
 }
 
+// getCELRuleEvaluator lazily builds the CEL evaluator of the policy library's
+// CEL rules (see the celRuleEvaluator field).
+func (opap *OPAProcessor) getCELRuleEvaluator() (*cel.Evaluator, error) {
+	opap.celRuleEvaluatorOnce.Do(func() {
+		opap.celRuleEvaluator, opap.celRuleEvaluatorErr = cel.NewRuleEvaluator()
+	})
+	return opap.celRuleEvaluator, opap.celRuleEvaluatorErr
+}
+
 // celNamespaceObjectFor resolves the Namespace object a scanned resource lives
 // in, for the evaluator's namespaceObject binding. It returns nil for a
 // cluster-scoped resource (no namespace to resolve) and for a namespace the
@@ -1766,18 +2074,25 @@ func (opap *OPAProcessor) regoEval(ctx context.Context, inputObj []map[string]an
 	return results, nil
 }
 
-// enumerateData resolves a rule's ResourceEnumerator. A CEL rule must not carry
-// one (it scopes via the VAP's matchConstraints); the guard below enforces that
-// rather than trusting it, because routing a CEL rule through the enumerator
-// would run its validations as the enumerator and silently drop every compliant
-// resource. So the enumerator path here is provably the Rego path, and controlID
-// is unused on it.
+// enumerateData resolves a rule's ResourceEnumerator.
+//
+// A CEL rule of the policy library declares its enumerator as a boolean CEL
+// expression selecting the objects to evaluate (see celEnumerateData). A
+// policy-backed CEL rule must not carry one (it scopes via the VAP's
+// matchConstraints); the guard below enforces that rather than trusting it,
+// because routing it through the Rego enumerator path would run its
+// validations as the enumerator and silently drop every compliant resource.
+// So the remaining enumerator path is provably the Rego path, and controlID is
+// unused on it.
 func (opap *OPAProcessor) enumerateData(ctx context.Context, rule *reporthandling.PolicyRule, k8sObjects []map[string]any, controlID string) ([]map[string]any, error) {
 	if ruleEnumeratorData(rule) == "" {
 		return k8sObjects, nil
 	}
+	if isCELLibraryRule(rule) {
+		return opap.celEnumerateData(ctx, rule, k8sObjects)
+	}
 	if rule.RuleLanguage == reporthandling.CELLanguage {
-		return nil, fmt.Errorf("rule: '%s', CEL rules must not declare a ResourceEnumerator; they scope via the policy's matchConstraints", rule.Name)
+		return nil, fmt.Errorf("rule: '%s', CEL policies must not declare a ResourceEnumerator; they scope via the policy's matchConstraints", rule.Name)
 	}
 
 	ruleRegoDependenciesData := opap.makeRegoDeps(rule.ControlConfigInputs, nil)
@@ -1794,6 +2109,31 @@ func (opap *OPAProcessor) enumerateData(ctx context.Context, rule *reporthandlin
 	return failedResources, nil
 }
 
+// celEnumerateData keeps the objects a library CEL rule's ResourceEnumerator
+// selects. The enumerator sees the same bindings as the rule's validations. An
+// enumerator that errors on one object fails the whole rule, as a Rego
+// enumerator that errors does.
+func (opap *OPAProcessor) celEnumerateData(ctx context.Context, rule *reporthandling.PolicyRule, k8sObjects []map[string]any) ([]map[string]any, error) {
+	evaluator, err := opap.getCELRuleEvaluator()
+	if err != nil {
+		return nil, fmt.Errorf("rule: '%s', %w", rule.Name, err)
+	}
+	params := celRuleParams(opap.makeRegoDeps(rule.ControlConfigInputs, nil))
+	related := celRelatedObjects(k8sObjects)
+
+	selected := make([]map[string]any, 0, len(k8sObjects))
+	for _, obj := range k8sObjects {
+		ok, err := evaluator.Selects(ctx, rule.ResourceEnumerator, obj, opap.celNamespaceObjectFor(obj), related(obj), params)
+		if err != nil {
+			return nil, fmt.Errorf("rule: '%s', %w", rule.Name, err)
+		}
+		if ok {
+			selected = append(selected, obj)
+		}
+	}
+	return selected, nil
+}
+
 // makeRegoDeps builds a resources.RegoDependenciesData struct for the current cloud provider.
 //
 // If some extra fixedControlInputs are provided, they are merged into the "posture" control inputs.
@@ -1863,53 +2203,161 @@ func split(namespaces string) []string {
 // those inputs scannable instead of producing a fatal, empty-report exit.
 // Modules that do declare "import rego.v1" parse identically under either
 // default, so this only changes behavior for genuinely legacy v0 rules.
+//
+// With a rule cache, a rule compiled on an earlier run is loaded from it
+// instead of being compiled, and a rule compiled now is stored in it.
 func (opap *OPAProcessor) getCompiledRule(ctx context.Context, ruleName, ruleData string, printEnabled bool) (*ast.Compiler, ast.RegoVersion, error) {
 	cacheKey := ruleName + "|" + ruleData
 
 	opap.compiledMu.RLock()
-	if entry, ok := opap.compiledModules[cacheKey]; ok {
-		opap.compiledMu.RUnlock()
+	entry, ok := opap.compiledModules[cacheKey]
+	opap.compiledMu.RUnlock()
+	if ok {
 		return entry.compiler, entry.version, nil
 	}
-	opap.compiledMu.RUnlock()
 
-	opap.compiledMu.Lock()
-	defer opap.compiledMu.Unlock()
+	// Compile outside compiledMu, so that controls evaluated at the same time
+	// do not queue behind each other's compilation; compileGroup still
+	// compiles each rule once. The compilation is shared by every caller, so
+	// it does not stop when the caller that started it is canceled; each
+	// caller stops waiting for it when its own context is done.
+	compileCtx := context.WithoutCancel(ctx)
+	compiled := opap.compileGroup.DoChan(cacheKey, func() (any, error) {
+		opap.compiledMu.RLock()
+		entry, ok := opap.compiledModules[cacheKey]
+		opap.compiledMu.RUnlock()
+		if ok {
+			return entry, nil
+		}
 
-	if entry, ok := opap.compiledModules[cacheKey]; ok {
+		entry, err := opap.loadOrCompileRule(compileCtx, ruleName, ruleData, printEnabled)
+		if err != nil {
+			return nil, err
+		}
+		opap.compiledMu.Lock()
+		opap.compiledModules[cacheKey] = entry
+		opap.compiledMu.Unlock()
+		return entry, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	case result := <-compiled:
+		if result.Err != nil {
+			return nil, 0, result.Err
+		}
+		entry = result.Val.(compiledRule)
 		return entry.compiler, entry.version, nil
 	}
+}
 
-	baseModules, err := getRuleDependencies(ctx)
+// loadOrCompileRule loads ruleName+ruleData from the rule cache, compiling
+// and storing it there on a miss. A failure to store does not fail the rule,
+// but a cache that cannot be written (read-only or full disk) would make every
+// run pay the full compilation, so the first such failure is a Warning.
+func (opap *OPAProcessor) loadOrCompileRule(ctx context.Context, ruleName, ruleData string, printEnabled bool) (compiledRule, error) {
+	if opap.ruleCache == nil {
+		return compileRuleFunc(ctx, ruleName, ruleData, printEnabled)
+	}
+	key := rulecache.Key(ruleName, ruleData, printEnabled)
+	if compiler, version, ok := opap.ruleCache.Get(key, printEnabled); ok {
+		return compiledRule{compiler: compiler, version: version}, nil
+	}
+	entry, err := compileRuleFunc(ctx, ruleName, ruleData, printEnabled)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get rule dependencies: %w", err)
+		return compiledRule{}, err
+	}
+	if err := opap.ruleCache.Put(key, entry.compiler, entry.version); err != nil {
+		if errors.Is(err, rulecache.ErrNotRoundTrip) {
+			logger.L().Ctx(ctx).Debug("compiled rule not cached", helpers.String("rule", ruleName), helpers.Error(err))
+			return entry, nil
+		}
+		warned := false
+		opap.ruleCacheWarnOnce.Do(func() {
+			opap.ruleCacheErr = err
+			warned = true
+			logger.L().Ctx(ctx).Warning("failed to write the compiled rule cache, rules will be compiled on every run", helpers.String("rule", ruleName), helpers.Error(err))
+		})
+		if !warned {
+			logger.L().Ctx(ctx).Debug("failed to cache the compiled rule", helpers.String("rule", ruleName), helpers.Error(err))
+		}
 	}
+	return entry, nil
+}
 
-	modules := make(map[string]string, len(baseModules)+1)
-	maps.Copy(modules, baseModules)
-	modules[ruleName] = ruleData
+// compileRuleFunc is compileRule, swapped in tests to count compilations.
+var compileRuleFunc = compileRule
 
-	version := ast.RegoV1
-	compiled, v1Err := ast.CompileModulesWithOpt(modules, ast.CompileOpts{
-		EnablePrintStatements: printEnabled,
-		ParserOptions:         ast.ParserOptions{RegoVersion: ast.RegoV1},
-	})
-	if v1Err != nil {
-		var v0Err error
-		compiled, v0Err = ast.CompileModulesWithOpt(modules, ast.CompileOpts{
-			EnablePrintStatements: printEnabled,
-			ParserOptions:         ast.ParserOptions{RegoVersion: ast.RegoV0},
-		})
-		if v0Err != nil {
-			return nil, 0, fmt.Errorf("failed to compile rule '%s': %w", ruleName, v1Err)
-		}
-		version = ast.RegoV0
-		logger.L().Ctx(ctx).Warning("rule uses deprecated Rego v0 syntax; v0 support will be removed in a future release",
-			helpers.String("rule", ruleName))
+func compileRule(ctx context.Context, ruleName, ruleData string, printEnabled bool) (compiledRule, error) {
+	compiled, v1Err := compileRuleAs(ctx, ruleName, ruleData, printEnabled, ast.RegoV1)
+	if v1Err == nil {
+		return compiledRule{compiler: compiled, version: ast.RegoV1}, nil
+	}
+	compiled, v0Err := compileRuleAs(ctx, ruleName, ruleData, printEnabled, ast.RegoV0)
+	if v0Err != nil {
+		return compiledRule{}, fmt.Errorf("failed to compile rule '%s': %w", ruleName, v1Err)
 	}
+	logger.L().Ctx(ctx).Warning("rule uses deprecated Rego v0 syntax; v0 support will be removed in a future release",
+		helpers.String("rule", ruleName))
+	return compiledRule{compiler: compiled, version: ast.RegoV0}, nil
+}
 
-	opap.compiledModules[cacheKey] = compiledRule{compiler: compiled, version: version}
-	return compiled, version, nil
+// compileRuleAs compiles ruleName+ruleData together with the shared rule
+// dependencies, parsing both as version.
+func compileRuleAs(ctx context.Context, ruleName, ruleData string, printEnabled bool, version ast.RegoVersion) (*ast.Compiler, error) {
+	dependencies, err := parseRuleDependencies(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := ast.ParseModuleWithOpts(ruleName, ruleData, ast.ParserOptions{RegoVersion: version})
+	if err != nil {
+		return nil, err
+	}
+
+	modules := make(map[string]*ast.Module, len(dependencies)+1)
+	maps.Copy(modules, dependencies)
+	modules[ruleName] = rule
+
+	compiler := ast.NewCompiler().
+		WithDefaultRegoVersion(version).
+		WithEnablePrintStatements(printEnabled)
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		return nil, compiler.Errors
+	}
+	return compiler, nil
+}
+
+// parsedRuleDependencies holds the shared rule dependencies parsed once per
+// process and Rego version, instead of once for every rule compiled with
+// them. The compiler copies the modules it is given, so they can be shared.
+var parsedRuleDependencies sync.Map // ast.RegoVersion -> ruleDependencies
+
+type ruleDependencies struct {
+	modules map[string]*ast.Module
+	err     error
+}
+
+func parseRuleDependencies(ctx context.Context, version ast.RegoVersion) (map[string]*ast.Module, error) {
+	if parsed, ok := parsedRuleDependencies.Load(version); ok {
+		return parsed.(ruleDependencies).modules, parsed.(ruleDependencies).err
+	}
+
+	sources, err := getRuleDependencies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule dependencies: %w", err)
+	}
+	parsed := ruleDependencies{modules: make(map[string]*ast.Module, len(sources))}
+	for name, source := range sources {
+		module, err := ast.ParseModuleWithOpts(name, source, ast.ParserOptions{RegoVersion: version})
+		if err != nil {
+			parsed = ruleDependencies{err: err}
+			break
+		}
+		parsed.modules[name] = module
+	}
+	actual, _ := parsedRuleDependencies.LoadOrStore(version, parsed)
+	return actual.(ruleDependencies).modules, actual.(ruleDependencies).err
 }
 
 // createLightweightResource is intentionally removed. Stripping spec/status/data
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"runtime"
 	"sort"
 	"testing"
 
@@ -255,6 +256,53 @@ func TestProcess_PartitioningMatchesSingleScope(t *testing.T) {
 	assert.Equal(t, normalize(single.ResourcesResult), normalize(partitioned.ResourcesResult))
 }
 
+// TestProcess_EvalConcurrencyMatchesSequential pins that evaluating the
+// controls of a scope concurrently changes nothing, not even the order of a
+// resource's AssociatedControls: results are compared without normalizing.
+func TestProcess_EvalConcurrencyMatchesSequential(t *testing.T) {
+	t.Setenv("LARGE_CLUSTER_SIZE", "1")
+
+	policies := parityPolicies(true)
+
+	sequential := newParityProcessor(policies)
+	sequential.SetEvalConcurrency(1)
+	require.NoError(t, sequential.Process(context.Background(), policies, nil))
+	require.NotEmpty(t, sequential.ResourcesResult)
+
+	for _, concurrency := range []int{2, 8} {
+		concurrent := newParityProcessor(policies)
+		concurrent.SetEvalConcurrency(concurrency)
+		require.NoError(t, concurrent.Process(context.Background(), policies, nil))
+		assert.Equal(t, sequential.ResourcesResult, concurrent.ResourcesResult, "concurrency %d", concurrency)
+	}
+}
+
+func TestEvalWorkers(t *testing.T) {
+	t.Setenv("LARGE_CLUSTER_SIZE", "1")
+
+	opap := newParityProcessor(parityPolicies(true))
+	scopes := opap.evaluationScopes()
+	require.Len(t, scopes, 3)
+	resident, namespace := scopes[0], scopes[1]
+	require.Equal(t, 1, resident.objectCount(), "the ClusterRole")
+	require.Equal(t, 4, namespace.objectCount(), "the Namespace, its two workloads and the ClusterRole")
+
+	opap.SetEvalConcurrency(8)
+	assert.Equal(t, 8, opap.evalWorkers(namespace, 20))
+	assert.Equal(t, 3, opap.evalWorkers(namespace, 3), "there are no more workers than controls")
+
+	opap.streaming = true
+	opap.largeClusterSizeThreshold = 12
+	assert.Equal(t, 8, opap.evalWorkers(resident, 20))
+	assert.Equal(t, 3, opap.evalWorkers(namespace, 20), "no more than 12 objects are evaluated at once")
+	opap.largeClusterSizeThreshold = 2
+	assert.Equal(t, 1, opap.evalWorkers(namespace, 20), "a scope over the bound is still evaluated")
+
+	opap.streaming = false
+	opap.SetEvalConcurrency(0)
+	assert.Equal(t, min(runtime.GOMAXPROCS(0), 20), opap.evalWorkers(namespace, 20))
+}
+
 // TestProcess_ResidentVerdictsMergeAcrossScopes covers the resource kind that
 // only scope partitioning can get wrong: a cluster-scoped resource, which is
 // part of the input of every scope. Its verdicts must accumulate into one
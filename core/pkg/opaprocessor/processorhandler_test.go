@@ -16,6 +16,7 @@ import (
 	"github.com/armosec/armoapi-go/armotypes"
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	"github.com/kubescape/kubescape/v4/core/mocks"
 	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor/cel"
 	"github.com/kubescape/opa-utils/objectsenvelopes"
@@ -744,6 +745,41 @@ func TestRunOPAOnSingleRuleDispatch(t *testing.T) {
 		assert.Contains(t, err.Error(), "C-9999")
 	})
 
+	t.Run("inline CEL policy is evaluated from the rule", func(t *testing.T) {
+		rule := &reporthandling.PolicyRule{
+			PortalBase: armotypes.PortalBase{
+				Name:       "host-network",
+				Attributes: map[string]any{getter.RuleAttributeInlinePolicy: true},
+			},
+			RuleLanguage: reporthandling.CELLanguage,
+			Rule: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: host-network
+spec:
+  matchConstraints:
+    resourceRules:
+      - apiGroups: [""]
+        apiVersions: ["v1"]
+        operations: ["CREATE", "UPDATE"]
+        resources: ["pods"]
+  validations:
+    - expression: "!has(object.spec.hostNetwork) || !object.spec.hostNetwork"
+      message: "pods must not use the host network"
+`,
+		}
+		obj := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]any{"name": "p", "namespace": "default"},
+			"spec":       map[string]any{"hostNetwork": true},
+		}
+		responses, _, err := opap.runOPAOnSingleRule(context.Background(), rule, []map[string]any{obj}, getRuleData, resources.RegoDependenciesData{}, "custom-host-network")
+		require.NoError(t, err)
+		require.Len(t, responses, 1)
+		assert.Equal(t, "pods must not use the host network", responses[0].AlertMessage)
+	})
+
 	t.Run("unknown language returns not-supported error", func(t *testing.T) {
 		rule := &reporthandling.PolicyRule{
 			PortalBase:   armotypes.PortalBase{Name: "mystery-rule"},
@@ -804,6 +840,81 @@ this is not valid rego at all {{{
 	})
 }
 
+// TestParseRuleDependencies_ParsedOnce pins that the shared rule dependencies
+// are parsed once per Rego version rather than once for every rule, and that
+// rules compiled with the same parsed modules do not interfere.
+func TestParseRuleDependencies_ParsedOnce(t *testing.T) {
+	first, err := parseRuleDependencies(context.Background(), ast.RegoV1)
+	require.NoError(t, err)
+	second, err := parseRuleDependencies(context.Background(), ast.RegoV1)
+	require.NoError(t, err)
+	require.Len(t, second, len(first))
+	for name, module := range first {
+		assert.Same(t, module, second[name], name)
+	}
+
+	opap := &OPAProcessor{compiledModules: make(map[string]compiledRule)}
+	for _, name := range []string{"rule-a", "rule-b"} {
+		_, version, err := opap.getCompiledRule(context.Background(), name, `package armo_builtins
+import rego.v1
+
+deny contains msga if {
+	input.kind == "Pod"
+	msga := {"alertMessage": "test", "alertScore": 1, "failedPaths": [], "fixPaths": [], "alertObject": {"k8sApiObjects": [input]}}
+}
+`, false)
+		require.NoError(t, err)
+		assert.Equal(t, ast.RegoV1, version)
+	}
+	after, err := parseRuleDependencies(context.Background(), ast.RegoV1)
+	require.NoError(t, err)
+	for name, module := range first {
+		assert.Same(t, module, after[name], "compiling must not parse %s again", name)
+	}
+}
+
+// TestGetCompiledRule_CanceledCallerDoesNotFailOthers proves a compilation
+// shared by several callers outlives the caller that started it: that caller
+// stops waiting when canceled, while the others get the compiled rule.
+func TestGetCompiledRule_CanceledCallerDoesNotFailOthers(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var compileErr error
+	compileRuleFunc = func(ctx context.Context, ruleName, ruleData string, printEnabled bool) (compiledRule, error) {
+		close(started)
+		<-release
+		compileErr = ctx.Err()
+		return compileRule(ctx, ruleName, ruleData, printEnabled)
+	}
+	t.Cleanup(func() { compileRuleFunc = compileRule })
+
+	opap := &OPAProcessor{compiledModules: make(map[string]compiledRule)}
+	rule := "package armo_builtins\nimport rego.v1\n\ndeny contains 1 if input.kind == \"Pod\"\n"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, _, err := opap.getCompiledRule(ctx, "shared-rule", rule, false)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan error, 1)
+	go func() {
+		_, _, err := opap.getCompiledRule(context.Background(), "shared-rule", rule, false)
+		second <- err
+	}()
+
+	cancel()
+	require.ErrorIs(t, <-firstErr, context.Canceled, "the canceled caller stops waiting")
+	close(release)
+	require.NoError(t, <-second)
+	assert.NoError(t, compileErr, "the compilation does not see the first caller's cancellation")
+
+	_, _, err := opap.getCompiledRule(context.Background(), "shared-rule", rule, false)
+	assert.NoError(t, err)
+}
+
 // TestRunOPAOnSingleRule_RegoV0FallbackEvaluates proves the fallback isn't
 // just a compile-time formality: a legacy v0 rule must actually evaluate
 // and produce a result, which requires regoEval to run at the same Rego
@@ -924,7 +1035,7 @@ func TestRunCELOnK8s(t *testing.T) {
 	}
 
 	t.Run("violation produces a failure response carrying the object", func(t *testing.T) {
-		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{violatingPod}, nil, "C-0017")
+		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{violatingPod}, nil, resources.RegoDependenciesData{}, "C-0017")
 		require.NoError(t, err)
 		require.Len(t, responses, 1)
 		assert.Empty(t, outcome.skipped)
@@ -941,7 +1052,7 @@ func TestRunCELOnK8s(t *testing.T) {
 		// The gap this closes: without paths on the response, appendPaths has
 		// nothing to contribute and the finding reaches the printers and
 		// `kubescape fix` with an empty Paths list.
-		responses, _, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{violatingPod}, nil, "C-0017")
+		responses, _, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{violatingPod}, nil, resources.RegoDependenciesData{}, "C-0017")
 		require.NoError(t, err)
 		require.Len(t, responses, 1)
 
@@ -956,7 +1067,7 @@ func TestRunCELOnK8s(t *testing.T) {
 	})
 
 	t.Run("compliant object produces no response and no skip", func(t *testing.T) {
-		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{compliantPod}, nil, "C-0017")
+		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{compliantPod}, nil, resources.RegoDependenciesData{}, "C-0017")
 		require.NoError(t, err)
 		assert.Empty(t, responses)
 		assert.Empty(t, outcome.skipped)
@@ -966,7 +1077,7 @@ func TestRunCELOnK8s(t *testing.T) {
 	// Blocker 1: a single object whose expression errors must NOT bury a
 	// confirmed violation on another object in the same batch.
 	t.Run("a broken object does not erase a sibling violation", func(t *testing.T) {
-		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{violatingPod, brokenPod}, nil, "C-0017")
+		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{violatingPod, brokenPod}, nil, resources.RegoDependenciesData{}, "C-0017")
 		require.NoError(t, err, "an eval error on one object must not fail the whole rule")
 		require.Len(t, responses, 2, "the confirmed violation must survive alongside the eval-error deny")
 		assert.Empty(t, outcome.skipped, "an eval error under failurePolicy Fail is a deny, not a skip")
@@ -981,7 +1092,7 @@ func TestRunCELOnK8s(t *testing.T) {
 
 	// An eval error alone is a deny under failurePolicy Fail, not a skip.
 	t.Run("an eval error is denied under failurePolicy Fail", func(t *testing.T) {
-		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{brokenPod}, nil, "C-0017")
+		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{brokenPod}, nil, resources.RegoDependenciesData{}, "C-0017")
 		require.NoError(t, err)
 		require.Len(t, responses, 1)
 		assert.Empty(t, outcome.skipped)
@@ -995,7 +1106,7 @@ func TestRunCELOnK8s(t *testing.T) {
 	// Blocker 2: an out-of-scope object must be excluded, not left to be
 	// inferred as passed.
 	t.Run("out-of-scope object is excluded, not passed", func(t *testing.T) {
-		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{configMap}, nil, "C-0017")
+		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{configMap}, nil, resources.RegoDependenciesData{}, "C-0017")
 		require.NoError(t, err)
 		assert.Empty(t, responses)
 		assert.Empty(t, outcome.skipped)
@@ -1006,14 +1117,14 @@ func TestRunCELOnK8s(t *testing.T) {
 	})
 
 	t.Run("unknown control skips the whole rule via an error", func(t *testing.T) {
-		_, _, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{violatingPod}, nil, "C-9999")
+		_, _, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{violatingPod}, nil, resources.RegoDependenciesData{}, "C-9999")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "cel-c-0017")
 		assert.Contains(t, err.Error(), "C-9999")
 	})
 
 	t.Run("empty batch is a clean no-op", func(t *testing.T) {
-		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, nil, nil, "C-0017")
+		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, nil, nil, resources.RegoDependenciesData{}, "C-0017")
 		require.NoError(t, err)
 		assert.Empty(t, responses)
 		assert.Empty(t, outcome.skipped)
@@ -1021,6 +1132,126 @@ func TestRunCELOnK8s(t *testing.T) {
 	})
 }
 
+func TestRunCELOnK8s_LibraryRule(t *testing.T) {
+	opap := &OPAProcessor{}
+	rule := &reporthandling.PolicyRule{
+		PortalBase:   armotypes.PortalBase{Name: "exposed-workload"},
+		RuleLanguage: reporthandling.CELLanguage,
+		Rule: `variables:
+  - name: exposingServices
+    expression: "relatedObjects.filter(s, s.kind == 'Service' && s.spec.type in params.settings.exposedServiceTypes && s.spec.selector.all(k, k in object.metadata.labels && object.metadata.labels[k] == s.spec.selector[k]))"
+validations:
+  - expression: "variables.exposingServices.size() == 0"
+    messageExpression: "'workload ' + object.metadata.name + ' is exposed through service ' + variables.exposingServices[0].metadata.name"
+  - expression: "!has(object.spec.hostNetwork) || object.spec.hostNetwork == false"
+    message: "the workload uses the host network"
+`,
+	}
+	pod := func(name string, hostNetwork bool) map[string]any {
+		return map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]any{"name": name, "namespace": "default", "labels": map[string]any{"app": name}},
+			"spec":       map[string]any{"hostNetwork": hostNetwork},
+		}
+	}
+	service := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]any{"name": "web", "namespace": "default"},
+		"spec":       map[string]any{"type": "NodePort", "selector": map[string]any{"app": "web"}},
+	}
+	deps := resources.RegoDependenciesData{PostureControlInputs: map[string][]string{"exposedServiceTypes": {"NodePort", "LoadBalancer"}}}
+
+	t.Run("evaluates the rule with related objects and control inputs", func(t *testing.T) {
+		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{pod("web", false), pod("db", false), service}, nil, deps, "C-TEST")
+		require.NoError(t, err)
+		assert.Empty(t, outcome.skipped)
+		assert.Empty(t, outcome.excluded)
+		require.Len(t, responses, 1)
+		assert.Equal(t, "workload web is exposed through service web", responses[0].AlertMessage)
+		failed := responses[0].GetFailedResources()
+		require.Len(t, failed, 1)
+		assert.Equal(t, "web", failed[0]["metadata"].(map[string]any)["name"])
+	})
+
+	t.Run("control inputs decide the verdict", func(t *testing.T) {
+		responses, _, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{pod("web", false), service}, nil, resources.RegoDependenciesData{PostureControlInputs: map[string][]string{"exposedServiceTypes": {"LoadBalancer"}}}, "C-TEST")
+		require.NoError(t, err)
+		assert.Empty(t, responses)
+	})
+
+	t.Run("failures carry Rego-shaped remediation paths", func(t *testing.T) {
+		responses, _, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{pod("db", true)}, nil, deps, "C-TEST")
+		require.NoError(t, err)
+		require.Len(t, responses, 1)
+		assert.Equal(t, "the workload uses the host network", responses[0].AlertMessage)
+		assert.Equal(t, rule.Name, responses[0].Rulename)
+		assert.Equal(t, []armotypes.FixPath{{Path: "spec.hostNetwork", Value: "false"}}, responses[0].FixPaths)
+	})
+
+	t.Run("an eval error is an unknown verdict", func(t *testing.T) {
+		responses, outcome, err := opap.runCELOnK8s(context.Background(), rule, []map[string]any{pod("web", false), service}, nil, resources.RegoDependenciesData{}, "C-TEST")
+		require.NoError(t, err)
+		assert.Empty(t, responses)
+		require.Len(t, outcome.skipped, 1, "a rule has no failurePolicy to turn the error into a deny")
+	})
+
+	t.Run("a malformed rule skips the whole rule", func(t *testing.T) {
+		broken := *rule
+		broken.Rule = "validations: []"
+		_, _, err := opap.runCELOnK8s(context.Background(), &broken, []map[string]any{pod("web", false)}, nil, deps, "C-TEST")
+		assert.ErrorContains(t, err, "exposed-workload")
+	})
+}
+
+func TestCELRelatedObjects(t *testing.T) {
+	pod := map[string]any{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{"name": "p"}}
+	otherPod := map[string]any{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{"name": "q"}}
+	service := map[string]any{"apiVersion": "v1", "kind": "Service", "metadata": map[string]any{"name": "s"}}
+	role := map[string]any{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "Role", "metadata": map[string]any{"name": "r"}}
+
+	related := celRelatedObjects([]map[string]any{pod, service, otherPod, role})
+	assert.Equal(t, []any{service, role}, related(pod), "objects of other kinds, in input order")
+	assert.Equal(t, []any{pod, otherPod, role}, related(service))
+
+	// The objects of a kind share one slice rather than each building its own.
+	assert.Same(t, &related(pod)[0], &related(otherPod)[0])
+
+	node := map[string]any{"apiVersion": "v1", "kind": "Node", "metadata": map[string]any{"name": "n"}}
+	assert.Equal(t, []any{pod, otherPod, service, role}, related(node), "an object of another kind is related to every object")
+}
+
+func TestEnumerateData_CELLibraryRule(t *testing.T) {
+	opap := NewOPAProcessor(cautils.NewOPASessionObjMock(), resources.NewRegoDependenciesDataMock(), "test", "", "", false, nil)
+	objs := []map[string]any{
+		{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{"name": "a", "namespace": "default"}},
+		{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{"name": "b", "namespace": "kube-system"}},
+	}
+	rule := &reporthandling.PolicyRule{
+		PortalBase:         armotypes.PortalBase{Name: "default-only"},
+		RuleLanguage:       reporthandling.CELLanguage,
+		Rule:               "validations: [{expression: 'true'}]",
+		ResourceEnumerator: "object.metadata.namespace == 'default'",
+	}
+
+	selected, err := opap.enumerateData(context.Background(), rule, objs, "C-TEST")
+	require.NoError(t, err)
+	assert.Equal(t, objs[:1], selected)
+
+	rule.ResourceEnumerator = "object.metadata.name"
+	_, err = opap.enumerateData(context.Background(), rule, objs, "C-TEST")
+	assert.ErrorContains(t, err, "expected bool")
+
+	policy := &reporthandling.PolicyRule{
+		PortalBase:         armotypes.PortalBase{Name: "cel-c-0017"},
+		RuleLanguage:       reporthandling.CELLanguage,
+		ResourceEnumerator: "true",
+	}
+	_, err = opap.enumerateData(context.Background(), policy, objs, "C-0017")
+	assert.ErrorContains(t, err, "must not declare a ResourceEnumerator")
+}
+
 // TestCELNamespaceObjectFor covers the resolver behind the evaluator's
 // namespaceObject binding: the scanned resource's Namespace object out of the
 // scan's collected resources, and nil on every path where the scan cannot
@@ -0,0 +1,80 @@
+package opaprocessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/core/pkg/rulecache"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/version"
+)
+
+// OpenRuleCache opens the compiled rule cache under cacheDir. Its generation
+// covers what a compiled rule depends on besides its own source: the OPA
+// version, the builtins registered with it and the shared rule dependencies,
+// so that a change to any of them invalidates every cached rule.
+func OpenRuleCache(ctx context.Context, cacheDir string) (*rulecache.Store, error) {
+	registerOPABuiltins()
+	dependencies, err := getRuleDependencies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule dependencies: %w", err)
+	}
+
+	parts := [][]byte{[]byte(version.Version)}
+	builtins := make([]string, 0, len(ast.Builtins))
+	for _, builtin := range ast.Builtins {
+		builtins = append(builtins, fmt.Sprintf("%s %v", builtin.Name, builtin.Decl))
+	}
+	sort.Strings(builtins)
+	for _, builtin := range builtins {
+		parts = append(parts, []byte(builtin))
+	}
+	names := make([]string, 0, len(dependencies))
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, []byte(name), []byte(dependencies[name]))
+	}
+	return rulecache.Open(cacheDir, rulecache.Generation(parts...))
+}
+
+// WarmRuleCache compiles the Rego rules and resource enumerators of rules into
+// cache, so that the next scan loads them instead of compiling them. It
+// returns how many distinct modules it compiled or found cached (a rule shared
+// by several controls counts once). A rule that fails to compile is logged and
+// skipped, as the scan reports it anyway; a failure to write the cache is
+// returned, since warming it is then pointless.
+func WarmRuleCache(ctx context.Context, cache *rulecache.Store, rules []reporthandling.PolicyRule) (int, error) {
+	registerOPABuiltins()
+	opap := &OPAProcessor{compiledModules: make(map[string]compiledRule), ruleCache: cache}
+
+	seen := make(map[string]bool)
+	var warmed int
+	for _, rule := range rules {
+		if rule.RuleLanguage != reporthandling.RegoLanguage && rule.RuleLanguage != reporthandling.RegoLanguage2 {
+			continue
+		}
+		for _, data := range []string{ruleData(&rule), ruleEnumeratorData(&rule)} {
+			key := rulecache.Key(rule.Name, data, false)
+			if data == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, _, err := opap.getCompiledRule(ctx, rule.Name, data, false); err != nil {
+				logger.L().Ctx(ctx).Warning("failed to compile rule", helpers.String("rule", rule.Name), helpers.Error(err))
+				continue
+			}
+			if opap.ruleCacheErr != nil {
+				return warmed, fmt.Errorf("failed to write the rule cache: %w", opap.ruleCacheErr)
+			}
+			warmed++
+		}
+	}
+	return warmed, nil
+}
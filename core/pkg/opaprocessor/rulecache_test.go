@@ -0,0 +1,119 @@
+package opaprocessor
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ruleCacheTestRule = reporthandling.PolicyRule{
+	PortalBase:   armotypes.PortalBase{Name: "rule-cache-test"},
+	RuleLanguage: reporthandling.RegoLanguage,
+	Rule: `package armo_builtins
+
+deny contains msga if {
+	some pod in input
+	pod.kind == "Pod"
+	some container in pod.spec.containers
+	not container.securityContext.readOnlyRootFilesystem
+	msga := {
+		"alertMessage": sprintf("container %v has a writable root filesystem", [container.name]),
+		"packagename": "armo_builtins",
+		"alertScore": 1,
+		"failedPaths": [],
+		"fixPaths": [],
+		"alertObject": {"k8sApiObjects": [pod]}
+	}
+}
+`,
+}
+
+// countCompilations counts the rules compiled until the test ends.
+func countCompilations(t *testing.T) *atomic.Int32 {
+	var compilations atomic.Int32
+	compileRuleFunc = func(ctx context.Context, ruleName, ruleData string, printEnabled bool) (compiledRule, error) {
+		compilations.Add(1)
+		return compileRule(ctx, ruleName, ruleData, printEnabled)
+	}
+	t.Cleanup(func() { compileRuleFunc = compileRule })
+	return &compilations
+}
+
+// runWithRuleCache evaluates rule the way a scan does, in a new processor
+// using the rule cache under cacheDir, as a new run would.
+func runWithRuleCache(t *testing.T, cacheDir string, rule reporthandling.PolicyRule) []reporthandling.RuleResponse {
+	t.Helper()
+	cache, err := OpenRuleCache(context.Background(), cacheDir)
+	require.NoError(t, err)
+	opap := &OPAProcessor{compiledModules: make(map[string]compiledRule)}
+	opap.SetRuleCache(cache)
+
+	pod := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "p", "namespace": "default"},
+		"spec":       map[string]any{"containers": []any{map[string]any{"name": "app"}}},
+	}
+	responses, _, err := opap.runOPAOnSingleRule(context.Background(), &rule, []map[string]any{pod}, ruleData, resources.RegoDependenciesData{}, "C-0017")
+	require.NoError(t, err)
+	require.Len(t, responses, 1)
+	return responses
+}
+
+func TestRuleCache_SecondRunSkipsCompilation(t *testing.T) {
+	compilations := countCompilations(t)
+	cacheDir := t.TempDir()
+
+	cold := runWithRuleCache(t, cacheDir, ruleCacheTestRule)
+	assert.Equal(t, int32(1), compilations.Load())
+
+	warm := runWithRuleCache(t, cacheDir, ruleCacheTestRule)
+	assert.Equal(t, int32(1), compilations.Load(), "the second run loads the compiled rule")
+	assert.Equal(t, cold, warm)
+
+	changed := ruleCacheTestRule
+	changed.Rule += "\n# changed\n"
+	runWithRuleCache(t, cacheDir, changed)
+	assert.Equal(t, int32(2), compilations.Load(), "a changed rule is compiled again")
+}
+
+func TestWarmRuleCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	cache, err := OpenRuleCache(context.Background(), cacheDir)
+	require.NoError(t, err)
+
+	broken := reporthandling.PolicyRule{PortalBase: armotypes.PortalBase{Name: "broken"}, RuleLanguage: reporthandling.RegoLanguage, Rule: "package armo_builtins\n\ndeny contains"}
+	cel := reporthandling.PolicyRule{PortalBase: armotypes.PortalBase{Name: "cel"}, RuleLanguage: reporthandling.CELLanguage, Rule: "object.spec.hostNetwork == false"}
+	warmed, err := WarmRuleCache(context.Background(), cache, []reporthandling.PolicyRule{ruleCacheTestRule, broken, ruleCacheTestRule, cel})
+	require.NoError(t, err, "a rule that fails to compile is skipped")
+	assert.Equal(t, 1, warmed, "a rule shared by several controls counts once and CEL rules are not compiled by OPA")
+
+	compilations := countCompilations(t)
+	runWithRuleCache(t, cacheDir, ruleCacheTestRule)
+	assert.Zero(t, compilations.Load(), "the scan after warming the cache compiles nothing")
+}
+
+func TestWarmRuleCache_WriteFailure(t *testing.T) {
+	cacheDir := t.TempDir()
+	cache, err := OpenRuleCache(context.Background(), cacheDir)
+	require.NoError(t, err)
+	// The store's directory is gone, so the entry cannot be written.
+	require.NoError(t, os.RemoveAll(cacheDir))
+
+	warmed, err := WarmRuleCache(context.Background(), cache, []reporthandling.PolicyRule{ruleCacheTestRule})
+	assert.ErrorContains(t, err, "failed to write the rule cache")
+	assert.Zero(t, warmed)
+
+	opap := &OPAProcessor{compiledModules: make(map[string]compiledRule)}
+	opap.SetRuleCache(cache)
+	_, _, err = opap.getCompiledRule(context.Background(), ruleCacheTestRule.Name, ruleCacheTestRule.Rule, false)
+	require.NoError(t, err, "a scan still evaluates rules it cannot cache")
+	assert.Error(t, opap.ruleCacheErr)
+}
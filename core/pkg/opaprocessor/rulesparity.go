@@ -0,0 +1,351 @@
+package opaprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor/cel"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+)
+
+// parityMinimizeBudget caps the evaluations spent minimizing one divergent
+// resource. Each one runs every rule of the control on the whole input, so
+// a large resource is left partly minimized rather than stalling the check.
+const parityMinimizeBudget = 200
+
+// ControlParity is how the Rego rules of a control and the control's policy
+// in the embedded CEL bundle agree on a set of resources.
+type ControlParity struct {
+	ControlID  string `json:"controlID"`
+	Name       string `json:"name,omitempty"`
+	PolicyName string `json:"policyName"`
+	// Resources counts the resources either implementation evaluated.
+	Resources   int                `json:"resources"`
+	Divergences []ParityDivergence `json:"divergences,omitempty"`
+}
+
+// ParityVerdict is one implementation's verdict on a resource. An empty
+// Status means the implementation did not evaluate the resource at all.
+type ParityVerdict struct {
+	Status apis.ScanningStatus `json:"status,omitempty"`
+	// Paths are the failed, fix, review and delete paths of the verdict,
+	// sorted. A path into a related resource is prefixed with its ID.
+	Paths []string `json:"paths,omitempty"`
+}
+
+// ParityDivergence is a resource the two implementations disagree on: their
+// verdicts differ, or both fail it on different paths.
+type ParityDivergence struct {
+	ResourceID string        `json:"resourceID"`
+	Rego       ParityVerdict `json:"rego"`
+	CEL        ParityVerdict `json:"cel"`
+	// RegoOnlyPaths and CELOnlyPaths are the paths only one side reported
+	// when both fail the resource.
+	RegoOnlyPaths []string `json:"regoOnlyPaths,omitempty"`
+	CELOnlyPaths  []string `json:"celOnlyPaths,omitempty"`
+	// Resource is the smallest version of the resource found to reproduce
+	// the same divergence. Minimized is false when none of its fields could
+	// be dropped.
+	Resource  map[string]any `json:"resource,omitempty"`
+	Minimized bool           `json:"minimized"`
+	// Source is the file the resource was loaded from, filled in by callers
+	// that know it.
+	Source string `json:"source,omitempty"`
+}
+
+// CheckParity evaluates the Rego rules of control and the control's policy in
+// the embedded CEL bundle on the session's resources, and reports the
+// resources they disagree on, each minimized to the fields the disagreement
+// depends on. With ignorePaths, only verdicts are compared.
+//
+// The CEL side matches what the Rego rules match, so both see the same
+// resources; a resource only one side evaluated is a divergence too.
+func (opap *OPAProcessor) CheckParity(ctx context.Context, control *reporthandling.Control, ignorePaths bool) (ControlParity, error) {
+	parity := ControlParity{ControlID: control.ControlID, Name: control.Name}
+
+	regoControl, celControl, err := parityControls(control)
+	if err != nil {
+		return parity, err
+	}
+	parity.PolicyName = celControl.Rules[0].Name
+
+	regoVerdicts, celVerdicts, err := opap.parityVerdicts(ctx, &regoControl, &celControl)
+	if err != nil {
+		return parity, err
+	}
+	resourceIDs := slices.Sorted(maps.Keys(regoVerdicts))
+	for id := range celVerdicts {
+		if _, ok := regoVerdicts[id]; !ok {
+			resourceIDs = append(resourceIDs, id)
+		}
+	}
+	slices.Sort(resourceIDs)
+	parity.Resources = len(resourceIDs)
+
+	for _, id := range resourceIDs {
+		divergence, diverges := compareParityVerdicts(id, regoVerdicts[id], celVerdicts[id], ignorePaths)
+		if !diverges {
+			continue
+		}
+		divergence.Resource, divergence.Minimized = opap.minimizeDivergence(ctx, &regoControl, &celControl, divergence, ignorePaths)
+		parity.Divergences = append(parity.Divergences, divergence)
+	}
+	return parity, nil
+}
+
+// parityControls splits control into the control as its Rego rules implement
+// it, and as the CEL bundle does: a single rule named after the bundle
+// policy, matching the union of what the Rego rules match.
+func parityControls(control *reporthandling.Control) (regoControl, celControl reporthandling.Control, err error) {
+	policyName, err := cel.PolicyNameForControl(control.ControlID)
+	if err != nil {
+		return regoControl, celControl, err
+	}
+
+	celRule := reporthandling.PolicyRule{
+		PortalBase:   armotypes.PortalBase{Name: policyName},
+		RuleLanguage: reporthandling.CELLanguage,
+	}
+	regoControl = *control
+	regoControl.Rules = nil
+	for _, rule := range control.Rules {
+		if rule.RuleLanguage != reporthandling.RegoLanguage && rule.RuleLanguage != reporthandling.RegoLanguage2 {
+			continue
+		}
+		regoControl.Rules = append(regoControl.Rules, rule)
+		for _, match := range rule.Match {
+			if !slices.ContainsFunc(celRule.Match, func(m reporthandling.RuleMatchObjects) bool { return reflect.DeepEqual(m, match) }) {
+				celRule.Match = append(celRule.Match, match)
+			}
+		}
+	}
+	if len(regoControl.Rules) == 0 {
+		return regoControl, celControl, fmt.Errorf("control %s has no Rego rules to compare with its CEL policy", control.ControlID)
+	}
+
+	celControl = *control
+	celControl.Rules = []reporthandling.PolicyRule{celRule}
+	return regoControl, celControl, nil
+}
+
+// parityVerdicts evaluates both implementations of a control on the session's
+// resources.
+func (opap *OPAProcessor) parityVerdicts(ctx context.Context, regoControl, celControl *reporthandling.Control) (regoVerdicts, celVerdicts map[string]ParityVerdict, err error) {
+	if regoVerdicts, err = opap.controlVerdicts(ctx, regoControl); err != nil {
+		return nil, nil, fmt.Errorf("evaluate the Rego rules of %s: %w", regoControl.ControlID, err)
+	}
+	if celVerdicts, err = opap.controlVerdicts(ctx, celControl); err != nil {
+		return nil, nil, fmt.Errorf("evaluate the CEL policy of %s: %w", celControl.ControlID, err)
+	}
+	return regoVerdicts, celVerdicts, nil
+}
+
+// controlVerdicts evaluates the rules of control on every resource and folds
+// their results into one verdict per resource, as processControl does: a
+// resource any rule fails, fails.
+func (opap *OPAProcessor) controlVerdicts(ctx context.Context, control *reporthandling.Control) (map[string]ParityVerdict, error) {
+	merged := make(map[string]*resourcesresults.ResourceAssociatedRule)
+	var evalErrs []error
+	for i := range control.Rules {
+		results, err := opap.processRule(ctx, &control.Rules[i], control.FixedInput, evaluationScope{}, control)
+		if err != nil {
+			evalErrs = append(evalErrs, err)
+		}
+		for resourceID, result := range results {
+			merged[resourceID] = mergeAssociatedRule(merged[resourceID], result)
+		}
+	}
+
+	verdicts := make(map[string]ParityVerdict, len(merged))
+	for resourceID, result := range merged {
+		verdicts[resourceID] = ParityVerdict{
+			Status: result.GetStatus(nil).Status(),
+			Paths:  parityPaths(resourceID, result.Paths),
+		}
+	}
+	return verdicts, errors.Join(evalErrs...)
+}
+
+// parityPaths flattens the paths of a verdict for comparison. The kind of a
+// path is left out: a Rego rule reporting a failed path and a CEL policy
+// reporting a fix path at the same place agree on what is wrong.
+func parityPaths(resourceID string, paths []armotypes.PosturePaths) []string {
+	var flat []string
+	for _, p := range paths {
+		var path string
+		switch {
+		case p.FailedPath != "":
+			path = p.FailedPath
+		case p.FixPath.Path != "":
+			path = p.FixPath.Path
+		case p.ReviewPath != "":
+			path = p.ReviewPath
+		case p.DeletePath != "":
+			path = p.DeletePath
+		default:
+			continue
+		}
+		if p.ResourceID != "" && p.ResourceID != resourceID {
+			path = p.ResourceID + ":" + path
+		}
+		flat = append(flat, path)
+	}
+	slices.Sort(flat)
+	return slices.Compact(flat)
+}
+
+// compareParityVerdicts reports whether two verdicts on a resource disagree.
+func compareParityVerdicts(resourceID string, rego, celVerdict ParityVerdict, ignorePaths bool) (ParityDivergence, bool) {
+	if ignorePaths {
+		rego.Paths, celVerdict.Paths = nil, nil
+	}
+	divergence := ParityDivergence{ResourceID: resourceID, Rego: rego, CEL: celVerdict}
+	if rego.Status != celVerdict.Status {
+		return divergence, true
+	}
+	if rego.Status != apis.StatusFailed || slices.Equal(rego.Paths, celVerdict.Paths) {
+		return divergence, false
+	}
+	for _, path := range rego.Paths {
+		if !slices.Contains(celVerdict.Paths, path) {
+			divergence.RegoOnlyPaths = append(divergence.RegoOnlyPaths, path)
+		}
+	}
+	for _, path := range celVerdict.Paths {
+		if !slices.Contains(rego.Paths, path) {
+			divergence.CELOnlyPaths = append(divergence.CELOnlyPaths, path)
+		}
+	}
+	return divergence, true
+}
+
+// minimizeDivergence greedily drops the fields and list elements of the
+// divergent resource for as long as both implementations keep reaching the
+// same verdicts on it, re-evaluating the control with the reduced resource
+// in place of the original. The resource's identity (apiVersion, kind, name
+// and namespace) is always kept, so it stays the same resource to the
+// rules' matching and aggregation.
+func (opap *OPAProcessor) minimizeDivergence(ctx context.Context, regoControl, celControl *reporthandling.Control, divergence ParityDivergence, ignorePaths bool) (map[string]any, bool) {
+	original, ok := opap.AllResources[divergence.ResourceID]
+	if !ok {
+		return nil, false
+	}
+	defer func() { opap.AllResources[divergence.ResourceID] = original }()
+
+	root, err := copyObject(original.GetObject())
+	if err != nil {
+		return original.GetObject(), false
+	}
+	m := &objectMinimizer{
+		root:   root,
+		budget: parityMinimizeBudget,
+		reproduces: func(candidate map[string]any) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			object, err := copyObject(candidate)
+			if err != nil {
+				return false
+			}
+			opap.AllResources[divergence.ResourceID] = workloadinterface.NewWorkloadObj(object)
+			regoVerdicts, celVerdicts, err := opap.parityVerdicts(ctx, regoControl, celControl)
+			if err != nil {
+				return false
+			}
+			reduced, diverges := compareParityVerdicts(divergence.ResourceID, regoVerdicts[divergence.ResourceID], celVerdicts[divergence.ResourceID], ignorePaths)
+			return diverges && reflect.DeepEqual(reduced.Rego, divergence.Rego) && reflect.DeepEqual(reduced.CEL, divergence.CEL)
+		},
+	}
+	m.reduce(m.root, nil, nil)
+	return m.root, m.dropped > 0
+}
+
+// objectMinimizer removes what it can from an object while reproduces holds.
+type objectMinimizer struct {
+	root       map[string]any
+	budget     int
+	dropped    int
+	reproduces func(map[string]any) bool
+}
+
+// try reports whether the object as it now stands still reproduces, within
+// the evaluation budget.
+func (m *objectMinimizer) try() bool {
+	if m.budget <= 0 {
+		return false
+	}
+	m.budget--
+	if !m.reproduces(m.root) {
+		return false
+	}
+	m.dropped++
+	return true
+}
+
+// reduce minimizes value, found at path in the object. set replaces value
+// where it is held, which dropping a list element needs.
+func (m *objectMinimizer) reduce(value any, path []string, set func(any)) {
+	switch node := value.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(node)) {
+			child := node[key]
+			childPath := append(slices.Clone(path), key)
+			if !isIdentityField(childPath) {
+				delete(node, key)
+				if m.try() {
+					continue
+				}
+				node[key] = child
+			}
+			m.reduce(child, childPath, func(v any) { node[key] = v })
+		}
+	case []any:
+		for i := 0; i < len(node); {
+			candidate := slices.Delete(slices.Clone(node), i, i+1)
+			set(candidate)
+			if m.try() {
+				node = candidate
+				continue
+			}
+			set(node)
+			i++
+		}
+		for i := range node {
+			m.reduce(node[i], append(slices.Clone(path), "[]"), func(v any) { node[i] = v })
+		}
+	}
+}
+
+// isIdentityField reports whether path is a field the resource's ID is made
+// of, or the metadata holding them.
+func isIdentityField(path []string) bool {
+	switch len(path) {
+	case 1:
+		return path[0] == "apiVersion" || path[0] == "kind" || path[0] == "metadata"
+	case 2:
+		return path[0] == "metadata" && (path[1] == "name" || path[1] == "namespace")
+	}
+	return false
+}
+
+// copyObject deep-copies a decoded Kubernetes object.
+func copyObject(obj map[string]any) (map[string]any, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
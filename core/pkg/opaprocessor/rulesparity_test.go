@@ -0,0 +1,221 @@
+package opaprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// securityContextRule is a Rego C-0017 that only fails containers with no
+// securityContext at all, so it passes a container the CEL policy fails for
+// not setting readOnlyRootFilesystem.
+const securityContextRule = `package armo_builtins
+
+deny[msga] {
+    pod := input[_]
+    pod.kind == "Pod"
+    container := pod.spec.containers[i]
+    not container.securityContext
+    msga := {
+        "alertMessage": "container has no security context",
+        "packagename":  "armo_builtins",
+        "alertScore":   7,
+        "fixPaths":     [{"path": sprintf("spec.containers[%v].securityContext.readOnlyRootFilesystem", [i]), "value": "true"}],
+        "failedPaths":  [],
+        "alertObject":  {"k8sApiObjects": [pod]},
+    }
+}
+`
+
+func parityControl() reporthandling.Control {
+	control := reporthandling.Control{
+		ControlID: "C-0017",
+		Rules: []reporthandling.PolicyRule{{
+			PortalBase:   armotypes.PortalBase{Name: "immutable-container-filesystem"},
+			Rule:         securityContextRule,
+			RuleLanguage: reporthandling.RegoLanguage,
+			Match: []reporthandling.RuleMatchObjects{{
+				APIGroups:   []string{""},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"Pod"},
+			}},
+		}},
+	}
+	control.Name = "Immutable container filesystem"
+	return control
+}
+
+func newRulesParityProcessor(pods ...map[string]any) *OPAProcessor {
+	sessionObj := cautils.NewOPASessionObjMock()
+	sessionObj.K8SResources = cautils.K8SResources{}
+	sessionObj.AllResources = map[string]workloadinterface.IMetadata{}
+	for _, pod := range pods {
+		workload := workloadinterface.NewWorkloadObj(pod)
+		sessionObj.AllResources[workload.GetID()] = workload
+		sessionObj.K8SResources["/v1/pods"] = append(sessionObj.K8SResources["/v1/pods"], workload.GetID())
+	}
+	return NewOPAProcessor(sessionObj, resources.NewRegoDependenciesDataMock(), "test", "", "", false, nil)
+}
+
+func parityPod(name string, container map[string]any) map[string]any {
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": "default",
+			"labels":    map[string]any{"app": name},
+		},
+		"spec": map[string]any{"containers": []any{container}},
+	}
+}
+
+func TestCheckParity(t *testing.T) {
+	readOnly := parityPod("readonly", map[string]any{"name": "c", "image": "nginx", "securityContext": map[string]any{"readOnlyRootFilesystem": true}})
+	noContext := parityPod("nocontext", map[string]any{"name": "c", "image": "nginx"})
+	mutable := parityPod("mutable", map[string]any{"name": "c", "image": "nginx", "securityContext": map[string]any{"runAsUser": 1000}})
+	opap := newRulesParityProcessor(readOnly, noContext, mutable)
+	control := parityControl()
+
+	parity, err := opap.CheckParity(context.Background(), &control, false)
+	require.NoError(t, err)
+	assert.Equal(t, "C-0017", parity.ControlID)
+	assert.Equal(t, "kubescape-c-0017-deny-resources-with-mutable-container-filesystem", parity.PolicyName)
+	assert.Equal(t, 3, parity.Resources)
+
+	require.Len(t, parity.Divergences, 1, "the implementations agree on the read-only pod and the pod with no security context")
+	divergence := parity.Divergences[0]
+	assert.Equal(t, "/v1/default/Pod/mutable", divergence.ResourceID)
+	assert.Equal(t, ParityVerdict{Status: apis.StatusPassed}, divergence.Rego)
+	assert.Equal(t, ParityVerdict{Status: apis.StatusFailed, Paths: []string{"spec.containers[0].securityContext.readOnlyRootFilesystem"}}, divergence.CEL)
+
+	assert.True(t, divergence.Minimized)
+	assert.Equal(t, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "mutable", "namespace": "default"},
+		"spec":       map[string]any{"containers": []any{map[string]any{"securityContext": map[string]any{}}}},
+	}, divergence.Resource, "the divergence only depends on the container having a security context")
+	assert.Contains(t, opap.AllResources[divergence.ResourceID].GetObject()["metadata"], "labels", "the original resource is restored")
+}
+
+func TestParityControls(t *testing.T) {
+	control := parityControl()
+	control.Rules = append(control.Rules,
+		reporthandling.PolicyRule{
+			PortalBase:   armotypes.PortalBase{Name: "workloads"},
+			RuleLanguage: reporthandling.RegoLanguage,
+			Match: []reporthandling.RuleMatchObjects{
+				{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"Pod"}},
+				{APIGroups: []string{"apps"}, APIVersions: []string{"v1"}, Resources: []string{"Deployment"}},
+			},
+		},
+		reporthandling.PolicyRule{PortalBase: armotypes.PortalBase{Name: "cel"}, RuleLanguage: reporthandling.CELLanguage},
+	)
+
+	regoControl, celControl, err := parityControls(&control)
+	require.NoError(t, err)
+	require.Len(t, regoControl.Rules, 2, "CEL rules of the library are not part of the Rego side")
+	require.Len(t, celControl.Rules, 1)
+	assert.Equal(t, "kubescape-c-0017-deny-resources-with-mutable-container-filesystem", celControl.Rules[0].Name)
+	assert.Equal(t, reporthandling.CELLanguage, celControl.Rules[0].RuleLanguage)
+	assert.Empty(t, celControl.Rules[0].Rule, "the rule is looked up in the bundle")
+	assert.Len(t, celControl.Rules[0].Match, 2, "the Rego rules' match is merged")
+
+	control.Rules = control.Rules[2:]
+	_, _, err = parityControls(&control)
+	assert.ErrorContains(t, err, "has no Rego rules")
+
+	control = parityControl()
+	control.ControlID = "C-9999"
+	_, _, err = parityControls(&control)
+	assert.Error(t, err)
+}
+
+func TestCompareParityVerdicts(t *testing.T) {
+	failed := func(paths ...string) ParityVerdict { return ParityVerdict{Status: apis.StatusFailed, Paths: paths} }
+
+	tests := []struct {
+		name          string
+		rego, cel     ParityVerdict
+		ignorePaths   bool
+		wantDiverges  bool
+		wantRegoPaths []string
+		wantCELPaths  []string
+	}{
+		{name: "both pass", rego: ParityVerdict{Status: apis.StatusPassed}, cel: ParityVerdict{Status: apis.StatusPassed}},
+		{name: "same paths", rego: failed("a", "b"), cel: failed("a", "b")},
+		{name: "different verdicts", rego: ParityVerdict{Status: apis.StatusPassed}, cel: failed("a"), wantDiverges: true},
+		{name: "only one side evaluated", rego: failed("a"), wantDiverges: true},
+		{name: "different paths", rego: failed("a", "b"), cel: failed("b", "c"), wantDiverges: true, wantRegoPaths: []string{"a"}, wantCELPaths: []string{"c"}},
+		{name: "different paths ignored", rego: failed("a"), cel: failed("c"), ignorePaths: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			divergence, diverges := compareParityVerdicts("id", tt.rego, tt.cel, tt.ignorePaths)
+			assert.Equal(t, tt.wantDiverges, diverges)
+			assert.Equal(t, tt.wantRegoPaths, divergence.RegoOnlyPaths)
+			assert.Equal(t, tt.wantCELPaths, divergence.CELOnlyPaths)
+		})
+	}
+}
+
+func TestParityPaths(t *testing.T) {
+	paths := parityPaths("/v1/default/Pod/web", []armotypes.PosturePaths{
+		{ResourceID: "/v1/default/Pod/web", FixPath: armotypes.FixPath{Path: "spec.b", Value: "true"}},
+		{ResourceID: "/v1/default/Pod/web", FailedPath: "spec.b"},
+		{ReviewPath: "spec.a"},
+		{ResourceID: "/v1/default/Service/web", DeletePath: "spec.type"},
+		{FixCommand: "kubectl label pod web app=web"},
+	})
+	assert.Equal(t, []string{"/v1/default/Service/web:spec.type", "spec.a", "spec.b"}, paths)
+}
+
+func TestObjectMinimizer(t *testing.T) {
+	root := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "web", "labels": map[string]any{"app": "web"}},
+		"spec": map[string]any{
+			"containers": []any{
+				map[string]any{"name": "a", "image": "nginx"},
+				map[string]any{"name": "b", "image": "redis", "privileged": true},
+			},
+		},
+	}
+	m := &objectMinimizer{
+		root:   root,
+		budget: 100,
+		reproduces: func(obj map[string]any) bool {
+			spec, _ := obj["spec"].(map[string]any)
+			containers, _ := spec["containers"].([]any)
+			for _, c := range containers {
+				if c.(map[string]any)["privileged"] == true {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	m.reduce(m.root, nil, nil)
+
+	assert.Equal(t, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "web"},
+		"spec":       map[string]any{"containers": []any{map[string]any{"privileged": true}}},
+	}, m.root)
+	assert.Positive(t, m.dropped)
+
+	m = &objectMinimizer{root: map[string]any{"kind": "Pod", "spec": map[string]any{}}, budget: 0, reproduces: func(map[string]any) bool { return true }}
+	m.reduce(m.root, nil, nil)
+	assert.Equal(t, map[string]any{"kind": "Pod", "spec": map[string]any{}}, m.root, "no evaluations are left in the budget")
+}
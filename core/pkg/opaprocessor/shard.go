@@ -0,0 +1,524 @@
+package opaprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/opa-utils/objectsenvelopes"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/kubescape/opa-utils/resources"
+)
+
+// Distributed evaluation.
+//
+// A coordinator that collected the resources of a scan can hand the control
+// evaluation to worker processes instead of running it itself. The work is cut
+// into shards: a shard is a group of controls to evaluate on one evaluation
+// scope — the resident scope, a namespace evaluated together with the resident
+// scope, or, for whole-cluster controls, every resource of the scan. A shard
+// carries everything its evaluation reads, so a worker is stateless and any
+// worker can evaluate any shard.
+//
+// The scopes are the ones Process evaluates, so a sharded scan reaches the
+// verdicts a local scan does: the coordinator merges the per-shard results
+// with the same rules that merge the per-scope results of a local scan (see
+// resultmerge.go), then runs the exceptions and scoring as usual.
+
+// Shard is a group of controls to evaluate on one evaluation scope.
+type Shard struct {
+	// ID names the shard in logs and errors.
+	ID string `json:"id"`
+	// Scope is the name of the evaluation scope.
+	Scope    string                   `json:"scope"`
+	Controls []reporthandling.Control `json:"controls"`
+	// Resident is the resident batch every scope is evaluated with.
+	Resident ShardResources `json:"resident"`
+	// Batch is the scope's own batch, nil for the resident scope.
+	Batch *ShardResources `json:"batch,omitempty"`
+
+	PostureControlInputs map[string][]string         `json:"postureControlInputs,omitempty"`
+	DataControlInputs    map[string]string           `json:"dataControlInputs,omitempty"`
+	ControlInputsScopes  []getter.ControlInputsScope `json:"controlInputsScopes,omitempty"`
+	CloudProvider        string                      `json:"cloudProvider,omitempty"`
+	ExcludeNamespaces    string                      `json:"excludeNamespaces,omitempty"`
+	IncludeNamespaces    string                      `json:"includeNamespaces,omitempty"`
+	ControlTimeout       time.Duration               `json:"controlTimeout,omitempty"`
+	ResourceToControls   map[string][]string         `json:"resourceToControls,omitempty"`
+	CollectionFailures   map[string]apis.StatusInfo  `json:"collectionFailures,omitempty"` // the InfoMap entries of the resource types that failed to collect
+}
+
+// ShardResources is a batch of resources, by ID.
+type ShardResources struct {
+	K8SResources      cautils.K8SResources      `json:"k8sResources,omitempty"`
+	ExternalResources cautils.ExternalResources `json:"externalResources,omitempty"`
+	Objects           map[string]map[string]any `json:"objects,omitempty"`
+}
+
+// ShardResult is what evaluating a shard adds to the scan.
+type ShardResult struct {
+	ID      string                             `json:"id"`
+	Results map[string]resourcesresults.Result `json:"results,omitempty"`
+	// Resources are the resources the rules' aggregators produced.
+	Resources map[string]map[string]any `json:"resources,omitempty"`
+	// InfoMap holds the resources the evaluation skipped, with why.
+	InfoMap          map[string]apis.StatusInfo `json:"infoMap,omitempty"`
+	TimedOutControls map[string]string          `json:"timedOutControls,omitempty"`
+	// Error is why the evaluation of some controls failed, their results
+	// being partial.
+	Error string `json:"error,omitempty"`
+}
+
+// ShardEvaluator evaluates shards, typically on remote workers. It returns a
+// nil result when the shard could not be evaluated at all, and a result along
+// with an error when the evaluation of some controls failed. It returns an
+// error wrapping ErrShardTooLarge for a shard the workers refuse for its size,
+// which the coordinator then splits.
+type ShardEvaluator interface {
+	EvaluateShard(ctx context.Context, shard *Shard) (*ShardResult, error)
+}
+
+// ErrShardTooLarge reports a shard exceeding the size workers accept.
+var ErrShardTooLarge = errors.New("the shard exceeds the size the workers accept")
+
+// SetShardEvaluator distributes the evaluation of Process to evaluator,
+// keeping up to parallelism shards in flight. A shard the evaluator cannot
+// evaluate at all fails Process, unless localFallback is set, in which case
+// it is evaluated locally. The incremental cache is not consulted for
+// distributed evaluation.
+func (opap *OPAProcessor) SetShardEvaluator(evaluator ShardEvaluator, parallelism int, localFallback bool) {
+	opap.shardEvaluator = evaluator
+	opap.shardParallelism = max(parallelism, 1)
+	opap.shardLocalFallback = localFallback
+}
+
+// EvaluateShard evaluates the controls of shard on its scope. It is what a
+// worker runs for every shard it is sent. The returned error is set when the
+// evaluation of some controls failed, the result holding the rest.
+func EvaluateShard(ctx context.Context, shard *Shard) (*ShardResult, error) {
+	residentBatch := shard.Resident.batch(cautils.ClusterScope)
+	sessionObj := &cautils.OPASessionObj{
+		K8SResources:          maps.Clone(residentBatch.K8SResources),
+		ExternalResources:     residentBatch.ExternalResources,
+		AllResources:          maps.Clone(residentBatch.AllResources),
+		ResourcesResult:       make(map[string]resourcesresults.Result),
+		InfoMap:               maps.Clone(shard.CollectionFailures),
+		ResourceToControlsMap: shard.ResourceToControls,
+		ControlInputsScopes:   shard.ControlInputsScopes,
+		RegoInputData: cautils.RegoInputData{
+			PostureControlInputs: shard.PostureControlInputs,
+			DataControlInputs:    shard.DataControlInputs,
+		},
+		Report: &reporthandlingv2.PostureReport{ClusterCloudProvider: shard.CloudProvider},
+	}
+	if sessionObj.InfoMap == nil {
+		sessionObj.InfoMap = make(map[string]apis.StatusInfo)
+	}
+	var batch *cautils.ResourceBatch
+	if shard.Batch != nil {
+		batch = shard.Batch.batch(shard.Scope)
+		for gvr, ids := range batch.K8SResources {
+			sessionObj.K8SResources[gvr] = slices.Concat(sessionObj.K8SResources[gvr], ids)
+		}
+		maps.Copy(sessionObj.AllResources, batch.AllResources)
+	}
+	inputs := len(sessionObj.AllResources)
+
+	opap := NewOPAProcessor(sessionObj, &resources.RegoDependenciesData{}, "", shard.ExcludeNamespaces, shard.IncludeNamespaces, false, nil)
+	opap.ControlTimeout = shard.ControlTimeout
+	opap.AllPolicies = &cautils.Policies{Controls: make(map[string]reporthandling.Control, len(shard.Controls))}
+	controlIDs := make([]string, 0, len(shard.Controls))
+	for _, control := range shard.Controls {
+		opap.AllPolicies.Controls[control.ControlID] = control
+		controlIDs = append(controlIDs, control.ControlID)
+	}
+
+	scope := newEvaluationScope(shard.Scope, batch, newResidentIndex(residentBatch))
+	evalErr := opap.processScope(ctx, opap.AllPolicies, controlIDs, scope, nil)
+
+	result := &ShardResult{
+		ID:               shard.ID,
+		Results:          opap.ResourcesResult,
+		TimedOutControls: opap.TimedOutControls,
+	}
+	if len(opap.AllResources) > inputs {
+		result.Resources = make(map[string]map[string]any, len(opap.AllResources)-inputs)
+		for id, resource := range opap.AllResources {
+			if !shard.Resident.has(id) && (shard.Batch == nil || !shard.Batch.has(id)) {
+				result.Resources[id] = resource.GetObject()
+			}
+		}
+	}
+	for id, info := range opap.InfoMap {
+		if _, ok := shard.CollectionFailures[id]; !ok {
+			if result.InfoMap == nil {
+				result.InfoMap = make(map[string]apis.StatusInfo)
+			}
+			result.InfoMap[id] = info
+		}
+	}
+	if evalErr != nil {
+		result.Error = evalErr.Error()
+	}
+	return result, evalErr
+}
+
+// processSharded is Process for a processor with a shard evaluator: it
+// evaluates every shard of the scan on the evaluator, then merges the results
+// in shard order.
+func (opap *OPAProcessor) processSharded(ctx context.Context, policies *cautils.Policies, progressListener IJobProgressNotificationClient) error {
+	shards := opap.planShards(policies)
+	logger.L().Debug("distributing the evaluation", helpers.Int("shards", len(shards)), helpers.Int("parallelism", opap.shardParallelism))
+
+	if progressListener != nil {
+		progressListener.Start(len(shards))
+		defer progressListener.Stop()
+	}
+
+	results := make([]*ShardResult, len(shards))
+	errs := make([]error, len(shards))
+	shardChan := make(chan int, len(shards))
+	for i := range shards {
+		shardChan <- i
+	}
+	close(shardChan)
+
+	var wg sync.WaitGroup
+	for range min(opap.shardParallelism, len(shards)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range shardChan {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i], errs[i] = opap.evaluateShard(ctx, shards[i])
+				if progressListener != nil {
+					opap.mu.Lock()
+					progressListener.ProgressJob(1, fmt.Sprintf("Shard: %s", shards[i].ID))
+					opap.mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var processErrs []error
+	for i, shard := range shards {
+		if results[i] != nil {
+			opap.mergeShardResult(results[i])
+		}
+		if errs[i] != nil {
+			processErrs = append(processErrs, fmt.Errorf("shard %q: %w", shard.ID, errs[i]))
+		}
+	}
+	return errors.Join(processErrs...)
+}
+
+// evaluateShard evaluates shard on the shard evaluator. A shard too large for
+// the workers is split in two, down to a single control. When no worker could
+// evaluate it, it is evaluated locally if the local fallback is enabled.
+func (opap *OPAProcessor) evaluateShard(ctx context.Context, shard *Shard) (*ShardResult, error) {
+	result, err := opap.shardEvaluator.EvaluateShard(ctx, shard)
+	if result == nil && errors.Is(err, ErrShardTooLarge) && len(shard.Controls) > 1 {
+		logger.L().Ctx(ctx).Debug("splitting a shard too large for the workers", helpers.String("shard", shard.ID), helpers.Int("controls", len(shard.Controls)))
+		return opap.evaluateSplitShard(ctx, shard)
+	}
+	if result == nil && ctx.Err() == nil && opap.shardLocalFallback {
+		logger.L().Ctx(ctx).Warning("failed to evaluate a shard on the workers, evaluating it locally", helpers.String("shard", shard.ID), helpers.Error(err))
+		return EvaluateShard(ctx, shard)
+	}
+	return result, err
+}
+
+// evaluateSplitShard evaluates the halves of shard and combines their results
+// into the result of shard. The halves hold different controls, so a
+// resource's results only need their controls appended.
+func (opap *OPAProcessor) evaluateSplitShard(ctx context.Context, shard *Shard) (*ShardResult, error) {
+	combined := &ShardResult{ID: shard.ID}
+	evaluated := false
+	var errs []error
+	for _, half := range shard.split() {
+		result, err := opap.evaluateShard(ctx, half)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shard %q: %w", half.ID, err))
+		}
+		if result == nil {
+			continue
+		}
+		evaluated = true
+		for id, incoming := range result.Results {
+			if combined.Results == nil {
+				combined.Results = make(map[string]resourcesresults.Result)
+			}
+			t, ok := combined.Results[id]
+			if !ok {
+				t = resourcesresults.Result{ResourceID: id}
+			}
+			t.AssociatedControls = append(t.AssociatedControls, incoming.AssociatedControls...)
+			combined.Results[id] = t
+		}
+		combined.Resources = mergeMaps(combined.Resources, result.Resources)
+		combined.InfoMap = mergeMaps(combined.InfoMap, result.InfoMap)
+		combined.TimedOutControls = mergeMaps(combined.TimedOutControls, result.TimedOutControls)
+	}
+	// Like a shard evaluated whole, a half no worker evaluated fails the shard
+	// while the other half's results are kept.
+	err := errors.Join(errs...)
+	if !evaluated {
+		return nil, err
+	}
+	if err != nil {
+		combined.Error = err.Error()
+	}
+	return combined, err
+}
+
+// split halves the controls of a shard too large for the workers. Each half
+// carries only the resources its rules can match in the shard's scope, which
+// is all their evaluation reads (see evaluationScope.matchedObjects).
+func (s *Shard) split() []*Shard {
+	half := len(s.Controls) / 2
+	halves := make([]*Shard, 0, 2)
+	for i, controls := range [][]reporthandling.Control{s.Controls[:half], s.Controls[half:]} {
+		part := *s
+		part.ID = fmt.Sprintf("%s.%d", s.ID, i)
+		part.Controls = controls
+		part.Resident = s.Resident.matching(controls)
+		if s.Batch != nil {
+			batch := s.Batch.matching(controls)
+			part.Batch = &batch
+		}
+		halves = append(halves, &part)
+	}
+	return halves
+}
+
+func mergeMaps[V any](dst, src map[string]V) map[string]V {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]V, len(src))
+	}
+	maps.Copy(dst, src)
+	return dst
+}
+
+// planShards cuts the evaluation Process would run into shards: the controls
+// evaluated per scope are split into groups, each group evaluated on every
+// scope, and the whole-cluster controls into groups evaluated on every
+// resource. Controls are split so that there are at least as many shards as
+// shards in flight when there are enough controls, which matters on the
+// clusters small enough to be a single scope.
+func (opap *OPAProcessor) planShards(policies *cautils.Policies) []*Shard {
+	resident, batches := cautils.PartitionResources(opap.initialResourceCount, opap.K8SResources, opap.ExternalResources, opap.AllResources, opap.largeClusterSizeThreshold)
+	scopeControlIDs, wholeClusterControlIDs := splitWholeClusterControls(policies, sortedControlIDs(policies))
+
+	residentResources := newShardResources(resident)
+	scopeGroups := splitControlIDs(scopeControlIDs, (opap.shardParallelism+len(batches))/(len(batches)+1))
+
+	var shards []*Shard
+	for i, group := range scopeGroups {
+		shards = append(shards, opap.newShard(fmt.Sprintf("%s/%d", resident.Scope, i), resident.Scope, policies, group, residentResources, nil))
+	}
+	for _, batch := range batches {
+		batchResources := newShardResources(batch)
+		for i, group := range scopeGroups {
+			shards = append(shards, opap.newShard(fmt.Sprintf("%s/%d", batch.Scope, i), batch.Scope, policies, group, residentResources, &batchResources))
+		}
+	}
+
+	if len(wholeClusterControlIDs) > 0 {
+		everything := newShardResources(&cautils.ResourceBatch{
+			K8SResources:      opap.K8SResources,
+			ExternalResources: opap.ExternalResources,
+			AllResources:      opap.AllResources,
+		})
+		for i, group := range splitControlIDs(wholeClusterControlIDs, opap.shardParallelism) {
+			shards = append(shards, opap.newShard(fmt.Sprintf("wholeCluster/%d", i), cautils.ClusterScope, policies, group, everything, nil))
+		}
+	}
+	return shards
+}
+
+func (opap *OPAProcessor) newShard(id, scope string, policies *cautils.Policies, controlIDs []string, resident ShardResources, batch *ShardResources) *Shard {
+	shard := &Shard{
+		ID:                   id,
+		Scope:                scope,
+		Controls:             make([]reporthandling.Control, 0, len(controlIDs)),
+		Resident:             resident,
+		Batch:                batch,
+		PostureControlInputs: opap.RegoInputData.PostureControlInputs,
+		DataControlInputs:    opap.RegoInputData.DataControlInputs,
+		ControlInputsScopes:  opap.ControlInputsScopes,
+		CloudProvider:        opap.Report.ClusterCloudProvider,
+		ExcludeNamespaces:    joinNamespaces(opap.excludeNamespaces),
+		IncludeNamespaces:    joinNamespaces(opap.includeNamespaces),
+		ControlTimeout:       opap.ControlTimeout,
+		ResourceToControls:   opap.ResourceToControlsMap,
+	}
+	for _, controlID := range controlIDs {
+		shard.Controls = append(shard.Controls, policies.Controls[controlID])
+	}
+	for gvr := range opap.ResourceToControlsMap {
+		if info, ok := opap.InfoMap[gvr]; ok {
+			if shard.CollectionFailures == nil {
+				shard.CollectionFailures = make(map[string]apis.StatusInfo)
+			}
+			shard.CollectionFailures[gvr] = info
+		}
+	}
+	return shard
+}
+
+// mergeShardResult merges the results of a shard into the scan.
+func (opap *OPAProcessor) mergeShardResult(result *ShardResult) {
+	opap.mu.Lock()
+	defer opap.mu.Unlock()
+
+	for id, object := range result.Resources {
+		if _, ok := opap.AllResources[id]; !ok {
+			opap.AllResources[id] = newShardObject(object)
+		}
+	}
+	for resourceID, incoming := range result.Results {
+		t, ok := opap.ResourcesResult[resourceID]
+		if !ok {
+			t = resourcesresults.Result{ResourceID: resourceID}
+		}
+		for _, control := range incoming.AssociatedControls {
+			t.AssociatedControls = mergeAssociatedControls(t.AssociatedControls, control, opap.AllPolicies)
+		}
+		opap.ResourcesResult[resourceID] = t
+	}
+	if opap.InfoMap != nil {
+		maps.Copy(opap.InfoMap, result.InfoMap)
+	}
+	if len(result.TimedOutControls) > 0 {
+		if opap.TimedOutControls == nil {
+			opap.TimedOutControls = make(map[string]string)
+		}
+		maps.Copy(opap.TimedOutControls, result.TimedOutControls)
+	}
+}
+
+// splitControlIDs deals controlIDs into at most n groups, round robin, so
+// that the controls of a framework, which tend to be numbered together, are
+// spread over the groups.
+func splitControlIDs(controlIDs []string, n int) [][]string {
+	n = min(max(n, 1), len(controlIDs))
+	groups := make([][]string, n)
+	for i, controlID := range controlIDs {
+		groups[i%n] = append(groups[i%n], controlID)
+	}
+	return groups
+}
+
+func newShardResources(batch *cautils.ResourceBatch) ShardResources {
+	resources := ShardResources{
+		K8SResources:      batch.K8SResources,
+		ExternalResources: batch.ExternalResources,
+		Objects:           make(map[string]map[string]any, len(batch.AllResources)),
+	}
+	for id, resource := range batch.AllResources {
+		resources.Objects[id] = resource.GetObject()
+	}
+	return resources
+}
+
+// batch rebuilds the resource batch of scope from the resources.
+func (r *ShardResources) batch(scope string) *cautils.ResourceBatch {
+	batch := cautils.NewResourceBatch(scope)
+	maps.Copy(batch.K8SResources, r.K8SResources)
+	maps.Copy(batch.ExternalResources, r.ExternalResources)
+	for id, object := range r.Objects {
+		batch.AllResources[id] = newShardObject(object)
+	}
+	return batch
+}
+
+// matching returns the resources of r the rules of controls match: the
+// Kubernetes objects their Match selects and the external ones their
+// DynamicMatch selects. Namespace objects are kept whatever the rules match,
+// since evaluation looks the namespace of an object up among them.
+func (r *ShardResources) matching(controls []reporthandling.Control) ShardResources {
+	batch := r.batch("")
+	k8s := newResourceGroupIndex(batch.K8SResources, batch.AllResources)
+	external := newResourceGroupIndex(cautils.K8SResources(batch.ExternalResources), batch.AllResources)
+
+	keep := make(map[string]bool)
+	for id, resource := range batch.AllResources {
+		if resource.GetKind() == "Namespace" && resource.GetApiVersion() == "v1" {
+			keep[id] = true
+		}
+	}
+	for _, control := range controls {
+		for i := range control.Rules {
+			rule := &control.Rules[i]
+			for _, object := range getKubernetesObjects(k8s, rule.Match) {
+				keep[object.GetID()] = true
+			}
+			for _, object := range getKubernetesObjects(external, rule.DynamicMatch) {
+				keep[object.GetID()] = true
+			}
+		}
+	}
+
+	matched := ShardResources{
+		K8SResources:      filterResourceIDs(r.K8SResources, keep),
+		ExternalResources: cautils.ExternalResources(filterResourceIDs(cautils.K8SResources(r.ExternalResources), keep)),
+		Objects:           make(map[string]map[string]any, len(keep)),
+	}
+	for id, object := range r.Objects {
+		if keep[id] {
+			matched.Objects[id] = object
+		}
+	}
+	return matched
+}
+
+// filterResourceIDs returns the IDs of resources under each key that keep
+// holds, keeping every key.
+func filterResourceIDs(resources cautils.K8SResources, keep map[string]bool) cautils.K8SResources {
+	if resources == nil {
+		return nil
+	}
+	filtered := make(cautils.K8SResources, len(resources))
+	for key, ids := range resources {
+		filtered[key] = slices.DeleteFunc(slices.Clone(ids), func(id string) bool { return !keep[id] })
+	}
+	return filtered
+}
+
+func (r *ShardResources) has(id string) bool {
+	_, ok := r.Objects[id]
+	return ok
+}
+
+// newShardObject rebuilds a resource sent in a shard, of any of the kinds a
+// scan collects.
+func newShardObject(object map[string]any) workloadinterface.IMetadata {
+	if resource := objectsenvelopes.NewObject(object); resource != nil {
+		return resource
+	}
+	return workloadinterface.NewWorkloadObj(object)
+}
+
+func joinNamespaces(namespaces []string) string {
+	return strings.Join(namespaces, ",")
+}
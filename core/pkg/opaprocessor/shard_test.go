@@ -0,0 +1,283 @@
+package opaprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/kubescape/opa-utils/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const podSecurityContextRule = `package armo_builtins
+import rego.v1
+
+deny contains msga if {
+    pod := input[_]
+    pod.kind == "Pod"
+    not pod.spec.securityContext
+    msga := {
+        "alertMessage": "pod has no security context",
+        "packagename":  "armo_builtins",
+        "alertScore":   5,
+        "fixPaths":     [],
+        "failedPaths":  ["spec.securityContext"],
+        "alertObject":  {"k8sApiObjects": [pod]},
+    }
+}
+`
+
+const clusterRoleBoundRule = `package armo_builtins
+import rego.v1
+
+deny contains msga if {
+    cr := input[_]
+    cr.kind == "ClusterRole"
+    pod := input[_]
+    pod.kind == "Pod"
+    msga := {
+        "alertMessage": "wide-open binds pod",
+        "packagename":  "armo_builtins",
+        "alertScore":   5,
+        "fixPaths":     [],
+        "failedPaths":  [sprintf("metadata.annotations.bound-by-%s", [pod.metadata.namespace])],
+        "alertObject":  {"k8sApiObjects": [cr]},
+    }
+}
+`
+
+func shardTestControl(id, rule string, wholeCluster bool) reporthandling.Control {
+	control := reporthandling.Control{
+		ControlID: id,
+		Rules: []reporthandling.PolicyRule{{
+			PortalBase:   armotypes.PortalBase{Name: strings.ToLower(id)},
+			Rule:         rule,
+			RuleLanguage: reporthandling.RegoLanguage,
+			Match: []reporthandling.RuleMatchObjects{
+				{APIGroups: []string{"rbac.authorization.k8s.io"}, APIVersions: []string{"v1"}, Resources: []string{"ClusterRole"}},
+				{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"Pod"}},
+			},
+		}},
+	}
+	if wholeCluster {
+		control.Attributes = map[string]any{ControlAttributeRequiresWholeClusterInput: true}
+	}
+	return control
+}
+
+// newShardTestProcessor returns a processor partitioning a ClusterRole and
+// pods in two namespaces into three scopes.
+func newShardTestProcessor(t *testing.T) *OPAProcessor {
+	t.Setenv("LARGE_CLUSTER_SIZE", "1")
+
+	sessionObj := cautils.NewOPASessionObjMock()
+	sessionObj.K8SResources = cautils.K8SResources{}
+	sessionObj.InfoMap = map[string]apis.StatusInfo{}
+	for _, object := range []map[string]any{
+		{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRole", "metadata": map[string]any{"name": "wide-open"}},
+		{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{"name": "pa", "namespace": "ns-a"}, "spec": map[string]any{}},
+		{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{"name": "pb", "namespace": "ns-b"}, "spec": map[string]any{"securityContext": map[string]any{"runAsNonRoot": true}}},
+	} {
+		resource := workloadinterface.NewWorkloadObj(object)
+		gvr := "/v1/pods"
+		if resource.GetKind() == "ClusterRole" {
+			gvr = "rbac.authorization.k8s.io/v1/clusterroles"
+		}
+		sessionObj.K8SResources[gvr] = append(sessionObj.K8SResources[gvr], resource.GetID())
+		sessionObj.AllResources[resource.GetID()] = resource
+	}
+
+	opap := NewOPAProcessor(sessionObj, resources.NewRegoDependenciesDataMock(), "test", "", "", false, nil)
+	opap.AllPolicies = &cautils.Policies{Controls: map[string]reporthandling.Control{
+		"C-0001": shardTestControl("C-0001", podSecurityContextRule, false),
+		"C-0002": shardTestControl("C-0002", clusterRoleBoundRule, false),
+		"C-0003": shardTestControl("C-0003", clusterRoleBoundRule, true),
+	}}
+	return opap
+}
+
+// jsonShardEvaluator evaluates shards in this process, through the JSON
+// encoding a worker receives and answers them in.
+type jsonShardEvaluator struct {
+	calls atomic.Int32
+	fail  bool
+	// maxControls, when set, refuses shards of more controls as too large.
+	maxControls int
+}
+
+func (e *jsonShardEvaluator) EvaluateShard(ctx context.Context, shard *Shard) (*ShardResult, error) {
+	e.calls.Add(1)
+	if e.fail {
+		return nil, errors.New("no worker is reachable")
+	}
+	if e.maxControls > 0 && len(shard.Controls) > e.maxControls {
+		return nil, fmt.Errorf("413 Request Entity Too Large: %w", ErrShardTooLarge)
+	}
+	data, err := json.Marshal(shard)
+	if err != nil {
+		return nil, err
+	}
+	var received Shard
+	if err := json.Unmarshal(data, &received); err != nil {
+		return nil, err
+	}
+	result, evalErr := EvaluateShard(ctx, &received)
+	if data, err = json.Marshal(result); err != nil {
+		return nil, err
+	}
+	var answered ShardResult
+	if err := json.Unmarshal(data, &answered); err != nil {
+		return nil, err
+	}
+	return &answered, evalErr
+}
+
+// resultsJSON encodes results with the controls of every result sorted,
+// which a sharded scan merges in the order of its shards.
+func resultsJSON(t *testing.T, results map[string]resourcesresults.Result) string {
+	for id, result := range results {
+		slices.SortFunc(result.AssociatedControls, func(a, b resourcesresults.ResourceAssociatedControl) int {
+			return strings.Compare(a.ControlID, b.ControlID)
+		})
+		results[id] = result
+	}
+	data, err := json.Marshal(results)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestProcessSharded_MatchesProcess(t *testing.T) {
+	ctx := context.Background()
+
+	local := newShardTestProcessor(t)
+	require.NoError(t, local.Process(ctx, local.AllPolicies, nil))
+
+	sharded := newShardTestProcessor(t)
+	evaluator := &jsonShardEvaluator{}
+	sharded.SetShardEvaluator(evaluator, 4, false)
+	require.NoError(t, sharded.Process(ctx, sharded.AllPolicies, nil))
+
+	assert.Equal(t, int32(7), evaluator.calls.Load(), "two control groups on three scopes, and the whole-cluster control")
+	assert.JSONEq(t, resultsJSON(t, local.ResourcesResult), resultsJSON(t, sharded.ResourcesResult))
+
+	var paths []string
+	for id, result := range sharded.ResourcesResult {
+		if !strings.Contains(id, "ClusterRole") {
+			continue
+		}
+		control := result.AssociatedControls[slices.IndexFunc(result.AssociatedControls, func(c resourcesresults.ResourceAssociatedControl) bool { return c.ControlID == "C-0002" })]
+		for _, path := range control.ResourceAssociatedRules[0].Paths {
+			paths = append(paths, path.FailedPath)
+		}
+	}
+	assert.ElementsMatch(t, []string{"metadata.annotations.bound-by-ns-a", "metadata.annotations.bound-by-ns-b"}, paths, "the paths found on every namespace are merged")
+}
+
+func TestProcessSharded_FailsWhenNoWorkerAnswers(t *testing.T) {
+	sharded := newShardTestProcessor(t)
+	sharded.SetShardEvaluator(&jsonShardEvaluator{fail: true}, 2, false)
+
+	err := sharded.Process(context.Background(), sharded.AllPolicies, nil)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "no worker is reachable")
+	assert.Empty(t, sharded.ResourcesResult)
+}
+
+func TestProcessSharded_FallsBackToLocalEvaluation(t *testing.T) {
+	local := newShardTestProcessor(t)
+	require.NoError(t, local.Process(context.Background(), local.AllPolicies, nil))
+
+	sharded := newShardTestProcessor(t)
+	sharded.SetShardEvaluator(&jsonShardEvaluator{fail: true}, 2, true)
+	require.NoError(t, sharded.Process(context.Background(), sharded.AllPolicies, nil))
+
+	assert.JSONEq(t, resultsJSON(t, local.ResourcesResult), resultsJSON(t, sharded.ResourcesResult))
+}
+
+func TestProcessSharded_SplitsOversizedShards(t *testing.T) {
+	local := newShardTestProcessor(t)
+	require.NoError(t, local.Process(context.Background(), local.AllPolicies, nil))
+
+	sharded := newShardTestProcessor(t)
+	evaluator := &jsonShardEvaluator{maxControls: 1}
+	// One shard in flight puts both per-scope controls in every scope's shard.
+	sharded.SetShardEvaluator(evaluator, 1, false)
+	require.NoError(t, sharded.Process(context.Background(), sharded.AllPolicies, nil))
+
+	assert.Equal(t, int32(10), evaluator.calls.Load(), "three refused shards split in two, and the whole-cluster control")
+	assert.JSONEq(t, resultsJSON(t, local.ResourcesResult), resultsJSON(t, sharded.ResourcesResult))
+}
+
+func TestShardSplit(t *testing.T) {
+	opap := newShardTestProcessor(t)
+	podsOnly := shardTestControl("C-0004", podSecurityContextRule, false)
+	podsOnly.Rules[0].Match = podsOnly.Rules[0].Match[1:]
+	opap.AllPolicies.Controls["C-0004"] = podsOnly
+	namespace := workloadinterface.NewWorkloadObj(map[string]any{"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]any{"name": "ns-a"}})
+	opap.K8SResources["/v1/namespaces"] = []string{namespace.GetID()}
+	opap.AllResources[namespace.GetID()] = namespace
+
+	everything := newShardResources(&cautils.ResourceBatch{K8SResources: opap.K8SResources, AllResources: opap.AllResources})
+	shard := opap.newShard("wholeCluster/0", cautils.ClusterScope, opap.AllPolicies, []string{"C-0004", "C-0002"}, everything, nil)
+
+	halves := shard.split()
+	require.Len(t, halves, 2)
+	assert.Equal(t, "wholeCluster/0.0", halves[0].ID)
+	require.Len(t, halves[0].Controls, 1)
+	assert.Equal(t, "C-0004", halves[0].Controls[0].ControlID)
+	assert.ElementsMatch(t, []string{"/v1/ns-a/Pod/pa", "/v1/ns-b/Pod/pb", namespace.GetID()}, slices.Collect(maps.Keys(halves[0].Resident.Objects)),
+		"the pods-only control carries no ClusterRole, and the namespaces evaluation looks up")
+	assert.Empty(t, halves[0].Resident.K8SResources["rbac.authorization.k8s.io/v1/clusterroles"])
+
+	require.Len(t, halves[1].Controls, 1)
+	assert.Equal(t, "C-0002", halves[1].Controls[0].ControlID)
+	assert.Len(t, halves[1].Resident.Objects, 4)
+	assert.Len(t, shard.Resident.Objects, 4, "splitting leaves the shard as it was")
+}
+
+func TestPlanShards(t *testing.T) {
+	opap := newShardTestProcessor(t)
+	opap.SetShardEvaluator(&jsonShardEvaluator{}, 4, false)
+
+	shards := opap.planShards(opap.AllPolicies)
+	var ids []string
+	for _, shard := range shards {
+		ids = append(ids, shard.ID)
+	}
+	assert.Equal(t, []string{"clusterScope/0", "clusterScope/1", "ns-a/0", "ns-a/1", "ns-b/0", "ns-b/1", "wholeCluster/0"}, ids)
+
+	resident := shards[0]
+	assert.Nil(t, resident.Batch)
+	assert.Len(t, resident.Resident.Objects, 1, "the resident scope holds the ClusterRole")
+	require.Len(t, resident.Controls, 1)
+	assert.Equal(t, "C-0001", resident.Controls[0].ControlID)
+
+	namespace := shards[2]
+	require.NotNil(t, namespace.Batch)
+	assert.Contains(t, namespace.Batch.Objects, "/v1/ns-a/Pod/pa")
+
+	wholeCluster := shards[6]
+	assert.Len(t, wholeCluster.Resident.Objects, 3, "whole-cluster controls see every resource")
+	require.Len(t, wholeCluster.Controls, 1)
+	assert.Equal(t, "C-0003", wholeCluster.Controls[0].ControlID)
+}
+
+func TestSplitControlIDs(t *testing.T) {
+	assert.Equal(t, [][]string{{"C-1", "C-3", "C-5"}, {"C-2", "C-4"}}, splitControlIDs([]string{"C-1", "C-2", "C-3", "C-4", "C-5"}, 2))
+	assert.Equal(t, [][]string{{"C-1"}, {"C-2"}}, splitControlIDs([]string{"C-1", "C-2"}, 8), "there are no more groups than controls")
+	assert.Equal(t, [][]string{{"C-1", "C-2"}}, splitControlIDs([]string{"C-1", "C-2"}, 0))
+	assert.Empty(t, splitControlIDs(nil, 4))
+}
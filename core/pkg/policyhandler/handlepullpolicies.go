@@ -126,6 +126,9 @@ func (policyHandler *PolicyHandler) CollectPolicies(ctx context.Context, policyI
 	}
 	opaSessionObj.RegoInputData.PostureControlInputs = controlInputs
 	opaSessionObj.PolicyDegradations = degradations
+	if opaSessionObj.ControlInputsScopes, err = policyHandler.getControlInputsScopes(ctx, getters); err != nil {
+		return opaSessionObj, err
+	}
 
 	return opaSessionObj, nil
 }
@@ -181,8 +184,17 @@ func (policyHandler *PolicyHandler) getPolicies(ctx context.Context, policyIdent
 func (policyHandler *PolicyHandler) getScanPolicies(ctx context.Context, policyIdentifier []cautils.PolicyIdentifier, getters *cautils.Getters) ([]reporthandling.Framework, error) {
 	policyIdentifiersSlice := policyIdentifierToSlice(policyIdentifier)
 	_, isLocalPolicy := getters.PolicyGetter.(*getter.LoadPolicy)
-	// Explicit local policy sources are request-scoped inputs. They must not be
-	// shadowed by, or replace, a shared cache entry for the same identifiers.
+	if _, isDefinition := getters.PolicyGetter.(*getter.FrameworkDefinitionGetter); isDefinition {
+		isLocalPolicy = true
+	}
+	// Explicit local policy sources, framework definitions included, are
+	// request-scoped inputs. They must not be shadowed by, or replace, a shared
+	// cache entry for the same identifiers.
+	if shared := sharedPoliciesFromContext(ctx); shared != nil && !isLocalPolicy {
+		return shared.get(policyIdentifiersSlice, func() ([]reporthandling.Framework, error) {
+			return policyHandler.downloadScanPolicies(ctx, policyIdentifier, getters)
+		})
+	}
 	if !isLocalPolicy {
 		// check if policies are cached atomically
 		if entry, exist := policyHandler.cachedPolicies.Get(); exist {
@@ -228,6 +240,7 @@ func (policyHandler *PolicyHandler) downloadScanPolicies(ctx context.Context, po
 	if persistence, ok := getters.PolicyGetter.(policyArtifactPersistence); ok {
 		persistPolicyArtifacts = persistence.ShouldPersistPolicyArtifacts()
 	}
+	definitions, _ := getters.PolicyGetter.(*getter.FrameworkDefinitionGetter)
 
 	switch getScanKind(policyIdentifier) {
 	case apisv1.KindFramework: // Download frameworks
@@ -242,7 +255,8 @@ func (policyHandler *PolicyHandler) downloadScanPolicies(ctx context.Context, po
 			}
 			if receivedFramework != nil {
 				frameworks = append(frameworks, *receivedFramework)
-				if !persistPolicyArtifacts {
+				// a composed framework is only as current as its definition file
+				if !persistPolicyArtifacts || (definitions != nil && definitions.Defines(rule.Identifier)) {
 					continue
 				}
 				cache, err := getter.PolicyCachePath(rule.Identifier)
@@ -301,6 +315,22 @@ func (policyHandler *PolicyHandler) getExceptions(ctx context.Context, getters *
 	return exceptions, err
 }
 
+// getControlInputsScopes retrieves the scoped control inputs from the control
+// inputs getters supporting them. Unlike the global inputs, they are neither
+// cached nor replaced by defaults: they come from a local file, which must be
+// valid.
+func (policyHandler *PolicyHandler) getControlInputsScopes(ctx context.Context, getters *cautils.Getters) ([]getter.ControlInputsScope, error) {
+	scopedGetter, ok := getters.ControlsInputsGetter.(getter.IScopedControlsInputsGetter)
+	if !ok {
+		return nil, nil
+	}
+	scopes, err := scopedGetter.GetScopedControlsInputs(ctx, policyHandler.clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scoped control inputs: %w", err)
+	}
+	return scopes, nil
+}
+
 func (policyHandler *PolicyHandler) getControlInputs(ctx context.Context, getters *cautils.Getters) (map[string][]string, error) {
 	if cachedControlInputs, exist := policyHandler.cachedControlInputs.Get(); exist {
 		logger.L().Info("Using cached control inputs")
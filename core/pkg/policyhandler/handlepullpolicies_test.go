@@ -478,3 +478,37 @@ func TestGetScanPolicies_ConcurrentDifferentFrameworksAtomicCache(t *testing.T)
 
 	wg.Wait()
 }
+
+func TestDownloadScanPolicies_FrameworkDefinitionsAreNotCached(t *testing.T) {
+	cacheDir := t.TempDir()
+	originalLocalStore := getter.DefaultLocalStore
+	getter.DefaultLocalStore = cacheDir
+	t.Cleanup(func() { getter.DefaultLocalStore = originalLocalStore })
+
+	definition := &getter.FrameworkDefinition{
+		Name: "golden-baseline",
+		Sections: []getter.FrameworkSection{{
+			ID:       "1",
+			Controls: []getter.DefinitionControl{{ID: "C-0013", Framework: FrameworkName, Severity: "critical"}},
+		}},
+	}
+	getters := &cautils.Getters{PolicyGetter: getter.NewFrameworkDefinitionGetter(&PolicyGetterMock{}, map[string]*getter.FrameworkDefinition{
+		"golden-baseline": definition,
+	})}
+	policyHandler := NewRequestScopedPolicyHandler("framework-definitions")
+	t.Cleanup(policyHandler.Close)
+	policyIdent := []cautils.PolicyIdentifier{{Identifier: "golden-baseline", Kind: "Framework"}, {Identifier: FrameworkName, Kind: "Framework"}}
+
+	frameworks, err := policyHandler.getScanPolicies(context.Background(), policyIdent, getters)
+	require.NoError(t, err)
+	require.Len(t, frameworks, 2)
+	assert.Equal(t, "golden-baseline", frameworks[0].Name)
+	require.Len(t, frameworks[0].Controls, 1)
+	assert.Equal(t, "C-0013", frameworks[0].Controls[0].ControlID)
+	assert.Equal(t, float32(9), frameworks[0].Controls[0].BaseScore)
+
+	assert.NoFileExists(t, filepath.Join(cacheDir, "golden-baseline.json"), "a composed framework must not be cached")
+	assert.FileExists(t, filepath.Join(cacheDir, FrameworkName+".json"))
+	_, cached := policyHandler.cachedPolicies.Get()
+	assert.False(t, cached, "framework definitions are request-scoped")
+}
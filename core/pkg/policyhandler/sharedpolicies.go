@@ -0,0 +1,54 @@
+package policyhandler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+)
+
+// SharedPolicies holds the frameworks and controls downloaded for one set of
+// policy identifiers, so a process scanning several clusters (a fleet scan)
+// downloads them once instead of once per cluster. Only the policies are
+// shared: exceptions and control inputs are cluster-specific and still come
+// from each cluster's handler in the registry.
+type SharedPolicies struct {
+	mu    sync.Mutex
+	entry *cachedPoliciesEntry
+}
+
+func NewSharedPolicies() *SharedPolicies {
+	return &SharedPolicies{}
+}
+
+type sharedPoliciesKey struct{}
+
+// WithSharedPolicies returns a context whose policy collection reads from, and
+// fills, shared rather than downloading the policies again.
+func WithSharedPolicies(ctx context.Context, shared *SharedPolicies) context.Context {
+	return context.WithValue(ctx, sharedPoliciesKey{}, shared)
+}
+
+func sharedPoliciesFromContext(ctx context.Context) *SharedPolicies {
+	shared, _ := ctx.Value(sharedPoliciesKey{}).(*SharedPolicies)
+	return shared
+}
+
+// get returns a copy of the policies for identifiers, calling download the
+// first time. Concurrent callers wait for that download rather than starting
+// their own. A failed download is not remembered, so the next cluster retries.
+func (s *SharedPolicies) get(identifiers []string, download func() ([]reporthandling.Framework, error)) ([]reporthandling.Framework, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entry == nil || !cautils.StringSlicesAreEqual(s.entry.identifiers, identifiers) {
+		frameworks, err := download()
+		if err != nil {
+			return frameworks, err
+		}
+		s.entry = &cachedPoliciesEntry{identifiers: identifiers, frameworks: frameworks}
+	}
+	// Each scan filters and annotates its own copy (see excludeControls).
+	return deepCopyPolicies(s.entry.frameworks)
+}
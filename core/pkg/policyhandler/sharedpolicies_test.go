@@ -0,0 +1,73 @@
+package policyhandler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingPolicyGetterMock struct {
+	nonPersistentPolicyGetterMock
+	downloads atomic.Int32
+	fail      bool
+}
+
+func (mock *countingPolicyGetterMock) GetFramework(name string) (*reporthandling.Framework, error) {
+	mock.downloads.Add(1)
+	if mock.fail {
+		return nil, errors.New("release unavailable")
+	}
+	return mock.nonPersistentPolicyGetterMock.GetFramework(name)
+}
+
+func TestGetScanPolicies_SharedPoliciesDownloadOnceAcrossClusters(t *testing.T) {
+	shared := NewSharedPolicies()
+	ctx := WithSharedPolicies(context.Background(), shared)
+	policyGetter := &countingPolicyGetterMock{}
+	getters := &cautils.Getters{PolicyGetter: policyGetter}
+	policyIdent := []cautils.PolicyIdentifier{{Identifier: FrameworkName, Kind: "Framework"}}
+
+	prod := NewRequestScopedPolicyHandler("prod")
+	defer prod.Close()
+	staging := NewRequestScopedPolicyHandler("staging")
+	defer staging.Close()
+
+	prodPolicies, err := prod.getScanPolicies(ctx, policyIdent, getters)
+	require.NoError(t, err)
+	require.NotEmpty(t, prodPolicies)
+	prodPolicies[0].Name = "mutated by the prod scan"
+
+	stagingPolicies, err := staging.getScanPolicies(ctx, policyIdent, getters)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, policyGetter.downloads.Load(), "the second cluster reuses the fleet's policies")
+	assert.NotEqual(t, "mutated by the prod scan", stagingPolicies[0].Name, "every cluster gets its own copy")
+
+	_, err = staging.getScanPolicies(ctx, []cautils.PolicyIdentifier{{Identifier: "mitre", Kind: "Framework"}}, getters)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, policyGetter.downloads.Load(), "other identifiers are downloaded")
+}
+
+func TestGetScanPolicies_SharedPoliciesDoNotRememberFailures(t *testing.T) {
+	ctx := WithSharedPolicies(context.Background(), NewSharedPolicies())
+	policyGetter := &countingPolicyGetterMock{fail: true}
+	getters := &cautils.Getters{PolicyGetter: policyGetter}
+	policyIdent := []cautils.PolicyIdentifier{{Identifier: FrameworkName, Kind: "Framework"}}
+
+	handler := NewRequestScopedPolicyHandler("prod")
+	defer handler.Close()
+
+	_, err := handler.getScanPolicies(ctx, policyIdent, getters)
+	require.Error(t, err)
+
+	policyGetter.fail = false
+	policies, err := handler.getScanPolicies(ctx, policyIdent, getters)
+	require.NoError(t, err)
+	assert.NotEmpty(t, policies)
+	assert.EqualValues(t, 2, policyGetter.downloads.Load())
+}
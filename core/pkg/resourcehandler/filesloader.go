@@ -36,6 +36,13 @@ func (fileHandler *FileResourceHandler) GetResources(ctx context.Context, sessio
 
 	// load resources from all input paths
 	mappedResources := map[string][]workloadinterface.IMetadata{}
+	var gitOpsSources cautils.GitOpsSources
+	if scanInfo.GitOps {
+		var err error
+		if gitOpsSources, err = cautils.ParseGitOpsSources(scanInfo.GitOpsSources); err != nil {
+			return nil, allResources, nil, nil, err
+		}
+	}
 	for path := range scanInfo.InputPatterns {
 		var workloadIDToSource map[string]reporthandling.Source
 		var workloads []workloadinterface.IMetadata
@@ -51,7 +58,19 @@ func (fileHandler *FileResourceHandler) GetResources(ctx context.Context, sessio
 			if filterErr != nil {
 				return nil, allResources, nil, nil, filterErr
 			}
-			workloadIDToSource, workloads, skipped, err = getResourcesFromPath(ctx, scanInfo.InputPatterns[path], helmValueOpts, pathFilter)
+			workloadIDToSource, workloads, skipped, err = getResourcesFromPath(ctx, scanInfo.InputPatterns[path], helmValueOpts, jsonnetOptionsFromScanInfo(scanInfo), pathFilter)
+			if err == nil && scanInfo.GitOps {
+				var gitOpsObjects map[string]cautils.GitOpsObjectRef
+				var gitOpsSkipped []cautils.SkippedManifest
+				workloads, workloadIDToSource, gitOpsObjects, gitOpsSkipped = renderGitOpsObjects(ctx, scanInfo.InputPatterns[path], gitOpsSources, workloads, workloadIDToSource)
+				skipped = append(skipped, gitOpsSkipped...)
+				if len(gitOpsObjects) > 0 {
+					if sessionObj.GitOpsObjects == nil {
+						sessionObj.GitOpsObjects = map[string]cautils.GitOpsObjectRef{}
+					}
+					maps.Copy(sessionObj.GitOpsObjects, gitOpsObjects)
+				}
+			}
 		}
 		sessionObj.SkippedManifests = append(sessionObj.SkippedManifests, skipped...)
 		if err != nil {
@@ -202,6 +221,18 @@ func helmValueOptionsFromScanInfo(scanInfo *cautils.ScanInfo) cautils.HelmValueO
 	}
 }
 
+// jsonnetOptionsFromScanInfo extracts the Jsonnet evaluation flags from ScanInfo.
+func jsonnetOptionsFromScanInfo(scanInfo *cautils.ScanInfo) cautils.JsonnetOptions {
+	if scanInfo == nil {
+		return cautils.JsonnetOptions{}
+	}
+	return cautils.JsonnetOptions{
+		JPaths:  scanInfo.JsonnetJPaths,
+		ExtStrs: scanInfo.JsonnetExtStrs,
+		TLAStrs: scanInfo.JsonnetTLAStrs,
+	}
+}
+
 // pathFilterFromScanInfo compiles the exclusions that apply to a single scan input, from the
 // ignore file at its root and from --exclude-path. It returns nil when nothing is excluded.
 func pathFilterFromScanInfo(ctx context.Context, input string, scanInfo *cautils.ScanInfo) (*cautils.PathFilter, error) {
@@ -332,9 +363,9 @@ func excludeFilesUnderDirectories(sourceToWorkloads map[string][]workloadinterfa
 }
 
 // getResourcesFromPath loads every scannable resource under path, from plain
-// manifests, helm charts and kustomize directories, and maps each workload to the
-// source file it came from.
-func getResourcesFromPath(ctx context.Context, path string, helmValueOpts cautils.HelmValueOptions, pathFilter *cautils.PathFilter) (map[string]reporthandling.Source, []workloadinterface.IMetadata, []cautils.SkippedManifest, error) {
+// manifests, helm charts, kustomize directories, Terraform, Jsonnet and CUE, and
+// maps each workload to the source file it came from.
+func getResourcesFromPath(ctx context.Context, path string, helmValueOpts cautils.HelmValueOptions, jsonnetOpts cautils.JsonnetOptions, pathFilter *cautils.PathFilter) (map[string]reporthandling.Source, []workloadinterface.IMetadata, []cautils.SkippedManifest, error) {
 	workloadIDToSource := make(map[string]reporthandling.Source)
 	var workloads []workloadinterface.IMetadata
 	var allSkips []cautils.SkippedManifest
@@ -384,56 +415,81 @@ func getResourcesFromPath(ctx context.Context, path string, helmValueOpts cautil
 	if err != nil {
 		return nil, nil, allSkips, err
 	}
+	jsonnetSourceToWorkloads, jsonnetSkips, err := cautils.LoadResourcesFromJsonnetFiltered(ctx, path, jsonnetOpts, pathFilter)
+	allSkips = append(allSkips, jsonnetSkips...)
+	if err != nil {
+		return nil, nil, allSkips, err
+	}
+	cueSourceToWorkloads, cueSkips, err := cautils.LoadResourcesFromCueFiltered(ctx, path, pathFilter)
+	allSkips = append(allSkips, cueSkips...)
+	if err != nil {
+		return nil, nil, allSkips, err
+	}
+
+	// evaluated sources keep their own file type, so the printers and fix can tell
+	// them from plain manifests
+	evaluatedSources := []struct {
+		fileType          string
+		sourceToWorkloads map[string][]workloadinterface.IMetadata
+	}{
+		{"Terraform", terraformSourceToWorkloads},
+		{cautils.SourceTypeJsonnet, jsonnetSourceToWorkloads},
+		{cautils.SourceTypeCUE, cueSourceToWorkloads},
+	}
 	if filesErr != nil {
-		terraformWorkloads := 0
-		for _, ws := range terraformSourceToWorkloads {
-			terraformWorkloads += len(ws)
+		evaluatedWorkloads := 0
+		for _, evaluated := range evaluatedSources {
+			for _, ws := range evaluated.sourceToWorkloads {
+				evaluatedWorkloads += len(ws)
+			}
 		}
-		if terraformWorkloads == 0 {
+		if evaluatedWorkloads == 0 {
 			return nil, nil, allSkips, filesErr
 		}
 	}
 
-	// merge Terraform-derived workloads, same pattern as the Kustomize block below
-	for source, ws := range terraformSourceToWorkloads {
-		workloads = append(workloads, ws...)
-		relSource, err := filepath.Rel(repoRoot, source)
-		if err == nil {
-			source = relSource
-		}
+	// merge Terraform-, Jsonnet- and CUE-derived workloads, same pattern as the Kustomize block below
+	for _, evaluated := range evaluatedSources {
+		for source, ws := range evaluated.sourceToWorkloads {
+			workloads = append(workloads, ws...)
+			relSource, err := filepath.Rel(repoRoot, source)
+			if err == nil {
+				source = relSource
+			}
 
-		var lastCommit reporthandling.LastCommit
-		if gitRepo != nil {
-			if commitInfo, _ := gitRepo.GetFileLastCommit(source); commitInfo != nil {
-				lastCommit = reporthandling.LastCommit{
-					Hash:           commitInfo.SHA,
-					Date:           commitInfo.Author.Date,
-					CommitterName:  commitInfo.Author.Name,
-					CommitterEmail: commitInfo.Author.Email,
-					Message:        commitInfo.Message,
+			var lastCommit reporthandling.LastCommit
+			if gitRepo != nil {
+				if commitInfo, _ := gitRepo.GetFileLastCommit(source); commitInfo != nil {
+					lastCommit = reporthandling.LastCommit{
+						Hash:           commitInfo.SHA,
+						Date:           commitInfo.Author.Date,
+						CommitterName:  commitInfo.Author.Name,
+						CommitterEmail: commitInfo.Author.Email,
+						Message:        commitInfo.Message,
+					}
 				}
 			}
-		}
 
-		var workloadSource reporthandling.Source
-		if clonedRepo != "" {
-			workloadSource = reporthandling.Source{
-				Path:         "",
-				RelativePath: source,
-				FileType:     "Terraform",
-				LastCommit:   lastCommit,
-			}
-		} else {
-			workloadSource = reporthandling.Source{
-				Path:         repoRoot,
-				RelativePath: source,
-				FileType:     "Terraform",
-				LastCommit:   lastCommit,
+			var workloadSource reporthandling.Source
+			if clonedRepo != "" {
+				workloadSource = reporthandling.Source{
+					Path:         "",
+					RelativePath: source,
+					FileType:     evaluated.fileType,
+					LastCommit:   lastCommit,
+				}
+			} else {
+				workloadSource = reporthandling.Source{
+					Path:         repoRoot,
+					RelativePath: source,
+					FileType:     evaluated.fileType,
+					LastCommit:   lastCommit,
+				}
 			}
-		}
 
-		for i := range ws {
-			workloadIDToSource[ws[i].GetID()] = workloadSource
+			for i := range ws {
+				workloadIDToSource[ws[i].GetID()] = workloadSource
+			}
 		}
 	}
 
@@ -221,7 +221,7 @@ items:
 
 // A single-file scan must yield a repository-relative RelativePath: the SARIF and GitLab SAST printers build the finding's file location from it, and the GitLab printer drops findings whose path is empty, absolute, or escaping the repo root. See #2496.
 func TestGetResourcesFromPath_SingleFileRelativePathIsRepositoryRelative(t *testing.T) {
-	workloadIDToSource, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/mixed_extensions/pod.yaml", cautils.HelmValueOptions{}, nil)
+	workloadIDToSource, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/mixed_extensions/pod.yaml", cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 	require.NotEmpty(t, workloads, "the single-file scan must discover the pod")
 
@@ -277,7 +277,7 @@ spec:
           image: nginx:1.27
 `), 0o600))
 
-	_, workloads, _, err := getResourcesFromPath(context.Background(), manifestPath, cautils.HelmValueOptions{}, nil)
+	_, workloads, _, err := getResourcesFromPath(context.Background(), manifestPath, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 	require.Len(t, workloads, 1, "an exact file scan must not add resources from its parent tree")
 	assert.Equal(t, "ConfigMap", workloads[0].GetKind())
@@ -326,7 +326,7 @@ func TestGetResourcesFromPath_AnchorsOnRepositoryRootWithoutUsableGitMetadata(t
 		t.Run(tt.name, func(t *testing.T) {
 			repoRoot := newRepoWithUnusableGitMetadata(t, manifest)
 
-			workloadIDToSource, workloads, _, err := getResourcesFromPath(context.TODO(), filepath.Join(repoRoot, filepath.FromSlash(tt.scanPath)), cautils.HelmValueOptions{}, nil)
+			workloadIDToSource, workloads, _, err := getResourcesFromPath(context.TODO(), filepath.Join(repoRoot, filepath.FromSlash(tt.scanPath)), cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 			require.NoError(t, err)
 			require.NotEmpty(t, workloads)
 
@@ -355,7 +355,7 @@ spec:
 // would make those resources reach neither loader and vanish silently. Regression guard for the #2501
 // review: templates/ is excluded only for charts that rendered without errors.
 func TestGetResourcesFromPath_ScansTemplatesOfChartThatFailedToRender(t *testing.T) {
-	_, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/helm_chart_broken", cautils.HelmValueOptions{}, nil)
+	_, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/helm_chart_broken", cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	var found bool
@@ -441,7 +441,7 @@ func TestResolveHelmRemotePath(t *testing.T) {
 // not scan them again (no duplicate, no malformed-template warnings), while crds/ and files outside
 // templates/ stay plainly scanned.
 func TestGetResourcesFromPath_RenderedChartTemplatesLoadedOnce(t *testing.T) {
-	_, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/helm_chart_layout", cautils.HelmValueOptions{}, nil)
+	_, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/helm_chart_layout", cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	counts := map[string]int{}
@@ -460,7 +460,7 @@ func TestGetResourcesFromPath_RenderedChartTemplatesLoadedOnce(t *testing.T) {
 
 // Deduplicates resources discovered by both kustomize render and the plain-YAML glob.
 func TestGetResourcesFromPath_DeduplicatesKustomizeAndPlainYaml(t *testing.T) {
-	workloadIDToSource, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/kustomize/base", cautils.HelmValueOptions{}, nil)
+	workloadIDToSource, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/kustomize/base", cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	var deployments []string
@@ -476,7 +476,7 @@ func TestGetResourcesFromPath_DeduplicatesKustomizeAndPlainYaml(t *testing.T) {
 
 // Kustomize transformers mutate identity fields, so path-based exclusion (not identity dedup) must keep the result single.
 func TestGetResourcesFromPath_KustomizeTransformersDoNotDuplicate(t *testing.T) {
-	workloadIDToSource, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/kustomize/transformed", cautils.HelmValueOptions{}, nil)
+	workloadIDToSource, workloads, _, err := getResourcesFromPath(context.TODO(), "../../cautils/testdata/kustomize/transformed", cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	var deploymentIDs []string
@@ -520,7 +520,7 @@ metadata:
   name: {{ .Release.Name }}
 `), 0o600))
 
-	sources, workloads, _, err := getResourcesFromPath(context.Background(), root, cautils.HelmValueOptions{}, nil)
+	sources, workloads, _, err := getResourcesFromPath(context.Background(), root, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	counts := map[string]int{}
@@ -627,7 +627,7 @@ metadata:
   name: standalone
 `), 0o600))
 
-	sources, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, nil)
+	sources, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	counts := map[string]int{}
@@ -680,7 +680,7 @@ helmCharts:
     releaseName: app
 `), 0o600))
 
-	sources, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, nil)
+	sources, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	counts := map[string]int{}
@@ -779,7 +779,7 @@ helmCharts:
 				assert.Equal(t, 1, rawCRDs, "the raw pass must retain the omitted CRD")
 			}
 
-			sources, workloads, _, err := getResourcesFromPath(ctx, repoRoot, cautils.HelmValueOptions{}, nil)
+			sources, workloads, _, err := getResourcesFromPath(ctx, repoRoot, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 			require.NoError(t, err)
 
 			var crds []workloadinterface.IMetadata
@@ -819,7 +819,7 @@ metadata:
   name: standalone
 `), 0o600))
 
-	sources, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, nil)
+	sources, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	counts := map[string]int{}
@@ -840,7 +840,7 @@ func TestGetResourcesFromPathRejectsDirectoryWithoutKubernetesResources(t *testi
 	dir := t.TempDir()
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("replicas: 3\n"), 0o600))
 
-	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, nil)
+	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 
 	require.Error(t, err)
 	assert.Nil(t, sources)
@@ -875,7 +875,7 @@ func TestGetResourcesFromPathLoadsTerraformOnlyDirectory(t *testing.T) {
 	dir := t.TempDir()
 	path := writeTerraformFixture(t, dir, terraformPodFixture)
 
-	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, nil)
+	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 
 	require.NoError(t, err)
 	assert.Empty(t, skips)
@@ -891,7 +891,7 @@ func TestGetResourcesFromPathLoadsExplicitTerraformFile(t *testing.T) {
 	dir := t.TempDir()
 	path := writeTerraformFixture(t, dir, terraformPodFixture)
 
-	_, workloads, _, err := getResourcesFromPath(context.Background(), path, cautils.HelmValueOptions{}, nil)
+	_, workloads, _, err := getResourcesFromPath(context.Background(), path, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 
 	require.NoError(t, err)
 	require.Len(t, workloads, 1)
@@ -919,7 +919,7 @@ metadata:
   name: yaml-config
 `), 0o600))
 
-	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, nil)
+	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 
 	require.NoError(t, err)
 	assert.Empty(t, skips)
@@ -944,7 +944,7 @@ func TestGetResourcesFromPathReturnsTerraformErrorForMalformedTerraformOnlyDirec
 	dir := t.TempDir()
 	writeTerraformFixture(t, dir, `resource "kubernetes_pod_v1" "broken" {`)
 
-	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, nil)
+	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 
 	require.Error(t, err)
 	assert.Nil(t, sources)
@@ -958,7 +958,7 @@ func TestGetResourcesFromPathKeepsNoManifestErrorForUnsupportedTerraformOnlyDire
 	dir := t.TempDir()
 	writeTerraformFixture(t, dir, `resource "null_resource" "example" {}`)
 
-	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, nil)
+	sources, workloads, skips, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 
 	require.Error(t, err)
 	assert.ErrorIs(t, err, cautils.ErrNoManifestFiles)
@@ -977,7 +977,7 @@ metadata:
   name: yaml-config
 `), 0o600))
 
-	_, workloads, _, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, nil)
+	_, workloads, _, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 
 	require.NoError(t, err)
 	resources := map[string]bool{}
@@ -999,7 +999,7 @@ resources:
 `
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(kustomization), 0o600))
 
-	sources, workloads, _, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, nil)
+	sources, workloads, _, err := getResourcesFromPath(context.Background(), dir, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 
 	require.Error(t, err)
 	assert.Nil(t, sources)
@@ -1035,7 +1035,7 @@ spec:
           image: nginx:1.27
 `), 0o600))
 
-	_, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, nil)
+	_, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	counts := map[string]int{}
@@ -1080,7 +1080,7 @@ metadata:
   name: standalone
 `), 0o600))
 
-	_, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, nil)
+	_, workloads, _, err := getResourcesFromPath(context.Background(), repoRoot, cautils.HelmValueOptions{}, cautils.JsonnetOptions{}, nil)
 	require.NoError(t, err)
 
 	counts := map[string]int{}
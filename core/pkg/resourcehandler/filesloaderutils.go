@@ -8,6 +8,7 @@ import (
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/k8s-interface/k8sinterface"
 	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
 	"github.com/kubescape/opa-utils/objectsenvelopes"
 	"github.com/kubescape/opa-utils/reporthandling"
 )
@@ -15,7 +16,7 @@ import (
 // providerRank ranks discovery providers so rendered output wins over raw file input.
 func providerRank(fileType string) int {
 	switch fileType {
-	case reporthandling.SourceTypeKustomizeDirectory, reporthandling.SourceTypeHelmChart, "Terraform":
+	case reporthandling.SourceTypeKustomizeDirectory, reporthandling.SourceTypeHelmChart, "Terraform", cautils.SourceTypeJsonnet, cautils.SourceTypeCUE, cautils.SourceTypeGitOps:
 		return 2
 	case reporthandling.SourceTypeYaml, reporthandling.SourceTypeJson:
 		return 1
@@ -0,0 +1,100 @@
+package resourcehandler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+)
+
+// maxGitOpsDepth bounds app-of-apps recursion: a GitOps object rendered from
+// another is rendered in turn, up to this depth.
+const maxGitOpsDepth = 5
+
+// renderGitOpsObjects renders the Argo CD and Flux objects among workloads
+// into the resources they deploy, and adds them to the scan. Every rendered
+// resource is attributed to the file declaring its GitOps object, and mapped
+// to that object in the returned refs. Objects that cannot be rendered
+// offline are reported as skipped rather than failing the scan.
+func renderGitOpsObjects(ctx context.Context, input string, sources cautils.GitOpsSources, workloads []workloadinterface.IMetadata, workloadIDToSource map[string]reporthandling.Source) ([]workloadinterface.IMetadata, map[string]reporthandling.Source, map[string]cautils.GitOpsObjectRef, []cautils.SkippedManifest) {
+	path := input
+	if clonedRepo := cautils.GetClonedPath(input); clonedRepo != "" {
+		path = clonedRepo
+	}
+	repoRoot, gitRepo := extractGitRepo(path)
+	var repoURL string
+	if gitRepo != nil {
+		repoURL, _ = gitRepo.GetRemoteUrl()
+	}
+	renderer := cautils.NewGitOpsRenderer(sources, repoRoot, repoURL, workloads)
+
+	type queued struct {
+		object workloadinterface.IMetadata
+		depth  int
+	}
+	var queue []queued
+	for _, wl := range workloads {
+		if cautils.IsGitOpsObject(wl) {
+			queue = append(queue, queued{object: wl})
+		}
+	}
+
+	refs := map[string]cautils.GitOpsObjectRef{}
+	var skipped []cautils.SkippedManifest
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		source := workloadIDToSource[next.object.GetID()]
+
+		if next.depth >= maxGitOpsDepth {
+			skipped = append(skipped, cautils.SkippedManifest{
+				Path:   source.RelativePath,
+				Reason: fmt.Sprintf("%s %s: GitOps objects nested deeper than %d levels are not rendered", next.object.GetKind(), next.object.GetName(), maxGitOpsDepth),
+			})
+			continue
+		}
+
+		renderings, err := renderer.Render(ctx, next.object)
+		if err != nil {
+			skipped = append(skipped, cautils.SkippedManifest{
+				Path:   source.RelativePath,
+				Reason: fmt.Sprintf("%s %s: %v", next.object.GetKind(), next.object.GetName(), err),
+			})
+			continue
+		}
+
+		for _, rendering := range renderings {
+			rendering.Ref.Path = source.RelativePath
+			logger.L().Debug("rendered GitOps object",
+				helpers.String("kind", rendering.Ref.Kind),
+				helpers.String("name", rendering.Ref.Name),
+				helpers.String("renderer", rendering.Ref.Renderer),
+				helpers.Int("resources", len(rendering.Workloads)))
+			for _, wl := range rendering.Workloads {
+				workloads = append(workloads, wl)
+				workloadIDToSource[wl.GetID()] = reporthandling.Source{
+					Path:         source.Path,
+					RelativePath: source.RelativePath,
+					FileType:     cautils.SourceTypeGitOps,
+					LastCommit:   source.LastCommit,
+				}
+				refs[wl.GetID()] = rendering.Ref
+				if cautils.IsGitOpsObject(wl) {
+					queue = append(queue, queued{object: wl, depth: next.depth + 1})
+				}
+			}
+		}
+	}
+
+	workloads, workloadIDToSource = dedupWorkloads(workloads, workloadIDToSource)
+	for id := range refs {
+		if _, ok := workloadIDToSource[id]; !ok {
+			delete(refs, id)
+		}
+	}
+	return workloads, workloadIDToSource, refs, skipped
+}
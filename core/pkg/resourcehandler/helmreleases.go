@@ -0,0 +1,94 @@
+package resourcehandler
+
+import (
+	"context"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/cautils/helmrelease"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// collectHelmReleases decodes the Helm v3 releases stored in the cluster,
+// records them on the session and returns the index attributing resources to
+// them. Credentials that may not list Secrets only lose the attribution: a
+// warning is logged and the scan goes on.
+func (k8sHandler *K8sResourceHandler) collectHelmReleases(ctx context.Context, sessionObj *cautils.OPASessionObj) helmrelease.Index {
+	if k8sHandler.k8s == nil || k8sHandler.k8s.KubernetesClient == nil {
+		return nil
+	}
+
+	releases, err := k8sHandler.listHelmReleases(ctx)
+	switch {
+	case apierrors.IsForbidden(err):
+		logger.L().Ctx(ctx).Warning("skipping Helm release attribution, credentials may not list Secrets", helpers.Error(err))
+		return nil
+	case err != nil:
+		logger.L().Ctx(ctx).Warning("failed to list Helm releases", helpers.Error(err))
+		return nil
+	}
+
+	releases = helmrelease.Current(releases)
+	sessionObj.HelmReleases = make([]helmrelease.Summary, 0, len(releases))
+	for _, rel := range releases {
+		sessionObj.HelmReleases = append(sessionObj.HelmReleases, helmrelease.Summarize(rel))
+	}
+	return helmrelease.NewIndex(releases)
+}
+
+// listHelmReleases decodes every release revision stored in Secrets across
+// namespaces. Releases that cannot be decoded are skipped.
+func (k8sHandler *K8sResourceHandler) listHelmReleases(ctx context.Context) ([]*release.Release, error) {
+	var releases []*release.Release
+	err := getter.ListWithPagination(ctx, func(opts metav1.ListOptions) (string, error) {
+		opts.LabelSelector = helmrelease.SecretLabelSelector
+		opts.FieldSelector = "type=" + helmrelease.SecretType
+		chunk, err := k8sHandler.k8s.KubernetesClient.CoreV1().Secrets("").List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, secret := range chunk.Items {
+			rel, err := helmrelease.Decode(secret.Data[helmrelease.SecretReleaseKey])
+			if err != nil {
+				logger.L().Debug("skipping undecodable Helm release", helpers.String("namespace", secret.Namespace), helpers.String("name", secret.Name), helpers.Error(err))
+				continue
+			}
+			releases = append(releases, rel)
+		}
+		return chunk.GetContinue(), nil
+	})
+	return releases, err
+}
+
+// attributeToHelmReleases returns the Helm provenance of the resources index
+// attributes to a release, keyed by resource ID.
+func attributeToHelmReleases(index helmrelease.Index, resources map[string]workloadinterface.IMetadata) map[string]reporthandling.Source {
+	if len(index) == 0 {
+		return nil
+	}
+	sources := make(map[string]reporthandling.Source)
+	for resourceID, resource := range resources {
+		attribution, ok := index.Lookup(resource.GetApiVersion(), resource.GetKind(), resource.GetNamespace(), resource.GetName())
+		if !ok {
+			continue
+		}
+		source := reporthandling.Source{
+			FileType:         reporthandling.SourceTypeHelmChart,
+			HelmPath:         helmrelease.Path(attribution.Release),
+			HelmTemplateFile: attribution.Object.TemplateFile,
+			HelmValuesPaths:  attribution.Object.ValuesPaths,
+			HelmTemplateLine: attribution.Object.TemplateLine,
+		}
+		if chart := attribution.Release.Chart; chart != nil && chart.Metadata != nil {
+			source.HelmChartName = chart.Metadata.Name
+		}
+		sources[resourceID] = source
+	}
+	return sources
+}
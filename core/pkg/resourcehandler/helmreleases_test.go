@@ -0,0 +1,108 @@
+package resourcehandler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+// helmReleaseSecret stores rel the way Helm's Secret storage driver does.
+func helmReleaseSecret(t *testing.T, rel *release.Release) *corev1.Secret {
+	t.Helper()
+	data, err := json.Marshal(rel)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("sh.helm.release.v1.%s.v%d", rel.Name, rel.Version),
+			Namespace: rel.Namespace,
+			Labels:    map[string]string{"owner": "helm", "name": rel.Name},
+		},
+		Type: "helm.sh/release.v1",
+		Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))},
+	}
+}
+
+func TestCollectHelmReleases(t *testing.T) {
+	web := func(version int, status release.Status, replicas string) *release.Release {
+		return &release.Release{
+			Name:      "web",
+			Namespace: "prod",
+			Version:   version,
+			Info:      &release.Info{Status: status},
+			Chart: &chart.Chart{
+				Metadata: &chart.Metadata{Name: "web", Version: "1.2.3"},
+				Templates: []*chart.File{{Name: "templates/deployment.yaml", Data: []byte(
+					"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: {{ .Values.replicaCount }}\n",
+				)}},
+			},
+			Config:   map[string]any{"replicaCount": replicas},
+			Manifest: "---\n# Source: web/templates/deployment.yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: " + replicas + "\n",
+		}
+	}
+	client := fakeclientset.NewClientset(
+		helmReleaseSecret(t, web(1, release.StatusSuperseded, "1")),
+		helmReleaseSecret(t, web(2, release.StatusDeployed, "3")),
+	)
+	handler := &K8sResourceHandler{k8s: &k8sinterface.KubernetesApi{KubernetesClient: client, Context: context.Background()}}
+	sessionObj := cautils.NewOPASessionObjMock()
+
+	index := handler.collectHelmReleases(context.Background(), sessionObj)
+	require.Len(t, sessionObj.HelmReleases, 1)
+	assert.Equal(t, 2, sessionObj.HelmReleases[0].Revision)
+	assert.Equal(t, []string{"replicaCount"}, sessionObj.HelmReleases[0].ValuesKeys)
+
+	deployment := workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "apps/v1", "kind": "Deployment",
+		"metadata": map[string]any{"name": "web", "namespace": "prod"},
+	})
+	unmanaged := workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "apps/v1", "kind": "Deployment",
+		"metadata": map[string]any{"name": "api", "namespace": "prod"},
+	})
+	sources := attributeToHelmReleases(index, map[string]workloadinterface.IMetadata{
+		deployment.GetID(): deployment,
+		unmanaged.GetID():  unmanaged,
+	})
+	assert.Equal(t, map[string]reporthandling.Source{
+		deployment.GetID(): {
+			FileType:         reporthandling.SourceTypeHelmChart,
+			HelmPath:         "helm://prod/web",
+			HelmChartName:    "web",
+			HelmTemplateFile: "templates/deployment.yaml",
+			HelmValuesPaths:  []string{"replicaCount"},
+			HelmTemplateLine: 1,
+		},
+	}, sources)
+
+	sessionObj.ResourceSource = sources
+	releases := sessionObj.HelmReleasesWithResources()
+	require.Len(t, releases, 1)
+	assert.Equal(t, []string{deployment.GetID()}, releases[0].Resources)
+}
+
+func TestCollectHelmReleasesWithoutClient(t *testing.T) {
+	sessionObj := cautils.NewOPASessionObjMock()
+	assert.Nil(t, (&K8sResourceHandler{}).collectHelmReleases(context.Background(), sessionObj))
+	assert.Empty(t, sessionObj.HelmReleases)
+}
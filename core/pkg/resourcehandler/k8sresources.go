@@ -74,9 +74,12 @@ func (k8sHandler *K8sResourceHandler) GetResources(ctx context.Context, sessionO
 	resolver, discoveryFailures := newDiscoveryResourceResolver(k8sHandler.k8s.DiscoveryClient)
 	sessionObj.PartialGVRFailures = append(sessionObj.PartialGVRFailures, discoveryFailures...)
 
-	if scanInfo.IsDeletedScanObject {
+	switch {
+	case scanInfo.AdmissionObject != nil:
+		sessionObj.SingleResourceScan = workloadinterface.NewWorkloadObj(scanInfo.AdmissionObject)
+	case scanInfo.IsDeletedScanObject:
 		sessionObj.SingleResourceScan, err = getWorkloadFromScanObject(scanInfo.ScanObject)
-	} else {
+	default:
 		sessionObj.SingleResourceScan, err = k8sHandler.findScanObjectResource(ctx, scanInfo.ScanObject, globalFieldSelectors, resolver)
 	}
 
@@ -208,6 +211,11 @@ func (k8sHandler *K8sResourceHandler) GetResources(ctx context.Context, sessionO
 		k8sHandler.collectVAPResources(ctx, sessionObj)
 	}
 
+	if scanInfo.HelmReleases {
+		index := k8sHandler.collectHelmReleases(ctx, sessionObj)
+		maps.Copy(sessionObj.ResourceSource, attributeToHelmReleases(index, allResources))
+	}
+
 	return k8sResourcesMap, allResources, ksResourceMap, excludedRulesMap, nil
 }
 
@@ -263,9 +271,12 @@ func (k8sHandler *K8sResourceHandler) StreamResourcesBatches(ctx context.Context
 	sessionObj.PartialGVRFailures = append(sessionObj.PartialGVRFailures, discoveryFailures...)
 
 	var setupErr error
-	if scanInfo.IsDeletedScanObject {
+	switch {
+	case scanInfo.AdmissionObject != nil:
+		sessionObj.SingleResourceScan = workloadinterface.NewWorkloadObj(scanInfo.AdmissionObject)
+	case scanInfo.IsDeletedScanObject:
 		sessionObj.SingleResourceScan, setupErr = getWorkloadFromScanObject(scanInfo.ScanObject)
-	} else {
+	default:
 		sessionObj.SingleResourceScan, setupErr = k8sHandler.findScanObjectResource(ctx, scanInfo.ScanObject, globalFieldSelectors, resolver)
 	}
 
@@ -523,6 +534,16 @@ func (k8sHandler *K8sResourceHandler) collectAndStreamBatches(ctx context.Contex
 		k8sHandler.collectVAPResources(ctx, sessionObj)
 	}
 
+	// Like the resources, their Helm provenance travels with the batches
+	// rather than being written to sessionObj.ResourceSource here.
+	if scanInfo.HelmReleases {
+		index := k8sHandler.collectHelmReleases(ctx, sessionObj)
+		resident.ResourceSource = attributeToHelmReleases(index, resident.AllResources)
+		for _, batch := range namespaceBatches {
+			batch.ResourceSource = attributeToHelmReleases(index, batch.AllResources)
+		}
+	}
+
 	for groupResource, ids := range ksResourceMap {
 		for _, id := range ids {
 			if _, ok := allResources[id]; ok {
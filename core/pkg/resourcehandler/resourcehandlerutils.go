@@ -35,6 +35,11 @@ func addSingleResourceToResourceMaps(k8sResources cautils.K8SResources, allResou
 			continue
 		}
 		seen[resourceGroup] = struct{}{}
+		// The pulled resources hold the stored version of an object under
+		// admission review; the map keeps one ID, now pointing at wl.
+		if slices.Contains(k8sResources[resourceGroup], wl.GetID()) {
+			continue
+		}
 		k8sResources[resourceGroup] = append(k8sResources[resourceGroup], wl.GetID())
 	}
 	allResources[wl.GetID()] = wl
@@ -167,6 +167,20 @@ func TestAddSingleResourceToResourceMaps_KnownApiVersion(t *testing.T) {
 	assert.Contains(t, k8sResources["apps/v1/deployments"], wl.GetID())
 }
 
+func TestAddSingleResourceToResourceMaps_ReplacesPulledVersion(t *testing.T) {
+	k8sinterface.InitializeMapResourcesMock()
+
+	pulled := mockWorkload("apps/v1", "Deployment", "default", "nginx")
+	admitted := mockWorkload("apps/v1", "Deployment", "default", "nginx")
+	k8sResources := cautils.K8SResources{"apps/v1/deployments": {pulled.GetID()}}
+	allResources := map[string]workloadinterface.IMetadata{pulled.GetID(): pulled}
+
+	addSingleResourceToResourceMaps(k8sResources, allResources, admitted, defaultResourceResolver)
+
+	assert.Equal(t, []string{admitted.GetID()}, k8sResources["apps/v1/deployments"])
+	assert.Same(t, admitted, allResources[admitted.GetID()])
+}
+
 func TestFilterRuleMatchesForResource_Wildcard(t *testing.T) {
 	match := []reporthandling.RuleMatchObjects{{
 		APIGroups:   []string{"*"},
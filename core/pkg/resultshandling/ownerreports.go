@@ -0,0 +1,154 @@
+package resultshandling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
+	"github.com/kubescape/kubescape/v4/core/pkg/score"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	helpersv1 "github.com/kubescape/opa-utils/reporthandling/helpers/v1"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+)
+
+// writeOwnerReports writes, for each owner of the ownership file and for the
+// resources no owner claims, a report in every requested format to
+// <dir>/<owner>.<ext>. Each report holds the owner's resources only, and its
+// scores and counters are computed over them.
+func writeOwnerReports(ctx context.Context, sessionObj *cautils.OPASessionObj, scanInfo *cautils.ScanInfo, dir string) error {
+	if sessionObj == nil || sessionObj.Report == nil || sessionObj.Ownership == nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create per-owner report directory: %w", err)
+	}
+
+	owners := append(sessionObj.Ownership.OwnerList(), cautils.Owner{Name: cautils.Unowned})
+	var errs error
+	for _, owner := range owners {
+		ownerObj := ownerSession(sessionObj, owner.Name)
+		if owner.Name == cautils.Unowned && len(ownerObj.AllResources) == 0 {
+			continue
+		}
+		if err := score.NewScoreWrapper(ownerObj).Calculate(score.EPostureReportV2); err != nil {
+			logger.L().Ctx(ctx).Warning("failed to calculate owner scores", helpers.String("owner", owner.Name), helpers.Error(err))
+		}
+		recomputeSeverityCounters(ownerObj)
+
+		written := map[string]bool{}
+		for _, format := range scanInfo.Formats() {
+			if _, err := ValidatePrinter(scanInfo.ScanType, scanInfo.GetScanningContext(), format); err != nil {
+				continue
+			}
+			outputFile, _ := printer.ResolveOutputFile(format, filepath.Join(dir, owner.Name), owner.Name)
+			if written[outputFile] {
+				continue
+			}
+			written[outputFile] = true
+
+			p := NewPrinter(ctx, format, scanInfo, sessionObj.Report.ClusterName)
+			if err := p.SetWriter(ctx, outputFile); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("owner %s: configure %q output: %w", owner.Name, format, err))
+				continue
+			}
+			if err := p.ActionPrint(ctx, ownerObj, nil); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("owner %s: %q printer: %w", owner.Name, format, err))
+			}
+			if err := closePrinter(p); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("owner %s: %q printer close: %w", owner.Name, format, err))
+			}
+		}
+	}
+	return errs
+}
+
+// ownerSession returns a copy of sessionObj narrowed to the resources owned by
+// owner, or to the unowned resources for cautils.Unowned. The control
+// summaries are rebuilt over the owned resources; the scores are left to the
+// caller. sessionObj is not modified.
+func ownerSession(sessionObj *cautils.OPASessionObj, owner string) *cautils.OPASessionObj {
+	owned := func(resourceID string) bool {
+		resourceOwner := sessionObj.ResourceOwners[resourceID]
+		return resourceOwner == owner || resourceOwner == "" && owner == cautils.Unowned
+	}
+
+	ownerObj := *sessionObj
+	ownerObj.AllResources = make(map[string]workloadinterface.IMetadata)
+	for resourceID, resource := range sessionObj.AllResources {
+		if owned(resourceID) {
+			ownerObj.AllResources[resourceID] = resource
+		}
+	}
+	ownerObj.ResourcesResult = make(map[string]resourcesresults.Result)
+	for resourceID, result := range sessionObj.ResourcesResult {
+		if owned(resourceID) {
+			ownerObj.ResourcesResult[resourceID] = result
+		}
+	}
+	ownerObj.ResourceSource = make(map[string]reporthandling.Source)
+	for resourceID, source := range sessionObj.ResourceSource {
+		if owned(resourceID) {
+			ownerObj.ResourceSource[resourceID] = source
+		}
+	}
+	ownerObj.ResourceOwners = make(map[string]string)
+	for resourceID, resourceOwner := range sessionObj.ResourceOwners {
+		if owned(resourceID) {
+			ownerObj.ResourceOwners[resourceID] = resourceOwner
+		}
+	}
+	var topWorkloads []reporthandling.IResource
+	for _, workload := range sessionObj.TopWorkloadsByScore {
+		if owned(workload.GetID()) {
+			topWorkloads = append(topWorkloads, workload)
+		}
+	}
+	ownerObj.TopWorkloadsByScore = topWorkloads
+
+	report := *sessionObj.Report
+	summary := &report.SummaryDetails
+	controlInfo := make(map[string]apis.StatusInfo)
+	summary.Controls = make(reportsummary.ControlSummaries, len(sessionObj.Report.SummaryDetails.Controls))
+	for controlID, control := range sessionObj.Report.SummaryDetails.Controls {
+		if len(control.ResourceIDs.All()) == 0 {
+			// Controls that evaluated no resource keep the reason why.
+			controlInfo[controlID] = control.StatusInfo
+		}
+		control.ResourceIDs = ownedResourceIDs(control.ResourceIDs.All(), owned)
+		summary.Controls[controlID] = control
+	}
+	summary.Frameworks = make([]reportsummary.FrameworkSummary, len(sessionObj.Report.SummaryDetails.Frameworks))
+	for i, framework := range sessionObj.Report.SummaryDetails.Frameworks {
+		controls := make(reportsummary.ControlSummaries, len(framework.Controls))
+		for controlID := range framework.Controls {
+			if control, ok := summary.Controls[controlID]; ok {
+				controls[controlID] = control
+			}
+		}
+		framework.Controls = controls
+		summary.Frameworks[i] = framework
+	}
+	summary.InitResourcesSummary(controlInfo)
+	ownerObj.Report = &report
+
+	return &ownerObj
+}
+
+func ownedResourceIDs(resourceIDs map[string]apis.ScanningStatus, owned func(string) bool) helpersv1.AllLists {
+	var ownedIDs helpersv1.AllLists
+	for resourceID, status := range resourceIDs {
+		if owned(resourceID) {
+			ownedIDs.Append(status, resourceID)
+		}
+	}
+	return ownedIDs
+}
@@ -186,3 +186,37 @@ func TestHtmlPrinter_ActionPrint_RiskScoreRounding(t *testing.T) {
 	assert.Contains(t, htmlContent, `<td class="controlRiskCell numericCell">1</td>`)
 	assert.NotContains(t, htmlContent, `<td class="controlRiskCell numericCell">0</td>`)
 }
+
+func TestHtmlPrinter_ActionPrint_StandardReports(t *testing.T) {
+	ctx := context.Background()
+	out := filepath.Join(t.TempDir(), "report.html")
+
+	hp := NewHtmlPrinter()
+	require.NoError(t, hp.SetWriter(ctx, out))
+
+	session := cautils.NewOPASessionObjMock()
+	session.StandardReports = []cautils.StandardReport{{
+		Standard:        "pci-dss",
+		Name:            "PCI DSS v4.0",
+		ComplianceScore: 50,
+		Clauses: []cautils.ClauseReport{{
+			ID:              "7.2.1",
+			Name:            "An access control model is defined",
+			Status:          apis.StatusFailed,
+			ComplianceScore: 50,
+			Controls:        []string{"C-0035"},
+			FailedControls:  []string{"C-0035"},
+			FailedResources: []string{"rbac.authorization.k8s.io/v1//ClusterRoleBinding/admins"},
+		}},
+	}}
+	require.NoError(t, hp.ActionPrint(ctx, session, nil))
+	require.NoError(t, hp.CloseWriter())
+
+	content, err := os.ReadFile(out)
+	require.NoError(t, err)
+	htmlContent := string(content)
+
+	assert.Contains(t, htmlContent, "<h2>PCI DSS v4.0: 50.00%</h2>")
+	assert.Contains(t, htmlContent, `<td class="controlSeverityCell">7.2.1</td>`)
+	assert.Contains(t, htmlContent, "<p>rbac.authorization.k8s.io/v1//ClusterRoleBinding/admins</p>")
+}
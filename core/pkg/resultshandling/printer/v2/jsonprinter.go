@@ -97,6 +97,17 @@ func printConfigurationsScanning(opaSessionObj *cautils.OPASessionObj, imageScan
 	// extract specified labels from workloads, and attach scan coverage gaps.
 	reportWithSeverity := ConvertToPostureReportWithSeverityLabelsAndCoverage(finalizedReport, opaSessionObj.LabelsToCopy, opaSessionObj.AllResources, &opaSessionObj.ScanCoverage)
 	reportWithSeverity.ExceptionAudit = opaSessionObj.ExceptionAudit
+	reportWithSeverity.ScoringProfile = opaSessionObj.ScoringProfile
+	reportWithSeverity.FrameworkDefinitions = opaSessionObj.FrameworkDefinitions
+	reportWithSeverity.FrameworkSections = opaSessionObj.FrameworkSections
+	reportWithSeverity.Crosswalks = opaSessionObj.Crosswalks
+	reportWithSeverity.StandardReports = opaSessionObj.StandardReports
+	reportWithSeverity.ControlInputsScopes = opaSessionObj.ControlInputsScopes
+	reportWithSeverity.ResourceInputsScopes = opaSessionObj.ResourceInputsScopes
+	reportWithSeverity.RuntimeScore = opaSessionObj.RuntimeScore
+	reportWithSeverity.GitOpsObjects = opaSessionObj.GitOpsObjects
+	reportWithSeverity.HelmReleases = opaSessionObj.HelmReleasesWithResources()
+	reportWithSeverity.SetOwners(opaSessionObj.Ownership, opaSessionObj.ResourceOwners)
 
 	r, err := json.Marshal(reportWithSeverity)
 	if err != nil {
@@ -0,0 +1,118 @@
+package printer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+)
+
+// ownerTable prints the failed resources of each owner of the ownership file,
+// followed by the resources no owner claims.
+func (prettyPrinter *PrettyPrinter) ownerTable(opaSessionObj *cautils.OPASessionObj) {
+	if opaSessionObj.Ownership == nil {
+		return
+	}
+	failed := failedResourcesByOwner(opaSessionObj.ResourcesResult, opaSessionObj.ResourceOwners)
+
+	for _, owner := range append(opaSessionObj.Ownership.OwnerList(), cautils.Owner{Name: cautils.Unowned}) {
+		resourceIDs := failed[owner.Name]
+		if owner.Name == cautils.Unowned && len(resourceIDs) == 0 {
+			continue
+		}
+		fmt.Fprintf(prettyPrinter.writer, "\n%s\n", getSeparator("#"))
+		fmt.Fprintf(prettyPrinter.writer, "Owner: %s\n", owner.Name)
+		if contact := ownerContact(owner); contact != "" {
+			fmt.Fprintf(prettyPrinter.writer, "Contact: %s\n", contact)
+		}
+		if len(resourceIDs) == 0 {
+			fmt.Fprintf(prettyPrinter.writer, "\nNo failed resources\n")
+			continue
+		}
+		fmt.Fprintf(prettyPrinter.writer, "Failed resources: %d\n\n", len(resourceIDs))
+
+		ownerTable := table.NewWriter()
+		ownerTable.SetOutputMirror(prettyPrinter.writer)
+
+		ownerTable.Style().Options.SeparateHeader = true
+		ownerTable.Style().Options.SeparateRows = true
+		ownerTable.Style().Format.HeaderAlign = text.AlignLeft
+		ownerTable.Style().Format.Header = text.FormatDefault
+		ownerTable.Style().Box = table.StyleBoxRounded
+
+		ownerTable.AppendHeader(table.Row{"Severity", "Resource", "Control name", "Docs"})
+		for _, resourceID := range resourceIDs {
+			result := opaSessionObj.ResourcesResult[resourceID]
+			ownerTable.AppendRows(generateOwnerRows(resourceName(opaSessionObj, resourceID), result.ListControls(), &opaSessionObj.Report.SummaryDetails))
+		}
+		ownerTable.Render()
+	}
+}
+
+// failedResourcesByOwner groups the IDs of the failed resources by owner,
+// sorted. Resources without an owner are grouped under cautils.Unowned.
+func failedResourcesByOwner(results map[string]resourcesresults.Result, resourceOwners map[string]string) map[string][]string {
+	failed := map[string][]string{}
+	for resourceID, result := range results {
+		if !result.GetStatus(nil).IsFailed() {
+			continue
+		}
+		owner := resourceOwners[resourceID]
+		if owner == "" {
+			owner = cautils.Unowned
+		}
+		failed[owner] = append(failed[owner], resourceID)
+	}
+	for _, resourceIDs := range failed {
+		sort.Strings(resourceIDs)
+	}
+	return failed
+}
+
+// ownerContact joins the ways to reach owner.
+func ownerContact(owner cautils.Owner) string {
+	var contact []string
+	if len(owner.Contacts) > 0 {
+		contact = append(contact, strings.Join(owner.Contacts, ", "))
+	}
+	if owner.Slack != "" {
+		contact = append(contact, "Slack "+owner.Slack)
+	}
+	if owner.Email != "" {
+		contact = append(contact, owner.Email)
+	}
+	return strings.Join(contact, " | ")
+}
+
+// resourceName names a resource as <kind>/<namespace>/<name>, or by ID when
+// it is unknown.
+func resourceName(opaSessionObj *cautils.OPASessionObj, resourceID string) string {
+	resource, ok := opaSessionObj.AllResources[resourceID]
+	if !ok {
+		return resourceID
+	}
+	if resource.GetNamespace() == "" {
+		return resource.GetKind() + "/" + resource.GetName()
+	}
+	return resource.GetKind() + "/" + resource.GetNamespace() + "/" + resource.GetName()
+}
+
+func generateOwnerRows(resource string, controls []resourcesresults.ResourceAssociatedControl, summaryDetails *reportsummary.SummaryDetails) []table.Row {
+	var rows []table.Row
+	for i := range controls {
+		if !controls[i].GetStatus(nil).IsFailed() {
+			continue
+		}
+		var severity string
+		if c := summaryDetails.Controls.GetControl(reportsummary.EControlCriteriaID, controls[i].GetID()); c != nil {
+			severity = getSeverityColumn(c)
+		}
+		rows = append(rows, table.Row{severity, resource, controls[i].GetName(), cautils.GetControlLink(controls[i].GetID())})
+	}
+	return rows
+}
@@ -0,0 +1,65 @@
+package printer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedResourcesByOwner(t *testing.T) {
+	failed := resourcesresults.Result{AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+		{ControlID: "C-0012", Status: apis.StatusInfo{InnerStatus: apis.StatusFailed}},
+	}}
+	passed := resourcesresults.Result{AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+		{ControlID: "C-0012", Status: apis.StatusInfo{InnerStatus: apis.StatusPassed}},
+	}}
+
+	got := failedResourcesByOwner(map[string]resourcesresults.Result{
+		"b": failed,
+		"a": failed,
+		"c": passed,
+		"d": failed,
+	}, map[string]string{"a": "payments", "b": "payments", "c": "payments"})
+
+	assert.Equal(t, map[string][]string{
+		"payments":      {"a", "b"},
+		cautils.Unowned: {"d"},
+	}, got)
+}
+
+func TestOwnerContact(t *testing.T) {
+	assert.Equal(t, "alice, bob | Slack #payments | payments@example.com", ownerContact(cautils.Owner{
+		Name:     "payments",
+		Contacts: []string{"alice", "bob"},
+		Slack:    "#payments",
+		Email:    "payments@example.com",
+	}))
+	assert.Empty(t, ownerContact(cautils.Owner{Name: "payments"}))
+}
+
+func TestActionPrint_OwnerView(t *testing.T) {
+	pp, read := newControlViewPrettyPrinter(t, false)
+	pp.viewType = cautils.OwnerViewType
+	session := controlViewAssistedRemediationSession()
+	session.Ownership = &cautils.Ownership{Owners: []cautils.Owner{
+		{Name: "payments", Slack: "#payments"},
+		{Name: "platform"},
+	}}
+	session.ResourceOwners = map[string]string{}
+	for resourceID := range session.ResourcesResult {
+		session.ResourceOwners[resourceID] = "payments"
+	}
+
+	require.NoError(t, pp.ActionPrint(context.Background(), session, nil))
+	out := read()
+
+	assert.Contains(t, out, "Owner: payments\nContact: Slack #payments\nFailed resources: 1")
+	assert.Contains(t, out, "Applications credentials in configuration files")
+	assert.Contains(t, out, "Owner: platform\n\nNo failed resources")
+	assert.NotContains(t, out, "Owner: "+cautils.Unowned, "the unowned group is left out when empty")
+}
@@ -1,6 +1,7 @@
 package printer
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v2/pdf"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v2/prettyprinter/tableprinter/imageprinter"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v2/prettyprinter/tableprinter/utils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
 	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
 )
 
@@ -75,7 +77,7 @@ func (pp *PdfPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OP
 	var err error
 
 	if opaSessionObj != nil {
-		outBuff, err = pp.generatePdf(&opaSessionObj.Report.SummaryDetails)
+		outBuff, err = pp.generatePdf(&opaSessionObj.Report.SummaryDetails, opaSessionObj.StandardReports)
 	} else if len(imageScanData) > 0 {
 		outBuff, err = pp.generateImagePdf(imageScanData)
 	} else {
@@ -143,7 +145,7 @@ func (pp *PdfPrinter) getImageTableObjects(cves []imageprinter.CVE) (*[]pdf.Imag
 	return &rows, fixableCVEs
 }
 
-func (pp *PdfPrinter) generatePdf(summaryDetails *reportsummary.SummaryDetails) ([]byte, error) {
+func (pp *PdfPrinter) generatePdf(summaryDetails *reportsummary.SummaryDetails, standardReports []cautils.StandardReport) ([]byte, error) {
 	sortedControlIDs := getSortedControlsIDs(summaryDetails.Controls)
 	infoToPrintInfo := mapInfoToPrintInfo(summaryDetails.Controls)
 
@@ -156,9 +158,27 @@ func (pp *PdfPrinter) generatePdf(summaryDetails *reportsummary.SummaryDetails)
 		return nil, err
 	}
 	template.GenerateInfoRows(pp.getFormattedInformation(infoToPrintInfo))
+	for i := range standardReports {
+		report := &standardReports[i]
+		if err := template.GenerateClauseTable(cmp.Or(report.Name, report.Standard), report.ComplianceScore, pp.getClauseTableObjects(report)); err != nil {
+			return nil, err
+		}
+	}
 	return template.GetPdf()
 }
 
+// getClauseTableObjects converts the clauses of a standard report into PDF table rows
+func (pp *PdfPrinter) getClauseTableObjects(report *cautils.StandardReport) *[]pdf.ClauseTableObject {
+	rows := make([]pdf.ClauseTableObject, 0, len(report.Clauses))
+	for _, clause := range report.Clauses {
+		rows = append(rows, *pdf.NewClauseTableRow(
+			string(clause.Status), clause.ID, clause.Name, strings.Join(clause.FailedControls, ", "),
+			fmt.Sprintf("%d", len(clause.FailedResources)), cautils.ComplianceScoreToString(clause.ComplianceScore, 2)+"%", getStatusColor,
+		))
+	}
+	return &rows
+}
+
 func (pp *PdfPrinter) getFormattedInformation(infoMap []infoStars) []string {
 	rows := make([]string, 0, len(infoMap))
 	for i := range infoMap {
@@ -196,6 +216,16 @@ func getSeverityColor(severity string) *props.Color {
 	return &props.BlackColor
 }
 
+func getStatusColor(status string) *props.Color {
+	switch apis.ScanningStatus(status) {
+	case apis.StatusFailed:
+		return &props.Color{Red: 255, Green: 0, Blue: 0}
+	case apis.StatusPassed:
+		return &props.Color{Red: 0, Green: 128, Blue: 0}
+	}
+	return &props.BlackColor
+}
+
 // CloseWriter closes the PDF output writer, returning any error from flushing or closing.
 func (p *PdfPrinter) CloseWriter() error {
 	if p.writer != nil && p.writer != os.Stdout {
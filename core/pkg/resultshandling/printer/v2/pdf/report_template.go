@@ -116,6 +116,27 @@ func (t *Template) GenerateImageTable(tableRows *[]ImageTableObject, totalCVEs,
 	return nil
 }
 
+// GenerateClauseTable is responsible for adding the clauses of a standard the results are reported as in table format to the pdf
+func (t *Template) GenerateClauseTable(standard string, score float32, tableRows *[]ClauseTableObject) error {
+	t.maroto.AddRow(10, text.NewCol(12, fmt.Sprintf("%s: %s%%", standard, cautils.ComplianceScoreToString(score, 2)), props.Text{
+		Align:  align.Left,
+		Size:   8,
+		Style:  fontstyle.Bold,
+		Family: fontfamily.Arial,
+		Top:    4,
+	}))
+	rows, err := list.Build[ClauseTableObject](*tableRows)
+	if err != nil {
+		return err
+	}
+	t.maroto.AddRows(rows...)
+	t.maroto.AddRows(
+		line.NewAutoRow(props.Line{Thickness: 0.3, SizePercent: 100}),
+		row.New(2),
+	)
+	return nil
+}
+
 func (t *Template) generateImageTableResult(totalCVEs, fixableCVEs int) {
 	defaultProps := props.Text{
 		Align:  align.Left,
@@ -278,3 +299,60 @@ func (t ImageTableObject) GetContent(i int) core.Row {
 
 	return r
 }
+
+// ClauseTableObject maps a single clause row of a standard the results are reported as
+type ClauseTableObject struct {
+	status          string
+	id              string
+	name            string
+	failedControls  string
+	failedResources string
+	complianceScore string
+	getTextColor    getTextColorFunc
+}
+
+func NewClauseTableRow(status, id, name, failedControls, failedResources, score string, getTextColor getTextColorFunc) *ClauseTableObject {
+	return &ClauseTableObject{
+		status:          status,
+		id:              id,
+		name:            name,
+		failedControls:  failedControls,
+		failedResources: failedResources,
+		complianceScore: score,
+		getTextColor:    getTextColor,
+	}
+}
+
+func (t ClauseTableObject) GetHeader() core.Row {
+	return row.New(10).Add(
+		text.NewCol(1, "Status", props.Text{Size: 6, Family: fontfamily.Arial, Style: fontstyle.Bold}),
+		text.NewCol(1, "Clause", props.Text{Size: 6, Family: fontfamily.Arial, Style: fontstyle.Bold}),
+		text.NewCol(5, "Clause name", props.Text{Size: 6, Family: fontfamily.Arial, Style: fontstyle.Bold}),
+		text.NewCol(2, "Failed controls", props.Text{Size: 6, Family: fontfamily.Arial, Style: fontstyle.Bold}),
+		text.NewCol(1, "Failed resources", props.Text{Size: 6, Family: fontfamily.Arial, Style: fontstyle.Bold}),
+		text.NewCol(2, "Compliance score", props.Text{Size: 6, Family: fontfamily.Arial, Style: fontstyle.Bold}),
+	)
+}
+
+func (t ClauseTableObject) GetContent(i int) core.Row {
+	r := row.New(3).Add(
+		text.NewCol(1, t.status, props.Text{Style: fontstyle.Normal, Family: fontfamily.Courier, Size: 6, Color: t.getTextColor(t.status)}),
+		text.NewCol(1, t.id, props.Text{Style: fontstyle.Normal, Family: fontfamily.Courier, Size: 6, Color: &props.Color{}}),
+		text.NewCol(5, t.name, props.Text{Style: fontstyle.Normal, Family: fontfamily.Courier, Size: 6}),
+		text.NewCol(2, t.failedControls, props.Text{Style: fontstyle.Normal, Family: fontfamily.Courier, Size: 6}),
+		text.NewCol(1, t.failedResources, props.Text{Style: fontstyle.Normal, Family: fontfamily.Courier, Size: 6}),
+		text.NewCol(2, t.complianceScore, props.Text{VerticalPadding: 1, Style: fontstyle.Normal, Family: fontfamily.Courier, Size: 6}),
+	)
+
+	if i%2 == 0 {
+		r.WithStyle(&props.Cell{
+			BackgroundColor: &props.Color{
+				Red:   224,
+				Green: 224,
+				Blue:  224,
+			},
+		})
+	}
+
+	return r
+}
@@ -56,3 +56,21 @@ func TestGenerateInfoRows(t *testing.T) {
 		test.New(t).Assert(template.GetStructure()).Equals("infoTemplate.json")
 	})
 }
+
+func TestGenerateClauseTable(t *testing.T) {
+	t.Run("when generateClauseTable is called, it should add the standard and its clauses to the pdf", func(t *testing.T) {
+		clauseTableObjectMock := pdf.NewClauseTableRow(
+			"failed", "A.8.2", "Privileged access rights", "C-0035, C-0057", "3", "25.00%",
+			func(status string) *props.Color { return &props.Color{Red: 0, Blue: 0, Green: 0} },
+		)
+
+		template := pdf.NewReportTemplate()
+
+		err := template.GenerateClauseTable("ISO/IEC 27001:2022 Annex A", 25, &[]pdf.ClauseTableObject{*clauseTableObjectMock})
+
+		assert.Nil(t, err)
+		bytes, err := template.GetPdf()
+		assert.Nil(t, err)
+		assert.NotEmpty(t, bytes)
+	})
+}
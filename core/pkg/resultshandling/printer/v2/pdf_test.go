@@ -8,6 +8,8 @@ import (
 
 	"github.com/anchore/grype/grype/match"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -173,3 +175,25 @@ func TestGenerateImagePdf_NoVulnerabilities(t *testing.T) {
 		t.Fatal("expected non-empty PDF bytes for clean image")
 	}
 }
+
+func TestGetClauseTableObjects(t *testing.T) {
+	pp := NewPdfPrinter()
+	rows := pp.getClauseTableObjects(&cautils.StandardReport{
+		Standard: "soc2",
+		Clauses: []cautils.ClauseReport{
+			{ID: "CC6.1", Status: apis.StatusFailed, ComplianceScore: 50, FailedControls: []string{"C-0035", "C-0185"}, FailedResources: []string{"a", "b"}},
+			{ID: "CC6.6", Status: apis.StatusSkipped},
+		},
+	})
+	if len(*rows) != 2 {
+		t.Fatalf("expected one row per clause, got %d", len(*rows))
+	}
+
+	out, err := pp.generatePdf(&reportsummary.SummaryDetails{Controls: reportsummary.ControlSummaries{"C-0035": {ControlID: "C-0035", Name: "Administrative Roles", ScoreFactor: 6}}}, []cautils.StandardReport{{Standard: "soc2", Clauses: []cautils.ClauseReport{{ID: "CC6.1", Status: apis.StatusPassed}}}})
+	if err != nil {
+		t.Fatalf("expected no error generating PDF with a standard report, got: %v", err)
+	}
+	if len(out) == 0 {
+		t.Fatal("expected non-empty PDF bytes")
+	}
+}
@@ -1,6 +1,7 @@
 package printer
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
@@ -101,6 +102,8 @@ func (pp *PrettyPrinter) ActionPrint(_ context.Context, opaSessionObj *cautils.O
 			if pp.verboseMode {
 				pp.resourceTable(opaSessionObj)
 			}
+		case cautils.OwnerViewType:
+			pp.ownerTable(opaSessionObj)
 		}
 
 		pp.printOverview(opaSessionObj, pp.verboseMode)
@@ -108,6 +111,10 @@ func (pp *PrettyPrinter) ActionPrint(_ context.Context, opaSessionObj *cautils.O
 		pp.mainPrinter.PrintConfigurationsScanning(&opaSessionObj.Report.SummaryDetails, sortedControlIDs, opaSessionObj.TopWorkloadsByScore)
 
 		pp.printScanCoverage(opaSessionObj.ScanCoverage)
+		pp.printScoringProfile(opaSessionObj.ScoringProfile)
+		pp.printFrameworkSections(opaSessionObj.FrameworkSections)
+		pp.printStandardReports(opaSessionObj.StandardReports)
+		pp.printRuntimeScore(opaSessionObj.RuntimeScore)
 
 		// When writing to Stdout, we aren’t really writing to an output file,
 		// so no need to print that we are
@@ -355,6 +362,72 @@ func isPrintSeparatorType(scanType cautils.ScanTypes) bool {
 	}
 }
 
+// printScoringProfile notes that the compliance scores above were computed
+// with a scoring profile rather than the default scoring.
+func (pp *PrettyPrinter) printScoringProfile(profile *cautils.ScoringProfile) {
+	if profile == nil {
+		return
+	}
+	fmt.Fprintf(pp.writer, "\nCompliance scores computed with scoring profile %q (%s)\n", profile.Name, profile.Source)
+}
+
+// printFrameworkSections prints the compliance score of every section of the
+// scanned framework definitions.
+func (pp *PrettyPrinter) printFrameworkSections(sections []cautils.FrameworkSectionScore) {
+	if len(sections) == 0 {
+		return
+	}
+	fmt.Fprintf(pp.writer, "\nFramework sections:\n")
+	for _, section := range sections {
+		name := section.ID
+		if section.Name != "" {
+			name += " " + section.Name
+		}
+		fmt.Fprintf(pp.writer, "  %s / %s: %.2f%% (%d controls)\n", section.Framework, name, section.ComplianceScore, len(section.Controls))
+	}
+}
+
+// printStandardReports prints the status and score of every clause of the
+// standards the results are reported as, with the failed supporting controls.
+func (pp *PrettyPrinter) printStandardReports(reports []cautils.StandardReport) {
+	for _, report := range reports {
+		name := cmp.Or(report.Name, report.Standard)
+		fmt.Fprintf(pp.writer, "\n%s: %.2f%%\n", name, report.ComplianceScore)
+		for _, clause := range report.Clauses {
+			fmt.Fprintf(pp.writer, "  %-8s %s %s: %.2f%% (%d controls)\n", clause.Status, clause.ID, clause.Name, clause.ComplianceScore, len(clause.Controls))
+			if len(clause.FailedControls) > 0 {
+				fmt.Fprintf(pp.writer, "           failed: %s, %d resources\n", strings.Join(clause.FailedControls, ", "), len(clause.FailedResources))
+			}
+		}
+	}
+}
+
+// printRuntimeScore prints the runtime-adjusted compliance score next to the
+// static one, and the workloads runtime telemetry boosted.
+func (pp *PrettyPrinter) printRuntimeScore(runtimeScore *cautils.RuntimeScore) {
+	if runtimeScore == nil {
+		return
+	}
+	fmt.Fprintf(pp.writer, "\nRuntime-adjusted compliance score: %.2f%% (static: %.2f%%, from %d runtime events)\n",
+		runtimeScore.ComplianceScore, runtimeScore.StaticComplianceScore, runtimeScore.Events)
+	for _, workload := range runtimeScore.Workloads {
+		if workload.Adjustment != cautils.RuntimeBoosted {
+			continue
+		}
+		var evidence []string
+		if len(workload.Shells) > 0 {
+			evidence = append(evidence, "ran "+strings.Join(workload.Shells, ", "))
+		}
+		if len(workload.OutboundConnections) > 0 {
+			evidence = append(evidence, "connected to "+strings.Join(workload.OutboundConnections, ", "))
+		}
+		if len(workload.Capabilities) > 0 {
+			evidence = append(evidence, "used "+strings.Join(workload.Capabilities, ", "))
+		}
+		fmt.Fprintf(pp.writer, "  %s %s/%s: %s\n", workload.Kind, workload.Namespace, workload.Name, strings.Join(evidence, "; "))
+	}
+}
+
 // printScanCoverage prints a "Scan Coverage" warning block when GVR pull
 // failures caused controls to be skipped or partial data was collected.
 // Nothing is printed on a clean scan.
@@ -464,3 +464,40 @@ func TestActionPrint_ControlViewVerboseOmitsRemediationOnPassedAndSkipped(t *tes
 	assert.NotContains(t, out, "spec.hostPID")
 	assert.NotContains(t, out, "spec.hostNetwork")
 }
+
+func TestPrintFrameworkSections(t *testing.T) {
+	pp, read := newTestPrettyPrinterFile(t)
+	pp.printFrameworkSections([]cautils.FrameworkSectionScore{
+		{Framework: "golden-baseline", ID: "1", Name: "Workload hardening", Controls: []string{"C-0017", "C-0044"}, ComplianceScore: 75},
+		{Framework: "golden-baseline", ID: "2", Controls: []string{"custom-no-latest"}, ComplianceScore: 20},
+	})
+
+	out := read()
+	assert.Contains(t, out, "golden-baseline / 1 Workload hardening: 75.00% (2 controls)")
+	assert.Contains(t, out, "golden-baseline / 2: 20.00% (1 controls)")
+}
+
+func TestPrintFrameworkSections_NoSectionsNoOutput(t *testing.T) {
+	pp, read := newTestPrettyPrinterFile(t)
+	pp.printFrameworkSections(nil)
+	assert.Empty(t, read())
+}
+
+func TestPrintStandardReports(t *testing.T) {
+	pp, read := newTestPrettyPrinterFile(t)
+	pp.printStandardReports([]cautils.StandardReport{{
+		Standard:        "iso27001",
+		Name:            "ISO/IEC 27001:2022 Annex A",
+		ComplianceScore: 62.5,
+		Clauses: []cautils.ClauseReport{
+			{ID: "A.8.2", Name: "Privileged access rights", Status: apis.StatusFailed, ComplianceScore: 25, Controls: []string{"C-0035", "C-0057"}, FailedControls: []string{"C-0035", "C-0057"}, FailedResources: []string{"a", "b", "c"}},
+			{ID: "A.8.9", Name: "Configuration management", Status: apis.StatusPassed, ComplianceScore: 100, Controls: []string{"C-0017"}},
+		},
+	}})
+
+	out := read()
+	assert.Contains(t, out, "ISO/IEC 27001:2022 Annex A: 62.50%")
+	assert.Contains(t, out, "failed   A.8.2 Privileged access rights: 25.00% (2 controls)")
+	assert.Contains(t, out, "failed: C-0035, C-0057, 3 resources")
+	assert.Contains(t, out, "passed   A.8.9 Configuration management: 100.00% (1 controls)")
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/kubescape/k8s-interface/k8sinterface"
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/cautils/helmrelease"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v2/prettyprinter/tableprinter/imageprinter"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v2/prettyprinter/tableprinter/utils"
 	"github.com/kubescape/opa-utils/reporthandling"
@@ -39,6 +41,7 @@ type ResultWithSeverity struct {
 	ResourceID          string                                  `json:"resourceID"`
 	AssociatedControls  []ResourceAssociatedControlWithSeverity `json:"controls,omitempty"`
 	PrioritizedResource *prioritization.PrioritizedResource     `json:"prioritizedResource,omitempty"`
+	Owner               string                                  `json:"owner,omitempty"`
 }
 
 // SummaryDetailsWithSeverity wraps SummaryDetails to include enriched controls
@@ -56,20 +59,43 @@ type SummaryDetailsWithSeverity struct {
 
 // PostureReportWithSeverity wraps PostureReport to include severity in controls
 type PostureReportWithSeverity struct {
-	ReportGenerationTime string                            `json:"generationTime"`
-	ClusterAPIServerInfo any                               `json:"clusterAPIServerInfo"`
-	ClusterCloudProvider string                            `json:"clusterCloudProvider"`
-	CustomerGUID         string                            `json:"customerGUID"`
-	ClusterName          string                            `json:"clusterName"`
-	ReportID             string                            `json:"reportGUID"`
-	SummaryDetails       SummaryDetailsWithSeverity        `json:"summaryDetails"`
-	Resources            []reporthandling.Resource         `json:"resources,omitempty"`
-	Attributes           []reportsummary.PostureAttributes `json:"attributes"`
-	Results              []ResultWithSeverity              `json:"results,omitempty"`
-	Metadata             reporthandlingv2.Metadata         `json:"metadata"`
-	ResourceLabels       map[string]map[string]string      `json:"resourceLabels,omitempty"` // map[resourceID]map[labelKey]labelValue - extracted labels from workloads
-	ScanCoverage         *cautils.ScanCoverage             `json:"scanCoverage,omitempty"`
-	ExceptionAudit       *cautils.ExceptionAudit           `json:"exceptionAudit,omitempty"`
+	ReportGenerationTime string                             `json:"generationTime"`
+	ClusterAPIServerInfo any                                `json:"clusterAPIServerInfo"`
+	ClusterCloudProvider string                             `json:"clusterCloudProvider"`
+	CustomerGUID         string                             `json:"customerGUID"`
+	ClusterName          string                             `json:"clusterName"`
+	ReportID             string                             `json:"reportGUID"`
+	SummaryDetails       SummaryDetailsWithSeverity         `json:"summaryDetails"`
+	Resources            []reporthandling.Resource          `json:"resources,omitempty"`
+	Attributes           []reportsummary.PostureAttributes  `json:"attributes"`
+	Results              []ResultWithSeverity               `json:"results,omitempty"`
+	Metadata             reporthandlingv2.Metadata          `json:"metadata"`
+	ResourceLabels       map[string]map[string]string       `json:"resourceLabels,omitempty"` // map[resourceID]map[labelKey]labelValue - extracted labels from workloads
+	ScanCoverage         *cautils.ScanCoverage              `json:"scanCoverage,omitempty"`
+	ExceptionAudit       *cautils.ExceptionAudit            `json:"exceptionAudit,omitempty"`
+	ScoringProfile       *cautils.ScoringProfile            `json:"scoringProfile,omitempty"`
+	FrameworkDefinitions []*getter.FrameworkDefinition      `json:"frameworkDefinitions,omitempty"`
+	FrameworkSections    []cautils.FrameworkSectionScore    `json:"frameworkSections,omitempty"`
+	Crosswalks           []*cautils.Crosswalk               `json:"crosswalks,omitempty"`
+	StandardReports      []cautils.StandardReport           `json:"standardReports,omitempty"`
+	ControlInputsScopes  []getter.ControlInputsScope        `json:"controlInputsScopes,omitempty"`
+	ResourceInputsScopes map[string]string                  `json:"resourceInputsScopes,omitempty"` // map[resourceID]scopeName - scope whose control inputs the resource was evaluated with
+	RuntimeScore         *cautils.RuntimeScore              `json:"runtimeScore,omitempty"`
+	GitOpsObjects        map[string]cautils.GitOpsObjectRef `json:"gitOpsObjects,omitempty"`
+	HelmReleases         []helmrelease.Summary              `json:"helmReleases,omitempty"`
+	Owners               []cautils.Owner                    `json:"owners,omitempty"`
+}
+
+// SetOwners tags every result with the owner of its resource, and lists the
+// owners, when the scan was given an ownership file.
+func (report *PostureReportWithSeverity) SetOwners(ownership *cautils.Ownership, resourceOwners map[string]string) {
+	if ownership == nil {
+		return
+	}
+	report.Owners = ownership.OwnerList()
+	for i := range report.Results {
+		report.Results[i].Owner = resourceOwners[report.Results[i].ResourceID]
+	}
 }
 
 // enrichControlsWithSeverity adds severity field to controls based on scoreFactor
@@ -10,6 +10,8 @@ import (
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/kubescape/v4/core/cautils/helmrelease"
 	"github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer"
 	printerv1 "github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v1"
 	printerv2 "github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v2"
@@ -99,6 +101,7 @@ func (rh *ResultsHandler) ToJson() ([]byte, error) {
 	type resultWithEnrichment struct {
 		resourcesresults.Result
 		AssociatedControls []printerv2.ResourceAssociatedControlWithSeverity `json:"controls,omitempty"`
+		Owner              string                                            `json:"owner,omitempty"`
 	}
 
 	results := make([]resultWithEnrichment, len(finalizedReport.Results))
@@ -106,26 +109,49 @@ func (rh *ResultsHandler) ToJson() ([]byte, error) {
 		results[i] = resultWithEnrichment{
 			Result:             finalizedReport.Results[i],
 			AssociatedControls: enrichedReport.Results[i].AssociatedControls,
+			Owner:              rh.ScanData.ResourceOwners[finalizedReport.Results[i].ResourceID],
 		}
 	}
 
 	output := struct {
 		*reporthandlingv2.PostureReport
-		SummaryDetails summaryWithEnrichment        `json:"summaryDetails,omitempty"`
-		Results        []resultWithEnrichment       `json:"results,omitempty"`
-		ResourceLabels map[string]map[string]string `json:"resourceLabels,omitempty"`
-		ScanCoverage   *cautils.ScanCoverage        `json:"scanCoverage,omitempty"`
-		ExceptionAudit *cautils.ExceptionAudit      `json:"exceptionAudit,omitempty"`
+		SummaryDetails       summaryWithEnrichment              `json:"summaryDetails,omitempty"`
+		Results              []resultWithEnrichment             `json:"results,omitempty"`
+		ResourceLabels       map[string]map[string]string       `json:"resourceLabels,omitempty"`
+		ScanCoverage         *cautils.ScanCoverage              `json:"scanCoverage,omitempty"`
+		ExceptionAudit       *cautils.ExceptionAudit            `json:"exceptionAudit,omitempty"`
+		ScoringProfile       *cautils.ScoringProfile            `json:"scoringProfile,omitempty"`
+		FrameworkDefinitions []*getter.FrameworkDefinition      `json:"frameworkDefinitions,omitempty"`
+		FrameworkSections    []cautils.FrameworkSectionScore    `json:"frameworkSections,omitempty"`
+		Crosswalks           []*cautils.Crosswalk               `json:"crosswalks,omitempty"`
+		StandardReports      []cautils.StandardReport           `json:"standardReports,omitempty"`
+		ControlInputsScopes  []getter.ControlInputsScope        `json:"controlInputsScopes,omitempty"`
+		ResourceInputsScopes map[string]string                  `json:"resourceInputsScopes,omitempty"`
+		RuntimeScore         *cautils.RuntimeScore              `json:"runtimeScore,omitempty"`
+		GitOpsObjects        map[string]cautils.GitOpsObjectRef `json:"gitOpsObjects,omitempty"`
+		HelmReleases         []helmrelease.Summary              `json:"helmReleases,omitempty"`
+		Owners               []cautils.Owner                    `json:"owners,omitempty"`
 	}{
 		PostureReport: finalizedReport,
 		SummaryDetails: summaryWithEnrichment{
 			SummaryDetails: finalizedReport.SummaryDetails,
 			Controls:       enrichedReport.SummaryDetails.Controls,
 		},
-		Results:        results,
-		ResourceLabels: enrichedReport.ResourceLabels,
-		ScanCoverage:   enrichedReport.ScanCoverage,
-		ExceptionAudit: rh.ScanData.ExceptionAudit,
+		Results:              results,
+		ResourceLabels:       enrichedReport.ResourceLabels,
+		ScanCoverage:         enrichedReport.ScanCoverage,
+		ExceptionAudit:       rh.ScanData.ExceptionAudit,
+		ScoringProfile:       rh.ScanData.ScoringProfile,
+		FrameworkDefinitions: rh.ScanData.FrameworkDefinitions,
+		FrameworkSections:    rh.ScanData.FrameworkSections,
+		Crosswalks:           rh.ScanData.Crosswalks,
+		StandardReports:      rh.ScanData.StandardReports,
+		ControlInputsScopes:  rh.ScanData.ControlInputsScopes,
+		ResourceInputsScopes: rh.ScanData.ResourceInputsScopes,
+		RuntimeScore:         rh.ScanData.RuntimeScore,
+		GitOpsObjects:        rh.ScanData.GitOpsObjects,
+		HelmReleases:         rh.ScanData.HelmReleasesWithResources(),
+		Owners:               rh.ScanData.Ownership.OwnerList(),
 	}
 
 	return json.Marshal(&output)
@@ -286,6 +312,12 @@ func (rh *ResultsHandler) HandleResults(ctx context.Context, scanInfo *cautils.S
 		}
 	}
 
+	if scanInfo.SplitByOwner != "" {
+		if err := writeOwnerReports(ctx, rh.ScanData, scanInfo, scanInfo.SplitByOwner); err != nil {
+			printErr = errors.Join(printErr, fmt.Errorf("per-owner reports: %w", err))
+		}
+	}
+
 	if err := errors.Join(printErr, rh.scanError); err != nil {
 		return err
 	}
@@ -108,6 +108,15 @@ func recomputeSummaryDetails(sessionObj *cautils.OPASessionObj) {
 		}
 	}
 
+	recomputeStatusCounters(summary)
+
+	recomputeSeverityCounters(sessionObj)
+}
+
+// recomputeSeverityCounters counts the failed controls, and the failed
+// controls of every failed resource, by severity.
+func recomputeSeverityCounters(sessionObj *cautils.OPASessionObj) {
+	summary := &sessionObj.Report.SummaryDetails
 	summary.ControlsSeverityCounters = reportsummary.SeverityCounters{}
 	for _, ctrl := range summary.Controls {
 		if ctrl.GetStatus().IsFailed() {
@@ -115,8 +124,6 @@ func recomputeSummaryDetails(sessionObj *cautils.OPASessionObj) {
 		}
 	}
 
-	recomputeStatusCounters(summary)
-
 	summary.ResourcesSeverityCounters = reportsummary.SeverityCounters{}
 	for _, result := range sessionObj.ResourcesResult {
 		if !result.GetStatus(nil).IsFailed() {
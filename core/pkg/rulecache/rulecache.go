@@ -0,0 +1,191 @@
+// Package rulecache persists compiled Rego rules across runs so that a rule
+// whose source has not changed is not parsed, checked and rewritten again on
+// every scan.
+//
+// Entries live under <cacheDir>/rule-cache/<generation>/<key>.json, where the
+// generation identifies everything a compiled rule depends on besides its own
+// source (OPA version, registered builtins, shared rule dependencies) and the
+// key identifies the rule (name, source, print statements). Opening a
+// generation removes every other one, so an upgrade invalidates the cache
+// automatically and stale entries do not accumulate on disk.
+//
+// An entry is the module set the compiler produced: the rule and its
+// dependencies after every rewrite and check, in Rego syntax. Loading it runs
+// only the compiler stages that build the rule trees and indices evaluation
+// needs; the stages that resolve, rewrite and check the modules already ran
+// when the entry was stored.
+package rulecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+const dirName = "rule-cache"
+
+// loadStages are the compiler stages run on a loaded entry. The modules are
+// already resolved, rewritten and checked; evaluation still needs the trees,
+// the dependency graph and the indices the compiler builds from them.
+var loadStages = []ast.StageID{
+	ast.StageInitLocalVarGen,
+	ast.StageSetModuleTree,
+	ast.StageSetRuleTree,
+	ast.StageSetGraph,
+	ast.StageBuildRuleIndices,
+	ast.StageBuildComprehensionIndices,
+}
+
+// ErrNotRoundTrip reports a compiled module that does not read back as
+// itself, which is therefore not cached. Put returns any other error when the
+// entry could not be written.
+var ErrNotRoundTrip = errors.New("the compiled module does not round-trip through Rego syntax")
+
+// Store is one cache generation. It is safe for concurrent use: entries are
+// written to a temporary file and renamed into place, so a reader sees either
+// a complete entry or none.
+type Store struct {
+	dir string
+}
+
+// entry is the on-disk form of a compiled rule.
+type entry struct {
+	RegoVersion int               `json:"regoVersion"`
+	Modules     map[string]string `json:"modules"`
+}
+
+// Open returns the store for generation under cacheDir, creating it if needed
+// and removing the directories of every other generation.
+func Open(cacheDir, generation string) (*Store, error) {
+	if generation == "" {
+		return nil, fmt.Errorf("rule cache generation is empty")
+	}
+	root := filepath.Join(cacheDir, dirName)
+	dir := filepath.Join(root, generation)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Name() != generation {
+			_ = os.RemoveAll(filepath.Join(root, e.Name())) // best effort: a leftover generation only costs disk space
+		}
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Delete removes every cached compiled rule under cacheDir.
+func Delete(cacheDir string) error {
+	return os.RemoveAll(filepath.Join(cacheDir, dirName))
+}
+
+// Generation builds the generation identifier from the given parts (e.g. the
+// OPA version, the registered builtins and the shared rule dependencies).
+func Generation(parts ...[]byte) string {
+	return hashParts(parts...)
+}
+
+// Key identifies the compilation of the rule ruleName with source ruleData.
+// Print statements are part of the key because the compiler rewrites them
+// away unless they are enabled.
+func Key(ruleName, ruleData string, printEnabled bool) string {
+	return hashParts([]byte(ruleName), []byte(ruleData), []byte(strconv.FormatBool(printEnabled)))
+}
+
+func hashParts(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the compiler of the cached rule for key, with the Rego version
+// it was compiled under. A missing, unreadable or stale entry is a miss.
+func (s *Store) Get(key string, printEnabled bool) (*ast.Compiler, ast.RegoVersion, bool) {
+	raw, err := os.ReadFile(filepath.Join(s.dir, key+".json"))
+	if err != nil {
+		return nil, 0, false
+	}
+	var e entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, 0, false
+	}
+	version := ast.RegoVersion(e.RegoVersion)
+
+	modules := make(map[string]*ast.Module, len(e.Modules))
+	for name, source := range e.Modules {
+		module, err := ast.ParseModuleWithOpts(name, source, ast.ParserOptions{RegoVersion: version})
+		if err != nil {
+			return nil, 0, false
+		}
+		modules[name] = module
+	}
+
+	compiler := ast.NewCompiler().
+		WithDefaultRegoVersion(version).
+		WithEnablePrintStatements(printEnabled).
+		WithSkipStages(skippedStages()...)
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		return nil, 0, false
+	}
+	return compiler, version, true
+}
+
+// Put stores the modules compiler compiled under version for key. Modules that
+// do not read back as themselves are not stored, since loading them could
+// evaluate differently from the rule that was compiled.
+func (s *Store) Put(key string, compiler *ast.Compiler, version ast.RegoVersion) error {
+	e := entry{RegoVersion: int(version), Modules: make(map[string]string, len(compiler.Modules))}
+	for name, module := range compiler.Modules {
+		source := module.String()
+		parsed, err := ast.ParseModuleWithOpts(name, source, ast.ParserOptions{RegoVersion: version})
+		if err != nil || !parsed.Equal(module) {
+			return fmt.Errorf("module %q: %w", name, ErrNotRoundTrip)
+		}
+		e.Modules[name] = source
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, key+".json"))
+}
+
+// skippedStages returns every compiler stage but loadStages.
+func skippedStages() []ast.StageID {
+	var skipped []ast.StageID
+	for _, stage := range ast.AllStages() {
+		if !slices.Contains(loadStages, stage) {
+			skipped = append(skipped, stage)
+		}
+	}
+	return skipped
+}
@@ -0,0 +1,157 @@
+package rulecache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dependency = `package cautils
+
+is_in(x, xs) if {
+	some y in xs
+	x == y
+}
+`
+
+const rule = `package armo_builtins
+
+import data.cautils
+
+deny contains msga if {
+	some pod in input
+	cautils.is_in(pod.kind, ["Pod", "Deployment"])
+	some i, container in pod.spec.containers
+	not container.securityContext.readOnlyRootFilesystem
+	names := [c.name | some c in pod.spec.containers]
+	every name in names { count(name) > 0 }
+	msga := {
+		"alertMessage": sprintf("container %v has a writable root filesystem", [container.name]),
+		"failedPaths": [sprintf("spec.containers[%d].securityContext.readOnlyRootFilesystem", [i])],
+	}
+}
+`
+
+const v0Rule = `package armo_builtins
+
+deny[msga] {
+	pod := input[_]
+	container := pod.spec.containers[i]
+	not container.securityContext.readOnlyRootFilesystem
+	msga := {"alertMessage": sprintf("container %v has a writable root filesystem", [container.name])}
+}
+`
+
+func compile(t *testing.T, version ast.RegoVersion, sources map[string]string) *ast.Compiler {
+	t.Helper()
+	modules := make(map[string]*ast.Module, len(sources))
+	for name, source := range sources {
+		module, err := ast.ParseModuleWithOpts(name, source, ast.ParserOptions{RegoVersion: version})
+		require.NoError(t, err)
+		modules[name] = module
+	}
+	compiler := ast.NewCompiler().WithDefaultRegoVersion(version)
+	compiler.Compile(modules)
+	require.False(t, compiler.Failed(), compiler.Errors)
+	return compiler
+}
+
+func eval(t *testing.T, compiler *ast.Compiler, version ast.RegoVersion) any {
+	t.Helper()
+	pq, err := rego.New(
+		rego.SetRegoVersion(version),
+		rego.Query("data.armo_builtins"),
+		rego.Compiler(compiler),
+	).PrepareForEval(context.Background())
+	require.NoError(t, err)
+	input := []any{map[string]any{
+		"kind": "Pod",
+		"spec": map[string]any{"containers": []any{
+			map[string]any{"name": "app"},
+			map[string]any{"name": "sidecar", "securityContext": map[string]any{"readOnlyRootFilesystem": true}},
+		}},
+	}}
+	rs, err := pq.Eval(context.Background(), rego.EvalInput(input))
+	require.NoError(t, err)
+	require.Len(t, rs, 1)
+	return rs[0].Expressions[0].Value
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		version ast.RegoVersion
+		sources map[string]string
+	}{
+		{name: "rego v1", version: ast.RegoV1, sources: map[string]string{"rule": rule, "cautils": dependency}},
+		{name: "rego v0", version: ast.RegoV0, sources: map[string]string{"rule": v0Rule}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			compiled := compile(t, tt.version, tt.sources)
+			key := Key("rule", tt.sources["rule"], false)
+
+			store, err := Open(cacheDir, Generation([]byte("opa-1")))
+			require.NoError(t, err)
+			require.NoError(t, store.Put(key, compiled, tt.version))
+
+			// A later run opens the same generation.
+			store, err = Open(cacheDir, Generation([]byte("opa-1")))
+			require.NoError(t, err)
+			loaded, version, ok := store.Get(key, false)
+			require.True(t, ok)
+			assert.Equal(t, tt.version, version)
+			for name, module := range compiled.Modules {
+				assert.True(t, module.Equal(loaded.Modules[name]), "module %s", name)
+			}
+			assert.Equal(t, eval(t, compiled, tt.version), eval(t, loaded, version))
+		})
+	}
+}
+
+func TestStoreGetMiss(t *testing.T) {
+	store, err := Open(t.TempDir(), Generation([]byte("opa-1")))
+	require.NoError(t, err)
+	_, _, ok := store.Get(Key("rule", rule, false), false)
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(filepath.Join(store.dir, Key("rule", rule, false)+".json"), []byte("{"), 0o600))
+	_, _, ok = store.Get(Key("rule", rule, false), false)
+	assert.False(t, ok, "an unreadable entry is a miss")
+}
+
+func TestOpenRemovesOtherGenerations(t *testing.T) {
+	cacheDir := t.TempDir()
+	key := Key("rule", rule, false)
+
+	oldGen := Generation([]byte("opa-1"))
+	old, err := Open(cacheDir, oldGen)
+	require.NoError(t, err)
+	require.NoError(t, old.Put(key, compile(t, ast.RegoV1, map[string]string{"rule": rule, "cautils": dependency}), ast.RegoV1))
+
+	current, err := Open(cacheDir, Generation([]byte("opa-2")))
+	require.NoError(t, err)
+	_, _, ok := current.Get(key, false)
+	assert.False(t, ok)
+	_, err = os.Stat(filepath.Join(cacheDir, dirName, oldGen))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, Delete(cacheDir))
+	_, err = os.Stat(filepath.Join(cacheDir, dirName))
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, Delete(cacheDir))
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("rule", rule, false), Key("rule", rule, false))
+	assert.NotEqual(t, Key("rule", rule, false), Key("other", rule, false))
+	assert.NotEqual(t, Key("rule", rule, false), Key("rule", v0Rule, false))
+	assert.NotEqual(t, Key("rule", rule, false), Key("rule", rule, true))
+}
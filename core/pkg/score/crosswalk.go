@@ -0,0 +1,69 @@
+package score
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+// standardReports aggregates the report's controls onto the clauses of the
+// crosswalks' standards. A clause's score averages the compliance scores of
+// its scanned supporting controls, weighted by the scoring profile, evenly
+// without one, and a standard's score averages the scores of its clauses
+// with a scanned supporting control.
+func (su *ScoreWrapper) standardReports() []cautils.StandardReport {
+	profile := su.opaSessionObj.ScoringProfile
+	if profile == nil {
+		profile = defaultScoringProfile
+	}
+	controls := su.opaSessionObj.Report.SummaryDetails.Controls
+	scores, weights := sectionControlScores(profile, controls)
+
+	var reports []cautils.StandardReport
+	for _, crosswalk := range su.opaSessionObj.Crosswalks {
+		report := cautils.StandardReport{
+			Standard: crosswalk.Standard,
+			Name:     crosswalk.Name,
+			Clauses:  make([]cautils.ClauseReport, 0, len(crosswalk.Clauses)),
+		}
+		clauseScores := map[string]float32{}
+		clauseWeights := map[string]float32{}
+		for _, clause := range crosswalk.Clauses {
+			result := cautils.ClauseReport{
+				ID:       clause.ID,
+				Name:     clause.Name,
+				Status:   apis.StatusSkipped,
+				Controls: []string{},
+			}
+			var failedResources []string
+			for _, id := range clause.Controls {
+				control, ok := controls[id]
+				if !ok {
+					continue
+				}
+				result.Controls = append(result.Controls, id)
+				switch status := control.GetStatus(); {
+				case status.IsFailed():
+					result.Status = apis.StatusFailed
+					result.FailedControls = append(result.FailedControls, id)
+					failedResources = append(failedResources, control.ListResourcesIDs(nil).GetItems(apis.StatusFailed)...)
+				case status.IsPassed() && result.Status == apis.StatusSkipped:
+					result.Status = apis.StatusPassed
+				}
+			}
+			slices.Sort(failedResources)
+			result.FailedResources = slices.Compact(failedResources)
+			result.ComplianceScore = weightedScore(slices.Values(result.Controls), scores, weights)
+			if result.Status != apis.StatusSkipped {
+				clauseScores[clause.ID] = result.ComplianceScore
+				clauseWeights[clause.ID] = 1
+			}
+			report.Clauses = append(report.Clauses, result)
+		}
+		report.ComplianceScore = weightedScore(maps.Keys(clauseScores), clauseScores, clauseWeights)
+		reports = append(reports, report)
+	}
+	return reports
+}
@@ -0,0 +1,72 @@
+package score
+
+import (
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statusControl(id string, complianceScore float32, status apis.ScanningStatus, resourceIDs ...string) reportsummary.ControlSummary {
+	control := scoredControl(id, 4, complianceScore)
+	control.Status = status
+	control.StatusInfo.InnerStatus = status
+	control.ResourceIDs.Append(status, resourceIDs...)
+	return control
+}
+
+func TestStandardReports(t *testing.T) {
+	session := cautils.NewOPASessionObjMock()
+	session.Report.SummaryDetails.Controls = reportsummary.ControlSummaries{
+		"C-0035": statusControl("C-0035", 50, apis.StatusFailed, "rbac/ClusterRoleBinding/admins", "rbac/ClusterRoleBinding/ci"),
+		"C-0057": statusControl("C-0057", 0, apis.StatusFailed, "apps/Deployment/default/web", "rbac/ClusterRoleBinding/ci"),
+		"C-0017": statusControl("C-0017", 100, apis.StatusPassed),
+		"C-0012": statusControl("C-0012", 0, apis.StatusSkipped),
+	}
+	session.Crosswalks = []*cautils.Crosswalk{{
+		Standard: "iso27001",
+		Name:     "ISO/IEC 27001:2022 Annex A",
+		Clauses: []cautils.CrosswalkClause{
+			{ID: "A.8.2", Name: "Privileged access rights", Controls: []string{"C-0035", "C-0057", "C-0185"}},
+			{ID: "A.8.9", Name: "Configuration management", Controls: []string{"C-0017"}},
+			{ID: "A.5.17", Name: "Authentication information", Controls: []string{"C-0012"}},
+			{ID: "A.8.15", Name: "Logging", Controls: []string{"C-0067"}},
+		},
+	}}
+
+	reports := NewScoreWrapper(session).standardReports()
+	require.Len(t, reports, 1)
+	report := reports[0]
+	assert.Equal(t, "iso27001", report.Standard)
+	require.Len(t, report.Clauses, 4)
+
+	t.Run("fails clauses with a failed supporting control", func(t *testing.T) {
+		assert.Equal(t, cautils.ClauseReport{
+			ID:              "A.8.2",
+			Name:            "Privileged access rights",
+			Status:          apis.StatusFailed,
+			ComplianceScore: 25,
+			Controls:        []string{"C-0035", "C-0057"},
+			FailedControls:  []string{"C-0035", "C-0057"},
+			FailedResources: []string{"apps/Deployment/default/web", "rbac/ClusterRoleBinding/admins", "rbac/ClusterRoleBinding/ci"},
+		}, report.Clauses[0])
+	})
+
+	t.Run("passes clauses whose supporting controls passed", func(t *testing.T) {
+		assert.Equal(t, apis.StatusPassed, report.Clauses[1].Status)
+		assert.Equal(t, float32(100), report.Clauses[1].ComplianceScore)
+	})
+
+	t.Run("skips clauses without a scanned or evaluated supporting control", func(t *testing.T) {
+		assert.Equal(t, apis.StatusSkipped, report.Clauses[2].Status)
+		assert.Equal(t, apis.StatusSkipped, report.Clauses[3].Status)
+		assert.Empty(t, report.Clauses[3].Controls)
+	})
+
+	t.Run("averages the scores of the evaluated clauses", func(t *testing.T) {
+		assert.Equal(t, float32(62.5), report.ComplianceScore)
+	})
+}
@@ -0,0 +1,163 @@
+package score
+
+import (
+	"iter"
+	"maps"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+)
+
+// controlTally sums the weights of the resources a control evaluated, and of
+// those passing it.
+type controlTally struct {
+	passed, total float32
+}
+
+// resultWeight is how a resource's result for a control counts under a
+// scoring profile: whether it counts at all, and whether it counts as passed.
+func resultWeight(profile *cautils.ScoringProfile, status apis.ScanningStatus, subStatus apis.ScanningSubStatus) (counted, passed bool) {
+	treatment := ""
+	switch status {
+	case apis.StatusFailed:
+		return true, false
+	case apis.StatusPassed:
+		if subStatus != apis.SubStatusException {
+			return true, true
+		}
+		treatment = profile.Exceptions
+	case apis.StatusSkipped:
+		treatment = profile.ActionRequired
+	default:
+		return false, false
+	}
+	switch treatment {
+	case cautils.ScoreAsPassed:
+		return true, true
+	case cautils.ScoreAsFailed:
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// profileControlScore scores a control from its tally. A control without
+// counted resources scores 100 when it passed, 0 when it requires action and
+// the profile counts that as failed, and is otherwise left out of framework
+// and report scores.
+func profileControlScore(profile *cautils.ScoringProfile, tally *controlTally, status apis.ScanningStatus) (score float32, scored bool) {
+	if tally != nil && tally.total > 0 {
+		return 100 * tally.passed / tally.total, true
+	}
+	switch {
+	case status == apis.StatusPassed:
+		return 100, true
+	case status == apis.StatusSkipped && profile.ActionRequired == cautils.ScoreAsFailed:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// weightedScore averages the scores of the scored controls among ids by their
+// weights, 0 when no control carries weight.
+func weightedScore(ids iter.Seq[string], scores, weights map[string]float32) float32 {
+	var sum, total float32
+	for id := range ids {
+		score, ok := scores[id]
+		if !ok {
+			continue
+		}
+		sum += weights[id] * score
+		total += weights[id]
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// defaultScoringProfile scores like the default compliance scoring: every
+// control and every resource weighs 1.
+var defaultScoringProfile = &cautils.ScoringProfile{Exceptions: cautils.ScoreAsPassed, ActionRequired: cautils.ScoreAsIgnored}
+
+// applyScoringProfile replaces the compliance scores of the report's controls,
+// frameworks and summary with the ones the scoring profile defines. Timed-out
+// controls score 0, as they do without a profile.
+func (su *ScoreWrapper) applyScoringProfile(profile *cautils.ScoringProfile) {
+	summary := &su.opaSessionObj.Report.SummaryDetails
+	scores, weights := su.controlScores(profile, su.tallyControls(profile, nil))
+
+	setControlScores(summary.Controls, scores)
+	summary.ComplianceScore = weightedScore(maps.Keys(summary.Controls), scores, weights)
+	for i := range summary.Frameworks {
+		framework := &summary.Frameworks[i]
+		setControlScores(framework.Controls, scores)
+		framework.ComplianceScore = weightedScore(maps.Keys(framework.Controls), scores, weights)
+	}
+}
+
+// tallyControls tallies the resource results of every control. A resource
+// weighs its namespace multiplier, and its failed results also weigh its
+// factor in failedFactors, if any.
+func (su *ScoreWrapper) tallyControls(profile *cautils.ScoringProfile, failedFactors map[string]float32) map[string]*controlTally {
+	tallies := map[string]*controlTally{}
+	for resourceID, result := range su.opaSessionObj.ResourcesResult {
+		multiplier := float32(1)
+		if resource, ok := su.opaSessionObj.AllResources[resourceID]; ok && resource != nil {
+			multiplier = profile.NamespaceMultiplier(resource.GetNamespace())
+		}
+		failedMultiplier := multiplier
+		if factor, ok := failedFactors[resourceID]; ok {
+			failedMultiplier *= factor
+		}
+		for _, control := range result.ListControls() {
+			statusInfo := control.GetStatus(nil)
+			counted, passed := resultWeight(profile, statusInfo.Status(), statusInfo.SubStatus)
+			if !counted {
+				continue
+			}
+			tally, ok := tallies[control.GetID()]
+			if !ok {
+				tally = &controlTally{}
+				tallies[control.GetID()] = tally
+			}
+			if passed {
+				tally.passed += multiplier
+				tally.total += multiplier
+			} else {
+				tally.total += failedMultiplier
+			}
+		}
+	}
+	return tallies
+}
+
+// controlScores scores the report's controls from their tallies and weighs
+// them by the profile. Controls left unscored are missing from scores.
+func (su *ScoreWrapper) controlScores(profile *cautils.ScoringProfile, tallies map[string]*controlTally) (scores, weights map[string]float32) {
+	controls := su.opaSessionObj.Report.SummaryDetails.Controls
+	scores = make(map[string]float32, len(controls))
+	weights = make(map[string]float32, len(controls))
+	for id, control := range controls {
+		weights[id] = profile.ControlWeight(id, apis.ControlSeverityToString(control.GetScoreFactor()))
+		if _, timedOut := su.timedOutControls[id]; timedOut {
+			scores[id] = 0
+			continue
+		}
+		if score, ok := profileControlScore(profile, tallies[id], control.GetStatus().Status()); ok {
+			scores[id] = score
+		}
+	}
+	return scores, weights
+}
+
+func setControlScores(controls reportsummary.ControlSummaries, scores map[string]float32) {
+	for id, control := range controls {
+		if score, ok := scores[id]; ok {
+			control.ComplianceScore = &score
+			controls[id] = control
+		}
+	}
+}
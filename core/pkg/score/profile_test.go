@@ -0,0 +1,63 @@
+package score
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultWeight(t *testing.T) {
+	profile := &cautils.ScoringProfile{Exceptions: cautils.ScoreAsFailed, ActionRequired: cautils.ScoreAsIgnored}
+
+	tests := []struct {
+		name                string
+		status              apis.ScanningStatus
+		subStatus           apis.ScanningSubStatus
+		counted, wantPassed bool
+	}{
+		{name: "passed", status: apis.StatusPassed, counted: true, wantPassed: true},
+		{name: "failed", status: apis.StatusFailed, counted: true},
+		{name: "exception", status: apis.StatusPassed, subStatus: apis.SubStatusException, counted: true},
+		{name: "action required", status: apis.StatusSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counted, passed := resultWeight(profile, tt.status, tt.subStatus)
+			assert.Equal(t, tt.counted, counted)
+			assert.Equal(t, tt.wantPassed, passed)
+		})
+	}
+}
+
+func TestProfileControlScore(t *testing.T) {
+	profile := &cautils.ScoringProfile{ActionRequired: cautils.ScoreAsFailed}
+
+	score, scored := profileControlScore(profile, &controlTally{passed: 3, total: 4}, apis.StatusFailed)
+	assert.True(t, scored)
+	assert.Equal(t, float32(75), score)
+
+	score, scored = profileControlScore(profile, nil, apis.StatusPassed)
+	assert.True(t, scored)
+	assert.Equal(t, float32(100), score)
+
+	score, scored = profileControlScore(profile, nil, apis.StatusSkipped)
+	assert.True(t, scored, "action required counts as failed in this profile")
+	assert.Equal(t, float32(0), score)
+
+	profile.ActionRequired = cautils.ScoreAsIgnored
+	_, scored = profileControlScore(profile, nil, apis.StatusSkipped)
+	assert.False(t, scored)
+}
+
+func TestWeightedScore(t *testing.T) {
+	scores := map[string]float32{"C-0001": 100, "C-0002": 40}
+	weights := map[string]float32{"C-0001": 1, "C-0002": 3, "C-0003": 5}
+
+	assert.Equal(t, float32(55), weightedScore(slices.Values([]string{"C-0001", "C-0002", "C-0003"}), scores, weights),
+		"unscored controls carry no weight")
+	assert.Equal(t, float32(0), weightedScore(slices.Values([]string{"C-0003"}), scores, weights))
+}
@@ -17,8 +17,9 @@ and the need of concrete objects
 	I've decided to create scoreWrapper that will allow calculating score regardless (as long as opaSessionObj is there)
 */
 type ScoreWrapper struct {
-	scoreUtil     *score.ScoreUtil
-	opaSessionObj *cautils.OPASessionObj
+	scoreUtil        *score.ScoreUtil
+	opaSessionObj    *cautils.OPASessionObj
+	timedOutControls map[string]string
 }
 
 type PostureReportVersion string
@@ -30,30 +31,55 @@ const (
 
 func (su *ScoreWrapper) Calculate(reportVersion PostureReportVersion) error {
 	if reportVersion == EPostureReportV2 {
-		return su.scoreUtil.SetPostureReportComplianceScores(su.opaSessionObj.Report)
+		if err := su.scoreUtil.SetPostureReportComplianceScores(su.opaSessionObj.Report); err != nil {
+			return err
+		}
+		if su.opaSessionObj.ScoringProfile != nil {
+			su.applyScoringProfile(su.opaSessionObj.ScoringProfile)
+		}
+		su.opaSessionObj.FrameworkSections = su.frameworkSections()
+		su.opaSessionObj.StandardReports = su.standardReports()
+		return nil
 	}
 
 	return fmt.Errorf("unsupported score calculator")
 }
 
+// CalculateWithTelemetry calculates the compliance scores like Calculate once
+// telemetryChan is closed or ctx is done, and adds a runtime-adjusted score
+// computed from the events received in the meantime: the failed findings of
+// workloads that ran a shell, connected outside their pod or used a
+// capability they add weigh more, and those of the other workloads less. No
+// runtime-adjusted score is added when no event was received.
 func (su *ScoreWrapper) CalculateWithTelemetry(ctx context.Context, reportVersion PostureReportVersion, telemetryChan <-chan hostsensorutils.SyscallEvent) error {
-	// Dynamically adjust scores based on eBPF telemetry
-	// This is a stub implementation
+	telemetry := newRuntimeTelemetry()
 	for {
 		select {
 		case <-ctx.Done():
-			return su.Calculate(reportVersion)
+			return su.calculateWithRuntimeScore(reportVersion, telemetry)
 		case event, ok := <-telemetryChan:
 			if !ok {
-				// Channel closed, compute final score
-				return su.Calculate(reportVersion)
+				return su.calculateWithRuntimeScore(reportVersion, telemetry)
 			}
-			fmt.Printf("Received telemetry: %+v\n", event)
-			// Adjust su.opaSessionObj.Report scores dynamically based on utilized capabilities
+			telemetry.record(event)
 		}
 	}
 }
 
+func (su *ScoreWrapper) calculateWithRuntimeScore(reportVersion PostureReportVersion, telemetry *runtimeTelemetry) error {
+	if err := su.Calculate(reportVersion); err != nil {
+		return err
+	}
+	su.opaSessionObj.RuntimeScore = su.runtimeScore(telemetry)
+	return nil
+}
+
+// SetTimedOutControls marks the controls whose evaluation timed out. A scoring
+// profile scores them 0.
+func (su *ScoreWrapper) SetTimedOutControls(timedOutControls map[string]string) {
+	su.timedOutControls = timedOutControls
+}
+
 func NewScoreWrapper(opaSessionObj *cautils.OPASessionObj) *ScoreWrapper {
 	return &ScoreWrapper{
 		scoreUtil:     score.NewScore(opaSessionObj.AllResources),
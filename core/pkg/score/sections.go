@@ -0,0 +1,70 @@
+package score
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+)
+
+// frameworkSections scores the sections of the framework definitions the
+// report's frameworks were composed from. A section's score averages the
+// compliance scores of its scanned controls, weighted by the scoring profile,
+// evenly without one.
+func (su *ScoreWrapper) frameworkSections() []cautils.FrameworkSectionScore {
+	profile := su.opaSessionObj.ScoringProfile
+	if profile == nil {
+		profile = defaultScoringProfile
+	}
+	var sections []cautils.FrameworkSectionScore
+	for _, definition := range su.opaSessionObj.FrameworkDefinitions {
+		framework := reportFramework(su.opaSessionObj.Report.SummaryDetails.Frameworks, definition.Name)
+		if framework == nil {
+			continue
+		}
+		scores, weights := sectionControlScores(profile, framework.Controls)
+		for _, section := range definition.Sections {
+			score := cautils.FrameworkSectionScore{
+				Framework: definition.Name,
+				ID:        section.ID,
+				Name:      section.Name,
+				Controls:  []string{},
+			}
+			for _, control := range section.Controls {
+				if _, ok := framework.Controls[control.ID]; ok {
+					score.Controls = append(score.Controls, control.ID)
+				}
+			}
+			score.ComplianceScore = weightedScore(slices.Values(score.Controls), scores, weights)
+			sections = append(sections, score)
+		}
+	}
+	return sections
+}
+
+// reportFramework returns the summary of the named framework, nil when the
+// report has none.
+func reportFramework(frameworks []reportsummary.FrameworkSummary, name string) *reportsummary.FrameworkSummary {
+	for i := range frameworks {
+		if strings.EqualFold(frameworks[i].Name, name) {
+			return &frameworks[i]
+		}
+	}
+	return nil
+}
+
+// sectionControlScores reads the compliance scores of a framework's controls,
+// and weighs them by the profile. Unscored controls are missing from scores.
+func sectionControlScores(profile *cautils.ScoringProfile, controls reportsummary.ControlSummaries) (scores, weights map[string]float32) {
+	scores = make(map[string]float32, len(controls))
+	weights = make(map[string]float32, len(controls))
+	for id, control := range controls {
+		weights[id] = profile.ControlWeight(id, apis.ControlSeverityToString(control.GetScoreFactor()))
+		if control.ComplianceScore != nil {
+			scores[id] = *control.ComplianceScore
+		}
+	}
+	return scores, weights
+}
@@ -0,0 +1,51 @@
+package score
+
+import (
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/cautils/getter"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/stretchr/testify/assert"
+)
+
+func scoredControl(id string, scoreFactor, complianceScore float32) reportsummary.ControlSummary {
+	return reportsummary.ControlSummary{ControlID: id, ScoreFactor: scoreFactor, ComplianceScore: &complianceScore}
+}
+
+func TestFrameworkSections(t *testing.T) {
+	session := cautils.NewOPASessionObjMock()
+	session.Report.SummaryDetails.Frameworks = []reportsummary.FrameworkSummary{{
+		Name: "golden-baseline",
+		Controls: reportsummary.ControlSummaries{
+			"C-0017":           scoredControl("C-0017", 9, 50),
+			"C-0044":           scoredControl("C-0044", 4, 100),
+			"custom-no-latest": scoredControl("custom-no-latest", 4, 20),
+		},
+	}}
+	session.FrameworkDefinitions = []*getter.FrameworkDefinition{{
+		Name: "golden-baseline",
+		Sections: []getter.FrameworkSection{
+			{ID: "1", Name: "Workload hardening", Controls: []getter.DefinitionControl{{ID: "C-0017"}, {ID: "C-0044"}}},
+			{ID: "2", Name: "Our checks", Controls: []getter.DefinitionControl{{ID: "custom-no-latest"}, {ID: "C-0012"}}},
+		},
+	}, {
+		Name:     "not-scanned",
+		Sections: []getter.FrameworkSection{{ID: "1", Controls: []getter.DefinitionControl{{ID: "C-0017"}}}},
+	}}
+
+	t.Run("weighs controls evenly without a scoring profile", func(t *testing.T) {
+		assert.Equal(t, []cautils.FrameworkSectionScore{
+			{Framework: "golden-baseline", ID: "1", Name: "Workload hardening", Controls: []string{"C-0017", "C-0044"}, ComplianceScore: 75},
+			{Framework: "golden-baseline", ID: "2", Name: "Our checks", Controls: []string{"custom-no-latest"}, ComplianceScore: 20},
+		}, NewScoreWrapper(session).frameworkSections())
+	})
+
+	t.Run("weighs controls by the scoring profile", func(t *testing.T) {
+		session.ScoringProfile = &cautils.ScoringProfile{SeverityWeights: map[string]float32{"critical": 3, "medium": 1}}
+		defer func() { session.ScoringProfile = nil }()
+
+		sections := NewScoreWrapper(session).frameworkSections()
+		assert.Equal(t, float32(62.5), sections[0].ComplianceScore)
+	})
+}
@@ -0,0 +1,317 @@
+package score
+
+import (
+	"cmp"
+	"maps"
+	"net"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/pkg/hostsensorutils"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+)
+
+const (
+	// runtimeBoost multiplies the weight of the failed findings of a workload
+	// that ran a shell, connected outside its pod or used a capability it adds.
+	runtimeBoost float32 = 2
+	// runtimeDiscount multiplies the weight of the failed findings of a
+	// workload that did none of these while telemetry was collected.
+	runtimeDiscount float32 = 0.5
+)
+
+// runtimeShells are the executables whose exec counts as running a shell.
+var runtimeShells = []string{"sh", "bash", "ash", "dash", "zsh", "ksh", "mksh", "csh", "tcsh", "fish", "busybox"}
+
+// runtimeWorkloadKinds are the kinds whose failed findings telemetry adjusts.
+var runtimeWorkloadKinds = []string{"Pod", "Deployment", "ReplicaSet", "StatefulSet", "DaemonSet", "Job", "CronJob"}
+
+// runtimeActivity is the behavior observed in a pod, or in all the pods of a
+// workload.
+type runtimeActivity struct {
+	shells       map[string]struct{}
+	destinations map[string]struct{}
+	capabilities map[string]struct{}
+}
+
+func newRuntimeActivity() *runtimeActivity {
+	return &runtimeActivity{
+		shells:       map[string]struct{}{},
+		destinations: map[string]struct{}{},
+		capabilities: map[string]struct{}{},
+	}
+}
+
+func (a *runtimeActivity) merge(other *runtimeActivity) {
+	maps.Copy(a.shells, other.shells)
+	maps.Copy(a.destinations, other.destinations)
+	maps.Copy(a.capabilities, other.capabilities)
+}
+
+// runtimeTelemetry accumulates the telemetry events received during a scan,
+// by pod UID.
+type runtimeTelemetry struct {
+	events       int
+	unattributed int
+	pods         map[string]*runtimeActivity
+	podEvents    map[string]int
+}
+
+func newRuntimeTelemetry() *runtimeTelemetry {
+	return &runtimeTelemetry{
+		pods:      map[string]*runtimeActivity{},
+		podEvents: map[string]int{},
+	}
+}
+
+func (t *runtimeTelemetry) record(event hostsensorutils.SyscallEvent) {
+	t.events++
+	if event.PodUID == "" {
+		t.unattributed++
+		return
+	}
+	t.podEvents[event.PodUID]++
+	activity, ok := t.pods[event.PodUID]
+	if !ok {
+		activity = newRuntimeActivity()
+		t.pods[event.PodUID] = activity
+	}
+
+	switch event.Type {
+	case hostsensorutils.SyscallEventExec:
+		if shell := path.Base(event.Path); slices.Contains(runtimeShells, shell) {
+			activity.shells[shell] = struct{}{}
+		}
+	case hostsensorutils.SyscallEventConnect:
+		if isOutbound(event.RemoteAddr) {
+			activity.destinations[event.RemoteAddr] = struct{}{}
+		}
+	}
+	if event.CapabilityName != "" {
+		activity.capabilities[normalizeCapability(event.CapabilityName)] = struct{}{}
+	}
+}
+
+// isOutbound reports whether a connect destination is outside the pod: an IP
+// address that is neither loopback nor unspecified. A unix socket, or a
+// destination that is missing or not an address, is not counted: a finding is
+// only boosted on activity actually observed.
+func isOutbound(addr string) bool {
+	if strings.HasPrefix(addr, "/") || strings.HasPrefix(addr, "@") {
+		return false
+	}
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && !ip.IsLoopback() && !ip.IsUnspecified()
+}
+
+// normalizeCapability turns "CAP_NET_ADMIN" and "net_admin" into "NET_ADMIN",
+// the form pod specs use.
+func normalizeCapability(name string) string {
+	return strings.TrimPrefix(strings.ToUpper(name), "CAP_")
+}
+
+// workloadActivity attributes the activity of every observed pod to the pod's
+// resource and to the workloads owning it. It also returns the number of
+// events of pods missing from the scanned resources.
+func (t *runtimeTelemetry) workloadActivity(allResources map[string]workloadinterface.IMetadata) (map[string]*runtimeActivity, int) {
+	byUID := make(map[string]string, len(allResources))
+	byName := make(map[string]string, len(allResources))
+	for id, resource := range allResources {
+		if resource == nil {
+			continue
+		}
+		if uid, ok := workloadinterface.InspectMap(resource.GetObject(), "metadata", "uid"); ok {
+			if uid, ok := uid.(string); ok && uid != "" {
+				byUID[uid] = id
+			}
+		}
+		byName[resourceKey(resource.GetKind(), resource.GetNamespace(), resource.GetName())] = id
+	}
+
+	activities := map[string]*runtimeActivity{}
+	unattributed := 0
+	for uid, pod := range t.pods {
+		id, ok := byUID[uid]
+		if !ok {
+			unattributed += t.podEvents[uid]
+			continue
+		}
+		for _, owner := range ownerChain(id, allResources, byUID, byName) {
+			activity, ok := activities[owner]
+			if !ok {
+				activity = newRuntimeActivity()
+				activities[owner] = activity
+			}
+			activity.merge(pod)
+		}
+	}
+	return activities, unattributed
+}
+
+func resourceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// ownerChain lists a resource and the resources owning it, transitively.
+func ownerChain(id string, allResources map[string]workloadinterface.IMetadata, byUID, byName map[string]string) []string {
+	chain := []string{id}
+	for current := id; ; {
+		owner, ok := resolveOwner(allResources[current], byUID, byName)
+		if !ok || slices.Contains(chain, owner) {
+			return chain
+		}
+		chain = append(chain, owner)
+		current = owner
+	}
+}
+
+// resolveOwner finds the scanned resource owning resource. ReplicaSets and
+// Jobs are often not scanned, so an owner of these kinds that is missing is
+// resolved to the Deployment or CronJob its name derives from.
+func resolveOwner(resource workloadinterface.IMetadata, byUID, byName map[string]string) (string, bool) {
+	if resource == nil {
+		return "", false
+	}
+	obj := workloadinterface.NewWorkloadObj(resource.GetObject())
+	if obj == nil {
+		return "", false
+	}
+	ownerReferences, err := obj.GetOwnerReferences()
+	if err != nil {
+		return "", false
+	}
+	for _, ref := range ownerReferences {
+		if id, ok := byUID[string(ref.UID)]; ok {
+			return id, true
+		}
+		var kind string
+		switch ref.Kind {
+		case "ReplicaSet":
+			kind = "Deployment"
+		case "Job":
+			kind = "CronJob"
+		default:
+			continue
+		}
+		if i := strings.LastIndex(ref.Name, "-"); i > 0 {
+			if id, ok := byName[resourceKey(kind, resource.GetNamespace(), ref.Name[:i])]; ok {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// addedCapabilities lists the capabilities the containers of a workload add.
+func addedCapabilities(resource workloadinterface.IMetadata) []string {
+	obj := workloadinterface.NewWorkloadObj(resource.GetObject())
+	if obj == nil {
+		return nil
+	}
+	containers, err := obj.GetContainers()
+	if err != nil {
+		return nil
+	}
+	var added []string
+	for _, container := range containers {
+		if container.SecurityContext == nil || container.SecurityContext.Capabilities == nil {
+			continue
+		}
+		for _, capability := range container.SecurityContext.Capabilities.Add {
+			added = append(added, normalizeCapability(string(capability)))
+		}
+	}
+	return added
+}
+
+// runtimeAdjustments decides how the failed findings of every workload with
+// failed findings are weighed, and the evidence for it.
+func (su *ScoreWrapper) runtimeAdjustments(activities map[string]*runtimeActivity) (map[string]float32, []cautils.RuntimeEvidence) {
+	factors := map[string]float32{}
+	var evidence []cautils.RuntimeEvidence
+	for resourceID, result := range su.opaSessionObj.ResourcesResult {
+		resource, ok := su.opaSessionObj.AllResources[resourceID]
+		if !ok || resource == nil || !slices.Contains(runtimeWorkloadKinds, resource.GetKind()) || !hasFailedControl(&result) {
+			continue
+		}
+
+		e := cautils.RuntimeEvidence{
+			ResourceID: resourceID,
+			Kind:       resource.GetKind(),
+			Namespace:  resource.GetNamespace(),
+			Name:       resource.GetName(),
+			Adjustment: cautils.RuntimeLowered,
+			Factor:     runtimeDiscount,
+		}
+		if activity, ok := activities[resourceID]; ok {
+			e.Shells = slices.Sorted(maps.Keys(activity.shells))
+			e.OutboundConnections = slices.Sorted(maps.Keys(activity.destinations))
+			added := addedCapabilities(resource)
+			for capability := range activity.capabilities {
+				if slices.Contains(added, capability) || slices.Contains(added, "ALL") {
+					e.Capabilities = append(e.Capabilities, capability)
+				}
+			}
+			slices.Sort(e.Capabilities)
+		}
+		if len(e.Shells) > 0 || len(e.OutboundConnections) > 0 || len(e.Capabilities) > 0 {
+			e.Adjustment = cautils.RuntimeBoosted
+			e.Factor = runtimeBoost
+		}
+
+		factors[resourceID] = e.Factor
+		evidence = append(evidence, e)
+	}
+
+	// Boosted workloads first, as they are the ones worth looking at.
+	slices.SortFunc(evidence, func(a, b cautils.RuntimeEvidence) int {
+		return cmp.Or(cmp.Compare(a.Adjustment, b.Adjustment), cmp.Compare(a.ResourceID, b.ResourceID))
+	})
+	return factors, evidence
+}
+
+func hasFailedControl(result *resourcesresults.Result) bool {
+	for i := range result.AssociatedControls {
+		if result.AssociatedControls[i].GetStatus(nil).IsFailed() {
+			return true
+		}
+	}
+	return false
+}
+
+// runtimeScore scores the report again, with the failed findings of every
+// workload weighed by its runtime adjustment. It scores like the scan's
+// scoring profile, if any, and returns nil when no telemetry was received.
+func (su *ScoreWrapper) runtimeScore(telemetry *runtimeTelemetry) *cautils.RuntimeScore {
+	if telemetry.events == 0 {
+		return nil
+	}
+	activities, unattributed := telemetry.workloadActivity(su.opaSessionObj.AllResources)
+	factors, evidence := su.runtimeAdjustments(activities)
+
+	profile := cmp.Or(su.opaSessionObj.ScoringProfile, defaultScoringProfile)
+	scores, weights := su.controlScores(profile, su.tallyControls(profile, factors))
+
+	summary := &su.opaSessionObj.Report.SummaryDetails
+	runtimeScore := &cautils.RuntimeScore{
+		StaticComplianceScore: summary.ComplianceScore,
+		ComplianceScore:       weightedScore(maps.Keys(summary.Controls), scores, weights),
+		Events:                telemetry.events,
+		UnattributedEvents:    telemetry.unattributed + unattributed,
+		Workloads:             evidence,
+	}
+	for _, framework := range summary.Frameworks {
+		if runtimeScore.Frameworks == nil {
+			runtimeScore.Frameworks = map[string]float32{}
+		}
+		runtimeScore.Frameworks[framework.GetName()] = weightedScore(maps.Keys(framework.Controls), scores, weights)
+	}
+	return runtimeScore
+}
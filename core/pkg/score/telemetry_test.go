@@ -0,0 +1,130 @@
+package score
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/pkg/hostsensorutils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// telemetrySession has three deployments evaluated by one control: web and
+// batch fail it, api passes it. Each deployment runs one pod, owned through a
+// ReplicaSet that was not scanned.
+func telemetrySession() *cautils.OPASessionObj {
+	session := cautils.NewOPASessionObjMock()
+	session.Report.SummaryDetails.Controls = reportsummary.ControlSummaries{
+		"C-0046": reportsummary.ControlSummary{ControlID: "C-0046", ScoreFactor: 7, StatusInfo: apis.StatusInfo{InnerStatus: apis.StatusFailed}},
+	}
+
+	for name, status := range map[string]apis.ScanningStatus{"web": apis.StatusFailed, "batch": apis.StatusFailed, "api": apis.StatusPassed} {
+		deployment := workloadinterface.NewWorkloadObj(map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": name, "namespace": "prod", "uid": name + "-uid"},
+			"spec": map[string]any{"template": map[string]any{"spec": map[string]any{"containers": []any{map[string]any{
+				"name":            name,
+				"image":           name + ":1.0",
+				"securityContext": map[string]any{"capabilities": map[string]any{"add": []any{"NET_ADMIN"}}},
+			}}}}},
+		})
+		pod := workloadinterface.NewWorkloadObj(map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]any{
+				"name": name + "-5d8f7c-x2x9q", "namespace": "prod", "uid": name + "-pod-uid",
+				"ownerReferences": []any{map[string]any{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": name + "-5d8f7c", "uid": name + "-rs-uid"}},
+			},
+			"spec": map[string]any{"containers": []any{map[string]any{"name": name, "image": name + ":1.0"}}},
+		})
+		session.AllResources[deployment.GetID()] = deployment
+		session.AllResources[pod.GetID()] = pod
+		session.ResourcesResult[deployment.GetID()] = resourcesresults.Result{
+			ResourceID: deployment.GetID(),
+			AssociatedControls: []resourcesresults.ResourceAssociatedControl{{
+				ControlID:               "C-0046",
+				Status:                  apis.StatusInfo{InnerStatus: status},
+				ResourceAssociatedRules: []resourcesresults.ResourceAssociatedRule{{Name: "rule-capabilities", Status: status}},
+			}},
+		}
+	}
+	return session
+}
+
+func cannedTelemetry(events ...hostsensorutils.SyscallEvent) <-chan hostsensorutils.SyscallEvent {
+	telemetry := make(chan hostsensorutils.SyscallEvent, len(events))
+	for _, event := range events {
+		telemetry <- event
+	}
+	close(telemetry)
+	return telemetry
+}
+
+func TestCalculateWithTelemetry(t *testing.T) {
+	session := telemetrySession()
+	telemetry := cannedTelemetry(
+		hostsensorutils.SyscallEvent{PodUID: "web-pod-uid", Type: hostsensorutils.SyscallEventExec, Path: "/bin/sh"},
+		hostsensorutils.SyscallEvent{PodUID: "web-pod-uid", Type: hostsensorutils.SyscallEventExec, Path: "/usr/local/bin/web"},
+		hostsensorutils.SyscallEvent{PodUID: "web-pod-uid", Type: hostsensorutils.SyscallEventConnect, RemoteAddr: "203.0.113.7:443"},
+		hostsensorutils.SyscallEvent{PodUID: "web-pod-uid", Type: hostsensorutils.SyscallEventCapability, CapabilityName: "CAP_NET_ADMIN"},
+		hostsensorutils.SyscallEvent{PodUID: "batch-pod-uid", Type: hostsensorutils.SyscallEventConnect, RemoteAddr: "127.0.0.1:8080"},
+		hostsensorutils.SyscallEvent{PodUID: "deleted-pod-uid", Type: hostsensorutils.SyscallEventExec, Path: "/bin/bash"},
+	)
+
+	require.NoError(t, NewScoreWrapper(session).CalculateWithTelemetry(context.Background(), EPostureReportV2, telemetry))
+
+	runtimeScore := session.RuntimeScore
+	require.NotNil(t, runtimeScore)
+	assert.Equal(t, 6, runtimeScore.Events)
+	assert.Equal(t, 1, runtimeScore.UnattributedEvents, "events of pods that were not scanned are unattributed")
+	assert.Equal(t, session.Report.SummaryDetails.ComplianceScore, runtimeScore.StaticComplianceScore)
+
+	require.Len(t, runtimeScore.Workloads, 2, "only workloads with failed findings are adjusted")
+	web, batch := runtimeScore.Workloads[0], runtimeScore.Workloads[1]
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, cautils.RuntimeBoosted, web.Adjustment)
+	assert.Equal(t, []string{"sh"}, web.Shells)
+	assert.Equal(t, []string{"203.0.113.7:443"}, web.OutboundConnections)
+	assert.Equal(t, []string{"NET_ADMIN"}, web.Capabilities)
+
+	assert.Equal(t, "batch", batch.Name)
+	assert.Equal(t, cautils.RuntimeLowered, batch.Adjustment, "loopback connections are not outbound")
+	assert.Empty(t, batch.OutboundConnections)
+
+	// api passes (1), web fails boosted (2) and batch fails lowered (0.5).
+	assert.InDelta(t, 100.0/3.5, runtimeScore.ComplianceScore, 0.01)
+}
+
+func TestCalculateWithTelemetryWithoutEvents(t *testing.T) {
+	session := telemetrySession()
+
+	require.NoError(t, NewScoreWrapper(session).CalculateWithTelemetry(context.Background(), EPostureReportV2, cannedTelemetry()))
+
+	assert.Nil(t, session.RuntimeScore)
+}
+
+func TestCalculateWithTelemetryStopsWhenContextIsDone(t *testing.T) {
+	session := telemetrySession()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, NewScoreWrapper(session).CalculateWithTelemetry(ctx, EPostureReportV2, make(chan hostsensorutils.SyscallEvent)))
+
+	assert.Nil(t, session.RuntimeScore)
+}
+
+func TestIsOutbound(t *testing.T) {
+	assert.True(t, isOutbound("203.0.113.7:443"))
+	assert.True(t, isOutbound("[2001:db8::1]:443"))
+	assert.False(t, isOutbound(""), "a missing destination is not an outbound connection")
+	assert.False(t, isOutbound("not-an-address"))
+	assert.False(t, isOutbound("127.0.0.1:8080"))
+	assert.False(t, isOutbound("[::1]:8080"))
+	assert.False(t, isOutbound("/var/run/docker.sock"))
+}
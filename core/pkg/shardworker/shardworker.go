@@ -0,0 +1,290 @@
+// Package shardworker evaluates the shards of distributed scans over HTTP. A
+// worker serves Handler; the coordinating scan sends its shards to the workers
+// through a Client (see opaprocessor.Shard).
+package shardworker
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor"
+)
+
+// EvaluatePath is the path shards are posted to.
+const EvaluatePath = "/v1/shards"
+
+// TokenEnv holds the bearer token workers require and coordinators present,
+// the same variable that gates the API of the Kubescape microservice.
+const TokenEnv = "KS_API_TOKEN"
+
+// DefaultMaxShardBytes bounds a shard, both as posted and once decompressed,
+// when Handler.MaxShardBytes is zero. Workers refuse larger shards with 413,
+// and the coordinator splits them (see opaprocessor.ErrShardTooLarge).
+const DefaultMaxShardBytes = 64 << 20
+
+// DefaultShardTimeout bounds a shard's round trip to a worker when the client
+// is given no timeout. A shard a worker does not answer in time is sent to the
+// next worker.
+const DefaultShardTimeout = 10 * time.Minute
+
+// dialTimeout and tlsHandshakeTimeout bound connecting to a worker, so that an
+// unreachable one is given up on long before the shard timeout.
+const (
+	dialTimeout         = 10 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+// shutdownTimeout bounds how long in-flight shards may finish on shutdown.
+const shutdownTimeout = 30 * time.Second
+
+// Token returns the bearer token of TokenEnv, empty when unset.
+func Token() string {
+	return strings.TrimSpace(os.Getenv(TokenEnv))
+}
+
+// Handler evaluates the shards posted to it.
+type Handler struct {
+	// Token, when set, is the bearer token requests must present.
+	Token string
+	// Evaluate evaluates a shard, opaprocessor.EvaluateShard when nil.
+	Evaluate func(ctx context.Context, shard *opaprocessor.Shard) (*opaprocessor.ShardResult, error)
+	// MaxShardBytes bounds a shard, DefaultMaxShardBytes when zero. Larger
+	// shards are refused with 413, which the client reports as
+	// opaprocessor.ErrShardTooLarge.
+	MaxShardBytes int64
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Token != "" {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(h.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kubescape"`)
+			http.Error(w, "missing or invalid Authorization header", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	maxShardBytes := cmp.Or(h.MaxShardBytes, DefaultMaxShardBytes)
+	r.Body = http.MaxBytesReader(w, r.Body, maxShardBytes)
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			shardReadError(w, "failed to read the shard", err)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	// One byte past the limit tells a shard of exactly the limit from a
+	// larger one.
+	limited := &io.LimitedReader{R: body, N: maxShardBytes + 1}
+	var shard opaprocessor.Shard
+	err := json.NewDecoder(limited).Decode(&shard)
+	if limited.N <= 0 {
+		err = &http.MaxBytesError{Limit: maxShardBytes}
+	}
+	if err != nil {
+		shardReadError(w, "failed to decode the shard", err)
+		return
+	}
+
+	evaluate := h.Evaluate
+	if evaluate == nil {
+		evaluate = opaprocessor.EvaluateShard
+	}
+	start := time.Now()
+	result, err := evaluate(r.Context(), &shard)
+	if result == nil {
+		http.Error(w, fmt.Sprintf("failed to evaluate the shard: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	logger.L().Debug("evaluated shard", helpers.String("shard", shard.ID), helpers.Int("controls", len(shard.Controls)), helpers.String("duration", time.Since(start).String()))
+
+	w.Header().Set("Content-Type", "application/json")
+	out := io.Writer(w)
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+	if err := json.NewEncoder(out).Encode(result); err != nil {
+		logger.L().Ctx(r.Context()).Warning("failed to write the shard result", helpers.String("shard", shard.ID), helpers.Error(err))
+	}
+}
+
+// shardReadError answers a shard that could not be read: 413 when it exceeds
+// the size limit, 400 otherwise.
+func shardReadError(w http.ResponseWriter, message string, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("the shard exceeds %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusBadRequest)
+}
+
+// Serve serves handler on addr at EvaluatePath, with a /healthz probe, until
+// ctx is done. It serves TLS when certFile and keyFile are set.
+func Serve(ctx context.Context, addr, certFile, keyFile string, handler http.Handler) error {
+	mux := http.NewServeMux()
+	mux.Handle(EvaluatePath, handler)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdownErr <- server.Shutdown(shutdownCtx)
+	}()
+
+	logger.L().Info("Serving shard evaluation", helpers.String("address", addr), helpers.String("path", EvaluatePath))
+	var err error
+	if certFile != "" {
+		err = server.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-shutdownErr
+}
+
+// Client sends shards to workers. Shards are spread over the workers round
+// robin, and a shard a worker fails to answer, or does not answer within the
+// shard timeout, is sent to the next one. A shard a worker refuses for its
+// size is not: workers share the size limit, so the coordinator splits it.
+type Client struct {
+	workers      []string
+	token        string
+	shardTimeout time.Duration
+	httpClient   *http.Client
+	next         atomic.Uint64
+}
+
+// NewClient returns a client of the workers at the base URLs workers,
+// presenting token when set. Each attempt at a shard is bounded by
+// shardTimeout, DefaultShardTimeout when zero.
+func NewClient(workers []string, token string, shardTimeout time.Duration) *Client {
+	if shardTimeout <= 0 {
+		shardTimeout = DefaultShardTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	// The worker answers once the shard is evaluated, so the headers take as
+	// long as the evaluation.
+	transport.ResponseHeaderTimeout = shardTimeout
+	return &Client{
+		workers:      workers,
+		token:        token,
+		shardTimeout: shardTimeout,
+		httpClient:   &http.Client{Transport: transport},
+	}
+}
+
+// EvaluateShard implements opaprocessor.ShardEvaluator.
+func (c *Client) EvaluateShard(ctx context.Context, shard *opaprocessor.Shard) (*opaprocessor.ShardResult, error) {
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	if err := json.NewEncoder(gz).Encode(shard); err != nil {
+		return nil, fmt.Errorf("failed to encode the shard: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode the shard: %w", err)
+	}
+
+	first := c.next.Add(1) - 1
+	var errs []error
+	for i := range c.workers {
+		worker := c.workers[(first+uint64(i))%uint64(len(c.workers))]
+		result, err := c.post(ctx, worker, body.Bytes())
+		if err == nil {
+			if result.Error != "" {
+				return result, errors.New(result.Error)
+			}
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, opaprocessor.ErrShardTooLarge) {
+			return nil, fmt.Errorf("worker %s: %w", worker, err)
+		}
+		errs = append(errs, fmt.Errorf("worker %s: %w", worker, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (c *Client) post(ctx context.Context, worker string, body []byte) (*opaprocessor.ShardResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.shardTimeout)
+	defer cancel()
+
+	endpoint, err := url.JoinPath(worker, EvaluatePath)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		if resp.StatusCode == http.StatusRequestEntityTooLarge {
+			return nil, fmt.Errorf("%s: %s: %w", resp.Status, strings.TrimSpace(string(message)), opaprocessor.ErrShardTooLarge)
+		}
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	var result opaprocessor.ShardResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode the shard result: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,179 @@
+package shardworker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v4/core/pkg/opaprocessor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubWorker serves a Handler answering every shard with the worker's name
+// as its only timed-out control, and counts the shards it answered.
+type stubWorker struct {
+	*httptest.Server
+	mu     sync.Mutex
+	shards int
+}
+
+func newStubWorker(t *testing.T, name, token string) *stubWorker {
+	w := &stubWorker{}
+	w.Server = httptest.NewServer(&Handler{
+		Token: token,
+		Evaluate: func(_ context.Context, shard *opaprocessor.Shard) (*opaprocessor.ShardResult, error) {
+			w.mu.Lock()
+			w.shards++
+			w.mu.Unlock()
+			return &opaprocessor.ShardResult{ID: shard.ID, TimedOutControls: map[string]string{name: shard.Scope}}, nil
+		},
+	})
+	t.Cleanup(w.Close)
+	return w
+}
+
+func TestClient_SpreadsShardsOverWorkers(t *testing.T) {
+	a, b := newStubWorker(t, "a", "secret"), newStubWorker(t, "b", "secret")
+	client := NewClient([]string{a.URL, b.URL}, "secret", 0)
+
+	for i := range 4 {
+		shard := &opaprocessor.Shard{ID: fmt.Sprintf("ns-%d/0", i), Scope: fmt.Sprintf("ns-%d", i)}
+		result, err := client.EvaluateShard(context.Background(), shard)
+		require.NoError(t, err)
+		assert.Equal(t, shard.ID, result.ID)
+		assert.Contains(t, result.TimedOutControls, []string{"a", "b"}[i%2])
+	}
+	assert.Equal(t, 2, a.shards)
+	assert.Equal(t, 2, b.shards)
+}
+
+func TestClient_FailsOverToTheNextWorker(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "out of memory", http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	healthy := newStubWorker(t, "healthy", "")
+
+	client := NewClient([]string{broken.URL, healthy.URL}, "", 0)
+	for range 2 {
+		result, err := client.EvaluateShard(context.Background(), &opaprocessor.Shard{ID: "clusterScope/0"})
+		require.NoError(t, err)
+		assert.Contains(t, result.TimedOutControls, "healthy")
+	}
+
+	client = NewClient([]string{broken.URL}, "", 0)
+	result, err := client.EvaluateShard(context.Background(), &opaprocessor.Shard{ID: "clusterScope/0"})
+	assert.Nil(t, result, "no worker evaluated the shard")
+	assert.ErrorContains(t, err, "out of memory")
+}
+
+func TestClient_ReturnsPartialResults(t *testing.T) {
+	server := httptest.NewServer(&Handler{
+		Evaluate: func(_ context.Context, shard *opaprocessor.Shard) (*opaprocessor.ShardResult, error) {
+			return &opaprocessor.ShardResult{ID: shard.ID}, errors.New(`control "C-0001": rego eval failed`)
+		},
+	})
+	defer server.Close()
+
+	result, err := NewClient([]string{server.URL}, "", 0).EvaluateShard(context.Background(), &opaprocessor.Shard{ID: "ns-a/0"})
+	require.NotNil(t, result)
+	assert.Equal(t, "ns-a/0", result.ID)
+	assert.ErrorContains(t, err, "rego eval failed")
+}
+
+func TestClient_FailsOverFromAHungWorker(t *testing.T) {
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer hung.Close()
+	defer close(release)
+	healthy := newStubWorker(t, "healthy", "")
+
+	client := NewClient([]string{hung.URL, healthy.URL}, "", 100*time.Millisecond)
+	result, err := client.EvaluateShard(context.Background(), &opaprocessor.Shard{ID: "ns-a/0"})
+	require.NoError(t, err)
+	assert.Contains(t, result.TimedOutControls, "healthy")
+}
+
+func TestClient_ReportsOversizedShards(t *testing.T) {
+	small := httptest.NewServer(&Handler{MaxShardBytes: 64})
+	defer small.Close()
+	other := newStubWorker(t, "other", "")
+
+	shard := &opaprocessor.Shard{ID: "ns-a/0", Scope: strings.Repeat("a", 1<<10)}
+	result, err := NewClient([]string{small.URL, other.URL}, "", 0).EvaluateShard(context.Background(), shard)
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, opaprocessor.ErrShardTooLarge)
+	assert.Zero(t, other.shards, "a shard too large for one worker is split rather than sent to the next")
+}
+
+func TestHandler_RejectsBadRequests(t *testing.T) {
+	worker := newStubWorker(t, "a", "secret")
+
+	result, err := NewClient([]string{worker.URL}, "wrong", 0).EvaluateShard(context.Background(), &opaprocessor.Shard{})
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "401")
+
+	resp, err := http.Get(worker.URL + EvaluatePath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodPost, worker.URL+EvaluatePath, strings.NewReader("{"))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Zero(t, worker.shards)
+}
+
+func TestHandler_RefusesOversizedShards(t *testing.T) {
+	evaluated := false
+	handler := &Handler{
+		MaxShardBytes: 64,
+		Evaluate: func(_ context.Context, shard *opaprocessor.Shard) (*opaprocessor.ShardResult, error) {
+			evaluated = true
+			return &opaprocessor.ShardResult{ID: shard.ID}, nil
+		},
+	}
+	oversized := `{"id":"` + strings.Repeat("a", 128) + `"}`
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte(oversized))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.Less(t, compressed.Len(), 64, "the compressed shard fits the limit")
+
+	for name, req := range map[string]*http.Request{
+		"plain":   httptest.NewRequest(http.MethodPost, EvaluatePath, strings.NewReader(oversized)),
+		"gzipped": httptest.NewRequest(http.MethodPost, EvaluatePath, bytes.NewReader(compressed.Bytes())),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if name == "gzipped" {
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		})
+	}
+	assert.False(t, evaluated)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, EvaluatePath, strings.NewReader(`{"id":"ns-a/0"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, evaluated)
+}
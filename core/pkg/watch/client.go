@@ -0,0 +1,190 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/core/pkg/scancache"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// changesBuffer bounds the notices waiting for the watcher. A notice dropped
+// on a full buffer loses nothing: the watcher rescans the whole cluster on
+// the notices it already has.
+const changesBuffer = 1024
+
+// ResourceChange notifies that a watched object was created, updated or
+// deleted.
+type ResourceChange struct {
+	GVR       schema.GroupVersionResource
+	Namespace string
+	Name      string
+	Deleted   bool
+}
+
+// Client is a dynamic client serving the cluster-wide lists of a scan from
+// informer caches. The first list of a resource type starts an informer on it,
+// so the types watched are exactly those the loaded controls need. Later
+// scans list from memory, and every change to a cached object is notified on
+// Changes. Requests other than cluster-wide lists go to the API server.
+type Client struct {
+	dynamic.Interface
+
+	ctx       context.Context
+	mu        sync.Mutex
+	informers map[schema.GroupVersionResource]cache.SharedIndexInformer
+	changes   chan ResourceChange
+}
+
+// NewClient returns a Client listing through client. Informers stop when ctx
+// is done.
+func NewClient(ctx context.Context, client dynamic.Interface) *Client {
+	return &Client{
+		Interface: client,
+		ctx:       ctx,
+		informers: map[schema.GroupVersionResource]cache.SharedIndexInformer{},
+		changes:   make(chan ResourceChange, changesBuffer),
+	}
+}
+
+// Changes returns the notices of changed objects.
+func (c *Client) Changes() <-chan ResourceChange {
+	return c.changes
+}
+
+// Resource returns a client for resource whose cluster-wide lists are served
+// from its informer cache.
+func (c *Client) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &cachedResource{
+		NamespaceableResourceInterface: c.Interface.Resource(resource),
+		client:                         c,
+		gvr:                            resource,
+	}
+}
+
+// informer returns the synced informer of gvr, starting it on first use.
+func (c *Client) informer(ctx context.Context, gvr schema.GroupVersionResource) (cache.SharedIndexInformer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if informer, ok := c.informers[gvr]; ok {
+		return informer, nil
+	}
+
+	// An informer retries a failed list forever, so a type the scan cannot
+	// list (forbidden, or removed from the cluster) would block the sync
+	// below. Listing it once first surfaces the error to the scan instead.
+	if _, err := c.Interface.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1}); err != nil {
+		return nil, err
+	}
+
+	informer := dynamicinformer.NewFilteredDynamicInformer(c.Interface, gvr, metav1.NamespaceAll, 0,
+		cache.Indexers{}, nil).Informer()
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if !isInInitialList {
+				c.notify(gvr, obj, false)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			// Resyncs and status-only updates do not trigger a rescan;
+			// status changes are picked up by the next one.
+			if hashOf(oldObj) != hashOf(newObj) {
+				c.notify(gvr, newObj, false)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			c.notify(gvr, obj, true)
+		},
+	}); err != nil {
+		return nil, err
+	}
+	go informer.Run(c.ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return nil, fmt.Errorf("failed to sync the cache of %s: %w", gvr, ctx.Err())
+	}
+	logger.L().Debug("watching resource", helpers.String("resource", gvr.String()))
+
+	c.informers[gvr] = informer
+	return informer, nil
+}
+
+func (c *Client) notify(gvr schema.GroupVersionResource, obj any, deleted bool) {
+	object, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	select {
+	case c.changes <- ResourceChange{GVR: gvr, Namespace: object.GetNamespace(), Name: object.GetName(), Deleted: deleted}:
+	default:
+	}
+}
+
+func hashOf(obj any) string {
+	object, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return ""
+	}
+	return scancache.ResourceHash(object.Object)
+}
+
+type cachedResource struct {
+	dynamic.NamespaceableResourceInterface
+
+	client *Client
+	gvr    schema.GroupVersionResource
+}
+
+// List serves the list from the informer cache. The cache holds every object,
+// so the list is returned in one page whatever opts.Limit asks.
+func (r *cachedResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	labelSelector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	for _, requirement := range fieldSelector.Requirements() {
+		if requirement.Field != "metadata.name" && requirement.Field != "metadata.namespace" {
+			// Only the API server knows the other fields of a type.
+			return r.NamespaceableResourceInterface.List(ctx, opts)
+		}
+	}
+
+	informer, err := r.client.informer(ctx, r.gvr)
+	if err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(r.gvr.GroupVersion().String())
+	list.SetKind("List")
+	list.SetResourceVersion(informer.LastSyncResourceVersion())
+	for _, obj := range informer.GetStore().List() {
+		object, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if !labelSelector.Matches(labels.Set(object.GetLabels())) {
+			continue
+		}
+		if !fieldSelector.Matches(fields.Set{"metadata.name": object.GetName(), "metadata.namespace": object.GetNamespace()}) {
+			continue
+		}
+		list.Items = append(list.Items, *object.DeepCopy())
+	}
+	return list, nil
+}
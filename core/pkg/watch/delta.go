@@ -0,0 +1,134 @@
+package watch
+
+import (
+	"sort"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+)
+
+// Change is how the result of a control on a resource changed between two
+// scans.
+type Change string
+
+const (
+	// ChangeFailed marks a control that started failing on a resource, new or
+	// existing.
+	ChangeFailed Change = "failed"
+	// ChangeResolved marks a control that stopped failing on a resource that
+	// still exists.
+	ChangeResolved Change = "resolved"
+	// ChangeDeleted marks a failure that went away with its resource.
+	ChangeDeleted Change = "deleted"
+)
+
+// Delta is a change in the result of a control on a resource.
+type Delta struct {
+	Change      Change `json:"change"`
+	ResourceID  string `json:"resourceID"`
+	APIVersion  string `json:"apiVersion,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	ControlID   string `json:"controlID"`
+	ControlName string `json:"controlName,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+}
+
+// Snapshot holds the failed controls of every scanned resource.
+type Snapshot map[string]ResourceState
+
+// ResourceState is a scanned resource and the controls failing on it.
+type ResourceState struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	// Failed holds the failed controls, keyed by control ID.
+	Failed map[string]FailedControl
+}
+
+// FailedControl is a control failing on a resource.
+type FailedControl struct {
+	Name     string
+	Severity string
+}
+
+// NewSnapshot returns the failed controls of every resource scanned in
+// sessionObj.
+func NewSnapshot(sessionObj *cautils.OPASessionObj) Snapshot {
+	snapshot := make(Snapshot, len(sessionObj.ResourcesResult))
+	for resourceID, result := range sessionObj.ResourcesResult {
+		state := ResourceState{Failed: map[string]FailedControl{}}
+		if resource, ok := sessionObj.AllResources[resourceID]; ok && resource != nil {
+			state.APIVersion = resource.GetApiVersion()
+			state.Kind = resource.GetKind()
+			state.Namespace = resource.GetNamespace()
+			state.Name = resource.GetName()
+		}
+		for _, control := range result.ListControls() {
+			if !control.GetStatus(nil).IsFailed() {
+				continue
+			}
+			failed := FailedControl{Name: control.GetName()}
+			if sessionObj.Report != nil {
+				if summary := sessionObj.Report.SummaryDetails.Controls.GetControl(reportsummary.EControlCriteriaID, control.GetID()); summary != nil {
+					failed.Severity = apis.ControlSeverityToString(summary.GetScoreFactor())
+				}
+			}
+			state.Failed[control.GetID()] = failed
+		}
+		snapshot[resourceID] = state
+	}
+	return snapshot
+}
+
+// Diff returns the changes from previous to current, sorted by resource and
+// control.
+func Diff(previous, current Snapshot) []Delta {
+	var deltas []Delta
+	for resourceID, state := range current {
+		before := previous[resourceID].Failed
+		for controlID, control := range state.Failed {
+			if _, ok := before[controlID]; !ok {
+				deltas = append(deltas, newDelta(ChangeFailed, resourceID, state, controlID, control))
+			}
+		}
+		for controlID, control := range before {
+			if _, ok := state.Failed[controlID]; !ok {
+				deltas = append(deltas, newDelta(ChangeResolved, resourceID, state, controlID, control))
+			}
+		}
+	}
+	for resourceID, state := range previous {
+		if _, ok := current[resourceID]; ok {
+			continue
+		}
+		for controlID, control := range state.Failed {
+			deltas = append(deltas, newDelta(ChangeDeleted, resourceID, state, controlID, control))
+		}
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].ResourceID != deltas[j].ResourceID {
+			return deltas[i].ResourceID < deltas[j].ResourceID
+		}
+		return deltas[i].ControlID < deltas[j].ControlID
+	})
+	return deltas
+}
+
+func newDelta(change Change, resourceID string, state ResourceState, controlID string, control FailedControl) Delta {
+	return Delta{
+		Change:      change,
+		ResourceID:  resourceID,
+		APIVersion:  state.APIVersion,
+		Kind:        state.Kind,
+		Namespace:   state.Namespace,
+		Name:        state.Name,
+		ControlID:   controlID,
+		ControlName: control.Name,
+		Severity:    control.Severity,
+	}
+}
@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+)
+
+func failing(ids ...string) map[string]FailedControl {
+	failed := map[string]FailedControl{}
+	for _, id := range ids {
+		failed[id] = FailedControl{Name: "control " + id, Severity: "High"}
+	}
+	return failed
+}
+
+func TestNewSnapshot(t *testing.T) {
+	pod := workloadinterface.NewWorkloadObj(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "api", "namespace": "payments"},
+	})
+	sessionObj := &cautils.OPASessionObj{
+		AllResources: map[string]workloadinterface.IMetadata{"pod": pod},
+		ResourcesResult: map[string]resourcesresults.Result{
+			"pod": {AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+				{ControlID: "C-0012", Name: "Secrets in env", Status: apis.StatusInfo{InnerStatus: apis.StatusFailed}},
+				{ControlID: "C-0016", Name: "Privilege escalation", Status: apis.StatusInfo{InnerStatus: apis.StatusPassed}},
+			}},
+		},
+	}
+
+	assert.Equal(t, Snapshot{"pod": {
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Namespace:  "payments",
+		Name:       "api",
+		Failed:     map[string]FailedControl{"C-0012": {Name: "Secrets in env"}},
+	}}, NewSnapshot(sessionObj))
+}
+
+func TestDiff(t *testing.T) {
+	previous := Snapshot{
+		"kept":    {Kind: "Pod", Name: "kept", Failed: failing("C-0001", "C-0002")},
+		"deleted": {Kind: "Pod", Name: "deleted", Failed: failing("C-0003")},
+		"clean":   {Kind: "Pod", Name: "clean", Failed: failing()},
+	}
+	current := Snapshot{
+		"kept":  {Kind: "Pod", Name: "kept", Failed: failing("C-0002", "C-0004")},
+		"clean": {Kind: "Pod", Name: "clean", Failed: failing()},
+		"new":   {Kind: "Pod", Name: "new", Failed: failing("C-0001")},
+	}
+
+	var got []string
+	for _, delta := range Diff(previous, current) {
+		got = append(got, string(delta.Change)+" "+delta.ResourceID+" "+delta.ControlID)
+	}
+	assert.Equal(t, []string{
+		"deleted deleted C-0003",
+		"resolved kept C-0001",
+		"failed kept C-0004",
+		"failed new C-0001",
+	}, got)
+}
+
+func TestDiffWithoutChanges(t *testing.T) {
+	snapshot := Snapshot{"pod": {Kind: "Pod", Name: "api", Failed: failing("C-0001")}}
+	assert.Empty(t, Diff(snapshot, snapshot))
+}
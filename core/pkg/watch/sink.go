@@ -0,0 +1,67 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Sink receives the deltas of every rescan, with the rescan's results.
+type Sink interface {
+	Emit(ctx context.Context, deltas []Delta, sessionObj *cautils.OPASessionObj) error
+}
+
+// JSONLSink writes each delta as one JSON line.
+type JSONLSink struct {
+	encoder *json.Encoder
+}
+
+// NewJSONLSink returns a sink writing to w.
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{encoder: json.NewEncoder(w)}
+}
+
+func (s *JSONLSink) Emit(_ context.Context, deltas []Delta, _ *cautils.OPASessionObj) error {
+	for i := range deltas {
+		if err := s.encoder.Encode(deltas[i]); err != nil {
+			return fmt.Errorf("failed to write delta: %w", err)
+		}
+	}
+	return nil
+}
+
+// EventSink records a Kubernetes Event on the resource of each delta: a
+// warning when a control starts failing, a normal event when it is resolved.
+// Failures that went away with their resource have no object to record on
+// and are skipped.
+type EventSink struct {
+	recorder record.EventRecorder
+}
+
+// NewEventSink returns a sink recording events with recorder.
+func NewEventSink(recorder record.EventRecorder) *EventSink {
+	return &EventSink{recorder: recorder}
+}
+
+func (s *EventSink) Emit(_ context.Context, deltas []Delta, _ *cautils.OPASessionObj) error {
+	for _, delta := range deltas {
+		ref := &corev1.ObjectReference{
+			APIVersion: delta.APIVersion,
+			Kind:       delta.Kind,
+			Namespace:  delta.Namespace,
+			Name:       delta.Name,
+		}
+		switch delta.Change {
+		case ChangeFailed:
+			s.recorder.Eventf(ref, corev1.EventTypeWarning, "ControlFailed", "%s %s (%s) failed", delta.ControlID, delta.ControlName, delta.Severity)
+		case ChangeResolved:
+			s.recorder.Eventf(ref, corev1.EventTypeNormal, "ControlResolved", "%s %s resolved", delta.ControlID, delta.ControlName)
+		}
+	}
+	return nil
+}
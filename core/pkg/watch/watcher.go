@@ -0,0 +1,98 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+)
+
+// DefaultDebounce is how long the watcher collects changes before rescanning.
+const DefaultDebounce = 10 * time.Second
+
+// ScanFunc scans the cluster and returns the results.
+type ScanFunc func(ctx context.Context) (*cautils.OPASessionObj, error)
+
+// Watcher rescans the cluster when watched objects change and emits how the
+// results changed. Rescans list from the informer caches of the Client and
+// reuse the verdicts of unchanged resources from the incremental scan cache,
+// so only the changed objects, and the multi-resource controls relating them
+// to others, are evaluated again.
+type Watcher struct {
+	Scan     ScanFunc
+	Changes  <-chan ResourceChange
+	Debounce time.Duration
+	Sinks    []Sink
+}
+
+// Run scans once for a baseline, then rescans after every batch of changes
+// until ctx is done. A failed rescan is logged and the baseline kept.
+func (w *Watcher) Run(ctx context.Context) error {
+	sessionObj, err := w.Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("initial scan failed: %w", err)
+	}
+	previous := NewSnapshot(sessionObj)
+	logger.L().Info("Watching for changes", helpers.Int("resources", len(previous)))
+
+	for {
+		changes, ok := w.collect(ctx)
+		if !ok {
+			return nil
+		}
+		logger.L().Debug("rescanning", helpers.Int("changes", changes))
+
+		sessionObj, err := w.Scan(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.L().Ctx(ctx).Warning("rescan failed", helpers.Error(err))
+			continue
+		}
+		current := NewSnapshot(sessionObj)
+		deltas := Diff(previous, current)
+		previous = current
+		if len(deltas) == 0 {
+			continue
+		}
+		for _, sink := range w.Sinks {
+			if err := sink.Emit(ctx, deltas, sessionObj); err != nil {
+				logger.L().Ctx(ctx).Warning("failed to emit result changes", helpers.Error(err))
+			}
+		}
+	}
+}
+
+// collect waits for a change, then collects the changes arriving within the
+// debounce period, and returns how many there were. It returns false when ctx
+// is done.
+func (w *Watcher) collect(ctx context.Context) (int, bool) {
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	var timeout <-chan time.Time
+	changes := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return changes, false
+		case change := <-w.Changes:
+			logger.L().Debug("resource changed",
+				helpers.String("resource", change.GVR.String()),
+				helpers.String("namespace", change.Namespace),
+				helpers.String("name", change.Name),
+				helpers.Interface("deleted", change.Deleted))
+			if changes == 0 {
+				timeout = time.After(debounce)
+			}
+			changes++
+		case <-timeout:
+			return changes, true
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	deltas chan []Delta
+}
+
+func (s *recordingSink) Emit(_ context.Context, deltas []Delta, _ *cautils.OPASessionObj) error {
+	s.deltas <- deltas
+	return nil
+}
+
+func sessionWithStatus(status apis.ScanningStatus) *cautils.OPASessionObj {
+	return &cautils.OPASessionObj{ResourcesResult: map[string]resourcesresults.Result{
+		"pod": {AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+			{ControlID: "C-0012", Status: apis.StatusInfo{InnerStatus: status}},
+		}},
+	}}
+}
+
+func TestWatcherRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scans := []*cautils.OPASessionObj{
+		sessionWithStatus(apis.StatusPassed),
+		sessionWithStatus(apis.StatusPassed),
+		sessionWithStatus(apis.StatusFailed),
+	}
+	changes := make(chan ResourceChange, 2)
+	sink := &recordingSink{deltas: make(chan []Delta, 1)}
+	w := &Watcher{
+		Scan: func(context.Context) (*cautils.OPASessionObj, error) {
+			sessionObj := scans[0]
+			scans = scans[1:]
+			return sessionObj, nil
+		},
+		Changes:  changes,
+		Debounce: time.Millisecond,
+		Sinks:    []Sink{sink},
+	}
+
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	// The first rescan changes nothing and emits nothing; the second emits
+	// the new failure.
+	changes <- ResourceChange{Name: "api"}
+	time.Sleep(50 * time.Millisecond)
+	changes <- ResourceChange{Name: "api"}
+
+	select {
+	case deltas := <-sink.deltas:
+		require.Len(t, deltas, 1)
+		assert.Equal(t, ChangeFailed, deltas[0].Change)
+		assert.Equal(t, "C-0012", deltas[0].ControlID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no deltas emitted")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatcherRunFailsOnInitialScan(t *testing.T) {
+	w := &Watcher{Scan: func(context.Context) (*cautils.OPASessionObj, error) {
+		return nil, errors.New("cluster unreachable")
+	}}
+	require.ErrorContains(t, w.Run(context.Background()), "initial scan failed: cluster unreachable")
+}
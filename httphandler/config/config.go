@@ -5,9 +5,10 @@ import (
 )
 
 type Config struct {
-	Namespace             string `mapstructure:"namespace"`
-	ClusterName           string `mapstructure:"clusterName"`
-	ContinuousPostureScan bool   `mapstructure:"continuousPostureScan"`
+	Namespace              string `mapstructure:"namespace"`
+	ClusterName            string `mapstructure:"clusterName"`
+	ContinuousPostureScan  bool   `mapstructure:"continuousPostureScan"`
+	ContinuousPostureWatch bool   `mapstructure:"continuousPostureWatch"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
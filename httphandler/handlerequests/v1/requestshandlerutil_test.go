@@ -110,6 +110,18 @@ func TestGetScanCommand(t *testing.T) {
 	assert.False(t, s.Submit.GetBool())
 }
 
+func TestGetScanCommandWithWorkers(t *testing.T) {
+	unsetEnvForTest(t, "KS_SUBMIT")
+	t.Setenv("KS_SCAN_WORKERS", "http://kubescape-worker-0.kubescape-worker:8080, http://kubescape-worker-1.kubescape-worker:8080,")
+
+	s, _ := getScanCommand(&utilsmetav1.PostScanRequest{TargetType: apisv1.KindFramework}, "abc")
+	assert.Equal(t, []string{"http://kubescape-worker-0.kubescape-worker:8080", "http://kubescape-worker-1.kubescape-worker:8080"}, s.Workers)
+
+	t.Setenv("KS_SCAN_WORKERS", "")
+	s, _ = getScanCommand(&utilsmetav1.PostScanRequest{TargetType: apisv1.KindFramework}, "abc")
+	assert.Nil(t, s.Workers)
+}
+
 func TestGetScanCommandWithAccessKey(t *testing.T) {
 	unsetEnvForTest(t, "KS_SUBMIT")
 	config.SetAccessKey("test-123")
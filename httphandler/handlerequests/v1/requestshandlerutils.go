@@ -406,6 +406,9 @@ func defaultScanInfo() *cautils.ScanInfo {
 	}
 	scanInfo.Local = envToBool("KS_KEEP_LOCAL", false)           // do not publish results to Kubescape SaaS
 	scanInfo.EnableRegoPrint = envToBool("KS_REGO_PRINT", false) // print rego rules
+	scanInfo.Workers = envToList("KS_SCAN_WORKERS")              // distribute the control evaluation to these workers
+	// evaluate locally the shards no worker could, instead of failing the scan
+	scanInfo.WorkersLocalFallback = envToBool("KS_SCAN_WORKERS_LOCAL_FALLBACK", false)
 	// Only set HostSensorEnabled when explicitly configured; leaving it nil allows
 	// auto-detection of node-agent CRDs in getHostSensorHandler.
 	if val, ok := os.LookupEnv("KS_ENABLE_HOST_SCANNER"); ok {
@@ -425,6 +428,17 @@ func envToBool(env string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// envToList returns the comma-separated values of env, nil when unset.
+func envToList(env string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(env), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func envToString(env string, defaultValue string) string {
 	if d, ok := os.LookupEnv(env); ok {
 		return d
@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/core"
+	metav1 "github.com/kubescape/kubescape/v4/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v4/core/pkg/watch"
+	"github.com/kubescape/kubescape/v4/httphandler/storage"
+)
+
+// WatchPosture watches the cluster against all frameworks and stores the
+// results of every resource whose results change. It returns when ctx is
+// done.
+func WatchPosture(ctx context.Context) {
+	scanInfo := defaultScanInfo()
+	scanInfo.FrameworkScan = true
+	scanInfo.ScanAll = true
+	scanInfo.SetScanType(cautils.ScanTypeFramework)
+
+	watchInfo := &metav1.WatchInfo{
+		Events:   envToBool("KS_WATCH_EVENTS", false),
+		Debounce: watch.DefaultDebounce,
+		Sinks:    []watch.Sink{storage.NewWatchSink(storage.GetStorage())},
+	}
+	if err := core.NewKubescape(ctx).Watch(ctx, watchInfo, scanInfo, nil); err != nil {
+		logger.L().Ctx(ctx).Error("posture watch stopped", helpers.Error(err))
+	}
+}
@@ -3,6 +3,7 @@ package listener
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -145,3 +146,30 @@ func TestBearerAuthMiddleware_ConstantTimeAndTrim(t *testing.T) {
 	rtr.ServeHTTP(rec, req)
 	require.Equal(t, http.StatusUnauthorized, rec.Code)
 }
+
+func TestHandleShards_RequiresToken(t *testing.T) {
+	newRouter := func() *mux.Router {
+		rtr := mux.NewRouter()
+		v1 := rtr.PathPrefix(v1PathPrefix).Subrouter()
+		v1.Use(bearerAuthMiddleware)
+		handleShards(v1)
+		return rtr
+	}
+
+	t.Setenv(authTokenEnv, "")
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/shards", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusNotFound, rec.Code, "shards are not evaluated without a token")
+
+	t.Setenv(authTokenEnv, "s3cr3t")
+	rtr := newRouter()
+	rec = httptest.NewRecorder()
+	rtr.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/shards", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/shards", strings.NewReader("not a shard"))
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	rec = httptest.NewRecorder()
+	rtr.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "an authenticated request reaches the shard handler")
+}
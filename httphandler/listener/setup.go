@@ -16,6 +16,7 @@ import (
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v4/core/metrics"
+	"github.com/kubescape/kubescape/v4/core/pkg/shardworker"
 	"github.com/kubescape/kubescape/v4/httphandler/docs"
 	handlerequestsv1 "github.com/kubescape/kubescape/v4/httphandler/handlerequests/v1"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
@@ -28,6 +29,7 @@ const (
 	v1StatusPath            = "/status"
 	v1ResultsPath           = "/results"
 	v1PrometheusMetricsPath = "/metrics"
+	v1ShardsPath            = "/shards" // shardworker.EvaluatePath under v1PathPrefix
 
 	// healthcheck paths
 	livePath  = "/livez"
@@ -91,6 +93,8 @@ func SetupHTTPListener() error {
 	// getAuthToken / bearerAuthMiddleware below. Unlike pprof (servePprof),
 	// which is off by default, the scan API is on by default because it is
 	// the product surface; the hardening is therefore additive and env-gated.
+	// The shard endpoint is the exception: it evaluates arbitrary Rego, so it
+	// is only served when KS_API_TOKEN is set (see handleShards).
 	//
 	// Rate limiting is intentionally not added here: scan admission already
 	// enforces bounded queue depth (KS_SCAN_QUEUE_CAPACITY, default 10) with
@@ -112,6 +116,7 @@ func SetupHTTPListener() error {
 	v1SubRouter.HandleFunc(v1StatusPath, httpHandler.Status)
 	v1SubRouter.HandleFunc(v1ResultsPath, httpHandler.GetResults).Methods(http.MethodGet)
 	v1SubRouter.HandleFunc(v1ResultsPath, httpHandler.DeleteResults).Methods(http.MethodDelete)
+	handleShards(v1SubRouter)
 
 	// OpenTelemetry metrics initialization
 	metrics.Init()
@@ -130,6 +135,20 @@ func SetupHTTPListener() error {
 	return server.Serve(ln)
 }
 
+// handleShards serves the shards of the scans distributed with
+// KS_SCAN_WORKERS, but only when KS_API_TOKEN is set: shard rules run Rego,
+// whose http.send would let any caller reaching the listener issue requests
+// from the pod, so the endpoint is never served unauthenticated.
+func handleShards(v1SubRouter *mux.Router) {
+	tok := getAuthToken()
+	if tok == "" {
+		logger.L().Info("shard evaluation endpoint disabled, set the API token to enable it", helpers.String("path", v1PathPrefix+v1ShardsPath), helpers.String("env", authTokenEnv))
+		return
+	}
+	handler := &shardworker.Handler{Token: tok, MaxShardBytes: shardworker.DefaultMaxShardBytes}
+	v1SubRouter.Handle(v1ShardsPath, handler).Methods(http.MethodPost)
+}
+
 func loadTLSKey(certFile, keyFile string) (*tls.Certificate, error) {
 	switch {
 	case certFile == "" && keyFile == "":
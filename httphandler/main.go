@@ -20,6 +20,7 @@ import (
 	"github.com/kubescape/kubescape/v4/core/cautils/getter"
 	"github.com/kubescape/kubescape/v4/httphandler/config"
 	_ "github.com/kubescape/kubescape/v4/httphandler/docs"
+	handlerequestsv1 "github.com/kubescape/kubescape/v4/httphandler/handlerequests/v1"
 	"github.com/kubescape/kubescape/v4/httphandler/listener"
 	"github.com/kubescape/kubescape/v4/httphandler/storage"
 	"github.com/kubescape/kubescape/v4/pkg/ksinit"
@@ -73,6 +74,9 @@ func main() {
 	initializeLoggerLevel()
 	initializeSaaSEnv()
 	initializeStorage(clusterName, cfg)
+	if cfg.ContinuousPostureWatch {
+		go handlerequestsv1.WatchPosture(ctx)
+	}
 	// traces will be created by otelmux.Middleware in SetupHTTPListener()
 
 	logger.L().Ctx(ctx).Fatal(listener.SetupHTTPListener().Error())
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/kubescape/kubescape/v4/core/cautils"
+	printerv2 "github.com/kubescape/kubescape/v4/core/pkg/resultshandling/printer/v2"
+	"github.com/kubescape/kubescape/v4/core/pkg/watch"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	v2 "github.com/kubescape/opa-utils/reporthandling/v2"
+)
+
+// WatchSink stores the results of the resources whose results changed in a
+// watch rescan as WorkloadConfigurationScan objects and their summaries.
+// The stored scans of resources deleted from the cluster are not removed.
+type WatchSink struct {
+	store *APIServerStore
+}
+
+var _ watch.Sink = (*WatchSink)(nil)
+
+// NewWatchSink returns a sink storing into store.
+func NewWatchSink(store *APIServerStore) *WatchSink {
+	return &WatchSink{store: store}
+}
+
+func (s *WatchSink) Emit(ctx context.Context, deltas []watch.Delta, sessionObj *cautils.OPASessionObj) error {
+	report := changedResults(printerv2.FinalizeResults(sessionObj), deltas)
+	if len(report.Results) == 0 {
+		return nil
+	}
+	return s.store.StorePostureReportResults(ctx, report)
+}
+
+// changedResults returns report narrowed to the results of the resources the
+// deltas changed and that still exist.
+func changedResults(report *v2.PostureReport, deltas []watch.Delta) *v2.PostureReport {
+	changed := make(map[string]struct{}, len(deltas))
+	for _, delta := range deltas {
+		if delta.Change != watch.ChangeDeleted {
+			changed[delta.ResourceID] = struct{}{}
+		}
+	}
+
+	narrowed := *report
+	narrowed.Results = make([]resourcesresults.Result, 0, len(changed))
+	for _, result := range report.Results {
+		if _, ok := changed[result.ResourceID]; ok {
+			narrowed.Results = append(narrowed.Results, result)
+		}
+	}
+	return &narrowed
+}
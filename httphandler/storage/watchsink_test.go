@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/kubescape/kubescape/v4/core/pkg/watch"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	v2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedResults(t *testing.T) {
+	report := &v2.PostureReport{
+		ClusterName: "cluster",
+		Results: []resourcesresults.Result{
+			{ResourceID: "failed"},
+			{ResourceID: "resolved"},
+			{ResourceID: "unchanged"},
+		},
+	}
+
+	narrowed := changedResults(report, []watch.Delta{
+		{Change: watch.ChangeFailed, ResourceID: "failed"},
+		{Change: watch.ChangeResolved, ResourceID: "resolved"},
+		{Change: watch.ChangeDeleted, ResourceID: "deleted"},
+	})
+
+	assert.Equal(t, "cluster", narrowed.ClusterName)
+	assert.Equal(t, []resourcesresults.Result{{ResourceID: "failed"}, {ResourceID: "resolved"}}, narrowed.Results)
+	assert.Len(t, report.Results, 3, "the report is not modified")
+}
@@ -0,0 +1,83 @@
+package imagescan
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/anchore/syft/syft/sbom"
+	"github.com/anchore/syft/syft/source"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/kubescape/kubescape/v4/core/pkg/imagecache"
+)
+
+// EnableResultCache makes the service reuse scan results stored under
+// cacheDir for images whose manifest digest was already scanned with the same
+// vulnerability DB and matcher configuration. The cache is keyed by the DB
+// build timestamp, so it cannot be enabled when the loaded DB does not report
+// one.
+func (s *Service) EnableResultCache(cacheDir string) error {
+	if s.dbStatus == nil || s.dbStatus.Built.IsZero() {
+		return fmt.Errorf("the vulnerability database does not report its build time")
+	}
+	matchers, err := json.Marshal(matcherConfig(s.useDefaultMatchers))
+	if err != nil {
+		return err
+	}
+	store, err := imagecache.Open(cacheDir, imagecache.Generation(
+		[]byte(s.dbStatus.SchemaVersion),
+		[]byte(s.dbStatus.Built.UTC().Format(time.RFC3339Nano)),
+		matchers,
+		[]byte(grypeVersion()),
+	))
+	if err != nil {
+		return err
+	}
+	s.resultCache = store
+	return nil
+}
+
+// grypeVersion returns the version of the grype module linked into the
+// binary, since a grype upgrade can change matching results for the same DB.
+func grypeVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/anchore/grype" {
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// referenceDigest returns the manifest digest userInput pins, which names the
+// image content before any source is asked for it.
+func referenceDigest(userInput string) (string, bool) {
+	ref, err := name.ParseReference(userInput)
+	if err != nil {
+		return "", false
+	}
+	digest, ok := ref.(name.Digest)
+	if !ok {
+		return "", false
+	}
+	return digest.DigestStr(), true
+}
+
+// sourceDigest returns the manifest digest of the image that supplied sb,
+// whichever source it came from. Sources that report no digest, such as
+// directories and archives, are not cached.
+func sourceDigest(sb *sbom.SBOM) (string, bool) {
+	if sb == nil {
+		return "", false
+	}
+	metadata, ok := sb.Source.Metadata.(source.ImageMetadata)
+	if !ok || !strings.HasPrefix(metadata.ManifestDigest, "sha256:") {
+		return "", false
+	}
+	return metadata.ManifestDigest, true
+}
@@ -0,0 +1,85 @@
+package imagescan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	grypepkg "github.com/anchore/grype/grype/pkg"
+	"github.com/anchore/grype/grype/version"
+	"github.com/anchore/grype/grype/vulnerability"
+	"github.com/anchore/grype/grype/vulnerability/mock"
+	syftPkg "github.com/anchore/syft/syft/pkg"
+	"github.com/anchore/syft/syft/sbom"
+	"github.com/anchore/syft/syft/source"
+	"github.com/kubescape/kubescape/v4/core/pkg/imagecache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+func TestEnableResultCache(t *testing.T) {
+	svc := &Service{}
+	assert.Error(t, svc.EnableResultCache(t.TempDir()))
+	assert.Nil(t, svc.resultCache)
+
+	svc.dbStatus = &vulnerability.ProviderStatus{SchemaVersion: "v6.0.2", Built: time.Now()}
+	require.NoError(t, svc.EnableResultCache(t.TempDir()))
+	assert.NotNil(t, svc.resultCache)
+}
+
+func TestReferenceDigest(t *testing.T) {
+	got, ok := referenceDigest("nginx@" + testDigest)
+	require.True(t, ok)
+	assert.Equal(t, testDigest, got)
+
+	_, ok = referenceDigest("nginx:1.25")
+	assert.False(t, ok)
+	_, ok = referenceDigest("dir:/tmp/rootfs")
+	assert.False(t, ok)
+}
+
+func TestSourceDigest(t *testing.T) {
+	got, ok := sourceDigest(&sbom.SBOM{Source: source.Description{Metadata: source.ImageMetadata{ManifestDigest: testDigest}}})
+	require.True(t, ok)
+	assert.Equal(t, testDigest, got)
+
+	_, ok = sourceDigest(&sbom.SBOM{Source: source.Description{Metadata: source.ImageMetadata{}}})
+	assert.False(t, ok)
+	_, ok = sourceDigest(&sbom.SBOM{Source: source.Description{Metadata: source.DirectoryMetadata{Path: "/tmp/rootfs"}}})
+	assert.False(t, ok)
+	_, ok = sourceDigest(nil)
+	assert.False(t, ok)
+}
+
+// TestProvideAndMatchCacheHit seeds the cache for a digest reference whose
+// registry does not exist: getting the result back proves the image was
+// neither pulled nor catalogued.
+func TestProvideAndMatchCacheHit(t *testing.T) {
+	image := "registry.invalid/app@" + testDigest
+
+	vp := mock.VulnerabilityProvider(vulnerability.Vulnerability{
+		Reference:   vulnerability.Reference{ID: "GHSA-1111", Namespace: "github:language:javascript"},
+		PackageName: "lodash",
+		Constraint:  version.MustGetConstraint("< 4.17.21", version.SemanticFormat),
+	})
+	svc := &Service{vp: vp, useDefaultMatchers: true, dbStatus: &vulnerability.ProviderStatus{Built: time.Now()}}
+	require.NoError(t, svc.EnableResultCache(t.TempDir()))
+
+	p := syftPkg.Package{Name: "lodash", Version: "4.17.0", Type: syftPkg.NpmPkg, Language: syftPkg.JavaScript, PURL: "pkg:npm/lodash@4.17.0"}
+	p.SetID()
+	sb := &sbom.SBOM{Artifacts: sbom.Artifacts{Packages: syftPkg.NewCollection(p)}}
+	packages := grypepkg.FromCollection(sb.Artifacts.Packages, grypepkg.SynthesisConfig{GenerateMissingCPEs: true})
+	remaining, ignored, err := getIgnoredMatches(nil, vp, packages, grypepkg.Context{}, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, remaining.Count())
+	require.NoError(t, svc.resultCache.Put(imagecache.Key(testDigest, "", nil), sb, *remaining, ignored))
+
+	gotPackages, _, gotSBOM, gotMatches, _, err := svc.provideAndMatch(context.Background(), image, RegistryCredentials{}, nil, ScanOptions{})
+	require.NoError(t, err)
+	require.Len(t, gotPackages, 1)
+	assert.Equal(t, "lodash", gotPackages[0].Name)
+	assert.NotNil(t, gotSBOM)
+	assert.Equal(t, 1, gotMatches.Count())
+}
@@ -26,9 +26,11 @@ import (
 	"github.com/anchore/grype/grype/vulnerability"
 	"github.com/anchore/stereoscope/pkg/image"
 	"github.com/anchore/syft/syft"
+	"github.com/anchore/syft/syft/sbom"
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v4/core/cautils"
+	"github.com/kubescape/kubescape/v4/core/pkg/imagecache"
 )
 
 const (
@@ -82,23 +84,27 @@ func NewDefaultDBConfig(grypeURL string, skipDBUpdate bool) (distribution.Config
 }
 
 func getMatchers(useDefaultMatchers bool) []match.Matcher {
+	return matcher.NewDefaultMatchers(matcherConfig(useDefaultMatchers))
+}
+
+// matcherConfig returns the grype matcher configuration. The non-default one
+// enables CPE matching for every ecosystem.
+func matcherConfig(useDefaultMatchers bool) matcher.Config {
 	if useDefaultMatchers {
-		return matcher.NewDefaultMatchers(defaultMatcherConfig())
-	}
-	return matcher.NewDefaultMatchers(
-		matcher.Config{
-			Java: java.MatcherConfig{
-				ExternalSearchConfig: java.ExternalSearchConfig{MavenBaseURL: "https://search.maven.org/solrsearch/select"},
-				UseCPEs:              true,
-			},
-			Ruby:       ruby.MatcherConfig{UseCPEs: true},
-			Python:     python.MatcherConfig{UseCPEs: true},
-			Dotnet:     dotnet.MatcherConfig{UseCPEs: true},
-			Javascript: javascript.MatcherConfig{UseCPEs: true},
-			Golang:     golang.MatcherConfig{UseCPEs: true},
-			Stock:      stock.MatcherConfig{UseCPEs: true},
+		return defaultMatcherConfig()
+	}
+	return matcher.Config{
+		Java: java.MatcherConfig{
+			ExternalSearchConfig: java.ExternalSearchConfig{MavenBaseURL: "https://search.maven.org/solrsearch/select"},
+			UseCPEs:              true,
 		},
-	)
+		Ruby:       ruby.MatcherConfig{UseCPEs: true},
+		Python:     python.MatcherConfig{UseCPEs: true},
+		Dotnet:     dotnet.MatcherConfig{UseCPEs: true},
+		Javascript: javascript.MatcherConfig{UseCPEs: true},
+		Golang:     golang.MatcherConfig{UseCPEs: true},
+		Stock:      stock.MatcherConfig{UseCPEs: true},
+	}
 }
 
 func defaultMatcherConfig() matcher.Config {
@@ -173,6 +179,9 @@ type Service struct {
 	// dbStatus carries the loaded vulnerability DB status so scan results can
 	// surface DB freshness (ProviderStatus.Built). Nil when the DB failed to load.
 	dbStatus *vulnerability.ProviderStatus
+	// resultCache reuses results for image digests already scanned with the
+	// same DB and matchers. Nil unless EnableResultCache was called.
+	resultCache *imagecache.Store
 }
 
 func getIgnoredMatches(vulnerabilityExceptions []string, vp vulnerability.Provider, packages []pkg.Package, pkgContext pkg.Context, useDefaultMatchers bool) (*match.Matches, []match.IgnoredMatch, error) {
@@ -251,19 +260,20 @@ func (s *Service) ScanWithOptions(ctx context.Context, userInput string, creds R
 	}
 	options.Platform = platform
 
-	packages, pkgContext, sbom, err := pkg.Provide(userInput, getProviderConfig(creds, s.sources, options))
+	packages, pkgContext, sbom, remainingMatches, ignoredMatches, err := s.provideAndMatch(ctx, userInput, creds, vulnerabilityExceptions, options)
 	if err != nil {
 		return nil, err
 	}
 
-	remainingMatches, ignoredMatches, err := getIgnoredMatches(vulnerabilityExceptions, s.vp, packages, pkgContext, s.useDefaultMatchers)
-	if err != nil {
-		return nil, err
-	}
+	return s.newScanData(ctx, userInput, platform, packages, pkgContext, sbom, remainingMatches, ignoredMatches, severityExceptions), nil
+}
 
+// newScanData applies the severity exceptions and VEX statuses to the matches
+// of an image and assembles its scan result.
+func (s *Service) newScanData(ctx context.Context, image, platform string, packages []pkg.Package, pkgContext pkg.Context, sb *sbom.SBOM, remainingMatches *match.Matches, ignoredMatches []match.IgnoredMatch, severityExceptions []string) *cautils.ImageScanData {
 	filteredMatches := filterMatchesBasedOnSeverity(severityExceptions, *remainingMatches, s.vp)
 
-	vexStatuses, err := s.vexClient.GetVexStatuses(ctx, userInput)
+	vexStatuses, err := s.vexClient.GetVexStatuses(ctx, image)
 	if err != nil {
 		// Log error but continue scanning
 		logger.L().Warning("Failed to fetch VEX statuses", helpers.Error(err))
@@ -272,18 +282,101 @@ func (s *Service) ScanWithOptions(ctx context.Context, userInput string, creds R
 	pb := cautils.ImageScanData{
 		Context:               pkgContext,
 		IgnoredMatches:        ignoredMatches,
-		Image:                 userInput,
+		Image:                 image,
 		Platform:              platform,
 		Matches:               filteredMatches,
 		Packages:              packages,
-		SBOM:                  sbom,
+		SBOM:                  sb,
 		VulnerabilityProvider: s.vp,
 		VexStatuses:           vexStatuses,
 	}
 
 	applyDBFreshness(&pb, s.dbStatus)
 
-	return &pb, nil
+	return &pb
+}
+
+// provideAndMatch catalogs the image and matches its packages against the DB.
+// With the result cache enabled, an image whose digest was already scanned is
+// served from the cache instead. A digest reference is looked up before the
+// image is pulled; for other references the digest is only known once the
+// configured sources have supplied the image, so a hit there spares matching.
+// A cached SBOM whose matches no longer resolve against the DB is matched
+// again. Severity exceptions are applied by the caller, so they are not part
+// of the cache key.
+func (s *Service) provideAndMatch(ctx context.Context, userInput string, creds RegistryCredentials, vulnerabilityExceptions []string, options ScanOptions) ([]pkg.Package, pkg.Context, *sbom.SBOM, *match.Matches, []match.IgnoredMatch, error) {
+	providerConfig := getProviderConfig(creds, s.sources, options)
+
+	var cacheKey string
+	if s.resultCache != nil {
+		if digest, ok := referenceDigest(userInput); ok {
+			cacheKey = imagecache.Key(digest, options.Platform, vulnerabilityExceptions)
+			cached, ok, err := s.cachedResult(cacheKey, userInput, vulnerabilityExceptions, providerConfig)
+			if err != nil {
+				return nil, pkg.Context{}, nil, nil, nil, err
+			}
+			if ok {
+				return cached.Packages, cached.Context, cached.SBOM, &cached.Matches, cached.IgnoredMatches, nil
+			}
+		}
+	}
+
+	packages, pkgContext, sb, err := pkg.Provide(userInput, providerConfig)
+	if err != nil {
+		return nil, pkg.Context{}, nil, nil, nil, err
+	}
+
+	if s.resultCache != nil && cacheKey == "" {
+		if digest, ok := sourceDigest(sb); ok {
+			cacheKey = imagecache.Key(digest, options.Platform, vulnerabilityExceptions)
+			cached, ok, err := s.cachedResult(cacheKey, userInput, vulnerabilityExceptions, providerConfig)
+			if err != nil {
+				return nil, pkg.Context{}, nil, nil, nil, err
+			}
+			if ok {
+				return cached.Packages, cached.Context, cached.SBOM, &cached.Matches, cached.IgnoredMatches, nil
+			}
+		}
+	}
+
+	remainingMatches, ignoredMatches, err := getIgnoredMatches(vulnerabilityExceptions, s.vp, packages, pkgContext, s.useDefaultMatchers)
+	if err != nil {
+		return nil, pkg.Context{}, nil, nil, nil, err
+	}
+
+	if cacheKey != "" {
+		s.storeResult(cacheKey, userInput, sb, remainingMatches, ignoredMatches)
+	}
+	return packages, pkgContext, sb, remainingMatches, ignoredMatches, nil
+}
+
+// cachedResult returns the cached result for key, re-matching a cached SBOM
+// whose matches no longer resolve against the DB.
+func (s *Service) cachedResult(key, userInput string, vulnerabilityExceptions []string, providerConfig pkg.ProviderConfig) (imagecache.Result, bool, error) {
+	cached, ok := s.resultCache.Get(key, s.vp, providerConfig)
+	if !ok {
+		return imagecache.Result{}, false, nil
+	}
+	if cached.Matched {
+		logger.L().Debug("image scan cache hit", helpers.String("image", userInput))
+		return *cached, true, nil
+	}
+	logger.L().Debug("image scan cache hit, re-matching the cached SBOM", helpers.String("image", userInput))
+	remainingMatches, ignoredMatches, err := getIgnoredMatches(vulnerabilityExceptions, s.vp, cached.Packages, cached.Context, s.useDefaultMatchers)
+	if err != nil {
+		return imagecache.Result{}, false, err
+	}
+	s.storeResult(key, userInput, cached.SBOM, remainingMatches, ignoredMatches)
+	cached.Matches, cached.IgnoredMatches = *remainingMatches, ignoredMatches
+	return *cached, true, nil
+}
+
+// storeResult writes a scan result to the cache. A failure only costs the next
+// scan a cache miss, so it is logged rather than returned.
+func (s *Service) storeResult(key, userInput string, sb *sbom.SBOM, remainingMatches *match.Matches, ignoredMatches []match.IgnoredMatch) {
+	if err := s.resultCache.Put(key, sb, *remainingMatches, ignoredMatches); err != nil {
+		logger.L().Warning("Failed to cache image scan result", helpers.String("image", userInput), helpers.Error(err))
+	}
 }
 
 // applyDBFreshness sets VulnDBBuilt on the scan result from the loaded DB
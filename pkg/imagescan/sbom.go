@@ -0,0 +1,355 @@
+package imagescan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/anchore/grype/grype/pkg"
+	"github.com/anchore/syft/syft/format"
+	"github.com/anchore/syft/syft/source"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v4/core/cautils"
+)
+
+// in-toto predicate types of the SBOM attestations cosign attaches with
+// "cosign attest --type cyclonedx|spdxjson".
+var sbomPredicateTypes = map[string]bool{
+	"https://cyclonedx.org/bom":      true,
+	"https://cyclonedx.org/schema":   true,
+	"https://spdx.dev/Document":      true,
+	"https://spdx.dev/Document/v2.3": true,
+}
+
+// SBOMSubject describes the image an SBOM document was generated for, as far as
+// the document (and the attestation wrapping it, if any) records it.
+type SBOMSubject struct {
+	Path string
+	// Names are the normalized image references (tags and digests) the document
+	// names.
+	Names []string
+	// Digests are the manifest, index and repository digests the document
+	// records for the image.
+	Digests []string
+	// Platform is os/architecture[/variant], empty when the document does not
+	// record it.
+	Platform string
+}
+
+// Image returns a reference for the image the SBOM describes, for reports and
+// exception targeting. It is empty when the document names no image.
+func (s SBOMSubject) Image() string {
+	for _, n := range s.Names {
+		if ref, err := name.ParseReference(n); err == nil {
+			if _, ok := ref.(name.Tag); ok {
+				return n
+			}
+		}
+	}
+	if len(s.Names) > 0 {
+		return s.Names[0]
+	}
+	return ""
+}
+
+type inTotoStatement struct {
+	Type          string `json:"_type"`
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate json.RawMessage `json:"predicate"`
+}
+
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+// readSBOMDocument returns the SBOM document stored at path. A cosign SBOM
+// attestation (a DSSE envelope or in-toto statement, one per line as written by
+// "cosign download attestation") is unwrapped to its predicate, and its
+// subject is returned alongside.
+func readSBOMDocument(path string) ([]byte, *inTotoStatement, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+	sawStatement := false
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			break
+		}
+		statement, ok := parseInTotoStatement(line)
+		if !ok {
+			break
+		}
+		sawStatement = true
+		if sbomPredicateTypes[statement.PredicateType] && len(statement.Predicate) > 0 {
+			return statement.Predicate, statement, nil
+		}
+	}
+	if sawStatement {
+		return nil, nil, fmt.Errorf("%s holds attestations but none of them is a CycloneDX or SPDX SBOM", path)
+	}
+	return raw, nil, nil
+}
+
+func parseInTotoStatement(line []byte) (*inTotoStatement, bool) {
+	var envelope dsseEnvelope
+	if err := json.Unmarshal(line, &envelope); err == nil && envelope.PayloadType != "" && envelope.Payload != "" {
+		payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+		if err != nil {
+			return nil, false
+		}
+		line = payload
+	}
+	var statement inTotoStatement
+	if err := json.Unmarshal(line, &statement); err != nil || !strings.HasPrefix(statement.Type, "https://in-toto.io/Statement/") {
+		return nil, false
+	}
+	return &statement, true
+}
+
+// ReadSBOMSubject reads the SBOM at path (syft JSON, CycloneDX or SPDX, or a
+// cosign attestation of one of them) and returns the image it describes.
+func ReadSBOMSubject(path string) (SBOMSubject, error) {
+	subject, _, _, err := readSBOMSubject(path)
+	return subject, err
+}
+
+// readSBOMSubject is ReadSBOMSubject that also returns the unwrapped document
+// and the attestation statement it came from, if any.
+func readSBOMSubject(path string) (SBOMSubject, []byte, *inTotoStatement, error) {
+	doc, statement, err := readSBOMDocument(path)
+	if err != nil {
+		return SBOMSubject{}, nil, nil, err
+	}
+	s, _, _, err := format.Decode(bytes.NewReader(doc))
+	if err != nil {
+		return SBOMSubject{}, nil, nil, fmt.Errorf("unable to decode SBOM %s: %w", path, err)
+	}
+	if s == nil {
+		return SBOMSubject{}, nil, nil, fmt.Errorf("%s is not a supported SBOM format", path)
+	}
+
+	subject := SBOMSubject{Path: path}
+	addName := func(ref string) {
+		// A bare repository would parse as its "latest" tag, which the SBOM
+		// does not claim to describe.
+		if !hasTagOrDigest(ref) {
+			return
+		}
+		parsed, err := name.ParseReference(ref)
+		if err != nil {
+			return
+		}
+		subject.Names = appendUnique(subject.Names, parsed.Name())
+		if digest, ok := parsed.(name.Digest); ok {
+			subject.Digests = appendUnique(subject.Digests, digest.DigestStr())
+		}
+	}
+	addDigest := func(digest string) {
+		if strings.HasPrefix(digest, "sha256:") {
+			subject.Digests = appendUnique(subject.Digests, digest)
+		}
+	}
+
+	if s.Source.Name != "" && s.Source.Version != "" {
+		if strings.HasPrefix(s.Source.Version, "sha256:") {
+			addName(s.Source.Name + "@" + s.Source.Version)
+		} else {
+			addName(s.Source.Name + ":" + s.Source.Version)
+		}
+	}
+	if metadata, ok := s.Source.Metadata.(source.ImageMetadata); ok {
+		addName(metadata.UserInput)
+		if !hasTagOrDigest(metadata.UserInput) && strings.HasPrefix(metadata.ManifestDigest, "sha256:") {
+			addName(metadata.UserInput + "@" + metadata.ManifestDigest)
+		}
+		for _, tag := range metadata.Tags {
+			addName(tag)
+		}
+		for _, repoDigest := range metadata.RepoDigests {
+			addName(repoDigest)
+		}
+		addDigest(metadata.ManifestDigest)
+		if metadata.OS != "" && metadata.Architecture != "" {
+			subject.Platform = metadata.OS + "/" + metadata.Architecture
+			if metadata.Variant != "" {
+				subject.Platform += "/" + metadata.Variant
+			}
+		}
+	}
+	if statement != nil {
+		for _, st := range statement.Subject {
+			addName(st.Name)
+			if d := st.Digest["sha256"]; d != "" {
+				addDigest("sha256:" + d)
+			}
+		}
+	}
+	return subject, doc, statement, nil
+}
+
+// hasTagOrDigest reports whether an image reference names a tag or a digest
+// rather than only a repository.
+func hasTagOrDigest(ref string) bool {
+	return strings.Contains(ref, "@") || strings.LastIndex(ref, ":") > strings.LastIndex(ref, "/")
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// ScanSBOM matches a pre-generated SBOM against the vulnerability DB instead of
+// pulling and cataloguing the image. The SBOM may be syft JSON, CycloneDX or
+// SPDX, or a cosign attestation of one of them. image is the image the SBOM
+// describes, used in reports and VEX lookups; when empty, the name recorded in
+// the SBOM is used.
+func (s *Service) ScanSBOM(ctx context.Context, sbomPath, image string, vulnerabilityExceptions, severityExceptions []string) (*cautils.ImageScanData, error) {
+	subject, doc, statement, err := readSBOMSubject(sbomPath)
+	if err != nil {
+		return nil, err
+	}
+	if image == "" {
+		image = subject.Image()
+	}
+	if image == "" {
+		image = sbomPath
+	}
+
+	input := sbomPath
+	if statement != nil {
+		f, err := os.CreateTemp("", "kubescape-sbom-*.json")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.Write(doc); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		input = f.Name()
+	}
+
+	config := getProviderConfig(RegistryCredentials{}, nil, ScanOptions{})
+	packages, pkgContext, sb, err := pkg.Provide("sbom:"+input, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read SBOM %s: %w", sbomPath, err)
+	}
+
+	remainingMatches, ignoredMatches, err := getIgnoredMatches(vulnerabilityExceptions, s.vp, packages, pkgContext, s.useDefaultMatchers)
+	if err != nil {
+		return nil, err
+	}
+	return s.newScanData(ctx, image, subject.Platform, packages, pkgContext, sb, remainingMatches, ignoredMatches, severityExceptions), nil
+}
+
+// SBOMIndex finds pre-generated SBOMs for images by name or digest.
+type SBOMIndex struct {
+	byName   map[string][]SBOMSubject
+	byDigest map[string][]SBOMSubject
+}
+
+// LoadSBOMIndex reads every SBOM under dir. Files that are not SBOMs are
+// skipped with a warning, so the directory can hold other build artifacts.
+func LoadSBOMIndex(dir string) (*SBOMIndex, error) {
+	index := &SBOMIndex{byName: map[string][]SBOMSubject{}, byDigest: map[string][]SBOMSubject{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		subject, err := ReadSBOMSubject(path)
+		if err != nil {
+			logger.L().Warning("Skipping file in SBOM directory", helpers.String("path", path), helpers.Error(err))
+			return nil
+		}
+		if len(subject.Names) == 0 && len(subject.Digests) == 0 {
+			logger.L().Warning("Skipping SBOM that does not name the image it describes", helpers.String("path", path))
+			return nil
+		}
+		for _, n := range subject.Names {
+			index.byName[n] = append(index.byName[n], subject)
+		}
+		for _, d := range subject.Digests {
+			index.byDigest[d] = append(index.byDigest[d], subject)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// Len returns the number of image names and digests the index can resolve.
+func (idx *SBOMIndex) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return len(idx.byName) + len(idx.byDigest)
+}
+
+// Lookup returns the SBOM for image on platform. A digest reference only
+// matches an SBOM recording that digest, and a tag reference an SBOM naming the
+// same repository and tag. SBOMs that record a different platform never match;
+// with no platform requested, one for the host architecture is preferred.
+func (idx *SBOMIndex) Lookup(image, platform string) (string, bool) {
+	if idx == nil {
+		return "", false
+	}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", false
+	}
+	var candidates []SBOMSubject
+	if digest, ok := ref.(name.Digest); ok {
+		candidates = idx.byDigest[digest.DigestStr()]
+	} else {
+		candidates = idx.byName[ref.Name()]
+	}
+
+	preferred := platform
+	if preferred == "" {
+		preferred = "linux/" + runtime.GOARCH
+	}
+	fallback := ""
+	for _, c := range candidates {
+		switch {
+		case c.Platform == preferred:
+			return c.Path, true
+		case c.Platform == "" || platform == "":
+			if fallback == "" {
+				fallback = c.Path
+			}
+		}
+	}
+	return fallback, fallback != ""
+}
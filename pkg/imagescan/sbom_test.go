@@ -0,0 +1,179 @@
+package imagescan
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anchore/grype/grype/version"
+	"github.com/anchore/grype/grype/vulnerability"
+	"github.com/anchore/grype/grype/vulnerability/mock"
+	"github.com/anchore/syft/syft/format/syftjson"
+	syftPkg "github.com/anchore/syft/syft/pkg"
+	"github.com/anchore/syft/syft/sbom"
+	"github.com/anchore/syft/syft/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDigest      = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testIndexDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func cycloneDXDocument(name, version string) []byte {
+	doc := map[string]any{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.5",
+		"version":     1,
+		"metadata": map[string]any{
+			"component": map[string]any{"type": "container", "name": name, "version": version, "bom-ref": "image"},
+		},
+		"components": []any{
+			map[string]any{"type": "library", "name": "lodash", "version": "4.17.0", "purl": "pkg:npm/lodash@4.17.0", "bom-ref": "lodash"},
+		},
+	}
+	b, _ := json.Marshal(doc)
+	return b
+}
+
+func syftDocument(t *testing.T, arch string) []byte {
+	t.Helper()
+	p := syftPkg.Package{Name: "lodash", Version: "4.17.0", Type: syftPkg.NpmPkg, Language: syftPkg.JavaScript, PURL: "pkg:npm/lodash@4.17.0"}
+	p.SetID()
+	s := sbom.SBOM{
+		Artifacts: sbom.Artifacts{Packages: syftPkg.NewCollection(p)},
+		Source: source.Description{
+			ID:      "image",
+			Name:    "registry.example.com/app",
+			Version: "1.2.3",
+			Metadata: source.ImageMetadata{
+				UserInput:      "registry.example.com/app:1.2.3",
+				ManifestDigest: testDigest,
+				RepoDigests:    []string{"registry.example.com/app@" + testIndexDigest},
+				OS:             "linux",
+				Architecture:   arch,
+			},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, syftjson.NewFormatEncoder().Encode(&buf, s))
+	return buf.Bytes()
+}
+
+func attestation(t *testing.T, predicateType string, predicate []byte, subjectName, subjectDigest string) []byte {
+	t.Helper()
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": predicateType,
+		"subject":       []any{map[string]any{"name": subjectName, "digest": map[string]string{"sha256": subjectDigest}}},
+		"predicate":     json.RawMessage(predicate),
+	})
+	require.NoError(t, err)
+	envelope, err := json.Marshal(map[string]string{
+		"payloadType": "application/vnd.in-toto+json",
+		"payload":     base64.StdEncoding.EncodeToString(statement),
+	})
+	require.NoError(t, err)
+	return envelope
+}
+
+func writeFile(t *testing.T, dir, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func TestReadSBOMSubject(t *testing.T) {
+	dir := t.TempDir()
+
+	subject, err := ReadSBOMSubject(writeFile(t, dir, "app.syft.json", syftDocument(t, "arm64")))
+	require.NoError(t, err)
+	assert.Contains(t, subject.Names, "registry.example.com/app:1.2.3")
+	assert.ElementsMatch(t, []string{testDigest, testIndexDigest}, subject.Digests)
+	assert.Equal(t, "linux/arm64", subject.Platform)
+	assert.Equal(t, "registry.example.com/app:1.2.3", subject.Image())
+
+	subject, err = ReadSBOMSubject(writeFile(t, dir, "app.cdx.json", cycloneDXDocument("registry.example.com/app", testDigest)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry.example.com/app@" + testDigest}, subject.Names)
+	assert.Equal(t, []string{testDigest}, subject.Digests)
+	assert.Empty(t, subject.Platform)
+
+	// A bare repository must not be read as its "latest" tag.
+	subject, err = ReadSBOMSubject(writeFile(t, dir, "bare.cdx.json", cycloneDXDocument("registry.example.com/app", "")))
+	require.NoError(t, err)
+	assert.Empty(t, subject.Names)
+
+	provenance := attestation(t, "https://slsa.dev/provenance/v0.2", []byte(`{}`), "registry.example.com/app", "33")
+	sbomAttestation := attestation(t, "https://cyclonedx.org/bom", cycloneDXDocument("registry.example.com/app", ""), "registry.example.com/app", testDigest[len("sha256:"):])
+	subject, err = ReadSBOMSubject(writeFile(t, dir, "app.att.json", append(append(provenance, '\n'), sbomAttestation...)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{testDigest}, subject.Digests)
+
+	_, err = ReadSBOMSubject(writeFile(t, dir, "provenance.att.json", provenance))
+	assert.ErrorContains(t, err, "none of them is a CycloneDX or SPDX SBOM")
+	_, err = ReadSBOMSubject(writeFile(t, dir, "notes.txt", []byte("not an sbom")))
+	assert.Error(t, err)
+}
+
+func TestSBOMIndexLookup(t *testing.T) {
+	dir := t.TempDir()
+	amd64 := writeFile(t, dir, "app-amd64.json", syftDocument(t, "amd64"))
+	arm64 := writeFile(t, dir, "app-arm64.json", syftDocument(t, "arm64"))
+	writeFile(t, dir, "README.md", []byte("# SBOMs"))
+
+	index, err := LoadSBOMIndex(dir)
+	require.NoError(t, err)
+
+	path, ok := index.Lookup("registry.example.com/app:1.2.3", "linux/arm64")
+	require.True(t, ok)
+	assert.Equal(t, arm64, path)
+	path, ok = index.Lookup("registry.example.com/app@"+testIndexDigest, "linux/amd64")
+	require.True(t, ok)
+	assert.Equal(t, amd64, path)
+
+	_, ok = index.Lookup("registry.example.com/app:1.2.3", "linux/s390x")
+	assert.False(t, ok)
+	_, ok = index.Lookup("registry.example.com/app:1.2.4", "")
+	assert.False(t, ok)
+	_, ok = index.Lookup("registry.example.com/other@"+testDigest+"0", "")
+	assert.False(t, ok)
+
+	var nilIndex *SBOMIndex
+	_, ok = nilIndex.Lookup("registry.example.com/app:1.2.3", "")
+	assert.False(t, ok)
+}
+
+func TestScanSBOM(t *testing.T) {
+	vp := mock.VulnerabilityProvider(vulnerability.Vulnerability{
+		Reference:   vulnerability.Reference{ID: "GHSA-1111", Namespace: "github:language:javascript"},
+		PackageName: "lodash",
+		Constraint:  version.MustGetConstraint("< 4.17.21", version.SemanticFormat),
+	})
+	built := time.Now()
+	svc := &Service{vp: vp, useDefaultMatchers: true, vexClient: NewVexClient(), dbStatus: &vulnerability.ProviderStatus{Built: built}}
+	dir := t.TempDir()
+
+	data, err := svc.ScanSBOM(context.Background(), writeFile(t, dir, "app.json", syftDocument(t, "amd64")), "", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/app:1.2.3", data.Image)
+	assert.Equal(t, "linux/amd64", data.Platform)
+	require.Len(t, data.Packages, 1)
+	assert.Equal(t, 1, data.Matches.Count())
+	assert.NotNil(t, data.SBOM)
+	require.NotNil(t, data.VulnDBBuilt)
+
+	att := attestation(t, "https://cyclonedx.org/bom", cycloneDXDocument("registry.example.com/app", testDigest), "registry.example.com/app", testDigest[len("sha256:"):])
+	data, err = svc.ScanSBOM(context.Background(), writeFile(t, dir, "app.att.json", att), "registry.example.com/app:1.2.3", []string{"GHSA-1111"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com/app:1.2.3", data.Image)
+	assert.Equal(t, 0, data.Matches.Count())
+	assert.Len(t, data.IgnoredMatches, 1)
+}